@@ -14,7 +14,7 @@ import (
 func main() {
 	var (
 		version = flag.Bool("version", false, "Show version information")
-		down    = flag.Bool("down", false, "Run down migrations (not implemented)")
+		down    = flag.Bool("down", false, "Drop every table instead of migrating")
 	)
 	flag.Parse()
 
@@ -41,14 +41,7 @@ func main() {
 
 	if *down {
 		fmt.Println("Dropping all tables...")
-		if err := db.Migrator().DropTable(
-			"users", "corporates", "corporate_members", "user_profiles", "otps",
-			"license_requests", "new_license_requests", "renewal_license_requests",
-			"extension_license_requests", "reduction_license_requests", "inspections",
-			"audit_reports", "attachments", "notifications", "service_flow_logs",
-			"admin_users", "task_assignments", "audit_report_versions", "workflow_comments",
-			"deadline_reminders", "role_dashboards", "workflow_metrics",
-		); err != nil {
+		if err := migrations.Drop(db); err != nil {
 			log.Fatal("Failed to drop tables:", err)
 		}
 		fmt.Println("Tables dropped successfully!")
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"eservice-backend/config"
+	"eservice-backend/database"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	workflowservice "eservice-backend/service/workflow/service"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: workflow <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  export    Render a workflow definition as json, dot or mermaid")
+	fmt.Fprintln(os.Stderr, "  verify    Replay request flow logs and report, or repair, inconsistencies")
+	fmt.Fprintln(os.Stderr, "  as-of     Reconstruct a request as it stood at a point in time")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "verify":
+		runVerify(os.Args[2:])
+	case "as-of":
+		runAsOf(os.Args[2:])
+	case "-h", "--help", "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	var (
+		format      = flags.String("format", workflowservice.WorkflowExportDOT, "Output format: json, dot or mermaid")
+		licenseType = flags.String("license-type", "", "License type of the definition (default: built-in workflow)")
+		version     = flags.Int("version", -1, "Definition version (default: active version of the license type)")
+		requestID   = flags.Uint("request", 0, "Highlight the path of this request from its flow log")
+		output      = flags.String("o", "", "Write to a file instead of stdout")
+	)
+	flags.Parse(args)
+	*licenseType = string(models.ParseLicenseType(*licenseType))
+
+	var diagram *dto.WorkflowDiagramResponse
+	builtIn := (*licenseType == "" || *licenseType == models.DefaultWorkflowLicenseType) && *version <= 0 && *requestID == 0
+	if builtIn {
+		// The built-in definition is embedded, so no database is needed
+		diagram = workflowservice.BuildWorkflowDiagram(models.NewWorkflowStateMachine())
+	} else {
+		cfg := config.LoadConfig()
+		db, err := database.InitDB(cfg)
+		if err != nil {
+			log.Fatal("Failed to initialize database:", err)
+		}
+
+		exportService := workflowservice.NewWorkflowExportService(db)
+		if *requestID != 0 {
+			diagram, err = exportService.GetRequestDiagram(*requestID)
+		} else {
+			var pinned *int
+			if *version >= 0 {
+				pinned = version
+			}
+			diagram, err = exportService.GetDiagram(*licenseType, pinned)
+		}
+		if err != nil {
+			log.Fatal("Failed to export workflow diagram:", err)
+		}
+	}
+
+	rendered, err := workflowservice.RenderWorkflowDiagram(diagram, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		fmt.Print(rendered)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(rendered), 0644); err != nil {
+		log.Fatal("Failed to write output:", err)
+	}
+	fmt.Printf("Workflow diagram written to %s\n", *output)
+}
+
+func runVerify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	var (
+		licenseType = flags.String("license-type", "", "Only check requests of this license type")
+		status      = flags.String("status", "", "Only check requests in this status")
+		requestID   = flags.Uint("request", 0, "Only check this request")
+		repair      = flags.Bool("repair", false, "Repair requests whose status does not match their flow log")
+		strategy    = flags.String("strategy", dto.RepairStrategyLog, "Repair strategy: log (append the missing entry) or status (restore the logged status)")
+		verbose     = flags.Bool("v", false, "Print every issue instead of one line per request")
+	)
+	flags.Parse(args)
+
+	cfg := config.LoadConfig()
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+
+	req := dto.ConsistencyCheckRequest{
+		LicenseType: *licenseType,
+		Status:      models.RequestStatus(*status),
+		Repair:      *repair,
+		Strategy:    *strategy,
+	}
+	if *requestID != 0 {
+		req.RequestIDs = []uint{*requestID}
+	}
+
+	result, err := workflowservice.NewReplayService(db).CheckConsistency(req)
+	if err != nil {
+		log.Fatal("Failed to check consistency:", err)
+	}
+
+	for _, report := range result.Reports {
+		fmt.Printf("%s (id %d): status %s, flow log ends in %s, %d issue(s)\n",
+			report.RequestNumber, report.RequestID, report.Status, report.ReplayedStatus, len(report.Issues))
+		if *verbose {
+			for _, issue := range report.Issues {
+				fmt.Printf("  %s: %s\n", issue.Kind, issue.Message)
+			}
+		}
+		if report.Repair != nil {
+			fmt.Printf("  repaired with %s strategy: %s -> %s\n", report.Repair.Strategy, report.Repair.FromStatus, report.Repair.ToStatus)
+		}
+	}
+	fmt.Printf("Checked %d request(s): %d consistent, %d inconsistent, %d repaired\n",
+		result.Checked, result.Consistent, result.Inconsistent, result.Repaired)
+
+	if result.Inconsistent > result.Repaired {
+		os.Exit(1)
+	}
+}
+
+func runAsOf(args []string) {
+	flags := flag.NewFlagSet("as-of", flag.ExitOnError)
+	var (
+		requestID = flags.Uint("request", 0, "Request to reconstruct (required)")
+		at        = flags.String("at", "", "RFC 3339 time, or a YYYY-MM-DD date for the end of that day (required)")
+	)
+	flags.Parse(args)
+
+	if *requestID == 0 || *at == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	asOf, err := workflowservice.ParseAsOf(*at)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := config.LoadConfig()
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+
+	state, err := workflowservice.NewReplayService(db).GetRequestStateAsOf(*requestID, asOf)
+	if err != nil {
+		log.Fatal("Failed to reconstruct request:", err)
+	}
+
+	encoded, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(encoded))
+}
@@ -23,6 +23,22 @@ type Config struct {
 	EmailUser  string
 	EmailPass  string
 	UploadPath string
+
+	// Comma separated IPs or CIDRs of the reverse proxies whose X-Forwarded-For header is trusted
+	// for the client IP; empty trusts none and uses the address of the connection
+	TrustedProxies string
+
+	// License certificates
+	CertificateFontPath string // Thai TrueType font embedded in certificates; empty uses the bundled font
+	LicenseVerifyURL    string // Base URL the certificate QR code points to, followed by the license's verification token
+	LicenseSigningKey   string // Base64 Ed25519 seed or private key signing verification responses; required for license verification
+
+	// License expiry
+	LicenseExpiryReminderDays string // Comma separated days before expiry on which holders are reminded, e.g. "180,90,30"
+
+	// Fees and payments
+	PaymentProvider string // Provider license fees are paid through: "promptpay", or "fake" for development
+	PromptPayID     string // PromptPay ID receiving license fees: a mobile number, tax ID or e-wallet ID
 }
 
 func LoadConfig() *Config {
@@ -47,6 +63,17 @@ func LoadConfig() *Config {
 		EmailUser:  getEnv("EMAIL_USER", ""),
 		EmailPass:  getEnv("EMAIL_PASS", ""),
 		UploadPath: getEnv("UPLOAD_PATH", "./uploads"),
+
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+
+		CertificateFontPath: getEnv("CERTIFICATE_FONT_PATH", ""),
+		LicenseVerifyURL:    getEnv("LICENSE_VERIFY_URL", "http://localhost:8080/api/v1/verify/licenses"),
+		LicenseSigningKey:   getEnv("LICENSE_SIGNING_KEY", ""),
+
+		LicenseExpiryReminderDays: getEnv("LICENSE_EXPIRY_REMINDER_DAYS", "180,90,30"),
+
+		PaymentProvider: getEnv("PAYMENT_PROVIDER", "fake"),
+		PromptPayID:     getEnv("PROMPTPAY_ID", ""),
 	}
 }
 
@@ -0,0 +1,39 @@
+-- Migration: Create workflow_definitions table
+-- Created: 2026-10-17
+-- Description: Versioned workflow definitions per license type, and pin each request to the version it started with
+
+CREATE TABLE IF NOT EXISTS workflow_definitions (
+    id SERIAL PRIMARY KEY,
+    license_type VARCHAR(20) NOT NULL,
+    version INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    definition TEXT NOT NULL,
+    is_active BOOLEAN DEFAULT FALSE,
+    created_by_id INTEGER,
+    activated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Foreign key constraints
+    CONSTRAINT fk_workflow_definitions_created_by
+        FOREIGN KEY (created_by_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workflow_definitions_type_version ON workflow_definitions(license_type, version);
+CREATE INDEX IF NOT EXISTS idx_workflow_definitions_is_active ON workflow_definitions(is_active);
+CREATE INDEX IF NOT EXISTS idx_workflow_definitions_created_by_id ON workflow_definitions(created_by_id);
+
+-- Pin license requests to a workflow definition version (0 = built-in definition)
+ALTER TABLE new_license_requests ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE renewal_license_requests ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE extension_license_requests ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reduction_license_requests ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 0;
+
+-- Add comment to the table
+COMMENT ON TABLE workflow_definitions IS 'Versioned, immutable workflow definitions per license type';
+COMMENT ON COLUMN workflow_definitions.license_type IS 'Type of license request (new, renewal, extension, reduction)';
+COMMENT ON COLUMN workflow_definitions.version IS 'Definition version, increasing per license type';
+COMMENT ON COLUMN workflow_definitions.definition IS 'JSON definition of states, transitions, roles and deadlines';
+COMMENT ON COLUMN workflow_definitions.is_active IS 'Whether new requests of this license type start on this version';
@@ -0,0 +1,37 @@
+-- Migration: Create outbox_messages table
+-- Created: 2026-10-17
+-- Description: Transactional outbox for notifications, emails and websocket pushes produced by workflow transitions
+
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id SERIAL PRIMARY KEY,
+    channel VARCHAR(20) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    entity_type VARCHAR(50),
+    entity_id INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_channel ON outbox_messages(channel);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_status ON outbox_messages(status);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_available_at ON outbox_messages(available_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_entity_type ON outbox_messages(entity_type);
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_entity_id ON outbox_messages(entity_id);
+
+-- Link audit report versions to the license request they were written for
+ALTER TABLE audit_report_versions ADD COLUMN IF NOT EXISTS license_request_id INTEGER;
+ALTER TABLE audit_report_versions ADD COLUMN IF NOT EXISTS license_type VARCHAR(20);
+CREATE INDEX IF NOT EXISTS idx_audit_report_versions_license_request_id ON audit_report_versions(license_request_id);
+
+-- Add comment to the table
+COMMENT ON TABLE outbox_messages IS 'Side effects written with workflow changes and delivered after commit';
+COMMENT ON COLUMN outbox_messages.channel IS 'Delivery channel (notification, email, websocket)';
+COMMENT ON COLUMN outbox_messages.payload IS 'JSON encoded channel payload';
+COMMENT ON COLUMN outbox_messages.status IS 'Delivery status (pending, processing, sent, failed)';
+COMMENT ON COLUMN outbox_messages.available_at IS 'Earliest time the next delivery attempt may run';
@@ -0,0 +1,14 @@
+-- Migration: Add optimistic concurrency version to license requests
+-- Created: 2026-10-17
+-- Description: Version column used for compare-and-swap updates and ETag/If-Match on the four license request tables
+
+ALTER TABLE new_license_requests ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE renewal_license_requests ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE extension_license_requests ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE reduction_license_requests ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Add comments to the columns
+COMMENT ON COLUMN new_license_requests.version IS 'Optimistic concurrency version, incremented on every update';
+COMMENT ON COLUMN renewal_license_requests.version IS 'Optimistic concurrency version, incremented on every update';
+COMMENT ON COLUMN extension_license_requests.version IS 'Optimistic concurrency version, incremented on every update';
+COMMENT ON COLUMN reduction_license_requests.version IS 'Optimistic concurrency version, incremented on every update';
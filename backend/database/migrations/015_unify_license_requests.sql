@@ -0,0 +1,27 @@
+-- Migration: Unify license requests into a single table
+-- Created: 2026-10-17
+-- Description: license_requests becomes the workflow core for every license type; type-specific fields move to a JSON payload.
+-- Rows from the per-type tables are copied by migrateLegacyLicenseRequests on startup.
+
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS license_number VARCHAR(255);
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS contact_person VARCHAR(255);
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS contact_phone VARCHAR(50);
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS contact_email VARCHAR(255);
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS payload TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS workflow_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS legacy_table VARCHAR(64);
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS legacy_id BIGINT;
+
+-- License type values now match the workflow definition license types
+UPDATE license_requests SET license_type = 'renewal' WHERE license_type = 'renew';
+UPDATE license_requests SET license_type = 'extension' WHERE license_type = 'expand';
+UPDATE license_requests SET license_type = 'reduction' WHERE license_type = 'reduce';
+
+CREATE INDEX IF NOT EXISTS idx_license_requests_license_type ON license_requests(license_type);
+CREATE INDEX IF NOT EXISTS idx_license_requests_legacy ON license_requests(legacy_table, legacy_id);
+
+-- Add comments to the columns
+COMMENT ON COLUMN license_requests.payload IS 'JSON encoded fields specific to the license type';
+COMMENT ON COLUMN license_requests.legacy_table IS 'Per-type table a migrated request was copied from';
+COMMENT ON COLUMN license_requests.legacy_id IS 'ID of the migrated request in its per-type table';
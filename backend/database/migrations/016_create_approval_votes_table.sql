@@ -0,0 +1,26 @@
+-- Migration: Create approval_votes table
+-- Created: 2026-10-17
+-- Description: Votes of approvers on workflow steps that need several independent approvals
+
+CREATE TABLE IF NOT EXISTS approval_votes (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES license_requests(id),
+    license_type VARCHAR(20) NOT NULL,
+    round_id INTEGER NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    from_status VARCHAR(50) NOT NULL,
+    voter_id INTEGER NOT NULL REFERENCES users(id),
+    voter_role VARCHAR(50) NOT NULL,
+    decision VARCHAR(20) NOT NULL,
+    comment TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One vote per approver per round
+CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_votes_round_voter ON approval_votes(request_id, round_id, voter_id);
+
+-- Add comment to the table
+COMMENT ON TABLE approval_votes IS 'Approver decisions on steps with a quorum rule';
+COMMENT ON COLUMN approval_votes.round_id IS 'service_flow_logs entry that moved the request into the step';
+COMMENT ON COLUMN approval_votes.decision IS 'Vote (approve, reject); a reject vetoes the step';
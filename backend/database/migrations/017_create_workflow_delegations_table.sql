@@ -0,0 +1,38 @@
+-- Migration: Create workflow_delegations table
+-- Created: 2026-10-17
+-- Description: Time-boxed delegations of workflow permissions, e.g. while a DEDE Head is on leave
+
+CREATE TABLE IF NOT EXISTS workflow_delegations (
+    id SERIAL PRIMARY KEY,
+    delegator_id INTEGER NOT NULL REFERENCES users(id),
+    delegate_id INTEGER NOT NULL REFERENCES users(id),
+    role VARCHAR(50) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_by_id INTEGER NOT NULL REFERENCES users(id),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (delegator_id <> delegate_id),
+    CHECK (ends_at > starts_at)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_workflow_delegations_delegator_id ON workflow_delegations(delegator_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_delegations_delegate_id ON workflow_delegations(delegate_id);
+CREATE INDEX IF NOT EXISTS idx_workflow_delegations_ends_at ON workflow_delegations(ends_at);
+CREATE INDEX IF NOT EXISTS idx_workflow_delegations_status ON workflow_delegations(status);
+
+-- Record who acted on behalf of whom
+ALTER TABLE service_flow_logs ADD COLUMN IF NOT EXISTS on_behalf_of INTEGER REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_service_flow_logs_on_behalf_of ON service_flow_logs(on_behalf_of);
+ALTER TABLE approval_votes ADD COLUMN IF NOT EXISTS cast_by_id INTEGER REFERENCES users(id);
+
+-- Add comment to the table
+COMMENT ON TABLE workflow_delegations IS 'Delegations of a user''s workflow role to another user for a period';
+COMMENT ON COLUMN workflow_delegations.role IS 'Delegator''s role when the delegation was created';
+COMMENT ON COLUMN workflow_delegations.status IS 'Delegation status (active, revoked, expired)';
+COMMENT ON COLUMN service_flow_logs.on_behalf_of IS 'Delegator when changed_by acted under a delegation';
+COMMENT ON COLUMN approval_votes.cast_by_id IS 'Delegate who cast the vote for voter_id';
@@ -0,0 +1,29 @@
+-- Migration: Create workflow_escalations table
+-- Created: 2026-10-17
+-- Description: Escalations fired for requests that stayed in a status longer than their workflow definition allows
+
+CREATE TABLE IF NOT EXISTS workflow_escalations (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES license_requests(id),
+    license_type VARCHAR(20) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    round_id INTEGER NOT NULL,
+    rule_index INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    after_business_days INTEGER NOT NULL,
+    owner_id INTEGER REFERENCES users(id),
+    owner_role VARCHAR(50),
+    reassigned_to_id INTEGER REFERENCES users(id),
+    notified_roles VARCHAR(255),
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Each rule fires once per visit to a status
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workflow_escalations_round_rule ON workflow_escalations(request_id, round_id, rule_index);
+CREATE INDEX IF NOT EXISTS idx_workflow_escalations_created_at ON workflow_escalations(created_at);
+
+-- Add comment to the table
+COMMENT ON TABLE workflow_escalations IS 'SLA escalations fired by the escalation cron job';
+COMMENT ON COLUMN workflow_escalations.round_id IS 'service_flow_logs entry that moved the request into the status';
+COMMENT ON COLUMN workflow_escalations.action IS 'Escalation action (notify, reassign)';
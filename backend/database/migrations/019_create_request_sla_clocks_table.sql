@@ -0,0 +1,30 @@
+-- Migration: Create request_sla_clocks table
+-- Created: 2026-10-17
+-- Description: Per-request SLA clock that pauses while the applicant owns the request
+
+CREATE TABLE IF NOT EXISTS request_sla_clocks (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES license_requests(id),
+    license_type VARCHAR(20) NOT NULL,
+    state VARCHAR(20) NOT NULL,
+    dede_seconds BIGINT NOT NULL DEFAULT 0,
+    applicant_seconds BIGINT NOT NULL DEFAULT 0,
+    pause_count INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_changed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    paused_at TIMESTAMP WITH TIME ZONE,
+    stopped_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_request_sla_clocks_request_id ON request_sla_clocks(request_id);
+CREATE INDEX IF NOT EXISTS idx_request_sla_clocks_license_type ON request_sla_clocks(license_type);
+CREATE INDEX IF NOT EXISTS idx_request_sla_clocks_state ON request_sla_clocks(state);
+
+-- Add comment to the table
+COMMENT ON TABLE request_sla_clocks IS 'Time each request spent with DEDE and with the applicant';
+COMMENT ON COLUMN request_sla_clocks.state IS 'Clock state (running, paused, stopped)';
+COMMENT ON COLUMN request_sla_clocks.dede_seconds IS 'Time with DEDE booked up to last_changed_at';
+COMMENT ON COLUMN request_sla_clocks.applicant_seconds IS 'Time with the applicant booked up to last_changed_at';
@@ -0,0 +1,39 @@
+-- Migration: Create request_submissions table
+-- Created: 2026-10-17
+-- Description: Immutable snapshots of what the applicant submitted, for resubmission diffs
+
+CREATE TABLE IF NOT EXISTS request_submissions (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES license_requests(id),
+    license_type VARCHAR(20) NOT NULL,
+    sequence INTEGER NOT NULL,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    flow_log_id INTEGER REFERENCES service_flow_logs(id),
+    submitted_by_id INTEGER NOT NULL REFERENCES users(id),
+    fields JSONB NOT NULL DEFAULT '{}',
+    attachments JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_request_submissions_sequence ON request_submissions(request_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_request_submissions_submitted_by_id ON request_submissions(submitted_by_id);
+
+-- Submissions are never changed once stored
+CREATE OR REPLACE FUNCTION reject_request_submission_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'request submissions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_request_submissions_immutable ON request_submissions;
+CREATE TRIGGER trg_request_submissions_immutable
+    BEFORE UPDATE OR DELETE ON request_submissions
+    FOR EACH ROW EXECUTE FUNCTION reject_request_submission_change();
+
+-- Add comment to the table
+COMMENT ON TABLE request_submissions IS 'Immutable snapshot of each submission of a license request';
+COMMENT ON COLUMN request_submissions.sequence IS 'Submission number within the request, starting at 1';
+COMMENT ON COLUMN request_submissions.fields IS 'Applicant-editable fields, payload fields under payload.<name>';
+COMMENT ON COLUMN request_submissions.attachments IS 'Attachments of the request at submission time';
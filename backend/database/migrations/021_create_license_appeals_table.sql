@@ -0,0 +1,39 @@
+-- Migration: Create license_appeals table
+-- Created: 2026-10-17
+-- Description: Applicant appeals against rejected requests, decided by someone other than the rejecter
+
+CREATE TABLE IF NOT EXISTS license_appeals (
+    id SERIAL PRIMARY KEY,
+    request_id INTEGER NOT NULL REFERENCES license_requests(id),
+    license_type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    grounds TEXT NOT NULL,
+    rejected_from VARCHAR(50) NOT NULL,
+    rejected_by_id INTEGER REFERENCES users(id),
+    rejected_for_id INTEGER REFERENCES users(id),
+    rejection_reason TEXT,
+    rejected_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    filed_by_id INTEGER NOT NULL REFERENCES users(id),
+    reviewer_id INTEGER REFERENCES users(id),
+    decided_by_id INTEGER REFERENCES users(id),
+    on_behalf_of_id INTEGER REFERENCES users(id),
+    decision_notes TEXT,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_license_appeals_request_id ON license_appeals(request_id);
+CREATE INDEX IF NOT EXISTS idx_license_appeals_status ON license_appeals(status);
+CREATE INDEX IF NOT EXISTS idx_license_appeals_reviewer_id ON license_appeals(reviewer_id);
+
+-- A request has at most one appeal waiting for a decision
+CREATE UNIQUE INDEX IF NOT EXISTS idx_license_appeals_pending ON license_appeals(request_id) WHERE status = 'pending';
+
+-- Add comment to the table
+COMMENT ON TABLE license_appeals IS 'Appeals against the rejection of license requests';
+COMMENT ON COLUMN license_appeals.status IS 'Appeal status (pending, granted, dismissed, withdrawn)';
+COMMENT ON COLUMN license_appeals.rejected_from IS 'Status the request was rejected from, reopened when the appeal is granted';
+COMMENT ON COLUMN license_appeals.rejected_for_id IS 'Delegator the rejecter acted for';
+COMMENT ON COLUMN license_appeals.reviewer_id IS 'Officer the appeal was routed to, never one who took part in the rejection';
@@ -0,0 +1,45 @@
+-- Migration: Create licenses table
+-- Created: 2026-10-17
+-- Description: Licenses issued on approval of new license requests, referenced by renewal, extension and reduction requests
+
+CREATE TABLE IF NOT EXISTS licenses (
+    id SERIAL PRIMARY KEY,
+    license_number VARCHAR(50) NOT NULL UNIQUE,
+    holder_user_id INTEGER NOT NULL REFERENCES users(id),
+    holder_corporate_id INTEGER REFERENCES corporates(id),
+    project_name VARCHAR(255) NOT NULL,
+    location TEXT,
+    province VARCHAR(100),
+    energy_type VARCHAR(50),
+    capacity DECIMAL(10,2),
+    capacity_unit VARCHAR(20),
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    issued_request_id INTEGER REFERENCES license_requests(id),
+    last_amended_request_id INTEGER REFERENCES license_requests(id),
+    amended_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_licenses_holder_user_id ON licenses(holder_user_id);
+CREATE INDEX IF NOT EXISTS idx_licenses_holder_corporate_id ON licenses(holder_corporate_id);
+CREATE INDEX IF NOT EXISTS idx_licenses_status ON licenses(status);
+CREATE INDEX IF NOT EXISTS idx_licenses_valid_until ON licenses(valid_until);
+
+-- Requests point at the license they refer to, or were issued on approval
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS license_id INTEGER REFERENCES licenses(id);
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS corporate_id INTEGER REFERENCES corporates(id);
+CREATE INDEX IF NOT EXISTS idx_license_requests_license_id ON license_requests(license_id);
+CREATE INDEX IF NOT EXISTS idx_license_requests_corporate_id ON license_requests(corporate_id);
+
+-- Add comment to the table
+COMMENT ON TABLE licenses IS 'Issued licenses';
+COMMENT ON COLUMN licenses.status IS 'License status (active, expired)';
+COMMENT ON COLUMN licenses.holder_corporate_id IS 'Corporate holding the license, null when held by the user';
+COMMENT ON COLUMN licenses.issued_request_id IS 'New license request the license was issued on, null for registered existing licenses';
+COMMENT ON COLUMN licenses.last_amended_request_id IS 'Latest approved renewal, extension or reduction request';
+COMMENT ON COLUMN license_requests.license_id IS 'Issued license the request refers to';
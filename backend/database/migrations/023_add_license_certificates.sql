@@ -0,0 +1,17 @@
+-- Migration: Add license certificates
+-- Created: 2026-10-17
+-- Description: Verification token printed in the certificate QR code and the attachment holding the current certificate PDF
+
+ALTER TABLE licenses ADD COLUMN IF NOT EXISTS verification_token VARCHAR(64);
+ALTER TABLE licenses ADD COLUMN IF NOT EXISTS certificate_id INTEGER REFERENCES attachments(id);
+
+-- Existing licenses get a random token
+UPDATE licenses
+SET verification_token = md5(random()::text || clock_timestamp()::text || id::text)
+WHERE verification_token IS NULL;
+
+ALTER TABLE licenses ALTER COLUMN verification_token SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_licenses_verification_token ON licenses(verification_token);
+
+COMMENT ON COLUMN licenses.verification_token IS 'Opaque token in the certificate QR code, used by the public verification endpoint';
+COMMENT ON COLUMN licenses.certificate_id IS 'Attachment holding the current certificate PDF';
@@ -0,0 +1,32 @@
+-- Migration: Add license expiry tracking
+-- Created: 2026-10-17
+-- Description: License validity on approved requests and the expiry reminders sent to license holders
+
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS license_valid_from TIMESTAMP WITH TIME ZONE;
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS license_valid_until TIMESTAMP WITH TIME ZONE;
+
+-- Approved requests take the validity their license has now
+UPDATE license_requests lr
+SET license_valid_from = l.valid_from,
+    license_valid_until = l.valid_until
+FROM licenses l
+WHERE lr.license_id = l.id
+  AND lr.status = 'approved'
+  AND lr.license_valid_until IS NULL;
+
+CREATE TABLE IF NOT EXISTS license_expiry_reminders (
+    id SERIAL PRIMARY KEY,
+    license_id INTEGER NOT NULL REFERENCES licenses(id),
+    valid_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    days_before INTEGER NOT NULL,
+    recipient_id INTEGER NOT NULL REFERENCES users(id),
+    renewal_request_id INTEGER REFERENCES license_requests(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_license_expiry_reminders_tier ON license_expiry_reminders(license_id, valid_until, days_before);
+CREATE INDEX IF NOT EXISTS idx_license_expiry_reminders_recipient_id ON license_expiry_reminders(recipient_id);
+
+COMMENT ON TABLE license_expiry_reminders IS 'Reminders sent to license holders before, or when, a license expires';
+COMMENT ON COLUMN license_expiry_reminders.days_before IS 'Reminder tier in days before expiry; 0 is the notice that the license has expired';
+COMMENT ON COLUMN license_expiry_reminders.renewal_request_id IS 'Renewal draft the reminder links to';
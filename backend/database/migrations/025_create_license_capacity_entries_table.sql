@@ -0,0 +1,46 @@
+-- Migration: Create license_capacity_entries table
+-- Created: 2026-10-17
+-- Description: Append-only capacity ledger per license, written when licenses are issued or registered and when extensions and reductions are approved
+
+CREATE TABLE IF NOT EXISTS license_capacity_entries (
+    id SERIAL PRIMARY KEY,
+    license_id INTEGER NOT NULL REFERENCES licenses(id),
+    entry_type VARCHAR(20) NOT NULL,
+    request_id INTEGER REFERENCES license_requests(id),
+    previous_capacity DECIMAL(10,2) NOT NULL DEFAULT 0,
+    capacity DECIMAL(10,2) NOT NULL,
+    capacity_unit VARCHAR(20),
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_license_capacity_entries_license ON license_capacity_entries(license_id, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_license_capacity_entries_request_id ON license_capacity_entries(request_id);
+
+-- Existing licenses open their ledger with the capacity they have now
+INSERT INTO license_capacity_entries (license_id, entry_type, request_id, capacity, capacity_unit, recorded_at)
+SELECT l.id,
+       CASE WHEN l.issued_request_id IS NULL THEN 'registered' ELSE 'issued' END,
+       l.issued_request_id,
+       l.capacity,
+       l.capacity_unit,
+       l.issued_at
+FROM licenses l
+WHERE NOT EXISTS (SELECT 1 FROM license_capacity_entries e WHERE e.license_id = l.id);
+
+-- Ledger entries are never changed once stored
+CREATE OR REPLACE FUNCTION reject_license_capacity_entry_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'capacity ledger entries cannot be changed';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_license_capacity_entries_append_only ON license_capacity_entries;
+CREATE TRIGGER trg_license_capacity_entries_append_only
+    BEFORE UPDATE OR DELETE ON license_capacity_entries
+    FOR EACH ROW EXECUTE FUNCTION reject_license_capacity_entry_change();
+
+-- Add comment to the table
+COMMENT ON TABLE license_capacity_entries IS 'Append-only capacity ledger of each license; the latest entry holds the licensed capacity';
+COMMENT ON COLUMN license_capacity_entries.request_id IS 'Approved request that changed the capacity; null for registered licenses';
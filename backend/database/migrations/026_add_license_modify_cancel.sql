@@ -0,0 +1,22 @@
+-- Migration: Add license modification and cancellation
+-- Created: 2026-10-17
+-- Description: Particulars amended by modify requests and the retirement of licenses surrendered by cancel requests
+
+ALTER TABLE licenses ADD COLUMN IF NOT EXISTS contact_person VARCHAR(255);
+ALTER TABLE licenses ADD COLUMN IF NOT EXISTS contact_phone VARCHAR(50);
+ALTER TABLE licenses ADD COLUMN IF NOT EXISTS contact_email VARCHAR(255);
+ALTER TABLE licenses ADD COLUMN IF NOT EXISTS equipment TEXT;
+ALTER TABLE licenses ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+
+-- Licenses take the contact of the request they were issued on
+UPDATE licenses l
+SET contact_person = r.contact_person,
+    contact_phone = r.contact_phone,
+    contact_email = r.contact_email
+FROM license_requests r
+WHERE r.id = l.issued_request_id AND l.contact_person IS NULL;
+
+COMMENT ON COLUMN licenses.status IS 'License status (active, expired, cancelled)';
+COMMENT ON COLUMN licenses.equipment IS 'Licensed equipment, amended by modify requests';
+COMMENT ON COLUMN licenses.cancelled_at IS 'When an approved cancel request retired the license';
+COMMENT ON COLUMN licenses.last_amended_request_id IS 'Latest approved renewal, extension, reduction, modify or cancel request';
@@ -0,0 +1,59 @@
+-- Migration: Create license_enforcements tables
+-- Created: 2026-10-17
+-- Description: DEDE-initiated cases to suspend or revoke issued licenses, with the history of each case
+
+CREATE TABLE IF NOT EXISTS license_enforcements (
+    id SERIAL PRIMARY KEY,
+    case_number VARCHAR(50) NOT NULL UNIQUE,
+    license_id INTEGER NOT NULL REFERENCES licenses(id),
+    proposed_action VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'notice_issued',
+    grounds TEXT NOT NULL,
+    response_deadline TIMESTAMP WITH TIME ZONE NOT NULL,
+    issued_by_id INTEGER NOT NULL REFERENCES users(id),
+    response TEXT,
+    responded_by_id INTEGER REFERENCES users(id),
+    responded_at TIMESTAMP WITH TIME ZONE,
+    decision VARCHAR(20),
+    decision_notes TEXT,
+    decided_by_id INTEGER REFERENCES users(id),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    suspended_until TIMESTAMP WITH TIME ZONE,
+    previous_status VARCHAR(20),
+    reinstated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS license_enforcement_events (
+    id SERIAL PRIMARY KEY,
+    enforcement_id INTEGER NOT NULL REFERENCES license_enforcements(id),
+    event_type VARCHAR(30) NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    license_status_before VARCHAR(20),
+    license_status_after VARCHAR(20),
+    actor_id INTEGER REFERENCES users(id),
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_license_enforcements_license_id ON license_enforcements(license_id);
+CREATE INDEX IF NOT EXISTS idx_license_enforcements_status ON license_enforcements(status);
+CREATE INDEX IF NOT EXISTS idx_license_enforcements_response_deadline ON license_enforcements(response_deadline);
+CREATE INDEX IF NOT EXISTS idx_license_enforcement_events_enforcement_id ON license_enforcement_events(enforcement_id);
+
+-- A license has at most one case waiting for the holder or a decision
+CREATE UNIQUE INDEX IF NOT EXISTS idx_license_enforcements_open ON license_enforcements(license_id)
+    WHERE status IN ('notice_issued', 'responded', 'response_overdue');
+
+-- Add comment to the table
+COMMENT ON TABLE license_enforcements IS 'Cases to suspend or revoke issued licenses: notice, holder response, Head decision';
+COMMENT ON COLUMN license_enforcements.status IS 'Case status (notice_issued, responded, response_overdue, suspended, revoked, dismissed, reinstated)';
+COMMENT ON COLUMN license_enforcements.decision IS 'Action the Head took (suspend, revoke); null when dismissed or undecided';
+COMMENT ON COLUMN license_enforcements.suspended_until IS 'End of a suspension; null keeps the license suspended until a Head reinstates it';
+COMMENT ON COLUMN license_enforcements.previous_status IS 'License status before the decision, restored on reinstatement';
+COMMENT ON TABLE license_enforcement_events IS 'History of each enforcement case';
+COMMENT ON COLUMN license_enforcement_events.actor_id IS 'User who acted; null when the system marked the notice overdue or ended the suspension';
+COMMENT ON COLUMN licenses.status IS 'License status (active, expired, cancelled, suspended, revoked)';
@@ -0,0 +1,75 @@
+-- Migration: Create fee_schedules, invoices and payments tables
+-- Created: 2026-10-17
+-- Description: License fees by license type and capacity band, the invoices they are charged with and the payments settling them
+
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id SERIAL PRIMARY KEY,
+    license_type VARCHAR(20) NOT NULL,
+    min_capacity DECIMAL(10,2) NOT NULL DEFAULT 0,
+    max_capacity DECIMAL(10,2),
+    amount DECIMAL(12,2) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id SERIAL PRIMARY KEY,
+    invoice_number VARCHAR(50) NOT NULL UNIQUE,
+    license_request_id INTEGER NOT NULL REFERENCES license_requests(id),
+    fee_schedule_id INTEGER NOT NULL REFERENCES fee_schedules(id),
+    capacity DECIMAL(10,2),
+    amount DECIMAL(12,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'THB',
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    due_date TIMESTAMP WITH TIME ZONE,
+    provider VARCHAR(50) NOT NULL,
+    provider_reference VARCHAR(100),
+    payment_payload TEXT,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    receipt_number VARCHAR(50),
+    receipt_id INTEGER REFERENCES attachments(id),
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id),
+    provider VARCHAR(50) NOT NULL,
+    reference VARCHAR(100) NOT NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    paid_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_by_id INTEGER REFERENCES users(id),
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_fee_schedules_license_type ON fee_schedules(license_type);
+CREATE INDEX IF NOT EXISTS idx_fee_schedules_is_active ON fee_schedules(is_active);
+CREATE INDEX IF NOT EXISTS idx_invoices_license_request_id ON invoices(license_request_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_status ON invoices(status);
+CREATE INDEX IF NOT EXISTS idx_invoices_receipt_number ON invoices(receipt_number);
+CREATE INDEX IF NOT EXISTS idx_payments_invoice_id ON payments(invoice_id);
+
+-- A bank or provider reference settles one payment only
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_provider_reference ON payments(provider, reference);
+
+-- A request is charged with at most one invoice that is not cancelled
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_live ON invoices(license_request_id)
+    WHERE status IN ('pending', 'paid');
+
+-- Add comments
+COMMENT ON TABLE fee_schedules IS 'License fees by license type and capacity band; active bands of a type do not overlap';
+COMMENT ON COLUMN fee_schedules.max_capacity IS 'Upper bound of the band, excluded; null for no upper bound';
+COMMENT ON TABLE invoices IS 'Fees charged on license requests';
+COMMENT ON COLUMN invoices.status IS 'Invoice status (pending, paid, cancelled)';
+COMMENT ON COLUMN invoices.payment_payload IS 'What the payer needs to pay, e.g. a PromptPay QR payload';
+COMMENT ON TABLE payments IS 'Confirmed payments of invoices';
+COMMENT ON COLUMN payments.confirmed_by_id IS 'Officer who confirmed the payment; null when the provider did';
@@ -0,0 +1,38 @@
+-- Migration: Create document_requirements table
+-- Created: 2026-10-17
+-- Description: Documents each license type needs, optionally by energy type and capacity band, and the review of each uploaded document
+
+CREATE TABLE IF NOT EXISTS document_requirements (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    license_type VARCHAR(20) NOT NULL,
+    energy_type VARCHAR(50),
+    min_capacity DECIMAL(10,2),
+    max_capacity DECIMAL(10,2),
+    sort_order INTEGER DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Link uploaded files to the checklist and record their review
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS document_requirement_id INTEGER REFERENCES document_requirements(id);
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS review_status VARCHAR(20) DEFAULT 'pending';
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS reviewed_by_id INTEGER REFERENCES users(id);
+ALTER TABLE attachments ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_document_requirements_code ON document_requirements(code);
+CREATE INDEX IF NOT EXISTS idx_document_requirements_license_type ON document_requirements(license_type);
+CREATE INDEX IF NOT EXISTS idx_document_requirements_is_active ON document_requirements(is_active);
+CREATE INDEX IF NOT EXISTS idx_attachments_document_requirement_id ON attachments(document_requirement_id);
+
+-- Add comments
+COMMENT ON TABLE document_requirements IS 'Documents applicants must attach to requests of a license type';
+COMMENT ON COLUMN document_requirements.energy_type IS 'Energy type the document is required for; empty for every energy type';
+COMMENT ON COLUMN document_requirements.min_capacity IS 'Capacity from which the document is required, included; null for no lower bound';
+COMMENT ON COLUMN document_requirements.max_capacity IS 'Capacity below which the document is required; null for no upper bound';
+COMMENT ON COLUMN attachments.review_status IS 'Officer review of a required document (pending, accepted, rejected)';
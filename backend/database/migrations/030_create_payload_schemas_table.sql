@@ -0,0 +1,33 @@
+-- Migration: Create payload_schemas table
+-- Created: 2026-10-17
+-- Description: Versioned JSON Schemas for the technical data of requests per license type and energy type, and the technical data of each request
+
+CREATE TABLE IF NOT EXISTS payload_schemas (
+    id SERIAL PRIMARY KEY,
+    license_type VARCHAR(20) NOT NULL,
+    energy_type VARCHAR(50) NOT NULL DEFAULT '',
+    version INTEGER NOT NULL,
+    schema TEXT NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT FALSE,
+    created_by_id INTEGER REFERENCES users(id),
+    activated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Store the technical data of each request and the schema version it was saved against
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS technical_data TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE license_requests ADD COLUMN IF NOT EXISTS technical_schema_id INTEGER REFERENCES payload_schemas(id);
+
+-- Create indexes
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payload_schemas_version ON payload_schemas(license_type, energy_type, version);
+CREATE INDEX IF NOT EXISTS idx_payload_schemas_is_active ON payload_schemas(is_active);
+
+-- Add comments
+COMMENT ON TABLE payload_schemas IS 'Versioned, immutable JSON Schemas for the technical data of requests';
+COMMENT ON COLUMN payload_schemas.energy_type IS 'Energy type the schema is for; empty for energy types without a schema of their own';
+COMMENT ON COLUMN payload_schemas.schema IS 'JSON Schema of the technical data, also served to the frontend to render the form';
+COMMENT ON COLUMN payload_schemas.is_active IS 'Whether requests of this license type and energy type are checked against this version';
+COMMENT ON COLUMN license_requests.technical_data IS 'JSON technical data of the request, e.g. inverter specs or turbine counts';
+COMMENT ON COLUMN license_requests.technical_schema_id IS 'Payload schema version the technical data was last saved against';
@@ -0,0 +1,292 @@
+package migrations
+
+import (
+	"eservice-backend/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyLicenseTables are the per-type tables license requests were stored in before they
+// were unified into license_requests, with the license type of their rows
+var legacyLicenseTables = []struct {
+	table       string
+	licenseType models.LicenseType
+}{
+	{"new_license_requests", models.LicenseTypeNew},
+	{"renewal_license_requests", models.LicenseTypeRenew},
+	{"extension_license_requests", models.LicenseTypeExpand},
+	{"reduction_license_requests", models.LicenseTypeReduce},
+}
+
+// legacyRequestReferences are the columns that point at a request by ID together with a license_type column
+var legacyRequestReferences = []struct {
+	table  string
+	column string
+}{
+	{"service_flow_logs", "license_request_id"},
+	{"task_assignments", "request_id"},
+	{"deadline_reminders", "request_id"},
+	{"workflow_comments", "request_id"},
+	{"workflow_metrics", "request_id"},
+	{"audit_report_versions", "license_request_id"},
+}
+
+// legacyMigrationBatchSize is how many rows of a per-type table are loaded at a time
+const legacyMigrationBatchSize = 500
+
+// legacyLicenseRequest has the union of the columns of the per-type request tables
+type legacyLicenseRequest struct {
+	ID                    uint
+	UserID                uint
+	RequestNumber         string
+	Status                models.RequestStatus
+	LicenseType           string
+	LicenseNumber         string
+	ProjectName           string
+	ProjectAddress        string
+	Province              string
+	District              string
+	Subdistrict           string
+	PostalCode            string
+	EnergyType            string
+	Capacity              float64
+	CapacityUnit          string
+	CurrentCapacity       float64
+	CurrentCapacityUnit   string
+	RequestedCapacity     float64
+	RequestedCapacityUnit string
+	ExpiryDate            *time.Time
+	RequestedExpiryDate   *time.Time
+	ExpectedStartDate     *time.Time
+	Description           string
+	Reason                string
+	ExtensionReason       string
+	ReductionReason       string
+	ContactPerson         string
+	ContactPhone          string
+	ContactEmail          string
+	WorkflowVersion       int
+	Version               int
+	InspectorID           *uint
+	AssignedByID          *uint
+	AssignedAt            *time.Time
+	AppointmentDate       *time.Time
+	InspectionDate        *time.Time
+	CompletionDate        *time.Time
+	Deadline              *time.Time
+	RejectionReason       string
+	Notes                 string
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	DeletedAt             gorm.DeletedAt
+}
+
+// migrateLegacyLicenseRequests copies requests from the per-type tables into license_requests
+// and repoints their flow logs, tasks, reminders, comments, metrics and report versions at the
+// new IDs. Rows that were already copied are skipped, so it is safe to run on every start.
+func migrateLegacyLicenseRequests(db *gorm.DB) error {
+	// Requests created before the license type values were aligned with the workflow
+	for oldType, newType := range models.LegacyLicenseTypes {
+		err := db.Model(&models.LicenseRequest{}).Unscoped().
+			Where("license_type = ?", oldType).
+			Update("license_type", newType).Error
+		if err != nil {
+			return fmt.Errorf("failed to rename license type %s: %w", oldType, err)
+		}
+	}
+
+	for _, legacy := range legacyLicenseTables {
+		if !db.Migrator().HasTable(legacy.table) {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return migrateLegacyTable(tx, legacy.table, legacy.licenseType)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", legacy.table, err)
+		}
+	}
+
+	return nil
+}
+
+func migrateLegacyTable(tx *gorm.DB, table string, licenseType models.LicenseType) error {
+	// Map old IDs to new ones in a temporary table rather than in memory or statement parameters,
+	// which Postgres limits to 65535 per statement
+	if err := tx.Exec("CREATE TEMPORARY TABLE legacy_request_ids (old_id BIGINT PRIMARY KEY, new_id BIGINT)").Error; err != nil {
+		return fmt.Errorf("failed to create request ID mapping: %w", err)
+	}
+	err := tx.Exec(fmt.Sprintf(
+		"INSERT INTO legacy_request_ids (old_id) SELECT id FROM %s WHERE NOT EXISTS (SELECT 1 FROM license_requests lr WHERE lr.legacy_table = ? AND lr.legacy_id = %s.id)",
+		table, table,
+	), table).Error
+	if err != nil {
+		return fmt.Errorf("failed to collect requests to copy: %w", err)
+	}
+
+	// Rows are copied a batch at a time so large tables are never loaded at once
+	var rows []legacyLicenseRequest
+	err = tx.Table(table).Unscoped().
+		Where("id IN (SELECT old_id FROM legacy_request_ids)").
+		FindInBatches(&rows, legacyMigrationBatchSize, func(_ *gorm.DB, _ int) error {
+			for _, row := range rows {
+				if err := copyLegacyRequest(tx, row, table, licenseType); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Exec(
+		"UPDATE legacy_request_ids SET new_id = (SELECT lr.id FROM license_requests lr WHERE lr.legacy_table = ? AND lr.legacy_id = legacy_request_ids.old_id)",
+		table,
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to map request IDs: %w", err)
+	}
+
+	// Repoint references in one statement per table so an old ID is never mistaken for a new one
+	for _, ref := range legacyRequestReferences {
+		if !tx.Migrator().HasTable(ref.table) {
+			continue
+		}
+		query := fmt.Sprintf(
+			"UPDATE %s AS t SET %s = m.new_id FROM legacy_request_ids AS m WHERE t.%s = m.old_id AND t.license_type = ?",
+			ref.table, ref.column, ref.column,
+		)
+		if err := tx.Exec(query, string(licenseType)).Error; err != nil {
+			return fmt.Errorf("failed to repoint %s: %w", ref.table, err)
+		}
+	}
+
+	return tx.Exec("DROP TABLE legacy_request_ids").Error
+}
+
+// copyLegacyRequest inserts a per-type row into license_requests
+func copyLegacyRequest(tx *gorm.DB, row legacyLicenseRequest, table string, licenseType models.LicenseType) error {
+	request, err := convertLegacyRequest(row, table, licenseType)
+	if err != nil {
+		return err
+	}
+
+	// Request numbers were generated per table, so they can clash once merged
+	var clashes int64
+	if err := tx.Model(&models.LicenseRequest{}).Unscoped().Where("request_number = ?", request.RequestNumber).Count(&clashes).Error; err != nil {
+		return err
+	}
+	if clashes > 0 {
+		request.RequestNumber = fmt.Sprintf("%s-%s%d", request.RequestNumber, strings.ToUpper(string(licenseType)[:1]), row.ID)
+	}
+
+	return tx.Omit(clause.Associations).Create(request).Error
+}
+
+// convertLegacyRequest maps a per-type row onto the unified core and its type-specific payload
+func convertLegacyRequest(row legacyLicenseRequest, table string, licenseType models.LicenseType) (*models.LicenseRequest, error) {
+	legacyID := row.ID
+	version := row.Version
+	if version == 0 {
+		version = 1
+	}
+
+	request := &models.LicenseRequest{
+		UserID:          row.UserID,
+		RequestNumber:   row.RequestNumber,
+		LicenseType:     licenseType,
+		Status:          row.Status,
+		Title:           row.ProjectName,
+		Description:     row.Description,
+		LicenseNumber:   row.LicenseNumber,
+		ContactPerson:   row.ContactPerson,
+		ContactPhone:    row.ContactPhone,
+		ContactEmail:    row.ContactEmail,
+		WorkflowVersion: row.WorkflowVersion,
+		Version:         version,
+		LegacyTable:     table,
+		LegacyID:        &legacyID,
+		InspectorID:     row.InspectorID,
+		AssignedByID:    row.AssignedByID,
+		AssignedAt:      row.AssignedAt,
+		AppointmentDate: row.AppointmentDate,
+		InspectionDate:  row.InspectionDate,
+		CompletionDate:  row.CompletionDate,
+		Deadline:        row.Deadline,
+		RejectionReason: row.RejectionReason,
+		Notes:           row.Notes,
+		CreatedAt:       row.CreatedAt,
+		UpdatedAt:       row.UpdatedAt,
+		DeletedAt:       row.DeletedAt,
+	}
+
+	var payload interface{}
+	switch licenseType {
+	case models.LicenseTypeNew:
+		newPayload := models.NewLicensePayload{
+			LicenseCategory:   row.LicenseType,
+			ProjectAddress:    row.ProjectAddress,
+			Province:          row.Province,
+			District:          row.District,
+			Subdistrict:       row.Subdistrict,
+			PostalCode:        row.PostalCode,
+			EnergyType:        row.EnergyType,
+			CapacityUnit:      row.CapacityUnit,
+			ExpectedStartDate: timeValue(row.ExpectedStartDate),
+		}
+		request.RequestedCapacity = row.Capacity
+		request.Location = newPayload.Location()
+		payload = newPayload
+	case models.LicenseTypeRenew:
+		request.Description = row.Reason
+		request.Location = row.ProjectAddress
+		request.CurrentCapacity = row.CurrentCapacity
+		request.RequestedCapacity = row.RequestedCapacity
+		payload = models.RenewalLicensePayload{
+			LicenseCategory:       row.LicenseType,
+			ProjectAddress:        row.ProjectAddress,
+			CurrentCapacityUnit:   row.CurrentCapacityUnit,
+			RequestedCapacityUnit: row.RequestedCapacityUnit,
+			ExpiryDate:            timeValue(row.ExpiryDate),
+			RequestedExpiryDate:   timeValue(row.RequestedExpiryDate),
+			Reason:                row.Reason,
+		}
+	case models.LicenseTypeExpand:
+		request.CurrentCapacity = row.CurrentCapacity
+		request.RequestedCapacity = row.RequestedCapacity
+		payload = models.ExtensionLicensePayload{
+			LicenseCategory:       row.LicenseType,
+			CurrentCapacityUnit:   row.CurrentCapacityUnit,
+			RequestedCapacityUnit: row.RequestedCapacityUnit,
+			ExtensionReason:       row.ExtensionReason,
+			ExpectedStartDate:     timeValue(row.ExpectedStartDate),
+		}
+	case models.LicenseTypeReduce:
+		request.CurrentCapacity = row.CurrentCapacity
+		request.RequestedCapacity = row.RequestedCapacity
+		payload = models.ReductionLicensePayload{
+			LicenseCategory:       row.LicenseType,
+			CurrentCapacityUnit:   row.CurrentCapacityUnit,
+			RequestedCapacityUnit: row.RequestedCapacityUnit,
+			ReductionReason:       row.ReductionReason,
+			ExpectedStartDate:     timeValue(row.ExpectedStartDate),
+		}
+	}
+
+	if err := request.SetPayload(payload); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
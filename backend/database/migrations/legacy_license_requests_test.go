@@ -0,0 +1,117 @@
+package migrations
+
+import (
+	"eservice-backend/models"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openLegacyTestDB returns an in-memory database with license_requests, a per-type renewal table
+// and flow logs pointing at the renewal rows
+func openLegacyTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// Every connection to :memory: is a database of its own
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(&models.LicenseRequest{}))
+	require.NoError(t, db.Exec(`CREATE TABLE renewal_license_requests (
+		id INTEGER PRIMARY KEY, user_id INTEGER, request_number TEXT, status TEXT, license_type TEXT,
+		project_name TEXT, project_address TEXT, current_capacity REAL, requested_capacity REAL, reason TEXT,
+		created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE service_flow_logs (
+		id INTEGER PRIMARY KEY, license_request_id INTEGER, license_type TEXT)`).Error)
+	return db
+}
+
+func TestMigrateLegacyTable(t *testing.T) {
+	tests := []struct {
+		name          string
+		legacyRows    int
+		existingRows  int  // Requests already in license_requests, which take the low IDs
+		clashingFirst bool // The first legacy request number is already taken
+		runs          int
+	}{
+		{name: "copies rows and repoints flow logs", legacyRows: 3, existingRows: 2, runs: 1},
+		{name: "renames clashing request numbers", legacyRows: 2, existingRows: 1, clashingFirst: true, runs: 1},
+		{name: "skips rows copied by an earlier run", legacyRows: 3, existingRows: 1, runs: 2},
+		{name: "copies tables larger than a batch", legacyRows: legacyMigrationBatchSize*2 + 1, existingRows: 5, runs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openLegacyTestDB(t)
+
+			for i := 1; i <= tt.existingRows; i++ {
+				number := fmt.Sprintf("NEW-%d", i)
+				if tt.clashingFirst && i == 1 {
+					number = "REN-1"
+				}
+				require.NoError(t, db.Exec(
+					"INSERT INTO license_requests (user_id, request_number, license_type, status, title) VALUES (1, ?, 'new', 'draft', 'existing')",
+					number,
+				).Error)
+				// Flow logs of another type with the same IDs must be left alone
+				require.NoError(t, db.Exec("INSERT INTO service_flow_logs (license_request_id, license_type) VALUES (?, 'new')", i).Error)
+			}
+			for i := 1; i <= tt.legacyRows; i++ {
+				require.NoError(t, db.Exec(
+					"INSERT INTO renewal_license_requests (id, user_id, request_number, status, project_name, reason) VALUES (?, 7, ?, 'new_request', ?, 'expiring')",
+					i, fmt.Sprintf("REN-%d", i), fmt.Sprintf("Plant %d", i),
+				).Error)
+				require.NoError(t, db.Exec("INSERT INTO service_flow_logs (license_request_id, license_type) VALUES (?, ?)", i, models.LicenseTypeRenew).Error)
+			}
+
+			for run := 0; run < tt.runs; run++ {
+				err := db.Transaction(func(tx *gorm.DB) error {
+					return migrateLegacyTable(tx, "renewal_license_requests", models.LicenseTypeRenew)
+				})
+				require.NoError(t, err)
+			}
+
+			var copied []models.LicenseRequest
+			require.NoError(t, db.Where("legacy_table = ?", "renewal_license_requests").Order("legacy_id").Find(&copied).Error)
+			require.Len(t, copied, tt.legacyRows)
+
+			newIDs := make(map[uint]uint, len(copied))
+			for _, request := range copied {
+				require.NotNil(t, request.LegacyID)
+				newIDs[*request.LegacyID] = request.ID
+				assert.Equal(t, models.LicenseTypeRenew, request.LicenseType)
+				assert.Equal(t, fmt.Sprintf("Plant %d", *request.LegacyID), request.Title)
+			}
+			if tt.clashingFirst {
+				assert.Equal(t, "REN-1-R1", copied[0].RequestNumber)
+				assert.Equal(t, "REN-2", copied[1].RequestNumber)
+			}
+
+			var logs []struct {
+				LicenseRequestID uint
+				LicenseType      string
+			}
+			require.NoError(t, db.Table("service_flow_logs").Order("id").Find(&logs).Error)
+			for i, log := range logs {
+				if i < tt.existingRows {
+					assert.Equal(t, "new", log.LicenseType)
+					assert.Equal(t, uint(i+1), log.LicenseRequestID)
+					continue
+				}
+				legacyID := uint(i - tt.existingRows + 1)
+				assert.Equal(t, newIDs[legacyID], log.LicenseRequestID, "flow log of legacy request %d", legacyID)
+			}
+
+			assert.False(t, db.Migrator().HasTable("legacy_request_ids"))
+		})
+	}
+}
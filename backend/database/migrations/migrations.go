@@ -25,19 +25,13 @@ func Migrate(db *gorm.DB) error {
 	if err := db.AutoMigrate(&models.OTP{}); err != nil {
 		return err
 	}
-	if err := db.AutoMigrate(&models.LicenseRequest{}); err != nil {
-		return err
-	}
-	if err := db.AutoMigrate(&models.NewLicenseRequest{}); err != nil {
+	if err := db.AutoMigrate(&models.License{}); err != nil {
 		return err
 	}
-	if err := db.AutoMigrate(&models.RenewalLicenseRequest{}); err != nil {
-		return err
-	}
-	if err := db.AutoMigrate(&models.ExtensionLicenseRequest{}); err != nil {
+	if err := db.AutoMigrate(&models.LicenseRequest{}); err != nil {
 		return err
 	}
-	if err := db.AutoMigrate(&models.ReductionLicenseRequest{}); err != nil {
+	if err := db.AutoMigrate(&models.LicenseCapacityEntry{}); err != nil {
 		return err
 	}
 	if err := db.AutoMigrate(&models.Inspection{}); err != nil {
@@ -76,6 +70,86 @@ func Migrate(db *gorm.DB) error {
 	if err := db.AutoMigrate(&models.WorkflowMetric{}); err != nil {
 		return err
 	}
+	if err := db.AutoMigrate(&models.WorkflowDefinition{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.OutboxMessage{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.ApprovalVote{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.WorkflowDelegation{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.WorkflowEscalation{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.RequestSLAClock{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.RequestSubmission{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.LicenseAppeal{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.LicenseExpiryReminder{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.LicenseEnforcement{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.LicenseEnforcementEvent{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.FeeSchedule{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Invoice{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Payment{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.DocumentRequirement{}); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.PayloadSchema{}); err != nil {
+		return err
+	}
+
+	// Move requests from the per-type tables into license_requests
+	if err := migrateLegacyLicenseRequests(db); err != nil {
+		return err
+	}
+
+	// Publish the standard workflow for requests created from now on
+	if err := seedStandardWorkflowDefinitions(db); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+// tables lists every table Migrate creates, and the per-type tables requests were kept in before
+// they were unified. Each table comes after the tables it references, apart from licenses and
+// license_requests, which reference each other.
+var tables = []interface{}{
+	"users", "corporates", "corporate_members", "user_profiles", "otp_codes", "admin_users",
+	"workflow_definitions", "payload_schemas", "document_requirements", "fee_schedules", "attachments",
+	"new_license_requests", "renewal_license_requests", "extension_license_requests",
+	"reduction_license_requests", "license_requests", "licenses", "license_capacity_entries",
+	"license_expiry_reminders", "license_enforcements", "license_enforcement_events",
+	"inspections", "audit_reports", "audit_report_versions", "notifications", "service_flow_logs",
+	"task_assignments", "workflow_comments", "deadline_reminders", "role_dashboards",
+	"workflow_metrics", "outbox_messages", "approval_votes", "workflow_delegations",
+	"workflow_escalations", "request_sla_clocks", "request_submissions", "license_appeals",
+	"invoices", "payments",
+}
+
+// Drop drops every table Migrate creates. DropTable works through the list last to first,
+// so each table is dropped before the tables it references.
+func Drop(db *gorm.DB) error {
+	return db.Migrator().DropTable(tables...)
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDrop(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, db *gorm.DB)
+	}{
+		{name: "migrated database"},
+		{
+			name: "database with the per-type request tables",
+			prepare: func(t *testing.T, db *gorm.DB) {
+				for _, table := range []string{"new_license_requests", "renewal_license_requests", "extension_license_requests", "reduction_license_requests"} {
+					require.NoError(t, db.Exec("CREATE TABLE "+table+" (id INTEGER PRIMARY KEY, deleted_at DATETIME)").Error)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			sqlDB, err := db.DB()
+			require.NoError(t, err)
+			sqlDB.SetMaxOpenConns(1)
+			t.Cleanup(func() { sqlDB.Close() })
+
+			if tt.prepare != nil {
+				tt.prepare(t, db)
+			}
+			require.NoError(t, Migrate(db))
+
+			require.NoError(t, Drop(db))
+
+			tables, err := db.Migrator().GetTables()
+			require.NoError(t, err)
+			var remaining []string
+			for _, table := range tables {
+				// SQLite keeps its own bookkeeping tables
+				if !strings.HasPrefix(table, "sqlite_") {
+					remaining = append(remaining, table)
+				}
+			}
+			assert.Empty(t, remaining, "tables Migrate created but Drop left")
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"bytes"
+	"encoding/json"
+	"eservice-backend/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// workflowLicenseTypes are the license types the standard workflow definition is published for
+var workflowLicenseTypes = []models.LicenseType{
+	models.LicenseTypeNew,
+	models.LicenseTypeRenew,
+	models.LicenseTypeExpand,
+	models.LicenseTypeReduce,
+	models.LicenseTypeModify,
+	models.LicenseTypeCancel,
+}
+
+// seedStandardWorkflowDefinitions publishes the standard workflow definition as the next version
+// of every license type that does not have it yet. The new version is activated unless an admin
+// has activated a version of their own. Requests already in flight stay on the version they are
+// pinned to, so only requests created afterwards run on the new workflow.
+func seedStandardWorkflowDefinitions(db *gorm.DB) error {
+	spec, err := models.ParseWorkflowDefinitionSpec(models.StandardWorkflowDefinitionJSON())
+	if err != nil {
+		return fmt.Errorf("standard workflow definition: %w", err)
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, models.StandardWorkflowDefinitionJSON()); err != nil {
+		return fmt.Errorf("standard workflow definition: %w", err)
+	}
+	definition := compacted.String()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, licenseType := range workflowLicenseTypes {
+			var published int64
+			err := tx.Model(&models.WorkflowDefinition{}).
+				Where("license_type = ? AND definition = ?", licenseType, definition).
+				Count(&published).Error
+			if err != nil {
+				return fmt.Errorf("failed to check %s workflow definitions: %w", licenseType, err)
+			}
+			if published > 0 {
+				continue
+			}
+
+			var latest int
+			err = tx.Model(&models.WorkflowDefinition{}).
+				Where("license_type = ?", licenseType).
+				Select("COALESCE(MAX(version), 0)").
+				Scan(&latest).Error
+			if err != nil {
+				return fmt.Errorf("failed to get %s workflow definition version: %w", licenseType, err)
+			}
+
+			// A version an admin published and activated takes precedence over the seeded one
+			var adminActive int64
+			err = tx.Model(&models.WorkflowDefinition{}).
+				Where("license_type = ? AND is_active = ? AND created_by_id IS NOT NULL", licenseType, true).
+				Count(&adminActive).Error
+			if err != nil {
+				return fmt.Errorf("failed to get active %s workflow definition: %w", licenseType, err)
+			}
+
+			seeded := &models.WorkflowDefinition{
+				LicenseType: string(licenseType),
+				Version:     latest + 1,
+				Name:        spec.Name,
+				Description: spec.Description,
+				Definition:  definition,
+			}
+			if adminActive == 0 {
+				now := time.Now()
+				seeded.IsActive = true
+				seeded.ActivatedAt = &now
+				err = tx.Model(&models.WorkflowDefinition{}).
+					Where("license_type = ? AND is_active = ?", licenseType, true).
+					Update("is_active", false).Error
+				if err != nil {
+					return fmt.Errorf("failed to deactivate %s workflow definition: %w", licenseType, err)
+				}
+			}
+			if err := tx.Create(seeded).Error; err != nil {
+				return fmt.Errorf("failed to publish %s workflow definition: %w", licenseType, err)
+			}
+		}
+		return nil
+	})
+}
@@ -3,11 +3,15 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"eservice-backend/config"
 	"eservice-backend/database"
 	"eservice-backend/router"
 	"eservice-backend/server"
+	"eservice-backend/service/workflow/cron"
+	"eservice-backend/service/workflow/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,9 +33,50 @@ func main() {
 
 	r := gin.Default()
 
+	// Only take the client IP from X-Forwarded-For when it comes from a known proxy, so clients
+	// cannot pick their own IP to get around per-IP rate limits
+	if err := r.SetTrustedProxies(trustedProxies(cfg.TrustedProxies)); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
 	// Setup routes
 	router.SetupRoutes(r, db, cfg)
 
+	// Report a bad payment configuration at startup rather than when the first fee is invoiced
+	if _, err := service.NewPaymentProvider(cfg); err != nil {
+		log.Printf("Payment provider unavailable: %v", err)
+	}
+
+	// Start delivering queued notifications, emails and websocket pushes
+	outboxCron := cron.NewOutboxCronJob(service.NewOutboxService(db, cfg), 30*time.Second)
+	outboxCron.Start()
+	defer outboxCron.Stop()
+
+	// Mark delegations whose period has ended as expired
+	delegationCron := cron.NewDelegationCronJob(service.NewDelegationService(db, cfg), 5*time.Minute)
+	delegationCron.Start()
+	defer delegationCron.Stop()
+
+	// Escalate requests that wait too long in a status, per the workflow definitions
+	escalationCron := cron.NewEscalationCronJob(service.NewEscalationService(db, cfg), time.Hour)
+	escalationCron.Start()
+	defer escalationCron.Stop()
+
+	// Make rejections final once their appeal window has passed
+	appealCron := cron.NewAppealCronJob(service.NewAppealService(db, cfg), time.Hour)
+	appealCron.Start()
+	defer appealCron.Stop()
+
+	// Remind holders of licenses nearing expiry and expire licenses past their validity
+	licenseExpiryCron := cron.NewLicenseExpiryCronJob(service.NewLicenseExpiryService(db, cfg), 6*time.Hour)
+	licenseExpiryCron.Start()
+	defer licenseExpiryCron.Stop()
+
+	// Mark enforcement notices overdue at their response deadline and end suspensions that ran out
+	licenseEnforcementCron := cron.NewLicenseEnforcementCronJob(service.NewLicenseEnforcementService(db, cfg), time.Hour)
+	licenseEnforcementCron.Start()
+	defer licenseEnforcementCron.Stop()
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -43,3 +88,14 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// trustedProxies splits the comma separated TRUSTED_PROXIES setting
+func trustedProxies(setting string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(setting, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -3,7 +3,11 @@ package middleware
 import (
 	"eservice-backend/models"
 	"eservice-backend/utils"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -198,12 +202,85 @@ func ProfileCompleteMiddleware(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// RateLimitMiddleware implements basic rate limiting
+// rateLimitMaxClients bounds the clients a limiter tracks at once, so a flood of new addresses
+// cannot grow its memory without limit
+const rateLimitMaxClients = 10000
+
+// RateLimitMiddleware allows each client IP at most requests requests per window seconds. The
+// client IP only comes from X-Forwarded-For behind the proxies set with TRUSTED_PROXIES.
+// Counts are kept in memory, so every server instance limits on its own.
 func RateLimitMiddleware(requests int, window int) gin.HandlerFunc {
-	// This is a simplified version - in production, you'd use Redis or similar
+	limiter := &rateLimiter{
+		requests:   requests,
+		window:     time.Duration(window) * time.Second,
+		maxClients: rateLimitMaxClients,
+		clients:    make(map[string]*rateLimitWindow),
+	}
 	return func(c *gin.Context) {
-		// TODO: Implement proper rate limiting using Redis or in-memory store
-		// For now, just pass through
+		if retryAfter, ok := limiter.allow(c.ClientIP(), time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
+
+// rateLimitWindow counts the requests of one client in the current fixed window
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+type rateLimiter struct {
+	mu         sync.Mutex
+	requests   int
+	window     time.Duration
+	maxClients int
+	clients    map[string]*rateLimitWindow
+	lastSweep  time.Time
+}
+
+// allow counts a request from the client and reports whether it is within the limit,
+// or else how long until the client's window ends
+func (l *rateLimiter) allow(client string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop finished windows once per window so the map does not keep every client ever seen
+	if now.Sub(l.lastSweep) >= l.window {
+		for key, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, exists := l.clients[client]
+	if !exists && len(l.clients) >= l.maxClients {
+		l.evictOldest()
+	}
+	if !exists || now.Sub(w.start) >= l.window {
+		l.clients[client] = &rateLimitWindow{start: now, count: 1}
+		return 0, true
+	}
+	if w.count >= l.requests {
+		return w.start.Add(l.window).Sub(now), false
+	}
+	w.count++
+	return 0, true
+}
+
+// evictOldest drops the client whose window started first to make room for a new one
+func (l *rateLimiter) evictOldest() {
+	var oldest string
+	var oldestStart time.Time
+	for key, w := range l.clients {
+		if oldest == "" || w.start.Before(oldestStart) {
+			oldest, oldestStart = key, w.start
+		}
+	}
+	delete(l.clients, oldest)
+}
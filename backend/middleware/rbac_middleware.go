@@ -214,6 +214,8 @@ func WorkflowStateAccess(getCurrentState func(*gin.Context) (string, error)) gin
 				"issued":                true,
 				"expired":               true,
 				"renewed":               true,
+				"withdrawn":             true,
+				"appealed":              true,
 			},
 			"dede_staff": {
 				"accepted":              true,
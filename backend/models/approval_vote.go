@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+type ApprovalDecision string
+
+const (
+	ApprovalDecisionApprove ApprovalDecision = "approve" // อนุมัติ
+	ApprovalDecisionReject  ApprovalDecision = "reject"  // ไม่อนุมัติ (veto)
+)
+
+// ApprovalVote is one approver's decision on a transition that needs several approvals.
+// Votes belong to a round: the flow log entry that moved the request into the approval
+// step, so a request that comes back to the step starts collecting votes afresh. A vote cast
+// under a delegation counts as the delegator's vote.
+type ApprovalVote struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	RequestID   uint             `json:"request_id" gorm:"not null;uniqueIndex:idx_approval_votes_round_voter"`
+	LicenseType string           `json:"license_type" gorm:"not null"`
+	RoundID     uint             `json:"round_id" gorm:"not null;uniqueIndex:idx_approval_votes_round_voter"` // Flow log entry that started the round
+	Action      string           `json:"action" gorm:"not null"`
+	FromStatus  RequestStatus    `json:"from_status" gorm:"not null"`
+	VoterID     uint             `json:"voter_id" gorm:"not null;uniqueIndex:idx_approval_votes_round_voter"`
+	Voter       User             `json:"voter" gorm:"foreignKey:VoterID"`
+	CastByID    *uint            `json:"cast_by_id"` // Delegate who cast the vote on the voter's behalf
+	VoterRole   UserRole         `json:"voter_role" gorm:"not null"`
+	Decision    ApprovalDecision `json:"decision" gorm:"not null"`
+	Comment     string           `json:"comment"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// TableName specifies the table name for the ApprovalVote model
+func (ApprovalVote) TableName() string {
+	return "approval_votes"
+}
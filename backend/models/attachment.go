@@ -19,6 +19,14 @@ const (
 	AttachmentTypeOther        AttachmentType = "other"        // อื่นๆ
 )
 
+type DocumentReviewStatus string
+
+const (
+	DocumentReviewPending  DocumentReviewStatus = "pending"  // รอตรวจสอบ
+	DocumentReviewAccepted DocumentReviewStatus = "accepted" // ผ่านการตรวจสอบ
+	DocumentReviewRejected DocumentReviewStatus = "rejected" // ไม่ผ่านการตรวจสอบ
+)
+
 type Attachment struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
 	FileName     string         `json:"file_name" gorm:"not null"`
@@ -41,6 +49,12 @@ type Attachment struct {
 	Reviewed    bool   `json:"reviewed" gorm:"default:false"`
 	ReviewNotes string `json:"review_notes"`
 
+	// Document checklist of license requests
+	DocumentRequirementID *uint                `json:"document_requirement_id" gorm:"index"` // Checklist item the file is uploaded for
+	ReviewStatus          DocumentReviewStatus `json:"review_status" gorm:"default:'pending'"`
+	ReviewedByID          *uint                `json:"reviewed_by_id"`
+	ReviewedAt            *time.Time           `json:"reviewed_at"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -11,6 +11,8 @@ type AuditReportVersion struct {
 	ID                uint            `json:"id" gorm:"primaryKey"`
 	ReportID          uint            `json:"report_id" gorm:"not null;index"`
 	Report            AuditReport     `json:"report" gorm:"foreignKey:ReportID"`
+	LicenseRequestID  *uint           `json:"license_request_id" gorm:"index"` // Typed license request the report was written for
+	LicenseType       string          `json:"license_type"`                    // 'new', 'renewal', 'extension', 'reduction'
 	VersionNumber     int             `json:"version_number" gorm:"not null"`
 	Title             string          `json:"title" gorm:"not null"`
 	Content           string          `json:"content"`
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// DocumentRequirement is a document applicants must attach to requests of a license type, e.g. a
+// single-line diagram or a land deed. A requirement can be limited to an energy type and to a
+// capacity band; without those it applies to every request of the type.
+type DocumentRequirement struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	Code        string      `json:"code" gorm:"not null;index"` // Stable key of the document, e.g. single_line_diagram
+	Name        string      `json:"name" gorm:"not null"`       // Shown to applicants on the checklist
+	Description string      `json:"description" gorm:"type:text"`
+	LicenseType LicenseType `json:"license_type" gorm:"not null;index"`
+	EnergyType  string      `json:"energy_type"`  // Empty for every energy type
+	MinCapacity *float64    `json:"min_capacity"` // Applies from this capacity, included; nil for no lower bound
+	MaxCapacity *float64    `json:"max_capacity"` // Applies below this capacity; nil for no upper bound
+	SortOrder   int         `json:"sort_order" gorm:"default:0"`
+	IsActive    bool        `json:"is_active" gorm:"default:true;index"`
+	CreatedByID *uint       `json:"created_by_id"`
+	CreatedBy   *User       `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// TableName specifies the table name for the DocumentRequirement model
+func (DocumentRequirement) TableName() string {
+	return "document_requirements"
+}
+
+// AppliesTo checks if the requirement applies to a request of an energy type and capacity
+func (r *DocumentRequirement) AppliesTo(energyType string, capacity float64) bool {
+	return r.MatchesEnergyType(energyType) && r.CoversCapacity(capacity)
+}
+
+// MatchesEnergyType checks if the requirement applies to an energy type
+func (r *DocumentRequirement) MatchesEnergyType(energyType string) bool {
+	return r.EnergyType == "" || strings.EqualFold(r.EnergyType, strings.TrimSpace(energyType))
+}
+
+// CoversCapacity checks if a capacity falls in the band of the requirement
+func (r *DocumentRequirement) CoversCapacity(capacity float64) bool {
+	if r.MinCapacity != nil && capacity < *r.MinCapacity {
+		return false
+	}
+	return r.MaxCapacity == nil || capacity < *r.MaxCapacity
+}
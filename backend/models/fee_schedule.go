@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// FeeSchedule is the fee charged for requests of a license type whose capacity falls in a band.
+// A band includes MinCapacity and excludes MaxCapacity; without MaxCapacity it has no upper bound.
+// Schedules are not edited: a fee change deactivates the schedule and adds a new one, so invoices
+// keep pointing at the fee they were issued with.
+type FeeSchedule struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	LicenseType LicenseType `json:"license_type" gorm:"not null;index"`
+	MinCapacity float64     `json:"min_capacity" gorm:"not null;default:0"`
+	MaxCapacity *float64    `json:"max_capacity"`
+	Amount      float64     `json:"amount" gorm:"type:decimal(12,2);not null"` // Baht
+	Description string      `json:"description"`
+	IsActive    bool        `json:"is_active" gorm:"default:true;index"`
+	CreatedByID *uint       `json:"created_by_id"`
+	CreatedBy   *User       `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// TableName specifies the table name for the FeeSchedule model
+func (FeeSchedule) TableName() string {
+	return "fee_schedules"
+}
+
+// Covers checks if a capacity falls in the band of the schedule
+func (fs *FeeSchedule) Covers(capacity float64) bool {
+	return capacity >= fs.MinCapacity && (fs.MaxCapacity == nil || capacity < *fs.MaxCapacity)
+}
+
+// Overlaps checks if the bands of two schedules share a capacity
+func (fs *FeeSchedule) Overlaps(other *FeeSchedule) bool {
+	belowOther := fs.MaxCapacity != nil && *fs.MaxCapacity <= other.MinCapacity
+	aboveOther := other.MaxCapacity != nil && *other.MaxCapacity <= fs.MinCapacity
+	return !belowOther && !aboveOther
+}
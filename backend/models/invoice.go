@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+type InvoiceStatus string
+
+const (
+	InvoiceStatusPending   InvoiceStatus = "pending"   // รอชำระ
+	InvoiceStatusPaid      InvoiceStatus = "paid"      // ชำระแล้ว
+	InvoiceStatusCancelled InvoiceStatus = "cancelled" // ยกเลิก
+)
+
+// Invoice is the fee a license request is charged, issued when the request enters a workflow
+// state that issues invoices. A request has at most one invoice that is pending or paid.
+type Invoice struct {
+	ID                uint            `json:"id" gorm:"primaryKey"`
+	InvoiceNumber     string          `json:"invoice_number" gorm:"uniqueIndex;not null"`
+	LicenseRequestID  uint            `json:"license_request_id" gorm:"not null;index"`
+	LicenseRequest    *LicenseRequest `json:"license_request,omitempty" gorm:"foreignKey:LicenseRequestID"`
+	FeeScheduleID     uint            `json:"fee_schedule_id" gorm:"not null"`
+	FeeSchedule       *FeeSchedule    `json:"fee_schedule,omitempty" gorm:"foreignKey:FeeScheduleID"`
+	Capacity          float64         `json:"capacity"` // Capacity the fee was assessed on
+	Amount            float64         `json:"amount" gorm:"type:decimal(12,2);not null"`
+	Currency          string          `json:"currency" gorm:"not null;default:'THB'"`
+	Description       string          `json:"description"`
+	Status            InvoiceStatus   `json:"status" gorm:"not null;default:'pending';index"`
+	DueDate           *time.Time      `json:"due_date"`
+	Provider          string          `json:"provider" gorm:"not null"`         // Payment provider the invoice is paid through
+	ProviderReference string          `json:"provider_reference"`               // Reference of the charge at the provider
+	PaymentPayload    string          `json:"payment_payload" gorm:"type:text"` // What the payer needs, e.g. a PromptPay QR payload
+	IssuedAt          time.Time       `json:"issued_at" gorm:"not null"`
+	PaidAt            *time.Time      `json:"paid_at"`
+	ReceiptNumber     string          `json:"receipt_number" gorm:"index"`
+	ReceiptID         *uint           `json:"receipt_id"` // Attachment holding the receipt PDF
+	CancelledAt       *time.Time      `json:"cancelled_at"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Invoice model
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// IsPending checks if the invoice waits for payment
+func (i *Invoice) IsPending() bool {
+	return i.Status == InvoiceStatusPending
+}
+
+// IsPaid checks if the invoice has been paid
+func (i *Invoice) IsPaid() bool {
+	return i.Status == InvoiceStatusPaid
+}
+
+// Payment is a confirmed payment of an invoice
+type Payment struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	InvoiceID     uint      `json:"invoice_id" gorm:"not null;index"`
+	Provider      string    `json:"provider" gorm:"not null;uniqueIndex:idx_payments_provider_reference"`
+	Reference     string    `json:"reference" gorm:"not null;uniqueIndex:idx_payments_provider_reference"` // Transaction reference at the provider or bank
+	Amount        float64   `json:"amount" gorm:"type:decimal(12,2);not null"`
+	PaidAt        time.Time `json:"paid_at" gorm:"not null"`
+	ConfirmedByID *uint     `json:"confirmed_by_id"` // Officer who confirmed the payment; nil when the provider did
+	ConfirmedBy   *User     `json:"confirmed_by,omitempty" gorm:"foreignKey:ConfirmedByID"`
+	Notes         string    `json:"notes" gorm:"type:text"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the Payment model
+func (Payment) TableName() string {
+	return "payments"
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LicenseStatus string
+
+const (
+	LicenseStatusActive    LicenseStatus = "active"    // มีผลใช้บังคับ
+	LicenseStatusExpired   LicenseStatus = "expired"   // หมดอายุ
+	LicenseStatusCancelled LicenseStatus = "cancelled" // เลิกใบอนุญาต
+	LicenseStatusSuspended LicenseStatus = "suspended" // พักใช้ใบอนุญาต
+	LicenseStatusRevoked   LicenseStatus = "revoked"   // เพิกถอนใบอนุญาต
+)
+
+// DefaultLicenseValidityYears is how long a license issued on approval of a new request is valid
+const DefaultLicenseValidityYears = 5
+
+// License is a license issued when a new license request is approved. Renewal, extension, reduction
+// and modify requests refer to it and, once approved, change its validity, capacity or particulars;
+// an approved cancel request retires it. Enforcement cases suspend or revoke it.
+type License struct {
+	ID                   uint          `json:"id" gorm:"primaryKey"`
+	LicenseNumber        string        `json:"license_number" gorm:"uniqueIndex;not null"`
+	HolderUserID         uint          `json:"holder_user_id" gorm:"not null;index"`
+	HolderUser           *User         `json:"holder_user,omitempty" gorm:"foreignKey:HolderUserID"`
+	HolderCorporateID    *uint         `json:"holder_corporate_id" gorm:"index"` // Set when the license is held by a corporate
+	HolderCorporate      *Corporate    `json:"holder_corporate,omitempty" gorm:"foreignKey:HolderCorporateID"`
+	ProjectName          string        `json:"project_name" gorm:"not null"`
+	Location             string        `json:"location"`
+	Province             string        `json:"province"`
+	EnergyType           string        `json:"energy_type"`
+	Capacity             float64       `json:"capacity"`
+	CapacityUnit         string        `json:"capacity_unit"`
+	ContactPerson        string        `json:"contact_person"`
+	ContactPhone         string        `json:"contact_phone"`
+	ContactEmail         string        `json:"contact_email"`
+	Equipment            string        `json:"equipment"`
+	Status               LicenseStatus `json:"status" gorm:"not null;default:'active';index"`
+	IssuedAt             time.Time     `json:"issued_at" gorm:"not null"`
+	ValidFrom            time.Time     `json:"valid_from" gorm:"not null"`
+	ValidUntil           time.Time     `json:"valid_until" gorm:"not null;index"`
+	IssuedRequestID      *uint         `json:"issued_request_id"`       // Request the license was issued on; nil for registered existing licenses
+	LastAmendedRequestID *uint         `json:"last_amended_request_id"` // Latest approved request that changed the license
+	AmendedAt            *time.Time    `json:"amended_at"`
+	CancelledAt          *time.Time    `json:"cancelled_at"`                  // Set when an approved cancel request retired the license
+	VerificationToken    string        `json:"-" gorm:"uniqueIndex;not null"` // Opaque token in the certificate QR code
+	CertificateID        *uint         `json:"certificate_id"`                // Attachment holding the current certificate PDF
+	CreatedAt            time.Time     `json:"created_at"`
+	UpdatedAt            time.Time     `json:"updated_at"`
+}
+
+// TableName specifies the table name for the License model
+func (License) TableName() string {
+	return "licenses"
+}
+
+// BeforeCreate gives the license the verification token printed in its certificate QR code
+func (l *License) BeforeCreate(tx *gorm.DB) error {
+	if l.VerificationToken != "" {
+		return nil
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	l.VerificationToken = hex.EncodeToString(token)
+	return nil
+}
+
+// IsActive checks if the license is in force
+func (l *License) IsActive() bool {
+	return l.Status == LicenseStatusActive
+}
+
+// CurrentStatus returns the status of the license at a time, treating an active license past its validity as expired
+func (l *License) CurrentStatus(now time.Time) LicenseStatus {
+	if l.Status == LicenseStatusActive && now.After(l.ValidUntil) {
+		return LicenseStatusExpired
+	}
+	return l.Status
+}
+
+// HolderName returns the name printed for the holder: the corporate when the license is held by one, else the user.
+// HolderUser and HolderCorporate must be preloaded.
+func (l *License) HolderName() string {
+	if l.HolderCorporate != nil {
+		return l.HolderCorporate.CorporateName
+	}
+	if l.HolderUser != nil {
+		return l.HolderUser.FullName
+	}
+	return ""
+}
+
+// IsHeldBy reports whether a user holds the license, directly or through one of their corporates
+func (l *License) IsHeldBy(userID uint, corporateIDs []uint) bool {
+	if l.HolderUserID == userID {
+		return true
+	}
+	if l.HolderCorporateID == nil {
+		return false
+	}
+	for _, id := range corporateIDs {
+		if id == *l.HolderCorporateID {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckChange reports why a renewal, extension, reduction, modify or cancel request cannot be applied to the license
+func (l *License) CheckChange(lr *LicenseRequest) error {
+	switch lr.LicenseType {
+	case LicenseTypeRenew:
+		if l.Status != LicenseStatusActive && l.Status != LicenseStatusExpired {
+			return fmt.Errorf("license %s is %s and cannot be renewed", l.LicenseNumber, l.Status)
+		}
+		payload, err := lr.DecodePayload()
+		if err != nil {
+			return err
+		}
+		renewal := payload.(*RenewalLicensePayload)
+		if !renewal.RequestedExpiryDate.After(l.ValidUntil) {
+			return fmt.Errorf("requested expiry date must be after %s, when license %s expires", l.ValidUntil.Format("2006-01-02"), l.LicenseNumber)
+		}
+	case LicenseTypeExpand:
+		if !l.IsActive() {
+			return fmt.Errorf("license %s is %s and cannot be extended", l.LicenseNumber, l.Status)
+		}
+		if lr.RequestedCapacity <= l.Capacity {
+			return fmt.Errorf("requested capacity %.2f must be more than the licensed capacity %.2f", lr.RequestedCapacity, l.Capacity)
+		}
+	case LicenseTypeReduce:
+		if !l.IsActive() {
+			return fmt.Errorf("license %s is %s and cannot be reduced", l.LicenseNumber, l.Status)
+		}
+		if lr.RequestedCapacity <= 0 || lr.RequestedCapacity >= l.Capacity {
+			return fmt.Errorf("requested capacity %.2f must be more than 0 and less than the licensed capacity %.2f", lr.RequestedCapacity, l.Capacity)
+		}
+	case LicenseTypeModify:
+		if !l.IsActive() {
+			return fmt.Errorf("license %s is %s and cannot be modified", l.LicenseNumber, l.Status)
+		}
+		payload, err := lr.DecodePayload()
+		if err != nil {
+			return err
+		}
+		if !payload.(*ModifyLicensePayload).HasChanges() {
+			return fmt.Errorf("modify request for license %s amends no particulars", l.LicenseNumber)
+		}
+	case LicenseTypeCancel:
+		if !l.IsActive() {
+			return fmt.Errorf("license %s is %s and cannot be cancelled", l.LicenseNumber, l.Status)
+		}
+	}
+	return nil
+}
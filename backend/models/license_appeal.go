@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+type AppealStatus string
+
+const (
+	AppealStatusPending   AppealStatus = "pending"   // รอพิจารณา
+	AppealStatusGranted   AppealStatus = "granted"   // รับอุทธรณ์
+	AppealStatusDismissed AppealStatus = "dismissed" // ยกอุทธรณ์
+	AppealStatusWithdrawn AppealStatus = "withdrawn" // ถอนอุทธรณ์
+)
+
+// AppealAttachmentEntityType is the attachment entity type of the files filed with an appeal
+const AppealAttachmentEntityType = "license_appeal"
+
+// LicenseAppeal is an applicant's appeal against the rejection of a request. It is routed to a
+// DEDE Head, or an admin, other than the officer who rejected the request. Granting it sends the
+// request back into review; dismissing it makes the rejection final.
+type LicenseAppeal struct {
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	RequestID       uint          `json:"request_id" gorm:"not null;index"`
+	LicenseType     string        `json:"license_type" gorm:"not null"`
+	Status          AppealStatus  `json:"status" gorm:"not null;default:'pending';index"`
+	Grounds         string        `json:"grounds" gorm:"type:text;not null"`
+	RejectedFrom    RequestStatus `json:"rejected_from" gorm:"not null"` // Status the request was rejected from
+	RejectedByID    *uint         `json:"rejected_by_id"`                // Officer who rejected the request; may not decide the appeal
+	RejectedBy      *User         `json:"rejected_by,omitempty" gorm:"foreignKey:RejectedByID"`
+	RejectedForID   *uint         `json:"rejected_for_id"` // Delegator the rejecter acted for; may not decide the appeal either
+	RejectionReason string        `json:"rejection_reason"`
+	RejectedAt      time.Time     `json:"rejected_at" gorm:"not null"`
+	FiledByID       uint          `json:"filed_by_id" gorm:"not null"`
+	FiledBy         *User         `json:"filed_by,omitempty" gorm:"foreignKey:FiledByID"`
+	ReviewerID      *uint         `json:"reviewer_id" gorm:"index"` // Officer the appeal was routed to
+	Reviewer        *User         `json:"reviewer,omitempty" gorm:"foreignKey:ReviewerID"`
+	DecidedByID     *uint         `json:"decided_by_id"`
+	DecidedBy       *User         `json:"decided_by,omitempty" gorm:"foreignKey:DecidedByID"`
+	OnBehalfOfID    *uint         `json:"on_behalf_of_id"` // Delegator the decider acted for
+	DecisionNotes   string        `json:"decision_notes"`
+	DecidedAt       *time.Time    `json:"decided_at"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// TableName specifies the table name for the LicenseAppeal model
+func (LicenseAppeal) TableName() string {
+	return "license_appeals"
+}
+
+// IsPending checks if the appeal still waits for a decision
+func (la *LicenseAppeal) IsPending() bool {
+	return la.Status == AppealStatusPending
+}
+
+// WasRejectedBy reports whether a user, or the delegator they act for, took part in the rejection
+func (la *LicenseAppeal) WasRejectedBy(userID uint, onBehalfOfID *uint) bool {
+	for _, rejecter := range []*uint{la.RejectedByID, la.RejectedForID} {
+		if rejecter == nil {
+			continue
+		}
+		if *rejecter == userID || (onBehalfOfID != nil && *rejecter == *onBehalfOfID) {
+			return true
+		}
+	}
+	return false
+}
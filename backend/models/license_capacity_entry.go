@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrCapacityLedgerAppendOnly is returned when a capacity ledger entry is updated or deleted
+var ErrCapacityLedgerAppendOnly = errors.New("capacity ledger entries cannot be changed")
+
+type CapacityEntryType string
+
+const (
+	CapacityEntryIssued     CapacityEntryType = "issued"     // ออกใบอนุญาต
+	CapacityEntryRegistered CapacityEntryType = "registered" // ลงทะเบียนใบอนุญาตเดิม
+	CapacityEntryExtension  CapacityEntryType = "extension"  // ขยายการผลิต
+	CapacityEntryReduction  CapacityEntryType = "reduction"  // ลดการผลิต
+	CapacityEntryCancelled  CapacityEntryType = "cancelled"  // เลิกใบอนุญาต
+	CapacityEntryRevoked    CapacityEntryType = "revoked"    // เพิกถอนใบอนุญาต
+)
+
+// LicenseCapacityEntry is an entry in the append-only capacity ledger of a license. The latest
+// entry holds the licensed capacity; earlier entries give the capacity history.
+type LicenseCapacityEntry struct {
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	LicenseID        uint              `json:"license_id" gorm:"not null;index:idx_license_capacity_entries_license"`
+	EntryType        CapacityEntryType `json:"entry_type" gorm:"not null"`
+	RequestID        *uint             `json:"request_id" gorm:"index"` // Approved request that changed the capacity; nil for registered or revoked licenses
+	PreviousCapacity float64           `json:"previous_capacity"`
+	Capacity         float64           `json:"capacity" gorm:"not null"`
+	CapacityUnit     string            `json:"capacity_unit"`
+	RecordedAt       time.Time         `json:"recorded_at" gorm:"not null;index:idx_license_capacity_entries_license"`
+	CreatedAt        time.Time         `json:"created_at"`
+}
+
+// TableName specifies the table name for the LicenseCapacityEntry model
+func (LicenseCapacityEntry) TableName() string {
+	return "license_capacity_entries"
+}
+
+// BeforeUpdate rejects changes to a stored ledger entry
+func (e *LicenseCapacityEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrCapacityLedgerAppendOnly
+}
+
+// BeforeDelete rejects deleting a stored ledger entry
+func (e *LicenseCapacityEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrCapacityLedgerAppendOnly
+}
+
+// Change returns how much the entry changed the capacity by
+func (e *LicenseCapacityEntry) Change() float64 {
+	return e.Capacity - e.PreviousCapacity
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+)
+
+type EnforcementAction string
+
+const (
+	EnforcementActionSuspend EnforcementAction = "suspend" // พักใช้ใบอนุญาต
+	EnforcementActionRevoke  EnforcementAction = "revoke"  // เพิกถอนใบอนุญาต
+)
+
+type EnforcementStatus string
+
+const (
+	EnforcementStatusNoticeIssued    EnforcementStatus = "notice_issued"    // แจ้งให้ชี้แจง
+	EnforcementStatusResponded       EnforcementStatus = "responded"        // ผู้รับใบอนุญาตชี้แจงแล้ว
+	EnforcementStatusResponseOverdue EnforcementStatus = "response_overdue" // พ้นกำหนดชี้แจง
+	EnforcementStatusSuspended       EnforcementStatus = "suspended"        // พักใช้ใบอนุญาต
+	EnforcementStatusRevoked         EnforcementStatus = "revoked"          // เพิกถอนใบอนุญาต
+	EnforcementStatusDismissed       EnforcementStatus = "dismissed"        // ยุติเรื่อง
+	EnforcementStatusReinstated      EnforcementStatus = "reinstated"       // คืนสิทธิ์ใบอนุญาต
+)
+
+// Attachment entity types of the files issued with a notice and filed with the holder's response
+const (
+	EnforcementNoticeAttachmentEntityType   = "license_enforcement_notice"
+	EnforcementResponseAttachmentEntityType = "license_enforcement_response"
+)
+
+// LicenseEnforcement is a DEDE-initiated case to suspend or revoke an issued license. The holder is
+// given notice and a deadline to respond, then a DEDE Head decides. A suspension lasts until the
+// license is reinstated, by a Head or when the suspension period ends.
+type LicenseEnforcement struct {
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	CaseNumber       string            `json:"case_number" gorm:"uniqueIndex;not null"`
+	LicenseID        uint              `json:"license_id" gorm:"not null;index"`
+	License          *License          `json:"license,omitempty" gorm:"foreignKey:LicenseID"`
+	ProposedAction   EnforcementAction `json:"proposed_action" gorm:"not null"`
+	Status           EnforcementStatus `json:"status" gorm:"not null;default:'notice_issued';index"`
+	Grounds          string            `json:"grounds" gorm:"type:text;not null"`
+	ResponseDeadline time.Time         `json:"response_deadline" gorm:"not null;index"`
+	IssuedByID       uint              `json:"issued_by_id" gorm:"not null"`
+	IssuedBy         *User             `json:"issued_by,omitempty" gorm:"foreignKey:IssuedByID"`
+	Response         string            `json:"response" gorm:"type:text"`
+	RespondedByID    *uint             `json:"responded_by_id"`
+	RespondedBy      *User             `json:"responded_by,omitempty" gorm:"foreignKey:RespondedByID"`
+	RespondedAt      *time.Time        `json:"responded_at"`
+	Decision         EnforcementAction `json:"decision"` // Action the Head took; empty when the case was dismissed or is undecided
+	DecisionNotes    string            `json:"decision_notes"`
+	DecidedByID      *uint             `json:"decided_by_id"`
+	DecidedBy        *User             `json:"decided_by,omitempty" gorm:"foreignKey:DecidedByID"`
+	DecidedAt        *time.Time        `json:"decided_at"`
+	SuspendedUntil   *time.Time        `json:"suspended_until"` // End of a suspension; nil until a Head reinstates the license
+	PreviousStatus   LicenseStatus     `json:"previous_status"` // License status before the decision, restored on reinstatement
+	ReinstatedAt     *time.Time        `json:"reinstated_at"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// TableName specifies the table name for the LicenseEnforcement model
+func (LicenseEnforcement) TableName() string {
+	return "license_enforcements"
+}
+
+// IsOpen checks if the case still waits for the holder or for a decision
+func (le *LicenseEnforcement) IsOpen() bool {
+	return le.Status == EnforcementStatusNoticeIssued ||
+		le.Status == EnforcementStatusResponded ||
+		le.Status == EnforcementStatusResponseOverdue
+}
+
+// CanRespond reports whether the holder may still respond to the notice
+func (le *LicenseEnforcement) CanRespond(now time.Time) bool {
+	return le.Status == EnforcementStatusNoticeIssued && !now.After(le.ResponseDeadline)
+}
+
+// CanDecide reports whether a Head may decide the case: once the holder responded or the deadline passed
+func (le *LicenseEnforcement) CanDecide(now time.Time) bool {
+	switch le.Status {
+	case EnforcementStatusResponded, EnforcementStatusResponseOverdue:
+		return true
+	case EnforcementStatusNoticeIssued:
+		return now.After(le.ResponseDeadline)
+	}
+	return false
+}
+
+type EnforcementEventType string
+
+const (
+	EnforcementEventNoticeIssued    EnforcementEventType = "notice_issued"
+	EnforcementEventResponded       EnforcementEventType = "responded"
+	EnforcementEventResponseOverdue EnforcementEventType = "response_overdue"
+	EnforcementEventDecided         EnforcementEventType = "decided"
+	EnforcementEventReinstated      EnforcementEventType = "reinstated"
+)
+
+// LicenseEnforcementEvent is an entry in the history of an enforcement case
+type LicenseEnforcementEvent struct {
+	ID                  uint                 `json:"id" gorm:"primaryKey"`
+	EnforcementID       uint                 `json:"enforcement_id" gorm:"not null;index"`
+	EventType           EnforcementEventType `json:"event_type" gorm:"not null"`
+	FromStatus          *EnforcementStatus   `json:"from_status"`
+	ToStatus            EnforcementStatus    `json:"to_status" gorm:"not null"`
+	LicenseStatusBefore LicenseStatus        `json:"license_status_before"`
+	LicenseStatusAfter  LicenseStatus        `json:"license_status_after"`
+	ActorID             *uint                `json:"actor_id"` // nil when the system acted, e.g. at the response deadline
+	Actor               *User                `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	Notes               string               `json:"notes" gorm:"type:text"`
+	CreatedAt           time.Time            `json:"created_at"`
+}
+
+// TableName specifies the table name for the LicenseEnforcementEvent model
+func (LicenseEnforcementEvent) TableName() string {
+	return "license_enforcement_events"
+}
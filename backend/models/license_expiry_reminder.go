@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// LicenseExpiryReminder records a reminder sent to the holder of a license before, or when, it
+// expires. Reminders are keyed by the expiry they warn about, so a renewed license starts afresh.
+type LicenseExpiryReminder struct {
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	LicenseID        uint            `json:"license_id" gorm:"not null;uniqueIndex:idx_license_expiry_reminders_tier"`
+	License          *License        `json:"license,omitempty" gorm:"foreignKey:LicenseID"`
+	ValidUntil       time.Time       `json:"valid_until" gorm:"not null;uniqueIndex:idx_license_expiry_reminders_tier"`
+	DaysBefore       int             `json:"days_before" gorm:"not null;uniqueIndex:idx_license_expiry_reminders_tier"` // Reminder tier; 0 is the notice that the license has expired
+	RecipientID      uint            `json:"recipient_id" gorm:"not null;index"`
+	RenewalRequestID *uint           `json:"renewal_request_id"` // Renewal draft the reminder links to
+	RenewalRequest   *LicenseRequest `json:"renewal_request,omitempty" gorm:"foreignKey:RenewalRequestID"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// TableName specifies the table name for the LicenseExpiryReminder model
+func (LicenseExpiryReminder) TableName() string {
+	return "license_expiry_reminders"
+}
+
+// IsExpiryNotice checks if the reminder tells the holder that the license has expired
+func (r *LicenseExpiryReminder) IsExpiryNotice() bool {
+	return r.DaysBefore == 0
+}
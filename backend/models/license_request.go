@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,15 +9,48 @@ import (
 
 type LicenseType string
 
+// License type values match the workflow definition license types
 const (
-	LicenseTypeNew    LicenseType = "new"    // ขอรับใบอนุญาต
-	LicenseTypeRenew  LicenseType = "renew"  // ขอต่ออายุใบอนุญาต
-	LicenseTypeExpand LicenseType = "expand" // ขอขยายการผลิต
-	LicenseTypeReduce LicenseType = "reduce" // ขอลดการผลิต
-	LicenseTypeModify LicenseType = "modify" // ขอแก้ไข
-	LicenseTypeCancel LicenseType = "cancel" // ขอเลิก
+	LicenseTypeNew    LicenseType = "new"       // ขอรับใบอนุญาต
+	LicenseTypeRenew  LicenseType = "renewal"   // ขอต่ออายุใบอนุญาต
+	LicenseTypeExpand LicenseType = "extension" // ขอขยายการผลิต
+	LicenseTypeReduce LicenseType = "reduction" // ขอลดการผลิต
+	LicenseTypeModify LicenseType = "modify"    // ขอแก้ไข
+	LicenseTypeCancel LicenseType = "cancel"    // ขอเลิก
 )
 
+// LegacyLicenseTypes maps the values license_requests used for license types before they were
+// aligned with the workflow onto the current ones. Clients may still send them.
+var LegacyLicenseTypes = map[string]LicenseType{
+	"renew":  LicenseTypeRenew,
+	"expand": LicenseTypeExpand,
+	"reduce": LicenseTypeReduce,
+}
+
+// ParseLicenseType returns the license type a client sent, accepting the legacy values
+func ParseLicenseType(value string) LicenseType {
+	if licenseType, ok := LegacyLicenseTypes[value]; ok {
+		return licenseType
+	}
+	return LicenseType(value)
+}
+
+// UnmarshalJSON accepts the legacy values, so request bodies using them keep binding
+func (t *LicenseType) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*t = ParseLicenseType(value)
+	return nil
+}
+
+// RefersToLicense reports whether requests of the type change a license that has already been issued
+func (t LicenseType) RefersToLicense() bool {
+	return t == LicenseTypeRenew || t == LicenseTypeExpand || t == LicenseTypeReduce ||
+		t == LicenseTypeModify || t == LicenseTypeCancel
+}
+
 type RequestStatus string
 
 const (
@@ -35,20 +69,42 @@ const (
 	StatusRejectedFinal  RequestStatus = "rejected_final"  // ปฏิเสธสุดท้าย
 	StatusReturned       RequestStatus = "returned"        // ตีเอกสารกลับไปแก้ไข
 	StatusForwarded      RequestStatus = "forwarded"       // ส่งต่อให้ DEDE Admin
+	StatusWithdrawn      RequestStatus = "withdrawn"       // ถอนคำขอ
+	StatusAppealed       RequestStatus = "appealed"        // ยื่นอุทธรณ์
+	StatusPaymentPending RequestStatus = "payment_pending" // รอชำระค่าธรรมเนียม
 )
 
+// LicenseRequest is the workflow-bearing core shared by every license type.
+// Fields specific to one license type live in Payload (see license_request_payload.go).
 type LicenseRequest struct {
 	ID                uint           `json:"id" gorm:"primaryKey"`
 	UserID            uint           `json:"user_id" gorm:"not null;index"`
 	User              User           `json:"user" gorm:"foreignKey:UserID"`
 	RequestNumber     string         `json:"request_number" gorm:"uniqueIndex;not null"`
-	LicenseType       LicenseType    `json:"license_type" gorm:"not null"`
+	LicenseType       LicenseType    `json:"license_type" gorm:"not null;index"`
 	Status            RequestStatus  `json:"status" gorm:"not null;default:'draft'"`
 	Title             string         `json:"title" gorm:"not null"`
 	Description       string         `json:"description"`
+	LicenseNumber     string         `json:"license_number"`          // Existing license the request refers to, empty for new licenses until issued
+	LicenseID         *uint          `json:"license_id" gorm:"index"` // Issued license the request refers to, or was issued on approval
+	License           *License       `json:"license,omitempty" gorm:"foreignKey:LicenseID"`
+	CorporateID       *uint          `json:"corporate_id" gorm:"index"` // Corporate the applicant files for; it holds the issued license
+	Corporate         *Corporate     `json:"corporate,omitempty" gorm:"foreignKey:CorporateID"`
+	LicenseValidFrom  *time.Time     `json:"license_valid_from"`  // Validity of the license as it stood after the request was approved
+	LicenseValidUntil *time.Time     `json:"license_valid_until"` // Watched for expiry through the license registry
 	CurrentCapacity   float64        `json:"current_capacity"`
 	RequestedCapacity float64        `json:"requested_capacity"`
 	Location          string         `json:"location"`
+	ContactPerson     string         `json:"contact_person"`
+	ContactPhone      string         `json:"contact_phone"`
+	ContactEmail      string         `json:"contact_email"`
+	Payload           string         `json:"-" gorm:"type:text;not null;default:'{}'"`   // JSON encoded type-specific payload
+	TechnicalData     string         `json:"-" gorm:"type:text;not null;default:'{}'"`   // JSON technical data checked against the payload schema of the request
+	TechnicalSchemaID *uint          `json:"technical_schema_id"`                        // Payload schema version the technical data was last saved against
+	WorkflowVersion   int            `json:"workflow_version" gorm:"not null;default:0"` // Pinned workflow definition version
+	Version           int            `json:"version" gorm:"not null;default:1"`          // Optimistic concurrency version, bumped on every update
+	LegacyTable       string         `json:"-" gorm:"index:idx_license_requests_legacy"` // Source table of a migrated request
+	LegacyID          *uint          `json:"-" gorm:"index:idx_license_requests_legacy"` // Source row of a migrated request
 	InspectorID       *uint          `json:"inspector_id" gorm:"index"`
 	Inspector         *User          `json:"inspector" gorm:"foreignKey:InspectorID"`
 	AssignedByID      *uint          `json:"assigned_by_id" gorm:"index"`
@@ -114,3 +170,13 @@ func (lr *LicenseRequest) CanBeInspected() bool {
 func (lr *LicenseRequest) CanBeApproved() bool {
 	return lr.Status == StatusInspectionDone
 }
+
+// GetVersion returns the optimistic concurrency version
+func (lr *LicenseRequest) GetVersion() int {
+	return lr.Version
+}
+
+// SetVersion sets the optimistic concurrency version
+func (lr *LicenseRequest) SetVersion(version int) {
+	lr.Version = version
+}
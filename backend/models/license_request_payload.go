@@ -0,0 +1,203 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NewLicensePayload holds the fields specific to a new license request
+type NewLicensePayload struct {
+	LicenseCategory   string    `json:"license_category"`
+	ProjectAddress    string    `json:"project_address"`
+	Province          string    `json:"province"`
+	District          string    `json:"district"`
+	Subdistrict       string    `json:"subdistrict"`
+	PostalCode        string    `json:"postal_code"`
+	EnergyType        string    `json:"energy_type"`
+	CapacityUnit      string    `json:"capacity_unit"`
+	ExpectedStartDate time.Time `json:"expected_start_date"`
+}
+
+// Location formats the project address of a new license request
+func (p NewLicensePayload) Location() string {
+	return p.ProjectAddress + ", " + p.District + ", " + p.Subdistrict + ", " + p.Province + " " + p.PostalCode
+}
+
+// RenewalLicensePayload holds the fields specific to a renewal license request
+type RenewalLicensePayload struct {
+	LicenseCategory       string    `json:"license_category"`
+	ProjectAddress        string    `json:"project_address"`
+	CurrentCapacityUnit   string    `json:"current_capacity_unit"`
+	RequestedCapacityUnit string    `json:"requested_capacity_unit"`
+	ExpiryDate            time.Time `json:"expiry_date"`
+	RequestedExpiryDate   time.Time `json:"requested_expiry_date"`
+	Reason                string    `json:"reason"`
+}
+
+// ExtensionLicensePayload holds the fields specific to an extension license request
+type ExtensionLicensePayload struct {
+	LicenseCategory       string    `json:"license_category"`
+	CurrentCapacityUnit   string    `json:"current_capacity_unit"`
+	RequestedCapacityUnit string    `json:"requested_capacity_unit"`
+	ExtensionReason       string    `json:"extension_reason"`
+	ExpectedStartDate     time.Time `json:"expected_start_date"`
+}
+
+// ReductionLicensePayload holds the fields specific to a reduction license request
+type ReductionLicensePayload struct {
+	LicenseCategory       string    `json:"license_category"`
+	CurrentCapacityUnit   string    `json:"current_capacity_unit"`
+	RequestedCapacityUnit string    `json:"requested_capacity_unit"`
+	ReductionReason       string    `json:"reduction_reason"`
+	ExpectedStartDate     time.Time `json:"expected_start_date"`
+}
+
+// ModifyLicensePayload holds the particulars a modify request amends. Empty fields leave the
+// license particular unchanged.
+type ModifyLicensePayload struct {
+	LicenseCategory    string `json:"license_category"`
+	ProjectAddress     string `json:"project_address"`
+	Province           string `json:"province"`
+	District           string `json:"district"`
+	Subdistrict        string `json:"subdistrict"`
+	PostalCode         string `json:"postal_code"`
+	ContactPerson      string `json:"contact_person"`
+	ContactPhone       string `json:"contact_phone"`
+	ContactEmail       string `json:"contact_email"`
+	Equipment          string `json:"equipment"`
+	ModificationReason string `json:"modification_reason"`
+}
+
+// Location formats the amended project address, or returns "" when the address is not amended
+func (p ModifyLicensePayload) Location() string {
+	if p.ProjectAddress == "" {
+		return ""
+	}
+	parts := []string{p.ProjectAddress}
+	for _, part := range []string{p.District, p.Subdistrict} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	location := strings.Join(parts, ", ")
+	if p.Province != "" || p.PostalCode != "" {
+		location += ", " + strings.TrimSpace(p.Province+" "+p.PostalCode)
+	}
+	return location
+}
+
+// HasChanges reports whether the request amends any license particular
+func (p ModifyLicensePayload) HasChanges() bool {
+	return p.ProjectAddress != "" || p.Province != "" || p.ContactPerson != "" ||
+		p.ContactPhone != "" || p.ContactEmail != "" || p.Equipment != ""
+}
+
+// CancelLicensePayload holds the fields specific to a cancel license request
+type CancelLicensePayload struct {
+	LicenseCategory    string    `json:"license_category"`
+	CancellationReason string    `json:"cancellation_reason"`
+	LastOperationDate  time.Time `json:"last_operation_date"` // When the holder stopped or will stop operating under the license
+}
+
+// NewPayload returns an empty payload for a license type, or nil if the type has no payload
+func NewPayload(licenseType LicenseType) interface{} {
+	switch licenseType {
+	case LicenseTypeNew:
+		return &NewLicensePayload{}
+	case LicenseTypeRenew:
+		return &RenewalLicensePayload{}
+	case LicenseTypeExpand:
+		return &ExtensionLicensePayload{}
+	case LicenseTypeReduce:
+		return &ReductionLicensePayload{}
+	case LicenseTypeModify:
+		return &ModifyLicensePayload{}
+	case LicenseTypeCancel:
+		return &CancelLicensePayload{}
+	default:
+		return nil
+	}
+}
+
+// SetPayload encodes the type-specific payload of the request
+func (lr *LicenseRequest) SetPayload(payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", lr.LicenseType, err)
+	}
+	lr.Payload = string(data)
+	return nil
+}
+
+// DecodePayload decodes the payload into the struct for the request's license type.
+// Types without a payload struct decode into a generic map.
+func (lr *LicenseRequest) DecodePayload() (interface{}, error) {
+	payload := NewPayload(lr.LicenseType)
+	if payload == nil {
+		payload = &map[string]interface{}{}
+	}
+	if lr.Payload == "" {
+		return payload, nil
+	}
+	if err := json.Unmarshal([]byte(lr.Payload), payload); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", lr.LicenseType, err)
+	}
+	return payload, nil
+}
+
+// PayloadFields returns the payload as a map, e.g. for merging into list responses
+func (lr *LicenseRequest) PayloadFields() map[string]interface{} {
+	fields := map[string]interface{}{}
+	if lr.Payload != "" {
+		_ = json.Unmarshal([]byte(lr.Payload), &fields)
+	}
+	return fields
+}
+
+// TechnicalFields decodes the technical data, which must be a JSON object. Unreadable data is an
+// error rather than an empty object, which could pass a schema without required fields.
+func (lr *LicenseRequest) TechnicalFields() (map[string]interface{}, error) {
+	if lr.TechnicalData == "" {
+		return map[string]interface{}{}, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(lr.TechnicalData), &fields); err != nil {
+		return nil, fmt.Errorf("invalid technical data of request %s: %w", lr.RequestNumber, err)
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("invalid technical data of request %s: not a JSON object", lr.RequestNumber)
+	}
+	return fields, nil
+}
+
+// MergePayload overlays fields onto the current payload. Fields are decoded into the
+// payload struct of the request's license type, so unknown or mistyped fields are rejected.
+func (lr *LicenseRequest) MergePayload(fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	payload, err := lr.DecodePayload()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %w", lr.LicenseType, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if NewPayload(lr.LicenseType) != nil {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(payload); err != nil {
+		return fmt.Errorf("invalid %s payload: %w", lr.LicenseType, err)
+	}
+
+	return lr.SetPayload(payload)
+}
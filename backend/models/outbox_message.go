@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+type OutboxChannel string
+
+const (
+	OutboxChannelNotification OutboxChannel = "notification" // in-app notification
+	OutboxChannelEmail        OutboxChannel = "email"        // email via SMTP
+	OutboxChannelWebSocket    OutboxChannel = "websocket"    // real-time push to connected clients
+	OutboxChannelCertificate  OutboxChannel = "certificate"  // license certificate PDF rendering
+	OutboxChannelReceipt      OutboxChannel = "receipt"      // fee receipt PDF rendering
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending    OutboxStatus = "pending"
+	OutboxStatusProcessing OutboxStatus = "processing"
+	OutboxStatusSent       OutboxStatus = "sent"
+	OutboxStatusFailed     OutboxStatus = "failed"
+)
+
+// OutboxMaxAttempts is the number of delivery attempts before a message is marked as failed
+const OutboxMaxAttempts = 5
+
+// OutboxMessage is a side effect written in the same transaction as a workflow
+// change and delivered by the outbox dispatcher after the transaction commits
+type OutboxMessage struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	Channel     OutboxChannel `json:"channel" gorm:"not null;index"`
+	Payload     string        `json:"payload" gorm:"type:text;not null"` // JSON encoded channel payload
+	Status      OutboxStatus  `json:"status" gorm:"not null;default:'pending';index"`
+	Attempts    int           `json:"attempts" gorm:"not null;default:0"`
+	LastError   string        `json:"last_error"`
+	AvailableAt time.Time     `json:"available_at" gorm:"not null;index"`
+	SentAt      *time.Time    `json:"sent_at"`
+	EntityType  string        `json:"entity_type" gorm:"index"`
+	EntityID    *uint         `json:"entity_id" gorm:"index"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// TableName specifies the table name for the OutboxMessage model
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// IsPending checks if the message is waiting for delivery
+func (om *OutboxMessage) IsPending() bool {
+	return om.Status == OutboxStatusPending
+}
+
+// CanRetry checks if the message can be attempted again
+func (om *OutboxMessage) CanRetry() bool {
+	return om.Attempts < OutboxMaxAttempts
+}
+
+// OutboxEmailPayload is the payload of an email outbox message
+type OutboxEmailPayload struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	IsHTML  bool     `json:"is_html"`
+}
+
+// OutboxWebSocketPayload is the payload of a websocket outbox message
+type OutboxWebSocketPayload struct {
+	RecipientID   *uint        `json:"recipient_id"`
+	RecipientRole *UserRole    `json:"recipient_role"`
+	Notification  Notification `json:"notification"`
+}
+
+// OutboxCertificatePayload is the payload of a certificate outbox message
+type OutboxCertificatePayload struct {
+	LicenseID uint `json:"license_id"`
+}
+
+// OutboxReceiptPayload is the payload of a receipt outbox message
+type OutboxReceiptPayload struct {
+	InvoiceID uint `json:"invoice_id"`
+}
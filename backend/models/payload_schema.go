@@ -0,0 +1,35 @@
+package models
+
+import (
+	"eservice-backend/utils"
+	"time"
+)
+
+// PayloadSchema is a version of the JSON Schema the technical data of requests of a license type
+// and energy type is checked against, e.g. the inverter specs of solar requests. Versions are
+// immutable; a change is published as the next version, and one version per license type and
+// energy type is active.
+type PayloadSchema struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	LicenseType LicenseType `json:"license_type" gorm:"not null;uniqueIndex:idx_payload_schemas_version"`
+	EnergyType  string      `json:"energy_type" gorm:"not null;default:'';uniqueIndex:idx_payload_schemas_version"` // Empty for energy types without a schema of their own
+	Version     int         `json:"version" gorm:"not null;uniqueIndex:idx_payload_schemas_version"`
+	Schema      string      `json:"-" gorm:"type:text;not null"` // JSON Schema of the technical data
+	Description string      `json:"description" gorm:"type:text"`
+	IsActive    bool        `json:"is_active" gorm:"default:false;index"`
+	CreatedByID *uint       `json:"created_by_id"`
+	CreatedBy   *User       `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	ActivatedAt *time.Time  `json:"activated_at"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// TableName specifies the table name for the PayloadSchema model
+func (PayloadSchema) TableName() string {
+	return "payload_schemas"
+}
+
+// Parse decodes the stored schema
+func (ps *PayloadSchema) Parse() (*utils.JSONSchema, error) {
+	return utils.ParseJSONSchema([]byte(ps.Schema))
+}
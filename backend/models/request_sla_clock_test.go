@@ -0,0 +1,115 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestSLAClockAdvance(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// A change of the clock to a state, hours after the clock started
+	type change struct {
+		hours float64
+		state SLAClockState
+	}
+
+	tests := []struct {
+		name          string
+		changes       []change
+		at            float64 // Hours after the start at which the totals are read
+		wantDEDE      time.Duration
+		wantApplicant time.Duration
+		wantPauses    int
+		wantState     SLAClockState
+	}{
+		{name: "running", at: 5, wantDEDE: 5 * time.Hour, wantState: SLAClockRunning},
+		{
+			name:          "paused while returned",
+			changes:       []change{{2, SLAClockPaused}},
+			at:            10,
+			wantDEDE:      2 * time.Hour,
+			wantApplicant: 8 * time.Hour,
+			wantPauses:    1,
+			wantState:     SLAClockPaused,
+		},
+		{
+			name:          "resumed on resubmission",
+			changes:       []change{{2, SLAClockPaused}, {26, SLAClockRunning}},
+			at:            30,
+			wantDEDE:      6 * time.Hour,
+			wantApplicant: 24 * time.Hour,
+			wantPauses:    1,
+			wantState:     SLAClockRunning,
+		},
+		{
+			name:          "staying paused counts one pause",
+			changes:       []change{{1, SLAClockPaused}, {3, SLAClockPaused}, {4, SLAClockRunning}, {6, SLAClockPaused}},
+			at:            7,
+			wantDEDE:      3 * time.Hour,
+			wantApplicant: 4 * time.Hour,
+			wantPauses:    2,
+			wantState:     SLAClockPaused,
+		},
+		{
+			name:          "stopped clocks no longer count",
+			changes:       []change{{2, SLAClockPaused}, {3, SLAClockRunning}, {8, SLAClockStopped}},
+			at:            100,
+			wantDEDE:      7 * time.Hour,
+			wantApplicant: time.Hour,
+			wantPauses:    1,
+			wantState:     SLAClockStopped,
+		},
+		{
+			name:      "reading before the last change adds nothing",
+			changes:   []change{{4, SLAClockRunning}},
+			at:        1,
+			wantDEDE:  4 * time.Hour,
+			wantState: SLAClockRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &RequestSLAClock{State: SLAClockRunning, StartedAt: start, LastChangedAt: start}
+			for _, c := range tt.changes {
+				clock.Advance(c.state, start.Add(time.Duration(c.hours*float64(time.Hour))))
+			}
+
+			withDEDE, withApplicant := clock.Totals(start.Add(time.Duration(tt.at * float64(time.Hour))))
+			assert.Equal(t, tt.wantDEDE, withDEDE)
+			assert.Equal(t, tt.wantApplicant, withApplicant)
+			assert.Equal(t, tt.wantPauses, clock.PauseCount)
+			assert.Equal(t, tt.wantState, clock.State)
+			assert.Equal(t, tt.wantState == SLAClockPaused, clock.PausedAt != nil)
+			assert.Equal(t, tt.wantState == SLAClockStopped, clock.StoppedAt != nil)
+		})
+	}
+}
+
+func TestWorkflowStateMachineSLAClockState(t *testing.T) {
+	spec, err := ParseWorkflowDefinitionSpec(StandardWorkflowDefinitionJSON())
+	require.NoError(t, err)
+	wsm := NewWorkflowStateMachineFromSpec("new", 1, spec)
+
+	tests := []struct {
+		status RequestStatus
+		want   SLAClockState
+	}{
+		{StatusDraft, SLAClockPaused},
+		{StatusNewRequest, SLAClockRunning},
+		{StatusInspecting, SLAClockRunning},
+		{StatusReturned, SLAClockPaused},
+		{StatusApproved, SLAClockStopped},
+		{StatusRejectedFinal, SLAClockStopped},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			assert.Equal(t, tt.want, wsm.SLAClockState(tt.status))
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSubmissionImmutable is returned when code tries to change or delete a stored submission
+var ErrSubmissionImmutable = errors.New("request submissions are immutable")
+
+// RequestSubmission is an immutable snapshot of what the applicant sent to DEDE. A snapshot is
+// taken every time a request leaves an applicant-owned status such as draft or returned, so
+// reviewers can compare a resubmission with the submission they returned.
+type RequestSubmission struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	RequestID     uint            `json:"request_id" gorm:"not null;uniqueIndex:idx_request_submissions_sequence"`
+	LicenseType   string          `json:"license_type" gorm:"not null"`
+	Sequence      int             `json:"sequence" gorm:"not null;uniqueIndex:idx_request_submissions_sequence"` // 1 for the first submission
+	FromStatus    RequestStatus   `json:"from_status" gorm:"not null"`
+	ToStatus      RequestStatus   `json:"to_status" gorm:"not null"`
+	FlowLogID     *uint           `json:"flow_log_id"` // Flow log entry of the transition that took the snapshot
+	SubmittedByID uint            `json:"submitted_by_id" gorm:"not null;index"`
+	SubmittedBy   *User           `json:"submitted_by,omitempty" gorm:"foreignKey:SubmittedByID"`
+	Fields        json.RawMessage `json:"fields" gorm:"type:jsonb;not null;default:'{}'"`
+	Attachments   json.RawMessage `json:"attachments" gorm:"type:jsonb;not null;default:'[]'"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// TableName specifies the table name for the RequestSubmission model
+func (RequestSubmission) TableName() string {
+	return "request_submissions"
+}
+
+// BeforeUpdate rejects changes to a stored submission
+func (rs *RequestSubmission) BeforeUpdate(tx *gorm.DB) error {
+	return ErrSubmissionImmutable
+}
+
+// BeforeDelete rejects deleting a stored submission
+func (rs *RequestSubmission) BeforeDelete(tx *gorm.DB) error {
+	return ErrSubmissionImmutable
+}
+
+// SubmittedAttachment is the part of an attachment kept in a submission snapshot
+type SubmittedAttachment struct {
+	ID           uint      `json:"id"`
+	FileName     string    `json:"file_name"`
+	OriginalName string    `json:"original_name"`
+	FileSize     int64     `json:"file_size"`
+	MimeType     string    `json:"mime_type"`
+	Description  string    `json:"description"`
+	IsRequired   bool      `json:"is_required"`
+	UploadedAt   time.Time `json:"uploaded_at"`
+}
+
+// SubmissionFields flattens the applicant-editable fields of a request, with the
+// type-specific payload fields under "payload.<name>" and the technical data under "technical.<name>"
+func SubmissionFields(lr *LicenseRequest) (map[string]interface{}, error) {
+	fields := map[string]interface{}{
+		"title":              lr.Title,
+		"description":        lr.Description,
+		"license_number":     lr.LicenseNumber,
+		"current_capacity":   lr.CurrentCapacity,
+		"requested_capacity": lr.RequestedCapacity,
+		"location":           lr.Location,
+		"contact_person":     lr.ContactPerson,
+		"contact_phone":      lr.ContactPhone,
+		"contact_email":      lr.ContactEmail,
+	}
+	for name, value := range lr.PayloadFields() {
+		fields["payload."+name] = value
+	}
+	technical, err := lr.TechnicalFields()
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range technical {
+		fields["technical."+name] = value
+	}
+	return fields, nil
+}
+
+// SetSnapshot encodes the request's fields and attachments into the submission
+func (rs *RequestSubmission) SetSnapshot(lr *LicenseRequest, attachments []Attachment) error {
+	snapshot, err := SubmissionFields(lr)
+	if err != nil {
+		return err
+	}
+	fields, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode submission fields: %w", err)
+	}
+
+	submitted := make([]SubmittedAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		submitted = append(submitted, SubmittedAttachment{
+			ID:           attachment.ID,
+			FileName:     attachment.FileName,
+			OriginalName: attachment.OriginalName,
+			FileSize:     attachment.FileSize,
+			MimeType:     attachment.MimeType,
+			Description:  attachment.Description,
+			IsRequired:   attachment.IsRequired,
+			UploadedAt:   attachment.CreatedAt,
+		})
+	}
+	files, err := json.Marshal(submitted)
+	if err != nil {
+		return fmt.Errorf("failed to encode submission attachments: %w", err)
+	}
+
+	rs.Fields = fields
+	rs.Attachments = files
+	return nil
+}
+
+// GetFields returns the snapshot fields as a map
+func (rs *RequestSubmission) GetFields() map[string]interface{} {
+	fields := map[string]interface{}{}
+	if rs.Fields != nil {
+		json.Unmarshal(rs.Fields, &fields)
+	}
+	return fields
+}
+
+// GetAttachments returns the snapshot attachments
+func (rs *RequestSubmission) GetAttachments() []SubmittedAttachment {
+	var attachments []SubmittedAttachment
+	if rs.Attachments != nil {
+		json.Unmarshal(rs.Attachments, &attachments)
+	}
+	return attachments
+}
@@ -14,6 +14,8 @@ type ServiceFlowLog struct {
 	NewStatus        RequestStatus  `json:"new_status" gorm:"not null"`
 	ChangedBy        *uint          `json:"changed_by" gorm:"index"`
 	ChangedByUser    *User          `json:"changed_by_user" gorm:"foreignKey:ChangedBy"`
+	OnBehalfOf       *uint          `json:"on_behalf_of" gorm:"index"` // Delegator when ChangedBy acted under a delegation
+	OnBehalfOfUser   *User          `json:"on_behalf_of_user" gorm:"foreignKey:OnBehalfOf"`
 	ChangeReason     string         `json:"change_reason"`
 	LicenseType      string         `json:"license_type" gorm:"not null"` // 'new', 'renewal', 'extension', 'reduction'
 	CreatedAt        time.Time      `json:"created_at"`
@@ -25,6 +27,18 @@ func (ServiceFlowLog) TableName() string {
 	return "service_flow_logs"
 }
 
+// GetActorDisplayName describes who made the change, e.g. "A ในนามของ B" when acting under a delegation
+func (sfl *ServiceFlowLog) GetActorDisplayName() string {
+	if sfl.ChangedByUser == nil {
+		return "ระบบ"
+	}
+	actor := sfl.ChangedByUser.FullName
+	if sfl.OnBehalfOfUser != nil {
+		return actor + " ในนามของ " + sfl.OnBehalfOfUser.FullName
+	}
+	return actor
+}
+
 // GetStatusDisplayName returns the display name for the status
 func (sfl *ServiceFlowLog) GetStatusDisplayName(status RequestStatus) string {
 	switch status {
@@ -58,6 +72,12 @@ func (sfl *ServiceFlowLog) GetStatusDisplayName(status RequestStatus) string {
 		return "ตีเอกสารกลับไปแก้ไข"
 	case StatusForwarded:
 		return "ส่งต่อให้ DEDE Admin"
+	case StatusWithdrawn:
+		return "ถอนคำขอ"
+	case StatusAppealed:
+		return "ยื่นอุทธรณ์"
+	case StatusPaymentPending:
+		return "รอชำระค่าธรรมเนียม"
 	default:
 		return string(status)
 	}
@@ -94,6 +114,12 @@ func (sfl *ServiceFlowLog) GetStatusColor(status RequestStatus) string {
 		return "bg-amber-100 text-amber-800"
 	case StatusForwarded:
 		return "bg-cyan-100 text-cyan-800"
+	case StatusWithdrawn:
+		return "bg-slate-100 text-slate-800"
+	case StatusAppealed:
+		return "bg-pink-100 text-pink-800"
+	case StatusPaymentPending:
+		return "bg-lime-100 text-lime-800"
 	default:
 		return "bg-gray-100 text-gray-800"
 	}
@@ -118,6 +144,9 @@ func (sfl *ServiceFlowLog) IsStatusProgress() bool {
 		StatusRejected:       -1,
 		StatusRejectedFinal:  -2,
 		StatusOverdue:        -3,
+		StatusWithdrawn:      -4,
+		StatusAppealed:       0, // Back under review once the appeal is granted
+		StatusPaymentPending: 8, // Waits for the fee between report approval and final approval
 	}
 
 	if sfl.PreviousStatus == nil {
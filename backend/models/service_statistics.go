@@ -72,6 +72,12 @@ func (ss *ServiceStatistics) GetStatusDisplayName() string {
 		return "อนุมัติใบอนุญาต"
 	case "rejected_final":
 		return "ปฏิเสธสุดท้าย"
+	case "withdrawn":
+		return "ถอนคำขอ"
+	case "appealed":
+		return "ยื่นอุทธรณ์"
+	case "payment_pending":
+		return "รอชำระค่าธรรมเนียม"
 	default:
 		return ss.Status
 	}
@@ -104,6 +110,12 @@ func (ss *ServiceStatistics) GetStatusColor() string {
 		return "bg-green-100 text-green-800"
 	case "approved":
 		return "bg-green-100 text-green-800"
+	case "withdrawn":
+		return "bg-slate-100 text-slate-800"
+	case "appealed":
+		return "bg-pink-100 text-pink-800"
+	case "payment_pending":
+		return "bg-lime-100 text-lime-800"
 	default:
 		return "bg-gray-100 text-gray-800"
 	}
@@ -119,7 +131,7 @@ func (ss *ServiceStatistics) IsInProgress() bool {
 	return ss.Status == "accepted" || ss.Status == "assigned" ||
 		ss.Status == "appointment" || ss.Status == "inspecting" ||
 		ss.Status == "inspection_done" || ss.Status == "document_edit" ||
-		ss.Status == "report_approved"
+		ss.Status == "report_approved" || ss.Status == "payment_pending"
 }
 
 // IsPending returns true if the status represents a pending request
@@ -0,0 +1,262 @@
+package models
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultWorkflowVersion is the version of the built-in workflow definition.
+// Requests pinned to this version (including every request created before
+// versioned definitions existed) run on the embedded default workflow.
+const DefaultWorkflowVersion = 0
+
+// DefaultWorkflowLicenseType is the license type key of the built-in workflow definition
+const DefaultWorkflowLicenseType = "default"
+
+// The built-in definition is frozen: requests pinned to version 0 must keep the workflow they
+// started on. Workflow changes go into the standard definition, which is published as a new
+// version of every license type (see database/migrations/workflow_definitions.go).
+//
+//go:embed workflow_definitions/default.json
+var defaultWorkflowDefinitionJSON []byte
+
+//go:embed workflow_definitions/standard.json
+var standardWorkflowDefinitionJSON []byte
+
+var (
+	defaultWorkflowSpec     *WorkflowDefinitionSpec
+	defaultWorkflowSpecErr  error
+	defaultWorkflowSpecOnce sync.Once
+)
+
+// WorkflowDefinition is a versioned, immutable workflow definition for a license type
+type WorkflowDefinition struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	LicenseType string     `json:"license_type" gorm:"not null;uniqueIndex:idx_workflow_definitions_type_version"`
+	Version     int        `json:"version" gorm:"not null;uniqueIndex:idx_workflow_definitions_type_version"`
+	Name        string     `json:"name" gorm:"not null"`
+	Description string     `json:"description"`
+	Definition  string     `json:"definition" gorm:"type:text;not null"` // JSON encoded WorkflowDefinitionSpec
+	IsActive    bool       `json:"is_active" gorm:"default:false;index"`
+	CreatedByID *uint      `json:"created_by_id" gorm:"index"`
+	CreatedBy   *User      `json:"created_by,omitempty" gorm:"foreignKey:CreatedByID"`
+	ActivatedAt *time.Time `json:"activated_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for the WorkflowDefinition model
+func (WorkflowDefinition) TableName() string {
+	return "workflow_definitions"
+}
+
+// GetSpec parses the stored definition
+func (wd *WorkflowDefinition) GetSpec() (*WorkflowDefinitionSpec, error) {
+	return ParseWorkflowDefinitionSpec([]byte(wd.Definition))
+}
+
+// WorkflowDefinitionSpec describes the states and transitions of a workflow
+type WorkflowDefinitionSpec struct {
+	Name        string                         `json:"name"`
+	Description string                         `json:"description"`
+	Path        []RequestStatus                `json:"path"`
+	States      []WorkflowStateDefinition      `json:"states"`
+	Transitions []WorkflowTransitionDefinition `json:"transitions"`
+}
+
+// WorkflowStateDefinition describes a single workflow state
+type WorkflowStateDefinition struct {
+	Status         RequestStatus            `json:"status"`
+	Description    string                   `json:"description"`
+	NextAction     string                   `json:"next_action"`
+	Progress       int                      `json:"progress"`
+	Terminal       bool                     `json:"terminal"`
+	DeadlineDays   int                      `json:"deadline_days"`             // 0 means the state has no default deadline
+	ApplicantOwned bool                     `json:"applicant_owned,omitempty"` // Waiting on the applicant; the SLA clock pauses here
+	IssueInvoice   bool                     `json:"issue_invoice,omitempty"`   // Entering the state invoices the fee of the request
+	Escalations    []WorkflowEscalationRule `json:"escalations,omitempty"`     // Actions taken when a request stays in the state too long
+}
+
+// WorkflowEscalationRule acts on a request that has stayed in a state for more than
+// AfterBusinessDays business days. Each rule fires once per visit to the state.
+type WorkflowEscalationRule struct {
+	AfterBusinessDays int        `json:"after_business_days"`
+	Action            string     `json:"action"`                 // notify or reassign
+	NotifyRoles       []UserRole `json:"notify_roles,omitempty"` // Default: the next supervisor up the chain from the task owner
+}
+
+// WorkflowTransitionDefinition describes a transition allowed for one or more roles
+type WorkflowTransitionDefinition struct {
+	FromStatus  RequestStatus           `json:"from_status"`
+	ToStatus    RequestStatus           `json:"to_status"`
+	Roles       []UserRole              `json:"roles"`
+	Action      string                  `json:"action"`
+	Description string                  `json:"description"`
+	AutoAllowed bool                    `json:"auto_allowed"`
+	Guards      []string                `json:"guards,omitempty"`   // Named conditions that must hold for the transition to commit
+	Approval    *WorkflowApprovalPolicy `json:"approval,omitempty"` // Collect several approvals before the transition commits
+}
+
+// WorkflowApprovalPolicy makes a transition wait for several independent approvals.
+// Each allowed user's attempt to take the transition counts as an approving vote; taking
+// the veto transition instead counts as a rejecting vote and short-circuits the step.
+type WorkflowApprovalPolicy struct {
+	MinApprovals  int           `json:"min_approvals"`            // Distinct approvers needed, e.g. 2 for 2-of-3
+	RequiredRoles []UserRole    `json:"required_roles,omitempty"` // Roles that must each approve at least once
+	MinCapacity   float64       `json:"min_capacity,omitempty"`   // Only requests with at least this requested capacity need the quorum
+	VetoStatus    RequestStatus `json:"veto_status"`              // Status a rejecting vote moves the request to
+}
+
+// AppliesTo checks if a request with the given requested capacity needs the quorum
+func (p *WorkflowApprovalPolicy) AppliesTo(requestedCapacity float64) bool {
+	return p != nil && requestedCapacity >= p.MinCapacity
+}
+
+// ParseWorkflowDefinitionSpec decodes and validates a JSON workflow definition
+func ParseWorkflowDefinitionSpec(data []byte) (*WorkflowDefinitionSpec, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var spec WorkflowDefinitionSpec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid workflow definition: %w", err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+// DefaultWorkflowDefinitionSpec returns the built-in workflow definition
+func DefaultWorkflowDefinitionSpec() (*WorkflowDefinitionSpec, error) {
+	defaultWorkflowSpecOnce.Do(func() {
+		defaultWorkflowSpec, defaultWorkflowSpecErr = ParseWorkflowDefinitionSpec(defaultWorkflowDefinitionJSON)
+	})
+	return defaultWorkflowSpec, defaultWorkflowSpecErr
+}
+
+// StandardWorkflowDefinitionJSON returns the standard workflow definition that is published as a
+// version of every license type
+func StandardWorkflowDefinitionJSON() []byte {
+	return standardWorkflowDefinitionJSON
+}
+
+// Validate checks that the definition is internally consistent
+func (spec *WorkflowDefinitionSpec) Validate() error {
+	if len(spec.States) == 0 {
+		return fmt.Errorf("workflow definition must declare at least one state")
+	}
+
+	states := make(map[RequestStatus]bool, len(spec.States))
+	for _, state := range spec.States {
+		if state.Status == "" {
+			return fmt.Errorf("workflow state must have a status")
+		}
+		if states[state.Status] {
+			return fmt.Errorf("workflow state %s is declared more than once", state.Status)
+		}
+		if state.Progress < 0 || state.Progress > 100 {
+			return fmt.Errorf("workflow state %s has invalid progress %d", state.Status, state.Progress)
+		}
+		if state.DeadlineDays < 0 {
+			return fmt.Errorf("workflow state %s has negative deadline_days", state.Status)
+		}
+		if state.Terminal && state.ApplicantOwned {
+			return fmt.Errorf("workflow state %s cannot be both terminal and applicant_owned", state.Status)
+		}
+		if state.Terminal && state.IssueInvoice {
+			return fmt.Errorf("workflow state %s is terminal and cannot issue an invoice", state.Status)
+		}
+		for _, rule := range state.Escalations {
+			if rule.AfterBusinessDays < 1 {
+				return fmt.Errorf("workflow state %s has an escalation with after_business_days below 1", state.Status)
+			}
+			if rule.Action != EscalationActionNotify && rule.Action != EscalationActionReassign {
+				return fmt.Errorf("workflow state %s has an escalation with unknown action %q", state.Status, rule.Action)
+			}
+			if state.Terminal {
+				return fmt.Errorf("workflow state %s is terminal and cannot escalate", state.Status)
+			}
+		}
+		states[state.Status] = true
+	}
+
+	for _, status := range spec.Path {
+		if !states[status] {
+			return fmt.Errorf("workflow path references undeclared state %s", status)
+		}
+	}
+
+	for _, transition := range spec.Transitions {
+		if !states[transition.FromStatus] {
+			return fmt.Errorf("transition %s references undeclared state %s", transition.Action, transition.FromStatus)
+		}
+		if !states[transition.ToStatus] {
+			return fmt.Errorf("transition %s references undeclared state %s", transition.Action, transition.ToStatus)
+		}
+		if transition.Action == "" {
+			return fmt.Errorf("transition from %s to %s must have an action", transition.FromStatus, transition.ToStatus)
+		}
+		if !transition.AutoAllowed && len(transition.Roles) == 0 {
+			return fmt.Errorf("transition %s from %s must list at least one role", transition.Action, transition.FromStatus)
+		}
+		guards := make(map[string]bool, len(transition.Guards))
+		for _, guard := range transition.Guards {
+			if guard == "" {
+				return fmt.Errorf("transition %s from %s has an empty guard name", transition.Action, transition.FromStatus)
+			}
+			if guards[guard] {
+				return fmt.Errorf("transition %s from %s lists guard %s more than once", transition.Action, transition.FromStatus, guard)
+			}
+			guards[guard] = true
+		}
+		if err := spec.validateApproval(transition, states); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateApproval checks that an approval policy can be met and that its veto has a transition to take
+func (spec *WorkflowDefinitionSpec) validateApproval(transition WorkflowTransitionDefinition, states map[RequestStatus]bool) error {
+	policy := transition.Approval
+	if policy == nil {
+		return nil
+	}
+	if transition.AutoAllowed {
+		return fmt.Errorf("transition %s from %s cannot be automatic and require approvals", transition.Action, transition.FromStatus)
+	}
+	if policy.MinApprovals < 1 || policy.MinApprovals < len(policy.RequiredRoles) {
+		return fmt.Errorf("transition %s from %s needs min_approvals of at least 1 and at least one per required role", transition.Action, transition.FromStatus)
+	}
+	if policy.MinCapacity < 0 {
+		return fmt.Errorf("transition %s from %s has negative min_capacity", transition.Action, transition.FromStatus)
+	}
+
+	roles := make(map[UserRole]bool, len(transition.Roles))
+	for _, role := range transition.Roles {
+		roles[role] = true
+	}
+	for _, role := range policy.RequiredRoles {
+		if !roles[role] {
+			return fmt.Errorf("transition %s from %s requires approval from role %s, which cannot take it", transition.Action, transition.FromStatus, role)
+		}
+	}
+
+	if !states[policy.VetoStatus] {
+		return fmt.Errorf("transition %s from %s vetoes to undeclared state %s", transition.Action, transition.FromStatus, policy.VetoStatus)
+	}
+	for _, other := range spec.Transitions {
+		if other.FromStatus == transition.FromStatus && other.ToStatus == policy.VetoStatus {
+			return nil
+		}
+	}
+	return fmt.Errorf("transition %s from %s vetoes to %s, but no transition leads there", transition.Action, transition.FromStatus, policy.VetoStatus)
+}
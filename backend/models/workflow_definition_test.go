@@ -0,0 +1,142 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWorkflowDefinition is a small valid definition; tests replace parts of it to break it
+const testWorkflowDefinition = `{
+	"name": "Test workflow",
+	"path": ["draft", "new_request", "accepted"],
+	"states": [
+		{"status": "draft", "progress": 0},
+		{"status": "new_request", "progress": 50, "deadline_days": 3},
+		{"status": "accepted", "progress": 100, "terminal": true}
+	],
+	"transitions": [
+		{"from_status": "draft", "to_status": "new_request", "roles": ["user"], "action": "submit"},
+		{"from_status": "new_request", "to_status": "accepted", "roles": ["dede_head", "dede_staff"], "action": "accept"},
+		{"from_status": "new_request", "to_status": "draft", "auto_allowed": true, "action": "expire"}
+	]
+}`
+
+func TestParseWorkflowDefinitionSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string // Replaced in testWorkflowDefinition by new
+		new     string
+		wantErr string
+	}{
+		{name: "valid definition"},
+		{name: "unknown field", old: `"name"`, new: `"title": "x", "name"`, wantErr: "unknown field"},
+		{name: "malformed JSON", old: `"path"`, new: `"path" "`, wantErr: "invalid workflow definition"},
+		{
+			name:    "no states",
+			old:     testWorkflowDefinition,
+			new:     `{"name": "Empty", "states": []}`,
+			wantErr: "at least one state",
+		},
+		{name: "state without status", old: `{"status": "draft", "progress": 0}`, new: `{"progress": 0}`, wantErr: "must have a status"},
+		{name: "duplicate state", old: `{"status": "accepted"`, new: `{"status": "draft"`, wantErr: "declared more than once"},
+		{name: "progress above 100", old: `"progress": 50`, new: `"progress": 150`, wantErr: "invalid progress"},
+		{name: "negative deadline", old: `"deadline_days": 3`, new: `"deadline_days": -1`, wantErr: "negative deadline_days"},
+		{name: "path to undeclared state", old: `"path": ["draft"`, new: `"path": ["assigned"`, wantErr: "path references undeclared state assigned"},
+		{
+			name:    "transition to undeclared state",
+			old:     `"to_status": "accepted"`,
+			new:     `"to_status": "approved"`,
+			wantErr: "transition accept references undeclared state approved",
+		},
+		{name: "transition without action", old: `"action": "submit"`, new: `"action": ""`, wantErr: "must have an action"},
+		{name: "transition without roles", old: `"roles": ["user"], `, new: ``, wantErr: "must list at least one role"},
+		{name: "duplicate guard", old: `"action": "submit"`, new: `"action": "submit", "guards": ["a", "a"]`, wantErr: "lists guard a more than once"},
+		{name: "empty guard", old: `"action": "submit"`, new: `"action": "submit", "guards": [""]`, wantErr: "empty guard name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition := testWorkflowDefinition
+			if tt.old != "" {
+				require.Contains(t, definition, tt.old)
+				definition = strings.Replace(definition, tt.old, tt.new, 1)
+			}
+
+			spec, err := ParseWorkflowDefinitionSpec([]byte(definition))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Test workflow", spec.Name)
+			assert.Len(t, spec.States, 3)
+			assert.Len(t, spec.Transitions, 3)
+		})
+	}
+}
+
+func TestBuiltInWorkflowDefinitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition []byte
+	}{
+		{name: "default", definition: defaultWorkflowDefinitionJSON},
+		{name: "standard", definition: StandardWorkflowDefinitionJSON()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseWorkflowDefinitionSpec(tt.definition)
+			require.NoError(t, err)
+
+			wsm := NewWorkflowStateMachineFromSpec(DefaultWorkflowLicenseType, DefaultWorkflowVersion, spec)
+			assert.True(t, wsm.IsInitialState(StatusDraft))
+			assert.True(t, wsm.CanTransition(StatusDraft, StatusNewRequest, RoleUser))
+			assert.True(t, wsm.IsTerminalState(StatusApproved))
+		})
+	}
+}
+
+func TestWorkflowStateMachineGetTransition(t *testing.T) {
+	spec, err := ParseWorkflowDefinitionSpec([]byte(testWorkflowDefinition))
+	require.NoError(t, err)
+	wsm := NewWorkflowStateMachineFromSpec("new", 3, spec)
+
+	tests := []struct {
+		name       string
+		from       RequestStatus
+		to         RequestStatus
+		role       UserRole
+		wantOK     bool
+		wantRole   UserRole
+		wantAction string
+	}{
+		{name: "role listed on the transition", from: StatusDraft, to: StatusNewRequest, role: RoleUser, wantOK: true, wantRole: RoleUser, wantAction: "submit"},
+		{name: "role not listed", from: StatusDraft, to: StatusNewRequest, role: RoleDEDEStaff},
+		{name: "one of several roles", from: StatusNewRequest, to: StatusAccepted, role: RoleDEDEStaff, wantOK: true, wantRole: RoleDEDEStaff, wantAction: "accept"},
+		{name: "automatic transition for any caller", from: StatusNewRequest, to: StatusDraft, role: RoleUser, wantOK: true, wantAction: "expire"},
+		{name: "automatic transition for the system", from: StatusNewRequest, to: StatusDraft, wantOK: true, wantAction: "expire"},
+		{name: "no such transition", from: StatusAccepted, to: StatusDraft, role: RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transition, ok := wsm.GetTransition(tt.from, tt.to, tt.role)
+			require.Equal(t, tt.wantOK, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.wantRole, transition.RequiredRole)
+			assert.Equal(t, tt.wantAction, transition.Action)
+		})
+	}
+
+	assert.Equal(t, "new", wsm.LicenseType())
+	assert.Equal(t, 3, wsm.Version())
+	assert.True(t, wsm.IsInitialState(StatusNewRequest), "a request may be created already submitted")
+	assert.False(t, wsm.IsInitialState(StatusAccepted))
+}
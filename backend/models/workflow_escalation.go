@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EscalationActionNotify   = "notify"   // แจ้งเตือนผู้บังคับบัญชา
+	EscalationActionReassign = "reassign" // มอบหมายงานใหม่อัตโนมัติ
+)
+
+// supervisorRoles is the supervisor chain used when an escalation rule names no roles
+var supervisorRoles = map[UserRole]UserRole{
+	RoleDEDEStaff:   RoleDEDEHead,
+	RoleDEDEConsult: RoleDEDEHead,
+	RoleAuditor:     RoleDEDEHead,
+	RoleDEDEHead:    RoleAdmin,
+}
+
+// SupervisorRole returns the role levels steps up the supervisor chain from role.
+// The chain stops at its top, so a large level returns the highest supervisor.
+func SupervisorRole(role UserRole, levels int) UserRole {
+	for i := 0; i < levels; i++ {
+		next, ok := supervisorRoles[role]
+		if !ok {
+			break
+		}
+		role = next
+	}
+	return role
+}
+
+// WorkflowEscalation records an escalation rule that fired for a request. Escalations belong
+// to a round, the flow log entry that moved the request into the status, so each rule fires
+// once per visit to the status.
+type WorkflowEscalation struct {
+	ID                uint          `json:"id" gorm:"primaryKey"`
+	RequestID         uint          `json:"request_id" gorm:"not null;uniqueIndex:idx_workflow_escalations_round_rule"`
+	LicenseType       string        `json:"license_type" gorm:"not null"`
+	Status            RequestStatus `json:"status" gorm:"not null"`
+	RoundID           uint          `json:"round_id" gorm:"not null;uniqueIndex:idx_workflow_escalations_round_rule"` // Flow log entry that started the round
+	RuleIndex         int           `json:"rule_index" gorm:"not null;uniqueIndex:idx_workflow_escalations_round_rule"`
+	Action            string        `json:"action" gorm:"not null"`
+	AfterBusinessDays int           `json:"after_business_days" gorm:"not null"`
+	OwnerID           *uint         `json:"owner_id"` // Officer the request was waiting on
+	Owner             *User         `json:"owner,omitempty" gorm:"foreignKey:OwnerID"`
+	OwnerRole         UserRole      `json:"owner_role"`
+	ReassignedToID    *uint         `json:"reassigned_to_id"`
+	ReassignedTo      *User         `json:"reassigned_to,omitempty" gorm:"foreignKey:ReassignedToID"`
+	NotifiedRoles     string        `json:"notified_roles"` // Comma separated
+	Note              string        `json:"note"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+// TableName specifies the table name for the WorkflowEscalation model
+func (WorkflowEscalation) TableName() string {
+	return "workflow_escalations"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupervisorRole(t *testing.T) {
+	tests := []struct {
+		role   UserRole
+		levels int
+		want   UserRole
+	}{
+		{role: RoleDEDEStaff, levels: 0, want: RoleDEDEStaff},
+		{role: RoleDEDEStaff, levels: 1, want: RoleDEDEHead},
+		{role: RoleDEDEStaff, levels: 2, want: RoleAdmin},
+		{role: RoleDEDEConsult, levels: 1, want: RoleDEDEHead},
+		{role: RoleAuditor, levels: 2, want: RoleAdmin},
+		{role: RoleDEDEHead, levels: 1, want: RoleAdmin},
+		{role: RoleDEDEStaff, levels: 5, want: RoleAdmin},
+		{role: RoleAdmin, levels: 1, want: RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			assert.Equal(t, tt.want, SupervisorRole(tt.role, tt.levels))
+		})
+	}
+}
@@ -10,6 +10,7 @@ const (
 	MetricTypeProcessingTime MetricType = "processing_time"
 	MetricTypeInspectionTime MetricType = "inspection_time"
 	MetricTypeReviewTime     MetricType = "review_time"
+	MetricTypeApplicantTime  MetricType = "applicant_time" // Time the request waited on the applicant
 )
 
 type WorkflowMetric struct {
@@ -51,6 +52,8 @@ func (wm *WorkflowMetric) GetMetricTypeDisplayName() string {
 		return "เวลาตรวจสอบ"
 	case MetricTypeReviewTime:
 		return "เวลาตรวจสอบ"
+	case MetricTypeApplicantTime:
+		return "เวลารอผู้ยื่นคำขอ"
 	default:
 		return string(wm.MetricType)
 	}
@@ -19,89 +19,98 @@ type WorkflowState struct {
 
 // WorkflowTransition represents a state transition in the workflow
 type WorkflowTransition struct {
-	FromStatus   RequestStatus `json:"from_status"`
-	ToStatus     RequestStatus `json:"to_status"`
-	RequiredRole UserRole      `json:"required_role"`
-	Action       string        `json:"action"`
-	Description  string        `json:"description"`
-	AutoAllowed  bool          `json:"auto_allowed"`
+	FromStatus   RequestStatus           `json:"from_status"`
+	ToStatus     RequestStatus           `json:"to_status"`
+	RequiredRole UserRole                `json:"required_role"`
+	Action       string                  `json:"action"`
+	Description  string                  `json:"description"`
+	AutoAllowed  bool                    `json:"auto_allowed"`
+	Guards       []string                `json:"guards,omitempty"`
+	Approval     *WorkflowApprovalPolicy `json:"approval,omitempty"`
 }
 
 // WorkflowStateMachine manages the state transitions for DEDE workflow
 type WorkflowStateMachine struct {
+	licenseType string
+	version     int
+	name        string
+	path        []RequestStatus
+	states      map[RequestStatus]WorkflowStateDefinition
+	stateOrder  []RequestStatus
 	transitions map[RequestStatus][]WorkflowTransition
 }
 
-// NewWorkflowStateMachine creates a new workflow state machine
+// NewWorkflowStateMachine creates a new workflow state machine from the built-in definition
 func NewWorkflowStateMachine() *WorkflowStateMachine {
-	wsm := &WorkflowStateMachine{
-		transitions: make(map[RequestStatus][]WorkflowTransition),
+	spec, err := DefaultWorkflowDefinitionSpec()
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in workflow definition: %v", err))
 	}
 
-	// Initialize all possible transitions
-	wsm.initializeTransitions()
-	return wsm
+	return NewWorkflowStateMachineFromSpec(DefaultWorkflowLicenseType, DefaultWorkflowVersion, spec)
 }
 
-// initializeTransitions sets up all valid state transitions
-func (wsm *WorkflowStateMachine) initializeTransitions() {
-	// From Draft
-	wsm.addTransition(StatusDraft, StatusNewRequest, RoleUser, "submit", "Submit request for review", false)
-
-	// From New Request (DEDE Admin receives)
-	wsm.addTransition(StatusNewRequest, StatusAccepted, RoleAdmin, "accept", "Accept request for processing", false)
-	wsm.addTransition(StatusNewRequest, StatusRejected, RoleAdmin, "reject", "Reject request", false)
-	wsm.addTransition(StatusNewRequest, StatusReturned, RoleAdmin, "return", "Return to user for corrections", false)
-
-	// From Accepted (Forward to DEDE Head)
-	wsm.addTransition(StatusAccepted, StatusForwarded, RoleAdmin, "forward", "Forward to DEDE Head", false)
-
-	// From Forwarded (DEDE Head receives)
-	wsm.addTransition(StatusForwarded, StatusAssigned, RoleDEDEHead, "assign", "Assign to DEDE Staff/Consult", false)
-	wsm.addTransition(StatusForwarded, StatusRejected, RoleDEDEHead, "reject", "Reject request", false)
-
-	// From Assigned (Task assignment)
-	wsm.addTransition(StatusAssigned, StatusAppointment, RoleDEDEHead, "schedule", "Schedule appointment", false)
-	wsm.addTransition(StatusAssigned, StatusAppointment, RoleDEDEStaff, "schedule", "Schedule appointment", false)
+// NewWorkflowStateMachineFromSpec creates a workflow state machine from a validated definition
+func NewWorkflowStateMachineFromSpec(licenseType string, version int, spec *WorkflowDefinitionSpec) *WorkflowStateMachine {
+	wsm := &WorkflowStateMachine{
+		licenseType: licenseType,
+		version:     version,
+		name:        spec.Name,
+		path:        append([]RequestStatus(nil), spec.Path...),
+		states:      make(map[RequestStatus]WorkflowStateDefinition),
+		stateOrder:  make([]RequestStatus, 0, len(spec.States)),
+		transitions: make(map[RequestStatus][]WorkflowTransition),
+	}
 
-	// From Appointment
-	wsm.addTransition(StatusAppointment, StatusInspecting, RoleDEDEConsult, "start_inspection", "Start site inspection", false)
-	wsm.addTransition(StatusAppointment, StatusInspecting, RoleDEDEStaff, "start_inspection", "Start site inspection", false)
+	for _, state := range spec.States {
+		wsm.states[state.Status] = state
+		wsm.stateOrder = append(wsm.stateOrder, state.Status)
+	}
 
-	// From Inspecting
-	wsm.addTransition(StatusInspecting, StatusInspectionDone, RoleDEDEConsult, "complete_inspection", "Complete inspection", false)
-	wsm.addTransition(StatusInspecting, StatusInspectionDone, RoleDEDEStaff, "complete_inspection", "Complete inspection", false)
+	for _, transition := range spec.Transitions {
+		if transition.AutoAllowed && len(transition.Roles) == 0 {
+			wsm.addTransition(transition, UserRole(""))
+			continue
+		}
+		for _, role := range transition.Roles {
+			wsm.addTransition(transition, role)
+		}
+	}
 
-	// From Inspection Done (Audit Report phase)
-	wsm.addTransition(StatusInspectionDone, StatusDocumentEdit, RoleDEDEConsult, "submit_report", "Submit audit report", false)
-	wsm.addTransition(StatusInspectionDone, StatusDocumentEdit, RoleDEDEStaff, "submit_report", "Submit audit report", false)
+	return wsm
+}
 
-	// From Document Edit (Review phase)
-	wsm.addTransition(StatusDocumentEdit, StatusReportApproved, RoleDEDEStaff, "approve_report", "Approve audit report", false)
-	wsm.addTransition(StatusDocumentEdit, StatusReturned, RoleDEDEStaff, "reject_report", "Reject report for revision", false)
+// LicenseType returns the license type the definition belongs to
+func (wsm *WorkflowStateMachine) LicenseType() string {
+	return wsm.licenseType
+}
 
-	// From Report Approved
-	wsm.addTransition(StatusReportApproved, StatusApproved, RoleDEDEStaff, "approve_license", "Approve license", false)
-	wsm.addTransition(StatusReportApproved, StatusApproved, RoleDEDEHead, "approve_license", "Approve license", false)
+// Version returns the definition version the state machine was built from
+func (wsm *WorkflowStateMachine) Version() int {
+	return wsm.version
+}
 
-	// Auto transitions
-	wsm.addTransition(StatusAppointment, StatusOverdue, UserRole(""), "auto_overdue", "Auto-cancel due to missed appointment", true)
-	wsm.addTransition(StatusDocumentEdit, StatusOverdue, UserRole(""), "auto_overdue", "Auto-cancel due to 14+ day delay", true)
+// Name returns the definition name
+func (wsm *WorkflowStateMachine) Name() string {
+	return wsm.name
 }
 
-// addTransition adds a transition to the state machine
-func (wsm *WorkflowStateMachine) addTransition(from, to RequestStatus, role UserRole, action, description string, autoAllowed bool) {
+// addTransition adds a transition definition for a single role to the state machine
+func (wsm *WorkflowStateMachine) addTransition(definition WorkflowTransitionDefinition, role UserRole) {
+	from := definition.FromStatus
 	if wsm.transitions[from] == nil {
 		wsm.transitions[from] = make([]WorkflowTransition, 0)
 	}
 
 	wsm.transitions[from] = append(wsm.transitions[from], WorkflowTransition{
 		FromStatus:   from,
-		ToStatus:     to,
+		ToStatus:     definition.ToStatus,
 		RequiredRole: role,
-		Action:       action,
-		Description:  description,
-		AutoAllowed:  autoAllowed,
+		Action:       definition.Action,
+		Description:  definition.Description,
+		AutoAllowed:  definition.AutoAllowed,
+		Guards:       append([]string(nil), definition.Guards...),
+		Approval:     definition.Approval,
 	})
 }
 
@@ -122,53 +131,92 @@ func (wsm *WorkflowStateMachine) GetValidTransitions(currentStatus RequestStatus
 
 // CanTransition checks if a transition is valid
 func (wsm *WorkflowStateMachine) CanTransition(from, to RequestStatus, userRole UserRole) bool {
-	transitions := wsm.transitions[from]
+	_, ok := wsm.GetTransition(from, to, userRole)
+	return ok
+}
 
-	for _, transition := range transitions {
-		if transition.ToStatus == to {
-			// Allow auto transitions or check role
-			if transition.AutoAllowed || transition.RequiredRole == userRole || transition.RequiredRole == UserRole("") {
-				return true
-			}
+// GetTransition returns the transition a role would take from one status to another.
+// A transition granted to the role itself is preferred over an automatic one.
+func (wsm *WorkflowStateMachine) GetTransition(from, to RequestStatus, userRole UserRole) (WorkflowTransition, bool) {
+	var match *WorkflowTransition
+
+	for i, transition := range wsm.transitions[from] {
+		if transition.ToStatus != to {
+			continue
+		}
+		if transition.RequiredRole == userRole && userRole != UserRole("") {
+			return transition, true
+		}
+		// Allow auto transitions or transitions open to every role
+		if match == nil && (transition.AutoAllowed || transition.RequiredRole == UserRole("")) {
+			match = &wsm.transitions[from][i]
 		}
 	}
 
+	if match == nil {
+		return WorkflowTransition{}, false
+	}
+	return *match, true
+}
+
+// HasTransition checks if any role, or the system, may move a request from one status to another
+func (wsm *WorkflowStateMachine) HasTransition(from, to RequestStatus) bool {
+	for _, transition := range wsm.transitions[from] {
+		if transition.ToStatus == to {
+			return true
+		}
+	}
 	return false
 }
 
+// IsInitialState checks if a request may start its life in a status: the first status of the
+// workflow path, or one it leads to directly for requests created already submitted
+func (wsm *WorkflowStateMachine) IsInitialState(status RequestStatus) bool {
+	if len(wsm.path) == 0 {
+		return false
+	}
+	return status == wsm.path[0] || wsm.HasTransition(wsm.path[0], status)
+}
+
+// GetStates returns the state definitions in the order they were declared
+func (wsm *WorkflowStateMachine) GetStates() []WorkflowStateDefinition {
+	states := make([]WorkflowStateDefinition, 0, len(wsm.stateOrder))
+	for _, status := range wsm.stateOrder {
+		states = append(states, wsm.states[status])
+	}
+	return states
+}
+
+// GetTransitions returns every transition, one per role, grouped by source state in declaration order
+func (wsm *WorkflowStateMachine) GetTransitions() []WorkflowTransition {
+	transitions := make([]WorkflowTransition, 0)
+	for _, status := range wsm.stateOrder {
+		transitions = append(transitions, wsm.transitions[status]...)
+	}
+	return transitions
+}
+
+// GetApprovalTransition returns the transition out of a status that collects approvals, if any
+func (wsm *WorkflowStateMachine) GetApprovalTransition(from RequestStatus) (WorkflowTransition, bool) {
+	for _, transition := range wsm.transitions[from] {
+		if transition.Approval != nil {
+			return transition, true
+		}
+	}
+	return WorkflowTransition{}, false
+}
+
+// GetEscalations returns the escalation rules of a status in declaration order
+func (wsm *WorkflowStateMachine) GetEscalations(status RequestStatus) []WorkflowEscalationRule {
+	return wsm.states[status].Escalations
+}
+
 // GetNextRequiredActions returns actions required to move forward
 func (wsm *WorkflowStateMachine) GetNextRequiredActions(currentStatus RequestStatus) []string {
 	actions := make([]string, 0)
 
-	switch currentStatus {
-	case StatusDraft:
-		actions = append(actions, "Submit request for review")
-	case StatusNewRequest:
-		actions = append(actions, "DEDE Admin: Accept, Reject, or Return request")
-	case StatusAccepted:
-		actions = append(actions, "DEDE Admin: Forward to DEDE Head")
-	case StatusForwarded:
-		actions = append(actions, "DEDE Head: Assign to staff or reject")
-	case StatusAssigned:
-		actions = append(actions, "Schedule appointment with factory")
-	case StatusAppointment:
-		actions = append(actions, "Conduct site inspection")
-	case StatusInspecting:
-		actions = append(actions, "Complete inspection and submit report")
-	case StatusInspectionDone:
-		actions = append(actions, "Submit audit report for review")
-	case StatusDocumentEdit:
-		actions = append(actions, "DEDE Staff: Review and approve/reject report")
-	case StatusReportApproved:
-		actions = append(actions, "Final license approval")
-	case StatusReturned:
-		actions = append(actions, "User: Update and resubmit documents")
-	case StatusOverdue:
-		actions = append(actions, "Request auto-cancelled")
-	case StatusApproved:
-		actions = append(actions, "Process completed")
-	case StatusRejected, StatusRejectedFinal:
-		actions = append(actions, "Request rejected")
+	if state, exists := wsm.states[currentStatus]; exists && state.NextAction != "" {
+		actions = append(actions, state.NextAction)
 	}
 
 	return actions
@@ -196,74 +244,48 @@ func (wsm *WorkflowStateMachine) GetRoleDisplayName(role UserRole) string {
 
 // GetStatusProgress returns progress percentage (0-100)
 func (wsm *WorkflowStateMachine) GetStatusProgress(status RequestStatus) int {
-	progressMap := map[RequestStatus]int{
-		StatusDraft:          0,
-		StatusNewRequest:     10,
-		StatusAccepted:       20,
-		StatusForwarded:      30,
-		StatusAssigned:       40,
-		StatusAppointment:    50,
-		StatusInspecting:     60,
-		StatusInspectionDone: 70,
-		StatusDocumentEdit:   80,
-		StatusReportApproved: 90,
-		StatusApproved:       100,
-		StatusRejected:       0,
-		StatusRejectedFinal:  0,
-		StatusReturned:       15,
-		StatusOverdue:        0,
-	}
-
-	if progress, exists := progressMap[status]; exists {
-		return progress
+	if state, exists := wsm.states[status]; exists {
+		return state.Progress
 	}
 	return 0
 }
 
 // IsTerminalState checks if the status is a terminal state
 func (wsm *WorkflowStateMachine) IsTerminalState(status RequestStatus) bool {
-	return status == StatusApproved || status == StatusRejectedFinal || status == StatusOverdue
+	return wsm.states[status].Terminal
+}
+
+// IsApplicantOwned checks if the status waits on the applicant rather than DEDE
+func (wsm *WorkflowStateMachine) IsApplicantOwned(status RequestStatus) bool {
+	return wsm.states[status].ApplicantOwned
+}
+
+// IssuesInvoice checks if entering the status invoices the fee of the request
+func (wsm *WorkflowStateMachine) IssuesInvoice(status RequestStatus) bool {
+	return wsm.states[status].IssueInvoice
+}
+
+// SLAClockState returns the state of the SLA clock while a request is in the status
+func (wsm *WorkflowStateMachine) SLAClockState(status RequestStatus) SLAClockState {
+	switch {
+	case wsm.IsTerminalState(status):
+		return SLAClockStopped
+	case wsm.IsApplicantOwned(status):
+		return SLAClockPaused
+	default:
+		return SLAClockRunning
+	}
 }
 
 // GetWorkflowPath returns the complete workflow path
 func (wsm *WorkflowStateMachine) GetWorkflowPath() []RequestStatus {
-	return []RequestStatus{
-		StatusDraft,
-		StatusNewRequest,
-		StatusAccepted,
-		StatusForwarded,
-		StatusAssigned,
-		StatusAppointment,
-		StatusInspecting,
-		StatusInspectionDone,
-		StatusDocumentEdit,
-		StatusReportApproved,
-		StatusApproved,
-	}
+	return append([]RequestStatus(nil), wsm.path...)
 }
 
 // GetStatusDescription returns a description of the current status
 func (wsm *WorkflowStateMachine) GetStatusDescription(status RequestStatus) string {
-	descriptions := map[RequestStatus]string{
-		StatusDraft:          "Request is being prepared by user",
-		StatusNewRequest:     "Request submitted and waiting for DEDE Admin review",
-		StatusAccepted:       "Request accepted by DEDE Admin",
-		StatusForwarded:      "Request forwarded to DEDE Head",
-		StatusAssigned:       "Request assigned to DEDE Staff/Consult",
-		StatusAppointment:    "Appointment scheduled with factory",
-		StatusInspecting:     "Site inspection in progress",
-		StatusInspectionDone: "Inspection completed, preparing report",
-		StatusDocumentEdit:   "Audit report submitted for review",
-		StatusReportApproved: "Audit report approved, pending final approval",
-		StatusApproved:       "License approved and issued",
-		StatusRejected:       "Request rejected, can be resubmitted",
-		StatusRejectedFinal:  "Request permanently rejected",
-		StatusReturned:       "Request returned to user for corrections",
-		StatusOverdue:        "Request auto-cancelled due to timeout",
-	}
-
-	if desc, exists := descriptions[status]; exists {
-		return desc
+	if state, exists := wsm.states[status]; exists && state.Description != "" {
+		return state.Description
 	}
 	return "Unknown status"
 }
@@ -274,48 +296,79 @@ func (wsm *WorkflowStateMachine) ValidateDeadline(status RequestStatus, deadline
 		return false
 	}
 
-	// Check for document edit status (14 days)
-	if status == StatusDocumentEdit {
-		return time.Now().After(*deadline)
-	}
-
-	// Check for appointment status
-	if status == StatusAppointment {
-		return time.Now().After(*deadline)
+	// Only states with an automatic transition can become overdue
+	if !wsm.hasAutoTransition(status) {
+		return false
 	}
 
-	return false
+	return time.Now().After(*deadline)
 }
 
 // GetDefaultDeadline returns the default deadline for a status
 func (wsm *WorkflowStateMachine) GetDefaultDeadline(status RequestStatus, fromTime time.Time) *time.Time {
-	var deadline time.Time
-
-	switch status {
-	case StatusDocumentEdit:
-		// 14 days for document review
-		deadline = fromTime.AddDate(0, 0, 14)
-	case StatusAppointment:
-		// 7 days for appointment
-		deadline = fromTime.AddDate(0, 0, 7)
-	default:
+	state, exists := wsm.states[status]
+	if !exists || state.DeadlineDays <= 0 {
 		return nil
 	}
 
+	deadline := fromTime.AddDate(0, 0, state.DeadlineDays)
 	return &deadline
 }
 
 // GetWorkflowSummary returns a summary of the workflow
 func (wsm *WorkflowStateMachine) GetWorkflowSummary() map[string]interface{} {
+	terminalStates := make([]string, 0)
+	autoTransitions := make([]string, 0)
+	roles := make([]string, 0)
+	seenActions := make(map[string]bool)
+	seenRoles := make(map[UserRole]bool)
+	maxDaysDelay := 0
+
+	for _, status := range wsm.stateOrder {
+		state := wsm.states[status]
+		if state.Terminal {
+			terminalStates = append(terminalStates, string(status))
+		}
+
+		for _, transition := range wsm.transitions[status] {
+			if transition.AutoAllowed {
+				if !seenActions[transition.Action] {
+					seenActions[transition.Action] = true
+					autoTransitions = append(autoTransitions, transition.Action)
+				}
+				if state.DeadlineDays > maxDaysDelay {
+					maxDaysDelay = state.DeadlineDays
+				}
+			}
+			if transition.RequiredRole != "" && !seenRoles[transition.RequiredRole] {
+				seenRoles[transition.RequiredRole] = true
+				roles = append(roles, string(transition.RequiredRole))
+			}
+		}
+	}
+
 	return map[string]interface{}{
-		"total_states":     len(wsm.GetWorkflowPath()),
-		"terminal_states":  []string{"approved", "rejected_final", "overdue"},
-		"auto_transitions": []string{"auto_overdue"},
-		"roles":            []string{"user", "admin", "dede_head", "dede_staff", "dede_consult"},
-		"max_days_delay":   14,
+		"license_type":     wsm.licenseType,
+		"version":          wsm.version,
+		"name":             wsm.name,
+		"total_states":     len(wsm.path),
+		"terminal_states":  terminalStates,
+		"auto_transitions": autoTransitions,
+		"roles":            roles,
+		"max_days_delay":   maxDaysDelay,
 	}
 }
 
+// hasAutoTransition checks if the status has an automatic outgoing transition
+func (wsm *WorkflowStateMachine) hasAutoTransition(status RequestStatus) bool {
+	for _, transition := range wsm.transitions[status] {
+		if transition.AutoAllowed {
+			return true
+		}
+	}
+	return false
+}
+
 // TransitionRequest represents a request to transition workflow state
 type TransitionRequest struct {
 	FromStatus   RequestStatus `json:"from_status" binding:"required"`
@@ -333,6 +386,6 @@ func (wsm *WorkflowStateMachine) ValidateTransitionRequest(req TransitionRequest
 		return fmt.Errorf("invalid transition from %s to %s for role %s", req.FromStatus, req.ToStatus, req.UserRole)
 	}
 
-	// Additional validation can be added here
+	// Guards need the request's data, so the transition service checks them
 	return nil
 }
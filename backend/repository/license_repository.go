@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"eservice-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// closedRequestStatuses are the statuses of requests that no longer change the license they refer to
+var closedRequestStatuses = []models.RequestStatus{
+	models.StatusApproved,
+	models.StatusRejectedFinal,
+	models.StatusWithdrawn,
+}
+
+type LicenseRepository interface {
+	Create(license *models.License) error
+	GetByID(id uint) (*models.License, error)
+	GetByLicenseNumber(licenseNumber string) (*models.License, error)
+	GetByVerificationToken(token string) (*models.License, error)
+	GetAll() ([]models.License, error)
+	GetByHolder(userID uint) ([]models.License, error)
+	Update(license *models.License) error
+	GetCorporateIDs(userID uint) ([]uint, error)
+	GetOpenRequest(licenseID uint, excludeRequestID uint) (*models.LicenseRequest, error)
+	AddCapacityEntry(entry *models.LicenseCapacityEntry) error
+	GetCapacityHistory(licenseID uint, until *time.Time) ([]models.LicenseCapacityEntry, error)
+	GetLatestCapacityEntry(licenseID uint) (*models.LicenseCapacityEntry, error)
+}
+
+type licenseRepository struct {
+	db *gorm.DB
+}
+
+// NewLicenseRepository creates a license repository. Pass the transaction handle to
+// issue or amend a license in the same transaction as the approval that causes it.
+func NewLicenseRepository(db *gorm.DB) LicenseRepository {
+	return &licenseRepository{db: db}
+}
+
+func (r *licenseRepository) Create(license *models.License) error {
+	if license.Status == "" {
+		license.Status = models.LicenseStatusActive
+	}
+	return r.db.Create(license).Error
+}
+
+func (r *licenseRepository) GetByID(id uint) (*models.License, error) {
+	var license models.License
+	err := r.db.Preload("HolderUser").Preload("HolderCorporate").First(&license, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &license, nil
+}
+
+func (r *licenseRepository) GetByLicenseNumber(licenseNumber string) (*models.License, error) {
+	var license models.License
+	err := r.db.Preload("HolderUser").Preload("HolderCorporate").
+		Where("license_number = ?", licenseNumber).First(&license).Error
+	if err != nil {
+		return nil, err
+	}
+	return &license, nil
+}
+
+func (r *licenseRepository) GetByVerificationToken(token string) (*models.License, error) {
+	var license models.License
+	err := r.db.Preload("HolderUser").Preload("HolderCorporate").
+		Where("verification_token = ?", token).First(&license).Error
+	if err != nil {
+		return nil, err
+	}
+	return &license, nil
+}
+
+func (r *licenseRepository) GetAll() ([]models.License, error) {
+	var licenses []models.License
+	err := r.db.Preload("HolderUser").Preload("HolderCorporate").
+		Order("issued_at DESC").Find(&licenses).Error
+	return licenses, err
+}
+
+// GetByHolder returns the licenses a user holds, directly or through a corporate they are an active member of
+func (r *licenseRepository) GetByHolder(userID uint) ([]models.License, error) {
+	corporateIDs, err := r.GetCorporateIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.db.Preload("HolderUser").Preload("HolderCorporate").Where("holder_user_id = ?", userID)
+	if len(corporateIDs) > 0 {
+		query = query.Or("holder_corporate_id IN ?", corporateIDs)
+	}
+
+	var licenses []models.License
+	err = query.Order("issued_at DESC").Find(&licenses).Error
+	return licenses, err
+}
+
+func (r *licenseRepository) Update(license *models.License) error {
+	return r.db.Save(license).Error
+}
+
+// GetCorporateIDs returns the corporates a user is an active member of
+func (r *licenseRepository) GetCorporateIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.CorporateMember{}).
+		Where("user_id = ? AND status = ?", userID, models.MemberStatusActive).
+		Pluck("corporate_id", &ids).Error
+	return ids, err
+}
+
+// GetOpenRequest returns a request other than the excluded one that refers to the license and
+// has not finished yet, or nil when there is none
+func (r *licenseRepository) GetOpenRequest(licenseID uint, excludeRequestID uint) (*models.LicenseRequest, error) {
+	var requests []models.LicenseRequest
+	err := r.db.Where("license_id = ? AND id <> ? AND status NOT IN ?", licenseID, excludeRequestID, closedRequestStatuses).
+		Order("created_at").Limit(1).Find(&requests).Error
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return &requests[0], nil
+}
+
+// AddCapacityEntry appends an entry to the capacity ledger of a license
+func (r *licenseRepository) AddCapacityEntry(entry *models.LicenseCapacityEntry) error {
+	if entry.RecordedAt.IsZero() {
+		entry.RecordedAt = time.Now()
+	}
+	return r.db.Create(entry).Error
+}
+
+// GetCapacityHistory returns the capacity ledger of a license oldest first, up to until when it is set
+func (r *licenseRepository) GetCapacityHistory(licenseID uint, until *time.Time) ([]models.LicenseCapacityEntry, error) {
+	query := r.db.Where("license_id = ?", licenseID)
+	if until != nil {
+		query = query.Where("recorded_at <= ?", *until)
+	}
+
+	var entries []models.LicenseCapacityEntry
+	err := query.Order("recorded_at, id").Find(&entries).Error
+	return entries, err
+}
+
+// GetLatestCapacityEntry returns the entry holding the licensed capacity, or nil when the ledger is empty
+func (r *licenseRepository) GetLatestCapacityEntry(licenseID uint) (*models.LicenseCapacityEntry, error) {
+	var entries []models.LicenseCapacityEntry
+	err := r.db.Where("license_id = ?", licenseID).
+		Order("recorded_at DESC, id DESC").Limit(1).Find(&entries).Error
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
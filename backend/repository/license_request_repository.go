@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 )
 
+// LicenseRequestRepository is the single repository for license requests of every type
 type LicenseRequestRepository interface {
 	Create(request *models.LicenseRequest) error
 	GetByID(id uint) (*models.LicenseRequest, error)
@@ -18,8 +19,6 @@ type LicenseRequestRepository interface {
 	GetByLicenseType(licenseType models.LicenseType) ([]models.LicenseRequest, error)
 	Update(request *models.LicenseRequest) error
 	Delete(id uint) error
-	UpdateStatus(id uint, status models.RequestStatus) error
-	AssignInspector(requestID, inspectorID, assignedByID uint) error
 	SetDeadline(requestID uint, deadline time.Time) error
 	GetPendingRequests() ([]models.LicenseRequest, error)
 	GetOverdueRequests() ([]models.LicenseRequest, error)
@@ -35,8 +34,23 @@ func NewLicenseRequestRepository(db *gorm.DB) LicenseRequestRepository {
 	return &licenseRequestRepository{db: db}
 }
 
+// Create stores the request together with the flow log entry for its initial status
 func (r *licenseRequestRepository) Create(request *models.LicenseRequest) error {
-	return r.db.Create(request).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(request).Error; err != nil {
+			return err
+		}
+
+		log := &models.ServiceFlowLog{
+			LicenseRequestID: request.ID,
+			PreviousStatus:   nil, // This is the initial status
+			NewStatus:        request.Status,
+			ChangedBy:        &request.UserID,
+			ChangeReason:     "คำขอถูกสร้างและส่งเข้าระบบ",
+			LicenseType:      string(request.LicenseType),
+		}
+		return tx.Create(log).Error
+	})
 }
 
 func (r *licenseRequestRepository) GetByID(id uint) (*models.LicenseRequest, error) {
@@ -93,28 +107,16 @@ func (r *licenseRequestRepository) GetByLicenseType(licenseType models.LicenseTy
 	return requests, err
 }
 
+// Update saves the request if its version still matches the stored one and bumps the version.
+// It returns ErrVersionConflict when the request was changed in the meantime.
 func (r *licenseRequestRepository) Update(request *models.LicenseRequest) error {
-	return r.db.Save(request).Error
+	return SaveVersioned(r.db, request)
 }
 
 func (r *licenseRequestRepository) Delete(id uint) error {
 	return r.db.Delete(&models.LicenseRequest{}, id).Error
 }
 
-func (r *licenseRequestRepository) UpdateStatus(id uint, status models.RequestStatus) error {
-	return r.db.Model(&models.LicenseRequest{}).Where("id = ?", id).Update("status", status).Error
-}
-
-func (r *licenseRequestRepository) AssignInspector(requestID, inspectorID, assignedByID uint) error {
-	now := time.Now()
-	return r.db.Model(&models.LicenseRequest{}).Where("id = ?", requestID).Updates(map[string]interface{}{
-		"inspector_id":   inspectorID,
-		"assigned_by_id": assignedByID,
-		"assigned_at":    &now,
-		"status":         models.StatusAssigned,
-	}).Error
-}
-
 func (r *licenseRequestRepository) SetDeadline(requestID uint, deadline time.Time) error {
 	return r.db.Model(&models.LicenseRequest{}).Where("id = ?", requestID).
 		Update("deadline", deadline).Error
@@ -139,7 +141,9 @@ func (r *licenseRequestRepository) GetOverdueRequests() ([]models.LicenseRequest
 	err := r.db.Preload("User").Preload("Inspector").Preload("AssignedBy").
 		Where("deadline < ? AND status NOT IN ?", now, []models.RequestStatus{
 			models.StatusApproved,
+			models.StatusRejected,
 			models.StatusRejectedFinal,
+			models.StatusWithdrawn,
 		}).Order("deadline ASC").Find(&requests).Error
 	return requests, err
 }
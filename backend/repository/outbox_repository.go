@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"eservice-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Create(message *models.OutboxMessage) error
+	GetPending(limit int) ([]models.OutboxMessage, error)
+	Claim(id uint) (bool, error)
+	MarkSent(id uint) error
+	MarkFailed(id uint, attempts int, lastError string, retryAt *time.Time) error
+	CountByStatus() (map[models.OutboxStatus]int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates an outbox repository. Pass the transaction handle
+// to enqueue messages atomically with the change that produced them.
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Create(message *models.OutboxMessage) error {
+	if message.Status == "" {
+		message.Status = models.OutboxStatusPending
+	}
+	if message.AvailableAt.IsZero() {
+		message.AvailableAt = time.Now()
+	}
+	return r.db.Create(message).Error
+}
+
+func (r *outboxRepository) GetPending(limit int) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := r.db.Where("status = ? AND available_at <= ?", models.OutboxStatusPending, time.Now()).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// Claim marks a pending message as processing. It returns false if another dispatcher claimed it first.
+func (r *outboxRepository) Claim(id uint) (bool, error) {
+	result := r.db.Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, models.OutboxStatusPending).
+		Update("status", models.OutboxStatusProcessing)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *outboxRepository) MarkSent(id uint) error {
+	now := time.Now()
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     models.OutboxStatusSent,
+		"sent_at":    &now,
+		"last_error": "",
+	}).Error
+}
+
+// MarkFailed records a failed attempt. A nil retryAt marks the message as permanently failed.
+func (r *outboxRepository) MarkFailed(id uint, attempts int, lastError string, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": lastError,
+		"status":     models.OutboxStatusFailed,
+	}
+	if retryAt != nil {
+		updates["status"] = models.OutboxStatusPending
+		updates["available_at"] = *retryAt
+	}
+	return r.db.Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *outboxRepository) CountByStatus() (map[models.OutboxStatus]int64, error) {
+	var rows []struct {
+		Status models.OutboxStatus
+		Count  int64
+	}
+	err := r.db.Model(&models.OutboxMessage{}).Select("status, COUNT(*) as count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.OutboxStatus]int64)
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
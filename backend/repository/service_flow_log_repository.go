@@ -29,7 +29,7 @@ func (r *serviceFlowLogRepo) Create(flowLog *models.ServiceFlowLog) error {
 
 func (r *serviceFlowLogRepo) GetByID(id uint) (*models.ServiceFlowLog, error) {
 	var flowLog models.ServiceFlowLog
-	err := r.db.Preload("ChangedByUser").First(&flowLog, id).Error
+	err := r.db.Preload("ChangedByUser").Preload("OnBehalfOfUser").First(&flowLog, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -38,13 +38,13 @@ func (r *serviceFlowLogRepo) GetByID(id uint) (*models.ServiceFlowLog, error) {
 
 func (r *serviceFlowLogRepo) GetByLicenseRequestID(licenseRequestID uint) ([]models.ServiceFlowLog, error) {
 	var flowLogs []models.ServiceFlowLog
-	err := r.db.Preload("ChangedByUser").Where("license_request_id = ?", licenseRequestID).Order("created_at DESC").Find(&flowLogs).Error
+	err := r.db.Preload("ChangedByUser").Preload("OnBehalfOfUser").Where("license_request_id = ?", licenseRequestID).Order("created_at DESC").Find(&flowLogs).Error
 	return flowLogs, err
 }
 
 func (r *serviceFlowLogRepo) GetAll() ([]models.ServiceFlowLog, error) {
 	var flowLogs []models.ServiceFlowLog
-	err := r.db.Preload("ChangedByUser").Order("created_at DESC").Find(&flowLogs).Error
+	err := r.db.Preload("ChangedByUser").Preload("OnBehalfOfUser").Order("created_at DESC").Find(&flowLogs).Error
 	return flowLogs, err
 }
 
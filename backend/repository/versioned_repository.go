@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionConflict is returned when a row was changed by someone else after it was read
+var ErrVersionConflict = errors.New("version conflict")
+
+// VersionedModel is a model guarded by an optimistic concurrency version column
+type VersionedModel interface {
+	GetVersion() int
+	SetVersion(version int)
+}
+
+// SaveVersioned writes all columns of model only if the stored version still matches the
+// model's version, and bumps the version. It returns ErrVersionConflict when the row has
+// been changed or deleted in the meantime.
+func SaveVersioned(db *gorm.DB, model VersionedModel) error {
+	expectedVersion := model.GetVersion()
+	model.SetVersion(expectedVersion + 1)
+
+	result := db.Model(model).
+		Where("version = ?", expectedVersion).
+		Select("*").
+		Omit(clause.Associations, "created_at").
+		Updates(model)
+	if result.Error != nil {
+		model.SetVersion(expectedVersion)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		model.SetVersion(expectedVersion)
+		return ErrVersionConflict
+	}
+
+	return nil
+}
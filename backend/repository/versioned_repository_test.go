@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"eservice-backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSaveVersioned(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare changes the stored request after the caller read it at version 1
+		prepare     func(t *testing.T, db *gorm.DB, id uint)
+		wantErr     error
+		wantVersion int    // Version of the caller's copy afterwards
+		wantTitle   string // Stored title afterwards
+		wantStored  int    // Stored version afterwards
+	}{
+		{name: "unchanged since read", wantVersion: 2, wantTitle: "Updated", wantStored: 2},
+		{
+			name: "changed by someone else",
+			prepare: func(t *testing.T, db *gorm.DB, id uint) {
+				require.NoError(t, db.Model(&models.LicenseRequest{}).Where("id = ?", id).
+					Updates(map[string]interface{}{"title": "Concurrent", "version": 2}).Error)
+			},
+			wantErr:     ErrVersionConflict,
+			wantVersion: 1,
+			wantTitle:   "Concurrent",
+			wantStored:  2,
+		},
+		{
+			name: "deleted in the meantime",
+			prepare: func(t *testing.T, db *gorm.DB, id uint) {
+				require.NoError(t, db.Delete(&models.LicenseRequest{}, id).Error)
+			},
+			wantErr:     ErrVersionConflict,
+			wantVersion: 1,
+			wantTitle:   "Original",
+			wantStored:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			sqlDB, err := db.DB()
+			require.NoError(t, err)
+			sqlDB.SetMaxOpenConns(1)
+			t.Cleanup(func() { sqlDB.Close() })
+			require.NoError(t, db.AutoMigrate(&models.LicenseRequest{}))
+
+			stored := &models.LicenseRequest{UserID: 1, RequestNumber: "REQ-1", LicenseType: models.LicenseTypeNew, Status: models.StatusDraft, Title: "Original"}
+			require.NoError(t, db.Omit("User").Create(stored).Error)
+			require.Equal(t, 1, stored.Version)
+
+			var read models.LicenseRequest
+			require.NoError(t, db.First(&read, stored.ID).Error)
+			if tt.prepare != nil {
+				tt.prepare(t, db, stored.ID)
+			}
+
+			read.Title = "Updated"
+			err = SaveVersioned(db, &read)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantVersion, read.Version)
+
+			var current models.LicenseRequest
+			require.NoError(t, db.Unscoped().First(&current, stored.ID).Error)
+			assert.Equal(t, tt.wantTitle, current.Title)
+			assert.Equal(t, tt.wantStored, current.Version)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"eservice-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WorkflowDefinitionRepository interface {
+	Create(definition *models.WorkflowDefinition) error
+	GetByID(id uint) (*models.WorkflowDefinition, error)
+	GetByLicenseTypeAndVersion(licenseType string, version int) (*models.WorkflowDefinition, error)
+	GetActive(licenseType string) (*models.WorkflowDefinition, error)
+	GetAll(licenseType string) ([]models.WorkflowDefinition, error)
+	GetLatestVersion(licenseType string) (int, error)
+	Activate(id uint) error
+}
+
+type workflowDefinitionRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkflowDefinitionRepository(db *gorm.DB) WorkflowDefinitionRepository {
+	return &workflowDefinitionRepository{db: db}
+}
+
+func (r *workflowDefinitionRepository) Create(definition *models.WorkflowDefinition) error {
+	return r.db.Create(definition).Error
+}
+
+func (r *workflowDefinitionRepository) GetByID(id uint) (*models.WorkflowDefinition, error) {
+	var definition models.WorkflowDefinition
+	err := r.db.First(&definition, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func (r *workflowDefinitionRepository) GetByLicenseTypeAndVersion(licenseType string, version int) (*models.WorkflowDefinition, error) {
+	var definition models.WorkflowDefinition
+	err := r.db.Where("license_type = ? AND version = ?", licenseType, version).First(&definition).Error
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func (r *workflowDefinitionRepository) GetActive(licenseType string) (*models.WorkflowDefinition, error) {
+	var definition models.WorkflowDefinition
+	err := r.db.Where("license_type = ? AND is_active = ?", licenseType, true).First(&definition).Error
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func (r *workflowDefinitionRepository) GetAll(licenseType string) ([]models.WorkflowDefinition, error) {
+	var definitions []models.WorkflowDefinition
+	query := r.db.Order("license_type ASC, version DESC")
+	if licenseType != "" {
+		query = query.Where("license_type = ?", licenseType)
+	}
+	err := query.Find(&definitions).Error
+	return definitions, err
+}
+
+func (r *workflowDefinitionRepository) GetLatestVersion(licenseType string) (int, error) {
+	var version int
+	err := r.db.Model(&models.WorkflowDefinition{}).
+		Where("license_type = ?", licenseType).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
+
+// Activate makes the definition the active one for its license type
+func (r *workflowDefinitionRepository) Activate(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var definition models.WorkflowDefinition
+		if err := tx.First(&definition, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.WorkflowDefinition{}).
+			Where("license_type = ? AND id <> ?", definition.LicenseType, definition.ID).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&definition).Updates(map[string]interface{}{
+			"is_active":    true,
+			"activated_at": &now,
+		}).Error
+	})
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"eservice-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WorkflowDelegationRepository interface {
+	Create(delegation *models.WorkflowDelegation) error
+	GetByID(id uint) (*models.WorkflowDelegation, error)
+	GetByUser(userID uint) ([]models.WorkflowDelegation, error)
+	GetAll() ([]models.WorkflowDelegation, error)
+	GetActiveForDelegate(delegateID uint, at time.Time) ([]models.WorkflowDelegation, error)
+	GetDue(at time.Time) ([]models.WorkflowDelegation, error)
+	UpdateStatus(id uint, status models.DelegationStatus, revokedAt *time.Time) (bool, error)
+}
+
+type workflowDelegationRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkflowDelegationRepository creates a delegation repository. Pass the transaction
+// handle to look up delegations in the same transaction as the action they allow.
+func NewWorkflowDelegationRepository(db *gorm.DB) WorkflowDelegationRepository {
+	return &workflowDelegationRepository{db: db}
+}
+
+func (r *workflowDelegationRepository) Create(delegation *models.WorkflowDelegation) error {
+	if delegation.Status == "" {
+		delegation.Status = models.DelegationStatusActive
+	}
+	return r.db.Create(delegation).Error
+}
+
+func (r *workflowDelegationRepository) GetByID(id uint) (*models.WorkflowDelegation, error) {
+	var delegation models.WorkflowDelegation
+	err := r.db.Preload("Delegator").Preload("Delegate").First(&delegation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// GetByUser returns the delegations a user gave or received, newest first
+func (r *workflowDelegationRepository) GetByUser(userID uint) ([]models.WorkflowDelegation, error) {
+	var delegations []models.WorkflowDelegation
+	err := r.db.Preload("Delegator").Preload("Delegate").
+		Where("delegator_id = ? OR delegate_id = ?", userID, userID).
+		Order("starts_at DESC").
+		Find(&delegations).Error
+	return delegations, err
+}
+
+func (r *workflowDelegationRepository) GetAll() ([]models.WorkflowDelegation, error) {
+	var delegations []models.WorkflowDelegation
+	err := r.db.Preload("Delegator").Preload("Delegate").Order("starts_at DESC").Find(&delegations).Error
+	return delegations, err
+}
+
+// GetActiveForDelegate returns the delegations the user may act under at the given time, oldest first
+func (r *workflowDelegationRepository) GetActiveForDelegate(delegateID uint, at time.Time) ([]models.WorkflowDelegation, error) {
+	var delegations []models.WorkflowDelegation
+	err := r.db.Where("delegate_id = ? AND status = ? AND starts_at <= ? AND ends_at > ?",
+		delegateID, models.DelegationStatusActive, at, at).
+		Order("starts_at ASC, id ASC").
+		Find(&delegations).Error
+	return delegations, err
+}
+
+// GetDue returns active delegations whose end has passed
+func (r *workflowDelegationRepository) GetDue(at time.Time) ([]models.WorkflowDelegation, error) {
+	var delegations []models.WorkflowDelegation
+	err := r.db.Preload("Delegator").Preload("Delegate").
+		Where("status = ? AND ends_at <= ?", models.DelegationStatusActive, at).
+		Find(&delegations).Error
+	return delegations, err
+}
+
+// UpdateStatus ends an active delegation. It returns false if the delegation had already ended.
+func (r *workflowDelegationRepository) UpdateStatus(id uint, status models.DelegationStatus, revokedAt *time.Time) (bool, error) {
+	result := r.db.Model(&models.WorkflowDelegation{}).
+		Where("id = ? AND status = ?", id, models.DelegationStatusActive).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"revoked_at": revokedAt,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
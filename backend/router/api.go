@@ -3,6 +3,7 @@ package router
 import (
 	"eservice-backend/config"
 	"eservice-backend/middleware"
+	workflowhandler "eservice-backend/service/workflow/handler"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -37,6 +38,9 @@ func SetupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		// Admin portal routes (mixed public and protected)
 		AdminPortalRoutes(v1, db, cfg)
 
+		// Public license verification routes for third parties (rate limited)
+		workflowhandler.SetLicenseVerificationRoutes(v1, db, cfg)
+
 		// Protected routes (authentication required)
 		protected := v1.Group("")
 		protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
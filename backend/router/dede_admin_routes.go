@@ -27,6 +27,7 @@ func DedeAdminRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 		admin.POST("/requests/:id/reject", dedeAdminHandler.RejectRequest)
 		admin.POST("/requests/:id/return", dedeAdminHandler.ReturnRequest)
 		admin.POST("/requests/:id/forward", dedeAdminHandler.ForwardRequest)
+		admin.POST("/requests/bulk", dedeAdminHandler.BulkAction)
 
 		// Dashboard
 		admin.GET("/dashboard/stats", dedeAdminHandler.GetDashboardStats)
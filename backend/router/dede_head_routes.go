@@ -17,7 +17,7 @@ func DedeHeadRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 	// DEDE Head routes (protected)
 	head := r.Group("/dede-head")
 	head.Use(middleware.AuthMiddleware(cfg.JWTSecret))
-	head.Use(middleware.RequireRole([]string{"admin", "dede_head"}))
+	head.Use(middleware.RequireRoleOrDelegation(db, []string{"admin", "dede_head"}))
 	{
 		// Get forwarded requests
 		head.GET("/forwarded-requests", dedeHeadHandler.GetForwardedRequests)
@@ -29,6 +29,7 @@ func DedeHeadRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 		head.POST("/requests/:id/assign", dedeHeadHandler.AssignRequest)
 		head.POST("/requests/:id/reject", dedeHeadHandler.RejectRequest)
 		head.POST("/requests/:id/final-approve", dedeHeadHandler.FinalApproveRequest)
+		head.POST("/requests/bulk", dedeHeadHandler.BulkAction)
 
 		// Dashboard
 		head.GET("/dashboard/stats", dedeHeadHandler.GetDashboardStats)
@@ -17,7 +17,7 @@ func DedeStaffRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 	// DEDE Staff routes (protected)
 	staff := r.Group("/dede-staff")
 	staff.Use(middleware.AuthMiddleware(cfg.JWTSecret))
-	staff.Use(middleware.RequireRole([]string{"admin", "dede_staff"}))
+	staff.Use(middleware.RequireRoleOrDelegation(db, []string{"admin", "dede_staff"}))
 	{
 		// Get tasks
 		staff.GET("/tasks", dedeStaffHandler.GetMyTasks)
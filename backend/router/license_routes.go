@@ -22,6 +22,8 @@ func LicenseRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 
 		// License request actions
 		licenses.POST("/:id/submit", licenseHandler.SubmitLicenseRequest)
+		licenses.POST("/:id/resubmit", licenseHandler.ResubmitLicenseRequest)
+		licenses.POST("/:id/withdraw", licenseHandler.WithdrawLicenseRequest)
 		licenses.POST("/:id/accept", licenseHandler.AcceptLicenseRequest)
 		licenses.POST("/:id/reject", licenseHandler.RejectLicenseRequest)
 		licenses.POST("/:id/assign", licenseHandler.AssignInspector)
@@ -38,5 +40,7 @@ func LicenseRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 		licenses.POST("/renewal", licenseHandler.CreateRenewalLicenseRequest)
 		licenses.POST("/extension", licenseHandler.CreateExtensionLicenseRequest)
 		licenses.POST("/reduction", licenseHandler.CreateReductionLicenseRequest)
+		licenses.POST("/modify", licenseHandler.CreateModifyLicenseRequest)
+		licenses.POST("/cancel", licenseHandler.CreateCancelLicenseRequest)
 	}
 }
@@ -59,4 +59,43 @@ func WorkflowRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
 	}
 	// Set up overdue routes
 	handler.SetOverdueRoutes(r, db, cfg)
+
+	// Set up workflow definition routes
+	handler.SetWorkflowDefinitionRoutes(r, db, cfg)
+
+	// Set up approval routes
+	handler.SetApprovalRoutes(r, db, cfg)
+
+	// Set up delegation routes
+	handler.SetDelegationRoutes(r, db, cfg)
+
+	// Set up escalation routes
+	handler.SetEscalationRoutes(r, db, cfg)
+
+	// Set up SLA routes
+	handler.SetSLARoutes(r, db, cfg)
+
+	// Set up submission routes
+	handler.SetSubmissionRoutes(r, db, cfg)
+
+	// Set up appeal routes
+	handler.SetAppealRoutes(r, db, cfg)
+
+	// Set up replay routes
+	handler.SetReplayRoutes(r, db, cfg)
+
+	// Set up issued license registry routes
+	handler.SetLicenseRegistryRoutes(r, db, cfg)
+
+	// Set up license enforcement routes
+	handler.SetLicenseEnforcementRoutes(r, db, cfg)
+
+	// Set up fee and payment routes
+	handler.SetPaymentRoutes(r, db, cfg)
+
+	// Set up document checklist routes
+	handler.SetDocumentChecklistRoutes(r, db, cfg)
+
+	// Set up payload schema routes
+	handler.SetPayloadSchemaRoutes(r, db, cfg)
 }
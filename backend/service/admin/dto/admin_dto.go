@@ -26,25 +26,27 @@ type StatusHistoryEntry struct {
 
 // UnifiedLicenseRequestResponse represents a unified response for all license request types
 type UnifiedLicenseRequestResponse struct {
-	ID            uint                 `json:"id"`
-	RequestNumber string               `json:"request_number"`
-	LicenseType   string               `json:"license_type"`
-	Status        string               `json:"status"`
-	Title         string               `json:"title"`
-	Description   string               `json:"description"`
-	RequestDate   time.Time            `json:"request_date"`
-	UserID        uint                 `json:"user_id"`
-	User          UserInfo             `json:"user"`
-	StatusHistory []StatusHistoryEntry `json:"status_history"`
+	ID            uint                   `json:"id"`
+	RequestNumber string                 `json:"request_number"`
+	LicenseType   string                 `json:"license_type"`
+	Status        string                 `json:"status"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	RequestDate   time.Time              `json:"request_date"`
+	UserID        uint                   `json:"user_id"`
+	User          UserInfo               `json:"user"`
+	Version       int                    `json:"version"`
+	Payload       map[string]interface{} `json:"payload"` // Type-specific fields
+	StatusHistory []StatusHistoryEntry   `json:"status_history"`
 }
 
 // GetStatusHistory retrieves the status history for a license request
-func GetStatusHistory(db *gorm.DB, requestID uint, licenseType string) []StatusHistoryEntry {
+func GetStatusHistory(db *gorm.DB, requestID uint) []StatusHistoryEntry {
 	var logs []models.ServiceFlowLog
 	var notifications []models.Notification
 
 	// Query the service flow logs for this request
-	err := db.Preload("ChangedByUser").Where("license_request_id = ? AND license_type = ?", requestID, licenseType).Order("created_at ASC").Find(&logs).Error
+	err := db.Preload("ChangedByUser").Preload("OnBehalfOfUser").Where("license_request_id = ?", requestID).Order("created_at ASC").Find(&logs).Error
 	if err != nil {
 		return []StatusHistoryEntry{}
 	}
@@ -67,6 +69,11 @@ func GetStatusHistory(db *gorm.DB, requestID uint, licenseType string) []StatusH
 
 	// Add notifications as history entries
 	for _, notif := range notifications {
+		// Escalations are listed once below rather than once per recipient
+		if notif.Type == models.NotificationType("request_escalated") {
+			continue
+		}
+
 		var description string
 		switch notif.Type {
 		case models.NotificationTypeRequestSubmitted:
@@ -101,10 +108,7 @@ func GetStatusHistory(db *gorm.DB, requestID uint, licenseType string) []StatusH
 
 	// Add each status change from service flow logs
 	for _, log := range logs {
-		officerName := "ระบบ"
-		if log.ChangedByUser != nil {
-			officerName = log.ChangedByUser.FullName
-		}
+		officerName := log.GetActorDisplayName()
 
 		var description string
 		switch log.NewStatus {
@@ -132,6 +136,12 @@ func GetStatusHistory(db *gorm.DB, requestID uint, licenseType string) []StatusH
 			description = "เอกสารถูกส่งกลับให้ผู้ยื่นเพื่อแก้ไข"
 		case models.StatusForwarded:
 			description = "ส่งเรื่องให้ DEDE Head พิจารณา"
+		case models.StatusWithdrawn:
+			description = "ผู้ยื่นคำขอถอนคำขอ"
+		case models.StatusAppealed:
+			description = "ผู้ยื่นคำขอยื่นอุทธรณ์คำสั่งปฏิเสธ"
+		case models.StatusPaymentPending:
+			description = "ออกใบแจ้งชำระค่าธรรมเนียมใบอนุญาต"
 		default:
 			description = fmt.Sprintf("สถานะเปลี่ยนเป็น %s", log.GetStatusDisplayName(log.NewStatus))
 		}
@@ -148,6 +158,23 @@ func GetStatusHistory(db *gorm.DB, requestID uint, licenseType string) []StatusH
 		})
 	}
 
+	// Add escalations of requests that waited too long
+	var escalations []models.WorkflowEscalation
+	db.Where("request_id = ?", requestID).Order("created_at ASC").Find(&escalations)
+	for _, escalation := range escalations {
+		description := fmt.Sprintf("ยกระดับคำขอ: ค้างในสถานะ %s เกิน %d วันทำการ", escalation.Status, escalation.AfterBusinessDays)
+		if escalation.Note != "" {
+			description = fmt.Sprintf("%s (%s)", description, escalation.Note)
+		}
+
+		history = append(history, StatusHistoryEntry{
+			Date:        escalation.CreatedAt.Format("2006-01-02 15:04:05"),
+			Status:      "escalated",
+			Description: description,
+			Officer:     "ระบบ",
+		})
+	}
+
 	// Sort history by date
 	for i := 0; i < len(history); i++ {
 		for j := i + 1; j < len(history); j++ {
@@ -160,77 +187,14 @@ func GetStatusHistory(db *gorm.DB, requestID uint, licenseType string) []StatusH
 	return history
 }
 
-// ConvertNewLicenseRequest converts a NewLicenseRequest to UnifiedLicenseRequestResponse
-func ConvertNewLicenseRequest(db *gorm.DB, req models.NewLicenseRequest) UnifiedLicenseRequestResponse {
-	return UnifiedLicenseRequestResponse{
-		ID:            req.ID,
-		RequestNumber: req.RequestNumber,
-		LicenseType:   "new",
-		Status:        string(req.Status),
-		Title:         req.ProjectName,
-		Description:   req.Description,
-		RequestDate:   req.CreatedAt,
-		UserID:        req.UserID,
-		User: UserInfo{
-			ID:       req.User.ID,
-			Username: req.User.Username,
-			Email:    req.User.Email,
-			FullName: req.User.FullName,
-		},
-		StatusHistory: GetStatusHistory(db, req.ID, "new"),
-	}
-}
-
-// ConvertRenewalLicenseRequest converts a RenewalLicenseRequest to UnifiedLicenseRequestResponse
-func ConvertRenewalLicenseRequest(db *gorm.DB, req models.RenewalLicenseRequest) UnifiedLicenseRequestResponse {
-	return UnifiedLicenseRequestResponse{
-		ID:            req.ID,
-		RequestNumber: req.RequestNumber,
-		LicenseType:   "renewal",
-		Status:        string(req.Status),
-		Title:         req.ProjectName,
-		Description:   req.Reason,
-		RequestDate:   req.CreatedAt,
-		UserID:        req.UserID,
-		User: UserInfo{
-			ID:       req.User.ID,
-			Username: req.User.Username,
-			Email:    req.User.Email,
-			FullName: req.User.FullName,
-		},
-		StatusHistory: GetStatusHistory(db, req.ID, "renewal"),
-	}
-}
-
-// ConvertExtensionLicenseRequest converts an ExtensionLicenseRequest to UnifiedLicenseRequestResponse
-func ConvertExtensionLicenseRequest(db *gorm.DB, req models.ExtensionLicenseRequest) UnifiedLicenseRequestResponse {
-	return UnifiedLicenseRequestResponse{
-		ID:            req.ID,
-		RequestNumber: req.RequestNumber,
-		LicenseType:   "extension",
-		Status:        string(req.Status),
-		Title:         req.ProjectName,
-		Description:   req.Description,
-		RequestDate:   req.CreatedAt,
-		UserID:        req.UserID,
-		User: UserInfo{
-			ID:       req.User.ID,
-			Username: req.User.Username,
-			Email:    req.User.Email,
-			FullName: req.User.FullName,
-		},
-		StatusHistory: GetStatusHistory(db, req.ID, "extension"),
-	}
-}
-
-// ConvertReductionLicenseRequest converts a ReductionLicenseRequest to UnifiedLicenseRequestResponse
-func ConvertReductionLicenseRequest(db *gorm.DB, req models.ReductionLicenseRequest) UnifiedLicenseRequestResponse {
+// ConvertLicenseRequest converts a LicenseRequest of any type to UnifiedLicenseRequestResponse
+func ConvertLicenseRequest(db *gorm.DB, req models.LicenseRequest) UnifiedLicenseRequestResponse {
 	return UnifiedLicenseRequestResponse{
 		ID:            req.ID,
 		RequestNumber: req.RequestNumber,
-		LicenseType:   "reduction",
+		LicenseType:   string(req.LicenseType),
 		Status:        string(req.Status),
-		Title:         req.ProjectName,
+		Title:         req.Title,
 		Description:   req.Description,
 		RequestDate:   req.CreatedAt,
 		UserID:        req.UserID,
@@ -240,6 +204,8 @@ func ConvertReductionLicenseRequest(db *gorm.DB, req models.ReductionLicenseRequ
 			Email:    req.User.Email,
 			FullName: req.User.FullName,
 		},
-		StatusHistory: GetStatusHistory(db, req.ID, "reduction"),
+		Version:       req.Version,
+		Payload:       req.PayloadFields(),
+		StatusHistory: GetStatusHistory(db, req.ID),
 	}
 }
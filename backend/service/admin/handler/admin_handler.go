@@ -6,6 +6,9 @@ import (
 	"eservice-backend/models"
 	"eservice-backend/repository"
 	"eservice-backend/service/admin/dto"
+	workflowdto "eservice-backend/service/workflow/dto"
+	workflowhandler "eservice-backend/service/workflow/handler"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 	"fmt"
 	"strconv"
@@ -16,70 +19,40 @@ import (
 )
 
 type AdminHandler struct {
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	db                   *gorm.DB
-	config               *config.Config
+	licenseRequestRepo repository.LicenseRequestRepository
+	transitionService  workflowservice.WorkflowTransitionService
+	submissionService  workflowservice.SubmissionService
+	db                 *gorm.DB
+	config             *config.Config
 }
 
 func NewAdminHandler(db *gorm.DB, cfg *config.Config) *AdminHandler {
-	newLicenseRepo := repository.NewNewLicenseRepo(db)
-	renewalLicenseRepo := repository.NewRenewalLicenseRepo(db)
-	extensionLicenseRepo := repository.NewExtensionLicenseRepo(db)
-	reductionLicenseRepo := repository.NewReductionLicenseRepo(db)
-
 	return &AdminHandler{
-		newLicenseRepo:       newLicenseRepo,
-		renewalLicenseRepo:   renewalLicenseRepo,
-		extensionLicenseRepo: extensionLicenseRepo,
-		reductionLicenseRepo: reductionLicenseRepo,
-		db:                   db,
-		config:               cfg,
+		licenseRequestRepo: repository.NewLicenseRequestRepository(db),
+		transitionService:  workflowservice.NewWorkflowTransitionService(db, cfg),
+		submissionService:  workflowservice.NewSubmissionService(db),
+		db:                 db,
+		config:             cfg,
 	}
 }
 
-// GetAllLicenseRequests handles getting all license requests from all four tables
+// GetAllLicenseRequests handles getting all license requests of every type
 func (h *AdminHandler) GetAllLicenseRequests(c *gin.Context) {
 	search := c.Query("search")
 	status := c.Query("status")
 	licenseType := c.Query("licenseType")
 
-	// Fetch requests from all four tables
-	newRequests, _ := h.newLicenseRepo.GetAll()
-	renewalRequests, _ := h.renewalLicenseRepo.GetAll()
-	extensionRequests, _ := h.extensionLicenseRepo.GetAll()
-	reductionRequests, _ := h.reductionLicenseRepo.GetAll()
+	requests, err := h.licenseRequestRepo.GetAll()
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to retrieve license requests", err)
+		return
+	}
 
 	// Convert to unified response format
 	var allRequests []dto.UnifiedLicenseRequestResponse
-
-	// Process new license requests
-	for _, req := range newRequests {
-		if h.matchesFilters(req, search, status, licenseType) {
-			allRequests = append(allRequests, dto.ConvertNewLicenseRequest(h.db, req))
-		}
-	}
-
-	// Process renewal license requests
-	for _, req := range renewalRequests {
+	for _, req := range requests {
 		if h.matchesFilters(req, search, status, licenseType) {
-			allRequests = append(allRequests, dto.ConvertRenewalLicenseRequest(h.db, req))
-		}
-	}
-
-	// Process extension license requests
-	for _, req := range extensionRequests {
-		if h.matchesFilters(req, search, status, licenseType) {
-			allRequests = append(allRequests, dto.ConvertExtensionLicenseRequest(h.db, req))
-		}
-	}
-
-	// Process reduction license requests
-	for _, req := range reductionRequests {
-		if h.matchesFilters(req, search, status, licenseType) {
-			allRequests = append(allRequests, dto.ConvertReductionLicenseRequest(h.db, req))
+			allRequests = append(allRequests, dto.ConvertLicenseRequest(h.db, req))
 		}
 	}
 
@@ -89,219 +62,133 @@ func (h *AdminHandler) GetAllLicenseRequests(c *gin.Context) {
 // GetLicenseRequestDetails handles getting details of a specific license request
 func (h *AdminHandler) GetLicenseRequestDetails(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
-
-	var response interface{}
-	var err error
 
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err == nil {
-			response = dto.ConvertNewLicenseRequest(h.db, *request)
-		}
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err == nil {
-			response = dto.ConvertRenewalLicenseRequest(h.db, *request)
-		}
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err == nil {
-			response = dto.ConvertExtensionLicenseRequest(h.db, *request)
-		}
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err == nil {
-			response = dto.ConvertReductionLicenseRequest(h.db, *request)
-		}
-	default:
-		err = errors.New("Invalid license type")
-	}
+	idInt, _ := strconv.ParseInt(id, 10, 64)
 
+	request, err := h.licenseRequestRepo.GetByID(uint(idInt))
 	if err != nil {
 		utils.ErrorNotFound(c, "License request not found", err)
 		return
 	}
 
-	utils.SuccessOK(c, "License request details retrieved successfully", response)
+	// Clients send the ETag back in If-Match when they update or transition the request
+	utils.SetVersionETag(c, request.Version)
+	utils.SuccessOK(c, "License request details retrieved successfully", dto.ConvertLicenseRequest(h.db, *request))
 }
 
 // UpdateLicenseRequest handles updating a license request (for returned documents)
 func (h *AdminHandler) UpdateLicenseRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	// Log the update request
-	fmt.Printf("Updating license request ID: %s, Type: %s\n", id, licenseType)
+	fmt.Printf("Updating license request ID: %s\n", id)
+
+	expectedVersion, ok := workflowhandler.ExpectedVersion(c)
+	if !ok {
+		return
+	}
 
-	var err error
 	idInt, _ := strconv.ParseInt(id, 10, 64)
 	requestID := uint(idInt)
 
-	switch licenseType {
-	case "new":
-		var req models.NewLicenseRequest
-		if err := c.ShouldBindJSON(&req); err != nil {
-			utils.ErrorBadRequest(c, "Invalid request body", err)
-			return
-		}
-
-		// Get the original request to preserve status
-		original, err := h.newLicenseRepo.GetByID(requestID)
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update the request with new data but preserve status and ID
-		req.ID = requestID
-		req.Status = original.Status
-		req.UserID = original.UserID
-		req.RequestNumber = original.RequestNumber
-		req.CreatedAt = original.CreatedAt
-
-		err = h.newLicenseRepo.Update(&req)
-
-	case "renewal":
-		var req struct {
-			ProjectName    string `json:"title"`
-			Reason         string `json:"description"`
-			ProjectAddress string `json:"projectAddress"`
-			ContactPerson  string `json:"contactPerson"`
-			ContactPhone   string `json:"contactPhone"`
-			ContactEmail   string `json:"contactEmail"`
-		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			utils.ErrorBadRequest(c, "Invalid request body", err)
-			return
-		}
-
-		// For renewal requests, we need to update the underlying model
-		original, err := h.renewalLicenseRepo.GetByID(requestID)
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update the original request with new data
-		original.ProjectName = req.ProjectName
-		original.Reason = req.Reason
-		original.ProjectAddress = req.ProjectAddress
-		original.ContactPerson = req.ContactPerson
-		original.ContactPhone = req.ContactPhone
-		original.ContactEmail = req.ContactEmail
-
-		// Save the updated request
-		err = h.db.Save(&original).Error
-
-	case "extension":
-		var req struct {
-			ProjectName   string `json:"title"`
-			Description   string `json:"description"`
-			ContactPerson string `json:"contactPerson"`
-			ContactPhone  string `json:"contactPhone"`
-			ContactEmail  string `json:"contactEmail"`
-		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			utils.ErrorBadRequest(c, "Invalid request body", err)
-			return
-		}
-
-		// For extension requests, we need to update the underlying model
-		original, err := h.extensionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update the original request with new data
-		original.ProjectName = req.ProjectName
-		original.Description = req.Description
-		original.ContactPerson = req.ContactPerson
-		original.ContactPhone = req.ContactPhone
-		original.ContactEmail = req.ContactEmail
-
-		// Save the updated request
-		err = h.db.Save(&original).Error
-
-	case "reduction":
-		var req struct {
-			ProjectName   string `json:"title"`
-			Description   string `json:"description"`
-			ContactPerson string `json:"contactPerson"`
-			ContactPhone  string `json:"contactPhone"`
-			ContactEmail  string `json:"contactEmail"`
-		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			utils.ErrorBadRequest(c, "Invalid request body", err)
-			return
-		}
+	var req struct {
+		Title          string                 `json:"title"`
+		Description    string                 `json:"description"`
+		ProjectAddress string                 `json:"projectAddress"`
+		ContactPerson  string                 `json:"contactPerson"`
+		ContactPhone   string                 `json:"contactPhone"`
+		ContactEmail   string                 `json:"contactEmail"`
+		Payload        map[string]interface{} `json:"payload"` // Type-specific fields to change
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
 
-		// For reduction requests, we need to update the underlying model
-		original, err := h.reductionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
+	original, err := h.licenseRequestRepo.GetByID(requestID)
+	if err != nil {
+		utils.ErrorNotFound(c, "License request not found", err)
+		return
+	}
 
-		// Update the original request with new data
-		original.ProjectName = req.ProjectName
-		original.Description = req.Description
-		original.ContactPerson = req.ContactPerson
-		original.ContactPhone = req.ContactPhone
-		original.ContactEmail = req.ContactEmail
+	// Update the original request with new data but preserve status, owner and number
+	original.Title = req.Title
+	original.Description = req.Description
+	if req.ProjectAddress != "" {
+		original.Location = req.ProjectAddress
+	}
+	original.ContactPerson = req.ContactPerson
+	original.ContactPhone = req.ContactPhone
+	original.ContactEmail = req.ContactEmail
+	if err := original.MergePayload(req.Payload); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
 
-		// Save the updated request
-		err = h.db.Save(&original).Error
+	// A returned request is resubmitted together with its corrections
+	if original.Status == models.StatusReturned {
+		h.resubmitLicenseRequest(c, original, expectedVersion)
+		return
+	}
 
-	default:
-		err = errors.New("Invalid license type")
+	// The version the client edited: If-Match wins over the stored version
+	if expectedVersion != nil {
+		original.Version = *expectedVersion
 	}
 
+	// Save the updated request unless someone else changed it meanwhile
+	err = h.licenseRequestRepo.Update(original)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		h.respondVersionConflict(c, requestID, err)
+		return
+	}
 	if err != nil {
 		fmt.Printf("Error updating license request: %v\n", err)
 		utils.ErrorBadRequest(c, "Failed to update license request", err)
 		return
 	}
 
-	// If the request was in "returned" status, update it to "new_request" to resubmit
-	// We need to check the original status before updating
-	switch licenseType {
-	case "new":
-		original, _ := h.newLicenseRepo.GetByID(requestID)
-		if original.Status == models.StatusReturned {
-			h.newLicenseRepo.UpdateStatus(requestID, models.StatusNewRequest)
-		}
-	case "renewal":
-		original, _ := h.renewalLicenseRepo.GetByID(requestID)
-		if original.Status == models.StatusReturned {
-			h.renewalLicenseRepo.UpdateStatus(requestID, models.StatusNewRequest)
-		}
-	case "extension":
-		original, _ := h.extensionLicenseRepo.GetByID(requestID)
-		if original.Status == models.StatusReturned {
-			h.extensionLicenseRepo.UpdateStatus(requestID, models.StatusNewRequest)
-		}
-	case "reduction":
-		original, _ := h.reductionLicenseRepo.GetByID(requestID)
-		if original.Status == models.StatusReturned {
-			h.reductionLicenseRepo.UpdateStatus(requestID, models.StatusNewRequest)
-		}
+	// Return the new version so the client can keep editing without re-reading the request
+	if state, stateErr := h.transitionService.GetRequestState(requestID); stateErr == nil {
+		utils.SetVersionETag(c, state.Version)
 	}
 
 	fmt.Printf("License request updated successfully\n")
 	utils.SuccessOK(c, "License request updated successfully", nil)
 }
 
+// resubmitLicenseRequest saves the corrections to a returned request and sends it back for review
+// in one transition, so the submission snapshot reviewers compare includes the corrections
+func (h *AdminHandler) resubmitLicenseRequest(c *gin.Context, request *models.LicenseRequest, expectedVersion *int) {
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("user_role")
+	role, _ := userRole.(models.UserRole)
+
+	toStatus, err := h.submissionService.GetResubmitStatus(request.ID, role)
+	if err != nil {
+		workflowhandler.RespondTransitionError(c, "Failed to update license request", err)
+		return
+	}
+
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       request.ID,
+		ExpectedVersion: expectedVersion,
+		ToStatus:        toStatus,
+		UserID:          userID.(uint),
+		UserRole:        role,
+	}, workflowservice.RequireRequestOwner(userID.(uint)), workflowservice.SaveRequestFields(request))
+	if err != nil {
+		workflowhandler.RespondTransitionError(c, "Failed to update license request", err)
+		return
+	}
+
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "License request updated successfully", result)
+}
+
 // UpdateRequestStatus handles updating the status of a license request
 func (h *AdminHandler) UpdateRequestStatus(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req struct {
 		Status string `json:"status" binding:"required"`
@@ -313,42 +200,28 @@ func (h *AdminHandler) UpdateRequestStatus(c *gin.Context) {
 		return
 	}
 
-	// Log the update request
-	fmt.Printf("Updating status for request ID: %s, Type: %s, New Status: %s\n", id, licenseType, req.Status)
-
-	var err error
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		err = h.newLicenseRepo.UpdateStatus(uint(idInt), models.RequestStatus(req.Status))
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		err = h.renewalLicenseRepo.UpdateStatus(uint(idInt), models.RequestStatus(req.Status))
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		err = h.extensionLicenseRepo.UpdateStatus(uint(idInt), models.RequestStatus(req.Status))
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		err = h.reductionLicenseRepo.UpdateStatus(uint(idInt), models.RequestStatus(req.Status))
-	default:
-		err = errors.New("Invalid license type")
+	transitionReq, ok := h.transitionRequest(c, id, models.RequestStatus(req.Status))
+	if !ok {
+		return
+	}
+	transitionReq.Comments = req.Reason
+	if transitionReq.ToStatus == models.StatusRejected || transitionReq.ToStatus == models.StatusReturned {
+		transitionReq.RejectionReason = req.Reason
 	}
 
+	result, err := h.transitionService.ProcessTransition(transitionReq)
 	if err != nil {
-		fmt.Printf("Error updating status: %v\n", err)
-		utils.ErrorBadRequest(c, "Failed to update request status", err)
+		workflowhandler.RespondTransitionError(c, "Failed to update request status", err)
 		return
 	}
 
-	fmt.Printf("Status updated successfully\n")
-	utils.SuccessOK(c, "Request status updated successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request status updated successfully", result)
 }
 
 // AssignRequest handles assigning a request to a specific role
 func (h *AdminHandler) AssignRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req struct {
 		Role       string `json:"role" binding:"required"`
@@ -362,40 +235,26 @@ func (h *AdminHandler) AssignRequest(c *gin.Context) {
 		return
 	}
 
-	idInt, _ := strconv.ParseInt(id, 10, 64)
-
-	var err error
-
-	switch licenseType {
-	case "new":
-		// For now, we'll just update the status
-		// In a real implementation, you would add proper assignment logic
-		err = h.newLicenseRepo.UpdateStatus(uint(idInt), models.StatusAssigned)
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		err = h.renewalLicenseRepo.UpdateStatus(uint(idInt), models.StatusAssigned)
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		err = h.extensionLicenseRepo.UpdateStatus(uint(idInt), models.StatusAssigned)
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		err = h.reductionLicenseRepo.UpdateStatus(uint(idInt), models.StatusAssigned)
-	default:
-		err = errors.New("Invalid license type")
+	transitionReq, ok := h.transitionRequest(c, id, models.StatusAssigned)
+	if !ok {
+		return
 	}
+	transitionReq.AssignedToID = req.AssignedTo
+	transitionReq.Comments = req.Reason
 
+	result, err := h.transitionService.ProcessTransition(transitionReq)
 	if err != nil {
-		utils.ErrorBadRequest(c, "Failed to assign request", err)
+		workflowhandler.RespondTransitionError(c, "Failed to assign request", err)
 		return
 	}
 
-	utils.SuccessOK(c, "Request assigned successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request assigned successfully", result)
 }
 
 // ReturnDocumentsToUser handles returning documents to the user for editing
 func (h *AdminHandler) ReturnDocumentsToUser(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req struct {
 		Reason string `json:"reason" binding:"required"`
@@ -406,152 +265,36 @@ func (h *AdminHandler) ReturnDocumentsToUser(c *gin.Context) {
 		return
 	}
 
-	// Get current user ID from context
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+	transitionReq, ok := h.transitionRequest(c, id, models.StatusReturned)
+	if !ok {
 		return
 	}
+	transitionReq.Comments = req.Reason
+	transitionReq.RejectionReason = req.Reason
 
-	// Convert userID to uint if needed
-	var assignedBy uint
-	if uid, ok := userID.(uint); ok {
-		assignedBy = uid
-	}
-	_ = assignedBy // Use the variable to avoid compiler error
-
-	var err error
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to returned
-		err = h.newLicenseRepo.UpdateStatus(uint(idInt), models.StatusReturned)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
-
-		// Create notification for user
-		h.createNotificationForUser(
-			request.UserID,
-			"เอกสารต้องแก้ไข",
-			"คำขอเลขที่ "+request.RequestNumber+" ต้องมีการแก้ไขเอกสาร: "+req.Reason,
-			models.NotificationTypeRequestRejected,
-			models.PriorityHigh,
-			"license_request",
-			uint(idInt),
-			"/dashboard/licenses",
-		)
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to returned
-		err = h.renewalLicenseRepo.UpdateStatus(uint(idInt), models.StatusReturned)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
-
-		// Create notification for user
-		h.createNotificationForUser(
-			request.UserID,
-			"เอกสารต้องแก้ไข",
-			"คำขอเลขที่ "+request.RequestNumber+" ต้องมีการแก้ไขเอกสาร: "+req.Reason,
-			models.NotificationTypeRequestRejected,
-			models.PriorityHigh,
-			"license_request",
-			uint(idInt),
-			"/dashboard/licenses",
-		)
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to returned
-		err = h.extensionLicenseRepo.UpdateStatus(uint(idInt), models.StatusReturned)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
-
-		// Create notification for user
-		h.createNotificationForUser(
-			request.UserID,
-			"เอกสารต้องแก้ไข",
-			"คำขอเลขที่ "+request.RequestNumber+" ต้องมีการแก้ไขเอกสาร: "+req.Reason,
-			models.NotificationTypeRequestRejected,
-			models.PriorityHigh,
-			"license_request",
-			uint(idInt),
-			"/dashboard/licenses",
-		)
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to returned
-		err = h.reductionLicenseRepo.UpdateStatus(uint(idInt), models.StatusReturned)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
-
+	result, err := h.transitionService.ProcessTransition(transitionReq, func(tc *workflowservice.TransitionContext) error {
 		// Create notification for user
-		h.createNotificationForUser(
-			request.UserID,
+		return tc.NotifyUser(
+			tc.Record.UserID,
 			"เอกสารต้องแก้ไข",
-			"คำขอเลขที่ "+request.RequestNumber+" ต้องมีการแก้ไขเอกสาร: "+req.Reason,
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ต้องมีการแก้ไขเอกสาร: "+req.Reason,
 			models.NotificationTypeRequestRejected,
 			models.PriorityHigh,
-			"license_request",
-			uint(idInt),
 			"/dashboard/licenses",
 		)
-	default:
-		err = errors.New("Invalid license type")
-	}
-
+	})
 	if err != nil {
-		utils.ErrorBadRequest(c, "Failed to return documents", err)
+		workflowhandler.RespondTransitionError(c, "Failed to return documents", err)
 		return
 	}
 
-	utils.SuccessOK(c, "Documents returned to user successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Documents returned to user successfully", result)
 }
 
 // ForwardToDedeHead handles forwarding the flow to DEDE Head role
 func (h *AdminHandler) ForwardToDedeHead(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req struct {
 		Reason string `json:"reason" binding:"required"`
@@ -562,173 +305,68 @@ func (h *AdminHandler) ForwardToDedeHead(c *gin.Context) {
 		return
 	}
 
-	// Get current user ID from context
-	_, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+	transitionReq, ok := h.transitionRequest(c, id, models.StatusForwarded)
+	if !ok {
 		return
 	}
+	transitionReq.Comments = req.Reason
 
-	var err error
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to forwarded
-		err = h.newLicenseRepo.UpdateStatus(uint(idInt), models.StatusForwarded)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
-
+	result, err := h.transitionService.ProcessTransition(transitionReq, func(tc *workflowservice.TransitionContext) error {
 		// Create notification for DEDE Head role
-		h.createNotificationForRole(
-			models.UserRole("DEDE_HEAD"),
+		return tc.NotifyRole(
+			models.RoleDEDEHead,
 			"คำขอที่ต้องดำเนินการ",
-			"คำขอเลขที่ "+request.RequestNumber+" ถูกส่งต่อให้ดำเนินการ: "+req.Reason,
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ถูกส่งต่อให้ดำเนินการ: "+req.Reason,
 			models.NotificationTypeRequestAssigned,
 			models.PriorityNormal,
-			"license_request",
-			uint(idInt),
 			"/admin-portal/services/"+id,
 		)
+	})
+	if err != nil {
+		workflowhandler.RespondTransitionError(c, "Failed to forward request", err)
+		return
+	}
 
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to forwarded
-		err = h.renewalLicenseRepo.UpdateStatus(uint(idInt), models.StatusForwarded)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
-
-		// Create notification for DEDE Head role
-		h.createNotificationForRole(
-			models.UserRole("DEDE_HEAD"),
-			"คำขอที่ต้องดำเนินการ",
-			"คำขอเลขที่ "+request.RequestNumber+" ถูกส่งต่อให้ดำเนินการ: "+req.Reason,
-			models.NotificationTypeRequestAssigned,
-			models.PriorityNormal,
-			"license_request",
-			uint(idInt),
-			"/admin-portal/services/"+id,
-		)
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to forwarded
-		err = h.extensionLicenseRepo.UpdateStatus(uint(idInt), models.StatusForwarded)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
-
-		// Create notification for DEDE Head role
-		h.createNotificationForRole(
-			models.UserRole("DEDE_HEAD"),
-			"คำขอที่ต้องดำเนินการ",
-			"คำขอเลขที่ "+request.RequestNumber+" ถูกส่งต่อให้ดำเนินการ: "+req.Reason,
-			models.NotificationTypeRequestAssigned,
-			models.PriorityNormal,
-			"license_request",
-			uint(idInt),
-			"/admin-portal/services/"+id,
-		)
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-
-		// Get the request to get user ID for notification
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "License request not found", err)
-			return
-		}
-
-		// Update status to forwarded
-		err = h.reductionLicenseRepo.UpdateStatus(uint(idInt), models.StatusForwarded)
-		if err != nil {
-			utils.ErrorBadRequest(c, "Failed to update request status", err)
-			return
-		}
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request forwarded to DEDE Head successfully", result)
+}
 
-		// Create notification for DEDE Head role
-		h.createNotificationForRole(
-			models.UserRole("DEDE_HEAD"),
-			"คำขอที่ต้องดำเนินการ",
-			"คำขอเลขที่ "+request.RequestNumber+" ถูกส่งต่อให้ดำเนินการ: "+req.Reason,
-			models.NotificationTypeRequestAssigned,
-			models.PriorityNormal,
-			"license_request",
-			uint(idInt),
-			"/admin-portal/services/"+id,
-		)
-	default:
-		err = errors.New("Invalid license type")
+// transitionRequest builds a workflow transition request for the current user.
+// It writes the error response and returns false if the user is not authenticated
+// or the If-Match header is malformed.
+func (h *AdminHandler) transitionRequest(c *gin.Context, id string, toStatus models.RequestStatus) (workflowdto.WorkflowTransitionRequest, bool) {
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return workflowdto.WorkflowTransitionRequest{}, false
 	}
+	userRole, _ := c.Get("user_role")
 
-	if err != nil {
-		utils.ErrorBadRequest(c, "Failed to forward request", err)
-		return
+	expectedVersion, ok := workflowhandler.ExpectedVersion(c)
+	if !ok {
+		return workflowdto.WorkflowTransitionRequest{}, false
 	}
 
-	utils.SuccessOK(c, "Request forwarded to DEDE Head successfully", nil)
-}
+	idInt, _ := strconv.ParseUint(id, 10, 32)
 
-// createNotificationForUser creates a notification for a specific user
-func (h *AdminHandler) createNotificationForUser(userID uint, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:       title,
-		Message:     message,
-		Type:        notifType,
-		Priority:    priority,
-		RecipientID: &userID,
-		EntityType:  entityType,
-		EntityID:    &entityID,
-		ActionURL:   actionURL,
-	}
-
-	// Save notification to database
-	h.db.Create(notification)
+	return workflowdto.WorkflowTransitionRequest{
+		RequestID:       uint(idInt),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        toStatus,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, true
 }
 
-// createNotificationForRole creates a notification for all users with a specific role
-func (h *AdminHandler) createNotificationForRole(role models.UserRole, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:         title,
-		Message:       message,
-		Type:          notifType,
-		Priority:      priority,
-		RecipientRole: &role,
-		EntityType:    entityType,
-		EntityID:      &entityID,
-		ActionURL:     actionURL,
-	}
-
-	// Save notification to database
-	h.db.Create(notification)
+// respondVersionConflict writes a 409 response carrying the request's current state
+func (h *AdminHandler) respondVersionConflict(c *gin.Context, requestID uint, err error) {
+	state, stateErr := h.transitionService.GetRequestState(requestID)
+	if stateErr != nil {
+		utils.ErrorConflict(c, "Request was modified by another user", err)
+		return
+	}
+	workflowhandler.RespondTransitionError(c, "Failed to update license request", &workflowservice.VersionConflictError{Current: *state})
 }
 
 // GetAdminUsers handles getting all admin users
@@ -767,46 +405,15 @@ func (h *AdminHandler) GetAdminUsers(c *gin.Context) {
 }
 
 // matchesFilters checks if a request matches the provided filters
-func (h *AdminHandler) matchesFilters(req interface{}, search, status, licenseType string) bool {
-	var requestNumber, title, description, reqStatus, reqLicenseType string
-	var userID uint
-
-	// Extract common fields based on request type
-	switch r := req.(type) {
-	case models.NewLicenseRequest:
-		requestNumber = r.RequestNumber
-		title = r.ProjectName
-		description = r.Description
-		reqStatus = string(r.Status)
-		reqLicenseType = "new"
-		userID = r.UserID
-	case models.RenewalLicenseRequest:
-		requestNumber = r.RequestNumber
-		title = r.ProjectName
-		description = r.Reason
-		reqStatus = string(r.Status)
-		reqLicenseType = "renewal"
-		userID = r.UserID
-	case models.ExtensionLicenseRequest:
-		requestNumber = r.RequestNumber
-		title = r.ProjectName
-		description = r.Description
-		reqStatus = string(r.Status)
-		reqLicenseType = "extension"
-		userID = r.UserID
-	case models.ReductionLicenseRequest:
-		requestNumber = r.RequestNumber
-		title = r.ProjectName
-		description = r.Description
-		reqStatus = string(r.Status)
-		reqLicenseType = "reduction"
-		userID = r.UserID
-	default:
-		return false
-	}
-
-	// Check license type filter
-	if licenseType != "" && reqLicenseType != licenseType {
+func (h *AdminHandler) matchesFilters(req models.LicenseRequest, search, status, licenseType string) bool {
+	requestNumber := req.RequestNumber
+	title := req.Title
+	description := req.Description
+	reqStatus := string(req.Status)
+	userID := req.UserID
+
+	// Check license type filter, which may use a legacy value
+	if licenseType != "" && req.LicenseType != models.ParseLicenseType(licenseType) {
 		return false
 	}
 
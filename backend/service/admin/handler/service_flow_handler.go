@@ -3,6 +3,9 @@ package handler
 import (
 	"eservice-backend/config"
 	"eservice-backend/models"
+	workflowdto "eservice-backend/service/workflow/dto"
+	workflowhandler "eservice-backend/service/workflow/handler"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 	"time"
 
@@ -11,21 +14,23 @@ import (
 )
 
 type ServiceFlowHandler struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db                *gorm.DB
+	cfg               *config.Config
+	transitionService workflowservice.WorkflowTransitionService
 }
 
 func NewServiceFlowHandler(db *gorm.DB, cfg *config.Config) *ServiceFlowHandler {
 	return &ServiceFlowHandler{
-		db:  db,
-		cfg: cfg,
+		db:                db,
+		cfg:               cfg,
+		transitionService: workflowservice.NewWorkflowTransitionService(db, cfg),
 	}
 }
 
 // GetServiceFlowLogs retrieves all service flow logs
 func (h *ServiceFlowHandler) GetServiceFlowLogs(c *gin.Context) {
 	var flowLogs []models.ServiceFlowLog
-	if err := h.db.Preload("LicenseRequest").Preload("ChangedByUser").Find(&flowLogs).Error; err != nil {
+	if err := h.db.Preload("LicenseRequest").Preload("ChangedByUser").Preload("OnBehalfOfUser").Find(&flowLogs).Error; err != nil {
 		utils.ErrorInternalServerError(c, "Failed to retrieve service flow logs", err)
 		return
 	}
@@ -37,7 +42,7 @@ func (h *ServiceFlowHandler) GetServiceFlowLogs(c *gin.Context) {
 func (h *ServiceFlowHandler) GetServiceFlowLogsByRequest(c *gin.Context) {
 	requestID := c.Param("requestId")
 	var flowLogs []models.ServiceFlowLog
-	if err := h.db.Preload("LicenseRequest").Preload("ChangedByUser").Where("license_request_id = ?", requestID).Find(&flowLogs).Error; err != nil {
+	if err := h.db.Preload("LicenseRequest").Preload("ChangedByUser").Preload("OnBehalfOfUser").Where("license_request_id = ?", requestID).Find(&flowLogs).Error; err != nil {
 		utils.ErrorInternalServerError(c, "Failed to retrieve service flow logs", err)
 		return
 	}
@@ -45,7 +50,8 @@ func (h *ServiceFlowHandler) GetServiceFlowLogsByRequest(c *gin.Context) {
 	utils.SuccessOK(c, "Service flow logs retrieved successfully", flowLogs)
 }
 
-// CreateServiceFlowLog creates a new service flow log
+// CreateServiceFlowLog changes the status of a request through the workflow, which writes the flow log
+// entry in the same transaction as the status change
 func (h *ServiceFlowHandler) CreateServiceFlowLog(c *gin.Context) {
 	var req struct {
 		LicenseRequestID uint                 `json:"license_request_id" binding:"required"`
@@ -64,71 +70,35 @@ func (h *ServiceFlowHandler) CreateServiceFlowLog(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
+	role, _ := userRole.(models.UserRole)
 
-	// Get current license request to capture previous status
-	var licenseRequest models.LicenseRequest
-	if err := h.db.First(&licenseRequest, req.LicenseRequestID).Error; err != nil {
-		utils.ErrorNotFound(c, "License request not found", err)
+	expectedVersion, ok := workflowhandler.ExpectedVersion(c)
+	if !ok {
 		return
 	}
 
-	// Create flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: req.LicenseRequestID,
-		PreviousStatus:   &licenseRequest.Status,
-		NewStatus:        req.NewStatus,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.ChangeReason,
-	}
-
-	if err := h.db.Create(flowLog).Error; err != nil {
-		utils.ErrorInternalServerError(c, "Failed to create service flow log", err)
-		return
-	}
-
-	// Update license request status
-	licenseRequest.Status = req.NewStatus
-	if err := h.db.Save(&licenseRequest).Error; err != nil {
-		utils.ErrorInternalServerError(c, "Failed to update license request status", err)
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       req.LicenseRequestID,
+		ExpectedVersion: expectedVersion,
+		ToStatus:        req.NewStatus,
+		Comments:        req.ChangeReason,
+		UserID:          userID.(uint),
+		UserRole:        role,
+	})
+	if err != nil {
+		workflowhandler.RespondTransitionError(c, "Failed to update license request status", err)
 		return
 	}
 
-	utils.SuccessCreated(c, "Service flow log created successfully", flowLog)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessCreated(c, "Service flow log created successfully", result)
 }
 
-// UpdateServiceFlowLog updates a service flow log
+// UpdateServiceFlowLog rejects edits: the flow log is the request's history, which replay and the
+// as-of view rely on. A wrong status is corrected by a new transition instead.
 func (h *ServiceFlowHandler) UpdateServiceFlowLog(c *gin.Context) {
-	id := c.Param("id")
-	var flowLog models.ServiceFlowLog
-	if err := h.db.First(&flowLog, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			utils.ErrorNotFound(c, "Service flow log not found", err)
-		} else {
-			utils.ErrorInternalServerError(c, "Failed to retrieve service flow log", err)
-		}
-		return
-	}
-
-	var req struct {
-		ChangeReason string `json:"change_reason"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ErrorBadRequest(c, "Invalid request body", err)
-		return
-	}
-
-	// Update fields
-	if req.ChangeReason != "" {
-		flowLog.ChangeReason = req.ChangeReason
-	}
-
-	if err := h.db.Save(&flowLog).Error; err != nil {
-		utils.ErrorInternalServerError(c, "Failed to update service flow log", err)
-		return
-	}
-
-	utils.SuccessOK(c, "Service flow log updated successfully", flowLog)
+	utils.ErrorForbidden(c, "Service flow logs cannot be edited", nil)
 }
 
 // GetDashboardStats retrieves dashboard statistics
@@ -139,6 +109,7 @@ func (h *ServiceFlowHandler) GetDashboardStats(c *gin.Context) {
 		InProgressRequests int64 `json:"in_progress_requests"`
 		CompletedRequests  int64 `json:"completed_requests"`
 		RejectedRequests   int64 `json:"rejected_requests"`
+		WithdrawnRequests  int64 `json:"withdrawn_requests"`
 	}
 
 	// Get total requests
@@ -148,7 +119,7 @@ func (h *ServiceFlowHandler) GetDashboardStats(c *gin.Context) {
 	h.db.Model(&models.LicenseRequest{}).Where("status IN ?", []string{"new_request", "draft"}).Count(&stats.PendingRequests)
 
 	// Get in progress requests
-	h.db.Model(&models.LicenseRequest{}).Where("status IN ?", []string{"accepted", "assigned", "appointment", "inspecting", "inspection_done", "document_edit", "report_approved"}).Count(&stats.InProgressRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status IN ?", []string{"accepted", "assigned", "appointment", "inspecting", "inspection_done", "document_edit", "report_approved", "payment_pending"}).Count(&stats.InProgressRequests)
 
 	// Get completed requests (approved)
 	h.db.Model(&models.LicenseRequest{}).Where("status = ?", "approved").Count(&stats.CompletedRequests)
@@ -156,6 +127,9 @@ func (h *ServiceFlowHandler) GetDashboardStats(c *gin.Context) {
 	// Get rejected requests
 	h.db.Model(&models.LicenseRequest{}).Where("status IN ?", []string{"rejected", "rejected_final"}).Count(&stats.RejectedRequests)
 
+	// Get requests withdrawn by the applicant
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", "withdrawn").Count(&stats.WithdrawnRequests)
+
 	utils.SuccessOK(c, "Dashboard statistics retrieved successfully", stats)
 }
 
@@ -25,6 +25,24 @@ type ForwardRequestRequest struct {
 	Comments string `json:"comments"`
 }
 
+// Actions of a bulk request action
+const (
+	BulkActionAccept  = "accept"
+	BulkActionForward = "forward"
+	BulkActionReject  = "reject"
+	BulkActionReturn  = "return"
+)
+
+// BulkActionRequest represents one action taken on several license requests at once
+type BulkActionRequest struct {
+	RequestIDs []uint       `json:"request_ids" binding:"required,min=1,max=100,dive,required"`
+	Action     string       `json:"action" binding:"required,oneof=accept forward reject return"`
+	Mode       string       `json:"mode" binding:"omitempty,oneof=best_effort all_or_nothing"` // Defaults to best_effort
+	Reason     string       `json:"reason"`                                                    // Required for every action but accept
+	Comments   string       `json:"comments"`
+	Versions   map[uint]int `json:"versions"` // Optional version each request was seen at, keyed by request ID
+}
+
 // PendingRequestResponse represents a pending request response
 type PendingRequestResponse struct {
 	ID            uint               `json:"id"`
@@ -5,7 +5,9 @@ import (
 	"eservice-backend/models"
 	"eservice-backend/repository"
 	"eservice-backend/service/dede_admin/dto"
+	workflowdto "eservice-backend/service/workflow/dto"
 	"eservice-backend/service/workflow/handler"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 	"fmt"
 	"strconv"
@@ -15,27 +17,19 @@ import (
 )
 
 type DedeAdminHandler struct {
-	db                   *gorm.DB
-	cfg                  *config.Config
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	notificationRepo     repository.NotificationRepository
-	serviceFlowLogRepo   repository.ServiceFlowLogRepo
-	workflowHandler      *handler.WorkflowHandler
+	db                 *gorm.DB
+	cfg                *config.Config
+	licenseRequestRepo repository.LicenseRequestRepository
+	transitionService  workflowservice.WorkflowTransitionService
+	workflowHandler    *handler.WorkflowHandler
 }
 
 func NewDedeAdminHandler(db *gorm.DB, cfg *config.Config) *DedeAdminHandler {
 	return &DedeAdminHandler{
-		db:                   db,
-		cfg:                  cfg,
-		newLicenseRepo:       repository.NewNewLicenseRepo(db),
-		renewalLicenseRepo:   repository.NewRenewalLicenseRepo(db),
-		extensionLicenseRepo: repository.NewExtensionLicenseRepo(db),
-		reductionLicenseRepo: repository.NewReductionLicenseRepo(db),
-		notificationRepo:     repository.NewNotificationRepository(db),
-		serviceFlowLogRepo:   repository.NewServiceFlowLogRepo(db),
+		db:                 db,
+		cfg:                cfg,
+		licenseRequestRepo: repository.NewLicenseRequestRepository(db),
+		transitionService:  workflowservice.NewWorkflowTransitionService(db, cfg),
 		workflowHandler: handler.NewWorkflowHandler(
 			nil, // dashboardService
 			nil, // taskService
@@ -57,127 +51,35 @@ func (h *DedeAdminHandler) GetPendingRequests(c *gin.Context) {
 	var allRequests []map[string]interface{}
 	var total int64
 
-	// Helper function to process requests
-	processRequests := func(requests interface{}, licenseType string) error {
-		switch reqs := requests.(type) {
-		case []models.NewLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusNewRequest {
-					// Apply search filter if provided
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-
-					// Apply license type filter if provided
-					if licenseType != "" && licenseType != "new" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "new",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Description,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"energy_type":    req.EnergyType,
-						"capacity":       req.Capacity,
-						"province":       req.Province,
-					})
-					total++
-				}
-			}
-		case []models.RenewalLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusNewRequest {
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-					if licenseType != "" && licenseType != "renewal" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "renewal",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Reason,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"license_number": req.LicenseNumber,
-						"expiry_date":    req.ExpiryDate,
-					})
-					total++
-				}
-			}
-		case []models.ExtensionLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusNewRequest {
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-					if licenseType != "" && licenseType != "extension" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "extension",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Description,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"license_number": req.LicenseNumber,
-					})
-					total++
-				}
-			}
-		case []models.ReductionLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusNewRequest {
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-					if licenseType != "" && licenseType != "reduction" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "reduction",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Description,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"license_number": req.LicenseNumber,
-					})
-					total++
-				}
-			}
-		}
-		return nil
+	requests, err := h.licenseRequestRepo.GetByStatus(models.StatusNewRequest)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to retrieve requests", err)
+		return
 	}
 
-	// Get requests from all license types
-	newRequests, _ := h.newLicenseRepo.GetAll()
-	processRequests(newRequests, "new")
-
-	renewalRequests, _ := h.renewalLicenseRepo.GetAll()
-	processRequests(renewalRequests, "renewal")
-
-	extensionRequests, _ := h.extensionLicenseRepo.GetAll()
-	processRequests(extensionRequests, "extension")
+	for _, req := range requests {
+		// Apply search filter if provided
+		if search != "" && !h.matchesSearch(req.RequestNumber, req.Title, req.User.FullName, search) {
+			continue
+		}
 
-	reductionRequests, _ := h.reductionLicenseRepo.GetAll()
-	processRequests(reductionRequests, "reduction")
+		// Type-specific fields first so the core fields always win
+		item := req.PayloadFields()
+		item["id"] = req.ID
+		item["request_number"] = req.RequestNumber
+		item["license_type"] = string(req.LicenseType)
+		item["status"] = string(req.Status)
+		item["title"] = req.Title
+		item["description"] = req.Description
+		item["request_date"] = req.CreatedAt
+		item["user"] = req.User
+		item["license_number"] = req.LicenseNumber
+		item["capacity"] = req.RequestedCapacity
+		item["version"] = req.Version
+
+		allRequests = append(allRequests, item)
+		total++
+	}
 
 	// Apply pagination
 	start := (page - 1) * limit
@@ -210,7 +112,6 @@ func (h *DedeAdminHandler) GetPendingRequests(c *gin.Context) {
 // AcceptRequest accepts a pending request
 func (h *DedeAdminHandler) AcceptRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req dto.AcceptRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -224,121 +125,33 @@ func (h *DedeAdminHandler) AcceptRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	var err error
-	var requestNumber string
-	var requestUserID uint
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		// Update status
-		request.Status = models.StatusAccepted
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusAccepted
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusAccepted
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusAccepted
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	default:
-		err = fmt.Errorf("invalid license type")
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
 	}
 
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(id),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusAccepted,
+		Comments:        req.Comments,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, h.notifyAccepted())
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to accept request", err)
+		handler.RespondTransitionError(c, "Failed to accept request", err)
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(id)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusNewRequest}[0],
-		NewStatus:        models.StatusAccepted,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for user
-	h.createNotificationForUser(
-		requestUserID,
-		"คำขอได้รับการอนุมัติ",
-		"คำขอเลขที่ "+requestNumber+" ได้รับการอนุมัติเบื้องต้นแล้ว",
-		models.NotificationTypeRequestAssigned,
-		models.PriorityNormal,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/dashboard/licenses",
-	)
-
-	// Create notification for DEDE Head
-	h.createNotificationForRole(
-		models.UserRole("dede_head"),
-		"คำขอใหม่ที่ต้องดำเนินการ",
-		"คำขอเลขที่ "+requestNumber+" พร้อมส่งต่อให้ดำเนินการ",
-		models.NotificationTypeRequestAssigned,
-		models.PriorityNormal,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/admin-portal/services",
-	)
-
-	utils.SuccessOK(c, "Request accepted successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request accepted successfully", result)
 }
 
 // RejectRequest rejects a pending request
 func (h *DedeAdminHandler) RejectRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req dto.RejectRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -352,113 +165,34 @@ func (h *DedeAdminHandler) RejectRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	var err error
-	var requestNumber string
-	var requestUserID uint
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		// Update status
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	default:
-		err = fmt.Errorf("invalid license type")
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
 	}
 
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(id),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusRejected,
+		Comments:        req.Comments,
+		RejectionReason: req.Reason,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, h.notifyRejected(req.Reason))
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to reject request", err)
+		handler.RespondTransitionError(c, "Failed to reject request", err)
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(id)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusNewRequest}[0],
-		NewStatus:        models.StatusRejected,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for user
-	h.createNotificationForUser(
-		requestUserID,
-		"คำขอถูกปฏิเสธ",
-		"คำขอเลขที่ "+requestNumber+" ถูกปฏิเสธ: "+req.Reason,
-		models.NotificationTypeRequestRejected,
-		models.PriorityHigh,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/dashboard/licenses",
-	)
-
-	utils.SuccessOK(c, "Request rejected successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request rejected successfully", result)
 }
 
 // ReturnRequest returns a request to user for corrections
 func (h *DedeAdminHandler) ReturnRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req dto.ReturnRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -472,113 +206,34 @@ func (h *DedeAdminHandler) ReturnRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	var err error
-	var requestNumber string
-	var requestUserID uint
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		// Update status
-		request.Status = models.StatusReturned
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusReturned
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusReturned
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusReturned
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	default:
-		err = fmt.Errorf("invalid license type")
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
 	}
 
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(id),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusReturned,
+		Comments:        req.Comments,
+		RejectionReason: req.Reason,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, h.notifyReturned(req.Reason))
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to return request", err)
+		handler.RespondTransitionError(c, "Failed to return request", err)
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(id)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusNewRequest}[0],
-		NewStatus:        models.StatusReturned,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for user
-	h.createNotificationForUser(
-		requestUserID,
-		"เอกสารถูกตีกลับเพื่อแก้ไข",
-		"คำขอเลขที่ "+requestNumber+" ต้องมีการแก้ไขเอกสาร: "+req.Reason,
-		models.NotificationTypeRequestRejected,
-		models.PriorityHigh,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/dashboard/licenses",
-	)
-
-	utils.SuccessOK(c, "Request returned to user successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request returned to user successfully", result)
 }
 
 // ForwardRequest forwards a request to DEDE Head
 func (h *DedeAdminHandler) ForwardRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req dto.ForwardRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -592,98 +247,89 @@ func (h *DedeAdminHandler) ForwardRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	var err error
-	var requestNumber string
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		// Update status
-		request.Status = models.StatusForwarded
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusForwarded
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
+	}
 
-		request.Status = models.StatusForwarded
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(id),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusForwarded,
+		Comments:        req.Comments,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, h.notifyForwarded(req.Reason))
+	if err != nil {
+		handler.RespondTransitionError(c, "Failed to forward request", err)
+		return
+	}
 
-		request.Status = models.StatusForwarded
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request forwarded to DEDE Head successfully", result)
+}
 
-	default:
-		err = fmt.Errorf("invalid license type")
+// BulkAction accepts, forwards, rejects or returns several requests at once with a shared comment.
+// Each request goes through the same transition validation as the single-request endpoints.
+func (h *DedeAdminHandler) BulkAction(c *gin.Context) {
+	var req dto.BulkActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
 	}
-
-	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to forward request", err)
+	if req.Action != dto.BulkActionAccept && req.Reason == "" {
+		utils.ErrorBadRequest(c, "Invalid request body", fmt.Errorf("reason is required to %s requests", req.Action))
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(id)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusAccepted}[0],
-		NewStatus:        models.StatusForwarded,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	var toStatus models.RequestStatus
+	var notify workflowservice.TransitionHook
+	switch req.Action {
+	case dto.BulkActionAccept:
+		toStatus, notify = models.StatusAccepted, h.notifyAccepted()
+	case dto.BulkActionForward:
+		toStatus, notify = models.StatusForwarded, h.notifyForwarded(req.Reason)
+	case dto.BulkActionReject:
+		toStatus, notify = models.StatusRejected, h.notifyRejected(req.Reason)
+	case dto.BulkActionReturn:
+		toStatus, notify = models.StatusReturned, h.notifyReturned(req.Reason)
 	}
 
-	h.serviceFlowLogRepo.Create(flowLog)
+	transitions := make([]workflowdto.WorkflowTransitionRequest, 0, len(req.RequestIDs))
+	for _, requestID := range req.RequestIDs {
+		transition := workflowdto.WorkflowTransitionRequest{
+			RequestID: requestID,
+			ToStatus:  toStatus,
+			Comments:  req.Comments,
+			UserID:    userID.(uint),
+			UserRole:  userRole.(models.UserRole),
+		}
+		if toStatus == models.StatusRejected || toStatus == models.StatusReturned {
+			transition.RejectionReason = req.Reason
+		}
+		if version, ok := req.Versions[requestID]; ok {
+			transition.ExpectedVersion = &version
+		}
+		transitions = append(transitions, transition)
+	}
 
-	// Create notification for DEDE Head role
-	h.createNotificationForRole(
-		models.UserRole("dede_head"),
-		"คำขอที่ต้องดำเนินการ",
-		"คำขอเลขที่ "+requestNumber+" ถูกส่งต่อให้ดำเนินการ: "+req.Reason,
-		models.NotificationTypeRequestAssigned,
-		models.PriorityNormal,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/admin-portal/services",
-	)
+	result, err := h.transitionService.ProcessTransitions(req.Action, transitions, workflowdto.BulkMode(req.Mode), notify)
+	if err != nil {
+		handler.RespondBulkError(c, "Failed to process bulk action", err)
+		return
+	}
 
-	utils.SuccessOK(c, "Request forwarded to DEDE Head successfully", nil)
+	handler.RespondBulkResult(c, result)
 }
 
 // GetDashboardStats returns dashboard statistics for DEDE Admin
@@ -697,37 +343,93 @@ func (h *DedeAdminHandler) GetDashboardStats(c *gin.Context) {
 		ForwardedRequests int64 `json:"forwarded_requests"`
 	}
 
-	// Count new license requests
-	h.db.Model(&models.NewLicenseRequest{}).Count(&stats.TotalRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusNewRequest).Count(&stats.PendingRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusAccepted).Count(&stats.AcceptedRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&stats.RejectedRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusReturned).Count(&stats.ReturnedRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusForwarded).Count(&stats.ForwardedRequests)
-
-	// Add counts from other license types
-	var renewalTotal, renewalPending, renewalAccepted, renewalRejected, renewalReturned, renewalForwarded int64
-	h.db.Model(&models.RenewalLicenseRequest{}).Count(&renewalTotal)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusNewRequest).Count(&renewalPending)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusAccepted).Count(&renewalAccepted)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&renewalRejected)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusReturned).Count(&renewalReturned)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusForwarded).Count(&renewalForwarded)
-
-	stats.TotalRequests += renewalTotal
-	stats.PendingRequests += renewalPending
-	stats.AcceptedRequests += renewalAccepted
-	stats.RejectedRequests += renewalRejected
-	stats.ReturnedRequests += renewalReturned
-	stats.ForwardedRequests += renewalForwarded
-
-	// Add extension and reduction counts similarly...
+	// Count requests of every license type by status
+	h.db.Model(&models.LicenseRequest{}).Count(&stats.TotalRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusNewRequest).Count(&stats.PendingRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusAccepted).Count(&stats.AcceptedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&stats.RejectedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusReturned).Count(&stats.ReturnedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusForwarded).Count(&stats.ForwardedRequests)
 
 	utils.SuccessOK(c, "Dashboard statistics retrieved successfully", stats)
 }
 
 // Helper functions
 
+// notifyAccepted tells the applicant and DEDE Heads that a request was accepted
+func (h *DedeAdminHandler) notifyAccepted() workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		requestNumber := tc.Record.RequestNumber
+
+		// Create notification for user
+		if err := tc.NotifyUser(
+			tc.Record.UserID,
+			"คำขอได้รับการอนุมัติ",
+			"คำขอเลขที่ "+requestNumber+" ได้รับการอนุมัติเบื้องต้นแล้ว",
+			models.NotificationTypeRequestAssigned,
+			models.PriorityNormal,
+			"/dashboard/licenses",
+		); err != nil {
+			return err
+		}
+
+		// Create notification for DEDE Head
+		return tc.NotifyRole(
+			models.RoleDEDEHead,
+			"คำขอใหม่ที่ต้องดำเนินการ",
+			"คำขอเลขที่ "+requestNumber+" พร้อมส่งต่อให้ดำเนินการ",
+			models.NotificationTypeRequestAssigned,
+			models.PriorityNormal,
+			"/admin-portal/services",
+		)
+	}
+}
+
+// notifyRejected tells the applicant that a request was rejected
+func (h *DedeAdminHandler) notifyRejected(reason string) workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		// Create notification for user
+		return tc.NotifyUser(
+			tc.Record.UserID,
+			"คำขอถูกปฏิเสธ",
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ถูกปฏิเสธ: "+reason,
+			models.NotificationTypeRequestRejected,
+			models.PriorityHigh,
+			"/dashboard/licenses",
+		)
+	}
+}
+
+// notifyReturned tells the applicant that a request needs corrections
+func (h *DedeAdminHandler) notifyReturned(reason string) workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		// Create notification for user
+		return tc.NotifyUser(
+			tc.Record.UserID,
+			"เอกสารถูกตีกลับเพื่อแก้ไข",
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ต้องมีการแก้ไขเอกสาร: "+reason,
+			models.NotificationTypeRequestRejected,
+			models.PriorityHigh,
+			"/dashboard/licenses",
+		)
+	}
+}
+
+// notifyForwarded tells DEDE Heads that a request was forwarded to them
+func (h *DedeAdminHandler) notifyForwarded(reason string) workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		// Create notification for DEDE Head role
+		return tc.NotifyRole(
+			models.RoleDEDEHead,
+			"คำขอที่ต้องดำเนินการ",
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ถูกส่งต่อให้ดำเนินการ: "+reason,
+			models.NotificationTypeRequestAssigned,
+			models.PriorityNormal,
+			"/admin-portal/services",
+		)
+	}
+}
+
 func (h *DedeAdminHandler) stringToUint(s string) uint {
 	val, _ := strconv.ParseUint(s, 10, 32)
 	return uint(val)
@@ -743,33 +445,3 @@ func (h *DedeAdminHandler) matchesSearch(requestNumber, title, userName, search
 		fmt.Sprintf("%s", titleLower) == searchLower ||
 		fmt.Sprintf("%s", userNameLower) == searchLower
 }
-
-func (h *DedeAdminHandler) createNotificationForUser(userID uint, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:       title,
-		Message:     message,
-		Type:        notifType,
-		Priority:    priority,
-		RecipientID: &userID,
-		EntityType:  entityType,
-		EntityID:    &entityID,
-		ActionURL:   actionURL,
-	}
-
-	h.notificationRepo.Create(notification)
-}
-
-func (h *DedeAdminHandler) createNotificationForRole(role models.UserRole, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:         title,
-		Message:       message,
-		Type:          notifType,
-		Priority:      priority,
-		RecipientRole: &role,
-		EntityType:    entityType,
-		EntityID:      &entityID,
-		ActionURL:     actionURL,
-	}
-
-	h.notificationRepo.Create(notification)
-}
@@ -5,7 +5,9 @@ import (
 	"eservice-backend/models"
 	"eservice-backend/repository"
 	"eservice-backend/service/dede_consults/dto"
+	workflowdto "eservice-backend/service/workflow/dto"
 	"eservice-backend/service/workflow/handler"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 	"fmt"
 	"strconv"
@@ -16,27 +18,19 @@ import (
 )
 
 type DedeConsultsHandler struct {
-	db                   *gorm.DB
-	cfg                  *config.Config
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	notificationRepo     repository.NotificationRepository
-	serviceFlowLogRepo   repository.ServiceFlowLogRepo
-	workflowHandler      *handler.WorkflowHandler
+	db                 *gorm.DB
+	cfg                *config.Config
+	licenseRequestRepo repository.LicenseRequestRepository
+	transitionService  workflowservice.WorkflowTransitionService
+	workflowHandler    *handler.WorkflowHandler
 }
 
 func NewDedeConsultsHandler(db *gorm.DB, cfg *config.Config) *DedeConsultsHandler {
 	return &DedeConsultsHandler{
-		db:                   db,
-		cfg:                  cfg,
-		newLicenseRepo:       repository.NewNewLicenseRepo(db),
-		renewalLicenseRepo:   repository.NewRenewalLicenseRepo(db),
-		extensionLicenseRepo: repository.NewExtensionLicenseRepo(db),
-		reductionLicenseRepo: repository.NewReductionLicenseRepo(db),
-		notificationRepo:     repository.NewNotificationRepository(db),
-		serviceFlowLogRepo:   repository.NewServiceFlowLogRepo(db),
+		db:                 db,
+		cfg:                cfg,
+		licenseRequestRepo: repository.NewLicenseRequestRepository(db),
+		transitionService:  workflowservice.NewWorkflowTransitionService(db, cfg),
 		workflowHandler: handler.NewWorkflowHandler(
 			nil, // dashboardService
 			nil, // taskService
@@ -96,7 +90,7 @@ func (h *DedeConsultsHandler) GetMyTasks(c *gin.Context) {
 			"appointment_date": task.AppointmentDate,
 			"assigned_at":      task.CreatedAt,
 			"comments":         task.Comments,
-			"request_details":  h.getRequestDetails(task.RequestID, task.LicenseType),
+			"request_details":  h.getRequestDetails(task.RequestID),
 		})
 	}
 
@@ -129,61 +123,54 @@ func (h *DedeConsultsHandler) ScheduleAppointment(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
 	// Get task
-	var task models.TaskAssignment
-	err := h.db.Where("id = ? AND assigned_to_id = ?", h.stringToUint(taskID), userID.(uint)).First(&task).Error
+	task, err := h.getTask(taskID, userID.(uint))
 	if err != nil {
 		utils.ErrorNotFound(c, "Task not found", err)
 		return
 	}
 
-	// Update task
-	task.AppointmentDate = &req.AppointmentDate
-	task.Status = models.TaskStatusInProgress
-	task.Comments = req.Comments
-	err = h.db.Save(&task).Error
-	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to schedule appointment", err)
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
 		return
 	}
 
-	// Update request status
-	h.updateRequestStatus(task.RequestID, task.LicenseType, models.StatusAppointment)
-
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: task.RequestID,
-		PreviousStatus:   &[]models.RequestStatus{models.StatusAssigned}[0],
-		NewStatus:        models.StatusAppointment,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     fmt.Sprintf("Appointment scheduled for %s", req.AppointmentDate.Format("2006-01-02 15:04")),
-		LicenseType:      task.LicenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create deadline reminder
-	h.createDeadlineReminder(task.RequestID, task.LicenseType, models.DeadlineTypeAppointment, req.AppointmentDate, userID.(uint))
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       task.RequestID,
+		LicenseType:     task.LicenseType,
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusAppointment,
+		Comments:        h.changeReason(req.Comments, fmt.Sprintf("Appointment scheduled for %s", req.AppointmentDate.Format("2006-01-02 15:04"))),
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+		AppointmentDate: &req.AppointmentDate,
+	}, func(tc *workflowservice.TransitionContext) error {
+		// Update task
+		if err := h.updateTaskComments(tc.Tx, task, req.Comments); err != nil {
+			return err
+		}
 
-	// Create notification for factory
-	requestDetails := h.getRequestDetails(task.RequestID, task.LicenseType)
-	if requestDetails != nil && requestDetails["user_id"] != nil {
-		h.createNotificationForUser(
-			requestDetails["user_id"].(uint),
+		// Create notification for factory
+		return tc.NotifyUser(
+			tc.Record.UserID,
 			"นัดหมายตรวจสอบระบบ",
 			fmt.Sprintf("นัดหมายตรวจสอบระบบในวันที่ %s เวลา %s",
 				req.AppointmentDate.Format("2006-01-02"),
 				req.AppointmentDate.Format("15:04")),
 			models.NotificationType("appointment_scheduled"),
 			models.PriorityNormal,
-			"license_request",
-			task.RequestID,
 			"/dashboard/licenses",
 		)
+	})
+	if err != nil {
+		handler.RespondTransitionError(c, "Failed to schedule appointment", err)
+		return
 	}
 
-	utils.SuccessOK(c, "Appointment scheduled successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Appointment scheduled successfully", result)
 }
 
 // StartInspection starts the inspection process
@@ -202,52 +189,51 @@ func (h *DedeConsultsHandler) StartInspection(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
 	// Get task
-	var task models.TaskAssignment
-	err := h.db.Where("id = ? AND assigned_to_id = ?", h.stringToUint(taskID), userID.(uint)).First(&task).Error
+	task, err := h.getTask(taskID, userID.(uint))
 	if err != nil {
 		utils.ErrorNotFound(c, "Task not found", err)
 		return
 	}
 
-	// Update task
-	task.Status = models.TaskStatusInProgress
-	task.Comments = req.Comments
-	err = h.db.Save(&task).Error
-	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to start inspection", err)
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
 		return
 	}
 
-	// Update request status
-	h.updateRequestStatus(task.RequestID, task.LicenseType, models.StatusInspecting)
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       task.RequestID,
+		LicenseType:     task.LicenseType,
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusInspecting,
+		Comments:        h.changeReason(req.Comments, "Inspection started"),
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, func(tc *workflowservice.TransitionContext) error {
+		// Update task
+		if err := h.updateTaskComments(tc.Tx, task, req.Comments); err != nil {
+			return err
+		}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: task.RequestID,
-		PreviousStatus:   &[]models.RequestStatus{models.StatusAppointment}[0],
-		NewStatus:        models.StatusInspecting,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     "Inspection started",
-		LicenseType:      task.LicenseType,
+		// Create notification for DEDE Staff
+		return tc.NotifyRole(
+			models.RoleDEDEStaff,
+			"การตรวจสอบเริ่มต้นแล้ว",
+			fmt.Sprintf("การตรวจสอบสำหรับคำขอเลขที่ %s เริ่มต้นแล้ว", tc.Record.RequestNumber),
+			models.NotificationType("inspection_started"),
+			models.PriorityNormal,
+			"/admin-portal/services",
+		)
+	})
+	if err != nil {
+		handler.RespondTransitionError(c, "Failed to start inspection", err)
+		return
 	}
 
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for DEDE Staff
-	h.createNotificationForRole(
-		models.UserRole("dede_staff"),
-		"การตรวจสอบเริ่มต้นแล้ว",
-		fmt.Sprintf("การตรวจสอบสำหรับคำขอเลขที่ %d เริ่มต้นแล้ว", task.RequestID),
-		models.NotificationType("inspection_started"),
-		models.PriorityNormal,
-		"license_request",
-		task.RequestID,
-		"/admin-portal/services",
-	)
-
-	utils.SuccessOK(c, "Inspection started successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Inspection started successfully", result)
 }
 
 // CompleteInspection completes the inspection process
@@ -266,67 +252,69 @@ func (h *DedeConsultsHandler) CompleteInspection(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
 	// Get task
-	var task models.TaskAssignment
-	err := h.db.Where("id = ? AND assigned_to_id = ?", h.stringToUint(taskID), userID.(uint)).First(&task).Error
+	task, err := h.getTask(taskID, userID.(uint))
 	if err != nil {
 		utils.ErrorNotFound(c, "Task not found", err)
 		return
 	}
 
-	// Update task
-	task.Status = models.TaskStatusCompleted
-	task.Comments = req.Comments
-	err = h.db.Save(&task).Error
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       task.RequestID,
+		LicenseType:     task.LicenseType,
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusInspectionDone,
+		Comments:        h.changeReason(req.Comments, "Inspection completed"),
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, func(tc *workflowservice.TransitionContext) error {
+		// Update task
+		if err := h.updateTaskComments(tc.Tx, task, req.Comments); err != nil {
+			return err
+		}
+
+		// Create audit report version
+		auditReportVersion := &models.AuditReportVersion{
+			ReportID:         0, // Will be created later
+			LicenseRequestID: &task.RequestID,
+			LicenseType:      task.LicenseType,
+			VersionNumber:    1,
+			Title:            fmt.Sprintf("รายงานตรวจสอบคำขอเลขที่ %s", tc.Record.RequestNumber),
+			Findings:         req.Findings,
+			Recommendations:  req.Recommendations,
+			ComplianceStatus: req.ComplianceStatus,
+			RiskLevel:        req.RiskLevel,
+			Status:           models.ReportStatusDraft,
+			SubmittedByID:    userID.(uint),
+		}
+		if err := tc.Tx.Create(auditReportVersion).Error; err != nil {
+			return fmt.Errorf("failed to create audit report: %w", err)
+		}
+
+		// Create notification for DEDE Staff
+		return tc.NotifyRole(
+			models.RoleDEDEStaff,
+			"การตรวจสอบเสร็จสิ้น",
+			fmt.Sprintf("การตรวจสอบสำหรับคำขอเลขที่ %s เสร็จสิ้น รอการตรวจสอบรายงาน", tc.Record.RequestNumber),
+			models.NotificationType("inspection_completed"),
+			models.PriorityNormal,
+			"/admin-portal/services",
+		)
+	})
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to complete inspection", err)
+		handler.RespondTransitionError(c, "Failed to complete inspection", err)
 		return
 	}
 
-	// Update request status
-	h.updateRequestStatus(task.RequestID, task.LicenseType, models.StatusInspectionDone)
-
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: task.RequestID,
-		PreviousStatus:   &[]models.RequestStatus{models.StatusInspecting}[0],
-		NewStatus:        models.StatusInspectionDone,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     "Inspection completed",
-		LicenseType:      task.LicenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create audit report version
-	auditReportVersion := &models.AuditReportVersion{
-		ReportID:         0, // Will be created later
-		VersionNumber:    1,
-		Title:            fmt.Sprintf("รายงานตรวจสอบคำขอเลขที่ %d", task.RequestID),
-		Findings:         req.Findings,
-		Recommendations:  req.Recommendations,
-		ComplianceStatus: req.ComplianceStatus,
-		RiskLevel:        req.RiskLevel,
-		Status:           models.ReportStatusDraft,
-		SubmittedByID:    userID.(uint),
-	}
-
-	h.db.Create(auditReportVersion)
-
-	// Create notification for DEDE Staff
-	h.createNotificationForRole(
-		models.UserRole("dede_staff"),
-		"การตรวจสอบเสร็จสิ้น",
-		fmt.Sprintf("การตรวจสอบสำหรับคำขอเลขที่ %d เสร็จสิ้น รอการตรวจสอบรายงาน", task.RequestID),
-		models.NotificationType("inspection_completed"),
-		models.PriorityNormal,
-		"license_request",
-		task.RequestID,
-		"/admin-portal/services",
-	)
-
-	utils.SuccessOK(c, "Inspection completed successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Inspection completed successfully", result)
 }
 
 // SubmitAuditReport submits an audit report
@@ -345,72 +333,71 @@ func (h *DedeConsultsHandler) SubmitAuditReport(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
 	// Get task
-	var task models.TaskAssignment
-	err := h.db.Where("id = ? AND assigned_to_id = ?", h.stringToUint(taskID), userID.(uint)).First(&task).Error
+	task, err := h.getTask(taskID, userID.(uint))
 	if err != nil {
 		utils.ErrorNotFound(c, "Task not found", err)
 		return
 	}
 
-	// Create or update audit report version
-	auditReportVersion := &models.AuditReportVersion{
-		ReportID:         0, // Will be created later
-		VersionNumber:    1,
-		Title:            req.Title,
-		Content:          req.Content,
-		Findings:         req.Findings,
-		Recommendations:  req.Recommendations,
-		ComplianceStatus: req.ComplianceStatus,
-		RiskLevel:        req.RiskLevel,
-		Status:           models.ReportStatusSubmitted,
-		SubmittedByID:    userID.(uint),
-	}
-
-	// Set file attachments
-	if len(req.FileAttachments) > 0 {
-		auditReportVersion.SetFileAttachments(req.FileAttachments)
-	}
-
-	err = h.db.Create(auditReportVersion).Error
-	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to submit audit report", err)
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
 		return
 	}
 
-	// Update request status
-	h.updateRequestStatus(task.RequestID, task.LicenseType, models.StatusDocumentEdit)
-
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: task.RequestID,
-		PreviousStatus:   &[]models.RequestStatus{models.StatusInspectionDone}[0],
-		NewStatus:        models.StatusDocumentEdit,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     "Audit report submitted",
-		LicenseType:      task.LicenseType,
-	}
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       task.RequestID,
+		LicenseType:     task.LicenseType,
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusDocumentEdit,
+		Comments:        "Audit report submitted",
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, func(tc *workflowservice.TransitionContext) error {
+		// Create or update audit report version
+		auditReportVersion := &models.AuditReportVersion{
+			ReportID:         0, // Will be created later
+			LicenseRequestID: &task.RequestID,
+			LicenseType:      task.LicenseType,
+			VersionNumber:    1,
+			Title:            req.Title,
+			Content:          req.Content,
+			Findings:         req.Findings,
+			Recommendations:  req.Recommendations,
+			ComplianceStatus: req.ComplianceStatus,
+			RiskLevel:        req.RiskLevel,
+			Status:           models.ReportStatusSubmitted,
+			SubmittedByID:    userID.(uint),
+		}
 
-	h.serviceFlowLogRepo.Create(flowLog)
+		// Set file attachments
+		if len(req.FileAttachments) > 0 {
+			auditReportVersion.SetFileAttachments(req.FileAttachments)
+		}
 
-	// Create deadline reminder for document review
-	deadline := time.Now().AddDate(0, 0, 14) // 14 days from now
-	h.createDeadlineReminder(task.RequestID, task.LicenseType, models.DeadlineTypeDocumentReview, deadline, userID.(uint))
+		if err := tc.Tx.Create(auditReportVersion).Error; err != nil {
+			return fmt.Errorf("failed to submit audit report: %w", err)
+		}
 
-	// Create notification for DEDE Staff
-	h.createNotificationForRole(
-		models.UserRole("dede_staff"),
-		"รายงานตรวจสอบสำหรับพิจารณา",
-		fmt.Sprintf("รายงานตรวจสอบสำหรับคำขอเลขที่ %d ส่งเพื่อพิจารณา", task.RequestID),
-		models.NotificationType("report_submitted"),
-		models.PriorityNormal,
-		"license_request",
-		task.RequestID,
-		"/admin-portal/services",
-	)
+		// Create notification for DEDE Staff
+		return tc.NotifyRole(
+			models.RoleDEDEStaff,
+			"รายงานตรวจสอบสำหรับพิจารณา",
+			fmt.Sprintf("รายงานตรวจสอบสำหรับคำขอเลขที่ %s ส่งเพื่อพิจารณา", tc.Record.RequestNumber),
+			models.NotificationType("report_submitted"),
+			models.PriorityNormal,
+			"/admin-portal/services",
+		)
+	})
+	if err != nil {
+		handler.RespondTransitionError(c, "Failed to submit audit report", err)
+		return
+	}
 
-	utils.SuccessOK(c, "Audit report submitted successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Audit report submitted successfully", result)
 }
 
 // GetDashboardStats returns dashboard statistics for DEDE Consult
@@ -457,128 +444,43 @@ func (h *DedeConsultsHandler) stringToUint(s string) uint {
 	return uint(val)
 }
 
-func (h *DedeConsultsHandler) getRequestDetails(requestID uint, licenseType string) map[string]interface{} {
-	switch licenseType {
-	case "new":
-		request, err := h.newLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
-	case "renewal":
-		request, err := h.renewalLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
-	case "extension":
-		request, err := h.extensionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
-	case "reduction":
-		request, err := h.reductionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
+func (h *DedeConsultsHandler) getRequestDetails(requestID uint) map[string]interface{} {
+	request, err := h.licenseRequestRepo.GetByID(requestID)
+	if err != nil {
+		return nil
 	}
-	return nil
-}
-
-func (h *DedeConsultsHandler) updateRequestStatus(requestID uint, licenseType string, status models.RequestStatus) {
-	switch licenseType {
-	case "new":
-		request, _ := h.newLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
-	case "renewal":
-		request, _ := h.renewalLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
-	case "extension":
-		request, _ := h.extensionLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
-	case "reduction":
-		request, _ := h.reductionLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
+	return map[string]interface{}{
+		"id":             request.ID,
+		"request_number": request.RequestNumber,
+		"license_type":   string(request.LicenseType),
+		"project_name":   request.Title,
+		"user_id":        request.UserID,
+		"user_full_name": request.User.FullName,
 	}
 }
 
-func (h *DedeConsultsHandler) createDeadlineReminder(requestID uint, licenseType string, deadlineType models.DeadlineType, deadlineDate time.Time, userID uint) {
-	reminder := &models.DeadlineReminder{
-		RequestID:    requestID,
-		LicenseType:  licenseType,
-		DeadlineType: deadlineType,
-		DeadlineDate: deadlineDate,
-		AssignedToID: &userID,
-		Status:       models.DeadlineReminderStatusActive,
+func (h *DedeConsultsHandler) getTask(taskID string, userID uint) (*models.TaskAssignment, error) {
+	var task models.TaskAssignment
+	err := h.db.Where("id = ? AND assigned_to_id = ?", h.stringToUint(taskID), userID).First(&task).Error
+	if err != nil {
+		return nil, err
 	}
-
-	h.db.Create(reminder)
+	return &task, nil
 }
 
-func (h *DedeConsultsHandler) createNotificationForUser(userID uint, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:       title,
-		Message:     message,
-		Type:        notifType,
-		Priority:    priority,
-		RecipientID: &userID,
-		EntityType:  entityType,
-		EntityID:    &entityID,
-		ActionURL:   actionURL,
+func (h *DedeConsultsHandler) updateTaskComments(tx *gorm.DB, task *models.TaskAssignment, comments string) error {
+	if comments == "" {
+		return nil
 	}
-
-	h.notificationRepo.Create(notification)
+	if err := tx.Model(task).Update("comments", comments).Error; err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	return nil
 }
 
-func (h *DedeConsultsHandler) createNotificationForRole(role models.UserRole, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:         title,
-		Message:       message,
-		Type:          notifType,
-		Priority:      priority,
-		RecipientRole: &role,
-		EntityType:    entityType,
-		EntityID:      &entityID,
-		ActionURL:     actionURL,
+func (h *DedeConsultsHandler) changeReason(comments, defaultReason string) string {
+	if comments != "" {
+		return comments
 	}
-
-	h.notificationRepo.Create(notification)
+	return defaultReason
 }
@@ -19,6 +19,24 @@ type FinalApproveRequestRequest struct {
 	Comments string `json:"comments"`
 }
 
+// Actions of a bulk request action
+const (
+	BulkActionAssign  = "assign"
+	BulkActionReject  = "reject"
+	BulkActionApprove = "approve"
+)
+
+// BulkActionRequest represents one action taken on several license requests at once
+type BulkActionRequest struct {
+	RequestIDs   []uint       `json:"request_ids" binding:"required,min=1,max=100,dive,required"`
+	Action       string       `json:"action" binding:"required,oneof=assign reject approve"`
+	Mode         string       `json:"mode" binding:"omitempty,oneof=best_effort all_or_nothing"` // Defaults to best_effort
+	AssignedToID uint         `json:"assigned_to_id"`                                            // Required to assign
+	Reason       string       `json:"reason"`                                                    // Required to reject
+	Comments     string       `json:"comments"`
+	Versions     map[uint]int `json:"versions"` // Optional version each request was seen at, keyed by request ID
+}
+
 // ForwardedRequestResponse represents a forwarded request response
 type ForwardedRequestResponse struct {
 	ID            uint                 `json:"id"`
@@ -5,40 +5,33 @@ import (
 	"eservice-backend/models"
 	"eservice-backend/repository"
 	"eservice-backend/service/dede_head/dto"
+	workflowdto "eservice-backend/service/workflow/dto"
 	"eservice-backend/service/workflow/handler"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 	"fmt"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type DedeHeadHandler struct {
-	db                   *gorm.DB
-	cfg                  *config.Config
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	userRepo             repository.UserRepository
-	notificationRepo     repository.NotificationRepository
-	serviceFlowLogRepo   repository.ServiceFlowLogRepo
-	workflowHandler      *handler.WorkflowHandler
+	db                 *gorm.DB
+	cfg                *config.Config
+	licenseRequestRepo repository.LicenseRequestRepository
+	userRepo           repository.UserRepository
+	transitionService  workflowservice.WorkflowTransitionService
+	workflowHandler    *handler.WorkflowHandler
 }
 
 func NewDedeHeadHandler(db *gorm.DB, cfg *config.Config) *DedeHeadHandler {
 	return &DedeHeadHandler{
-		db:                   db,
-		cfg:                  cfg,
-		newLicenseRepo:       repository.NewNewLicenseRepo(db),
-		renewalLicenseRepo:   repository.NewRenewalLicenseRepo(db),
-		extensionLicenseRepo: repository.NewExtensionLicenseRepo(db),
-		reductionLicenseRepo: repository.NewReductionLicenseRepo(db),
-		userRepo:             repository.NewUserRepository(db),
-		notificationRepo:     repository.NewNotificationRepository(db),
-		serviceFlowLogRepo:   repository.NewServiceFlowLogRepo(db),
+		db:                 db,
+		cfg:                cfg,
+		licenseRequestRepo: repository.NewLicenseRequestRepository(db),
+		userRepo:           repository.NewUserRepository(db),
+		transitionService:  workflowservice.NewWorkflowTransitionService(db, cfg),
 		workflowHandler: handler.NewWorkflowHandler(
 			nil, // dashboardService
 			nil, // taskService
@@ -60,127 +53,35 @@ func (h *DedeHeadHandler) GetForwardedRequests(c *gin.Context) {
 	var allRequests []map[string]interface{}
 	var total int64
 
-	// Helper function to process requests
-	processRequests := func(requests interface{}, licenseType string) error {
-		switch reqs := requests.(type) {
-		case []models.NewLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusForwarded {
-					// Apply search filter if provided
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-
-					// Apply license type filter if provided
-					if licenseType != "" && licenseType != "new" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "new",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Description,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"energy_type":    req.EnergyType,
-						"capacity":       req.Capacity,
-						"province":       req.Province,
-					})
-					total++
-				}
-			}
-		case []models.RenewalLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusForwarded {
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-					if licenseType != "" && licenseType != "renewal" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "renewal",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Reason,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"license_number": req.LicenseNumber,
-						"expiry_date":    req.ExpiryDate,
-					})
-					total++
-				}
-			}
-		case []models.ExtensionLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusForwarded {
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-					if licenseType != "" && licenseType != "extension" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "extension",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Description,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"license_number": req.LicenseNumber,
-					})
-					total++
-				}
-			}
-		case []models.ReductionLicenseRequest:
-			for _, req := range reqs {
-				if req.Status == models.StatusForwarded {
-					if search != "" && !h.matchesSearch(req.RequestNumber, req.ProjectName, req.User.FullName, search) {
-						continue
-					}
-					if licenseType != "" && licenseType != "reduction" {
-						continue
-					}
-
-					allRequests = append(allRequests, map[string]interface{}{
-						"id":             req.ID,
-						"request_number": req.RequestNumber,
-						"license_type":   "reduction",
-						"status":         string(req.Status),
-						"title":          req.ProjectName,
-						"description":    req.Description,
-						"request_date":   req.CreatedAt,
-						"user":           req.User,
-						"license_number": req.LicenseNumber,
-					})
-					total++
-				}
-			}
-		}
-		return nil
+	requests, err := h.licenseRequestRepo.GetByStatus(models.StatusForwarded)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to retrieve requests", err)
+		return
 	}
 
-	// Get requests from all license types
-	newRequests, _ := h.newLicenseRepo.GetAll()
-	processRequests(newRequests, "new")
-
-	renewalRequests, _ := h.renewalLicenseRepo.GetAll()
-	processRequests(renewalRequests, "renewal")
-
-	extensionRequests, _ := h.extensionLicenseRepo.GetAll()
-	processRequests(extensionRequests, "extension")
+	for _, req := range requests {
+		// Apply search filter if provided
+		if search != "" && !h.matchesSearch(req.RequestNumber, req.Title, req.User.FullName, search) {
+			continue
+		}
 
-	reductionRequests, _ := h.reductionLicenseRepo.GetAll()
-	processRequests(reductionRequests, "reduction")
+		// Type-specific fields first so the core fields always win
+		item := req.PayloadFields()
+		item["id"] = req.ID
+		item["request_number"] = req.RequestNumber
+		item["license_type"] = string(req.LicenseType)
+		item["status"] = string(req.Status)
+		item["title"] = req.Title
+		item["description"] = req.Description
+		item["request_date"] = req.CreatedAt
+		item["user"] = req.User
+		item["license_number"] = req.LicenseNumber
+		item["capacity"] = req.RequestedCapacity
+		item["version"] = req.Version
+
+		allRequests = append(allRequests, item)
+		total++
+	}
 
 	// Apply pagination
 	start := (page - 1) * limit
@@ -258,7 +159,6 @@ func (h *DedeHeadHandler) GetAvailableStaff(c *gin.Context) {
 // AssignRequest assigns a request to DEDE staff or consultant
 func (h *DedeHeadHandler) AssignRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req dto.AssignRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -272,147 +172,38 @@ func (h *DedeHeadHandler) AssignRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	// Validate assigned user exists
-	assignedUser, err := h.userRepo.GetByID(req.AssignedToID)
-	if err != nil {
-		utils.ErrorNotFound(c, "Assigned user not found", err)
+	if !h.validateAssignee(c, req.AssignedToID) {
 		return
 	}
 
-	// Validate assigned user has correct role
-	if assignedUser.Role != models.UserRole("dede_staff") && assignedUser.Role != models.UserRole("dede_consult") {
-		utils.ErrorBadRequest(c, "Assigned user must have DEDE Staff or DEDE Consult role", nil)
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
 		return
 	}
 
-	var requestNumber string
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		// Update request
-		request.Status = models.StatusAssigned
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{userID.(uint)}[0]
-		now := time.Now()
-		request.AssignedAt = &now
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusAssigned
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{userID.(uint)}[0]
-		now := time.Now()
-		request.AssignedAt = &now
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusAssigned
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{userID.(uint)}[0]
-		now := time.Now()
-		request.AssignedAt = &now
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusAssigned
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{userID.(uint)}[0]
-		now := time.Now()
-		request.AssignedAt = &now
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-
-	default:
-		err = fmt.Errorf("invalid license type")
-	}
-
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(id),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusAssigned,
+		AssignedToID:    req.AssignedToID,
+		Comments:        req.Comments,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, h.notifyAssigned(req.AssignedToID))
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to assign request", err)
+		handler.RespondTransitionError(c, "Failed to assign request", err)
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(id)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusForwarded}[0],
-		NewStatus:        models.StatusAssigned,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create task assignment
-	taskAssignment := &models.TaskAssignment{
-		RequestID:    uint(h.stringToUint(id)),
-		LicenseType:  licenseType,
-		AssignedToID: req.AssignedToID,
-		AssignedByID: userID.(uint),
-		AssignedRole: assignedUser.Role,
-		TaskType:     models.TaskTypeInspection,
-		Status:       models.TaskStatusPending,
-		Priority:     models.TaskPriorityNormal,
-		Comments:     req.Comments,
-	}
-
-	h.db.Create(taskAssignment)
-
-	// Create notification for assigned user
-	h.createNotificationForUser(
-		req.AssignedToID,
-		"มอบหมายงานใหม่",
-		"คำขอเลขที่ "+requestNumber+" ถูกมอบหมายให้ดำเนินการ",
-		models.NotificationTypeRequestAssigned,
-		models.PriorityNormal,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/admin-portal/services",
-	)
-
-	utils.SuccessOK(c, "Request assigned successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request assigned successfully", result)
 }
 
 // RejectRequest rejects a forwarded request
 func (h *DedeHeadHandler) RejectRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req dto.RejectRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -426,113 +217,34 @@ func (h *DedeHeadHandler) RejectRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	var err error
-	var requestNumber string
-	var requestUserID uint
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		// Update status
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusRejected
-		request.RejectionReason = req.Reason
-		request.Notes = req.Comments
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	default:
-		err = fmt.Errorf("invalid license type")
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
 	}
 
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(id),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusRejected,
+		Comments:        req.Comments,
+		RejectionReason: req.Reason,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, h.notifyRejected(req.Reason))
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to reject request", err)
+		handler.RespondTransitionError(c, "Failed to reject request", err)
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(id)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusForwarded}[0],
-		NewStatus:        models.StatusRejected,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for user
-	h.createNotificationForUser(
-		requestUserID,
-		"คำขอถูกปฏิเสธ",
-		"คำขอเลขที่ "+requestNumber+" ถูกปฏิเสธ: "+req.Reason,
-		models.NotificationTypeRequestRejected,
-		models.PriorityHigh,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/dashboard/licenses",
-	)
-
-	utils.SuccessOK(c, "Request rejected successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Request rejected successfully", result)
 }
 
 // FinalApproveRequest provides final approval for a request
 func (h *DedeHeadHandler) FinalApproveRequest(c *gin.Context) {
 	id := c.Param("id")
-	licenseType := c.Query("type")
 
 	var req dto.FinalApproveRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -546,111 +258,101 @@ func (h *DedeHeadHandler) FinalApproveRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	var err error
-	var requestNumber string
-	var requestUserID uint
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(id),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusApproved,
+		Comments:        req.Comments,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, h.notifyApproved())
+	if err != nil {
+		handler.RespondTransitionError(c, "Failed to approve request", err)
+		return
+	}
+
+	utils.SetVersionETag(c, result.Version)
+	if result.Pending {
+		utils.SuccessOK(c, "Approval recorded, waiting for other approvers", result)
+		return
+	}
+	utils.SuccessOK(c, "Request approved successfully", result)
+}
+
+// BulkAction assigns, rejects or approves several requests at once with a shared comment.
+// Each request goes through the same transition validation as the single-request endpoints.
+func (h *DedeHeadHandler) BulkAction(c *gin.Context) {
+	var req dto.BulkActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
 
-		// Update status
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	var toStatus models.RequestStatus
+	var notify workflowservice.TransitionHook
+	switch req.Action {
+	case dto.BulkActionAssign:
+		if req.AssignedToID == 0 {
+			utils.ErrorBadRequest(c, "Invalid request body", fmt.Errorf("assigned_to_id is required to assign requests"))
 			return
 		}
-
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
+		if !h.validateAssignee(c, req.AssignedToID) {
 			return
 		}
-
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(id, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
+		toStatus, notify = models.StatusAssigned, h.notifyAssigned(req.AssignedToID)
+	case dto.BulkActionReject:
+		if req.Reason == "" {
+			utils.ErrorBadRequest(c, "Invalid request body", fmt.Errorf("reason is required to reject requests"))
 			return
 		}
+		toStatus, notify = models.StatusRejected, h.notifyRejected(req.Reason)
+	case dto.BulkActionApprove:
+		toStatus, notify = models.StatusApproved, h.notifyApproved()
+	}
 
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	default:
-		err = fmt.Errorf("invalid license type")
+	transitions := make([]workflowdto.WorkflowTransitionRequest, 0, len(req.RequestIDs))
+	for _, requestID := range req.RequestIDs {
+		transition := workflowdto.WorkflowTransitionRequest{
+			RequestID: requestID,
+			ToStatus:  toStatus,
+			Comments:  req.Comments,
+			UserID:    userID.(uint),
+			UserRole:  userRole.(models.UserRole),
+		}
+		switch toStatus {
+		case models.StatusAssigned:
+			transition.AssignedToID = req.AssignedToID
+		case models.StatusRejected:
+			transition.RejectionReason = req.Reason
+		}
+		if version, ok := req.Versions[requestID]; ok {
+			transition.ExpectedVersion = &version
+		}
+		transitions = append(transitions, transition)
 	}
 
+	result, err := h.transitionService.ProcessTransitions(req.Action, transitions, workflowdto.BulkMode(req.Mode), notify)
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to approve request", err)
+		handler.RespondBulkError(c, "Failed to process bulk action", err)
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(id)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusReportApproved}[0],
-		NewStatus:        models.StatusApproved,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for user
-	h.createNotificationForUser(
-		requestUserID,
-		"คำขอได้รับการอนุมัติแล้ว",
-		"คำขอเลขที่ "+requestNumber+" ได้รับการอนุมัติแล้ว",
-		models.NotificationType("request_approved"),
-		models.PriorityHigh,
-		"license_request",
-		uint(h.stringToUint(id)),
-		"/dashboard/licenses",
-	)
-
-	utils.SuccessOK(c, "Request approved successfully", nil)
+	handler.RespondBulkResult(c, result)
 }
 
 // GetDashboardStats returns dashboard statistics for DEDE Head
@@ -662,45 +364,97 @@ func (h *DedeHeadHandler) GetDashboardStats(c *gin.Context) {
 		CompletedRequests int64 `json:"completed_requests"`
 		ApprovedRequests  int64 `json:"approved_requests"`
 		RejectedRequests  int64 `json:"rejected_requests"`
+		WithdrawnRequests int64 `json:"withdrawn_requests"`
 		StaffCount        int64 `json:"staff_count"`
 		ConsultCount      int64 `json:"consult_count"`
+		EscalatedRequests int64 `json:"escalated_requests"`
 	}
 
-	// Count requests by status
-	h.db.Model(&models.NewLicenseRequest{}).Count(&stats.TotalRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusForwarded).Count(&stats.ForwardedRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusAssigned).Count(&stats.AssignedRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusInspectionDone).Count(&stats.CompletedRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusApproved).Count(&stats.ApprovedRequests)
-	h.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&stats.RejectedRequests)
+	// Count requests of every license type by status
+	h.db.Model(&models.LicenseRequest{}).Count(&stats.TotalRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusForwarded).Count(&stats.ForwardedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusAssigned).Count(&stats.AssignedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusInspectionDone).Count(&stats.CompletedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusApproved).Count(&stats.ApprovedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&stats.RejectedRequests)
+	h.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusWithdrawn).Count(&stats.WithdrawnRequests)
 
 	// Count staff
 	h.db.Model(&models.User{}).Where("role = ?", models.UserRole("dede_staff")).Count(&stats.StaffCount)
 	h.db.Model(&models.User{}).Where("role = ?", models.UserRole("dede_consult")).Count(&stats.ConsultCount)
 
-	// Add counts from other license types
-	var renewalTotal, renewalForwarded, renewalAssigned, renewalCompleted, renewalApproved, renewalRejected int64
-	h.db.Model(&models.RenewalLicenseRequest{}).Count(&renewalTotal)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusForwarded).Count(&renewalForwarded)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusAssigned).Count(&renewalAssigned)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusInspectionDone).Count(&renewalCompleted)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusApproved).Count(&renewalApproved)
-	h.db.Model(&models.RenewalLicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&renewalRejected)
-
-	stats.TotalRequests += renewalTotal
-	stats.ForwardedRequests += renewalForwarded
-	stats.AssignedRequests += renewalAssigned
-	stats.CompletedRequests += renewalCompleted
-	stats.ApprovedRequests += renewalApproved
-	stats.RejectedRequests += renewalRejected
-
-	// Add extension and reduction counts similarly...
+	// Count requests still waiting in a status they were escalated in
+	if escalationStats, err := workflowservice.EscalationStatistics(h.db); err == nil {
+		stats.EscalatedRequests = escalationStats["escalated_requests"].(int64)
+	}
 
 	utils.SuccessOK(c, "Dashboard statistics retrieved successfully", stats)
 }
 
 // Helper functions
 
+// validateAssignee checks that requests can be assigned to a user. It writes the error response and returns false if not.
+func (h *DedeHeadHandler) validateAssignee(c *gin.Context, assignedToID uint) bool {
+	// Validate assigned user exists
+	assignedUser, err := h.userRepo.GetByID(assignedToID)
+	if err != nil {
+		utils.ErrorNotFound(c, "Assigned user not found", err)
+		return false
+	}
+
+	// Validate assigned user has correct role
+	if assignedUser.Role != models.UserRole("dede_staff") && assignedUser.Role != models.UserRole("dede_consult") {
+		utils.ErrorBadRequest(c, "Assigned user must have DEDE Staff or DEDE Consult role", nil)
+		return false
+	}
+	return true
+}
+
+// notifyAssigned tells the assigned officer about their new work
+func (h *DedeHeadHandler) notifyAssigned(assignedToID uint) workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		// Create notification for assigned user
+		return tc.NotifyUser(
+			assignedToID,
+			"มอบหมายงานใหม่",
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ถูกมอบหมายให้ดำเนินการ",
+			models.NotificationTypeRequestAssigned,
+			models.PriorityNormal,
+			"/admin-portal/services",
+		)
+	}
+}
+
+// notifyRejected tells the applicant that a request was rejected
+func (h *DedeHeadHandler) notifyRejected(reason string) workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		// Create notification for user
+		return tc.NotifyUser(
+			tc.Record.UserID,
+			"คำขอถูกปฏิเสธ",
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ถูกปฏิเสธ: "+reason,
+			models.NotificationTypeRequestRejected,
+			models.PriorityHigh,
+			"/dashboard/licenses",
+		)
+	}
+}
+
+// notifyApproved tells the applicant that a request was approved
+func (h *DedeHeadHandler) notifyApproved() workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		// Create notification for user
+		return tc.NotifyUser(
+			tc.Record.UserID,
+			"คำขอได้รับการอนุมัติแล้ว",
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ได้รับการอนุมัติแล้ว",
+			models.NotificationType("request_approved"),
+			models.PriorityHigh,
+			"/dashboard/licenses",
+		)
+	}
+}
+
 func (h *DedeHeadHandler) stringToUint(s string) uint {
 	val, _ := strconv.ParseUint(s, 10, 32)
 	return uint(val)
@@ -732,18 +486,3 @@ func (h *DedeHeadHandler) getAvailabilityStatus(assignmentCount int) string {
 		return "available"
 	}
 }
-
-func (h *DedeHeadHandler) createNotificationForUser(userID uint, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:       title,
-		Message:     message,
-		Type:        notifType,
-		Priority:    priority,
-		RecipientID: &userID,
-		EntityType:  entityType,
-		EntityID:    &entityID,
-		ActionURL:   actionURL,
-	}
-
-	h.notificationRepo.Create(notification)
-}
@@ -5,7 +5,9 @@ import (
 	"eservice-backend/models"
 	"eservice-backend/repository"
 	"eservice-backend/service/dede_staff/dto"
+	workflowdto "eservice-backend/service/workflow/dto"
 	"eservice-backend/service/workflow/handler"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 	"fmt"
 	"strconv"
@@ -16,27 +18,19 @@ import (
 )
 
 type DedeStaffHandler struct {
-	db                   *gorm.DB
-	cfg                  *config.Config
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	notificationRepo     repository.NotificationRepository
-	serviceFlowLogRepo   repository.ServiceFlowLogRepo
-	workflowHandler      *handler.WorkflowHandler
+	db                 *gorm.DB
+	cfg                *config.Config
+	licenseRequestRepo repository.LicenseRequestRepository
+	transitionService  workflowservice.WorkflowTransitionService
+	workflowHandler    *handler.WorkflowHandler
 }
 
 func NewDedeStaffHandler(db *gorm.DB, cfg *config.Config) *DedeStaffHandler {
 	return &DedeStaffHandler{
-		db:                   db,
-		cfg:                  cfg,
-		newLicenseRepo:       repository.NewNewLicenseRepo(db),
-		renewalLicenseRepo:   repository.NewRenewalLicenseRepo(db),
-		extensionLicenseRepo: repository.NewExtensionLicenseRepo(db),
-		reductionLicenseRepo: repository.NewReductionLicenseRepo(db),
-		notificationRepo:     repository.NewNotificationRepository(db),
-		serviceFlowLogRepo:   repository.NewServiceFlowLogRepo(db),
+		db:                 db,
+		cfg:                cfg,
+		licenseRequestRepo: repository.NewLicenseRequestRepository(db),
+		transitionService:  workflowservice.NewWorkflowTransitionService(db, cfg),
 		workflowHandler: handler.NewWorkflowHandler(
 			nil, // dashboardService
 			nil, // taskService
@@ -105,7 +99,7 @@ func (h *DedeStaffHandler) GetMyTasks(c *gin.Context) {
 			"appointment_date": task.AppointmentDate,
 			"assigned_at":      task.CreatedAt,
 			"comments":         task.Comments,
-			"request_details":  h.getRequestDetails(task.RequestID, task.LicenseType),
+			"request_details":  h.getRequestDetails(task.RequestID),
 		})
 	}
 
@@ -198,6 +192,19 @@ func (h *DedeStaffHandler) ReviewAuditReport(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
+
+	// Map the review outcome to the request status
+	var newStatus models.RequestStatus
+	switch req.Status {
+	case "approved":
+		newStatus = models.StatusReportApproved
+	case "rejected", "needs_edit":
+		newStatus = models.StatusReturned
+	default:
+		utils.ErrorBadRequest(c, "Invalid review status", nil)
+		return
+	}
 
 	// Get report
 	var report models.AuditReportVersion
@@ -207,84 +214,82 @@ func (h *DedeStaffHandler) ReviewAuditReport(c *gin.Context) {
 		return
 	}
 
-	// Update report
-	report.Status = models.ReportStatus(req.Status)
-	report.ReviewComments = req.Comments
-	report.ReviewedByID = &[]uint{userID.(uint)}[0]
-
-	if req.Status == "approved" {
-		report.ApprovedByID = &[]uint{userID.(uint)}[0]
-	} else if req.Status == "rejected" {
-		report.RejectionReason = req.Reason
-	} else if req.Status == "needs_edit" {
-		report.RejectionReason = req.Reason
+	if report.LicenseRequestID == nil || report.LicenseType == "" {
+		utils.ErrorUnprocessableEntity(c, "Report is not linked to a license request", nil)
+		return
 	}
 
-	err = h.db.Save(&report).Error
-	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to review report", err)
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
 		return
 	}
 
-	// Update request status based on report review
-	if req.Status == "approved" {
-		h.updateRequestStatus(report.ReportID, "new", models.StatusReportApproved)
-	} else if req.Status == "rejected" || req.Status == "needs_edit" {
-		h.updateRequestStatus(report.ReportID, "new", models.StatusReturned)
+	transitionReq := workflowdto.WorkflowTransitionRequest{
+		RequestID:       *report.LicenseRequestID,
+		LicenseType:     report.LicenseType,
+		ExpectedVersion: expectedVersion,
+		ToStatus:        newStatus,
+		Comments:        req.Comments,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
 	}
-
-	// Create service flow log
-	var newStatus models.RequestStatus
-	if req.Status == "approved" {
-		newStatus = models.StatusReportApproved
-	} else {
-		newStatus = models.StatusReturned
+	if newStatus == models.StatusReturned {
+		transitionReq.RejectionReason = req.Reason
 	}
 
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: report.ReportID,
-		PreviousStatus:   &[]models.RequestStatus{models.StatusDocumentEdit}[0],
-		NewStatus:        newStatus,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      "new",
-	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for DEDE Consult
-	h.createNotificationForUser(
-		report.SubmittedByID,
-		"รายงานตรวจสอบได้รับการตรวจสอบแล้ว",
-		fmt.Sprintf("รายงานตรวจสอบ %s ได้รับการตรวจสอบแล้ว: %s", report.Title, req.Status),
-		models.NotificationType("report_reviewed"),
-		models.PriorityNormal,
-		"audit_report",
-		report.ID,
-		"/admin-portal/services",
-	)
-
-	// If approved, create notification for DEDE Head
-	if req.Status == "approved" {
-		h.createNotificationForRole(
-			models.UserRole("dede_head"),
+	result, err := h.transitionService.ProcessTransition(transitionReq, func(tc *workflowservice.TransitionContext) error {
+		// Update report
+		report.Status = models.ReportStatus(req.Status)
+		report.ReviewComments = req.Comments
+		report.ReviewedByID = &[]uint{userID.(uint)}[0]
+
+		if req.Status == "approved" {
+			report.ApprovedByID = &[]uint{userID.(uint)}[0]
+		} else {
+			report.RejectionReason = req.Reason
+		}
+
+		if err := tc.Tx.Save(&report).Error; err != nil {
+			return fmt.Errorf("failed to review report: %w", err)
+		}
+
+		// Create notification for DEDE Consult
+		if err := tc.NotifyUser(
+			report.SubmittedByID,
+			"รายงานตรวจสอบได้รับการตรวจสอบแล้ว",
+			fmt.Sprintf("รายงานตรวจสอบ %s ได้รับการตรวจสอบแล้ว: %s", report.Title, req.Status),
+			models.NotificationType("report_reviewed"),
+			models.PriorityNormal,
+			"/admin-portal/services",
+		); err != nil {
+			return err
+		}
+
+		// If approved, create notification for DEDE Head
+		if req.Status != "approved" {
+			return nil
+		}
+		return tc.NotifyRole(
+			models.RoleDEDEHead,
 			"รายงานตรวจสอบได้รับการอนุมัติ",
 			fmt.Sprintf("รายงานตรวจสอบ %s ได้รับการอนุมัติแล้ว รอการอนุมัติสุดท้าย", report.Title),
 			models.NotificationType("report_approved"),
 			models.PriorityNormal,
-			"audit_report",
-			report.ID,
 			"/admin-portal/services",
 		)
+	})
+	if err != nil {
+		handler.RespondTransitionError(c, "Failed to review report", err)
+		return
 	}
 
-	utils.SuccessOK(c, "Report reviewed successfully", nil)
+	utils.SetVersionETag(c, result.Version)
+	utils.SuccessOK(c, "Report reviewed successfully", result)
 }
 
 // FinalApproveRequest provides final approval for a request
 func (h *DedeStaffHandler) FinalApproveRequest(c *gin.Context) {
 	requestID := c.Param("requestId")
-	licenseType := c.Query("type")
 
 	var req dto.FinalApproveRequestRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -298,111 +303,42 @@ func (h *DedeStaffHandler) FinalApproveRequest(c *gin.Context) {
 		utils.ErrorUnauthorized(c, "User not authenticated", nil)
 		return
 	}
+	userRole, _ := c.Get("user_role")
 
-	var err error
-	var requestNumber string
-	var requestUserID uint
-
-	switch licenseType {
-	case "new":
-		idInt, _ := strconv.ParseInt(requestID, 10, 64)
-		request, err := h.newLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		// Update status
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "renewal":
-		idInt, _ := strconv.ParseInt(requestID, 10, 64)
-		request, err := h.renewalLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "extension":
-		idInt, _ := strconv.ParseInt(requestID, 10, 64)
-		request, err := h.extensionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	case "reduction":
-		idInt, _ := strconv.ParseInt(requestID, 10, 64)
-		request, err := h.reductionLicenseRepo.GetByID(uint(idInt))
-		if err != nil {
-			utils.ErrorNotFound(c, "Request not found", err)
-			return
-		}
-
-		request.Status = models.StatusApproved
-		request.Notes = req.Comments
-		now := time.Now()
-		request.CompletionDate = &now
-		err = h.db.Save(request).Error
-		requestNumber = request.RequestNumber
-		requestUserID = request.UserID
-
-	default:
-		err = fmt.Errorf("invalid license type")
+	expectedVersion, ok := handler.ExpectedVersion(c)
+	if !ok {
+		return
 	}
 
+	result, err := h.transitionService.ProcessTransition(workflowdto.WorkflowTransitionRequest{
+		RequestID:       h.stringToUint(requestID),
+		ExpectedVersion: expectedVersion,
+		ToStatus:        models.StatusApproved,
+		Comments:        req.Comments,
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+	}, func(tc *workflowservice.TransitionContext) error {
+		// Create notification for user
+		return tc.NotifyUser(
+			tc.Record.UserID,
+			"คำขอได้รับการอนุมัติแล้ว",
+			"คำขอเลขที่ "+tc.Record.RequestNumber+" ได้รับการอนุมัติแล้ว",
+			models.NotificationType("request_approved"),
+			models.PriorityHigh,
+			"/dashboard/licenses",
+		)
+	})
 	if err != nil {
-		utils.ErrorInternalServerError(c, "Failed to approve request", err)
+		handler.RespondTransitionError(c, "Failed to approve request", err)
 		return
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: uint(h.stringToUint(requestID)),
-		PreviousStatus:   &[]models.RequestStatus{models.StatusReportApproved}[0],
-		NewStatus:        models.StatusApproved,
-		ChangedBy:        &[]uint{userID.(uint)}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      licenseType,
+	utils.SetVersionETag(c, result.Version)
+	if result.Pending {
+		utils.SuccessOK(c, "Approval recorded, waiting for other approvers", result)
+		return
 	}
-
-	h.serviceFlowLogRepo.Create(flowLog)
-
-	// Create notification for user
-	h.createNotificationForUser(
-		requestUserID,
-		"คำขอได้รับการอนุมัติแล้ว",
-		"คำขอเลขที่ "+requestNumber+" ได้รับการอนุมัติแล้ว",
-		models.NotificationType("request_approved"),
-		models.PriorityHigh,
-		"license_request",
-		uint(h.stringToUint(requestID)),
-		"/dashboard/licenses",
-	)
-
-	utils.SuccessOK(c, "Request approved successfully", nil)
+	utils.SuccessOK(c, "Request approved successfully", result)
 }
 
 // GetOverdueRequests returns overdue requests that need attention
@@ -436,7 +372,7 @@ func (h *DedeStaffHandler) GetOverdueRequests(c *gin.Context) {
 			"deadline_date":   reminder.DeadlineDate,
 			"assigned_to":     reminder.AssignedTo,
 			"days_overdue":    int(time.Now().Sub(reminder.DeadlineDate).Hours() / 24),
-			"request_details": h.getRequestDetails(reminder.RequestID, reminder.LicenseType),
+			"request_details": h.getRequestDetails(reminder.RequestID),
 		})
 	}
 
@@ -501,115 +437,17 @@ func (h *DedeStaffHandler) stringToUint(s string) uint {
 	return uint(val)
 }
 
-func (h *DedeStaffHandler) getRequestDetails(requestID uint, licenseType string) map[string]interface{} {
-	switch licenseType {
-	case "new":
-		request, err := h.newLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
-	case "renewal":
-		request, err := h.renewalLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
-	case "extension":
-		request, err := h.extensionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
-	case "reduction":
-		request, err := h.reductionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return nil
-		}
-		return map[string]interface{}{
-			"id":             request.ID,
-			"request_number": request.RequestNumber,
-			"project_name":   request.ProjectName,
-			"user_id":        request.UserID,
-			"user_full_name": request.User.FullName,
-		}
-	}
-	return nil
-}
-
-func (h *DedeStaffHandler) updateRequestStatus(requestID uint, licenseType string, status models.RequestStatus) {
-	switch licenseType {
-	case "new":
-		request, _ := h.newLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
-	case "renewal":
-		request, _ := h.renewalLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
-	case "extension":
-		request, _ := h.extensionLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
-	case "reduction":
-		request, _ := h.reductionLicenseRepo.GetByID(requestID)
-		if request != nil {
-			request.Status = status
-			h.db.Save(request)
-		}
-	}
-}
-
-func (h *DedeStaffHandler) createNotificationForUser(userID uint, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:       title,
-		Message:     message,
-		Type:        notifType,
-		Priority:    priority,
-		RecipientID: &userID,
-		EntityType:  entityType,
-		EntityID:    &entityID,
-		ActionURL:   actionURL,
+func (h *DedeStaffHandler) getRequestDetails(requestID uint) map[string]interface{} {
+	request, err := h.licenseRequestRepo.GetByID(requestID)
+	if err != nil {
+		return nil
 	}
-
-	h.notificationRepo.Create(notification)
-}
-
-func (h *DedeStaffHandler) createNotificationForRole(role models.UserRole, title, message string, notifType models.NotificationType, priority models.NotificationPriority, entityType string, entityID uint, actionURL string) {
-	notification := &models.Notification{
-		Title:         title,
-		Message:       message,
-		Type:          notifType,
-		Priority:      priority,
-		RecipientRole: &role,
-		EntityType:    entityType,
-		EntityID:      &entityID,
-		ActionURL:     actionURL,
+	return map[string]interface{}{
+		"id":             request.ID,
+		"request_number": request.RequestNumber,
+		"license_type":   string(request.LicenseType),
+		"project_name":   request.Title,
+		"user_id":        request.UserID,
+		"user_full_name": request.User.FullName,
 	}
-
-	h.notificationRepo.Create(notification)
 }
@@ -27,6 +27,8 @@ type CreateLicenseRequestRequest struct {
 	CurrentCapacity   float64 `json:"current_capacity"`
 	RequestedCapacity float64 `json:"requested_capacity"`
 	Location          string  `json:"location" binding:"required"`
+	LicenseNumber     string  `json:"license_number"` // Issued license a renewal, extension or reduction refers to
+	CorporateID       *uint   `json:"corporate_id"`   // Corporate a new license is requested for
 }
 
 // NewLicenseRequestRequest represents the new license request payload
@@ -46,6 +48,7 @@ type NewLicenseRequestRequest struct {
 	ContactPhone      string `json:"contactPhone" binding:"required"`
 	ContactEmail      string `json:"contactEmail" binding:"required"`
 	Description       string `json:"description" binding:"required"`
+	CorporateID       *uint  `json:"corporateId"` // Corporate the license is requested for; it will hold the license
 }
 
 // RenewalLicenseRequestRequest represents the renewal license request payload
@@ -100,13 +103,55 @@ type ReductionLicenseRequestRequest struct {
 	Description           string `json:"description" binding:"required"`
 }
 
+// ModifyLicenseRequestRequest represents the modify license request payload. Particulars left
+// empty stay as they are on the license.
+type ModifyLicenseRequestRequest struct {
+	LicenseType        string `json:"licenseType" binding:"required"`
+	LicenseNumber      string `json:"licenseNumber" binding:"required"`
+	ProjectName        string `json:"projectName" binding:"required"`
+	ProjectAddress     string `json:"projectAddress"`
+	Province           string `json:"province"`
+	District           string `json:"district"`
+	Subdistrict        string `json:"subdistrict"`
+	PostalCode         string `json:"postalCode"`
+	NewContactPerson   string `json:"newContactPerson"`
+	NewContactPhone    string `json:"newContactPhone"`
+	NewContactEmail    string `json:"newContactEmail"`
+	Equipment          string `json:"equipment"`
+	ModificationReason string `json:"modificationReason" binding:"required"`
+	ContactPerson      string `json:"contactPerson" binding:"required"`
+	ContactPhone       string `json:"contactPhone" binding:"required"`
+	ContactEmail       string `json:"contactEmail" binding:"required"`
+	Description        string `json:"description"`
+}
+
+// CancelLicenseRequestRequest represents the cancel license request payload
+type CancelLicenseRequestRequest struct {
+	LicenseType        string `json:"licenseType" binding:"required"`
+	LicenseNumber      string `json:"licenseNumber" binding:"required"`
+	ProjectName        string `json:"projectName" binding:"required"`
+	CancellationReason string `json:"cancellationReason" binding:"required"`
+	LastOperationDate  string `json:"lastOperationDate" binding:"required"`
+	ContactPerson      string `json:"contactPerson" binding:"required"`
+	ContactPhone       string `json:"contactPhone" binding:"required"`
+	ContactEmail       string `json:"contactEmail" binding:"required"`
+	Description        string `json:"description"`
+}
+
 // UpdateLicenseRequestRequest represents the update license request payload
 type UpdateLicenseRequestRequest struct {
-	Title             string  `json:"title"`
-	Description       string  `json:"description"`
-	CurrentCapacity   float64 `json:"current_capacity"`
-	RequestedCapacity float64 `json:"requested_capacity"`
-	Location          string  `json:"location"`
+	Title             string                 `json:"title"`
+	Description       string                 `json:"description"`
+	CurrentCapacity   float64                `json:"current_capacity"`
+	RequestedCapacity float64                `json:"requested_capacity"`
+	Location          string                 `json:"location"`
+	LicenseNumber     string                 `json:"license_number"`
+	Payload           map[string]interface{} `json:"payload"` // Type-specific fields to change
+}
+
+// WithdrawRequest represents the withdraw license request payload
+type WithdrawRequest struct {
+	Reason string `json:"reason" binding:"required"`
 }
 
 // AssignInspectorRequest represents the assign inspector payload
@@ -123,30 +168,37 @@ type SetAppointmentRequest struct {
 
 // LicenseRequestResponse represents the license request response
 type LicenseRequestResponse struct {
-	ID                uint       `json:"id"`
-	RequestNumber     string     `json:"request_number"`
-	LicenseType       string     `json:"license_type"`
-	Status            string     `json:"status"`
-	Title             string     `json:"title"`
-	Description       string     `json:"description"`
-	CurrentCapacity   float64    `json:"current_capacity"`
-	RequestedCapacity float64    `json:"requested_capacity"`
-	Location          string     `json:"location"`
-	InspectorID       *uint      `json:"inspector_id"`
-	Inspector         *UserInfo  `json:"inspector,omitempty"`
-	AssignedByID      *uint      `json:"assigned_by_id"`
-	AssignedBy        *UserInfo  `json:"assigned_by,omitempty"`
-	AssignedAt        *time.Time `json:"assigned_at"`
-	AppointmentDate   *time.Time `json:"appointment_date"`
-	InspectionDate    *time.Time `json:"inspection_date"`
-	CompletionDate    *time.Time `json:"completion_date"`
-	Deadline          *time.Time `json:"deadline"`
-	RejectionReason   string     `json:"rejection_reason"`
-	Notes             string     `json:"notes"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
-	UserID            uint       `json:"user_id"`
-	User              UserInfo   `json:"user"`
+	ID                uint                   `json:"id"`
+	RequestNumber     string                 `json:"request_number"`
+	LicenseType       string                 `json:"license_type"`
+	Status            string                 `json:"status"`
+	Title             string                 `json:"title"`
+	Description       string                 `json:"description"`
+	CurrentCapacity   float64                `json:"current_capacity"`
+	RequestedCapacity float64                `json:"requested_capacity"`
+	Location          string                 `json:"location"`
+	InspectorID       *uint                  `json:"inspector_id"`
+	Inspector         *UserInfo              `json:"inspector,omitempty"`
+	AssignedByID      *uint                  `json:"assigned_by_id"`
+	AssignedBy        *UserInfo              `json:"assigned_by,omitempty"`
+	AssignedAt        *time.Time             `json:"assigned_at"`
+	AppointmentDate   *time.Time             `json:"appointment_date"`
+	InspectionDate    *time.Time             `json:"inspection_date"`
+	CompletionDate    *time.Time             `json:"completion_date"`
+	Deadline          *time.Time             `json:"deadline"`
+	RejectionReason   string                 `json:"rejection_reason"`
+	Notes             string                 `json:"notes"`
+	LicenseNumber     string                 `json:"license_number,omitempty"`
+	LicenseID         *uint                  `json:"license_id,omitempty"`
+	CorporateID       *uint                  `json:"corporate_id,omitempty"`
+	LicenseValidFrom  *time.Time             `json:"license_valid_from,omitempty"`  // Set once the request is approved
+	LicenseValidUntil *time.Time             `json:"license_valid_until,omitempty"` // Set once the request is approved
+	Payload           map[string]interface{} `json:"payload,omitempty"` // Type-specific fields
+	Version           int                    `json:"version"`
+	CreatedAt         time.Time              `json:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+	UserID            uint                   `json:"user_id"`
+	User              UserInfo               `json:"user"`
 }
 
 // LicenseRequestListResponse represents the license request list response
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"strconv"
 
 	"eservice-backend/config"
@@ -8,6 +11,9 @@ import (
 	"eservice-backend/repository"
 	"eservice-backend/service/license/dto"
 	"eservice-backend/service/license/usecase"
+	workflowdto "eservice-backend/service/workflow/dto"
+	workflowhandler "eservice-backend/service/workflow/handler"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -15,29 +21,29 @@ import (
 )
 
 type LicenseHandler struct {
-	licenseUsecase usecase.LicenseUsecase
-	config         *config.Config
+	licenseUsecase    usecase.LicenseUsecase
+	transitionService workflowservice.WorkflowTransitionService
+	submissionService workflowservice.SubmissionService
+	config            *config.Config
 }
 
 func NewLicenseHandler(db *gorm.DB, config *config.Config) *LicenseHandler {
 	licenseRepo := repository.NewLicenseRequestRepository(db)
-	newLicenseRepo := repository.NewNewLicenseRepo(db)
-	renewalLicenseRepo := repository.NewRenewalLicenseRepo(db)
-	extensionLicenseRepo := repository.NewExtensionLicenseRepo(db)
-	reductionLicenseRepo := repository.NewReductionLicenseRepo(db)
 	userRepo := repository.NewUserRepository(db)
+	workflowDefRepo := repository.NewWorkflowDefinitionRepository(db)
+	issuedLicenseRepo := repository.NewLicenseRepository(db)
 	licenseUsecase := usecase.NewLicenseUsecase(
 		licenseRepo,
-		newLicenseRepo,
-		renewalLicenseRepo,
-		extensionLicenseRepo,
-		reductionLicenseRepo,
 		userRepo,
+		workflowDefRepo,
+		issuedLicenseRepo,
 	)
 
 	return &LicenseHandler{
-		licenseUsecase: licenseUsecase,
-		config:         config,
+		licenseUsecase:    licenseUsecase,
+		transitionService: workflowservice.NewWorkflowTransitionService(db, config),
+		submissionService: workflowservice.NewSubmissionService(db),
+		config:            config,
 	}
 }
 
@@ -150,12 +156,94 @@ func (h *LicenseHandler) SubmitLicenseRequest(c *gin.Context) {
 		return
 	}
 
-	if err := h.licenseUsecase.SubmitLicenseRequest(uint(id)); err != nil {
-		utils.ErrorBadRequest(c, err.Error(), nil)
+	// The submit transition checks that every required document has been uploaded
+	result, ok := h.processTransition(c, workflowdto.WorkflowTransitionRequest{
+		RequestID: uint(id),
+		ToStatus:  models.StatusNewRequest,
+	})
+	if !ok {
+		return
+	}
+
+	utils.SuccessOK(c, "License request submitted successfully", result)
+}
+
+// ResubmitLicenseRequest handles sending a returned license request back for review.
+// Without a target status the request goes back to the step it was returned from.
+func (h *LicenseHandler) ResubmitLicenseRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	var req workflowdto.ResubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	if req.ToStatus == "" {
+		userRole, _ := c.Get("user_role")
+		role, _ := userRole.(models.UserRole)
+
+		req.ToStatus, err = h.submissionService.GetResubmitStatus(uint(id), role)
+		if err != nil {
+			workflowhandler.RespondTransitionError(c, "Failed to resubmit license request", err)
+			return
+		}
+	}
+
+	// The resubmit transition checks the required documents again and snapshots the submission
+	result, ok := h.processTransition(c, workflowdto.WorkflowTransitionRequest{
+		RequestID: uint(id),
+		ToStatus:  req.ToStatus,
+		Comments:  req.Comments,
+	}, workflowservice.RequireRequestOwner(userID.(uint)))
+	if !ok {
+		return
+	}
+
+	utils.SuccessOK(c, "License request resubmitted successfully", result)
+}
+
+// WithdrawLicenseRequest handles the applicant withdrawing a request that has not finished.
+// Open tasks, deadline reminders and inspections are cancelled and the officers notified.
+func (h *LicenseHandler) WithdrawLicenseRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
 		return
 	}
 
-	utils.SuccessOK(c, "License request submitted successfully", nil)
+	var req dto.WithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	result, ok := h.processTransition(c, workflowdto.WorkflowTransitionRequest{
+		RequestID: uint(id),
+		ToStatus:  models.StatusWithdrawn,
+		Comments:  req.Reason,
+	}, workflowservice.RequireRequestOwner(userID.(uint)))
+	if !ok {
+		return
+	}
+
+	utils.SuccessOK(c, "License request withdrawn successfully", result)
 }
 
 // AcceptLicenseRequest handles accepting a license request
@@ -179,12 +267,15 @@ func (h *LicenseHandler) AcceptLicenseRequest(c *gin.Context) {
 		return
 	}
 
-	if err := h.licenseUsecase.AcceptLicenseRequest(uint(id)); err != nil {
-		utils.ErrorBadRequest(c, err.Error(), nil)
+	result, ok := h.processTransition(c, workflowdto.WorkflowTransitionRequest{
+		RequestID: uint(id),
+		ToStatus:  models.StatusAccepted,
+	})
+	if !ok {
 		return
 	}
 
-	utils.SuccessOK(c, "License request accepted successfully", nil)
+	utils.SuccessOK(c, "License request accepted successfully", result)
 }
 
 // RejectLicenseRequest handles rejecting a license request
@@ -217,12 +308,16 @@ func (h *LicenseHandler) RejectLicenseRequest(c *gin.Context) {
 		return
 	}
 
-	if err := h.licenseUsecase.RejectLicenseRequest(uint(id), req.Reason); err != nil {
-		utils.ErrorBadRequest(c, err.Error(), nil)
+	result, ok := h.processTransition(c, workflowdto.WorkflowTransitionRequest{
+		RequestID:       uint(id),
+		ToStatus:        models.StatusRejected,
+		RejectionReason: req.Reason,
+	})
+	if !ok {
 		return
 	}
 
-	utils.SuccessOK(c, "License request rejected successfully", nil)
+	utils.SuccessOK(c, "License request rejected successfully", result)
 }
 
 // AssignInspector handles assigning an inspector to a license request
@@ -252,24 +347,16 @@ func (h *LicenseHandler) AssignInspector(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorUnauthorized(c, "User not authenticated", nil)
-		return
-	}
-
-	assignedByID, ok := userID.(uint)
+	result, ok := h.processTransition(c, workflowdto.WorkflowTransitionRequest{
+		RequestID:    uint(id),
+		ToStatus:     models.StatusAssigned,
+		AssignedToID: req.InspectorID,
+	}, requireDEDEInspector(req.InspectorID))
 	if !ok {
-		utils.ErrorInternalServerError(c, "Invalid user ID", nil)
-		return
-	}
-
-	if err := h.licenseUsecase.AssignInspector(uint(id), req, assignedByID); err != nil {
-		utils.ErrorBadRequest(c, err.Error(), nil)
 		return
 	}
 
-	utils.SuccessOK(c, "Inspector assigned successfully", nil)
+	utils.SuccessOK(c, "Inspector assigned successfully", result)
 }
 
 // ApproveLicenseRequest handles approving a license request
@@ -293,12 +380,64 @@ func (h *LicenseHandler) ApproveLicenseRequest(c *gin.Context) {
 		return
 	}
 
-	if err := h.licenseUsecase.ApproveLicenseRequest(uint(id)); err != nil {
-		utils.ErrorBadRequest(c, err.Error(), nil)
+	// The approve transition checks that the audit report has been approved
+	result, ok := h.processTransition(c, workflowdto.WorkflowTransitionRequest{
+		RequestID: uint(id),
+		ToStatus:  models.StatusApproved,
+	})
+	if !ok {
+		return
+	}
+
+	if result.Pending {
+		utils.SuccessOK(c, "Approval recorded, waiting for other approvers", result)
 		return
 	}
+	utils.SuccessOK(c, "License request approved successfully", result)
+}
+
+// processTransition moves a request through the workflow on behalf of the current user.
+// It writes the error response and returns false if the transition fails.
+func (h *LicenseHandler) processTransition(c *gin.Context, req workflowdto.WorkflowTransitionRequest, hooks ...workflowservice.TransitionHook) (*workflowdto.WorkflowTransitionResult, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return nil, false
+	}
+	userRole, _ := c.Get("user_role")
+	role, _ := userRole.(models.UserRole)
+
+	expectedVersion, ok := workflowhandler.ExpectedVersion(c)
+	if !ok {
+		return nil, false
+	}
+
+	req.ExpectedVersion = expectedVersion
+	req.UserID = userID.(uint)
+	req.UserRole = role
+
+	result, err := h.transitionService.ProcessTransition(req, hooks...)
+	if err != nil {
+		workflowhandler.RespondTransitionError(c, "Failed to update license request", err)
+		return nil, false
+	}
+
+	utils.SetVersionETag(c, result.Version)
+	return result, true
+}
 
-	utils.SuccessOK(c, "License request approved successfully", nil)
+// requireDEDEInspector returns a transition hook that only lets a request be assigned to a DEDE officer
+func requireDEDEInspector(inspectorID uint) workflowservice.TransitionHook {
+	return func(tc *workflowservice.TransitionContext) error {
+		inspector, err := repository.NewUserRepository(tc.Tx).GetByID(inspectorID)
+		if err != nil {
+			return fmt.Errorf("%w: inspector not found", workflowservice.ErrInvalidTransition)
+		}
+		if !inspector.IsDEDE() {
+			return fmt.Errorf("%w: inspector must be a DEDE staff", workflowservice.ErrInvalidTransition)
+		}
+		return nil
+	}
 }
 
 // GetMyLicenseRequests handles getting the current user's license requests
@@ -448,3 +587,61 @@ func (h *LicenseHandler) CreateReductionLicenseRequest(c *gin.Context) {
 
 	utils.SuccessCreated(c, "License request created successfully", response)
 }
+
+// CreateModifyLicenseRequest handles creating a modify license request
+func (h *LicenseHandler) CreateModifyLicenseRequest(c *gin.Context) {
+	var req dto.ModifyLicenseRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	id, ok := userID.(uint)
+	if !ok {
+		utils.ErrorInternalServerError(c, "Invalid user ID", nil)
+		return
+	}
+
+	response, err := h.licenseUsecase.CreateModifyLicenseRequest(id, req)
+	if err != nil {
+		utils.ErrorBadRequest(c, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessCreated(c, "License request created successfully", response)
+}
+
+// CreateCancelLicenseRequest handles creating a cancel license request
+func (h *LicenseHandler) CreateCancelLicenseRequest(c *gin.Context) {
+	var req dto.CancelLicenseRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	id, ok := userID.(uint)
+	if !ok {
+		utils.ErrorInternalServerError(c, "Invalid user ID", nil)
+		return
+	}
+
+	response, err := h.licenseUsecase.CreateCancelLicenseRequest(id, req)
+	if err != nil {
+		utils.ErrorBadRequest(c, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessCreated(c, "License request created successfully", response)
+}
@@ -9,6 +9,7 @@ import (
 	"eservice-backend/models"
 	"eservice-backend/repository"
 	"eservice-backend/service/license/dto"
+	workflowservice "eservice-backend/service/workflow/service"
 	"eservice-backend/utils"
 )
 
@@ -18,49 +19,41 @@ type LicenseUsecase interface {
 	CreateRenewalLicenseRequest(userID uint, req dto.RenewalLicenseRequestRequest) (*dto.LicenseRequestResponse, error)
 	CreateExtensionLicenseRequest(userID uint, req dto.ExtensionLicenseRequestRequest) (*dto.LicenseRequestResponse, error)
 	CreateReductionLicenseRequest(userID uint, req dto.ReductionLicenseRequestRequest) (*dto.LicenseRequestResponse, error)
+	CreateModifyLicenseRequest(userID uint, req dto.ModifyLicenseRequestRequest) (*dto.LicenseRequestResponse, error)
+	CreateCancelLicenseRequest(userID uint, req dto.CancelLicenseRequestRequest) (*dto.LicenseRequestResponse, error)
 	GetLicenseRequestByID(id uint) (*dto.LicenseRequestResponse, error)
 	GetLicenseRequests(page, limit int, search string, status string, userID uint) (*dto.LicenseRequestListResponse, error)
 	UpdateLicenseRequest(id uint, req dto.UpdateLicenseRequestRequest) (*dto.LicenseRequestResponse, error)
 	DeleteLicenseRequest(id uint) error
-	SubmitLicenseRequest(id uint) error
-	AcceptLicenseRequest(id uint) error
-	RejectLicenseRequest(id uint, reason string) error
-	AssignInspector(id uint, req dto.AssignInspectorRequest, assignedByID uint) error
-	ApproveLicenseRequest(id uint) error
 	GetMyLicenseRequests(userID uint, page, limit int) (*dto.LicenseRequestListResponse, error)
 	GetLicenseTypes() []dto.LicenseTypeResponse
 	GetRequestStatuses() []dto.RequestStatusResponse
 }
 
 type licenseUsecase struct {
-	licenseRepo          repository.LicenseRequestRepository
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	userRepo             repository.UserRepository
+	licenseRepo       repository.LicenseRequestRepository
+	userRepo          repository.UserRepository
+	workflowDefRepo   repository.WorkflowDefinitionRepository
+	issuedLicenseRepo repository.LicenseRepository
 }
 
 func NewLicenseUsecase(
 	licenseRepo repository.LicenseRequestRepository,
-	newLicenseRepo repository.NewLicenseRepo,
-	renewalLicenseRepo repository.RenewalLicenseRepo,
-	extensionLicenseRepo repository.ExtensionLicenseRepo,
-	reductionLicenseRepo repository.ReductionLicenseRepo,
-	userRepo repository.UserRepository) LicenseUsecase {
+	userRepo repository.UserRepository,
+	workflowDefRepo repository.WorkflowDefinitionRepository,
+	issuedLicenseRepo repository.LicenseRepository) LicenseUsecase {
 	return &licenseUsecase{
-		licenseRepo:          licenseRepo,
-		newLicenseRepo:       newLicenseRepo,
-		renewalLicenseRepo:   renewalLicenseRepo,
-		extensionLicenseRepo: extensionLicenseRepo,
-		reductionLicenseRepo: reductionLicenseRepo,
-		userRepo:             userRepo,
+		licenseRepo:       licenseRepo,
+		userRepo:          userRepo,
+		workflowDefRepo:   workflowDefRepo,
+		issuedLicenseRepo: issuedLicenseRepo,
 	}
 }
 
 func (u *licenseUsecase) CreateLicenseRequest(userID uint, req dto.CreateLicenseRequestRequest) (*dto.LicenseRequestResponse, error) {
 	// Validate license type
-	if !u.isValidLicenseType(req.LicenseType) {
+	licenseType := models.ParseLicenseType(req.LicenseType)
+	if !u.isValidLicenseType(string(licenseType)) {
 		return nil, errors.New("invalid license type")
 	}
 
@@ -71,14 +64,21 @@ func (u *licenseUsecase) CreateLicenseRequest(userID uint, req dto.CreateLicense
 	licenseRequest := &models.LicenseRequest{
 		UserID:            userID,
 		RequestNumber:     requestNumber,
-		LicenseType:       models.LicenseType(req.LicenseType),
+		LicenseType:       licenseType,
 		Status:            models.StatusDraft,
 		Title:             req.Title,
 		Description:       req.Description,
 		CurrentCapacity:   req.CurrentCapacity,
 		RequestedCapacity: req.RequestedCapacity,
 		Location:          req.Location,
+		LicenseNumber:     req.LicenseNumber,
+		CorporateID:       req.CorporateID,
 		Deadline:          GetDeadlinePointer(),
+		WorkflowVersion:   u.activeWorkflowVersion(string(licenseType)),
+	}
+
+	if err := u.linkLicense(licenseRequest); err != nil {
+		return nil, err
 	}
 
 	if err := u.licenseRepo.Create(licenseRequest); err != nil {
@@ -156,9 +156,9 @@ func (u *licenseUsecase) UpdateLicenseRequest(id uint, req dto.UpdateLicenseRequ
 		return nil, err
 	}
 
-	// Check if request is in draft status
-	if licenseRequest.Status != models.StatusDraft {
-		return nil, errors.New("cannot update request that is not in draft status")
+	// Applicants edit drafts and correct returned requests before resubmitting them
+	if licenseRequest.Status != models.StatusDraft && licenseRequest.Status != models.StatusReturned {
+		return nil, errors.New("cannot update request that is not in draft or returned status")
 	}
 
 	// Update fields
@@ -177,6 +177,17 @@ func (u *licenseUsecase) UpdateLicenseRequest(id uint, req dto.UpdateLicenseRequ
 	if req.Location != "" {
 		licenseRequest.Location = req.Location
 	}
+	if req.LicenseNumber != "" && req.LicenseNumber != licenseRequest.LicenseNumber {
+		licenseRequest.LicenseNumber = req.LicenseNumber
+		licenseRequest.LicenseID = nil
+		licenseRequest.License = nil
+		if err := u.linkLicense(licenseRequest); err != nil {
+			return nil, err
+		}
+	}
+	if err := licenseRequest.MergePayload(req.Payload); err != nil {
+		return nil, err
+	}
 
 	if err := u.licenseRepo.Update(licenseRequest); err != nil {
 		return nil, errors.New("failed to update license request")
@@ -199,92 +210,6 @@ func (u *licenseUsecase) DeleteLicenseRequest(id uint) error {
 	return u.licenseRepo.Delete(id)
 }
 
-func (u *licenseUsecase) SubmitLicenseRequest(id uint) error {
-	licenseRequest, err := u.licenseRepo.GetByID(id)
-	if err != nil {
-		return err
-	}
-
-	// Check if request is in draft status
-	if licenseRequest.Status != models.StatusDraft {
-		return errors.New("cannot submit request that is not in draft status")
-	}
-
-	// Update status
-	return u.licenseRepo.UpdateStatus(id, models.StatusNewRequest)
-}
-
-func (u *licenseUsecase) AcceptLicenseRequest(id uint) error {
-	licenseRequest, err := u.licenseRepo.GetByID(id)
-	if err != nil {
-		return err
-	}
-
-	// Check if request is in new request status
-	if licenseRequest.Status != models.StatusNewRequest {
-		return errors.New("cannot accept request that is not in new request status")
-	}
-
-	// Update status
-	return u.licenseRepo.UpdateStatus(id, models.StatusAccepted)
-}
-
-func (u *licenseUsecase) RejectLicenseRequest(id uint, reason string) error {
-	licenseRequest, err := u.licenseRepo.GetByID(id)
-	if err != nil {
-		return err
-	}
-
-	// Check if request is in new request status
-	if licenseRequest.Status != models.StatusNewRequest {
-		return errors.New("cannot reject request that is not in new request status")
-	}
-
-	// Update status and rejection reason
-	return u.licenseRepo.UpdateStatus(id, models.StatusRejected)
-}
-
-func (u *licenseUsecase) AssignInspector(id uint, req dto.AssignInspectorRequest, assignedByID uint) error {
-	licenseRequest, err := u.licenseRepo.GetByID(id)
-	if err != nil {
-		return err
-	}
-
-	// Check if request can be assigned
-	if !licenseRequest.CanBeAssigned() {
-		return errors.New("cannot assign inspector to this request")
-	}
-
-	// Check if inspector exists
-	inspector, err := u.userRepo.GetByID(req.InspectorID)
-	if err != nil {
-		return errors.New("inspector not found")
-	}
-
-	// Check if inspector is DEDE role
-	if !inspector.IsDEDE() {
-		return errors.New("inspector must be a DEDE staff")
-	}
-
-	// Assign inspector
-	return u.licenseRepo.AssignInspector(id, req.InspectorID, assignedByID)
-}
-
-func (u *licenseUsecase) ApproveLicenseRequest(id uint) error {
-	licenseRequest, err := u.licenseRepo.GetByID(id)
-	if err != nil {
-		return err
-	}
-
-	// Check if request can be approved
-	if !licenseRequest.IsInspectionDone() {
-		return errors.New("cannot approve request that has not been inspected")
-	}
-
-	// Update status
-	return u.licenseRepo.UpdateStatus(id, models.StatusApproved)
-}
-
 func (u *licenseUsecase) GetMyLicenseRequests(userID uint, page, limit int) (*dto.LicenseRequestListResponse, error) {
 	return u.GetLicenseRequests(page, limit, "", "", userID)
 }
@@ -315,6 +240,9 @@ func (u *licenseUsecase) GetRequestStatuses() []dto.RequestStatusResponse {
 		{Value: string(models.StatusReportApproved), Label: "รับรองรายงาน"},
 		{Value: string(models.StatusApproved), Label: "อนุมัติใบอนุญาต"},
 		{Value: string(models.StatusRejectedFinal), Label: "ปฏิเสธสุดท้าย"},
+		{Value: string(models.StatusWithdrawn), Label: "ถอนคำขอ"},
+		{Value: string(models.StatusAppealed), Label: "ยื่นอุทธรณ์"},
+		{Value: string(models.StatusPaymentPending), Label: "รอชำระค่าธรรมเนียม"},
 	}
 }
 
@@ -375,6 +303,13 @@ func (u *licenseUsecase) convertToLicenseRequestResponse(request *models.License
 		Deadline:          request.Deadline,
 		RejectionReason:   request.RejectionReason,
 		Notes:             request.Notes,
+		LicenseNumber:     request.LicenseNumber,
+		LicenseID:         request.LicenseID,
+		CorporateID:       request.CorporateID,
+		LicenseValidFrom:  request.LicenseValidFrom,
+		LicenseValidUntil: request.LicenseValidUntil,
+		Payload:           request.PayloadFields(),
+		Version:           request.Version,
 		CreatedAt:         request.CreatedAt,
 		UpdatedAt:         request.UpdatedAt,
 		UserID:            request.UserID,
@@ -417,9 +352,6 @@ func (u *licenseUsecase) convertToLicenseRequestResponse(request *models.License
 
 // CreateNewLicenseRequest creates a new license request
 func (u *licenseUsecase) CreateNewLicenseRequest(userID uint, req dto.NewLicenseRequestRequest) (*dto.LicenseRequestResponse, error) {
-	// Generate request number
-	requestNumber := u.generateRequestNumber()
-
 	// Parse capacity
 	capacity, err := strconv.ParseFloat(req.Capacity, 64)
 	if err != nil {
@@ -432,55 +364,35 @@ func (u *licenseUsecase) CreateNewLicenseRequest(userID uint, req dto.NewLicense
 		expectedStartDate = time.Now()
 	}
 
-	// Create new license request
-	newLicenseRequest := &models.NewLicenseRequest{
-		UserID:            userID,
-		RequestNumber:     requestNumber,
-		Status:            models.StatusNewRequest,
-		LicenseType:       req.LicenseType,
-		ProjectName:       req.ProjectName,
+	payload := models.NewLicensePayload{
+		LicenseCategory:   req.LicenseType,
 		ProjectAddress:    req.ProjectAddress,
 		Province:          req.Province,
 		District:          req.District,
 		Subdistrict:       req.Subdistrict,
 		PostalCode:        req.PostalCode,
 		EnergyType:        req.EnergyType,
-		Capacity:          capacity,
 		CapacityUnit:      req.CapacityUnit,
 		ExpectedStartDate: expectedStartDate,
+	}
+
+	licenseRequest := &models.LicenseRequest{
+		LicenseType:       models.LicenseTypeNew,
+		CorporateID:       req.CorporateID,
+		Title:             req.ProjectName,
 		Description:       req.Description,
+		RequestedCapacity: capacity,
+		Location:          payload.Location(),
 		ContactPerson:     req.ContactPerson,
 		ContactPhone:      req.ContactPhone,
 		ContactEmail:      req.ContactEmail,
-		Deadline:          GetDeadlinePointer(),
 	}
 
-	if err := u.newLicenseRepo.Create(newLicenseRequest); err != nil {
-		return nil, errors.New("failed to create new license request")
-	}
-
-	// Convert to response format
-	response := &dto.LicenseRequestResponse{
-		ID:            newLicenseRequest.ID,
-		RequestNumber: newLicenseRequest.RequestNumber,
-		LicenseType:   newLicenseRequest.LicenseType,
-		Status:        string(newLicenseRequest.Status),
-		Title:         newLicenseRequest.ProjectName,
-		Description:   newLicenseRequest.Description,
-		Location:      newLicenseRequest.ProjectAddress + ", " + newLicenseRequest.District + ", " + newLicenseRequest.Subdistrict + ", " + newLicenseRequest.Province + " " + newLicenseRequest.PostalCode,
-		CreatedAt:     newLicenseRequest.CreatedAt,
-		UpdatedAt:     newLicenseRequest.UpdatedAt,
-		UserID:        newLicenseRequest.UserID,
-	}
-
-	return response, nil
+	return u.submitTypedRequest(userID, licenseRequest, payload)
 }
 
 // CreateRenewalLicenseRequest creates a renewal license request
 func (u *licenseUsecase) CreateRenewalLicenseRequest(userID uint, req dto.RenewalLicenseRequestRequest) (*dto.LicenseRequestResponse, error) {
-	// Generate request number
-	requestNumber := u.generateRequestNumber()
-
 	// Parse capacities
 	currentCapacity, err := strconv.ParseFloat(req.CurrentCapacity, 64)
 	if err != nil {
@@ -503,54 +415,34 @@ func (u *licenseUsecase) CreateRenewalLicenseRequest(userID uint, req dto.Renewa
 		requestedExpiryDate = time.Now()
 	}
 
-	// Create renewal license request
-	renewalLicenseRequest := &models.RenewalLicenseRequest{
-		UserID:                userID,
-		RequestNumber:         requestNumber,
-		Status:                models.StatusNewRequest,
-		LicenseType:           req.LicenseType,
-		LicenseNumber:         req.LicenseNumber,
-		ProjectName:           req.ProjectName,
+	payload := models.RenewalLicensePayload{
+		LicenseCategory:       req.LicenseType,
 		ProjectAddress:        req.ProjectAddress,
-		CurrentCapacity:       currentCapacity,
 		CurrentCapacityUnit:   req.CurrentCapacityUnit,
-		RequestedCapacity:     requestedCapacity,
 		RequestedCapacityUnit: req.RequestedCapacityUnit,
 		ExpiryDate:            expiryDate,
 		RequestedExpiryDate:   requestedExpiryDate,
 		Reason:                req.Reason,
-		ContactPerson:         req.ContactPerson,
-		ContactPhone:          req.ContactPhone,
-		ContactEmail:          req.ContactEmail,
-		Deadline:              GetDeadlinePointer(),
 	}
 
-	if err := u.renewalLicenseRepo.Create(renewalLicenseRequest); err != nil {
-		return nil, errors.New("failed to create renewal license request")
-	}
-
-	// Convert to response format
-	response := &dto.LicenseRequestResponse{
-		ID:            renewalLicenseRequest.ID,
-		RequestNumber: renewalLicenseRequest.RequestNumber,
-		LicenseType:   renewalLicenseRequest.LicenseType,
-		Status:        string(renewalLicenseRequest.Status),
-		Title:         renewalLicenseRequest.ProjectName,
-		Description:   renewalLicenseRequest.Reason,
-		Location:      renewalLicenseRequest.ProjectAddress,
-		CreatedAt:     renewalLicenseRequest.CreatedAt,
-		UpdatedAt:     renewalLicenseRequest.UpdatedAt,
-		UserID:        renewalLicenseRequest.UserID,
+	licenseRequest := &models.LicenseRequest{
+		LicenseType:       models.LicenseTypeRenew,
+		LicenseNumber:     req.LicenseNumber,
+		Title:             req.ProjectName,
+		Description:       req.Reason,
+		CurrentCapacity:   currentCapacity,
+		RequestedCapacity: requestedCapacity,
+		Location:          req.ProjectAddress,
+		ContactPerson:     req.ContactPerson,
+		ContactPhone:      req.ContactPhone,
+		ContactEmail:      req.ContactEmail,
 	}
 
-	return response, nil
+	return u.submitTypedRequest(userID, licenseRequest, payload)
 }
 
 // CreateExtensionLicenseRequest creates an extension license request
 func (u *licenseUsecase) CreateExtensionLicenseRequest(userID uint, req dto.ExtensionLicenseRequestRequest) (*dto.LicenseRequestResponse, error) {
-	// Generate request number
-	requestNumber := u.generateRequestNumber()
-
 	// Parse capacities
 	currentCapacity, err := strconv.ParseFloat(req.CurrentCapacity, 64)
 	if err != nil {
@@ -568,52 +460,31 @@ func (u *licenseUsecase) CreateExtensionLicenseRequest(userID uint, req dto.Exte
 		expectedStartDate = time.Now()
 	}
 
-	// Create extension license request
-	extensionLicenseRequest := &models.ExtensionLicenseRequest{
-		UserID:                userID,
-		RequestNumber:         requestNumber,
-		Status:                models.StatusNewRequest,
-		LicenseType:           req.LicenseType,
-		LicenseNumber:         req.LicenseNumber,
-		ProjectName:           req.ProjectName,
-		CurrentCapacity:       currentCapacity,
+	payload := models.ExtensionLicensePayload{
+		LicenseCategory:       req.LicenseType,
 		CurrentCapacityUnit:   req.CurrentCapacityUnit,
-		RequestedCapacity:     requestedCapacity,
 		RequestedCapacityUnit: req.RequestedCapacityUnit,
 		ExtensionReason:       req.ExtensionReason,
 		ExpectedStartDate:     expectedStartDate,
-		Description:           req.Description,
-		ContactPerson:         req.ContactPerson,
-		ContactPhone:          req.ContactPhone,
-		ContactEmail:          req.ContactEmail,
-		Deadline:              GetDeadlinePointer(),
-	}
-
-	if err := u.extensionLicenseRepo.Create(extensionLicenseRequest); err != nil {
-		return nil, errors.New("failed to create extension license request")
 	}
 
-	// Convert to response format
-	response := &dto.LicenseRequestResponse{
-		ID:            extensionLicenseRequest.ID,
-		RequestNumber: extensionLicenseRequest.RequestNumber,
-		LicenseType:   extensionLicenseRequest.LicenseType,
-		Status:        string(extensionLicenseRequest.Status),
-		Title:         extensionLicenseRequest.ProjectName,
-		Description:   extensionLicenseRequest.Description,
-		CreatedAt:     extensionLicenseRequest.CreatedAt,
-		UpdatedAt:     extensionLicenseRequest.UpdatedAt,
-		UserID:        extensionLicenseRequest.UserID,
+	licenseRequest := &models.LicenseRequest{
+		LicenseType:       models.LicenseTypeExpand,
+		LicenseNumber:     req.LicenseNumber,
+		Title:             req.ProjectName,
+		Description:       req.Description,
+		CurrentCapacity:   currentCapacity,
+		RequestedCapacity: requestedCapacity,
+		ContactPerson:     req.ContactPerson,
+		ContactPhone:      req.ContactPhone,
+		ContactEmail:      req.ContactEmail,
 	}
 
-	return response, nil
+	return u.submitTypedRequest(userID, licenseRequest, payload)
 }
 
 // CreateReductionLicenseRequest creates a reduction license request
 func (u *licenseUsecase) CreateReductionLicenseRequest(userID uint, req dto.ReductionLicenseRequestRequest) (*dto.LicenseRequestResponse, error) {
-	// Generate request number
-	requestNumber := u.generateRequestNumber()
-
 	// Parse capacities
 	currentCapacity, err := strconv.ParseFloat(req.CurrentCapacity, 64)
 	if err != nil {
@@ -631,45 +502,180 @@ func (u *licenseUsecase) CreateReductionLicenseRequest(userID uint, req dto.Redu
 		expectedStartDate = time.Now()
 	}
 
-	// Create reduction license request
-	reductionLicenseRequest := &models.ReductionLicenseRequest{
-		UserID:                userID,
-		RequestNumber:         requestNumber,
-		Status:                models.StatusNewRequest,
-		LicenseType:           req.LicenseType,
-		LicenseNumber:         req.LicenseNumber,
-		ProjectName:           req.ProjectName,
-		CurrentCapacity:       currentCapacity,
+	payload := models.ReductionLicensePayload{
+		LicenseCategory:       req.LicenseType,
 		CurrentCapacityUnit:   req.CurrentCapacityUnit,
-		RequestedCapacity:     requestedCapacity,
 		RequestedCapacityUnit: req.RequestedCapacityUnit,
 		ReductionReason:       req.ReductionReason,
 		ExpectedStartDate:     expectedStartDate,
-		Description:           req.Description,
-		ContactPerson:         req.ContactPerson,
-		ContactPhone:          req.ContactPhone,
-		ContactEmail:          req.ContactEmail,
-		Deadline:              GetDeadlinePointer(),
 	}
 
-	if err := u.reductionLicenseRepo.Create(reductionLicenseRequest); err != nil {
-		return nil, errors.New("failed to create reduction license request")
+	licenseRequest := &models.LicenseRequest{
+		LicenseType:       models.LicenseTypeReduce,
+		LicenseNumber:     req.LicenseNumber,
+		Title:             req.ProjectName,
+		Description:       req.Description,
+		CurrentCapacity:   currentCapacity,
+		RequestedCapacity: requestedCapacity,
+		ContactPerson:     req.ContactPerson,
+		ContactPhone:      req.ContactPhone,
+		ContactEmail:      req.ContactEmail,
 	}
 
-	// Convert to response format
-	response := &dto.LicenseRequestResponse{
-		ID:            reductionLicenseRequest.ID,
-		RequestNumber: reductionLicenseRequest.RequestNumber,
-		LicenseType:   reductionLicenseRequest.LicenseType,
-		Status:        string(reductionLicenseRequest.Status),
-		Title:         reductionLicenseRequest.ProjectName,
-		Description:   reductionLicenseRequest.Description,
-		CreatedAt:     reductionLicenseRequest.CreatedAt,
-		UpdatedAt:     reductionLicenseRequest.UpdatedAt,
-		UserID:        reductionLicenseRequest.UserID,
+	return u.submitTypedRequest(userID, licenseRequest, payload)
+}
+
+// CreateModifyLicenseRequest creates a request to amend the particulars of a license
+func (u *licenseUsecase) CreateModifyLicenseRequest(userID uint, req dto.ModifyLicenseRequestRequest) (*dto.LicenseRequestResponse, error) {
+	payload := models.ModifyLicensePayload{
+		LicenseCategory:    req.LicenseType,
+		ProjectAddress:     req.ProjectAddress,
+		Province:           req.Province,
+		District:           req.District,
+		Subdistrict:        req.Subdistrict,
+		PostalCode:         req.PostalCode,
+		ContactPerson:      req.NewContactPerson,
+		ContactPhone:       req.NewContactPhone,
+		ContactEmail:       req.NewContactEmail,
+		Equipment:          req.Equipment,
+		ModificationReason: req.ModificationReason,
 	}
 
-	return response, nil
+	description := req.Description
+	if description == "" {
+		description = req.ModificationReason
+	}
+
+	licenseRequest := &models.LicenseRequest{
+		LicenseType:   models.LicenseTypeModify,
+		LicenseNumber: req.LicenseNumber,
+		Title:         req.ProjectName,
+		Description:   description,
+		Location:      payload.Location(),
+		ContactPerson: req.ContactPerson,
+		ContactPhone:  req.ContactPhone,
+		ContactEmail:  req.ContactEmail,
+	}
+
+	return u.submitTypedRequest(userID, licenseRequest, payload)
+}
+
+// CreateCancelLicenseRequest creates a request to surrender a license
+func (u *licenseUsecase) CreateCancelLicenseRequest(userID uint, req dto.CancelLicenseRequestRequest) (*dto.LicenseRequestResponse, error) {
+	// Parse last operation date
+	lastOperationDate, err := time.Parse("2006-01-02", req.LastOperationDate)
+	if err != nil {
+		lastOperationDate = time.Now()
+	}
+
+	payload := models.CancelLicensePayload{
+		LicenseCategory:    req.LicenseType,
+		CancellationReason: req.CancellationReason,
+		LastOperationDate:  lastOperationDate,
+	}
+
+	description := req.Description
+	if description == "" {
+		description = req.CancellationReason
+	}
+
+	licenseRequest := &models.LicenseRequest{
+		LicenseType:   models.LicenseTypeCancel,
+		LicenseNumber: req.LicenseNumber,
+		Title:         req.ProjectName,
+		Description:   description,
+		ContactPerson: req.ContactPerson,
+		ContactPhone:  req.ContactPhone,
+		ContactEmail:  req.ContactEmail,
+	}
+
+	return u.submitTypedRequest(userID, licenseRequest, payload)
+}
+
+// submitTypedRequest stores a request built by one of the typed create methods together
+// with its payload. Typed requests skip the draft stage and enter the workflow directly.
+func (u *licenseUsecase) submitTypedRequest(userID uint, licenseRequest *models.LicenseRequest, payload interface{}) (*dto.LicenseRequestResponse, error) {
+	licenseRequest.UserID = userID
+	licenseRequest.RequestNumber = u.generateRequestNumber()
+	licenseRequest.Status = models.StatusNewRequest
+	licenseRequest.Deadline = GetDeadlinePointer()
+
+	// Pin the request to the workflow definition that is active right now
+	licenseRequest.WorkflowVersion = u.activeWorkflowVersion(string(licenseRequest.LicenseType))
+
+	if err := licenseRequest.SetPayload(payload); err != nil {
+		return nil, err
+	}
+
+	// Typed requests enter the workflow at once, so the license they refer to is checked in full
+	if licenseRequest.LicenseType.RefersToLicense() {
+		license, err := workflowservice.CheckRequestLicense(u.issuedLicenseRepo, licenseRequest)
+		if err != nil {
+			return nil, err
+		}
+		u.applyLicense(licenseRequest, license)
+	} else if err := u.checkCorporate(licenseRequest); err != nil {
+		return nil, err
+	}
+
+	if err := u.licenseRepo.Create(licenseRequest); err != nil {
+		return nil, fmt.Errorf("failed to create %s license request", licenseRequest.LicenseType)
+	}
+
+	return u.convertToLicenseRequestResponse(licenseRequest)
+}
+
+// linkLicense points a draft or returned request at the license it refers to. Whether the request
+// can be applied to the license is checked when it is submitted.
+func (u *licenseUsecase) linkLicense(licenseRequest *models.LicenseRequest) error {
+	if !licenseRequest.LicenseType.RefersToLicense() {
+		return u.checkCorporate(licenseRequest)
+	}
+	if licenseRequest.LicenseNumber == "" {
+		return nil
+	}
+
+	license, err := workflowservice.FindRequestLicense(u.issuedLicenseRepo, licenseRequest)
+	if err != nil {
+		return err
+	}
+	u.applyLicense(licenseRequest, license)
+	return nil
+}
+
+// applyLicense copies the license a request refers to onto the request; the registry, not the applicant, has the current capacity
+func (u *licenseUsecase) applyLicense(licenseRequest *models.LicenseRequest, license *models.License) {
+	licenseRequest.LicenseID = &license.ID
+	licenseRequest.LicenseNumber = license.LicenseNumber
+	licenseRequest.CurrentCapacity = license.Capacity
+	licenseRequest.CorporateID = license.HolderCorporateID
+}
+
+// checkCorporate requires the applicant to be an active member of the corporate a new license is requested for
+func (u *licenseUsecase) checkCorporate(licenseRequest *models.LicenseRequest) error {
+	if licenseRequest.CorporateID == nil {
+		return nil
+	}
+
+	corporateIDs, err := u.issuedLicenseRepo.GetCorporateIDs(licenseRequest.UserID)
+	if err != nil {
+		return errors.New("failed to get corporates")
+	}
+	for _, id := range corporateIDs {
+		if id == *licenseRequest.CorporateID {
+			return nil
+		}
+	}
+	return fmt.Errorf("applicant is not an active member of corporate %d", *licenseRequest.CorporateID)
+}
+
+// activeWorkflowVersion returns the workflow definition version new requests of a license type start on
+func (u *licenseUsecase) activeWorkflowVersion(licenseType string) int {
+	definition, err := u.workflowDefRepo.GetActive(licenseType)
+	if err != nil {
+		return models.DefaultWorkflowVersion
+	}
+	return definition.Version
 }
 
 // Helper function to get deadline pointer
@@ -0,0 +1,18 @@
+package cron
+
+import (
+	"eservice-backend/service/workflow/service"
+	"log"
+	"time"
+)
+
+// NewAppealCronJob returns the job that makes rejections final once their appeal window has passed
+func NewAppealCronJob(appealService service.AppealService, interval time.Duration) *TickerJob {
+	return NewTickerJob("appeal window", interval, func() error {
+		closed, err := appealService.CloseExpiredAppealWindows()
+		if closed > 0 {
+			log.Printf("Closed %d expired appeal windows", closed)
+		}
+		return err
+	})
+}
@@ -0,0 +1,18 @@
+package cron
+
+import (
+	"eservice-backend/service/workflow/service"
+	"log"
+	"time"
+)
+
+// NewDelegationCronJob returns the job that expires delegations whose period has ended
+func NewDelegationCronJob(delegationService service.DelegationService, interval time.Duration) *TickerJob {
+	return NewTickerJob("delegation expiry", interval, func() error {
+		expired, err := delegationService.ExpireDelegations()
+		if expired > 0 {
+			log.Printf("Expired %d delegations", expired)
+		}
+		return err
+	})
+}
@@ -0,0 +1,18 @@
+package cron
+
+import (
+	"eservice-backend/service/workflow/service"
+	"log"
+	"time"
+)
+
+// NewEscalationCronJob returns the job that escalates requests that wait too long in a status
+func NewEscalationCronJob(escalationService service.EscalationService, interval time.Duration) *TickerJob {
+	return NewTickerJob("escalation", interval, func() error {
+		fired, err := escalationService.RunEscalations()
+		if fired > 0 {
+			log.Printf("Fired %d escalations", fired)
+		}
+		return err
+	})
+}
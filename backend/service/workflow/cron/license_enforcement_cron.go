@@ -0,0 +1,18 @@
+package cron
+
+import (
+	"eservice-backend/service/workflow/service"
+	"log"
+	"time"
+)
+
+// NewLicenseEnforcementCronJob returns the job that marks enforcement notices overdue and ends suspensions that ran out
+func NewLicenseEnforcementCronJob(enforcementService service.LicenseEnforcementService, interval time.Duration) *TickerJob {
+	return NewTickerJob("license enforcement", interval, func() error {
+		result, err := enforcementService.RunEnforcementChecks()
+		if result != nil && (result.Overdue > 0 || result.Reinstated > 0) {
+			log.Printf("Marked %d enforcement notices overdue, reinstated %d suspended licenses", result.Overdue, result.Reinstated)
+		}
+		return err
+	})
+}
@@ -0,0 +1,18 @@
+package cron
+
+import (
+	"eservice-backend/service/workflow/service"
+	"log"
+	"time"
+)
+
+// NewLicenseExpiryCronJob returns the job that sends the due license expiry reminders and expires licenses past their validity
+func NewLicenseExpiryCronJob(licenseExpiryService service.LicenseExpiryService, interval time.Duration) *TickerJob {
+	return NewTickerJob("license expiry", interval, func() error {
+		result, err := licenseExpiryService.RunExpiryChecks()
+		if result != nil && (result.Reminded > 0 || result.Expired > 0) {
+			log.Printf("Sent %d license expiry reminders, expired %d licenses", result.Reminded, result.Expired)
+		}
+		return err
+	})
+}
@@ -0,0 +1,21 @@
+package cron
+
+import (
+	"eservice-backend/service/workflow/service"
+	"log"
+	"time"
+)
+
+// outboxBatchSize is the number of outbox messages delivered per tick
+const outboxBatchSize = 100
+
+// NewOutboxCronJob returns the job that delivers pending outbox messages
+func NewOutboxCronJob(outboxService service.OutboxService, interval time.Duration) *TickerJob {
+	return NewTickerJob("outbox dispatch", interval, func() error {
+		sent, err := outboxService.DispatchPending(outboxBatchSize)
+		if sent > 0 {
+			log.Printf("Delivered %d outbox messages", sent)
+		}
+		return err
+	})
+}
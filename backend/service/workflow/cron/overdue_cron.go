@@ -118,9 +118,9 @@ func (j *OverdueCronJob) GetStatistics() (map[string]interface{}, error) {
 }
 
 // RunTest runs a test of the overdue service with a specific request
-func (j *OverdueCronJob) RunTest(requestID uint, licenseType string) error {
-	log.Printf("Running overdue test for request %d (%s)...", requestID, licenseType)
-	return j.overdueService.ProcessOverdueRequest(requestID, licenseType)
+func (j *OverdueCronJob) RunTest(requestID uint) error {
+	log.Printf("Running overdue test for request %d...", requestID)
+	return j.overdueService.ProcessOverdueRequest(requestID)
 }
 
 // ScheduleNextRun schedules the next run of the overdue check
@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"log"
+	"time"
+)
+
+// TickerJob runs a task every interval until it is stopped
+type TickerJob struct {
+	name     string
+	interval time.Duration
+	task     func() error
+	ticker   *time.Ticker
+	stopChan chan bool
+	running  bool
+}
+
+// NewTickerJob returns a job that runs task every interval once started. The name is used in
+// the job's log messages.
+func NewTickerJob(name string, interval time.Duration, task func() error) *TickerJob {
+	return &TickerJob{
+		name:     name,
+		interval: interval,
+		task:     task,
+		stopChan: make(chan bool),
+		running:  false,
+	}
+}
+
+// Start starts the ticker
+func (j *TickerJob) Start() {
+	if j.running {
+		log.Printf("The %s job is already running", j.name)
+		return
+	}
+
+	log.Printf("Starting %s job...", j.name)
+	j.running = true
+
+	j.ticker = time.NewTicker(j.interval)
+	go func(ticker *time.Ticker, stopChan chan bool) {
+		for {
+			select {
+			case <-ticker.C:
+				j.RunOnce()
+			case <-stopChan:
+				return
+			}
+		}
+	}(j.ticker, j.stopChan)
+
+	log.Printf("The %s job started successfully", j.name)
+}
+
+// Stop stops the ticker
+func (j *TickerJob) Stop() {
+	if !j.running {
+		log.Printf("The %s job is not running", j.name)
+		return
+	}
+
+	log.Printf("Stopping %s job...", j.name)
+	j.running = false
+
+	if j.ticker != nil {
+		j.ticker.Stop()
+	}
+
+	// Signal stop to goroutine
+	close(j.stopChan)
+	j.stopChan = make(chan bool)
+
+	log.Printf("The %s job stopped", j.name)
+}
+
+// RunOnce runs the task once immediately
+func (j *TickerJob) RunOnce() {
+	if err := j.task(); err != nil {
+		log.Printf("Error running %s job: %v", j.name, err)
+	}
+}
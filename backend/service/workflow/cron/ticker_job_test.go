@@ -0,0 +1,44 @@
+package cron
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTickerJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		taskErr error
+	}{
+		{name: "task succeeds"},
+		{name: "task fails and keeps running", taskErr: errors.New("database unavailable")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs atomic.Int32
+			job := NewTickerJob("test", 5*time.Millisecond, func() error {
+				runs.Add(1)
+				return tt.taskErr
+			})
+
+			job.Start()
+			job.Start() // A second start must not add another ticker
+			assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+
+			job.Stop()
+			stopped := runs.Load()
+			time.Sleep(20 * time.Millisecond)
+			assert.LessOrEqual(t, runs.Load(), stopped+1, "a stopped job stops running its task")
+
+			// A stopped job can be started again
+			job.Start()
+			assert.Eventually(t, func() bool { return runs.Load() > stopped+1 }, time.Second, time.Millisecond)
+			job.Stop()
+		})
+	}
+}
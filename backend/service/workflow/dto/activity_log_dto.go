@@ -57,6 +57,8 @@ type ActivityStatisticsResponse struct {
 	RenewalLicenseActivities   int64         `json:"renewal_license_activities"`
 	ExtensionLicenseActivities int64         `json:"extension_license_activities"`
 	ReductionLicenseActivities int64         `json:"reduction_license_activities"`
+	ModifyLicenseActivities    int64         `json:"modify_license_activities"`
+	CancelLicenseActivities    int64         `json:"cancel_license_activities"`
 	ActivitiesByStatus         []StatusCount `json:"activities_by_status"`
 	ActivitiesByDate           []DateCount   `json:"activities_by_date"`
 	ActivitiesByUser           []UserCount   `json:"activities_by_user"`
@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"eservice-backend/models"
+	"time"
+)
+
+// AppealFile is a file the applicant uploaded with an appeal, already saved to disk
+type AppealFile struct {
+	FileName     string
+	OriginalName string
+	FilePath     string
+	FileSize     int64
+	MimeType     string
+	FileType     models.AttachmentType
+}
+
+// FileAppealRequest represents an applicant's appeal against the rejection of a request
+type FileAppealRequest struct {
+	RequestID       uint
+	UserID          uint
+	UserRole        models.UserRole
+	ExpectedVersion *int
+	Grounds         string
+	Files           []AppealFile
+}
+
+// DecideAppealRequest represents a DEDE Head's decision on an appeal
+type DecideAppealRequest struct {
+	AppealID        uint                 `json:"-"`
+	UserID          uint                 `json:"-"`
+	UserRole        models.UserRole      `json:"-"`
+	ExpectedVersion *int                 `json:"-"`
+	Decision        string               `json:"decision" binding:"required,oneof=grant dismiss"`
+	ToStatus        models.RequestStatus `json:"to_status"` // Status a granted appeal reopens; defaults to the status the request was rejected from
+	Comments        string               `json:"comments"`
+	OnBehalfOfID    *uint                `json:"on_behalf_of_id"`
+}
+
+// AppealFilter narrows the appeal queue
+type AppealFilter struct {
+	Status     models.AppealStatus `form:"status"`
+	ReviewerID uint                `form:"reviewer_id"`
+	Mine       bool                `form:"mine"` // Only appeals routed to the current user
+}
+
+// AppealResponse represents an appeal with its files
+type AppealResponse struct {
+	ID              uint                         `json:"id"`
+	RequestID       uint                         `json:"request_id"`
+	RequestNumber   string                       `json:"request_number"`
+	LicenseType     string                       `json:"license_type"`
+	Status          models.AppealStatus          `json:"status"`
+	Grounds         string                       `json:"grounds"`
+	RejectedFrom    models.RequestStatus         `json:"rejected_from"`
+	RejectedByID    *uint                        `json:"rejected_by_id"`
+	RejectedBy      string                       `json:"rejected_by"`
+	RejectionReason string                       `json:"rejection_reason"`
+	RejectedAt      time.Time                    `json:"rejected_at"`
+	FiledByID       uint                         `json:"filed_by_id"`
+	FiledBy         string                       `json:"filed_by"`
+	ReviewerID      *uint                        `json:"reviewer_id"`
+	Reviewer        string                       `json:"reviewer"`
+	DecidedByID     *uint                        `json:"decided_by_id"`
+	DecidedBy       string                       `json:"decided_by"`
+	OnBehalfOfID    *uint                        `json:"on_behalf_of_id"`
+	DecisionNotes   string                       `json:"decision_notes"`
+	DecidedAt       *time.Time                   `json:"decided_at"`
+	Attachments     []models.SubmittedAttachment `json:"attachments"`
+	FiledAt         time.Time                    `json:"filed_at"`
+	Transition      *WorkflowTransitionResult    `json:"transition,omitempty"` // Status change made by filing or deciding the appeal
+}
@@ -0,0 +1,40 @@
+package dto
+
+// BulkMode decides what happens to the other items of a bulk action when one of them fails
+type BulkMode string
+
+const (
+	BulkModeBestEffort   BulkMode = "best_effort"    // Apply every item that passes; report the rest
+	BulkModeAllOrNothing BulkMode = "all_or_nothing" // Apply the items only if every one of them passes
+)
+
+// Error codes of a failed bulk action item
+const (
+	BulkErrorNotFound          = "not_found"
+	BulkErrorInvalidTransition = "invalid_transition"
+	BulkErrorGuardFailed       = "guard_failed"
+	BulkErrorVersionConflict   = "version_conflict"
+	BulkErrorRolledBack        = "rolled_back" // The item passed but another item of an all-or-nothing action failed
+	BulkErrorInternal          = "internal_error"
+)
+
+// BulkItemResult reports the outcome of one request of a bulk action
+type BulkItemResult struct {
+	RequestID uint                      `json:"request_id"`
+	Success   bool                      `json:"success"`
+	Result    *WorkflowTransitionResult `json:"result,omitempty"`
+	ErrorCode string                    `json:"error_code,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+	Details   interface{}               `json:"details,omitempty"` // Unmet guard conditions, or the current state on a version conflict
+}
+
+// BulkTransitionResult reports the outcome of a bulk action, item by item in the order given
+type BulkTransitionResult struct {
+	Action    string           `json:"action"`
+	Mode      BulkMode         `json:"mode"`
+	Committed bool             `json:"committed"` // Whether any status change was saved
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
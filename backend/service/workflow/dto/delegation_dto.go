@@ -0,0 +1,14 @@
+package dto
+
+import (
+	"time"
+)
+
+// CreateDelegationRequest represents a request to delegate workflow permissions for a period
+type CreateDelegationRequest struct {
+	DelegatorID uint       `json:"delegator_id"` // Admin only: delegate on behalf of another user
+	DelegateID  uint       `json:"delegate_id" binding:"required"`
+	StartsAt    *time.Time `json:"starts_at"` // Defaults to now
+	EndsAt      time.Time  `json:"ends_at" binding:"required"`
+	Reason      string     `json:"reason"`
+}
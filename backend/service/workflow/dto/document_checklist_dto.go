@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"eservice-backend/models"
+)
+
+// Checklist states of a required document
+const (
+	ChecklistItemMissing  = "missing"  // No file uploaded yet
+	ChecklistItemPending  = "pending"  // Uploaded, waiting for an officer's review
+	ChecklistItemAccepted = "accepted" // Accepted by an officer
+	ChecklistItemRejected = "rejected" // Rejected by an officer; a new file is needed
+)
+
+// DocumentRequirementRequest represents an admin adding a required document, or replacing one
+type DocumentRequirementRequest struct {
+	RequirementID uint               `json:"-"`
+	Code          string             `json:"code" binding:"required,max=100"`
+	Name          string             `json:"name" binding:"required"`
+	Description   string             `json:"description"`
+	LicenseType   models.LicenseType `json:"license_type" binding:"required,oneof=new renewal extension reduction modify cancel"`
+	EnergyType    string             `json:"energy_type"`  // Omit for every energy type
+	MinCapacity   *float64           `json:"min_capacity"` // Included in the band
+	MaxCapacity   *float64           `json:"max_capacity"` // Excluded from the band
+	SortOrder     int                `json:"sort_order"`
+	IsActive      *bool              `json:"is_active"` // Defaults to true
+	UserID        uint               `json:"-"`
+}
+
+// DocumentRequirementFilter narrows the requirement catalog. With energy_type or capacity set,
+// only the requirements applying to such a request are listed.
+type DocumentRequirementFilter struct {
+	LicenseType models.LicenseType `form:"license_type"`
+	EnergyType  string             `form:"energy_type"`
+	Capacity    *float64           `form:"capacity"`
+	Active      *bool              `form:"active"`
+}
+
+// UploadDocumentRequest represents an applicant uploading the file of a required document
+type UploadDocumentRequest struct {
+	RequestID     uint
+	RequirementID uint
+	UserID        uint
+	FileName      string
+	OriginalName  string
+	FilePath      string
+	FileSize      int64
+	MimeType      string
+	FileType      models.AttachmentType
+}
+
+// ReviewDocumentRequest represents an officer accepting or rejecting an uploaded document
+type ReviewDocumentRequest struct {
+	AttachmentID uint                        `json:"-"`
+	Decision     models.DocumentReviewStatus `json:"decision" binding:"required,oneof=accepted rejected"`
+	Reason       string                      `json:"reason"` // Required when rejecting
+	UserID       uint                        `json:"-"`
+}
+
+// DocumentChecklistItem is a required document of a request with its latest upload
+type DocumentChecklistItem struct {
+	RequirementID uint               `json:"requirement_id"`
+	Code          string             `json:"code"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description"`
+	Status        string             `json:"status"`   // missing, pending, accepted or rejected
+	Document      *models.Attachment `json:"document"` // Latest upload; earlier ones stay in the submission history
+}
+
+// DocumentChecklistResponse lists the documents a request needs and how far it is from complete
+type DocumentChecklistResponse struct {
+	RequestID     uint                    `json:"request_id"`
+	RequestNumber string                  `json:"request_number"`
+	LicenseType   models.LicenseType      `json:"license_type"`
+	EnergyType    string                  `json:"energy_type"`
+	Capacity      float64                 `json:"capacity"` // Capacity the requirements were matched on
+	Complete      bool                    `json:"complete"` // Every document uploaded and none rejected
+	Missing       int                     `json:"missing"`
+	Rejected      int                     `json:"rejected"`
+	Items         []DocumentChecklistItem `json:"items"`
+}
@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"eservice-backend/models"
+	"time"
+)
+
+// EnforcementFile is a file uploaded with a notice or a response, already saved to disk
+type EnforcementFile struct {
+	FileName     string
+	OriginalName string
+	FilePath     string
+	FileSize     int64
+	MimeType     string
+	FileType     models.AttachmentType
+}
+
+// IssueEnforcementNoticeRequest represents a DEDE officer's notice to a license holder
+type IssueEnforcementNoticeRequest struct {
+	LicenseID        uint
+	Action           models.EnforcementAction
+	Grounds          string
+	ResponseDeadline *time.Time // Defaults to the standard response period from now
+	UserID           uint
+	Files            []EnforcementFile
+}
+
+// RespondEnforcementRequest represents the holder's response to a notice
+type RespondEnforcementRequest struct {
+	EnforcementID uint
+	UserID        uint
+	Response      string
+	Files         []EnforcementFile
+}
+
+// DecideEnforcementRequest represents a DEDE Head's decision on an enforcement case
+type DecideEnforcementRequest struct {
+	EnforcementID  uint       `json:"-"`
+	UserID         uint       `json:"-"`
+	Decision       string     `json:"decision" binding:"required,oneof=suspend revoke dismiss"`
+	Notes          string     `json:"notes"`
+	SuspendedUntil *time.Time `json:"suspended_until"` // End of a suspension; without it the license stays suspended until reinstated
+}
+
+// ReinstateLicenseRequest represents a DEDE Head lifting a suspension
+type ReinstateLicenseRequest struct {
+	EnforcementID uint   `json:"-"`
+	UserID        uint   `json:"-"`
+	Notes         string `json:"notes" binding:"required"`
+}
+
+// EnforcementFilter narrows the enforcement case list
+type EnforcementFilter struct {
+	Status    models.EnforcementStatus `form:"status"`
+	LicenseID uint                     `form:"license_id"`
+	Open      bool                     `form:"open"` // Only cases waiting for the holder or a decision
+}
+
+// EnforcementEventResponse is an entry in the history of an enforcement case
+type EnforcementEventResponse struct {
+	ID                  uint                        `json:"id"`
+	EventType           models.EnforcementEventType `json:"event_type"`
+	FromStatus          *models.EnforcementStatus   `json:"from_status"`
+	ToStatus            models.EnforcementStatus    `json:"to_status"`
+	LicenseStatusBefore models.LicenseStatus        `json:"license_status_before"`
+	LicenseStatusAfter  models.LicenseStatus        `json:"license_status_after"`
+	ActorID             *uint                       `json:"actor_id"`
+	Actor               string                      `json:"actor"`
+	Notes               string                      `json:"notes"`
+	CreatedAt           time.Time                   `json:"created_at"`
+}
+
+// EnforcementResponse represents an enforcement case with its files and history
+type EnforcementResponse struct {
+	ID                  uint                         `json:"id"`
+	CaseNumber          string                       `json:"case_number"`
+	LicenseID           uint                         `json:"license_id"`
+	LicenseNumber       string                       `json:"license_number"`
+	HolderName          string                       `json:"holder_name"`
+	LicenseStatus       models.LicenseStatus         `json:"license_status"`
+	ProposedAction      models.EnforcementAction     `json:"proposed_action"`
+	Status              models.EnforcementStatus     `json:"status"`
+	Grounds             string                       `json:"grounds"`
+	ResponseDeadline    time.Time                    `json:"response_deadline"`
+	IssuedByID          uint                         `json:"issued_by_id"`
+	IssuedBy            string                       `json:"issued_by"`
+	Response            string                       `json:"response"`
+	RespondedByID       *uint                        `json:"responded_by_id"`
+	RespondedBy         string                       `json:"responded_by"`
+	RespondedAt         *time.Time                   `json:"responded_at"`
+	Decision            models.EnforcementAction     `json:"decision"`
+	DecisionNotes       string                       `json:"decision_notes"`
+	DecidedByID         *uint                        `json:"decided_by_id"`
+	DecidedBy           string                       `json:"decided_by"`
+	DecidedAt           *time.Time                   `json:"decided_at"`
+	SuspendedUntil      *time.Time                   `json:"suspended_until"`
+	ReinstatedAt        *time.Time                   `json:"reinstated_at"`
+	NoticeAttachments   []models.SubmittedAttachment `json:"notice_attachments"`
+	ResponseAttachments []models.SubmittedAttachment `json:"response_attachments"`
+	Events              []EnforcementEventResponse   `json:"events,omitempty"` // Oldest first; only with a single case
+	CreatedAt           time.Time                    `json:"created_at"`
+}
+
+// EnforcementRunResult reports what a run of the enforcement checks did
+type EnforcementRunResult struct {
+	Overdue    int `json:"overdue"`    // Notices whose response deadline passed
+	Reinstated int `json:"reinstated"` // Suspensions that ended
+}
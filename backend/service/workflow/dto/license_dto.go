@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"eservice-backend/models"
+	"time"
+)
+
+// LicenseFilter narrows the license registry
+type LicenseFilter struct {
+	Status models.LicenseStatus `form:"status"`
+	Search string               `form:"search"` // License number or project name
+	Mine   bool                 `form:"mine"`   // Only licenses the current user holds; always set for applicants
+}
+
+// RegisterLicenseRequest records a license issued before the registry existed, so that
+// renewal, extension and reduction requests can refer to it
+type RegisterLicenseRequest struct {
+	LicenseNumber     string    `json:"license_number" binding:"required"`
+	HolderUserID      uint      `json:"holder_user_id" binding:"required"`
+	HolderCorporateID *uint     `json:"holder_corporate_id"`
+	ProjectName       string    `json:"project_name" binding:"required"`
+	Location          string    `json:"location"`
+	Province          string    `json:"province"`
+	EnergyType        string    `json:"energy_type"`
+	Capacity          float64   `json:"capacity" binding:"required,gt=0"`
+	CapacityUnit      string    `json:"capacity_unit" binding:"required"`
+	ValidFrom         time.Time `json:"valid_from" binding:"required"`
+	ValidUntil        time.Time `json:"valid_until" binding:"required,gtfield=ValidFrom"`
+}
+
+// LicenseRequestSummary is a request that was issued on, or changed, a license
+type LicenseRequestSummary struct {
+	RequestID         uint                 `json:"request_id"`
+	RequestNumber     string               `json:"request_number"`
+	LicenseType       string               `json:"license_type"`
+	Status            models.RequestStatus `json:"status"`
+	RequestedCapacity float64              `json:"requested_capacity"`
+	CreatedAt         time.Time            `json:"created_at"`
+}
+
+// LicenseResponse represents an issued license
+type LicenseResponse struct {
+	ID                   uint                    `json:"id"`
+	LicenseNumber        string                  `json:"license_number"`
+	Status               models.LicenseStatus    `json:"status"`
+	HolderUserID         uint                    `json:"holder_user_id"`
+	HolderName           string                  `json:"holder_name"`
+	HolderCorporateID    *uint                   `json:"holder_corporate_id"`
+	HolderCorporateName  string                  `json:"holder_corporate_name,omitempty"`
+	ProjectName          string                  `json:"project_name"`
+	Location             string                  `json:"location"`
+	Province             string                  `json:"province"`
+	EnergyType           string                  `json:"energy_type"`
+	Capacity             float64                 `json:"capacity"`
+	CapacityUnit         string                  `json:"capacity_unit"`
+	ContactPerson        string                  `json:"contact_person"`
+	ContactPhone         string                  `json:"contact_phone"`
+	ContactEmail         string                  `json:"contact_email"`
+	Equipment            string                  `json:"equipment"`
+	IssuedAt             time.Time               `json:"issued_at"`
+	ValidFrom            time.Time               `json:"valid_from"`
+	ValidUntil           time.Time               `json:"valid_until"`
+	IssuedRequestID      *uint                   `json:"issued_request_id"`
+	LastAmendedRequestID *uint                   `json:"last_amended_request_id"`
+	AmendedAt            *time.Time              `json:"amended_at"`
+	CancelledAt          *time.Time              `json:"cancelled_at"`
+	CertificateID        *uint                   `json:"certificate_id"`     // Attachment holding the current certificate PDF
+	Requests             []LicenseRequestSummary `json:"requests,omitempty"` // Requests that refer to the license, oldest first
+}
+
+// CapacityHistoryResponse is the capacity ledger of a license
+type CapacityHistoryResponse struct {
+	LicenseID     uint                          `json:"license_id"`
+	LicenseNumber string                        `json:"license_number"`
+	Capacity      *float64                      `json:"capacity"` // Licensed capacity at At, or now; nil before the license was issued
+	CapacityUnit  string                        `json:"capacity_unit"`
+	At            *time.Time                    `json:"at,omitempty"`
+	Entries       []models.LicenseCapacityEntry `json:"entries"` // Oldest first
+}
+
+// LicenseVerification is the privacy-safe view of a license returned to third parties
+type LicenseVerification struct {
+	LicenseNumber string               `json:"license_number"`
+	HolderName    string               `json:"holder_name"`
+	ProjectName   string               `json:"project_name"`
+	Capacity      float64              `json:"capacity"`
+	CapacityUnit  string               `json:"capacity_unit"`
+	ValidFrom     time.Time            `json:"valid_from"`
+	ValidUntil    time.Time            `json:"valid_until"`
+	Status        models.LicenseStatus `json:"status"`
+	VerifiedAt    time.Time            `json:"verified_at"` // When the server made the statement
+}
+
+// SignedLicenseVerification carries a license verification with the server's signature,
+// so that it can be checked offline against the published verification key
+type SignedLicenseVerification struct {
+	License       LicenseVerification `json:"license"`
+	SignedPayload string              `json:"signed_payload"` // Base64 of the exact JSON bytes that were signed
+	Signature     string              `json:"signature"`      // Base64 signature of the signed payload
+	Algorithm     string              `json:"algorithm"`
+	KeyID         string              `json:"key_id"`
+}
+
+// VerificationKeyResponse is the public key that checks license verification signatures
+type VerificationKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"` // Base64 raw public key
+}
+
+// LicenseExpiryRunResult reports what one run of the license expiry checks did
+type LicenseExpiryRunResult struct {
+	Reminded int `json:"reminded"` // Reminders sent to holders of licenses nearing expiry
+	Expired  int `json:"expired"`  // Licenses moved to expired
+}
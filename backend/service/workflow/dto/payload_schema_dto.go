@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"encoding/json"
+	"eservice-backend/models"
+	"time"
+)
+
+// PublishPayloadSchemaRequest represents an admin publishing the next schema version for the
+// technical data of a license type and energy type
+type PublishPayloadSchemaRequest struct {
+	LicenseType models.LicenseType `json:"license_type" binding:"required,oneof=new renewal extension reduction modify cancel"`
+	EnergyType  string             `json:"energy_type"` // Omit for energy types without a schema of their own
+	Schema      json.RawMessage    `json:"schema" binding:"required"`
+	Description string             `json:"description"`
+	Activate    *bool              `json:"activate"` // Defaults to true
+	UserID      uint               `json:"-"`
+}
+
+// PayloadSchemaFilter narrows the published schema versions
+type PayloadSchemaFilter struct {
+	LicenseType models.LicenseType `form:"license_type"`
+	EnergyType  *string            `form:"energy_type"`
+	Active      *bool              `form:"active"`
+}
+
+// PayloadSchemaResponse is a schema version, with the schema for forms to render from
+type PayloadSchemaResponse struct {
+	ID          uint               `json:"id"`
+	LicenseType models.LicenseType `json:"license_type"`
+	EnergyType  string             `json:"energy_type"`
+	Version     int                `json:"version"`
+	Description string             `json:"description"`
+	IsActive    bool               `json:"is_active"`
+	Schema      json.RawMessage    `json:"schema"`
+	CreatedByID *uint              `json:"created_by_id"`
+	ActivatedAt *time.Time         `json:"activated_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// SaveTechnicalDataRequest represents an applicant saving the technical data of their request.
+// The data replaces what was saved before.
+type SaveTechnicalDataRequest struct {
+	RequestID uint                   `json:"-"`
+	UserID    uint                   `json:"-"`
+	Data      map[string]interface{} `json:"data" binding:"required"`
+}
+
+// TechnicalDataResponse is the technical data of a request and the schema its form renders from
+type TechnicalDataResponse struct {
+	RequestID     uint                   `json:"request_id"`
+	RequestNumber string                 `json:"request_number"`
+	LicenseType   models.LicenseType     `json:"license_type"`
+	EnergyType    string                 `json:"energy_type"`
+	Data          map[string]interface{} `json:"data"`
+	SavedSchemaID *uint                  `json:"saved_schema_id"` // Version the data was last saved against
+	Schema        *PayloadSchemaResponse `json:"schema"`          // Active version for the request; nil when none applies
+}
@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"eservice-backend/models"
+	"time"
+)
+
+// CreateFeeScheduleRequest represents an admin adding the fee of a license type and capacity band
+type CreateFeeScheduleRequest struct {
+	LicenseType models.LicenseType `json:"license_type" binding:"required,oneof=new renewal extension reduction modify cancel"`
+	MinCapacity float64            `json:"min_capacity" binding:"min=0"`
+	MaxCapacity *float64           `json:"max_capacity"` // Excluded from the band; omit for no upper bound
+	Amount      float64            `json:"amount" binding:"required,gt=0"`
+	Description string             `json:"description"`
+	UserID      uint               `json:"-"`
+}
+
+// FeeScheduleFilter narrows the fee schedule list
+type FeeScheduleFilter struct {
+	LicenseType models.LicenseType `form:"license_type"`
+	Active      *bool              `form:"active"`
+}
+
+// FeeQuoteRequest asks for the fee of a license type at a capacity
+type FeeQuoteRequest struct {
+	LicenseType models.LicenseType `form:"license_type" binding:"required"`
+	Capacity    float64            `form:"capacity"`
+}
+
+// FeeQuoteResponse is the fee a request of a license type and capacity would be invoiced
+type FeeQuoteResponse struct {
+	LicenseType   models.LicenseType `json:"license_type"`
+	Capacity      float64            `json:"capacity"`
+	FeeScheduleID *uint              `json:"fee_schedule_id"` // nil when no schedule covers the capacity and no fee is charged
+	Amount        float64            `json:"amount"`
+	Currency      string             `json:"currency"`
+	Description   string             `json:"description"`
+}
+
+// InvoiceFilter narrows the invoice list
+type InvoiceFilter struct {
+	RequestID uint                 `form:"request_id"`
+	Status    models.InvoiceStatus `form:"status"`
+}
+
+// ConfirmPaymentRequest represents an officer recording a payment of an invoice
+type ConfirmPaymentRequest struct {
+	InvoiceID uint       `json:"-"`
+	Reference string     `json:"reference"` // Transaction reference at the provider or on the bank statement
+	Amount    float64    `json:"amount" binding:"required,gt=0"`
+	PaidAt    *time.Time `json:"paid_at"` // Defaults to now
+	Notes     string     `json:"notes"`
+	UserID    uint       `json:"-"`
+}
+
+// InvoiceResponse represents an invoice with its payments
+type InvoiceResponse struct {
+	ID                uint                      `json:"id"`
+	InvoiceNumber     string                    `json:"invoice_number"`
+	LicenseRequestID  uint                      `json:"license_request_id"`
+	RequestNumber     string                    `json:"request_number"`
+	LicenseType       models.LicenseType        `json:"license_type"`
+	ApplicantName     string                    `json:"applicant_name"`
+	FeeScheduleID     uint                      `json:"fee_schedule_id"`
+	Capacity          float64                   `json:"capacity"`
+	Amount            float64                   `json:"amount"`
+	Currency          string                    `json:"currency"`
+	Description       string                    `json:"description"`
+	Status            models.InvoiceStatus      `json:"status"`
+	DueDate           *time.Time                `json:"due_date"`
+	Provider          string                    `json:"provider"`
+	ProviderReference string                    `json:"provider_reference"`
+	PaymentPayload    string                    `json:"payment_payload"`
+	IssuedAt          time.Time                 `json:"issued_at"`
+	PaidAt            *time.Time                `json:"paid_at"`
+	ReceiptNumber     string                    `json:"receipt_number"`
+	ReceiptID         *uint                     `json:"receipt_id"`
+	CancelledAt       *time.Time                `json:"cancelled_at"`
+	Payments          []models.Payment          `json:"payments"`
+	Transition        *WorkflowTransitionResult `json:"transition,omitempty"` // Move of the request the payment cleared
+}
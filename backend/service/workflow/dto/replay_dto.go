@@ -0,0 +1,103 @@
+package dto
+
+import (
+	"eservice-backend/models"
+	"time"
+)
+
+// Kinds of inconsistency found by replaying a request's flow log
+const (
+	ReplayIssueIllegalTransition = "illegal_transition" // An entry the pinned workflow definition does not allow
+	ReplayIssueHistoryGap        = "history_gap"        // An entry starts from another status than the one the log was in
+	ReplayIssueStatusMismatch    = "status_mismatch"    // The request's status differs from the status the log ends in
+	ReplayIssueMissingHistory    = "missing_history"    // The request has no flow log at all
+)
+
+// Ways of repairing a request whose status does not match its flow log
+const (
+	RepairStrategyLog    = "log"    // Append a flow log entry recording the unlogged status change
+	RepairStrategyStatus = "status" // Set the status back to the one the flow log ends in
+)
+
+// ReplayStep is one flow log entry as replayed through the state machine
+type ReplayStep struct {
+	FlowLogID    uint                  `json:"flow_log_id"`
+	FromStatus   *models.RequestStatus `json:"from_status"` // As logged; nil for the entry that created the request
+	ToStatus     models.RequestStatus  `json:"to_status"`
+	ChangedByID  *uint                 `json:"changed_by_id"` // Nil for system changes
+	ChangedBy    string                `json:"changed_by"`
+	OnBehalfOfID *uint                 `json:"on_behalf_of_id,omitempty"`
+	Reason       string                `json:"reason"`
+	ChangedAt    time.Time             `json:"changed_at"`
+	Legal        bool                  `json:"legal"` // Whether the workflow definition allows the entry
+}
+
+// ReplayIssue is an inconsistency found while replaying a request
+type ReplayIssue struct {
+	Kind       string               `json:"kind"`
+	FlowLogID  *uint                `json:"flow_log_id,omitempty"`
+	FromStatus models.RequestStatus `json:"from_status,omitempty"`
+	ToStatus   models.RequestStatus `json:"to_status,omitempty"`
+	Message    string               `json:"message"`
+}
+
+// ReplayRepair describes the change made to bring a request's status and flow log back in line
+type ReplayRepair struct {
+	Strategy   string               `json:"strategy"`
+	FromStatus models.RequestStatus `json:"from_status"`
+	ToStatus   models.RequestStatus `json:"to_status"`
+	FlowLogID  *uint                `json:"flow_log_id,omitempty"` // Entry appended by the log strategy
+	Version    int                  `json:"version"`               // Request version after the repair
+}
+
+// ReplayReport is the outcome of replaying a request's flow log through its pinned workflow definition
+type ReplayReport struct {
+	RequestID       uint                 `json:"request_id"`
+	RequestNumber   string               `json:"request_number"`
+	LicenseType     string               `json:"license_type"`
+	WorkflowVersion int                  `json:"workflow_version"`
+	Status          models.RequestStatus `json:"status"`          // Status stored on the request
+	ReplayedStatus  models.RequestStatus `json:"replayed_status"` // Status the flow log ends in
+	Consistent      bool                 `json:"consistent"`
+	Steps           []ReplayStep         `json:"steps"`
+	Issues          []ReplayIssue        `json:"issues"`
+	Repair          *ReplayRepair        `json:"repair,omitempty"`
+}
+
+// ConsistencyCheckRequest selects the requests to replay and whether to repair them
+type ConsistencyCheckRequest struct {
+	LicenseType string               `json:"license_type"`
+	Status      models.RequestStatus `json:"status"`
+	RequestIDs  []uint               `json:"request_ids"`
+	Repair      bool                 `json:"repair"`
+	Strategy    string               `json:"strategy" binding:"omitempty,oneof=log status"` // Defaults to log
+	RepairedBy  *uint                `json:"-"`                                             // Nil when run from the command line
+}
+
+// ConsistencyCheckResult summarises a consistency check; only the requests with issues are reported
+type ConsistencyCheckResult struct {
+	Checked      int            `json:"checked"`
+	Consistent   int            `json:"consistent"`
+	Inconsistent int            `json:"inconsistent"`
+	Repaired     int            `json:"repaired"`
+	IssueCounts  map[string]int `json:"issue_counts"`
+	Reports      []ReplayReport `json:"reports"`
+	CheckedAt    time.Time      `json:"checked_at"`
+}
+
+// RequestStateAsOf is a request as it stood at a point in time, reconstructed from its flow log
+type RequestStateAsOf struct {
+	RequestID     uint                 `json:"request_id"`
+	RequestNumber string               `json:"request_number"`
+	LicenseType   string               `json:"license_type"`
+	AsOf          time.Time            `json:"as_of"`
+	Existed       bool                 `json:"existed"` // False when the request was created after the time asked for
+	Status        models.RequestStatus `json:"status"`
+	Since         *time.Time           `json:"since"` // When the request entered the status
+	ChangedByID   *uint                `json:"changed_by_id"`
+	ChangedBy     string               `json:"changed_by"`
+	Reason        string               `json:"reason"`
+	Steps         []ReplayStep         `json:"steps"`      // Flow log up to the time asked for
+	Submission    *SubmissionResponse  `json:"submission"` // Latest submission snapshot taken by then
+	CurrentStatus models.RequestStatus `json:"current_status"`
+}
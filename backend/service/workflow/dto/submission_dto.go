@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"eservice-backend/models"
+	"time"
+)
+
+// ResubmitRequest represents an applicant's resubmission of a returned request
+type ResubmitRequest struct {
+	ToStatus models.RequestStatus `json:"to_status"` // Defaults to the status the request was returned from
+	Comments string               `json:"comments"`
+}
+
+// SubmissionResponse represents a stored submission snapshot
+type SubmissionResponse struct {
+	ID            uint                         `json:"id"`
+	RequestID     uint                         `json:"request_id"`
+	LicenseType   string                       `json:"license_type"`
+	Sequence      int                          `json:"sequence"`
+	FromStatus    models.RequestStatus         `json:"from_status"`
+	ToStatus      models.RequestStatus         `json:"to_status"`
+	SubmittedByID uint                         `json:"submitted_by_id"`
+	SubmittedBy   string                       `json:"submitted_by"`
+	Fields        map[string]interface{}       `json:"fields"`
+	Attachments   []models.SubmittedAttachment `json:"attachments"`
+	SubmittedAt   time.Time                    `json:"submitted_at"`
+}
+
+// FieldChange describes a field whose value differs between two submissions
+type FieldChange struct {
+	Field    string      `json:"field"`
+	Previous interface{} `json:"previous"`
+	Current  interface{} `json:"current"`
+}
+
+// AttachmentChange describes an attachment that differs between two submissions
+type AttachmentChange struct {
+	Previous models.SubmittedAttachment `json:"previous"`
+	Current  models.SubmittedAttachment `json:"current"`
+}
+
+// SubmissionDiff lists what changed from one submission of a request to another
+type SubmissionDiff struct {
+	RequestID           uint                         `json:"request_id"`
+	PreviousSequence    int                          `json:"previous_sequence"`
+	CurrentSequence     int                          `json:"current_sequence"`
+	PreviousSubmittedAt time.Time                    `json:"previous_submitted_at"`
+	CurrentSubmittedAt  time.Time                    `json:"current_submitted_at"`
+	Fields              []FieldChange                `json:"fields"`
+	AddedAttachments    []models.SubmittedAttachment `json:"added_attachments"`
+	RemovedAttachments  []models.SubmittedAttachment `json:"removed_attachments"`
+	ChangedAttachments  []AttachmentChange           `json:"changed_attachments"`
+	HasChanges          bool                         `json:"has_changes"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"encoding/json"
+	"eservice-backend/models"
+	"time"
+)
+
+// CreateWorkflowDefinitionRequest represents a request to publish a new workflow definition version
+type CreateWorkflowDefinitionRequest struct {
+	LicenseType string          `json:"license_type" binding:"required"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Definition  json.RawMessage `json:"definition" binding:"required"`
+	Activate    bool            `json:"activate"`
+}
+
+// WorkflowDefinitionResponse represents a workflow definition version
+type WorkflowDefinitionResponse struct {
+	ID          uint                           `json:"id"`
+	LicenseType string                         `json:"license_type"`
+	Version     int                            `json:"version"`
+	Name        string                         `json:"name"`
+	Description string                         `json:"description"`
+	IsActive    bool                           `json:"is_active"`
+	IsBuiltIn   bool                           `json:"is_built_in"`
+	Definition  *models.WorkflowDefinitionSpec `json:"definition"`
+	Summary     map[string]interface{}         `json:"summary"`
+	CreatedByID *uint                          `json:"created_by_id"`
+	ActivatedAt *time.Time                     `json:"activated_at"`
+	CreatedAt   *time.Time                     `json:"created_at"`
+}
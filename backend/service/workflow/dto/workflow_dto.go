@@ -18,15 +18,77 @@ type WorkflowStateResponse struct {
 // WorkflowTransitionRequest represents a request to transition workflow state
 type WorkflowTransitionRequest struct {
 	RequestID       uint                 `json:"request_id" binding:"required"`
-	LicenseType     string               `json:"license_type" binding:"required"`
-	FromStatus      models.RequestStatus `json:"from_status" binding:"required"`
+	LicenseType     string               `json:"license_type"`     // Optional, must match the stored request when given
+	FromStatus      models.RequestStatus `json:"from_status"`      // Optional precondition on the current status
+	ExpectedVersion *int                 `json:"expected_version"` // Optional precondition on the current version, e.g. from If-Match
 	ToStatus        models.RequestStatus `json:"to_status" binding:"required"`
 	AssignedToID    uint                 `json:"assigned_to_id"`
 	Comments        string               `json:"comments"`
 	AutoApproved    bool                 `json:"auto_approved"`
 	UserID          uint                 `json:"user_id"`
 	UserRole        models.UserRole      `json:"user_role"`
+	OnBehalfOfID    *uint                `json:"on_behalf_of_id"` // Optional delegator to act for; resolved from active delegations when omitted
 	AppointmentDate *time.Time           `json:"appointment_date"`
+	RejectionReason string               `json:"rejection_reason"`
+}
+
+// WorkflowTransitionResult represents the outcome of a committed workflow transition
+type WorkflowTransitionResult struct {
+	RequestID      uint                 `json:"request_id"`
+	LicenseType    string               `json:"license_type"`
+	RequestNumber  string               `json:"request_number"`
+	PreviousStatus models.RequestStatus `json:"previous_status"`
+	NewStatus      models.RequestStatus `json:"new_status"`
+	Version        int                  `json:"version"`
+	Deadline       *time.Time           `json:"deadline"`
+	TransitionedAt time.Time            `json:"transitioned_at"`
+	OnBehalfOfID   *uint                `json:"on_behalf_of_id,omitempty"` // Delegator the caller acted for
+	Pending        bool                 `json:"pending,omitempty"`         // The vote was recorded but the step still waits for other approvers
+	Approval       *ApprovalStatus      `json:"approval,omitempty"`        // Votes of the step when it needs several approvals
+	LicenseID      *uint                `json:"license_id,omitempty"`      // License issued or changed by an approval
+	LicenseNumber  string               `json:"license_number,omitempty"`
+}
+
+// ApprovalStatus summarises the votes collected for a step that needs several approvals
+type ApprovalStatus struct {
+	Action        string                `json:"action"`
+	ToStatus      models.RequestStatus  `json:"to_status"`
+	VetoStatus    models.RequestStatus  `json:"veto_status"`
+	RoundID       uint                  `json:"round_id"`
+	MinApprovals  int                   `json:"min_approvals"`
+	RequiredRoles []models.UserRole     `json:"required_roles"`
+	Approvals     int                   `json:"approvals"`
+	MissingRoles  []models.UserRole     `json:"missing_roles"`
+	QuorumMet     bool                  `json:"quorum_met"`
+	Vetoed        bool                  `json:"vetoed"`
+	Votes         []models.ApprovalVote `json:"votes"`
+}
+
+// ApprovalVoteRequest represents an approver's vote on a step that needs several approvals
+type ApprovalVoteRequest struct {
+	RequestID       uint                    `json:"-"`
+	ExpectedVersion *int                    `json:"-"`
+	Decision        models.ApprovalDecision `json:"decision" binding:"required,oneof=approve reject"`
+	Comment         string                  `json:"comment"`
+	OnBehalfOfID    *uint                   `json:"on_behalf_of_id"` // Vote for this delegator under an active delegation
+	UserID          uint                    `json:"-"`
+	UserRole        models.UserRole         `json:"-"`
+}
+
+// UnmetCondition describes a guard condition that blocked a transition
+type UnmetCondition struct {
+	Guard   string      `json:"guard"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// WorkflowRequestState represents the current workflow state of a request, returned on version conflicts
+type WorkflowRequestState struct {
+	RequestID   uint                 `json:"request_id"`
+	LicenseType string               `json:"license_type"`
+	Status      models.RequestStatus `json:"status"`
+	Version     int                  `json:"version"`
+	UpdatedAt   time.Time            `json:"updated_at"`
 }
 
 // WorkflowHistoryResponse represents the response for workflow history
@@ -37,6 +99,8 @@ type WorkflowHistoryResponse struct {
 	NewStatus        models.RequestStatus  `json:"new_status"`
 	ChangedBy        uint                  `json:"changed_by"`
 	ChangedByUser    *models.User          `json:"changed_by_user"`
+	OnBehalfOf       *uint                 `json:"on_behalf_of"` // Delegator when ChangedBy acted under a delegation
+	OnBehalfOfUser   *models.User          `json:"on_behalf_of_user"`
 	ChangeReason     string                `json:"change_reason"`
 	LicenseType      string                `json:"license_type"`
 	CreatedAt        time.Time             `json:"created_at"`
@@ -44,25 +108,48 @@ type WorkflowHistoryResponse struct {
 
 // WorkflowDiagramResponse represents the response for workflow diagram
 type WorkflowDiagramResponse struct {
-	Nodes   []WorkflowNode         `json:"nodes"`
-	Edges   []WorkflowEdge         `json:"edges"`
-	Summary map[string]interface{} `json:"summary"`
+	LicenseType string                 `json:"license_type"`
+	Version     int                    `json:"version"`
+	Name        string                 `json:"name"`
+	Path        []models.RequestStatus `json:"path"`
+	Nodes       []WorkflowNode         `json:"nodes"`
+	Edges       []WorkflowEdge         `json:"edges"`
+	Summary     map[string]interface{} `json:"summary"`
+	Request     *WorkflowDiagramPath   `json:"request,omitempty"` // Set when the diagram highlights one request's history
 }
 
 // WorkflowNode represents a node in the workflow diagram
 type WorkflowNode struct {
-	ID          string                 `json:"id"`
-	Label       string                 `json:"label"`
-	Description string                 `json:"description"`
-	Progress    int                    `json:"progress"`
-	Position    map[string]interface{} `json:"position"`
+	ID           string                 `json:"id"`
+	Label        string                 `json:"label"`
+	Description  string                 `json:"description"`
+	Progress     int                    `json:"progress"`
+	Terminal     bool                   `json:"terminal"`
+	DeadlineDays int                    `json:"deadline_days"`
+	Visited      bool                   `json:"visited"` // The highlighted request has been in this state
+	Current      bool                   `json:"current"` // The highlighted request is in this state
+	Position     map[string]interface{} `json:"position,omitempty"`
 }
 
 // WorkflowEdge represents an edge in the workflow diagram
 type WorkflowEdge struct {
-	From string `json:"from"`
-	To   string `json:"to"`
-	Type string `json:"type"`
+	From        string                         `json:"from"`
+	To          string                         `json:"to"`
+	Type        string                         `json:"type"` // manual or auto
+	Action      string                         `json:"action"`
+	Description string                         `json:"description"`
+	Roles       []models.UserRole              `json:"roles"`
+	Guards      []string                       `json:"guards,omitempty"`
+	Approval    *models.WorkflowApprovalPolicy `json:"approval,omitempty"` // Quorum the transition waits for
+	Taken       bool                           `json:"taken"`              // The highlighted request went through this transition
+}
+
+// WorkflowDiagramPath is the path one request took through the workflow, from its flow log
+type WorkflowDiagramPath struct {
+	RequestID     uint                   `json:"request_id"`
+	RequestNumber string                 `json:"request_number"`
+	CurrentStatus models.RequestStatus   `json:"current_status"`
+	Statuses      []models.RequestStatus `json:"statuses"`
 }
 
 // TaskAssignmentRequest represents a request to assign a task
@@ -137,3 +224,26 @@ type WorkflowSummary struct {
 	Roles           []string `json:"roles"`
 	MaxDaysDelay    int      `json:"max_days_delay"`
 }
+
+// SLAClockResponse represents a request's SLA clock with totals up to now
+type SLAClockResponse struct {
+	RequestID         uint                 `json:"request_id"`
+	LicenseType       string               `json:"license_type"`
+	State             models.SLAClockState `json:"state"`
+	StartedAt         time.Time            `json:"started_at"`
+	PausedAt          *time.Time           `json:"paused_at"`
+	StoppedAt         *time.Time           `json:"stopped_at"`
+	PauseCount        int                  `json:"pause_count"`
+	DaysWithDEDE      float64              `json:"days_with_dede"`
+	DaysWithApplicant float64              `json:"days_with_applicant"`
+	TotalDays         float64              `json:"total_days"`
+}
+
+// SLAStatistics summarises the SLA clocks of a license type in one clock state
+type SLAStatistics struct {
+	LicenseType          string               `json:"license_type"`
+	State                models.SLAClockState `json:"state"`
+	Requests             int64                `json:"requests"`
+	AvgDaysWithDEDE      float64              `json:"avg_days_with_dede"`
+	AvgDaysWithApplicant float64              `json:"avg_days_with_applicant"`
+}
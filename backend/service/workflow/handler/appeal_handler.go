@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAppealFileSizeMB is the largest file accepted with an appeal
+const maxAppealFileSizeMB = 10
+
+// appealFileTypes are the kinds of files accepted with an appeal
+var appealFileTypes = []string{"pdf", "document", "image", "spreadsheet"}
+
+type AppealHandler struct {
+	appealService service.AppealService
+	uploadPath    string
+}
+
+func NewAppealHandler(db *gorm.DB, cfg *config.Config) *AppealHandler {
+	return &AppealHandler{
+		appealService: service.NewAppealService(db, cfg),
+		uploadPath:    cfg.UploadPath,
+	}
+}
+
+// FileAppeal files the applicant's appeal against the rejection of their request. The grounds
+// come in the "grounds" form field and supporting files in "attachments".
+func (h *AppealHandler) FileAppeal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	expectedVersion, ok := ExpectedVersion(c)
+	if !ok {
+		return
+	}
+
+	var uploads []*utils.FileUpload
+	if form, err := c.MultipartForm(); err == nil {
+		for _, file := range form.File["attachments"] {
+			fileType := utils.DetermineFileType(file.Filename, file.Header.Get("Content-Type"))
+			if !utils.IsValidFileSize(file.Size, maxAppealFileSizeMB) || !utils.IsValidFileType(fileType, appealFileTypes) {
+				removeUploads(uploads)
+				utils.ErrorBadRequest(c, "Invalid attachment", fmt.Errorf("%s must be a PDF, document, spreadsheet or image of at most %d MB", file.Filename, maxAppealFileSizeMB))
+				return
+			}
+
+			upload, err := utils.UploadFile(file, filepath.Join(h.uploadPath, models.AppealAttachmentEntityType))
+			if err != nil {
+				removeUploads(uploads)
+				utils.ErrorInternalServerError(c, "Failed to save attachment", err)
+				return
+			}
+			uploads = append(uploads, upload)
+		}
+	} else if !errors.Is(err, http.ErrNotMultipart) {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	req := dto.FileAppealRequest{
+		RequestID:       uint(id),
+		UserID:          userID.(uint),
+		UserRole:        userRole.(models.UserRole),
+		ExpectedVersion: expectedVersion,
+		Grounds:         c.PostForm("grounds"),
+	}
+	for _, upload := range uploads {
+		req.Files = append(req.Files, dto.AppealFile{
+			FileName:     upload.FileName,
+			OriginalName: upload.OriginalName,
+			FilePath:     upload.FilePath,
+			FileSize:     upload.FileSize,
+			MimeType:     upload.MimeType,
+			FileType:     models.AttachmentType(upload.FileType),
+		})
+	}
+
+	appeal, err := h.appealService.FileAppeal(req)
+	if err != nil {
+		// Nothing refers to the saved files when the appeal is not filed
+		removeUploads(uploads)
+		RespondTransitionError(c, "Failed to file appeal", err)
+		return
+	}
+
+	utils.SetVersionETag(c, appeal.Transition.Version)
+	utils.SuccessCreated(c, "Appeal filed successfully", appeal)
+}
+
+// DecideAppeal grants or dismisses an appeal
+func (h *AppealHandler) DecideAppeal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid appeal ID", err)
+		return
+	}
+
+	var req dto.DecideAppealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	expectedVersion, ok := ExpectedVersion(c)
+	if !ok {
+		return
+	}
+
+	req.AppealID = uint(id)
+	req.UserID = userID.(uint)
+	req.UserRole = userRole.(models.UserRole)
+	req.ExpectedVersion = expectedVersion
+
+	appeal, err := h.appealService.DecideAppeal(req)
+	if err != nil {
+		if errors.Is(err, service.ErrAppealNotFound) {
+			utils.ErrorNotFound(c, "Appeal not found", err)
+			return
+		}
+		RespondTransitionError(c, "Failed to decide appeal", err)
+		return
+	}
+
+	utils.SetVersionETag(c, appeal.Transition.Version)
+	utils.SuccessOK(c, "Appeal decided successfully", appeal)
+}
+
+// GetAppeal returns an appeal with its files
+func (h *AppealHandler) GetAppeal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid appeal ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	appeal, err := h.appealService.GetAppeal(uint(id), userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		if errors.Is(err, service.ErrAppealNotFound) {
+			utils.ErrorNotFound(c, "Appeal not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get appeal", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Appeal retrieved successfully", appeal)
+}
+
+// GetRequestAppeals returns every appeal filed against the rejections of a request
+func (h *AppealHandler) GetRequestAppeals(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	appeals, err := h.appealService.GetRequestAppeals(uint(id), userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		if errors.Is(err, service.ErrRequestNotFound) {
+			utils.ErrorNotFound(c, "Request not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get appeals", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Appeals retrieved successfully", appeals)
+}
+
+// GetAppeals returns the appeal queue, e.g. ?status=pending&mine=true for the appeals waiting on the current user
+func (h *AppealHandler) GetAppeals(c *gin.Context) {
+	var filter dto.AppealFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	appeals, err := h.appealService.GetAppeals(filter, userID.(uint))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get appeals", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Appeals retrieved successfully", appeals)
+}
+
+// removeUploads deletes files saved for an appeal that was not filed
+func removeUploads(uploads []*utils.FileUpload) {
+	for _, upload := range uploads {
+		utils.DeleteFile(upload.FilePath)
+	}
+}
+
+// SetAppealRoutes sets up routes for appeals against rejected requests
+func SetAppealRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create appeal handler
+	appealHandler := NewAppealHandler(db, cfg)
+
+	// Appeal routes (protected)
+	appeals := r.Group("/appeals")
+	appeals.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		// Applicants file appeals and follow their own
+		appeals.POST("/requests/:id", middleware.RequireRole([]string{"user"}), appealHandler.FileAppeal)
+		appeals.GET("/requests/:id", appealHandler.GetRequestAppeals)
+		appeals.GET("/:id", appealHandler.GetAppeal)
+
+		// Officers decide them; delegates of a DEDE Head act with the delegated role
+		appeals.GET("",
+			middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}),
+			appealHandler.GetAppeals)
+		appeals.POST("/:id/decision",
+			middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}),
+			appealHandler.DecideAppeal)
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ApprovalHandler struct {
+	transitionService service.WorkflowTransitionService
+}
+
+func NewApprovalHandler(db *gorm.DB, cfg *config.Config) *ApprovalHandler {
+	return &ApprovalHandler{
+		transitionService: service.NewWorkflowTransitionService(db, cfg),
+	}
+}
+
+// GetApprovals returns the votes collected at the request's current approval step
+func (h *ApprovalHandler) GetApprovals(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	status, err := h.transitionService.GetApprovalStatus(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrRequestNotFound) {
+			utils.ErrorNotFound(c, "Request not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get approvals", err)
+		return
+	}
+	if status == nil {
+		utils.SuccessOK(c, "Request is not waiting for several approvals", nil)
+		return
+	}
+
+	utils.SuccessOK(c, "Approvals retrieved successfully", status)
+}
+
+// CastVote records the current user's approval or veto at the request's approval step
+func (h *ApprovalHandler) CastVote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	var req dto.ApprovalVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	expectedVersion, ok := ExpectedVersion(c)
+	if !ok {
+		return
+	}
+
+	req.RequestID = uint(id)
+	req.ExpectedVersion = expectedVersion
+	req.UserID = userID.(uint)
+	req.UserRole = userRole.(models.UserRole)
+
+	result, err := h.transitionService.CastApprovalVote(req)
+	if err != nil {
+		if errors.Is(err, service.ErrNoApprovalStep) {
+			utils.ErrorUnprocessableEntity(c, "Request is not waiting for approvals", err)
+			return
+		}
+		RespondTransitionError(c, "Failed to record vote", err)
+		return
+	}
+
+	utils.SetVersionETag(c, result.Version)
+	if result.Pending {
+		utils.SuccessOK(c, "Vote recorded, waiting for other approvers", result)
+		return
+	}
+	utils.SuccessOK(c, "Vote recorded and request updated", result)
+}
+
+// SetApprovalRoutes sets up routes for steps that need several approvals
+func SetApprovalRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create approval handler
+	approvalHandler := NewApprovalHandler(db, cfg)
+
+	// Approval routes (protected)
+	approvals := r.Group("/approvals")
+	approvals.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	approvals.Use(middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}))
+	{
+		approvals.GET("/requests/:id", approvalHandler.GetApprovals)
+
+		// Whether a role may vote is decided by the request's workflow definition
+		approvals.POST("/requests/:id/votes", approvalHandler.CastVote)
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DelegationHandler struct {
+	delegationService service.DelegationService
+}
+
+func NewDelegationHandler(db *gorm.DB, cfg *config.Config) *DelegationHandler {
+	return &DelegationHandler{
+		delegationService: service.NewDelegationService(db, cfg),
+	}
+}
+
+// GetDelegations returns the delegations the current user gave or received
+func (h *DelegationHandler) GetDelegations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	delegations, err := h.delegationService.GetDelegations(userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get delegations", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Delegations retrieved successfully", delegations)
+}
+
+// CreateDelegation delegates the current user's workflow permissions to another user for a period
+func (h *DelegationHandler) CreateDelegation(c *gin.Context) {
+	var req dto.CreateDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	delegation, err := h.delegationService.CreateDelegation(userID.(uint), userRole.(models.UserRole), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDelegation) {
+			utils.ErrorBadRequest(c, "Invalid delegation", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to create delegation", err)
+		return
+	}
+
+	utils.SuccessCreated(c, "Delegation created successfully", delegation)
+}
+
+// RevokeDelegation ends a delegation before its end date
+func (h *DelegationHandler) RevokeDelegation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid delegation ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	delegation, err := h.delegationService.RevokeDelegation(uint(id), userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		if errors.Is(err, service.ErrDelegationNotFound) {
+			utils.ErrorNotFound(c, "Delegation not found", err)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidDelegation) {
+			utils.ErrorConflict(c, "Delegation has already ended", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to revoke delegation", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Delegation revoked successfully", delegation)
+}
+
+// SetDelegationRoutes sets up routes for delegating workflow permissions
+func SetDelegationRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create delegation handler
+	delegationHandler := NewDelegationHandler(db, cfg)
+
+	// Delegation routes (protected)
+	delegations := r.Group("/delegations")
+	delegations.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	delegations.Use(middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult", "auditor"}))
+	{
+		delegations.GET("", delegationHandler.GetDelegations)
+		delegations.POST("", delegationHandler.CreateDelegation)
+		delegations.POST("/:id/revoke", delegationHandler.RevokeDelegation)
+	}
+}
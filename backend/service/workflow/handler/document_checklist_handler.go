@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxDocumentFileSizeMB is the largest file accepted for a required document
+const maxDocumentFileSizeMB = 10
+
+// documentFileTypes are the kinds of files accepted for a required document
+var documentFileTypes = []string{"pdf", "document", "image", "spreadsheet"}
+
+type DocumentChecklistHandler struct {
+	checklistService service.DocumentChecklistService
+	uploadPath       string
+}
+
+func NewDocumentChecklistHandler(db *gorm.DB, cfg *config.Config) *DocumentChecklistHandler {
+	return &DocumentChecklistHandler{
+		checklistService: service.NewDocumentChecklistService(db, cfg),
+		uploadPath:       cfg.UploadPath,
+	}
+}
+
+// GetRequirements lists the requirement catalog, e.g. ?license_type=new&energy_type=solar&capacity=12
+// for the documents such a request would need
+func (h *DocumentChecklistHandler) GetRequirements(c *gin.Context) {
+	var filter dto.DocumentRequirementFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	filter.LicenseType = models.ParseLicenseType(string(filter.LicenseType))
+
+	requirements, err := h.checklistService.GetRequirements(filter)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get document requirements", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Document requirements retrieved successfully", requirements)
+}
+
+// CreateRequirement adds a document to the checklist of a license type
+func (h *DocumentChecklistHandler) CreateRequirement(c *gin.Context) {
+	var req dto.DocumentRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	req.UserID = userID.(uint)
+
+	requirement, err := h.checklistService.CreateRequirement(req)
+	if err != nil {
+		h.respondError(c, "Failed to create document requirement", err)
+		return
+	}
+
+	utils.SuccessCreated(c, "Document requirement created successfully", requirement)
+}
+
+// UpdateRequirement replaces a required document, e.g. with is_active false to retire it
+func (h *DocumentChecklistHandler) UpdateRequirement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid document requirement ID", err)
+		return
+	}
+
+	var req dto.DocumentRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+	req.RequirementID = uint(id)
+
+	requirement, err := h.checklistService.UpdateRequirement(req)
+	if err != nil {
+		h.respondError(c, "Failed to update document requirement", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Document requirement updated successfully", requirement)
+}
+
+// GetChecklist returns the required documents of a request and which of them are still missing or rejected
+func (h *DocumentChecklistHandler) GetChecklist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	checklist, err := h.checklistService.GetChecklist(uint(id), userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		h.respondError(c, "Failed to get document checklist", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Document checklist retrieved successfully", checklist)
+}
+
+// UploadDocument uploads the file of a required document. The form carries requirement_id and the "file".
+func (h *DocumentChecklistHandler) UploadDocument(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	requirementID, err := strconv.ParseUint(c.PostForm("requirement_id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid requirement ID", err)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorBadRequest(c, "File is required", err)
+		return
+	}
+	fileType := utils.DetermineFileType(file.Filename, file.Header.Get("Content-Type"))
+	if !utils.IsValidFileSize(file.Size, maxDocumentFileSizeMB) || !utils.IsValidFileType(fileType, documentFileTypes) {
+		utils.ErrorBadRequest(c, "Invalid document", fmt.Errorf("%s must be a PDF, document, spreadsheet or image of at most %d MB", file.Filename, maxDocumentFileSizeMB))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	upload, err := utils.UploadFile(file, filepath.Join(h.uploadPath, "license_request"))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to save document", err)
+		return
+	}
+
+	checklist, err := h.checklistService.UploadDocument(dto.UploadDocumentRequest{
+		RequestID:     uint(id),
+		RequirementID: uint(requirementID),
+		UserID:        userID.(uint),
+		FileName:      upload.FileName,
+		OriginalName:  upload.OriginalName,
+		FilePath:      upload.FilePath,
+		FileSize:      upload.FileSize,
+		MimeType:      upload.MimeType,
+		FileType:      models.AttachmentType(upload.FileType),
+	})
+	if err != nil {
+		// Nothing refers to the saved file when the document is not attached
+		removeUploads([]*utils.FileUpload{upload})
+		h.respondError(c, "Failed to upload document", err)
+		return
+	}
+
+	utils.SuccessCreated(c, "Document uploaded successfully", checklist)
+}
+
+// ReviewDocument accepts or rejects an uploaded document; a rejection needs a reason
+func (h *DocumentChecklistHandler) ReviewDocument(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid document ID", err)
+		return
+	}
+
+	var req dto.ReviewDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	req.AttachmentID = uint(id)
+	req.UserID = userID.(uint)
+
+	document, err := h.checklistService.ReviewDocument(req)
+	if err != nil {
+		h.respondError(c, "Failed to review document", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Document reviewed successfully", document)
+}
+
+func (h *DocumentChecklistHandler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrDocumentRequirementNotFound):
+		utils.ErrorNotFound(c, "Document requirement not found", err)
+	case errors.Is(err, service.ErrDocumentNotFound):
+		utils.ErrorNotFound(c, "Document not found", err)
+	case errors.Is(err, service.ErrRequestNotFound):
+		utils.ErrorNotFound(c, "Request not found", err)
+	case errors.Is(err, service.ErrInvalidTransition):
+		utils.ErrorUnprocessableEntity(c, message, err)
+	default:
+		utils.ErrorInternalServerError(c, message, err)
+	}
+}
+
+// SetDocumentChecklistRoutes sets up routes for the required documents of license requests
+func SetDocumentChecklistRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create document checklist handler
+	checklistHandler := NewDocumentChecklistHandler(db, cfg)
+
+	// Requirement catalog routes (protected); anyone signed in can look it up, admins maintain it
+	requirements := r.Group("/document-requirements")
+	requirements.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		requirements.GET("", checklistHandler.GetRequirements)
+		requirements.POST("", middleware.RequireRole([]string{"admin"}), checklistHandler.CreateRequirement)
+		requirements.PUT("/:id", middleware.RequireRole([]string{"admin"}), checklistHandler.UpdateRequirement)
+	}
+
+	// Checklist routes (protected); applicants only see and upload to their own requests
+	documents := r.Group("/documents")
+	documents.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		documents.GET("/requests/:id", checklistHandler.GetChecklist)
+		documents.POST("/requests/:id", middleware.RequireRole([]string{"user"}), checklistHandler.UploadDocument)
+
+		// Officers accept or reject each uploaded document
+		documents.POST("/:id/review",
+			middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}),
+			checklistHandler.ReviewDocument)
+	}
+}
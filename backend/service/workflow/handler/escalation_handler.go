@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type EscalationHandler struct {
+	escalationService service.EscalationService
+}
+
+func NewEscalationHandler(db *gorm.DB, cfg *config.Config) *EscalationHandler {
+	return &EscalationHandler{
+		escalationService: service.NewEscalationService(db, cfg),
+	}
+}
+
+// GetEscalations returns the latest escalations across all requests
+func (h *EscalationHandler) GetEscalations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	escalations, err := h.escalationService.GetRecentEscalations(limit)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get escalations", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Escalations retrieved successfully", escalations)
+}
+
+// GetRequestEscalations returns the escalations of a request
+func (h *EscalationHandler) GetRequestEscalations(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	escalations, err := h.escalationService.GetRequestEscalations(uint(id))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get escalations", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Escalations retrieved successfully", escalations)
+}
+
+// GetEscalationStatistics returns escalation counts
+func (h *EscalationHandler) GetEscalationStatistics(c *gin.Context) {
+	stats, err := h.escalationService.GetEscalationStatistics()
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get escalation statistics", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Escalation statistics retrieved successfully", stats)
+}
+
+// RunEscalations manually applies the escalation rules
+func (h *EscalationHandler) RunEscalations(c *gin.Context) {
+	fired, err := h.escalationService.RunEscalations()
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to run escalations", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Escalations completed successfully", gin.H{"fired": fired})
+}
+
+// SetEscalationRoutes sets up routes for SLA escalations
+func SetEscalationRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create escalation handler
+	escalationHandler := NewEscalationHandler(db, cfg)
+
+	// Escalation routes (protected)
+	escalations := r.Group("/escalations")
+	escalations.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	escalations.Use(middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}))
+	{
+		escalations.GET("", escalationHandler.GetEscalations)
+		escalations.GET("/statistics", escalationHandler.GetEscalationStatistics)
+		escalations.GET("/requests/:id", escalationHandler.GetRequestEscalations)
+
+		// Manual trigger
+		escalations.POST("/run", middleware.RequireRole([]string{"admin"}), escalationHandler.RunEscalations)
+	}
+}
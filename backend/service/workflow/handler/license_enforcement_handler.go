@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxEnforcementFileSizeMB is the largest file accepted with a notice or a response
+const maxEnforcementFileSizeMB = 10
+
+// enforcementFileTypes are the kinds of files accepted with a notice or a response
+var enforcementFileTypes = []string{"pdf", "document", "image", "spreadsheet"}
+
+type LicenseEnforcementHandler struct {
+	enforcementService service.LicenseEnforcementService
+	uploadPath         string
+}
+
+func NewLicenseEnforcementHandler(db *gorm.DB, cfg *config.Config) *LicenseEnforcementHandler {
+	return &LicenseEnforcementHandler{
+		enforcementService: service.NewLicenseEnforcementService(db, cfg),
+		uploadPath:         cfg.UploadPath,
+	}
+}
+
+// IssueNotice opens an enforcement case against a license. The form carries license_id, action
+// (suspend or revoke), grounds and an optional response_deadline; evidence comes in "attachments".
+func (h *LicenseEnforcementHandler) IssueNotice(c *gin.Context) {
+	licenseID, err := strconv.ParseUint(c.PostForm("license_id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid license ID", err)
+		return
+	}
+
+	var deadline *time.Time
+	if value := c.PostForm("response_deadline"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			date, dateErr := utils.ParseDate(value)
+			if dateErr != nil {
+				utils.ErrorBadRequest(c, "Invalid response_deadline, expected YYYY-MM-DD or RFC3339", err)
+				return
+			}
+			// A date gives the holder the whole day
+			parsed = date.Add(24*time.Hour - time.Nanosecond)
+		}
+		deadline = &parsed
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	files, uploads, ok := h.saveUploads(c, models.EnforcementNoticeAttachmentEntityType)
+	if !ok {
+		return
+	}
+
+	enforcement, err := h.enforcementService.IssueNotice(dto.IssueEnforcementNoticeRequest{
+		LicenseID:        uint(licenseID),
+		Action:           models.EnforcementAction(c.PostForm("action")),
+		Grounds:          c.PostForm("grounds"),
+		ResponseDeadline: deadline,
+		UserID:           userID.(uint),
+		Files:            files,
+	})
+	if err != nil {
+		// Nothing refers to the saved files when the notice is not issued
+		removeUploads(uploads)
+		h.respondError(c, "Failed to issue notice", err)
+		return
+	}
+
+	utils.SuccessCreated(c, "Notice issued successfully", enforcement)
+}
+
+// Respond records the holder's response to a notice. The response comes in the "response" form
+// field and supporting files in "attachments".
+func (h *LicenseEnforcementHandler) Respond(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid enforcement case ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	files, uploads, ok := h.saveUploads(c, models.EnforcementResponseAttachmentEntityType)
+	if !ok {
+		return
+	}
+
+	enforcement, err := h.enforcementService.Respond(dto.RespondEnforcementRequest{
+		EnforcementID: uint(id),
+		UserID:        userID.(uint),
+		Response:      c.PostForm("response"),
+		Files:         files,
+	})
+	if err != nil {
+		removeUploads(uploads)
+		h.respondError(c, "Failed to respond to notice", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Response recorded successfully", enforcement)
+}
+
+// Decide suspends or revokes the license of a case, or dismisses the case
+func (h *LicenseEnforcementHandler) Decide(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid enforcement case ID", err)
+		return
+	}
+
+	var req dto.DecideEnforcementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	req.EnforcementID = uint(id)
+	req.UserID = userID.(uint)
+
+	enforcement, err := h.enforcementService.Decide(req)
+	if err != nil {
+		h.respondError(c, "Failed to decide enforcement case", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Enforcement case decided successfully", enforcement)
+}
+
+// Reinstate lifts the suspension of a license
+func (h *LicenseEnforcementHandler) Reinstate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid enforcement case ID", err)
+		return
+	}
+
+	var req dto.ReinstateLicenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	req.EnforcementID = uint(id)
+	req.UserID = userID.(uint)
+
+	enforcement, err := h.enforcementService.Reinstate(req)
+	if err != nil {
+		h.respondError(c, "Failed to reinstate license", err)
+		return
+	}
+
+	utils.SuccessOK(c, "License reinstated successfully", enforcement)
+}
+
+// GetEnforcement returns a case with its files and history
+func (h *LicenseEnforcementHandler) GetEnforcement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid enforcement case ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	enforcement, err := h.enforcementService.GetEnforcement(uint(id), userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		h.respondError(c, "Failed to get enforcement case", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Enforcement case retrieved successfully", enforcement)
+}
+
+// GetEnforcements lists cases, e.g. ?open=true for the cases waiting for the holder or a decision
+func (h *LicenseEnforcementHandler) GetEnforcements(c *gin.Context) {
+	var filter dto.EnforcementFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	enforcements, err := h.enforcementService.GetEnforcements(filter, userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get enforcement cases", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Enforcement cases retrieved successfully", enforcements)
+}
+
+// RunEnforcementChecks marks overdue notices and ends suspensions now instead of waiting for the next scheduled run
+func (h *LicenseEnforcementHandler) RunEnforcementChecks(c *gin.Context) {
+	result, err := h.enforcementService.RunEnforcementChecks()
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to run enforcement checks", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Enforcement checks completed successfully", result)
+}
+
+// saveUploads saves the files in the "attachments" form field. It writes the error response and
+// returns false when a file is rejected or cannot be saved.
+func (h *LicenseEnforcementHandler) saveUploads(c *gin.Context, entityType string) ([]dto.EnforcementFile, []*utils.FileUpload, bool) {
+	var uploads []*utils.FileUpload
+	form, err := c.MultipartForm()
+	if err != nil {
+		if errors.Is(err, http.ErrNotMultipart) {
+			return nil, nil, true
+		}
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return nil, nil, false
+	}
+
+	for _, file := range form.File["attachments"] {
+		fileType := utils.DetermineFileType(file.Filename, file.Header.Get("Content-Type"))
+		if !utils.IsValidFileSize(file.Size, maxEnforcementFileSizeMB) || !utils.IsValidFileType(fileType, enforcementFileTypes) {
+			removeUploads(uploads)
+			utils.ErrorBadRequest(c, "Invalid attachment", fmt.Errorf("%s must be a PDF, document, spreadsheet or image of at most %d MB", file.Filename, maxEnforcementFileSizeMB))
+			return nil, nil, false
+		}
+
+		upload, err := utils.UploadFile(file, filepath.Join(h.uploadPath, entityType))
+		if err != nil {
+			removeUploads(uploads)
+			utils.ErrorInternalServerError(c, "Failed to save attachment", err)
+			return nil, nil, false
+		}
+		uploads = append(uploads, upload)
+	}
+
+	files := make([]dto.EnforcementFile, 0, len(uploads))
+	for _, upload := range uploads {
+		files = append(files, dto.EnforcementFile{
+			FileName:     upload.FileName,
+			OriginalName: upload.OriginalName,
+			FilePath:     upload.FilePath,
+			FileSize:     upload.FileSize,
+			MimeType:     upload.MimeType,
+			FileType:     models.AttachmentType(upload.FileType),
+		})
+	}
+	return files, uploads, true
+}
+
+func (h *LicenseEnforcementHandler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrEnforcementNotFound):
+		utils.ErrorNotFound(c, "Enforcement case not found", err)
+	case errors.Is(err, service.ErrLicenseNotFound):
+		utils.ErrorNotFound(c, "License not found", err)
+	case errors.Is(err, service.ErrInvalidTransition):
+		utils.ErrorUnprocessableEntity(c, message, err)
+	default:
+		utils.ErrorInternalServerError(c, message, err)
+	}
+}
+
+// SetLicenseEnforcementRoutes sets up routes for suspending and revoking issued licenses
+func SetLicenseEnforcementRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create license enforcement handler
+	enforcementHandler := NewLicenseEnforcementHandler(db, cfg)
+
+	// License enforcement routes (protected); holders only see cases against their licenses
+	enforcements := r.Group("/enforcements")
+	enforcements.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		enforcements.GET("", enforcementHandler.GetEnforcements)
+		enforcements.GET("/:id", enforcementHandler.GetEnforcement)
+
+		// Holders respond to notices
+		enforcements.POST("/:id/response", middleware.RequireRole([]string{"user"}), enforcementHandler.Respond)
+
+		// DEDE officers issue notices; Heads decide cases and reinstate suspended licenses
+		enforcements.POST("",
+			middleware.RequireRole([]string{"admin", "dede_head", "dede_staff"}),
+			enforcementHandler.IssueNotice)
+		enforcements.POST("/:id/decision",
+			middleware.RequireRole([]string{"admin", "dede_head"}),
+			enforcementHandler.Decide)
+		enforcements.POST("/:id/reinstate",
+			middleware.RequireRole([]string{"admin", "dede_head"}),
+			enforcementHandler.Reinstate)
+		enforcements.POST("/run", middleware.RequireRole([]string{"admin"}), enforcementHandler.RunEnforcementChecks)
+	}
+}
@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LicenseRegistryHandler struct {
+	registryService    service.LicenseRegistryService
+	certificateService service.LicenseCertificateService
+	expiryService      service.LicenseExpiryService
+}
+
+func NewLicenseRegistryHandler(db *gorm.DB, cfg *config.Config) *LicenseRegistryHandler {
+	return &LicenseRegistryHandler{
+		registryService:    service.NewLicenseRegistryService(db),
+		certificateService: service.NewLicenseCertificateService(db, cfg),
+		expiryService:      service.NewLicenseExpiryService(db, cfg),
+	}
+}
+
+// GetLicenses returns the issued licenses, e.g. ?status=active&search=LIC-2026
+func (h *LicenseRegistryHandler) GetLicenses(c *gin.Context) {
+	var filter dto.LicenseFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	licenses, err := h.registryService.GetLicenses(filter, userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get licenses", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Licenses retrieved successfully", licenses)
+}
+
+// GetLicense returns an issued license with the requests that refer to it
+func (h *LicenseRegistryHandler) GetLicense(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid license ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	license, err := h.registryService.GetLicense(uint(id), userID.(uint), userRole.(models.UserRole))
+	h.respondLicense(c, license, err)
+}
+
+// LookupLicense returns an issued license by its number, e.g. ?number=LIC-2026-000123
+func (h *LicenseRegistryHandler) LookupLicense(c *gin.Context) {
+	number := c.Query("number")
+	if number == "" {
+		utils.ErrorBadRequest(c, "License number is required", nil)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	license, err := h.registryService.GetLicenseByNumber(number, userID.(uint), userRole.(models.UserRole))
+	h.respondLicense(c, license, err)
+}
+
+// RegisterLicense records a license issued before the registry existed
+func (h *LicenseRegistryHandler) RegisterLicense(c *gin.Context) {
+	var req dto.RegisterLicenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	license, err := h.registryService.RegisterLicense(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDuplicateLicense):
+			utils.ErrorConflict(c, "License number already registered", err)
+		case errors.Is(err, service.ErrInvalidLicenseHolder):
+			utils.ErrorBadRequest(c, err.Error(), nil)
+		default:
+			utils.ErrorInternalServerError(c, "Failed to register license", err)
+		}
+		return
+	}
+
+	utils.SuccessCreated(c, "License registered successfully", license)
+}
+
+// DownloadCertificate sends the current certificate PDF of a license the caller may see
+func (h *LicenseRegistryHandler) DownloadCertificate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid license ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	if _, err := h.registryService.GetLicense(uint(id), userID.(uint), userRole.(models.UserRole)); err != nil {
+		h.respondLicense(c, nil, err)
+		return
+	}
+
+	certificate, err := h.certificateService.GetCertificate(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrCertificateNotFound) {
+			utils.ErrorNotFound(c, "Certificate not generated yet", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get certificate", err)
+		return
+	}
+
+	c.FileAttachment(certificate.FilePath, certificate.OriginalName)
+}
+
+// RegenerateCertificate renders the certificate of a license again, e.g. after a template change
+func (h *LicenseRegistryHandler) RegenerateCertificate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid license ID", err)
+		return
+	}
+
+	certificate, err := h.certificateService.GenerateCertificate(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLicenseNotFound):
+			utils.ErrorNotFound(c, "License not found", err)
+		case errors.Is(err, service.ErrNoCertificateRequest):
+			utils.ErrorConflict(c, err.Error(), nil)
+		default:
+			utils.ErrorInternalServerError(c, "Failed to generate certificate", err)
+		}
+		return
+	}
+
+	utils.SuccessCreated(c, "Certificate generated successfully", certificate)
+}
+
+// GetCapacityHistory returns the capacity ledger of a license, e.g. ?at=2026-01-31 for the capacity licensed then
+func (h *LicenseRegistryHandler) GetCapacityHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid license ID", err)
+		return
+	}
+
+	var at *time.Time
+	if value := c.Query("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			date, dateErr := utils.ParseDate(value)
+			if dateErr != nil {
+				utils.ErrorBadRequest(c, "Invalid at, expected YYYY-MM-DD or RFC3339", err)
+				return
+			}
+			// A date covers the whole day
+			parsed = date.Add(24*time.Hour - time.Nanosecond)
+		}
+		at = &parsed
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	history, err := h.registryService.GetCapacityHistory(uint(id), userID.(uint), userRole.(models.UserRole), at)
+	if err != nil {
+		if errors.Is(err, service.ErrLicenseNotFound) {
+			utils.ErrorNotFound(c, "License not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get capacity history", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Capacity history retrieved successfully", history)
+}
+
+// GetExpiryReminders returns the expiry reminders sent for a license the caller may see
+func (h *LicenseRegistryHandler) GetExpiryReminders(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid license ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	if _, err := h.registryService.GetLicense(uint(id), userID.(uint), userRole.(models.UserRole)); err != nil {
+		h.respondLicense(c, nil, err)
+		return
+	}
+
+	reminders, err := h.expiryService.GetLicenseReminders(uint(id))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get expiry reminders", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Expiry reminders retrieved successfully", reminders)
+}
+
+// RunExpiryChecks manually sends the due expiry reminders and expires licenses past their validity
+func (h *LicenseRegistryHandler) RunExpiryChecks(c *gin.Context) {
+	result, err := h.expiryService.RunExpiryChecks()
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to check license expiry", err)
+		return
+	}
+
+	utils.SuccessOK(c, "License expiry checked successfully", result)
+}
+
+func (h *LicenseRegistryHandler) respondLicense(c *gin.Context, license *dto.LicenseResponse, err error) {
+	if err != nil {
+		if errors.Is(err, service.ErrLicenseNotFound) {
+			utils.ErrorNotFound(c, "License not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get license", err)
+		return
+	}
+
+	utils.SuccessOK(c, "License retrieved successfully", license)
+}
+
+// SetLicenseRegistryRoutes sets up routes for the issued license registry
+func SetLicenseRegistryRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create license registry handler
+	registryHandler := NewLicenseRegistryHandler(db, cfg)
+
+	// License registry routes (protected); applicants only see the licenses they hold
+	licenses := r.Group("/issued-licenses")
+	licenses.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		licenses.GET("", registryHandler.GetLicenses)
+		licenses.GET("/lookup", registryHandler.LookupLicense)
+		licenses.GET("/:id", registryHandler.GetLicense)
+		licenses.GET("/:id/certificate", registryHandler.DownloadCertificate)
+		licenses.GET("/:id/reminders", registryHandler.GetExpiryReminders)
+		licenses.GET("/:id/capacity", registryHandler.GetCapacityHistory)
+
+		// Admins register licenses issued outside the system, regenerate certificates and run the expiry checks
+		licenses.POST("", middleware.RequireRole([]string{"admin"}), registryHandler.RegisterLicense)
+		licenses.POST("/:id/certificate", middleware.RequireRole([]string{"admin"}), registryHandler.RegenerateCertificate)
+		licenses.POST("/expiry/run", middleware.RequireRole([]string{"admin"}), registryHandler.RunExpiryChecks)
+	}
+}
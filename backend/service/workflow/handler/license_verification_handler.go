@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Each client may look up this many licenses per window; anything more looks like enumeration
+const (
+	verifyRateLimitRequests = 30
+	verifyRateLimitWindow   = 60 // seconds
+)
+
+type LicenseVerificationHandler struct {
+	verificationService service.LicenseVerificationService
+}
+
+func NewLicenseVerificationHandler(db *gorm.DB, cfg *config.Config) (*LicenseVerificationHandler, error) {
+	verificationService, err := service.NewLicenseVerificationService(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &LicenseVerificationHandler{
+		verificationService: verificationService,
+	}, nil
+}
+
+// VerifyByToken returns the signed statement of the license behind a certificate QR code
+func (h *LicenseVerificationHandler) VerifyByToken(c *gin.Context) {
+	verification, err := h.verificationService.VerifyByToken(c.Param("token"))
+	h.respondVerification(c, verification, err)
+}
+
+// VerifyByNumber returns the signed statement of a license by its number, e.g. ?number=LIC-2026-000123
+func (h *LicenseVerificationHandler) VerifyByNumber(c *gin.Context) {
+	number := c.Query("number")
+	if number == "" {
+		utils.ErrorBadRequest(c, "License number is required", nil)
+		return
+	}
+
+	verification, err := h.verificationService.VerifyByNumber(number)
+	h.respondVerification(c, verification, err)
+}
+
+// GetVerificationKey returns the public key that checks verification signatures
+func (h *LicenseVerificationHandler) GetVerificationKey(c *gin.Context) {
+	utils.SuccessOK(c, "Verification key retrieved successfully", h.verificationService.GetVerificationKey())
+}
+
+func (h *LicenseVerificationHandler) respondVerification(c *gin.Context, verification *dto.SignedLicenseVerification, err error) {
+	if err != nil {
+		if errors.Is(err, service.ErrLicenseNotFound) {
+			utils.ErrorNotFound(c, "License not found", nil)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to verify license", err)
+		return
+	}
+
+	utils.SuccessOK(c, "License verified successfully", verification)
+}
+
+// SetLicenseVerificationRoutes sets up the public license verification routes. They need no
+// authentication, so they must be registered outside the protected group. Without a valid
+// LICENSE_SIGNING_KEY the routes are not registered at all.
+func SetLicenseVerificationRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create license verification handler
+	verificationHandler, err := NewLicenseVerificationHandler(db, cfg)
+	if err != nil {
+		log.Printf("License verification routes are disabled: %v", err)
+		return
+	}
+
+	// License verification routes (public, rate limited per client)
+	verify := r.Group("/verify")
+	verify.Use(middleware.RateLimitMiddleware(verifyRateLimitRequests, verifyRateLimitWindow))
+	{
+		verify.GET("/licenses", verificationHandler.VerifyByNumber)
+		verify.GET("/licenses/:token", verificationHandler.VerifyByToken)
+		verify.GET("/key", verificationHandler.GetVerificationKey)
+	}
+}
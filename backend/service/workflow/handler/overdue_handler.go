@@ -18,7 +18,7 @@ type OverdueHandler struct {
 }
 
 func NewOverdueHandler(db *gorm.DB, cfg *config.Config) *OverdueHandler {
-	overdueService := service.NewOverdueService(db)
+	overdueService := service.NewOverdueService(db, cfg)
 	overdueCron := cron.NewOverdueCronJob(overdueService)
 
 	return &OverdueHandler{
@@ -40,7 +40,6 @@ func (h *OverdueHandler) CheckOverdueRequests(c *gin.Context) {
 // ProcessOverdueRequest processes a specific overdue request
 func (h *OverdueHandler) ProcessOverdueRequest(c *gin.Context) {
 	idStr := c.Param("id")
-	licenseType := c.Query("type")
 
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -48,13 +47,8 @@ func (h *OverdueHandler) ProcessOverdueRequest(c *gin.Context) {
 		return
 	}
 
-	if licenseType == "" {
-		utils.ErrorBadRequest(c, "License type is required", nil)
-		return
-	}
-
-	if err := h.overdueService.ProcessOverdueRequest(uint(id), licenseType); err != nil {
-		utils.ErrorInternalServerError(c, "Failed to process overdue request", err)
+	if err := h.overdueService.ProcessOverdueRequest(uint(id)); err != nil {
+		RespondTransitionError(c, "Failed to process overdue request", err)
 		return
 	}
 
@@ -85,7 +79,6 @@ func (h *OverdueHandler) GetOverdueStatistics(c *gin.Context) {
 // TestOverdueRequest tests the overdue functionality for a specific request
 func (h *OverdueHandler) TestOverdueRequest(c *gin.Context) {
 	idStr := c.Param("id")
-	licenseType := c.Query("type")
 
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -93,13 +86,8 @@ func (h *OverdueHandler) TestOverdueRequest(c *gin.Context) {
 		return
 	}
 
-	if licenseType == "" {
-		utils.ErrorBadRequest(c, "License type is required", nil)
-		return
-	}
-
-	if err := h.overdueCron.RunTest(uint(id), licenseType); err != nil {
-		utils.ErrorInternalServerError(c, "Failed to test overdue request", err)
+	if err := h.overdueCron.RunTest(uint(id)); err != nil {
+		RespondTransitionError(c, "Failed to test overdue request", err)
 		return
 	}
 
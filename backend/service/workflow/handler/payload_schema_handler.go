@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PayloadSchemaHandler struct {
+	schemaService service.PayloadSchemaService
+}
+
+func NewPayloadSchemaHandler(db *gorm.DB, cfg *config.Config) *PayloadSchemaHandler {
+	return &PayloadSchemaHandler{
+		schemaService: service.NewPayloadSchemaService(db),
+	}
+}
+
+// GetSchemas lists published schema versions, e.g. ?license_type=new&energy_type=solar&active=true
+func (h *PayloadSchemaHandler) GetSchemas(c *gin.Context) {
+	var filter dto.PayloadSchemaFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	filter.LicenseType = models.ParseLicenseType(string(filter.LicenseType))
+
+	schemas, err := h.schemaService.GetSchemas(filter)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get payload schemas", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Payload schemas retrieved successfully", schemas)
+}
+
+// GetActiveSchema returns the schema a form renders from, e.g. ?license_type=new&energy_type=wind
+func (h *PayloadSchemaHandler) GetActiveSchema(c *gin.Context) {
+	licenseType := c.Query("license_type")
+	if licenseType == "" {
+		utils.ErrorBadRequest(c, "license_type is required", nil)
+		return
+	}
+
+	schema, err := h.schemaService.GetActiveSchema(models.ParseLicenseType(licenseType), c.Query("energy_type"))
+	if err != nil {
+		h.respondError(c, "Failed to get active payload schema", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Active payload schema retrieved successfully", schema)
+}
+
+// GetSchema returns a schema version by ID
+func (h *PayloadSchemaHandler) GetSchema(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid payload schema ID", err)
+		return
+	}
+
+	schema, err := h.schemaService.GetSchema(uint(id))
+	if err != nil {
+		h.respondError(c, "Failed to get payload schema", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Payload schema retrieved successfully", schema)
+}
+
+// PublishSchema publishes the next schema version for a license type and energy type
+func (h *PayloadSchemaHandler) PublishSchema(c *gin.Context) {
+	var req dto.PublishPayloadSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	req.UserID = userID.(uint)
+
+	schema, err := h.schemaService.PublishSchema(req)
+	if err != nil {
+		h.respondError(c, "Failed to publish payload schema", err)
+		return
+	}
+
+	utils.SuccessCreated(c, "Payload schema published successfully", schema)
+}
+
+// ActivateSchema makes a schema version the active one for its license type and energy type
+func (h *PayloadSchemaHandler) ActivateSchema(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid payload schema ID", err)
+		return
+	}
+
+	schema, err := h.schemaService.ActivateSchema(uint(id))
+	if err != nil {
+		h.respondError(c, "Failed to activate payload schema", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Payload schema activated successfully", schema)
+}
+
+// GetTechnicalData returns the technical data of a request with the schema its form renders from
+func (h *PayloadSchemaHandler) GetTechnicalData(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	data, err := h.schemaService.GetTechnicalData(uint(id), userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		h.respondError(c, "Failed to get technical data", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Technical data retrieved successfully", data)
+}
+
+// SaveTechnicalData saves the technical data of the applicant's request once it matches the schema
+func (h *PayloadSchemaHandler) SaveTechnicalData(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	var req dto.SaveTechnicalDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	req.RequestID = uint(id)
+	req.UserID = userID.(uint)
+
+	data, err := h.schemaService.SaveTechnicalData(req)
+	if err != nil {
+		h.respondError(c, "Failed to save technical data", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Technical data saved successfully", data)
+}
+
+func (h *PayloadSchemaHandler) respondError(c *gin.Context, message string, err error) {
+	var invalid *service.PayloadValidationError
+
+	switch {
+	case errors.As(err, &invalid):
+		// List every field that does not match so the form can mark each of them
+		utils.ErrorUnprocessableEntityWithData(c, message, err, invalid)
+	case errors.Is(err, service.ErrPayloadSchemaNotFound):
+		utils.ErrorNotFound(c, "Payload schema not found", err)
+	case errors.Is(err, service.ErrRequestNotFound):
+		utils.ErrorNotFound(c, "Request not found", err)
+	case errors.Is(err, service.ErrInvalidTransition):
+		utils.ErrorUnprocessableEntity(c, message, err)
+	default:
+		utils.ErrorInternalServerError(c, message, err)
+	}
+}
+
+// SetPayloadSchemaRoutes sets up routes for the technical data schemas of license requests
+func SetPayloadSchemaRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create payload schema handler
+	schemaHandler := NewPayloadSchemaHandler(db, cfg)
+
+	// Schema registry routes (protected); anyone signed in can read schemas, admins publish them
+	schemas := r.Group("/payload-schemas")
+	schemas.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		schemas.GET("", schemaHandler.GetSchemas)
+		schemas.GET("/active", schemaHandler.GetActiveSchema)
+		schemas.GET("/:id", schemaHandler.GetSchema)
+		schemas.POST("", middleware.RequireRole([]string{"admin"}), schemaHandler.PublishSchema)
+		schemas.POST("/:id/activate", middleware.RequireRole([]string{"admin"}), schemaHandler.ActivateSchema)
+	}
+
+	// Technical data routes (protected); applicants only see and edit their own requests
+	technical := r.Group("/technical-data")
+	technical.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		technical.GET("/requests/:id", schemaHandler.GetTechnicalData)
+		technical.PUT("/requests/:id", middleware.RequireRole([]string{"user"}), schemaHandler.SaveTechnicalData)
+	}
+}
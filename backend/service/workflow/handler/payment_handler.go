@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PaymentHandler struct {
+	feeScheduleService service.FeeScheduleService
+	paymentService     service.PaymentService
+	receiptService     service.PaymentReceiptService
+}
+
+func NewPaymentHandler(db *gorm.DB, cfg *config.Config) *PaymentHandler {
+	return &PaymentHandler{
+		feeScheduleService: service.NewFeeScheduleService(db),
+		paymentService:     service.NewPaymentService(db, cfg),
+		receiptService:     service.NewPaymentReceiptService(db, cfg),
+	}
+}
+
+// GetFeeSchedules lists fee schedules, e.g. ?license_type=new&active=true
+func (h *PaymentHandler) GetFeeSchedules(c *gin.Context) {
+	var filter dto.FeeScheduleFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	filter.LicenseType = models.ParseLicenseType(string(filter.LicenseType))
+
+	schedules, err := h.feeScheduleService.GetFeeSchedules(filter)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get fee schedules", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Fee schedules retrieved successfully", schedules)
+}
+
+// QuoteFee returns the fee of a license type at a capacity, e.g. ?license_type=new&capacity=12.5
+func (h *PaymentHandler) QuoteFee(c *gin.Context) {
+	var req dto.FeeQuoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+	req.LicenseType = models.ParseLicenseType(string(req.LicenseType))
+
+	quote, err := h.feeScheduleService.QuoteFee(req)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to quote fee", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Fee quoted successfully", quote)
+}
+
+// CreateFeeSchedule adds the fee of a license type and capacity band
+func (h *PaymentHandler) CreateFeeSchedule(c *gin.Context) {
+	var req dto.CreateFeeScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	req.UserID = userID.(uint)
+
+	schedule, err := h.feeScheduleService.CreateFeeSchedule(req)
+	if err != nil {
+		h.respondError(c, "Failed to create fee schedule", err)
+		return
+	}
+
+	utils.SuccessCreated(c, "Fee schedule created successfully", schedule)
+}
+
+// DeactivateFeeSchedule stops a fee schedule from applying to new invoices
+func (h *PaymentHandler) DeactivateFeeSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid fee schedule ID", err)
+		return
+	}
+
+	schedule, err := h.feeScheduleService.DeactivateFeeSchedule(uint(id))
+	if err != nil {
+		h.respondError(c, "Failed to deactivate fee schedule", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Fee schedule deactivated successfully", schedule)
+}
+
+// GetInvoices lists invoices, e.g. ?request_id=42 or ?status=pending
+func (h *PaymentHandler) GetInvoices(c *gin.Context) {
+	var filter dto.InvoiceFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.ErrorBadRequest(c, "Invalid query parameters", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	invoices, err := h.paymentService.GetInvoices(filter, userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get invoices", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Invoices retrieved successfully", invoices)
+}
+
+// GetInvoice returns an invoice with its payment details and payments
+func (h *PaymentHandler) GetInvoice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid invoice ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	invoice, err := h.paymentService.GetInvoice(uint(id), userID.(uint), userRole.(models.UserRole))
+	if err != nil {
+		h.respondError(c, "Failed to get invoice", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Invoice retrieved successfully", invoice)
+}
+
+// ConfirmPayment records a payment of an invoice. A request waiting for its fee moves on to final approval.
+func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid invoice ID", err)
+		return
+	}
+
+	var req dto.ConfirmPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	req.InvoiceID = uint(id)
+	req.UserID = userID.(uint)
+
+	invoice, err := h.paymentService.ConfirmPayment(req)
+	if err != nil {
+		h.respondError(c, "Failed to confirm payment", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Payment confirmed successfully", invoice)
+}
+
+// DownloadReceipt sends the receipt PDF of an invoice the caller may see
+func (h *PaymentHandler) DownloadReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid invoice ID", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	userRole, _ := c.Get("user_role")
+
+	if _, err := h.paymentService.GetInvoice(uint(id), userID.(uint), userRole.(models.UserRole)); err != nil {
+		h.respondError(c, "Failed to get invoice", err)
+		return
+	}
+
+	receipt, err := h.receiptService.GetReceipt(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrReceiptNotFound) {
+			utils.ErrorNotFound(c, "Receipt not generated yet", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get receipt", err)
+		return
+	}
+
+	c.FileAttachment(receipt.FilePath, receipt.OriginalName)
+}
+
+func (h *PaymentHandler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrFeeScheduleNotFound):
+		utils.ErrorNotFound(c, "Fee schedule not found", err)
+	case errors.Is(err, service.ErrInvoiceNotFound):
+		utils.ErrorNotFound(c, "Invoice not found", err)
+	case errors.Is(err, service.ErrPaymentRejected):
+		utils.ErrorUnprocessableEntity(c, message, err)
+	default:
+		// Confirming a payment may move the request on to final approval
+		RespondTransitionError(c, message, err)
+	}
+}
+
+// SetPaymentRoutes sets up routes for license fees, invoices and payments
+func SetPaymentRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create payment handler
+	paymentHandler := NewPaymentHandler(db, cfg)
+
+	// Fee schedule routes (protected); anyone signed in can look up fees, admins maintain them
+	fees := r.Group("/fees")
+	fees.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		fees.GET("", paymentHandler.GetFeeSchedules)
+		fees.GET("/quote", paymentHandler.QuoteFee)
+		fees.POST("", middleware.RequireRole([]string{"admin"}), paymentHandler.CreateFeeSchedule)
+		fees.POST("/:id/deactivate", middleware.RequireRole([]string{"admin"}), paymentHandler.DeactivateFeeSchedule)
+	}
+
+	// Invoice routes (protected); applicants only see the invoices of their own requests
+	invoices := r.Group("/invoices")
+	invoices.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	{
+		invoices.GET("", paymentHandler.GetInvoices)
+		invoices.GET("/:id", paymentHandler.GetInvoice)
+		invoices.GET("/:id/receipt", paymentHandler.DownloadReceipt)
+
+		// DEDE officers confirm payments from the provider or the bank statement
+		invoices.POST("/:id/payments",
+			middleware.RequireRole([]string{"admin", "dede_head", "dede_staff"}),
+			paymentHandler.ConfirmPayment)
+	}
+}
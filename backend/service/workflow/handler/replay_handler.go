@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReplayHandler struct {
+	replayService service.ReplayService
+}
+
+func NewReplayHandler(db *gorm.DB, cfg *config.Config) *ReplayHandler {
+	return &ReplayHandler{
+		replayService: service.NewReplayService(db),
+	}
+}
+
+// ReplayRequest replays a request's flow log and reports how it differs from the request
+func (h *ReplayHandler) ReplayRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	report, err := h.replayService.ReplayRequest(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrRequestNotFound) {
+			utils.ErrorNotFound(c, "Request not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to replay request", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Request replayed successfully", report)
+}
+
+// GetRequestStateAsOf returns a request as it stood at a point in time, e.g. ?at=2026-10-01
+func (h *ReplayHandler) GetRequestStateAsOf(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	at, err := service.ParseAsOf(c.Query("at"))
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid at parameter", err)
+		return
+	}
+
+	state, err := h.replayService.GetRequestStateAsOf(uint(id), at)
+	if err != nil {
+		if errors.Is(err, service.ErrRequestNotFound) {
+			utils.ErrorNotFound(c, "Request not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to reconstruct request", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Request state retrieved successfully", state)
+}
+
+// CheckConsistency replays the selected requests and optionally repairs status/log mismatches
+func (h *ReplayHandler) CheckConsistency(c *gin.Context) {
+	var req dto.ConsistencyCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+	repairedBy := userID.(uint)
+	req.RepairedBy = &repairedBy
+
+	result, err := h.replayService.CheckConsistency(req)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to check consistency", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Consistency check completed successfully", result)
+}
+
+// SetReplayRoutes sets up routes for replaying request history
+func SetReplayRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create replay handler
+	replayHandler := NewReplayHandler(db, cfg)
+
+	// Replay routes (protected)
+	replay := r.Group("/replay")
+	replay.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	replay.Use(middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}))
+	{
+		replay.GET("/requests/:id/as-of", replayHandler.GetRequestStateAsOf)
+
+		// Consistency checks
+		replay.GET("/requests/:id", middleware.RequireRole([]string{"admin"}), replayHandler.ReplayRequest)
+		replay.POST("/check", middleware.RequireRole([]string{"admin"}), replayHandler.CheckConsistency)
+	}
+}
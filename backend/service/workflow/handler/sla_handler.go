@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SLAHandler struct {
+	slaClockService service.SLAClockService
+}
+
+func NewSLAHandler(db *gorm.DB, cfg *config.Config) *SLAHandler {
+	return &SLAHandler{
+		slaClockService: service.NewSLAClockService(db),
+	}
+}
+
+// GetRequestClock returns the time a request has spent with DEDE and with the applicant
+func (h *SLAHandler) GetRequestClock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	clock, err := h.slaClockService.GetRequestClock(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrSLAClockNotFound) {
+			utils.ErrorNotFound(c, "Request has no SLA clock yet", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get SLA clock", err)
+		return
+	}
+
+	utils.SuccessOK(c, "SLA clock retrieved successfully", clock)
+}
+
+// GetStatistics returns average time with DEDE and with the applicant per license type
+func (h *SLAHandler) GetStatistics(c *gin.Context) {
+	stats, err := h.slaClockService.GetStatistics(string(models.ParseLicenseType(c.Query("license_type"))))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get SLA statistics", err)
+		return
+	}
+
+	utils.SuccessOK(c, "SLA statistics retrieved successfully", stats)
+}
+
+// SetSLARoutes sets up routes for SLA reporting
+func SetSLARoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create SLA handler
+	slaHandler := NewSLAHandler(db, cfg)
+
+	// SLA routes (protected)
+	sla := r.Group("/sla")
+	sla.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	sla.Use(middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}))
+	{
+		sla.GET("/requests/:id", slaHandler.GetRequestClock)
+		sla.GET("/statistics", slaHandler.GetStatistics)
+	}
+}
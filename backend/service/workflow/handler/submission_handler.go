@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SubmissionHandler struct {
+	submissionService service.SubmissionService
+}
+
+func NewSubmissionHandler(db *gorm.DB, cfg *config.Config) *SubmissionHandler {
+	return &SubmissionHandler{
+		submissionService: service.NewSubmissionService(db),
+	}
+}
+
+// GetRequestSubmissions returns every submission snapshot of a request
+func (h *SubmissionHandler) GetRequestSubmissions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	submissions, err := h.submissionService.GetSubmissions(uint(id))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get submissions", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Submissions retrieved successfully", submissions)
+}
+
+// GetSubmissionDiff compares two submissions of a request, by default the latest with the one before it
+func (h *SubmissionHandler) GetSubmissionDiff(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid request ID", err)
+		return
+	}
+
+	previous, err := strconv.Atoi(c.DefaultQuery("from", "0"))
+	if err != nil || previous < 0 {
+		utils.ErrorBadRequest(c, "Invalid from sequence", err)
+		return
+	}
+	current, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil || current < 0 {
+		utils.ErrorBadRequest(c, "Invalid to sequence", err)
+		return
+	}
+
+	diff, err := h.submissionService.GetSubmissionDiff(uint(id), previous, current)
+	if err != nil {
+		if errors.Is(err, service.ErrSubmissionNotFound) {
+			utils.ErrorNotFound(c, "Submission not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to compare submissions", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Submission diff retrieved successfully", diff)
+}
+
+// SetSubmissionRoutes sets up routes for reviewing applicant submissions
+func SetSubmissionRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create submission handler
+	submissionHandler := NewSubmissionHandler(db, cfg)
+
+	// Submission routes (protected)
+	submissions := r.Group("/submissions")
+	submissions.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	submissions.Use(middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}))
+	{
+		submissions.GET("/requests/:id", submissionHandler.GetRequestSubmissions)
+		submissions.GET("/requests/:id/diff", submissionHandler.GetSubmissionDiff)
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RespondTransitionError writes the error response for a failed workflow transition
+func RespondTransitionError(c *gin.Context, message string, err error) {
+	var conflict *service.VersionConflictError
+	var guard *service.GuardError
+
+	switch {
+	case errors.As(err, &conflict):
+		// Give the loser the state it lost to so it can refresh and retry
+		utils.SetVersionETag(c, conflict.Current.Version)
+		utils.ErrorConflictWithData(c, "Request was modified by another user", err, conflict.Current)
+	case errors.As(err, &guard):
+		// List the unmet conditions so the UI can tell the user what is still missing
+		utils.ErrorUnprocessableEntityWithData(c, message, err, guard)
+	case errors.Is(err, service.ErrRequestNotFound):
+		utils.ErrorNotFound(c, "Request not found", err)
+	case errors.Is(err, service.ErrInvalidTransition):
+		utils.ErrorUnprocessableEntity(c, message, err)
+	default:
+		utils.ErrorInternalServerError(c, message, err)
+	}
+}
+
+// ExpectedVersion reads the request version the client acted on from the If-Match header.
+// It writes the error response and returns false if the header is malformed.
+func ExpectedVersion(c *gin.Context) (*int, bool) {
+	version, err := utils.IfMatchVersion(c)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid If-Match header", err)
+		return nil, false
+	}
+	return version, true
+}
+
+// RespondBulkError writes the error response for a bulk action that could not be run at all
+func RespondBulkError(c *gin.Context, message string, err error) {
+	if errors.Is(err, service.ErrDuplicateBulkItem) {
+		utils.ErrorBadRequest(c, message, err)
+		return
+	}
+	utils.ErrorInternalServerError(c, message, err)
+}
+
+// RespondBulkResult writes the per-request outcome of a bulk action: 200 when every request was
+// processed, 207 when only some were, and 422 when nothing was saved
+func RespondBulkResult(c *gin.Context, result *dto.BulkTransitionResult) {
+	switch {
+	case result.Failed == 0:
+		utils.SuccessOK(c, "Bulk action processed successfully", result)
+	case result.Committed:
+		utils.SuccessResponse(c, http.StatusMultiStatus, "Bulk action partially processed", result)
+	default:
+		utils.ErrorUnprocessableEntityWithData(c, "No request was processed", nil, result)
+	}
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/middleware"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/service/workflow/service"
+	"eservice-backend/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkflowDefinitionHandler struct {
+	definitionService service.WorkflowDefinitionService
+	exportService     service.WorkflowExportService
+}
+
+func NewWorkflowDefinitionHandler(db *gorm.DB, cfg *config.Config) *WorkflowDefinitionHandler {
+	return &WorkflowDefinitionHandler{
+		definitionService: service.NewWorkflowDefinitionService(db),
+		exportService:     service.NewWorkflowExportService(db),
+	}
+}
+
+// ListDefinitions returns all published workflow definitions
+func (h *WorkflowDefinitionHandler) ListDefinitions(c *gin.Context) {
+	definitions, err := h.definitionService.ListDefinitions(string(models.ParseLicenseType(c.Query("license_type"))))
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to get workflow definitions", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Workflow definitions retrieved successfully", definitions)
+}
+
+// GetDefinition returns a workflow definition by ID
+func (h *WorkflowDefinitionHandler) GetDefinition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid definition ID", err)
+		return
+	}
+
+	definition, err := h.definitionService.GetDefinition(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorNotFound(c, "Workflow definition not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to get workflow definition", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Workflow definition retrieved successfully", definition)
+}
+
+// GetActiveDefinition returns the definition new requests of a license type are pinned to
+func (h *WorkflowDefinitionHandler) GetActiveDefinition(c *gin.Context) {
+	definition, err := h.definitionService.GetActiveDefinition(c.Param("licenseType"))
+	if err != nil {
+		utils.ErrorBadRequest(c, "Failed to get active workflow definition", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Active workflow definition retrieved successfully", definition)
+}
+
+// CreateDefinition publishes a new workflow definition version
+func (h *WorkflowDefinitionHandler) CreateDefinition(c *gin.Context) {
+	var req dto.CreateWorkflowDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorBadRequest(c, "Invalid request body", err)
+		return
+	}
+
+	// Get current user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorUnauthorized(c, "User not authenticated", nil)
+		return
+	}
+
+	definition, err := h.definitionService.CreateDefinition(req, userID.(uint))
+	if err != nil {
+		utils.ErrorBadRequest(c, "Failed to create workflow definition", err)
+		return
+	}
+
+	utils.SuccessCreated(c, "Workflow definition created successfully", definition)
+}
+
+// ActivateDefinition makes a workflow definition the active version for its license type
+func (h *WorkflowDefinitionHandler) ActivateDefinition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorBadRequest(c, "Invalid definition ID", err)
+		return
+	}
+
+	definition, err := h.definitionService.ActivateDefinition(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorNotFound(c, "Workflow definition not found", err)
+			return
+		}
+		utils.ErrorInternalServerError(c, "Failed to activate workflow definition", err)
+		return
+	}
+
+	utils.SuccessOK(c, "Workflow definition activated successfully", definition)
+}
+
+// ExportDiagram renders a workflow definition as a JSON graph, Graphviz DOT or Mermaid.
+// With request_id the request's pinned definition is used and its path is highlighted.
+func (h *WorkflowDefinitionHandler) ExportDiagram(c *gin.Context) {
+	format := c.DefaultQuery("format", service.WorkflowExportJSON)
+	contentType, ok := workflowExportContentTypes[format]
+	if !ok {
+		utils.ErrorBadRequest(c, "Unsupported export format, use json, dot or mermaid", nil)
+		return
+	}
+
+	var diagram *dto.WorkflowDiagramResponse
+	var err error
+	if requestID := c.Query("request_id"); requestID != "" {
+		id, parseErr := strconv.ParseUint(requestID, 10, 32)
+		if parseErr != nil {
+			utils.ErrorBadRequest(c, "Invalid request ID", parseErr)
+			return
+		}
+		diagram, err = h.exportService.GetRequestDiagram(uint(id))
+	} else {
+		var version *int
+		if v := c.Query("version"); v != "" {
+			parsed, parseErr := strconv.Atoi(v)
+			if parseErr != nil {
+				utils.ErrorBadRequest(c, "Invalid definition version", parseErr)
+				return
+			}
+			version = &parsed
+		}
+		diagram, err = h.exportService.GetDiagram(string(models.ParseLicenseType(c.Query("license_type"))), version)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrRequestNotFound) {
+			utils.ErrorNotFound(c, "Request not found", err)
+			return
+		}
+		utils.ErrorBadRequest(c, "Failed to export workflow diagram", err)
+		return
+	}
+
+	if format == service.WorkflowExportJSON {
+		utils.SuccessOK(c, "Workflow diagram exported successfully", diagram)
+		return
+	}
+
+	rendered, err := service.RenderWorkflowDiagram(diagram, format)
+	if err != nil {
+		utils.ErrorInternalServerError(c, "Failed to render workflow diagram", err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, []byte(rendered))
+}
+
+// workflowExportContentTypes maps export formats to the content type they are served with
+var workflowExportContentTypes = map[string]string{
+	service.WorkflowExportJSON:    "application/json; charset=utf-8",
+	service.WorkflowExportDOT:     "text/vnd.graphviz; charset=utf-8",
+	service.WorkflowExportMermaid: "text/plain; charset=utf-8",
+}
+
+// SetWorkflowDefinitionRoutes sets up routes for workflow definition management
+func SetWorkflowDefinitionRoutes(r *gin.RouterGroup, db *gorm.DB, cfg *config.Config) {
+	// Create workflow definition handler
+	definitionHandler := NewWorkflowDefinitionHandler(db, cfg)
+
+	// Workflow definition routes (protected)
+	definitions := r.Group("/workflow-definitions")
+	definitions.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	definitions.Use(middleware.RequireRole([]string{"admin", "dede_head", "dede_staff", "dede_consult"}))
+	{
+		definitions.GET("", definitionHandler.ListDefinitions)
+		definitions.GET("/active/:licenseType", definitionHandler.GetActiveDefinition)
+		definitions.GET("/export", definitionHandler.ExportDiagram)
+		definitions.GET("/:id", definitionHandler.GetDefinition)
+
+		// Publishing and activation are restricted to admins
+		definitions.POST("", middleware.RequireRole([]string{"admin"}), definitionHandler.CreateDefinition)
+		definitions.POST("/:id/activate", middleware.RequireRole([]string{"admin"}), definitionHandler.ActivateDefinition)
+	}
+}
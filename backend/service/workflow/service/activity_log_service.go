@@ -99,7 +99,7 @@ func (s *activityLogService) GetActivityLogs(req dto.GetActivityLogsRequest) ([]
 	// Get logs with pagination
 	var logs []models.ServiceFlowLog
 	offset := (req.Page - 1) * req.Limit
-	err = query.Preload("ChangedByUser").Order("created_at DESC").Offset(offset).Limit(req.Limit).Find(&logs).Error
+	err = query.Preload("ChangedByUser").Preload("OnBehalfOfUser").Order("created_at DESC").Offset(offset).Limit(req.Limit).Find(&logs).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get activity logs: %w", err)
 	}
@@ -110,7 +110,7 @@ func (s *activityLogService) GetActivityLogs(req dto.GetActivityLogsRequest) ([]
 // GetActivityLogByID retrieves a specific activity log
 func (s *activityLogService) GetActivityLogByID(logID uint) (*models.ServiceFlowLog, error) {
 	var log models.ServiceFlowLog
-	err := s.db.Where("id = ?", logID).Preload("ChangedByUser").First(&log).Error
+	err := s.db.Where("id = ?", logID).Preload("ChangedByUser").Preload("OnBehalfOfUser").First(&log).Error
 	if err != nil {
 		return nil, fmt.Errorf("activity log not found: %w", err)
 	}
@@ -121,7 +121,7 @@ func (s *activityLogService) GetActivityLogByID(logID uint) (*models.ServiceFlow
 func (s *activityLogService) GetActivityLogsByEntity(entityType string, entityID uint) ([]models.ServiceFlowLog, error) {
 	var logs []models.ServiceFlowLog
 	err := s.db.Where("license_type = ? AND license_request_id = ?", entityType, entityID).
-		Preload("ChangedByUser").
+		Preload("ChangedByUser").Preload("OnBehalfOfUser").
 		Order("created_at DESC").
 		Find(&logs).Error
 	if err != nil {
@@ -134,7 +134,7 @@ func (s *activityLogService) GetActivityLogsByEntity(entityType string, entityID
 func (s *activityLogService) GetActivityLogsByUser(userID uint) ([]models.ServiceFlowLog, error) {
 	var logs []models.ServiceFlowLog
 	err := s.db.Where("changed_by = ?", userID).
-		Preload("ChangedByUser").
+		Preload("ChangedByUser").Preload("OnBehalfOfUser").
 		Order("created_at DESC").
 		Find(&logs).Error
 	if err != nil {
@@ -147,7 +147,7 @@ func (s *activityLogService) GetActivityLogsByUser(userID uint) ([]models.Servic
 func (s *activityLogService) GetActivityLogsByDateRange(startDate, endDate time.Time) ([]models.ServiceFlowLog, error) {
 	var logs []models.ServiceFlowLog
 	err := s.db.Where("created_at BETWEEN ? AND ?", startDate, endDate).
-		Preload("ChangedByUser").
+		Preload("ChangedByUser").Preload("OnBehalfOfUser").
 		Order("created_at DESC").
 		Find(&logs).Error
 	if err != nil {
@@ -166,16 +166,20 @@ func (s *activityLogService) GetActivityStatistics() (map[string]interface{}, er
 	stats["total_activities"] = totalActivities
 
 	// Count activities by entity type
-	var newLicenseCount, renewalLicenseCount, extensionLicenseCount, reductionLicenseCount int64
+	var newLicenseCount, renewalLicenseCount, extensionLicenseCount, reductionLicenseCount, modifyLicenseCount, cancelLicenseCount int64
 	s.db.Model(&models.ServiceFlowLog{}).Where("license_type = ?", "new").Count(&newLicenseCount)
 	s.db.Model(&models.ServiceFlowLog{}).Where("license_type = ?", "renewal").Count(&renewalLicenseCount)
 	s.db.Model(&models.ServiceFlowLog{}).Where("license_type = ?", "extension").Count(&extensionLicenseCount)
 	s.db.Model(&models.ServiceFlowLog{}).Where("license_type = ?", "reduction").Count(&reductionLicenseCount)
+	s.db.Model(&models.ServiceFlowLog{}).Where("license_type = ?", "modify").Count(&modifyLicenseCount)
+	s.db.Model(&models.ServiceFlowLog{}).Where("license_type = ?", "cancel").Count(&cancelLicenseCount)
 
 	stats["new_license_activities"] = newLicenseCount
 	stats["renewal_license_activities"] = renewalLicenseCount
 	stats["extension_license_activities"] = extensionLicenseCount
 	stats["reduction_license_activities"] = reductionLicenseCount
+	stats["modify_license_activities"] = modifyLicenseCount
+	stats["cancel_license_activities"] = cancelLicenseCount
 
 	// Count activities by status
 	var statusCounts []struct {
@@ -249,7 +253,7 @@ func (s *activityLogService) ExportActivityLogs(req dto.ExportActivityLogsReques
 			log.LicenseRequestID,
 			statusToStringPtr(log.PreviousStatus),
 			log.NewStatus,
-			userToString(log.ChangedByUser),
+			actorToString(&log),
 			log.ChangeReason,
 			log.CreatedAt.Format("2006-01-02 15:04:05"),
 		)
@@ -271,6 +275,15 @@ func statusToStringPtr(status *models.RequestStatus) string {
 	return string(*status)
 }
 
+// actorToString names who made a change, including the delegator when acting on their behalf
+func actorToString(log *models.ServiceFlowLog) string {
+	actor := userToString(log.ChangedByUser)
+	if log.OnBehalfOfUser != nil {
+		actor += " on behalf of " + log.OnBehalfOfUser.FullName
+	}
+	return actor
+}
+
 func userToString(user *models.User) string {
 	if user == nil {
 		return "System"
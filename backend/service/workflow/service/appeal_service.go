@@ -0,0 +1,524 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Appeal decisions a DEDE Head can take
+const (
+	AppealDecisionGrant   = "grant"
+	AppealDecisionDismiss = "dismiss"
+)
+
+// ErrAppealNotFound is returned when an appeal does not exist or is not visible to the caller
+var ErrAppealNotFound = errors.New("appeal not found")
+
+type AppealService interface {
+	FileAppeal(req dto.FileAppealRequest) (*dto.AppealResponse, error)
+	DecideAppeal(req dto.DecideAppealRequest) (*dto.AppealResponse, error)
+	GetAppeal(appealID, userID uint, role models.UserRole) (*dto.AppealResponse, error)
+	GetRequestAppeals(requestID, userID uint, role models.UserRole) ([]dto.AppealResponse, error)
+	GetAppeals(filter dto.AppealFilter, userID uint) ([]dto.AppealResponse, error)
+	CloseExpiredAppealWindows() (int, error)
+}
+
+type appealService struct {
+	db                *gorm.DB
+	transitionService WorkflowTransitionService
+	definitionService WorkflowDefinitionService
+}
+
+func NewAppealService(db *gorm.DB, cfg *config.Config) AppealService {
+	return &appealService{
+		db:                db,
+		transitionService: NewWorkflowTransitionService(db, cfg),
+		definitionService: NewWorkflowDefinitionService(db),
+	}
+}
+
+// FileAppeal moves a rejected request into appeal and routes the appeal to a DEDE Head who did
+// not take part in the rejection. The appeal window and grounds are checked by the transition's guards.
+func (s *appealService) FileAppeal(req dto.FileAppealRequest) (*dto.AppealResponse, error) {
+	var appeal *models.LicenseAppeal
+	result, err := s.transitionService.ProcessTransition(dto.WorkflowTransitionRequest{
+		RequestID:       req.RequestID,
+		ExpectedVersion: req.ExpectedVersion,
+		ToStatus:        models.StatusAppealed,
+		Comments:        req.Grounds,
+		UserID:          req.UserID,
+		UserRole:        req.UserRole,
+	}, RequireRequestOwner(req.UserID), func(tc *TransitionContext) error {
+		var err error
+		appeal, err = fileAppeal(tc, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.GetAppeal(appeal.ID, req.UserID, req.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	response.Transition = result
+	return response, nil
+}
+
+// DecideAppeal grants or dismisses a pending appeal. A granted appeal reopens the request at the
+// status it was rejected from, or at new_request when the workflow does not allow going back there.
+func (s *appealService) DecideAppeal(req dto.DecideAppealRequest) (*dto.AppealResponse, error) {
+	appeal, err := s.getAppeal(s.db, req.AppealID)
+	if err != nil {
+		return nil, err
+	}
+	if !appeal.IsPending() {
+		return nil, fmt.Errorf("%w: appeal %d is already %s", ErrInvalidTransition, appeal.ID, appeal.Status)
+	}
+
+	toStatus := models.StatusRejectedFinal
+	if req.Decision == AppealDecisionGrant {
+		toStatus = req.ToStatus
+		if toStatus == "" {
+			if toStatus, err = s.grantStatus(appeal, req.UserRole); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result, err := s.transitionService.ProcessTransition(dto.WorkflowTransitionRequest{
+		RequestID:       appeal.RequestID,
+		ExpectedVersion: req.ExpectedVersion,
+		FromStatus:      models.StatusAppealed,
+		ToStatus:        toStatus,
+		Comments:        req.Comments,
+		UserID:          req.UserID,
+		UserRole:        req.UserRole,
+		OnBehalfOfID:    req.OnBehalfOfID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.GetAppeal(appeal.ID, req.UserID, req.UserRole)
+	if err != nil {
+		return nil, err
+	}
+	response.Transition = result
+	return response, nil
+}
+
+// GetAppeal returns an appeal; applicants only see appeals they filed
+func (s *appealService) GetAppeal(appealID, userID uint, role models.UserRole) (*dto.AppealResponse, error) {
+	appeal, err := s.getAppeal(s.db.Preload("RejectedBy").Preload("FiledBy").Preload("Reviewer").Preload("DecidedBy"), appealID)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.RoleUser && appeal.FiledByID != userID {
+		return nil, ErrAppealNotFound
+	}
+
+	responses, err := s.appealResponses([]models.LicenseAppeal{*appeal})
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
+}
+
+// GetRequestAppeals returns the appeals of a request, oldest first; applicants only see their own requests
+func (s *appealService) GetRequestAppeals(requestID, userID uint, role models.UserRole) ([]dto.AppealResponse, error) {
+	if role == models.RoleUser {
+		var owned int64
+		err := s.db.Table(licenseRequestTable).
+			Where("id = ? AND user_id = ? AND deleted_at IS NULL", requestID, userID).
+			Count(&owned).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request: %w", err)
+		}
+		if owned == 0 {
+			return nil, ErrRequestNotFound
+		}
+	}
+
+	var appeals []models.LicenseAppeal
+	err := s.db.Preload("RejectedBy").Preload("FiledBy").Preload("Reviewer").Preload("DecidedBy").
+		Where("request_id = ?", requestID).
+		Order("id").
+		Find(&appeals).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appeals: %w", err)
+	}
+	return s.appealResponses(appeals)
+}
+
+// GetAppeals returns the appeal queue, oldest first
+func (s *appealService) GetAppeals(filter dto.AppealFilter, userID uint) ([]dto.AppealResponse, error) {
+	query := s.db.Preload("RejectedBy").Preload("FiledBy").Preload("Reviewer").Preload("DecidedBy")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Mine {
+		query = query.Where("reviewer_id = ?", userID)
+	} else if filter.ReviewerID != 0 {
+		query = query.Where("reviewer_id = ?", filter.ReviewerID)
+	}
+
+	var appeals []models.LicenseAppeal
+	if err := query.Order("created_at, id").Find(&appeals).Error; err != nil {
+		return nil, fmt.Errorf("failed to get appeals: %w", err)
+	}
+	return s.appealResponses(appeals)
+}
+
+// CloseExpiredAppealWindows makes the rejection final for rejected requests whose appeal window
+// has passed without an appeal. It returns the number of requests closed.
+func (s *appealService) CloseExpiredAppealWindows() (int, error) {
+	var requestIDs []uint
+	err := s.db.Table(licenseRequestTable).
+		Where("status = ? AND deadline < ? AND deleted_at IS NULL", models.StatusRejected, time.Now()).
+		Order("id").
+		Pluck("id", &requestIDs).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rejected requests: %w", err)
+	}
+
+	closed := 0
+	for _, requestID := range requestIDs {
+		_, err := s.transitionService.ProcessTransition(dto.WorkflowTransitionRequest{
+			RequestID:    requestID,
+			FromStatus:   models.StatusRejected,
+			ToStatus:     models.StatusRejectedFinal,
+			Comments:     "พ้นกำหนดระยะเวลายื่นอุทธรณ์",
+			AutoApproved: true,
+		})
+		switch {
+		case err == nil:
+			closed++
+		case errors.Is(err, ErrGuardFailed), errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrVersionConflict):
+			// The request was appealed meanwhile, or its workflow keeps the rejection open
+		default:
+			log.Printf("Failed to close appeal window of request %d: %v", requestID, err)
+		}
+	}
+	return closed, nil
+}
+
+// grantStatus returns the status a granted appeal reopens: the status the request was rejected
+// from if the workflow allows the role to go back there, otherwise new_request
+func (s *appealService) grantStatus(appeal *models.LicenseAppeal, role models.UserRole) (models.RequestStatus, error) {
+	var record WorkflowRequestRecord
+	err := s.db.Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", appeal.RequestID).
+		Take(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrRequestNotFound
+		}
+		return "", fmt.Errorf("failed to get request: %w", err)
+	}
+
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+	if stateMachine.CanTransition(models.StatusAppealed, appeal.RejectedFrom, role) {
+		return appeal.RejectedFrom, nil
+	}
+	return models.StatusNewRequest, nil
+}
+
+func (s *appealService) getAppeal(tx *gorm.DB, appealID uint) (*models.LicenseAppeal, error) {
+	var appeal models.LicenseAppeal
+	if err := tx.Take(&appeal, appealID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAppealNotFound
+		}
+		return nil, fmt.Errorf("failed to get appeal: %w", err)
+	}
+	return &appeal, nil
+}
+
+// appealResponses adds the request numbers and files to appeals
+func (s *appealService) appealResponses(appeals []models.LicenseAppeal) ([]dto.AppealResponse, error) {
+	responses := make([]dto.AppealResponse, 0, len(appeals))
+	if len(appeals) == 0 {
+		return responses, nil
+	}
+
+	appealIDs := make([]uint, 0, len(appeals))
+	requestIDs := make([]uint, 0, len(appeals))
+	for _, appeal := range appeals {
+		appealIDs = append(appealIDs, appeal.ID)
+		requestIDs = append(requestIDs, appeal.RequestID)
+	}
+
+	var requests []struct {
+		ID            uint
+		RequestNumber string
+	}
+	err := s.db.Table(licenseRequestTable).
+		Select("id, request_number").
+		Where("id IN ?", requestIDs).
+		Scan(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request numbers: %w", err)
+	}
+	requestNumbers := make(map[uint]string, len(requests))
+	for _, request := range requests {
+		requestNumbers[request.ID] = request.RequestNumber
+	}
+
+	var attachments []models.Attachment
+	err = s.db.Where("entity_type = ? AND entity_id IN ?", models.AppealAttachmentEntityType, appealIDs).
+		Order("id").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get appeal attachments: %w", err)
+	}
+	files := make(map[uint][]models.SubmittedAttachment, len(appeals))
+	for _, attachment := range attachments {
+		files[attachment.EntityID] = append(files[attachment.EntityID], models.SubmittedAttachment{
+			ID:           attachment.ID,
+			FileName:     attachment.FileName,
+			OriginalName: attachment.OriginalName,
+			FileSize:     attachment.FileSize,
+			MimeType:     attachment.MimeType,
+			Description:  attachment.Description,
+			UploadedAt:   attachment.CreatedAt,
+		})
+	}
+
+	fullName := func(user *models.User) string {
+		if user == nil {
+			return ""
+		}
+		return user.FullName
+	}
+	for _, appeal := range appeals {
+		response := dto.AppealResponse{
+			ID:              appeal.ID,
+			RequestID:       appeal.RequestID,
+			RequestNumber:   requestNumbers[appeal.RequestID],
+			LicenseType:     appeal.LicenseType,
+			Status:          appeal.Status,
+			Grounds:         appeal.Grounds,
+			RejectedFrom:    appeal.RejectedFrom,
+			RejectedByID:    appeal.RejectedByID,
+			RejectedBy:      fullName(appeal.RejectedBy),
+			RejectionReason: appeal.RejectionReason,
+			RejectedAt:      appeal.RejectedAt,
+			FiledByID:       appeal.FiledByID,
+			FiledBy:         fullName(appeal.FiledBy),
+			ReviewerID:      appeal.ReviewerID,
+			Reviewer:        fullName(appeal.Reviewer),
+			DecidedByID:     appeal.DecidedByID,
+			DecidedBy:       fullName(appeal.DecidedBy),
+			OnBehalfOfID:    appeal.OnBehalfOfID,
+			DecisionNotes:   appeal.DecisionNotes,
+			DecidedAt:       appeal.DecidedAt,
+			Attachments:     files[appeal.ID],
+			FiledAt:         appeal.CreatedAt,
+		}
+		if response.Attachments == nil {
+			response.Attachments = make([]models.SubmittedAttachment, 0)
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// fileAppeal records the appeal and its files inside the appeal transition and notifies the reviewer
+func fileAppeal(tc *TransitionContext, req dto.FileAppealRequest) (*models.LicenseAppeal, error) {
+	rejection, err := lastEntryInto(tc.Tx, tc.Record.ID, models.StatusRejected)
+	if err != nil {
+		return nil, err
+	}
+
+	var rejectionReason string
+	err = tc.Tx.Table(licenseRequestTable).
+		Where("id = ?", tc.Record.ID).
+		Pluck("rejection_reason", &rejectionReason).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rejection reason: %w", err)
+	}
+
+	appeal := &models.LicenseAppeal{
+		RequestID:       tc.Record.ID,
+		LicenseType:     tc.Record.LicenseType,
+		Status:          models.AppealStatusPending,
+		Grounds:         strings.TrimSpace(req.Grounds),
+		RejectedFrom:    models.StatusNewRequest,
+		RejectionReason: rejectionReason,
+		RejectedAt:      tc.Record.UpdatedAt,
+		FiledByID:       req.UserID,
+	}
+	// Requests rejected before flow logs were kept have no known rejecter and reopen at new_request
+	if rejection != nil {
+		appeal.RejectedByID = rejection.ChangedBy
+		appeal.RejectedForID = rejection.OnBehalfOf
+		appeal.RejectedAt = rejection.CreatedAt
+		if rejection.PreviousStatus != nil {
+			appeal.RejectedFrom = *rejection.PreviousStatus
+		}
+	}
+
+	reviewer, err := appealReviewer(tc.Tx, appeal)
+	if err != nil {
+		return nil, err
+	}
+	if reviewer != nil {
+		appeal.ReviewerID = &reviewer.ID
+	}
+
+	if err := tc.Tx.Create(appeal).Error; err != nil {
+		return nil, fmt.Errorf("failed to create appeal: %w", err)
+	}
+
+	for _, file := range req.Files {
+		attachment := &models.Attachment{
+			FileName:     file.FileName,
+			OriginalName: file.OriginalName,
+			FilePath:     file.FilePath,
+			FileSize:     file.FileSize,
+			MimeType:     file.MimeType,
+			FileType:     file.FileType,
+			Description:  "เอกสารประกอบการอุทธรณ์",
+			EntityType:   models.AppealAttachmentEntityType,
+			EntityID:     appeal.ID,
+			UploaderID:   req.UserID,
+		}
+		if err := tc.Tx.Create(attachment).Error; err != nil {
+			return nil, fmt.Errorf("failed to save appeal attachment: %w", err)
+		}
+	}
+
+	title := "อุทธรณ์คำสั่งปฏิเสธ"
+	message := fmt.Sprintf("คำขอเลขที่ %s ได้รับการอุทธรณ์จากผู้ยื่นคำขอ: %s", tc.Record.RequestNumber, appeal.Grounds)
+	actionURL := fmt.Sprintf("/admin-portal/services/%d", tc.Record.ID)
+	if reviewer != nil {
+		return appeal, tc.NotifyUser(reviewer.ID, title, message,
+			models.NotificationType("appeal_filed"), models.PriorityHigh, actionURL)
+	}
+	return appeal, tc.NotifyRole(models.RoleDEDEHead, title, message,
+		models.NotificationType("appeal_filed"), models.PriorityHigh, actionURL)
+}
+
+// appealReviewer picks who decides an appeal: the active DEDE Head with the fewest pending appeals
+// who did not take part in the rejection, or failing that such an admin. It returns nil if nobody qualifies.
+func appealReviewer(tx *gorm.DB, appeal *models.LicenseAppeal) (*models.User, error) {
+	var loads []struct {
+		ReviewerID uint
+		Pending    int64
+	}
+	err := tx.Model(&models.LicenseAppeal{}).
+		Select("reviewer_id, COUNT(*) AS pending").
+		Where("status = ? AND reviewer_id IS NOT NULL", models.AppealStatusPending).
+		Group("reviewer_id").
+		Scan(&loads).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pending appeals: %w", err)
+	}
+	pending := make(map[uint]int64, len(loads))
+	for _, load := range loads {
+		pending[load.ReviewerID] = load.Pending
+	}
+
+	for _, role := range []models.UserRole{models.RoleDEDEHead, models.RoleAdmin} {
+		var users []models.User
+		err := tx.Select("id", "full_name", "role").
+			Where("role = ? AND status = ?", role, models.UserStatusActive).
+			Order("id").
+			Find(&users).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get appeal reviewers: %w", err)
+		}
+
+		var reviewer *models.User
+		for i := range users {
+			if appeal.WasRejectedBy(users[i].ID, nil) {
+				continue
+			}
+			if reviewer == nil || pending[users[i].ID] < pending[reviewer.ID] {
+				reviewer = &users[i]
+			}
+		}
+		if reviewer != nil {
+			return reviewer, nil
+		}
+	}
+	return nil, nil
+}
+
+// settleAppeal records the outcome of the pending appeal of a request leaving appeal
+func settleAppeal(tx *gorm.DB, req dto.WorkflowTransitionRequest, record *WorkflowRequestRecord, stateMachine *models.WorkflowStateMachine, now time.Time) error {
+	status := models.AppealStatusGranted
+	switch {
+	case req.ToStatus == models.StatusWithdrawn:
+		status = models.AppealStatusWithdrawn
+	case stateMachine.IsTerminalState(req.ToStatus):
+		status = models.AppealStatusDismissed
+	}
+
+	var decidedBy *uint
+	if req.UserID != 0 {
+		decidedBy = &req.UserID
+	}
+	err := tx.Model(&models.LicenseAppeal{}).
+		Where("request_id = ? AND status = ?", record.ID, models.AppealStatusPending).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"decided_by_id":   decidedBy,
+			"on_behalf_of_id": req.OnBehalfOfID,
+			"decision_notes":  req.Comments,
+			"decided_at":      now,
+			"updated_at":      now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to settle appeal: %w", err)
+	}
+	return nil
+}
+
+// notifyAppealOutcome tells the applicant whether their appeal was granted or dismissed
+func notifyAppealOutcome(tc *TransitionContext) error {
+	if tc.StateMachine.IsTerminalState(tc.Request.ToStatus) {
+		return tc.NotifyUser(tc.Record.UserID, "ยกอุทธรณ์",
+			fmt.Sprintf("อุทธรณ์ของคำขอเลขที่ %s ถูกยก คำขอถูกปฏิเสธเป็นที่สุด: %s", tc.Record.RequestNumber, tc.Request.Comments),
+			models.NotificationType("appeal_dismissed"), models.PriorityHigh, "/dashboard/licenses")
+	}
+	return tc.NotifyUser(tc.Record.UserID, "รับอุทธรณ์",
+		fmt.Sprintf("อุทธรณ์ของคำขอเลขที่ %s ได้รับการพิจารณา คำขอกลับเข้าสู่การพิจารณาอีกครั้ง", tc.Record.RequestNumber),
+		models.NotificationType("appeal_granted"), models.PriorityHigh, "/dashboard/licenses")
+}
+
+// latestAppeal returns the most recent appeal of a request, or nil if it was never appealed
+func latestAppeal(tx *gorm.DB, requestID uint) (*models.LicenseAppeal, error) {
+	var appeal models.LicenseAppeal
+	err := tx.Where("request_id = ?", requestID).Order("id DESC").Take(&appeal).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get appeal: %w", err)
+	}
+	return &appeal, nil
+}
+
+// appealWindowEnd returns when the appeal window of the request's latest rejection closes: the
+// rejected status's deadline in the workflow definition, counted from the rejection. It returns
+// nil when the definition sets no window or the rejection predates flow logs.
+func appealWindowEnd(tx *gorm.DB, stateMachine *models.WorkflowStateMachine, requestID uint) (*time.Time, error) {
+	rejection, err := lastEntryInto(tx, requestID, models.StatusRejected)
+	if err != nil || rejection == nil {
+		return nil, err
+	}
+	return stateMachine.GetDefaultDeadline(models.StatusRejected, rejection.CreatedAt), nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// unmetGuards returns the guards a GuardError reports as unmet
+func unmetGuards(t *testing.T, err error) []string {
+	t.Helper()
+
+	var guardErr *GuardError
+	require.True(t, errors.As(err, &guardErr), "want a GuardError, got %v", err)
+	guards := make([]string, 0, len(guardErr.UnmetConditions))
+	for _, condition := range guardErr.UnmetConditions {
+		guards = append(guards, condition.Guard)
+	}
+	return guards
+}
+
+// rejectDaysAgo moves the request into rejected from a status as the rejecter did days ago, with
+// the appeal window of the standard workflow as its deadline
+func rejectDaysAgo(t *testing.T, db *gorm.DB, request *models.LicenseRequest, from models.RequestStatus, rejecterID uint, days int) {
+	t.Helper()
+
+	rejectedAt := time.Now().AddDate(0, 0, -days)
+	require.NoError(t, db.Create(&models.ServiceFlowLog{
+		LicenseRequestID: request.ID,
+		PreviousStatus:   &from,
+		NewStatus:        models.StatusRejected,
+		ChangedBy:        &rejecterID,
+		ChangeReason:     "Incomplete documents",
+		LicenseType:      string(request.LicenseType),
+		CreatedAt:        rejectedAt,
+	}).Error)
+	require.NoError(t, db.Model(request).Updates(map[string]interface{}{
+		"status":           models.StatusRejected,
+		"rejection_reason": "Incomplete documents",
+		"deadline":         rejectedAt.AddDate(0, 0, 30),
+	}).Error)
+}
+
+func newTestAppealService(db *gorm.DB) *appealService {
+	return &appealService{
+		db:                db,
+		transitionService: newTestTransitionService(db),
+		definitionService: NewWorkflowDefinitionService(db),
+	}
+}
+
+func TestFileAppeal(t *testing.T) {
+	tests := []struct {
+		name       string
+		days       int // Since the rejection
+		grounds    string
+		byOther    bool
+		wantErr    error
+		wantUnmet  []string
+		wantStatus models.RequestStatus
+	}{
+		{name: "within the appeal window", days: 5, grounds: "The documents were attached", wantStatus: models.StatusAppealed},
+		{name: "on the last day of the window", days: 29, grounds: "The documents were attached", wantStatus: models.StatusAppealed},
+		{name: "after the window closed", days: 31, grounds: "The documents were attached", wantErr: ErrGuardFailed, wantUnmet: []string{GuardAppealWindow}},
+		{name: "without grounds", days: 5, wantErr: ErrGuardFailed, wantUnmet: []string{GuardAppealFiled}},
+		{name: "by another applicant", days: 5, grounds: "The documents were attached", byOther: true, wantErr: ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := newTestAppealService(db)
+			applicant := createTestUser(t, db, models.RoleUser)
+			rejecter := createTestUser(t, db, models.RoleDEDEHead)
+			reviewer := createTestUser(t, db, models.RoleDEDEHead)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, models.StatusForwarded)
+			rejectDaysAgo(t, db, request, models.StatusForwarded, rejecter.ID, tt.days)
+
+			callerID := applicant.ID
+			if tt.byOther {
+				callerID = createTestUser(t, db, models.RoleUser).ID
+			}
+			response, err := s.FileAppeal(dto.FileAppealRequest{
+				RequestID: request.ID,
+				UserID:    callerID,
+				UserRole:  models.RoleUser,
+				Grounds:   tt.grounds,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				if tt.wantUnmet != nil {
+					assert.Equal(t, tt.wantUnmet, unmetGuards(t, err))
+				}
+				assert.Equal(t, models.StatusRejected, reloadTestRequest(t, db, request.ID).Status)
+
+				var appeals int64
+				require.NoError(t, db.Model(&models.LicenseAppeal{}).Count(&appeals).Error)
+				assert.Zero(t, appeals, "a blocked appeal is rolled back")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, reloadTestRequest(t, db, request.ID).Status)
+
+			appeal, err := latestAppeal(db, request.ID)
+			require.NoError(t, err)
+			require.NotNil(t, appeal)
+			assert.Equal(t, response.ID, appeal.ID)
+			assert.Equal(t, models.AppealStatusPending, appeal.Status)
+			assert.Equal(t, models.StatusForwarded, appeal.RejectedFrom)
+			require.NotNil(t, appeal.ReviewerID)
+			assert.Equal(t, reviewer.ID, *appeal.ReviewerID, "the rejecter does not review the appeal")
+		})
+	}
+}
+
+func TestDecideAppeal(t *testing.T) {
+	tests := []struct {
+		name        string
+		decision    string
+		comments    string
+		byRejecter  bool
+		wantErr     error
+		wantStatus  models.RequestStatus
+		wantOutcome models.AppealStatus
+	}{
+		{name: "granted back to where it was rejected", decision: AppealDecisionGrant, wantStatus: models.StatusForwarded, wantOutcome: models.AppealStatusGranted},
+		{name: "dismissed with a reason", decision: AppealDecisionDismiss, comments: "Grounds not supported", wantStatus: models.StatusRejectedFinal, wantOutcome: models.AppealStatusDismissed},
+		{name: "dismissed without a reason", decision: AppealDecisionDismiss, wantErr: ErrGuardFailed},
+		{name: "decided by the rejecter", decision: AppealDecisionGrant, byRejecter: true, wantErr: ErrGuardFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := newTestAppealService(db)
+			applicant := createTestUser(t, db, models.RoleUser)
+			rejecter := createTestUser(t, db, models.RoleDEDEHead)
+			reviewer := createTestUser(t, db, models.RoleDEDEHead)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, models.StatusForwarded)
+			rejectDaysAgo(t, db, request, models.StatusForwarded, rejecter.ID, 5)
+
+			filed, err := s.FileAppeal(dto.FileAppealRequest{
+				RequestID: request.ID,
+				UserID:    applicant.ID,
+				UserRole:  models.RoleUser,
+				Grounds:   "The documents were attached",
+			})
+			require.NoError(t, err)
+
+			deciderID := reviewer.ID
+			if tt.byRejecter {
+				deciderID = rejecter.ID
+			}
+			_, err = s.DecideAppeal(dto.DecideAppealRequest{
+				AppealID: filed.ID,
+				UserID:   deciderID,
+				UserRole: models.RoleDEDEHead,
+				Decision: tt.decision,
+				Comments: tt.comments,
+			})
+			appeal, getErr := latestAppeal(db, request.ID)
+			require.NoError(t, getErr)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, models.StatusAppealed, reloadTestRequest(t, db, request.ID).Status)
+				assert.Equal(t, models.AppealStatusPending, appeal.Status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, reloadTestRequest(t, db, request.ID).Status)
+			assert.Equal(t, tt.wantOutcome, appeal.Status)
+		})
+	}
+}
+
+func TestCloseExpiredAppealWindows(t *testing.T) {
+	tests := []struct {
+		name       string
+		days       int  // Since the rejection
+		early      bool // The stored deadline passed before the appeal window did
+		wantClosed int
+		wantStatus models.RequestStatus
+	}{
+		{name: "window passed", days: 31, wantClosed: 1, wantStatus: models.StatusRejectedFinal},
+		{name: "window still open", days: 5, wantStatus: models.StatusRejected},
+		{name: "deadline passed but the window is still open", days: 5, early: true, wantStatus: models.StatusRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := newTestAppealService(db)
+			applicant := createTestUser(t, db, models.RoleUser)
+			rejecter := createTestUser(t, db, models.RoleDEDEHead)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, models.StatusForwarded)
+			rejectDaysAgo(t, db, request, models.StatusForwarded, rejecter.ID, tt.days)
+			if tt.early {
+				require.NoError(t, db.Model(request).Update("deadline", time.Now().Add(-time.Hour)).Error)
+			}
+
+			closed, err := s.CloseExpiredAppealWindows()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantClosed, closed)
+			assert.Equal(t, tt.wantStatus, reloadTestRequest(t, db, request.ID).Status)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoApprovalStep is returned when a vote is cast on a request that is not at a step needing several approvals
+var ErrNoApprovalStep = errors.New("request is not waiting for approvals")
+
+// collectApproval records the caller's vote when the transition belongs to a step that needs
+// several approvals. Taking the step's transition is an approving vote and taking its veto
+// transition a rejecting one. It returns nil when the transition does not need approvals.
+func (s *workflowTransitionService) collectApproval(tx *gorm.DB, req dto.WorkflowTransitionRequest, record *WorkflowRequestRecord, stateMachine *models.WorkflowStateMachine) (*dto.ApprovalStatus, error) {
+	transition, ok := stateMachine.GetApprovalTransition(record.Status)
+	if !ok || req.AutoApproved || !transition.Approval.AppliesTo(record.RequestedCapacity) {
+		return nil, nil
+	}
+
+	var decision models.ApprovalDecision
+	switch req.ToStatus {
+	case transition.ToStatus:
+		decision = models.ApprovalDecisionApprove
+	case transition.Approval.VetoStatus:
+		decision = models.ApprovalDecisionReject
+	default:
+		return nil, nil
+	}
+
+	// Lock the request so concurrent votes are counted one after the other
+	var locked WorkflowRequestRecord
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", record.ID).
+		Take(&locked).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock request: %w", err)
+	}
+	if locked.Version != record.Version || locked.Status != record.Status {
+		return nil, s.conflict(&locked)
+	}
+
+	// An approval only counts if the step could be completed with it
+	if decision == models.ApprovalDecisionApprove {
+		voterTransition, _ := stateMachine.GetTransition(record.Status, req.ToStatus, req.UserRole)
+		if err := checkGuards(&GuardContext{Tx: tx, Request: req, Record: record, Transition: voterTransition, StateMachine: stateMachine}); err != nil {
+			return nil, err
+		}
+	}
+
+	roundID, err := approvalRound(tx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	// A vote cast under a delegation is the delegator's vote
+	voterID := req.UserID
+	var castByID *uint
+	if req.OnBehalfOfID != nil {
+		voterID = *req.OnBehalfOfID
+		castByID = &req.UserID
+	}
+
+	// One person counts once per round, whether voting for themselves or for a delegator
+	var otherVotes int64
+	err = tx.Model(&models.ApprovalVote{}).
+		Where("request_id = ? AND round_id = ? AND voter_id <> ? AND (voter_id = ? OR cast_by_id = ?)",
+			record.ID, roundID, voterID, req.UserID, req.UserID).
+		Count(&otherVotes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check approval votes: %w", err)
+	}
+	if otherVotes > 0 {
+		return nil, fmt.Errorf("%w: already voted in this approval round for another approver", ErrInvalidTransition)
+	}
+
+	comment := req.Comments
+	if comment == "" {
+		comment = req.RejectionReason
+	}
+	vote := &models.ApprovalVote{
+		RequestID:   record.ID,
+		LicenseType: record.LicenseType,
+		RoundID:     roundID,
+		Action:      transition.Action,
+		FromStatus:  record.Status,
+		VoterID:     voterID,
+		CastByID:    castByID,
+		VoterRole:   req.UserRole,
+		Decision:    decision,
+		Comment:     comment,
+	}
+
+	// A voter may change their mind until the step completes; the latest vote counts
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "request_id"}, {Name: "round_id"}, {Name: "voter_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cast_by_id", "voter_role", "decision", "comment", "updated_at"}),
+	}).Create(vote).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to record approval vote: %w", err)
+	}
+
+	return approvalStatus(tx, record, transition, roundID)
+}
+
+// notifyPendingApprovers tells the roles whose approval is still missing that the step waits for them
+func notifyPendingApprovers(tc *TransitionContext, status *dto.ApprovalStatus) error {
+	actionURL := fmt.Sprintf("/admin-portal/services/%d", tc.Record.ID)
+	for _, role := range status.MissingRoles {
+		err := tc.NotifyRole(
+			role,
+			"รอการอนุมัติ",
+			fmt.Sprintf("คำขอเลขที่ %s รอการอนุมัติจากท่าน (%d/%d)", tc.Record.RequestNumber, status.Approvals, status.MinApprovals),
+			models.NotificationType("approval_pending"),
+			models.PriorityNormal,
+			actionURL,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// approvalRound returns the flow log entry that moved the request into its current status.
+// Requests without one (e.g. migrated mid-step) use round 0.
+func approvalRound(tx *gorm.DB, record *WorkflowRequestRecord) (uint, error) {
+	entry, err := statusEntry(tx, record)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get approval round: %w", err)
+	}
+	return entry.ID, nil
+}
+
+// approvalStatus counts the votes of a round against the step's policy
+func approvalStatus(tx *gorm.DB, record *WorkflowRequestRecord, transition models.WorkflowTransition, roundID uint) (*dto.ApprovalStatus, error) {
+	policy := transition.Approval
+
+	var votes []models.ApprovalVote
+	err := tx.Preload("Voter").
+		Where("request_id = ? AND round_id = ?", record.ID, roundID).
+		Order("created_at").
+		Find(&votes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval votes: %w", err)
+	}
+
+	status := &dto.ApprovalStatus{
+		Action:        transition.Action,
+		ToStatus:      transition.ToStatus,
+		VetoStatus:    policy.VetoStatus,
+		RoundID:       roundID,
+		MinApprovals:  policy.MinApprovals,
+		RequiredRoles: append([]models.UserRole{}, policy.RequiredRoles...),
+		MissingRoles:  make([]models.UserRole, 0),
+		Votes:         votes,
+	}
+	tallyApprovalVotes(status, policy)
+
+	return status, nil
+}
+
+// tallyApprovalVotes counts the votes of the status against the policy and reports whether the quorum is met
+func tallyApprovalVotes(status *dto.ApprovalStatus, policy *models.WorkflowApprovalPolicy) {
+	approvedRoles := make(map[models.UserRole]bool)
+	for _, vote := range status.Votes {
+		switch vote.Decision {
+		case models.ApprovalDecisionApprove:
+			status.Approvals++
+			approvedRoles[vote.VoterRole] = true
+		case models.ApprovalDecisionReject:
+			status.Vetoed = true
+		}
+	}
+	for _, role := range policy.RequiredRoles {
+		if !approvedRoles[role] {
+			status.MissingRoles = append(status.MissingRoles, role)
+		}
+	}
+	status.QuorumMet = !status.Vetoed && status.Approvals >= policy.MinApprovals && len(status.MissingRoles) == 0
+}
+
+// GetApprovalStatus returns the votes collected so far when the request is at a step that
+// needs several approvals, or nil if it is not
+func (s *workflowTransitionService) GetApprovalStatus(requestID uint) (*dto.ApprovalStatus, error) {
+	record, err := s.getRecord(s.db, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+
+	transition, ok := stateMachine.GetApprovalTransition(record.Status)
+	if !ok || !transition.Approval.AppliesTo(record.RequestedCapacity) {
+		return nil, nil
+	}
+
+	roundID, err := approvalRound(s.db, record)
+	if err != nil {
+		return nil, err
+	}
+	return approvalStatus(s.db, record, transition, roundID)
+}
+
+// CastApprovalVote approves or vetoes the step the request is at. An approval that
+// completes the quorum, or any veto, moves the request on.
+func (s *workflowTransitionService) CastApprovalVote(req dto.ApprovalVoteRequest) (*dto.WorkflowTransitionResult, error) {
+	record, err := s.getRecord(s.db, req.RequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+
+	transition, ok := stateMachine.GetApprovalTransition(record.Status)
+	if !ok {
+		return nil, ErrNoApprovalStep
+	}
+
+	transitionReq := dto.WorkflowTransitionRequest{
+		RequestID:       record.ID,
+		FromStatus:      record.Status,
+		ExpectedVersion: req.ExpectedVersion,
+		ToStatus:        transition.ToStatus,
+		Comments:        req.Comment,
+		OnBehalfOfID:    req.OnBehalfOfID,
+		UserID:          req.UserID,
+		UserRole:        req.UserRole,
+	}
+	if req.Decision == models.ApprovalDecisionReject {
+		transitionReq.ToStatus = transition.Approval.VetoStatus
+		transitionReq.RejectionReason = req.Comment
+	}
+
+	return s.ProcessTransition(transitionReq)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTallyApprovalVotes(t *testing.T) {
+	approve := func(voterID uint, role models.UserRole) models.ApprovalVote {
+		return models.ApprovalVote{VoterID: voterID, VoterRole: role, Decision: models.ApprovalDecisionApprove}
+	}
+	reject := func(voterID uint, role models.UserRole) models.ApprovalVote {
+		return models.ApprovalVote{VoterID: voterID, VoterRole: role, Decision: models.ApprovalDecisionReject}
+	}
+	twoOfThree := &models.WorkflowApprovalPolicy{MinApprovals: 2}
+	headAndStaff := &models.WorkflowApprovalPolicy{
+		MinApprovals:  2,
+		RequiredRoles: []models.UserRole{models.RoleDEDEHead, models.RoleDEDEStaff},
+	}
+
+	tests := []struct {
+		name          string
+		policy        *models.WorkflowApprovalPolicy
+		votes         []models.ApprovalVote
+		wantApprovals int
+		wantVetoed    bool
+		wantMissing   []models.UserRole
+		wantQuorum    bool
+	}{
+		{name: "no votes", policy: twoOfThree, wantMissing: []models.UserRole{}},
+		{
+			name:          "below the minimum",
+			policy:        twoOfThree,
+			votes:         []models.ApprovalVote{approve(1, models.RoleDEDEHead)},
+			wantApprovals: 1,
+			wantMissing:   []models.UserRole{},
+		},
+		{
+			name:          "minimum reached",
+			policy:        twoOfThree,
+			votes:         []models.ApprovalVote{approve(1, models.RoleDEDEHead), approve(2, models.RoleDEDEHead)},
+			wantApprovals: 2,
+			wantMissing:   []models.UserRole{},
+			wantQuorum:    true,
+		},
+		{
+			name:          "a veto blocks the quorum",
+			policy:        twoOfThree,
+			votes:         []models.ApprovalVote{approve(1, models.RoleDEDEHead), approve(2, models.RoleDEDEHead), reject(3, models.RoleAdmin)},
+			wantApprovals: 2,
+			wantVetoed:    true,
+			wantMissing:   []models.UserRole{},
+		},
+		{
+			name:          "required role missing",
+			policy:        headAndStaff,
+			votes:         []models.ApprovalVote{approve(1, models.RoleDEDEHead), approve(2, models.RoleDEDEHead)},
+			wantApprovals: 2,
+			wantMissing:   []models.UserRole{models.RoleDEDEStaff},
+		},
+		{
+			name:          "rejection does not count for its role",
+			policy:        headAndStaff,
+			votes:         []models.ApprovalVote{approve(1, models.RoleDEDEHead), reject(2, models.RoleDEDEStaff)},
+			wantApprovals: 1,
+			wantVetoed:    true,
+			wantMissing:   []models.UserRole{models.RoleDEDEStaff},
+		},
+		{
+			name:          "every required role approved",
+			policy:        headAndStaff,
+			votes:         []models.ApprovalVote{approve(1, models.RoleDEDEStaff), approve(2, models.RoleDEDEHead)},
+			wantApprovals: 2,
+			wantMissing:   []models.UserRole{},
+			wantQuorum:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &dto.ApprovalStatus{MissingRoles: make([]models.UserRole, 0), Votes: tt.votes}
+			tallyApprovalVotes(status, tt.policy)
+
+			assert.Equal(t, tt.wantApprovals, status.Approvals)
+			assert.Equal(t, tt.wantVetoed, status.Vetoed)
+			assert.Equal(t, tt.wantMissing, status.MissingRoles)
+			assert.Equal(t, tt.wantQuorum, status.QuorumMet)
+		})
+	}
+}
+
+func TestApprovalPolicyAppliesTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *models.WorkflowApprovalPolicy
+		capacity float64
+		want     bool
+	}{
+		{name: "no policy", capacity: 50},
+		{name: "every capacity", policy: &models.WorkflowApprovalPolicy{MinApprovals: 2}, want: true},
+		{name: "below the threshold", policy: &models.WorkflowApprovalPolicy{MinApprovals: 2, MinCapacity: 10}, capacity: 9.9},
+		{name: "at the threshold", policy: &models.WorkflowApprovalPolicy{MinApprovals: 2, MinCapacity: 10}, capacity: 10, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.AppliesTo(tt.capacity))
+		})
+	}
+}
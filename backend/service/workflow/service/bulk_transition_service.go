@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateBulkItem is returned when a bulk action lists the same request more than once
+var ErrDuplicateBulkItem = errors.New("request listed more than once")
+
+// errBulkRolledBack rolls back an all-or-nothing bulk action after one of its items failed
+var errBulkRolledBack = errors.New("bulk action rolled back")
+
+// ProcessTransitions runs the same action on several requests. Every item goes through
+// ProcessTransition with its own validation, guards and hooks. In best-effort mode each item
+// commits on its own; in all-or-nothing mode the items share one transaction, each in its own
+// savepoint so that every item is still checked, and nothing is saved unless all of them pass.
+func (s *workflowTransitionService) ProcessTransitions(action string, reqs []dto.WorkflowTransitionRequest, mode dto.BulkMode, hooks ...TransitionHook) (*dto.BulkTransitionResult, error) {
+	seen := make(map[uint]bool, len(reqs))
+	for _, req := range reqs {
+		if seen[req.RequestID] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateBulkItem, req.RequestID)
+		}
+		seen[req.RequestID] = true
+	}
+
+	if mode == "" {
+		mode = dto.BulkModeBestEffort
+	}
+	bulk := &dto.BulkTransitionResult{
+		Action: action,
+		Mode:   mode,
+		Total:  len(reqs),
+		Items:  make([]dto.BulkItemResult, 0, len(reqs)),
+	}
+
+	if mode != dto.BulkModeAllOrNothing {
+		for _, req := range reqs {
+			result, err := s.ProcessTransition(req, hooks...)
+			addBulkItem(bulk, req.RequestID, result, err)
+		}
+		bulk.Committed = bulk.Succeeded > 0
+		return bulk, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Nested transactions of the bound service become savepoints of this one
+		bound := *s
+		bound.db = tx
+		bound.deferDispatch = true
+
+		for _, req := range reqs {
+			result, err := bound.ProcessTransition(req, hooks...)
+			addBulkItem(bulk, req.RequestID, result, err)
+		}
+		if bulk.Failed > 0 {
+			return errBulkRolledBack
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkRolledBack) {
+		for i := range bulk.Items {
+			item := &bulk.Items[i]
+			if !item.Success {
+				continue
+			}
+			item.Success = false
+			item.Result = nil
+			item.ErrorCode = dto.BulkErrorRolledBack
+			item.Error = "not applied because other requests in the action failed"
+		}
+		bulk.Failed = bulk.Total
+		bulk.Succeeded = 0
+		return bulk, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bulk.Committed = true
+	if !s.deferDispatch {
+		s.dispatchOutbox()
+	}
+	return bulk, nil
+}
+
+// addBulkItem records the outcome of one item of a bulk action
+func addBulkItem(bulk *dto.BulkTransitionResult, requestID uint, result *dto.WorkflowTransitionResult, err error) {
+	item := dto.BulkItemResult{RequestID: requestID}
+	if err == nil {
+		item.Success = true
+		item.Result = result
+		bulk.Succeeded++
+		bulk.Items = append(bulk.Items, item)
+		return
+	}
+
+	var conflict *VersionConflictError
+	var guard *GuardError
+	item.Error = err.Error()
+	switch {
+	case errors.As(err, &conflict):
+		item.ErrorCode = dto.BulkErrorVersionConflict
+		item.Details = conflict.Current
+	case errors.As(err, &guard):
+		item.ErrorCode = dto.BulkErrorGuardFailed
+		item.Details = guard
+	case errors.Is(err, ErrRequestNotFound):
+		item.ErrorCode = dto.BulkErrorNotFound
+	case errors.Is(err, ErrInvalidTransition):
+		item.ErrorCode = dto.BulkErrorInvalidTransition
+	default:
+		// Keep database errors out of the response
+		log.Printf("Error processing bulk %s of request %d: %v", bulk.Action, requestID, err)
+		item.ErrorCode = dto.BulkErrorInternal
+		item.Error = "failed to process request"
+	}
+	bulk.Failed++
+	bulk.Items = append(bulk.Items, item)
+}
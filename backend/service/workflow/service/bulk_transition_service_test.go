@@ -0,0 +1,118 @@
+package service
+
+import (
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessTransitions(t *testing.T) {
+	staleVersion := 5
+
+	// An item of the bulk accept; bad items fail on their own
+	type item struct {
+		status  models.RequestStatus
+		version *int
+	}
+	ok := item{status: models.StatusNewRequest}
+
+	tests := []struct {
+		name          string
+		mode          dto.BulkMode
+		items         []item
+		wantCommitted bool
+		wantCodes     []string // Error code per item, empty for a success
+	}{
+		{
+			name:          "all or nothing, every item passes",
+			mode:          dto.BulkModeAllOrNothing,
+			items:         []item{ok, ok, ok},
+			wantCommitted: true,
+			wantCodes:     []string{"", "", ""},
+		},
+		{
+			name:      "all or nothing, one invalid transition",
+			mode:      dto.BulkModeAllOrNothing,
+			items:     []item{ok, {status: models.StatusDraft}, ok},
+			wantCodes: []string{dto.BulkErrorRolledBack, dto.BulkErrorInvalidTransition, dto.BulkErrorRolledBack},
+		},
+		{
+			name:      "all or nothing, one stale version",
+			mode:      dto.BulkModeAllOrNothing,
+			items:     []item{ok, ok, {status: models.StatusNewRequest, version: &staleVersion}},
+			wantCodes: []string{dto.BulkErrorRolledBack, dto.BulkErrorRolledBack, dto.BulkErrorVersionConflict},
+		},
+		{
+			name:          "best effort, one invalid transition",
+			mode:          dto.BulkModeBestEffort,
+			items:         []item{ok, {status: models.StatusDraft}, ok},
+			wantCommitted: true,
+			wantCodes:     []string{"", dto.BulkErrorInvalidTransition, ""},
+		},
+		{
+			name:      "best effort, every item fails",
+			mode:      dto.BulkModeBestEffort,
+			items:     []item{{status: models.StatusApproved}, {status: models.StatusDraft}},
+			wantCodes: []string{dto.BulkErrorInvalidTransition, dto.BulkErrorInvalidTransition},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := newTestTransitionService(db)
+			applicant := createTestUser(t, db, models.RoleUser)
+			admin := createTestUser(t, db, models.RoleAdmin)
+
+			reqs := make([]dto.WorkflowTransitionRequest, len(tt.items))
+			requests := make([]*models.LicenseRequest, len(tt.items))
+			for i, item := range tt.items {
+				requests[i] = createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, item.status)
+				reqs[i] = dto.WorkflowTransitionRequest{
+					RequestID:       requests[i].ID,
+					ExpectedVersion: item.version,
+					ToStatus:        models.StatusAccepted,
+					UserID:          admin.ID,
+					UserRole:        models.RoleAdmin,
+				}
+			}
+
+			bulk, err := s.ProcessTransitions("accept", reqs, tt.mode)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCommitted, bulk.Committed)
+			require.Len(t, bulk.Items, len(tt.items))
+
+			succeeded := 0
+			for i, result := range bulk.Items {
+				assert.Equal(t, requests[i].ID, result.RequestID)
+				assert.Equal(t, tt.wantCodes[i], result.ErrorCode, "item %d", i)
+				assert.Equal(t, tt.wantCodes[i] == "", result.Success, "item %d", i)
+
+				// Only the items reported as successful were saved
+				want := tt.items[i].status
+				if result.Success {
+					want = models.StatusAccepted
+					succeeded++
+				}
+				assert.Equal(t, want, reloadTestRequest(t, db, requests[i].ID).Status, "item %d", i)
+			}
+			assert.Equal(t, succeeded, bulk.Succeeded)
+			assert.Equal(t, len(tt.items)-succeeded, bulk.Failed)
+
+			var flowLogs int64
+			require.NoError(t, db.Model(&models.ServiceFlowLog{}).Count(&flowLogs).Error)
+			assert.Equal(t, int64(succeeded), flowLogs)
+		})
+	}
+
+	t.Run("duplicate request", func(t *testing.T) {
+		db := openWorkflowTestDB(t)
+		s := newTestTransitionService(db)
+		reqs := []dto.WorkflowTransitionRequest{{RequestID: 1}, {RequestID: 1}}
+		_, err := s.ProcessTransitions("accept", reqs, dto.BulkModeAllOrNothing)
+		assert.ErrorIs(t, err, ErrDuplicateBulkItem)
+	})
+}
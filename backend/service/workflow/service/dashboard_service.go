@@ -23,26 +23,20 @@ type DashboardService interface {
 }
 
 type dashboardService struct {
-	db                   *gorm.DB
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	notificationRepo     repository.NotificationRepository
-	serviceFlowLogRepo   repository.ServiceFlowLogRepo
-	userRepo             repository.UserRepository
+	db                 *gorm.DB
+	licenseRequestRepo repository.LicenseRequestRepository
+	notificationRepo   repository.NotificationRepository
+	serviceFlowLogRepo repository.ServiceFlowLogRepo
+	userRepo           repository.UserRepository
 }
 
 func NewDashboardService(db *gorm.DB) DashboardService {
 	return &dashboardService{
-		db:                   db,
-		newLicenseRepo:       repository.NewNewLicenseRepo(db),
-		renewalLicenseRepo:   repository.NewRenewalLicenseRepo(db),
-		extensionLicenseRepo: repository.NewExtensionLicenseRepo(db),
-		reductionLicenseRepo: repository.NewReductionLicenseRepo(db),
-		notificationRepo:     repository.NewNotificationRepository(db),
-		serviceFlowLogRepo:   repository.NewServiceFlowLogRepo(db),
-		userRepo:             repository.NewUserRepository(db),
+		db:                 db,
+		licenseRequestRepo: repository.NewLicenseRequestRepository(db),
+		notificationRepo:   repository.NewNotificationRepository(db),
+		serviceFlowLogRepo: repository.NewServiceFlowLogRepo(db),
+		userRepo:           repository.NewUserRepository(db),
 	}
 }
 
@@ -267,16 +261,16 @@ func (s *dashboardService) GetRecentActivities(userID uint, role models.UserRole
 	switch role {
 	case models.UserRole("admin"):
 		// Admin can see all activities
-		err = s.db.Preload("ChangedByUser").Order("created_at DESC").Limit(10).Find(&activities).Error
+		err = s.db.Preload("ChangedByUser").Preload("OnBehalfOfUser").Order("created_at DESC").Limit(10).Find(&activities).Error
 	case models.UserRole("dede_head"):
 		// DEDE Head can see all activities
-		err = s.db.Preload("ChangedByUser").Order("created_at DESC").Limit(10).Find(&activities).Error
+		err = s.db.Preload("ChangedByUser").Preload("OnBehalfOfUser").Order("created_at DESC").Limit(10).Find(&activities).Error
 	case models.UserRole("dede_staff"):
 		// DEDE Staff can see activities they're involved in
-		err = s.db.Where("changed_by = ?", userID).Preload("ChangedByUser").Order("created_at DESC").Limit(10).Find(&activities).Error
+		err = s.db.Where("changed_by = ?", userID).Preload("ChangedByUser").Preload("OnBehalfOfUser").Order("created_at DESC").Limit(10).Find(&activities).Error
 	case models.UserRole("dede_consult"):
 		// DEDE Consult can see activities they're involved in
-		err = s.db.Where("changed_by = ?", userID).Preload("ChangedByUser").Order("created_at DESC").Limit(10).Find(&activities).Error
+		err = s.db.Where("changed_by = ?", userID).Preload("ChangedByUser").Preload("OnBehalfOfUser").Order("created_at DESC").Limit(10).Find(&activities).Error
 	default:
 		return nil, fmt.Errorf("unsupported role: %s", role)
 	}
@@ -399,10 +393,10 @@ func (s *dashboardService) getAdminStatistics() (map[string]interface{}, error)
 
 	// Count requests by status
 	var newRequestsCount, pendingCount, approvedCount, rejectedCount int64
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusNewRequest).Count(&newRequestsCount)
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusAccepted).Count(&pendingCount)
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusApproved).Count(&approvedCount)
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&rejectedCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusNewRequest).Count(&newRequestsCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusAccepted).Count(&pendingCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusApproved).Count(&approvedCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&rejectedCount)
 
 	stats["new_requests"] = newRequestsCount
 	stats["pending_requests"] = pendingCount
@@ -419,10 +413,31 @@ func (s *dashboardService) getAdminStatistics() (map[string]interface{}, error)
 
 	// Count overdue requests
 	var overdueCount int64
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusOverdue).Count(&overdueCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusOverdue).Count(&overdueCount)
 
 	stats["overdue_requests"] = overdueCount
 
+	// Count requests the applicant withdrew
+	var withdrawnCount int64
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusWithdrawn).Count(&withdrawnCount)
+
+	stats["withdrawn_requests"] = withdrawnCount
+
+	// Count requests waiting on the applicant, whose SLA clock is paused
+	var pausedCount int64
+	s.db.Model(&models.RequestSLAClock{}).Where("state = ?", models.SLAClockPaused).Count(&pausedCount)
+
+	stats["waiting_on_applicant"] = pausedCount
+
+	// Count escalations
+	escalationStats, err := EscalationStatistics(s.db)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range escalationStats {
+		stats[key] = value
+	}
+
 	return stats, nil
 }
 
@@ -431,10 +446,10 @@ func (s *dashboardService) getDEDEHeadStatistics() (map[string]interface{}, erro
 
 	// Count requests by status
 	var newRequestsCount, pendingCount, approvedCount, rejectedCount int64
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusNewRequest).Count(&newRequestsCount)
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusAccepted).Count(&pendingCount)
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusApproved).Count(&approvedCount)
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&rejectedCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusNewRequest).Count(&newRequestsCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusAccepted).Count(&pendingCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusApproved).Count(&approvedCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusRejected).Count(&rejectedCount)
 
 	stats["new_requests"] = newRequestsCount
 	stats["pending_requests"] = pendingCount
@@ -451,10 +466,31 @@ func (s *dashboardService) getDEDEHeadStatistics() (map[string]interface{}, erro
 
 	// Count overdue requests
 	var overdueCount int64
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusOverdue).Count(&overdueCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusOverdue).Count(&overdueCount)
 
 	stats["overdue_requests"] = overdueCount
 
+	// Count requests the applicant withdrew
+	var withdrawnCount int64
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusWithdrawn).Count(&withdrawnCount)
+
+	stats["withdrawn_requests"] = withdrawnCount
+
+	// Count requests waiting on the applicant, whose SLA clock is paused
+	var pausedCount int64
+	s.db.Model(&models.RequestSLAClock{}).Where("state = ?", models.SLAClockPaused).Count(&pausedCount)
+
+	stats["waiting_on_applicant"] = pausedCount
+
+	// Count escalations
+	escalationStats, err := EscalationStatistics(s.db)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range escalationStats {
+		stats[key] = value
+	}
+
 	return stats, nil
 }
 
@@ -476,6 +512,15 @@ func (s *dashboardService) getDEDEStaffStatistics(userID uint) (map[string]inter
 
 	stats["overdue_tasks"] = overdueTasksCount
 
+	// Count tasks escalated away from or onto this user in the last 7 days
+	var escalatedTasksCount, reassignedToMeCount int64
+	since := time.Now().AddDate(0, 0, -7)
+	s.db.Model(&models.WorkflowEscalation{}).Where("owner_id = ? AND created_at >= ?", userID, since).Count(&escalatedTasksCount)
+	s.db.Model(&models.WorkflowEscalation{}).Where("reassigned_to_id = ? AND created_at >= ?", userID, since).Count(&reassignedToMeCount)
+
+	stats["escalated_tasks"] = escalatedTasksCount
+	stats["reassigned_to_me"] = reassignedToMeCount
+
 	return stats, nil
 }
 
@@ -497,5 +542,14 @@ func (s *dashboardService) getDEDEConsultStatistics(userID uint) (map[string]int
 
 	stats["overdue_tasks"] = overdueTasksCount
 
+	// Count tasks escalated away from or onto this user in the last 7 days
+	var escalatedTasksCount, reassignedToMeCount int64
+	since := time.Now().AddDate(0, 0, -7)
+	s.db.Model(&models.WorkflowEscalation{}).Where("owner_id = ? AND created_at >= ?", userID, since).Count(&escalatedTasksCount)
+	s.db.Model(&models.WorkflowEscalation{}).Where("reassigned_to_id = ? AND created_at >= ?", userID, since).Count(&reassignedToMeCount)
+
+	stats["escalated_tasks"] = escalatedTasksCount
+	stats["reassigned_to_me"] = reassignedToMeCount
+
 	return stats, nil
 }
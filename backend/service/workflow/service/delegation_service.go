@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrDelegationNotFound is returned when a delegation does not exist or is not visible to the caller
+	ErrDelegationNotFound = errors.New("delegation not found")
+	// ErrInvalidDelegation is returned when a delegation cannot be created as requested
+	ErrInvalidDelegation = errors.New("invalid delegation")
+)
+
+// delegationTimeLayout formats delegation periods in notifications
+const delegationTimeLayout = "02/01/2006 15:04"
+
+type DelegationService interface {
+	CreateDelegation(userID uint, userRole models.UserRole, req dto.CreateDelegationRequest) (*models.WorkflowDelegation, error)
+	GetDelegations(userID uint, userRole models.UserRole) ([]models.WorkflowDelegation, error)
+	RevokeDelegation(id, userID uint, userRole models.UserRole) (*models.WorkflowDelegation, error)
+	ExpireDelegations() (int, error)
+}
+
+type delegationService struct {
+	db             *gorm.DB
+	delegationRepo repository.WorkflowDelegationRepository
+	userRepo       repository.UserRepository
+	outboxService  OutboxService
+}
+
+func NewDelegationService(db *gorm.DB, cfg *config.Config) DelegationService {
+	return &delegationService{
+		db:             db,
+		delegationRepo: repository.NewWorkflowDelegationRepository(db),
+		userRepo:       repository.NewUserRepository(db),
+		outboxService:  NewOutboxService(db, cfg),
+	}
+}
+
+// CreateDelegation lets the caller hand their workflow role to another user for a period.
+// Admins may register a delegation for someone else, e.g. a Head who left without doing so.
+func (s *delegationService) CreateDelegation(userID uint, userRole models.UserRole, req dto.CreateDelegationRequest) (*models.WorkflowDelegation, error) {
+	delegatorID := userID
+	if req.DelegatorID != 0 && req.DelegatorID != userID {
+		if userRole != models.RoleAdmin {
+			return nil, fmt.Errorf("%w: only admins can delegate for another user", ErrInvalidDelegation)
+		}
+		delegatorID = req.DelegatorID
+	}
+	if req.DelegateID == delegatorID {
+		return nil, fmt.Errorf("%w: cannot delegate to yourself", ErrInvalidDelegation)
+	}
+
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !req.EndsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidDelegation)
+	}
+	if !req.EndsAt.After(now) {
+		return nil, fmt.Errorf("%w: ends_at must be in the future", ErrInvalidDelegation)
+	}
+
+	delegator, err := s.userRepo.GetByID(delegatorID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: delegator not found", ErrInvalidDelegation)
+	}
+	if delegator.Role == models.RoleUser {
+		return nil, fmt.Errorf("%w: applicants have no workflow permissions to delegate", ErrInvalidDelegation)
+	}
+
+	delegate, err := s.userRepo.GetByID(req.DelegateID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: delegate not found", ErrInvalidDelegation)
+	}
+	if delegate.Role == models.RoleUser || !delegate.IsActive() {
+		return nil, fmt.Errorf("%w: delegate must be an active staff member", ErrInvalidDelegation)
+	}
+
+	delegation := &models.WorkflowDelegation{
+		DelegatorID: delegator.ID,
+		DelegateID:  delegate.ID,
+		Role:        delegator.Role,
+		StartsAt:    startsAt,
+		EndsAt:      req.EndsAt,
+		Reason:      req.Reason,
+		Status:      models.DelegationStatusActive,
+		CreatedByID: userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewWorkflowDelegationRepository(tx).Create(delegation); err != nil {
+			return fmt.Errorf("failed to create delegation: %w", err)
+		}
+
+		return s.notify(tx, delegation, delegate.ID,
+			"ได้รับมอบหมายสิทธิ์",
+			fmt.Sprintf("%s มอบหมายสิทธิ์ %s ให้ท่านดำเนินการแทน ตั้งแต่ %s ถึง %s",
+				delegator.FullName, delegator.Role, startsAt.Format(delegationTimeLayout), req.EndsAt.Format(delegationTimeLayout)),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delegation.Delegator = *delegator
+	delegation.Delegate = *delegate
+	return delegation, nil
+}
+
+// GetDelegations returns the delegations the user gave or received; admins see all of them
+func (s *delegationService) GetDelegations(userID uint, userRole models.UserRole) ([]models.WorkflowDelegation, error) {
+	if userRole == models.RoleAdmin {
+		return s.delegationRepo.GetAll()
+	}
+	return s.delegationRepo.GetByUser(userID)
+}
+
+// RevokeDelegation ends a delegation early. The delegator, the delegate and admins may revoke it.
+func (s *delegationService) RevokeDelegation(id, userID uint, userRole models.UserRole) (*models.WorkflowDelegation, error) {
+	delegation, err := s.delegationRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDelegationNotFound
+		}
+		return nil, fmt.Errorf("failed to get delegation: %w", err)
+	}
+	if userRole != models.RoleAdmin && delegation.DelegatorID != userID && delegation.DelegateID != userID {
+		return nil, ErrDelegationNotFound
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		updated, err := repository.NewWorkflowDelegationRepository(tx).UpdateStatus(delegation.ID, models.DelegationStatusRevoked, &now)
+		if err != nil {
+			return fmt.Errorf("failed to revoke delegation: %w", err)
+		}
+		if !updated {
+			return fmt.Errorf("%w: delegation has already ended", ErrInvalidDelegation)
+		}
+
+		return s.notify(tx, delegation, delegation.DelegateID,
+			"ยกเลิกการมอบหมายสิทธิ์",
+			fmt.Sprintf("การมอบหมายสิทธิ์ %s จาก %s ถูกยกเลิกแล้ว", delegation.Role, delegation.Delegator.FullName),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delegation.Status = models.DelegationStatusRevoked
+	delegation.RevokedAt = &now
+	return delegation, nil
+}
+
+// ExpireDelegations marks delegations whose period has ended as expired and tells both users.
+// Permission checks already ignore them once ends_at passes; this keeps the stored status in step.
+func (s *delegationService) ExpireDelegations() (int, error) {
+	due, err := s.delegationRepo.GetDue(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due delegations: %w", err)
+	}
+
+	expired := 0
+	for i := range due {
+		delegation := &due[i]
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			updated, err := repository.NewWorkflowDelegationRepository(tx).UpdateStatus(delegation.ID, models.DelegationStatusExpired, nil)
+			if err != nil || !updated {
+				return err
+			}
+			expired++
+
+			message := fmt.Sprintf("การมอบหมายสิทธิ์ %s จาก %s ให้ %s สิ้นสุดแล้ว",
+				delegation.Role, delegation.Delegator.FullName, delegation.Delegate.FullName)
+			for _, recipientID := range []uint{delegation.DelegatorID, delegation.DelegateID} {
+				if err := s.notify(tx, delegation, recipientID, "การมอบหมายสิทธิ์สิ้นสุด", message); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire delegation %d: %w", delegation.ID, err)
+		}
+	}
+
+	return expired, nil
+}
+
+func (s *delegationService) notify(tx *gorm.DB, delegation *models.WorkflowDelegation, recipientID uint, title, message string) error {
+	return s.outboxService.EnqueueNotification(tx, models.Notification{
+		Title:       title,
+		Message:     message,
+		Type:        models.NotificationType("delegation"),
+		Priority:    models.PriorityNormal,
+		RecipientID: &recipientID,
+		EntityType:  "workflow_delegation",
+		EntityID:    &delegation.ID,
+		ActionURL:   "/admin-portal/delegations",
+	})
+}
+
+// resolveDelegation picks the role the caller acts with. A caller whose own role cannot take
+// the transition acts with the role of the first active delegation that can, and the request
+// is marked as on behalf of that delegation's delegator. A caller naming a delegator acts for
+// that delegator only.
+func resolveDelegation(tx *gorm.DB, req *dto.WorkflowTransitionRequest, stateMachine *models.WorkflowStateMachine) error {
+	if req.AutoApproved {
+		return nil
+	}
+	if req.OnBehalfOfID == nil && stateMachine.CanTransition(req.FromStatus, req.ToStatus, req.UserRole) {
+		return nil
+	}
+
+	delegations, err := repository.NewWorkflowDelegationRepository(tx).GetActiveForDelegate(req.UserID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get delegations: %w", err)
+	}
+
+	for _, delegation := range delegations {
+		if req.OnBehalfOfID != nil {
+			if delegation.DelegatorID == *req.OnBehalfOfID {
+				req.UserRole = delegation.Role
+				return nil
+			}
+			continue
+		}
+		if stateMachine.CanTransition(req.FromStatus, req.ToStatus, delegation.Role) {
+			delegatorID := delegation.DelegatorID
+			req.UserRole = delegation.Role
+			req.OnBehalfOfID = &delegatorID
+			return nil
+		}
+	}
+
+	if req.OnBehalfOfID != nil {
+		return fmt.Errorf("%w: no active delegation from user %d", ErrInvalidTransition, *req.OnBehalfOfID)
+	}
+	// Without a usable delegation the caller's own role is validated and rejected as usual
+	return nil
+}
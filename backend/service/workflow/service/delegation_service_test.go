@@ -0,0 +1,142 @@
+package service
+
+import (
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDelegation(t *testing.T) {
+	now := time.Now()
+	in := func(d time.Duration) *time.Time {
+		at := now.Add(d)
+		return &at
+	}
+
+	tests := []struct {
+		name     string
+		byAdmin  bool
+		delegate models.UserRole
+		self     bool
+		startsAt *time.Time
+		endsAt   time.Time
+		wantErr  string
+	}{
+		{name: "to a staff member", delegate: models.RoleDEDEStaff, endsAt: now.Add(24 * time.Hour)},
+		{name: "by an admin for another user", byAdmin: true, delegate: models.RoleDEDEStaff, endsAt: now.Add(24 * time.Hour)},
+		{name: "to yourself", self: true, endsAt: now.Add(24 * time.Hour), wantErr: "cannot delegate to yourself"},
+		{name: "to an applicant", delegate: models.RoleUser, endsAt: now.Add(24 * time.Hour), wantErr: "delegate must be an active staff member"},
+		{name: "ending before it starts", delegate: models.RoleDEDEStaff, startsAt: in(48 * time.Hour), endsAt: now.Add(24 * time.Hour), wantErr: "ends_at must be after starts_at"},
+		{name: "ending in the past", delegate: models.RoleDEDEStaff, startsAt: in(-48 * time.Hour), endsAt: now.Add(-time.Hour), wantErr: "ends_at must be in the future"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewDelegationService(db, &config.Config{})
+			head := createTestUser(t, db, models.RoleDEDEHead)
+
+			req := dto.CreateDelegationRequest{StartsAt: tt.startsAt, EndsAt: tt.endsAt}
+			if tt.self {
+				req.DelegateID = head.ID
+			} else {
+				req.DelegateID = createTestUser(t, db, tt.delegate).ID
+			}
+			callerID, callerRole := head.ID, models.RoleDEDEHead
+			if tt.byAdmin {
+				callerID, callerRole = createTestUser(t, db, models.RoleAdmin).ID, models.RoleAdmin
+				req.DelegatorID = head.ID
+			}
+
+			delegation, err := s.CreateDelegation(callerID, callerRole, req)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, ErrInvalidDelegation)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, head.ID, delegation.DelegatorID)
+			assert.Equal(t, models.RoleDEDEHead, delegation.Role, "the delegate acts with the delegator's role")
+			assert.Equal(t, callerID, delegation.CreatedByID)
+		})
+	}
+}
+
+func TestDelegatedTransition(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		startsAt  time.Time
+		endsAt    time.Time
+		revoke    bool
+		expire    bool
+		wantActed bool
+	}{
+		{name: "within the delegation window", startsAt: now.Add(-time.Hour), endsAt: now.Add(time.Hour), wantActed: true},
+		{name: "before the window starts", startsAt: now.Add(time.Hour), endsAt: now.Add(2 * time.Hour)},
+		{name: "after the window ends", startsAt: now.Add(-2 * time.Hour), endsAt: now.Add(-time.Hour)},
+		{name: "after the window ends and the delegation expired", startsAt: now.Add(-2 * time.Hour), endsAt: now.Add(-time.Hour), expire: true},
+		{name: "revoked within the window", startsAt: now.Add(-time.Hour), endsAt: now.Add(time.Hour), revoke: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := newTestTransitionService(db)
+			delegations := NewDelegationService(db, &config.Config{})
+			applicant := createTestUser(t, db, models.RoleUser)
+			admin := createTestUser(t, db, models.RoleAdmin)
+			staff := createTestUser(t, db, models.RoleDEDEStaff)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, models.StatusNewRequest)
+
+			delegation := &models.WorkflowDelegation{
+				DelegatorID: admin.ID,
+				DelegateID:  staff.ID,
+				Role:        models.RoleAdmin,
+				StartsAt:    tt.startsAt,
+				EndsAt:      tt.endsAt,
+				Status:      models.DelegationStatusActive,
+				CreatedByID: admin.ID,
+			}
+			require.NoError(t, db.Omit("Delegator", "Delegate").Create(delegation).Error)
+			if tt.revoke {
+				_, err := delegations.RevokeDelegation(delegation.ID, admin.ID, models.RoleAdmin)
+				require.NoError(t, err)
+			}
+			if tt.expire {
+				expired, err := delegations.ExpireDelegations()
+				require.NoError(t, err)
+				assert.Equal(t, 1, expired)
+			}
+
+			// The staff member accepts a request, which only admins can do
+			result, err := s.ProcessTransition(dto.WorkflowTransitionRequest{
+				RequestID: request.ID,
+				ToStatus:  models.StatusAccepted,
+				UserID:    staff.ID,
+				UserRole:  models.RoleDEDEStaff,
+			})
+			if !tt.wantActed {
+				require.ErrorIs(t, err, ErrInvalidTransition)
+				assert.Equal(t, models.StatusNewRequest, reloadTestRequest(t, db, request.ID).Status)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, result.OnBehalfOfID)
+			assert.Equal(t, admin.ID, *result.OnBehalfOfID)
+
+			var flowLog models.ServiceFlowLog
+			require.NoError(t, db.Where("license_request_id = ?", request.ID).First(&flowLog).Error)
+			require.NotNil(t, flowLog.ChangedBy)
+			assert.Equal(t, staff.ID, *flowLog.ChangedBy)
+			require.NotNil(t, flowLog.OnBehalfOf)
+			assert.Equal(t, admin.ID, *flowLog.OnBehalfOf)
+		})
+	}
+}
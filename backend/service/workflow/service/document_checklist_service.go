@@ -0,0 +1,396 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrDocumentRequirementNotFound is returned when a required document does not exist
+	ErrDocumentRequirementNotFound = errors.New("document requirement not found")
+	// ErrDocumentNotFound is returned when an uploaded document of a request does not exist
+	ErrDocumentNotFound = errors.New("document not found")
+)
+
+// applicantEditStatuses are the statuses in which applicants may change the documents and technical data of a request
+var applicantEditStatuses = []models.RequestStatus{models.StatusDraft, models.StatusReturned}
+
+type DocumentChecklistService interface {
+	CreateRequirement(req dto.DocumentRequirementRequest) (*models.DocumentRequirement, error)
+	UpdateRequirement(req dto.DocumentRequirementRequest) (*models.DocumentRequirement, error)
+	GetRequirements(filter dto.DocumentRequirementFilter) ([]models.DocumentRequirement, error)
+	GetChecklist(requestID, userID uint, role models.UserRole) (*dto.DocumentChecklistResponse, error)
+	UploadDocument(req dto.UploadDocumentRequest) (*dto.DocumentChecklistResponse, error)
+	ReviewDocument(req dto.ReviewDocumentRequest) (*models.Attachment, error)
+}
+
+type documentChecklistService struct {
+	db            *gorm.DB
+	outboxService OutboxService
+}
+
+func NewDocumentChecklistService(db *gorm.DB, cfg *config.Config) DocumentChecklistService {
+	return &documentChecklistService{
+		db:            db,
+		outboxService: NewOutboxService(db, cfg),
+	}
+}
+
+// CreateRequirement adds a document to the checklist of a license type
+func (s *documentChecklistService) CreateRequirement(req dto.DocumentRequirementRequest) (*models.DocumentRequirement, error) {
+	requirement := &models.DocumentRequirement{CreatedByID: &req.UserID}
+	if err := applyDocumentRequirement(requirement, req); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		inactive := !requirement.IsActive
+		if err := tx.Create(requirement).Error; err != nil {
+			return fmt.Errorf("failed to create document requirement: %w", err)
+		}
+		// gorm inserts the column default in place of a false is_active, so an inactive requirement is deactivated after
+		if !inactive {
+			return nil
+		}
+		if err := tx.Model(requirement).Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("failed to create document requirement: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requirement, nil
+}
+
+// UpdateRequirement replaces a required document. Uploaded files stay linked to it, and the
+// checklists of open requests follow the new definition.
+func (s *documentChecklistService) UpdateRequirement(req dto.DocumentRequirementRequest) (*models.DocumentRequirement, error) {
+	var requirement models.DocumentRequirement
+	if err := s.db.First(&requirement, req.RequirementID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDocumentRequirementNotFound
+		}
+		return nil, fmt.Errorf("failed to get document requirement: %w", err)
+	}
+	if err := applyDocumentRequirement(&requirement, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(&requirement).Error; err != nil {
+		return nil, fmt.Errorf("failed to update document requirement: %w", err)
+	}
+	return &requirement, nil
+}
+
+// GetRequirements lists the requirement catalog in checklist order
+func (s *documentChecklistService) GetRequirements(filter dto.DocumentRequirementFilter) ([]models.DocumentRequirement, error) {
+	query := s.db.Model(&models.DocumentRequirement{})
+	if filter.LicenseType != "" {
+		query = query.Where("license_type = ?", filter.LicenseType)
+	}
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+
+	var requirements []models.DocumentRequirement
+	if err := query.Order("license_type, sort_order, id").Find(&requirements).Error; err != nil {
+		return nil, fmt.Errorf("failed to get document requirements: %w", err)
+	}
+
+	// Narrow to what a request of the energy type and capacity would need
+	applicable := make([]models.DocumentRequirement, 0, len(requirements))
+	for i := range requirements {
+		if filter.EnergyType != "" && !requirements[i].MatchesEnergyType(filter.EnergyType) {
+			continue
+		}
+		if filter.Capacity != nil && !requirements[i].CoversCapacity(*filter.Capacity) {
+			continue
+		}
+		applicable = append(applicable, requirements[i])
+	}
+	return applicable, nil
+}
+
+// GetChecklist returns the required documents of a request; applicants only see their own requests
+func (s *documentChecklistService) GetChecklist(requestID, userID uint, role models.UserRole) (*dto.DocumentChecklistResponse, error) {
+	if role == models.RoleUser {
+		var owned int64
+		err := s.db.Table(licenseRequestTable).
+			Where("id = ? AND user_id = ? AND deleted_at IS NULL", requestID, userID).
+			Count(&owned).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request: %w", err)
+		}
+		if owned == 0 {
+			return nil, ErrRequestNotFound
+		}
+	}
+
+	return documentChecklist(s.db, requestID)
+}
+
+// UploadDocument attaches the applicant's file for a required document of their request. A new
+// file replaces the previous one on the checklist, e.g. after an officer rejected it.
+func (s *documentChecklistService) UploadDocument(req dto.UploadDocumentRequest) (*dto.DocumentChecklistResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var request models.LicenseRequest
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", req.RequestID, req.UserID).
+			Take(&request).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRequestNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get request: %w", err)
+		}
+		if !slices.Contains(applicantEditStatuses, request.Status) {
+			return fmt.Errorf("%w: the documents of request %s cannot be changed while it is %s", ErrInvalidTransition, request.RequestNumber, request.Status)
+		}
+
+		var requirement models.DocumentRequirement
+		err = tx.Where("id = ? AND license_type = ? AND is_active = ?", req.RequirementID, request.LicenseType, true).
+			Take(&requirement).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDocumentRequirementNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get document requirement: %w", err)
+		}
+		energyType, err := requestEnergyType(tx, &request)
+		if err != nil {
+			return err
+		}
+		if !requirement.AppliesTo(energyType, assessedCapacity(&request)) {
+			return fmt.Errorf("%w: %s is not required for request %s", ErrInvalidTransition, requirement.Name, request.RequestNumber)
+		}
+
+		attachment := &models.Attachment{
+			FileName:              req.FileName,
+			OriginalName:          req.OriginalName,
+			FilePath:              req.FilePath,
+			FileSize:              req.FileSize,
+			MimeType:              req.MimeType,
+			FileType:              req.FileType,
+			Description:           requirement.Name,
+			EntityType:            "license_request",
+			EntityID:              request.ID,
+			UploaderID:            req.UserID,
+			IsRequired:            true,
+			DocumentRequirementID: &requirement.ID,
+			ReviewStatus:          models.DocumentReviewPending,
+		}
+		if err := repository.NewAttachmentRepository(tx).Create(attachment); err != nil {
+			return fmt.Errorf("failed to create document attachment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return documentChecklist(s.db, req.RequestID)
+}
+
+// ReviewDocument accepts or rejects an uploaded document of a submitted request. The applicant
+// is told why a document was rejected and replaces it once the request is returned to them.
+func (s *documentChecklistService) ReviewDocument(req dto.ReviewDocumentRequest) (*models.Attachment, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if req.Decision == models.DocumentReviewRejected && reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required to reject a document", ErrInvalidTransition)
+	}
+
+	var attachment models.Attachment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND entity_type = ?", req.AttachmentID, "license_request").
+			Take(&attachment).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDocumentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get document: %w", err)
+		}
+
+		var record WorkflowRequestRecord
+		err = tx.Table(licenseRequestTable).
+			Where("id = ? AND deleted_at IS NULL", attachment.EntityID).
+			Take(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRequestNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get request: %w", err)
+		}
+		if record.Status == models.StatusDraft {
+			return fmt.Errorf("%w: request %s has not been submitted", ErrInvalidTransition, record.RequestNumber)
+		}
+
+		now := time.Now()
+		err = tx.Model(&attachment).Updates(map[string]interface{}{
+			"review_status":  req.Decision,
+			"reviewed":       true,
+			"review_notes":   reason,
+			"reviewed_by_id": req.UserID,
+			"reviewed_at":    now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to review document: %w", err)
+		}
+
+		if req.Decision != models.DocumentReviewRejected {
+			return nil
+		}
+		name := attachment.Description
+		if name == "" {
+			name = attachment.OriginalName
+		}
+		return s.outboxService.EnqueueNotification(tx, models.Notification{
+			Title:       "เอกสารไม่ผ่านการตรวจสอบ",
+			Message:     fmt.Sprintf("เอกสาร %s ของคำขอเลขที่ %s ไม่ผ่านการตรวจสอบ: %s", name, record.RequestNumber, reason),
+			Type:        models.NotificationType("document_rejected"),
+			Priority:    models.PriorityHigh,
+			RecipientID: &record.UserID,
+			EntityType:  "license_request",
+			EntityID:    &record.ID,
+			ActionURL:   "/dashboard/licenses",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// applyDocumentRequirement copies an admin's definition onto a requirement
+func applyDocumentRequirement(requirement *models.DocumentRequirement, req dto.DocumentRequirementRequest) error {
+	if req.MinCapacity != nil && req.MaxCapacity != nil && *req.MaxCapacity <= *req.MinCapacity {
+		return fmt.Errorf("%w: max_capacity must be more than min_capacity", ErrInvalidTransition)
+	}
+
+	requirement.Code = strings.TrimSpace(req.Code)
+	requirement.Name = strings.TrimSpace(req.Name)
+	requirement.Description = strings.TrimSpace(req.Description)
+	requirement.LicenseType = req.LicenseType
+	requirement.EnergyType = strings.TrimSpace(req.EnergyType)
+	requirement.MinCapacity = req.MinCapacity
+	requirement.MaxCapacity = req.MaxCapacity
+	requirement.SortOrder = req.SortOrder
+	requirement.IsActive = req.IsActive == nil || *req.IsActive
+	return nil
+}
+
+// documentChecklist matches the active requirements of a request's license type, energy type and
+// capacity with the latest file uploaded for each
+func documentChecklist(tx *gorm.DB, requestID uint) (*dto.DocumentChecklistResponse, error) {
+	var request models.LicenseRequest
+	err := tx.Select("id", "request_number", "license_type", "license_id", "current_capacity", "requested_capacity", "payload").
+		Take(&request, requestID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+	energyType, err := requestEnergyType(tx, &request)
+	if err != nil {
+		return nil, err
+	}
+	capacity := assessedCapacity(&request)
+
+	var requirements []models.DocumentRequirement
+	err = tx.Where("license_type = ? AND is_active = ?", request.LicenseType, true).
+		Order("sort_order, id").
+		Find(&requirements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document requirements: %w", err)
+	}
+
+	var documents []models.Attachment
+	err = tx.Where("entity_type = ? AND entity_id = ? AND document_requirement_id IS NOT NULL", "license_request", request.ID).
+		Where("file_path <> '' AND file_size > 0").
+		Order("id").
+		Find(&documents).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get documents: %w", err)
+	}
+	latest := make(map[uint]*models.Attachment, len(documents))
+	for i := range documents {
+		latest[*documents[i].DocumentRequirementID] = &documents[i]
+	}
+
+	checklist := &dto.DocumentChecklistResponse{
+		RequestID:     request.ID,
+		RequestNumber: request.RequestNumber,
+		LicenseType:   request.LicenseType,
+		EnergyType:    energyType,
+		Capacity:      capacity,
+		Items:         make([]dto.DocumentChecklistItem, 0, len(requirements)),
+	}
+	for _, requirement := range requirements {
+		if !requirement.AppliesTo(energyType, capacity) {
+			continue
+		}
+
+		item := dto.DocumentChecklistItem{
+			RequirementID: requirement.ID,
+			Code:          requirement.Code,
+			Name:          requirement.Name,
+			Description:   requirement.Description,
+			Status:        dto.ChecklistItemMissing,
+			Document:      latest[requirement.ID],
+		}
+		if item.Document != nil {
+			switch item.Document.ReviewStatus {
+			case models.DocumentReviewAccepted:
+				item.Status = dto.ChecklistItemAccepted
+			case models.DocumentReviewRejected:
+				item.Status = dto.ChecklistItemRejected
+			default:
+				item.Status = dto.ChecklistItemPending
+			}
+		}
+
+		switch item.Status {
+		case dto.ChecklistItemMissing:
+			checklist.Missing++
+		case dto.ChecklistItemRejected:
+			checklist.Rejected++
+		}
+		checklist.Items = append(checklist.Items, item)
+	}
+	checklist.Complete = checklist.Missing == 0 && checklist.Rejected == 0
+	return checklist, nil
+}
+
+// requestEnergyType returns the energy type of a request: the one applied for on a new license
+// request, otherwise the one of the license the request refers to
+func requestEnergyType(tx *gorm.DB, request *models.LicenseRequest) (string, error) {
+	if energyType, ok := request.PayloadFields()["energy_type"].(string); ok && energyType != "" {
+		return energyType, nil
+	}
+	if request.LicenseID == nil {
+		return "", nil
+	}
+
+	var license models.License
+	err := tx.Select("id", "energy_type").Take(&license, *request.LicenseID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get license: %w", err)
+	}
+	return license.EnergyType, nil
+}
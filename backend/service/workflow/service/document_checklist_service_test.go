@@ -0,0 +1,154 @@
+package service
+
+import (
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createTestRequirements defines the checklist of new requests: a single line diagram for every
+// request, an environmental report from 10 MW and a wind study for wind farms only
+func createTestRequirements(t *testing.T, db *gorm.DB, s DocumentChecklistService) map[string]*models.DocumentRequirement {
+	t.Helper()
+
+	admin := createTestUser(t, db, models.RoleAdmin)
+	ten := 10.0
+	inactive := false
+	definitions := []dto.DocumentRequirementRequest{
+		{Code: "single_line_diagram", Name: "Single line diagram", LicenseType: models.LicenseTypeNew, SortOrder: 1},
+		{Code: "environmental_report", Name: "Environmental report", LicenseType: models.LicenseTypeNew, MinCapacity: &ten, SortOrder: 2},
+		{Code: "wind_study", Name: "Wind study", LicenseType: models.LicenseTypeNew, EnergyType: "wind", SortOrder: 3},
+		{Code: "site_photos", Name: "Site photos", LicenseType: models.LicenseTypeNew, IsActive: &inactive, SortOrder: 4},
+		{Code: "renewal_form", Name: "Renewal form", LicenseType: models.LicenseTypeRenew, SortOrder: 1},
+	}
+	requirements := make(map[string]*models.DocumentRequirement, len(definitions))
+	for _, definition := range definitions {
+		definition.UserID = admin.ID
+		requirement, err := s.CreateRequirement(definition)
+		require.NoError(t, err)
+		requirements[requirement.Code] = requirement
+	}
+	return requirements
+}
+
+func TestSubmitWithDocumentChecklist(t *testing.T) {
+	tests := []struct {
+		name         string
+		capacity     float64
+		uploaded     []string // Documents uploaded, by code
+		rejected     []string // Uploaded documents an officer rejected in an earlier round
+		replaced     []string // Rejected documents uploaded again
+		wantMissing  int
+		wantRejected int
+	}{
+		{name: "every document uploaded", capacity: 5, uploaded: []string{"single_line_diagram"}},
+		{name: "document missing", capacity: 5, wantMissing: 1},
+		{name: "capacity needs the environmental report", capacity: 20, uploaded: []string{"single_line_diagram"}, wantMissing: 1},
+		{name: "large request with every document", capacity: 20, uploaded: []string{"single_line_diagram", "environmental_report"}},
+		{name: "rejected document", capacity: 5, uploaded: []string{"single_line_diagram"}, rejected: []string{"single_line_diagram"}, wantRejected: 1},
+		{
+			name:     "rejected document replaced",
+			capacity: 5,
+			uploaded: []string{"single_line_diagram"},
+			rejected: []string{"single_line_diagram"},
+			replaced: []string{"single_line_diagram"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewDocumentChecklistService(db, &config.Config{})
+			transitions := newTestTransitionService(db)
+			requirements := createTestRequirements(t, db, s)
+			applicant := createTestUser(t, db, models.RoleUser)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, models.StatusDraft)
+			setTestRequestDetails(t, db, request, nil, 0, tt.capacity, models.NewLicensePayload{EnergyType: "solar", CapacityUnit: "MW"})
+
+			upload := func(code string) {
+				_, err := s.UploadDocument(dto.UploadDocumentRequest{
+					RequestID:     request.ID,
+					RequirementID: requirements[code].ID,
+					UserID:        applicant.ID,
+					FileName:      code + ".pdf",
+					OriginalName:  code + ".pdf",
+					FilePath:      "uploads/" + code + ".pdf",
+					FileSize:      1024,
+					MimeType:      "application/pdf",
+					FileType:      models.AttachmentTypePDF,
+				})
+				require.NoError(t, err)
+			}
+			for _, code := range tt.uploaded {
+				upload(code)
+			}
+			for _, code := range tt.rejected {
+				require.NoError(t, db.Model(&models.Attachment{}).
+					Where("entity_id = ? AND document_requirement_id = ?", request.ID, requirements[code].ID).
+					Updates(map[string]interface{}{"review_status": models.DocumentReviewRejected, "review_notes": "Unreadable scan"}).Error)
+			}
+			for _, code := range tt.replaced {
+				upload(code)
+			}
+
+			checklist, err := s.GetChecklist(request.ID, applicant.ID, models.RoleUser)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMissing, checklist.Missing)
+			assert.Equal(t, tt.wantRejected, checklist.Rejected)
+
+			_, err = transitions.ProcessTransition(dto.WorkflowTransitionRequest{
+				RequestID: request.ID,
+				ToStatus:  models.StatusNewRequest,
+				UserID:    applicant.ID,
+				UserRole:  models.RoleUser,
+			})
+			if tt.wantMissing+tt.wantRejected > 0 {
+				require.ErrorIs(t, err, ErrGuardFailed)
+				assert.Equal(t, []string{GuardDocumentChecklist}, unmetGuards(t, err))
+				assert.False(t, checklist.Complete)
+				assert.Equal(t, models.StatusDraft, reloadTestRequest(t, db, request.ID).Status)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, checklist.Complete)
+			assert.Equal(t, models.StatusNewRequest, reloadTestRequest(t, db, request.ID).Status)
+		})
+	}
+}
+
+func TestGetRequirements(t *testing.T) {
+	five, twenty := 5.0, 20.0
+	active := true
+
+	tests := []struct {
+		name   string
+		filter dto.DocumentRequirementFilter
+		want   []string
+	}{
+		{name: "catalog of a license type", filter: dto.DocumentRequirementFilter{LicenseType: models.LicenseTypeNew}, want: []string{"single_line_diagram", "environmental_report", "wind_study", "site_photos"}},
+		{name: "active only", filter: dto.DocumentRequirementFilter{LicenseType: models.LicenseTypeNew, Active: &active}, want: []string{"single_line_diagram", "environmental_report", "wind_study"}},
+		{name: "small solar farm", filter: dto.DocumentRequirementFilter{LicenseType: models.LicenseTypeNew, Active: &active, EnergyType: "solar", Capacity: &five}, want: []string{"single_line_diagram"}},
+		{name: "large wind farm", filter: dto.DocumentRequirementFilter{LicenseType: models.LicenseTypeNew, Active: &active, EnergyType: "Wind", Capacity: &twenty}, want: []string{"single_line_diagram", "environmental_report", "wind_study"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewDocumentChecklistService(db, &config.Config{})
+			createTestRequirements(t, db, s)
+
+			requirements, err := s.GetRequirements(tt.filter)
+			require.NoError(t, err)
+			codes := make([]string, 0, len(requirements))
+			for _, requirement := range requirements {
+				codes = append(codes, requirement.Code)
+			}
+			assert.Equal(t, tt.want, codes)
+		})
+	}
+}
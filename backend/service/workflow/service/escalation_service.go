@@ -0,0 +1,433 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/utils"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// openTaskStatuses are the task statuses that still wait on their assignee
+var openTaskStatuses = []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress}
+
+type EscalationService interface {
+	RunEscalations() (int, error)
+	GetRequestEscalations(requestID uint) ([]models.WorkflowEscalation, error)
+	GetRecentEscalations(limit int) ([]models.WorkflowEscalation, error)
+	GetEscalationStatistics() (map[string]interface{}, error)
+}
+
+type escalationService struct {
+	db                *gorm.DB
+	definitionService WorkflowDefinitionService
+	outboxService     OutboxService
+}
+
+func NewEscalationService(db *gorm.DB, cfg *config.Config) EscalationService {
+	return &escalationService{
+		db:                db,
+		definitionService: NewWorkflowDefinitionService(db),
+		outboxService:     NewOutboxService(db, cfg),
+	}
+}
+
+// escalationOwner is the officer, or failing that the role, a request is waiting on
+type escalationOwner struct {
+	task *models.TaskAssignment
+	id   *uint
+	role models.UserRole
+}
+
+// RunEscalations applies the escalation rules of every request's current status, as set in the
+// workflow definition the request is pinned to. It returns the number of escalations fired.
+func (s *escalationService) RunEscalations() (int, error) {
+	var groups []struct {
+		LicenseType     string
+		WorkflowVersion int
+		Status          models.RequestStatus
+	}
+	err := s.db.Table(licenseRequestTable).
+		Select("license_type, workflow_version, status").
+		Where("deleted_at IS NULL").
+		Group("license_type, workflow_version, status").
+		Scan(&groups).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to group requests: %w", err)
+	}
+
+	now := time.Now()
+	fired := 0
+	for _, group := range groups {
+		stateMachine, err := s.definitionService.GetStateMachine(group.LicenseType, group.WorkflowVersion)
+		if err != nil {
+			log.Printf("Skipping escalations for %s v%d: %v", group.LicenseType, group.WorkflowVersion, err)
+			continue
+		}
+		rules := stateMachine.GetEscalations(group.Status)
+		if len(rules) == 0 {
+			continue
+		}
+
+		var records []WorkflowRequestRecord
+		err = s.db.Table(licenseRequestTable).
+			Where("license_type = ? AND workflow_version = ? AND status = ? AND deleted_at IS NULL",
+				group.LicenseType, group.WorkflowVersion, group.Status).
+			Find(&records).Error
+		if err != nil {
+			return fired, fmt.Errorf("failed to get requests: %w", err)
+		}
+
+		for i := range records {
+			for ruleIndex := range rules {
+				ok, err := s.escalate(&records[i], stateMachine, rules, ruleIndex, now)
+				if err != nil {
+					log.Printf("Failed to escalate request %d: %v", records[i].ID, err)
+					break
+				}
+				if ok {
+					fired++
+				}
+			}
+		}
+	}
+
+	return fired, nil
+}
+
+// escalate fires one rule for a request if it is due and has not fired in the current round
+func (s *escalationService) escalate(record *WorkflowRequestRecord, stateMachine *models.WorkflowStateMachine, rules []models.WorkflowEscalationRule, ruleIndex int, now time.Time) (bool, error) {
+	rule := rules[ruleIndex]
+
+	fired := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the request so a transition cannot slip in between the check and the escalation
+		var locked WorkflowRequestRecord
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Table(licenseRequestTable).
+			Where("id = ? AND deleted_at IS NULL", record.ID).
+			Take(&locked).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock request: %w", err)
+		}
+		if locked.Status != record.Status {
+			return nil
+		}
+		*record = locked
+
+		entry, err := statusEntry(tx, record)
+		if err != nil {
+			return err
+		}
+		if !now.After(utils.AddBusinessDays(entry.CreatedAt, rule.AfterBusinessDays)) {
+			return nil
+		}
+
+		escalation := &models.WorkflowEscalation{
+			RequestID:         record.ID,
+			LicenseType:       record.LicenseType,
+			Status:            record.Status,
+			RoundID:           entry.ID,
+			RuleIndex:         ruleIndex,
+			Action:            rule.Action,
+			AfterBusinessDays: rule.AfterBusinessDays,
+		}
+
+		// The unique round/rule index makes a rule fire once even if two cron runs overlap
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(escalation)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record escalation: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		owner, err := escalationOwnerOf(tx, record, stateMachine)
+		if err != nil {
+			return err
+		}
+		escalation.OwnerID = owner.id
+		escalation.OwnerRole = owner.role
+
+		// Notify rules climb the supervisor chain one level each unless they name their roles
+		level := 0
+		for _, earlier := range rules[:ruleIndex+1] {
+			if earlier.Action == models.EscalationActionNotify {
+				level++
+			}
+		}
+		notifyRoles := rule.NotifyRoles
+		if len(notifyRoles) == 0 {
+			notifyRoles = []models.UserRole{models.SupervisorRole(owner.role, max(level, 1))}
+		}
+
+		if rule.Action == models.EscalationActionReassign {
+			reassignedTo, err := s.reassign(tx, record, owner, now)
+			if err != nil {
+				return err
+			}
+			if reassignedTo != nil {
+				escalation.ReassignedToID = &reassignedTo.ID
+				escalation.Note = fmt.Sprintf("มอบหมายใหม่ให้ %s", reassignedTo.FullName)
+			} else {
+				escalation.Note = "ไม่พบเจ้าหน้าที่ที่สามารถรับงานแทนได้"
+			}
+		}
+
+		roleNames := make([]string, 0, len(notifyRoles))
+		for _, role := range notifyRoles {
+			roleNames = append(roleNames, string(role))
+		}
+		escalation.NotifiedRoles = strings.Join(roleNames, ",")
+
+		if err := tx.Save(escalation).Error; err != nil {
+			return fmt.Errorf("failed to update escalation: %w", err)
+		}
+
+		if err := s.notifyEscalation(tx, record, escalation, owner, notifyRoles); err != nil {
+			return err
+		}
+
+		fired = true
+		return nil
+	})
+	return fired, err
+}
+
+// escalationOwnerOf finds who the request is waiting on: the open task's assignee, the
+// inspector, or else the first role allowed to move the request on
+func escalationOwnerOf(tx *gorm.DB, record *WorkflowRequestRecord, stateMachine *models.WorkflowStateMachine) (*escalationOwner, error) {
+	var task models.TaskAssignment
+	err := tx.Where("request_id = ? AND status IN ?", record.ID, openTaskStatuses).
+		Order("id DESC").
+		Take(&task).Error
+	if err == nil {
+		return &escalationOwner{task: &task, id: &task.AssignedToID, role: task.AssignedRole}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get open task: %w", err)
+	}
+
+	if record.InspectorID != nil {
+		var inspector models.User
+		if err := tx.Select("id", "role").First(&inspector, *record.InspectorID).Error; err == nil {
+			return &escalationOwner{id: &inspector.ID, role: inspector.Role}, nil
+		}
+	}
+
+	for _, transition := range stateMachine.GetTransitions() {
+		if transition.FromStatus == record.Status && transition.RequiredRole != "" {
+			return &escalationOwner{role: transition.RequiredRole}, nil
+		}
+	}
+	return &escalationOwner{role: models.RoleAdmin}, nil
+}
+
+// reassign hands the owner's open task to the least loaded active colleague with the same role.
+// It returns nil when there is no task or no one to hand it to.
+func (s *escalationService) reassign(tx *gorm.DB, record *WorkflowRequestRecord, owner *escalationOwner, now time.Time) (*models.User, error) {
+	if owner.task == nil {
+		return nil, nil
+	}
+	task := owner.task
+
+	var candidates []models.User
+	err := tx.Select("id", "full_name", "role").
+		Where("role = ? AND status = ? AND id <> ?", task.AssignedRole, models.UserStatusActive, task.AssignedToID).
+		Order("id").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reassignment candidates: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var loads []struct {
+		AssignedToID uint
+		OpenTasks    int64
+	}
+	err = tx.Model(&models.TaskAssignment{}).
+		Select("assigned_to_id, COUNT(*) AS open_tasks").
+		Where("status IN ?", openTaskStatuses).
+		Group("assigned_to_id").
+		Scan(&loads).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open tasks: %w", err)
+	}
+	openTasks := make(map[uint]int64, len(loads))
+	for _, load := range loads {
+		openTasks[load.AssignedToID] = load.OpenTasks
+	}
+
+	candidate := candidates[0]
+	for _, other := range candidates[1:] {
+		if openTasks[other.ID] < openTasks[candidate.ID] {
+			candidate = other
+		}
+	}
+
+	// The update also changes task.Status in memory, so keep the open status for the new task
+	openStatus := task.Status
+	if err := tx.Model(task).Update("status", models.TaskStatusCancelled).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel escalated task: %w", err)
+	}
+
+	reassigned := &models.TaskAssignment{
+		RequestID:       task.RequestID,
+		LicenseType:     task.LicenseType,
+		AssignedToID:    candidate.ID,
+		AssignedByID:    task.AssignedByID,
+		AssignedRole:    task.AssignedRole,
+		TaskType:        task.TaskType,
+		Status:          openStatus,
+		Priority:        models.TaskPriorityHigh,
+		Deadline:        task.Deadline,
+		AppointmentDate: task.AppointmentDate,
+		Comments:        "มอบหมายใหม่อัตโนมัติเนื่องจากเกินกำหนดเวลา",
+	}
+	if err := tx.Create(reassigned).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reassigned task: %w", err)
+	}
+
+	// The request and its reminders follow the task to the new officer
+	if record.InspectorID != nil && *record.InspectorID == task.AssignedToID {
+		update := tx.Table(licenseRequestTable).
+			Where("id = ? AND version = ?", record.ID, record.Version).
+			Updates(map[string]interface{}{
+				"inspector_id": candidate.ID,
+				"version":      record.Version + 1,
+				"updated_at":   now,
+			})
+		if update.Error != nil {
+			return nil, fmt.Errorf("failed to reassign request: %w", update.Error)
+		}
+	}
+	err = tx.Model(&models.DeadlineReminder{}).
+		Where("request_id = ? AND assigned_to_id = ? AND status = ?", record.ID, task.AssignedToID, models.DeadlineReminderStatusActive).
+		Update("assigned_to_id", candidate.ID).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign deadline reminders: %w", err)
+	}
+
+	return &candidate, nil
+}
+
+func (s *escalationService) notifyEscalation(tx *gorm.DB, record *WorkflowRequestRecord, escalation *models.WorkflowEscalation, owner *escalationOwner, notifyRoles []models.UserRole) error {
+	actionURL := fmt.Sprintf("/admin-portal/services/%d", record.ID)
+	notify := func(recipientID *uint, recipientRole *models.UserRole, title, message string) error {
+		return s.outboxService.EnqueueNotification(tx, models.Notification{
+			Title:         title,
+			Message:       message,
+			Type:          models.NotificationType("request_escalated"),
+			Priority:      models.PriorityHigh,
+			RecipientID:   recipientID,
+			RecipientRole: recipientRole,
+			EntityType:    "license_request",
+			EntityID:      &record.ID,
+			ActionURL:     actionURL,
+		})
+	}
+
+	summary := fmt.Sprintf("คำขอเลขที่ %s ค้างอยู่ในสถานะ %s เกิน %d วันทำการ", record.RequestNumber, record.Status, escalation.AfterBusinessDays)
+	if escalation.Note != "" {
+		summary += " (" + escalation.Note + ")"
+	}
+
+	for i := range notifyRoles {
+		if err := notify(nil, &notifyRoles[i], "คำขอถูกยกระดับ", summary); err != nil {
+			return err
+		}
+	}
+	if owner.id != nil {
+		if err := notify(owner.id, nil, "คำขอถูกยกระดับ", summary); err != nil {
+			return err
+		}
+	}
+	if escalation.ReassignedToID != nil {
+		message := fmt.Sprintf("คำขอเลขที่ %s ถูกมอบหมายให้ท่านดำเนินการแทนเนื่องจากเกินกำหนดเวลา", record.RequestNumber)
+		if err := notify(escalation.ReassignedToID, nil, "มอบหมายงานใหม่", message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statusEntry returns the flow log entry that moved the request into its current status.
+// Requests without one (e.g. migrated mid-step) get an entry with ID 0 dated at their last update.
+func statusEntry(tx *gorm.DB, record *WorkflowRequestRecord) (*models.ServiceFlowLog, error) {
+	var flowLog models.ServiceFlowLog
+	err := tx.Select("id", "created_at").
+		Where("license_request_id = ? AND new_status = ?", record.ID, record.Status).
+		Order("id DESC").
+		Take(&flowLog).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &models.ServiceFlowLog{CreatedAt: record.UpdatedAt}, nil
+		}
+		return nil, fmt.Errorf("failed to get status entry: %w", err)
+	}
+	return &flowLog, nil
+}
+
+// GetRequestEscalations returns the escalations of a request, newest first
+func (s *escalationService) GetRequestEscalations(requestID uint) ([]models.WorkflowEscalation, error) {
+	var escalations []models.WorkflowEscalation
+	err := s.db.Preload("Owner").Preload("ReassignedTo").
+		Where("request_id = ?", requestID).
+		Order("created_at DESC").
+		Find(&escalations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalations: %w", err)
+	}
+	return escalations, nil
+}
+
+// GetRecentEscalations returns the latest escalations across all requests
+func (s *escalationService) GetRecentEscalations(limit int) ([]models.WorkflowEscalation, error) {
+	var escalations []models.WorkflowEscalation
+	err := s.db.Preload("Owner").Preload("ReassignedTo").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&escalations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalations: %w", err)
+	}
+	return escalations, nil
+}
+
+// GetEscalationStatistics returns escalation counts for the dashboards
+func (s *escalationService) GetEscalationStatistics() (map[string]interface{}, error) {
+	return EscalationStatistics(s.db)
+}
+
+// EscalationStatistics counts escalations. Requests still waiting in the status they were
+// escalated in count as escalated.
+func EscalationStatistics(db *gorm.DB) (map[string]interface{}, error) {
+	stats := make(map[string]interface{})
+
+	var escalatedRequests int64
+	err := db.Model(&models.WorkflowEscalation{}).
+		Joins("JOIN license_requests ON license_requests.id = workflow_escalations.request_id AND license_requests.status = workflow_escalations.status AND license_requests.deleted_at IS NULL").
+		Distinct("workflow_escalations.request_id").
+		Count(&escalatedRequests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count escalated requests: %w", err)
+	}
+	stats["escalated_requests"] = escalatedRequests
+
+	var lastWeek, reassigned int64
+	since := time.Now().AddDate(0, 0, -7)
+	db.Model(&models.WorkflowEscalation{}).Where("created_at >= ?", since).Count(&lastWeek)
+	db.Model(&models.WorkflowEscalation{}).Where("created_at >= ? AND reassigned_to_id IS NOT NULL", since).Count(&reassigned)
+	stats["escalations_last_7_days"] = lastWeek
+	stats["reassignments_last_7_days"] = reassigned
+
+	return stats, nil
+}
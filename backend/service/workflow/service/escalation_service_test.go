@@ -0,0 +1,133 @@
+package service
+
+import (
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// enterStatusDaysAgo records the flow log entry that moved the request into its status days ago
+func enterStatusDaysAgo(t *testing.T, db *gorm.DB, request *models.LicenseRequest, days int) {
+	t.Helper()
+
+	require.NoError(t, db.Create(&models.ServiceFlowLog{
+		LicenseRequestID: request.ID,
+		NewStatus:        request.Status,
+		LicenseType:      string(request.LicenseType),
+		CreatedAt:        time.Now().AddDate(0, 0, -days),
+	}).Error)
+}
+
+func TestRunEscalations(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        models.RequestStatus
+		days          int // Calendar days in the status
+		colleague     bool
+		wantActions   []string
+		wantNotified  []string
+		wantReassign  bool
+		wantAssignees int
+	}{
+		{name: "not due yet", status: models.StatusAssigned, days: 2, colleague: true},
+		{
+			name:         "notify climbs one level up the chain",
+			status:       models.StatusAssigned,
+			days:         10,
+			colleague:    true,
+			wantActions:  []string{models.EscalationActionNotify},
+			wantNotified: []string{string(models.RoleDEDEHead)},
+		},
+		{
+			name:         "reassign to the least loaded colleague",
+			status:       models.StatusAssigned,
+			days:         21,
+			colleague:    true,
+			wantActions:  []string{models.EscalationActionNotify, models.EscalationActionReassign},
+			wantNotified: []string{string(models.RoleDEDEHead), string(models.RoleDEDEHead)},
+			wantReassign: true,
+		},
+		{
+			name:         "no colleague to reassign to",
+			status:       models.StatusAssigned,
+			days:         21,
+			wantActions:  []string{models.EscalationActionNotify, models.EscalationActionReassign},
+			wantNotified: []string{string(models.RoleDEDEHead), string(models.RoleDEDEHead)},
+		},
+		{
+			name:         "rule naming its own roles",
+			status:       models.StatusForwarded,
+			days:         10,
+			colleague:    true,
+			wantActions:  []string{models.EscalationActionNotify},
+			wantNotified: []string{string(models.RoleAdmin)},
+		},
+		{name: "status without rules", status: models.StatusInspecting, days: 60, colleague: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewEscalationService(db, &config.Config{})
+			applicant := createTestUser(t, db, models.RoleUser)
+			head := createTestUser(t, db, models.RoleDEDEHead)
+			staff := createTestUser(t, db, models.RoleDEDEStaff)
+			var colleague *models.User
+			if tt.colleague {
+				colleague = createTestUser(t, db, models.RoleDEDEStaff)
+			}
+
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, tt.status)
+			require.NoError(t, db.Model(request).Update("inspector_id", staff.ID).Error)
+			task := &models.TaskAssignment{
+				RequestID:    request.ID,
+				LicenseType:  string(request.LicenseType),
+				AssignedToID: staff.ID,
+				AssignedByID: head.ID,
+				AssignedRole: models.RoleDEDEStaff,
+				TaskType:     models.TaskTypeInspection,
+				Status:       models.TaskStatusPending,
+			}
+			require.NoError(t, db.Omit("AssignedTo", "AssignedBy").Create(task).Error)
+			enterStatusDaysAgo(t, db, request, tt.days)
+
+			fired, err := s.RunEscalations()
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.wantActions), fired)
+
+			// Each rule fires once per visit to the status
+			again, err := s.RunEscalations()
+			require.NoError(t, err)
+			assert.Zero(t, again)
+
+			var escalations []models.WorkflowEscalation
+			require.NoError(t, db.Where("request_id = ?", request.ID).Order("rule_index").Find(&escalations).Error)
+			actions := []string{}
+			notified := []string{}
+			for _, escalation := range escalations {
+				actions = append(actions, escalation.Action)
+				notified = append(notified, escalation.NotifiedRoles)
+			}
+			assert.Equal(t, append([]string{}, tt.wantActions...), actions)
+			assert.Equal(t, append([]string{}, tt.wantNotified...), notified)
+
+			var open []models.TaskAssignment
+			require.NoError(t, db.Where("request_id = ? AND status IN ?", request.ID, openTaskStatuses).Find(&open).Error)
+			require.Len(t, open, 1)
+			stored := reloadTestRequest(t, db, request.ID)
+			if tt.wantReassign {
+				assert.Equal(t, colleague.ID, open[0].AssignedToID)
+				assert.Equal(t, colleague.ID, *stored.InspectorID, "the request follows the task")
+				assert.Equal(t, colleague.ID, *escalations[len(escalations)-1].ReassignedToID)
+				return
+			}
+			assert.Equal(t, staff.ID, open[0].AssignedToID)
+			assert.Equal(t, staff.ID, *stored.InspectorID)
+		})
+	}
+}
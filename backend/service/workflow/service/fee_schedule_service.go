@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// feeCurrency is the currency fees are charged in
+const feeCurrency = "THB"
+
+// ErrFeeScheduleNotFound is returned when a fee schedule does not exist
+var ErrFeeScheduleNotFound = errors.New("fee schedule not found")
+
+type FeeScheduleService interface {
+	CreateFeeSchedule(req dto.CreateFeeScheduleRequest) (*models.FeeSchedule, error)
+	DeactivateFeeSchedule(scheduleID uint) (*models.FeeSchedule, error)
+	GetFeeSchedules(filter dto.FeeScheduleFilter) ([]models.FeeSchedule, error)
+	QuoteFee(req dto.FeeQuoteRequest) (*dto.FeeQuoteResponse, error)
+}
+
+type feeScheduleService struct {
+	db *gorm.DB
+}
+
+func NewFeeScheduleService(db *gorm.DB) FeeScheduleService {
+	return &feeScheduleService{db: db}
+}
+
+// CreateFeeSchedule adds the fee of a capacity band. Active bands of a license type may not overlap,
+// so a fee change deactivates the old schedule first.
+func (s *feeScheduleService) CreateFeeSchedule(req dto.CreateFeeScheduleRequest) (*models.FeeSchedule, error) {
+	if req.MaxCapacity != nil && *req.MaxCapacity <= req.MinCapacity {
+		return nil, fmt.Errorf("%w: max_capacity must be more than min_capacity", ErrInvalidTransition)
+	}
+
+	schedule := &models.FeeSchedule{
+		LicenseType: req.LicenseType,
+		MinCapacity: req.MinCapacity,
+		MaxCapacity: req.MaxCapacity,
+		Amount:      roundSatang(req.Amount),
+		Description: strings.TrimSpace(req.Description),
+		IsActive:    true,
+		CreatedByID: &req.UserID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the active bands of the license type so two admins cannot add overlapping ones
+		var active []models.FeeSchedule
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("license_type = ? AND is_active = ?", req.LicenseType, true).
+			Find(&active).Error
+		if err != nil {
+			return fmt.Errorf("failed to get fee schedules: %w", err)
+		}
+		for i := range active {
+			if schedule.Overlaps(&active[i]) {
+				return fmt.Errorf("%w: the band overlaps active fee schedule %d (%s)", ErrInvalidTransition, active[i].ID, feeBand(&active[i]))
+			}
+		}
+
+		if err := tx.Create(schedule).Error; err != nil {
+			return fmt.Errorf("failed to create fee schedule: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeactivateFeeSchedule stops a fee schedule from applying to new invoices. Issued invoices keep their fee.
+func (s *feeScheduleService) DeactivateFeeSchedule(scheduleID uint) (*models.FeeSchedule, error) {
+	var schedule models.FeeSchedule
+	if err := s.db.First(&schedule, scheduleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFeeScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to get fee schedule: %w", err)
+	}
+	if !schedule.IsActive {
+		return nil, fmt.Errorf("%w: fee schedule %d is already inactive", ErrInvalidTransition, schedule.ID)
+	}
+
+	if err := s.db.Model(&schedule).Update("is_active", false).Error; err != nil {
+		return nil, fmt.Errorf("failed to deactivate fee schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// GetFeeSchedules lists fee schedules by license type and band
+func (s *feeScheduleService) GetFeeSchedules(filter dto.FeeScheduleFilter) ([]models.FeeSchedule, error) {
+	query := s.db.Model(&models.FeeSchedule{})
+	if filter.LicenseType != "" {
+		query = query.Where("license_type = ?", filter.LicenseType)
+	}
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+
+	var schedules []models.FeeSchedule
+	if err := query.Order("license_type, min_capacity, id").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to get fee schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// QuoteFee returns the fee a request of a license type and capacity would be invoiced
+func (s *feeScheduleService) QuoteFee(req dto.FeeQuoteRequest) (*dto.FeeQuoteResponse, error) {
+	schedule, err := findFeeSchedule(s.db, req.LicenseType, req.Capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &dto.FeeQuoteResponse{
+		LicenseType: req.LicenseType,
+		Capacity:    req.Capacity,
+		Currency:    feeCurrency,
+	}
+	if schedule != nil {
+		quote.FeeScheduleID = &schedule.ID
+		quote.Amount = schedule.Amount
+		quote.Description = schedule.Description
+	}
+	return quote, nil
+}
+
+// findFeeSchedule returns the active fee schedule whose band covers a capacity, or nil when no fee applies
+func findFeeSchedule(tx *gorm.DB, licenseType models.LicenseType, capacity float64) (*models.FeeSchedule, error) {
+	var schedules []models.FeeSchedule
+	err := tx.Where("license_type = ? AND is_active = ? AND min_capacity <= ?", licenseType, true, capacity).
+		Order("min_capacity DESC, id DESC").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee schedules: %w", err)
+	}
+	for i := range schedules {
+		if schedules[i].Covers(capacity) {
+			return &schedules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// requestFee finds the fee schedule covering the assessed capacity of a request
+func requestFee(tx *gorm.DB, requestID uint) (*models.FeeSchedule, float64, error) {
+	var request models.LicenseRequest
+	err := tx.Select("id", "license_type", "current_capacity", "requested_capacity").Take(&request, requestID).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	capacity := assessedCapacity(&request)
+	schedule, err := findFeeSchedule(tx, request.LicenseType, capacity)
+	return schedule, capacity, err
+}
+
+// assessedCapacity is the capacity a request is assessed on: the requested capacity, or the
+// current capacity for requests that do not change it
+func assessedCapacity(request *models.LicenseRequest) float64 {
+	if request.RequestedCapacity > 0 {
+		return request.RequestedCapacity
+	}
+	return request.CurrentCapacity
+}
+
+// feeBand describes the capacity band of a schedule, e.g. "10 to 50" or "50 and above"
+func feeBand(schedule *models.FeeSchedule) string {
+	if schedule.MaxCapacity == nil {
+		return fmt.Sprintf("%g and above", schedule.MinCapacity)
+	}
+	return fmt.Sprintf("%g to %g", schedule.MinCapacity, *schedule.MaxCapacity)
+}
+
+// roundSatang rounds an amount in baht to whole satang
+func roundSatang(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
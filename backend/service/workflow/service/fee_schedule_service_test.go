@@ -0,0 +1,161 @@
+package service
+
+import (
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createTestFeeBands charges new requests 1,000 baht below 10 MW, 5,000 baht from 10 to 50 MW and 20,000 baht from 50 MW
+func createTestFeeBands(t *testing.T, db *gorm.DB, s FeeScheduleService) []*models.FeeSchedule {
+	t.Helper()
+
+	admin := createTestUser(t, db, models.RoleAdmin)
+	ten, fifty := 10.0, 50.0
+	bands := []dto.CreateFeeScheduleRequest{
+		{LicenseType: models.LicenseTypeNew, MinCapacity: 0, MaxCapacity: &ten, Amount: 1000, UserID: admin.ID},
+		{LicenseType: models.LicenseTypeNew, MinCapacity: 10, MaxCapacity: &fifty, Amount: 5000, UserID: admin.ID},
+		{LicenseType: models.LicenseTypeNew, MinCapacity: 50, Amount: 20000, UserID: admin.ID},
+	}
+	schedules := make([]*models.FeeSchedule, len(bands))
+	for i, band := range bands {
+		schedule, err := s.CreateFeeSchedule(band)
+		require.NoError(t, err)
+		schedules[i] = schedule
+	}
+	return schedules
+}
+
+func TestCreateFeeSchedule(t *testing.T) {
+	five, ten, twenty := 5.0, 10.0, 20.0
+
+	tests := []struct {
+		name        string
+		licenseType models.LicenseType
+		min         float64
+		max         *float64
+		amount      float64
+		wantErr     error
+		wantAmount  float64
+	}{
+		{name: "inside an active band", licenseType: models.LicenseTypeNew, min: 5, max: &twenty, amount: 3000, wantErr: ErrInvalidTransition},
+		{name: "open-ended over an active band", licenseType: models.LicenseTypeNew, min: 60, amount: 3000, wantErr: ErrInvalidTransition},
+		{name: "max not above min", licenseType: models.LicenseTypeRenew, min: 10, max: &five, amount: 3000, wantErr: ErrInvalidTransition},
+		{name: "band of another license type", licenseType: models.LicenseTypeRenew, min: 0, max: &ten, amount: 500, wantAmount: 500},
+		{name: "amount rounded to satang", licenseType: models.LicenseTypeRenew, min: 0, amount: 1234.567, wantAmount: 1234.57},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewFeeScheduleService(db)
+			createTestFeeBands(t, db, s)
+
+			schedule, err := s.CreateFeeSchedule(dto.CreateFeeScheduleRequest{
+				LicenseType: tt.licenseType,
+				MinCapacity: tt.min,
+				MaxCapacity: tt.max,
+				Amount:      tt.amount,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAmount, schedule.Amount)
+			assert.True(t, schedule.IsActive)
+		})
+	}
+
+	t.Run("replaces a deactivated band", func(t *testing.T) {
+		db := openWorkflowTestDB(t)
+		s := NewFeeScheduleService(db)
+		bands := createTestFeeBands(t, db, s)
+
+		_, err := s.DeactivateFeeSchedule(bands[1].ID)
+		require.NoError(t, err)
+		_, err = s.DeactivateFeeSchedule(bands[1].ID)
+		require.ErrorIs(t, err, ErrInvalidTransition)
+
+		fifty := 50.0
+		_, err = s.CreateFeeSchedule(dto.CreateFeeScheduleRequest{LicenseType: models.LicenseTypeNew, MinCapacity: 10, MaxCapacity: &fifty, Amount: 6000})
+		require.NoError(t, err)
+	})
+}
+
+func TestQuoteFee(t *testing.T) {
+	tests := []struct {
+		name        string
+		licenseType models.LicenseType
+		capacity    float64
+		deactivate  int // Band deactivated before the quote, 1-based
+		wantBand    int // Band charged, 1-based; 0 when no fee applies
+		wantAmount  float64
+	}{
+		{name: "no capacity", licenseType: models.LicenseTypeNew, capacity: 0, wantBand: 1, wantAmount: 1000},
+		{name: "just below a band boundary", licenseType: models.LicenseTypeNew, capacity: 9.99, wantBand: 1, wantAmount: 1000},
+		{name: "on a band boundary", licenseType: models.LicenseTypeNew, capacity: 10, wantBand: 2, wantAmount: 5000},
+		{name: "open-ended band", licenseType: models.LicenseTypeNew, capacity: 500, wantBand: 3, wantAmount: 20000},
+		{name: "deactivated band", licenseType: models.LicenseTypeNew, capacity: 20, deactivate: 2},
+		{name: "license type without bands", licenseType: models.LicenseTypeRenew, capacity: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewFeeScheduleService(db)
+			bands := createTestFeeBands(t, db, s)
+			if tt.deactivate > 0 {
+				_, err := s.DeactivateFeeSchedule(bands[tt.deactivate-1].ID)
+				require.NoError(t, err)
+			}
+
+			quote, err := s.QuoteFee(dto.FeeQuoteRequest{LicenseType: tt.licenseType, Capacity: tt.capacity})
+			require.NoError(t, err)
+			assert.Equal(t, feeCurrency, quote.Currency)
+			assert.Equal(t, tt.wantAmount, quote.Amount)
+			if tt.wantBand == 0 {
+				assert.Nil(t, quote.FeeScheduleID)
+				return
+			}
+			require.NotNil(t, quote.FeeScheduleID)
+			assert.Equal(t, bands[tt.wantBand-1].ID, *quote.FeeScheduleID)
+		})
+	}
+}
+
+func TestRequestFee(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      float64
+		requested    float64
+		wantCapacity float64
+		wantAmount   float64
+	}{
+		{name: "assessed on the requested capacity", current: 5, requested: 60, wantCapacity: 60, wantAmount: 20000},
+		{name: "assessed on the current capacity when unchanged", current: 30, wantCapacity: 30, wantAmount: 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			createTestFeeBands(t, db, NewFeeScheduleService(db))
+			applicant := createTestUser(t, db, models.RoleUser)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, models.StatusNewRequest)
+			require.NoError(t, db.Model(request).Updates(map[string]interface{}{
+				"current_capacity":   tt.current,
+				"requested_capacity": tt.requested,
+			}).Error)
+
+			schedule, capacity, err := requestFee(db, request.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCapacity, capacity)
+			require.NotNil(t, schedule)
+			assert.Equal(t, tt.wantAmount, schedule.Amount)
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCertificateNotFound is returned when a license has no certificate yet
+	ErrCertificateNotFound = errors.New("certificate not found")
+	// ErrNoCertificateRequest is returned for licenses without an approved request to attach a certificate to
+	ErrNoCertificateRequest = errors.New("license has no approved request to attach the certificate to")
+)
+
+// certificateSignerTitles are the titles printed under the signature of the officer who approved the request
+var certificateSignerTitles = map[models.UserRole]string{
+	models.RoleDEDEHead:  "หัวหน้าเจ้าหน้าที่ กรมพัฒนาพลังงานทดแทนและอนุรักษ์พลังงาน",
+	models.RoleDEDEStaff: "เจ้าหน้าที่ กรมพัฒนาพลังงานทดแทนและอนุรักษ์พลังงาน",
+	models.RoleAdmin:     "ผู้ดูแลระบบ กรมพัฒนาพลังงานทดแทนและอนุรักษ์พลังงาน",
+}
+
+type LicenseCertificateService interface {
+	GenerateCertificate(licenseID uint) (*models.Attachment, error)
+	GetCertificate(licenseID uint) (*models.Attachment, error)
+}
+
+type licenseCertificateService struct {
+	db          *gorm.DB
+	licenseRepo repository.LicenseRepository
+	uploadDir   string
+	fontPath    string
+	verifyURL   string
+}
+
+func NewLicenseCertificateService(db *gorm.DB, cfg *config.Config) LicenseCertificateService {
+	return &licenseCertificateService{
+		db:          db,
+		licenseRepo: repository.NewLicenseRepository(db),
+		uploadDir:   filepath.Join(cfg.UploadPath, "certificates"),
+		fontPath:    cfg.CertificateFontPath,
+		verifyURL:   strings.TrimRight(cfg.LicenseVerifyURL, "/"),
+	}
+}
+
+// certificateSigner is the officer who approved the request a certificate is issued on
+type certificateSigner struct {
+	User       *models.User
+	OnBehalfOf *models.User // Delegator when the officer approved under a delegation
+}
+
+// GenerateCertificate renders the certificate of a license as it stands now and attaches it to the
+// request that issued or last amended the license. The certificate is signed by the officer who
+// approved that request. Earlier certificates stay attached to their requests.
+func (s *licenseCertificateService) GenerateCertificate(licenseID uint) (*models.Attachment, error) {
+	license, err := s.licenseRepo.GetByID(licenseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLicenseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+
+	requestID := license.LastAmendedRequestID
+	if requestID == nil {
+		requestID = license.IssuedRequestID
+	}
+	if requestID == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoCertificateRequest, license.LicenseNumber)
+	}
+
+	signer, err := s.getSigner(*requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err := utils.NewThaiPDF(s.fontPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.drawCertificate(pdf, license, signer); err != nil {
+		return nil, err
+	}
+	file, err := utils.SavePDF(pdf, s.uploadDir, license.LicenseNumber+".pdf")
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		FileName:     file.FileName,
+		OriginalName: file.OriginalName,
+		FilePath:     file.FilePath,
+		FileSize:     file.FileSize,
+		MimeType:     file.MimeType,
+		FileType:     models.AttachmentTypePDF,
+		Description:  "ใบอนุญาตเลขที่ " + license.LicenseNumber,
+		EntityType:   "license_request",
+		EntityID:     *requestID,
+		UploaderID:   signer.User.ID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewAttachmentRepository(tx).Create(attachment); err != nil {
+			return fmt.Errorf("failed to create certificate attachment: %w", err)
+		}
+		err := tx.Model(&models.License{}).
+			Where("id = ?", license.ID).
+			UpdateColumn("certificate_id", attachment.ID).Error
+		if err != nil {
+			return fmt.Errorf("failed to link certificate to license: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		os.Remove(file.FilePath)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// GetCertificate returns the current certificate attachment of a license
+func (s *licenseCertificateService) GetCertificate(licenseID uint) (*models.Attachment, error) {
+	license, err := s.licenseRepo.GetByID(licenseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLicenseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+	if license.CertificateID == nil {
+		return nil, ErrCertificateNotFound
+	}
+
+	attachment, err := repository.NewAttachmentRepository(s.db).GetByID(*license.CertificateID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCertificateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+	return attachment, nil
+}
+
+// getSigner finds who approved a request from its latest approval in the flow log
+func (s *licenseCertificateService) getSigner(requestID uint) (*certificateSigner, error) {
+	var flowLog models.ServiceFlowLog
+	err := s.db.Preload("ChangedByUser").Preload("OnBehalfOfUser").
+		Where("license_request_id = ? AND new_status = ? AND changed_by IS NOT NULL", requestID, models.StatusApproved).
+		Order("created_at DESC, id DESC").
+		First(&flowLog).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: request %d", ErrNoCertificateRequest, requestID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	if flowLog.ChangedByUser == nil {
+		return nil, fmt.Errorf("approver of request %d not found", requestID)
+	}
+	return &certificateSigner{User: flowLog.ChangedByUser, OnBehalfOf: flowLog.OnBehalfOfUser}, nil
+}
+
+// drawCertificate lays out the certificate template on a single A4 page
+func (s *licenseCertificateService) drawCertificate(pdf *fpdf.Fpdf, license *models.License, signer *certificateSigner) error {
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		loc = time.UTC
+	}
+	thaiDate := func(t time.Time) string {
+		return utils.FormatThaiDate(t.In(loc))
+	}
+
+	pdf.AddPage()
+
+	// Double frame
+	pdf.SetLineWidth(1.2)
+	pdf.Rect(10, 10, 190, 277, "D")
+	pdf.SetLineWidth(0.3)
+	pdf.Rect(13, 13, 184, 271, "D")
+
+	// Issuer and title
+	pdf.SetY(24)
+	pdf.SetFontSize(16)
+	pdf.CellFormat(0, 8, "กระทรวงพลังงาน", "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 8, "กรมพัฒนาพลังงานทดแทนและอนุรักษ์พลังงาน", "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+	pdf.SetFontSize(26)
+	pdf.CellFormat(0, 14, "ใบอนุญาตผลิตพลังงานควบคุม", "", 1, "C", false, 0, "")
+	pdf.SetFontSize(16)
+	pdf.CellFormat(0, 9, "เลขที่ "+license.LicenseNumber, "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.SetFontSize(14)
+	pdf.SetX(25)
+	pdf.MultiCell(160, 7, "อาศัยอำนาจตามพระราชบัญญัติการพัฒนาและส่งเสริมพลังงาน พ.ศ. 2535 กรมพัฒนาพลังงานทดแทนและอนุรักษ์พลังงานออกใบอนุญาตฉบับนี้ให้แก่", "", "L", false)
+	pdf.Ln(4)
+
+	location := license.Location
+	if license.Province != "" {
+		location = strings.TrimSpace(location + " จังหวัด" + license.Province)
+	}
+	capacity := strings.TrimSpace(strconv.FormatFloat(license.Capacity, 'f', -1, 64) + " " + license.CapacityUnit)
+
+	fields := []struct{ label, value string }{
+		{"ผู้รับใบอนุญาต", license.HolderName()},
+		{"ชื่อโครงการ", license.ProjectName},
+		{"สถานที่ตั้ง", location},
+		{"ประเภทพลังงาน", license.EnergyType},
+		{"กำลังการผลิต", capacity},
+		{"วันที่ออกใบอนุญาต", thaiDate(license.IssuedAt)},
+		{"ระยะเวลาอนุญาต", "ตั้งแต่วันที่ " + thaiDate(license.ValidFrom) + " ถึงวันที่ " + thaiDate(license.ValidUntil)},
+	}
+	for _, field := range fields {
+		value := field.value
+		if value == "" {
+			value = "-"
+		}
+		pdf.SetX(25)
+		pdf.CellFormat(45, 8, field.label, "", 0, "L", false, 0, "")
+		pdf.MultiCell(115, 8, value, "", "L", false)
+	}
+	if license.AmendedAt != nil {
+		pdf.SetX(25)
+		pdf.CellFormat(45, 8, "แก้ไขล่าสุดเมื่อ", "", 0, "L", false, 0, "")
+		pdf.MultiCell(115, 8, thaiDate(*license.AmendedAt), "", "L", false)
+	}
+
+	// Signature block
+	lines := []string{"(" + signer.User.FullName + ")", certificateSignerTitles[signer.User.Role]}
+	if signer.OnBehalfOf != nil {
+		lines = append(lines, "ปฏิบัติราชการแทน "+signer.OnBehalfOf.FullName, certificateSignerTitles[signer.OnBehalfOf.Role])
+	}
+	pdf.SetY(200)
+	pdf.SetX(100)
+	pdf.CellFormat(90, 8, "ลงชื่อ ผู้อนุญาต (ลงนามอิเล็กทรอนิกส์)", "", 1, "C", false, 0, "")
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		pdf.SetX(100)
+		pdf.MultiCell(90, 7, line, "", "C", false)
+	}
+
+	// Verification QR code
+	verifyURL := s.verifyURL + "/" + license.VerificationToken
+	if err := utils.DrawQRCode(pdf, verifyURL, 22, 232, 35); err != nil {
+		return err
+	}
+	pdf.SetXY(60, 240)
+	pdf.SetFontSize(12)
+	pdf.MultiCell(130, 6, "ตรวจสอบความถูกต้องของใบอนุญาตได้โดยสแกนคิวอาร์โค้ด หรือที่", "", "L", false)
+	pdf.SetX(60)
+	pdf.SetFontSize(9)
+	pdf.MultiCell(130, 5, verifyURL, "", "L", false)
+
+	return pdf.Error()
+}
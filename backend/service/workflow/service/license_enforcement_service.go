@@ -0,0 +1,791 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/utils"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultEnforcementResponseDays is how long a holder has to respond when the notice sets no deadline
+const defaultEnforcementResponseDays = 15
+
+// EnforcementDecisionDismiss closes a case without action; a Head's other decisions are the enforcement actions
+const EnforcementDecisionDismiss = "dismiss"
+
+// ErrEnforcementNotFound is returned when an enforcement case does not exist or is not visible to the caller
+var ErrEnforcementNotFound = errors.New("enforcement case not found")
+
+// openEnforcementStatuses are the statuses of cases that wait for the holder or for a decision
+var openEnforcementStatuses = []models.EnforcementStatus{
+	models.EnforcementStatusNoticeIssued,
+	models.EnforcementStatusResponded,
+	models.EnforcementStatusResponseOverdue,
+}
+
+var enforcementActionLabels = map[models.EnforcementAction]string{
+	models.EnforcementActionSuspend: "พักใช้ใบอนุญาต",
+	models.EnforcementActionRevoke:  "เพิกถอนใบอนุญาต",
+}
+
+var enforcementActionVerbs = map[models.EnforcementAction]string{
+	models.EnforcementActionSuspend: "suspended",
+	models.EnforcementActionRevoke:  "revoked",
+}
+
+type LicenseEnforcementService interface {
+	IssueNotice(req dto.IssueEnforcementNoticeRequest) (*dto.EnforcementResponse, error)
+	Respond(req dto.RespondEnforcementRequest) (*dto.EnforcementResponse, error)
+	Decide(req dto.DecideEnforcementRequest) (*dto.EnforcementResponse, error)
+	Reinstate(req dto.ReinstateLicenseRequest) (*dto.EnforcementResponse, error)
+	GetEnforcement(enforcementID, userID uint, role models.UserRole) (*dto.EnforcementResponse, error)
+	GetEnforcements(filter dto.EnforcementFilter, userID uint, role models.UserRole) ([]dto.EnforcementResponse, error)
+	RunEnforcementChecks() (*dto.EnforcementRunResult, error)
+}
+
+type licenseEnforcementService struct {
+	db            *gorm.DB
+	licenseRepo   repository.LicenseRepository
+	outboxService OutboxService
+}
+
+func NewLicenseEnforcementService(db *gorm.DB, cfg *config.Config) LicenseEnforcementService {
+	return &licenseEnforcementService{
+		db:            db,
+		licenseRepo:   repository.NewLicenseRepository(db),
+		outboxService: NewOutboxService(db, cfg),
+	}
+}
+
+// IssueNotice opens an enforcement case against a license and gives the holder notice of the
+// proposed action and the deadline to respond. A license has at most one open case.
+func (s *licenseEnforcementService) IssueNotice(req dto.IssueEnforcementNoticeRequest) (*dto.EnforcementResponse, error) {
+	now := time.Now()
+	grounds := strings.TrimSpace(req.Grounds)
+	if grounds == "" {
+		return nil, fmt.Errorf("%w: the grounds of the notice are required", ErrInvalidTransition)
+	}
+	if _, ok := enforcementActionLabels[req.Action]; !ok {
+		return nil, fmt.Errorf("%w: unknown enforcement action %q", ErrInvalidTransition, req.Action)
+	}
+	deadline := now.AddDate(0, 0, defaultEnforcementResponseDays)
+	if req.ResponseDeadline != nil {
+		if !req.ResponseDeadline.After(now) {
+			return nil, fmt.Errorf("%w: the response deadline must be in the future", ErrInvalidTransition)
+		}
+		deadline = *req.ResponseDeadline
+	}
+
+	var enforcement *models.LicenseEnforcement
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		license, err := lockEnforcedLicense(tx, req.LicenseID)
+		if err != nil {
+			return err
+		}
+		if err := checkEnforceable(license, req.Action, now); err != nil {
+			return err
+		}
+
+		var open int64
+		err = tx.Model(&models.LicenseEnforcement{}).
+			Where("license_id = ? AND status IN ?", license.ID, openEnforcementStatuses).
+			Count(&open).Error
+		if err != nil {
+			return fmt.Errorf("failed to get open enforcement cases: %w", err)
+		}
+		if open > 0 {
+			return fmt.Errorf("%w: license %s already has an open enforcement case", ErrInvalidTransition, license.LicenseNumber)
+		}
+
+		caseNumber, err := nextCaseNumber(tx, now)
+		if err != nil {
+			return err
+		}
+		enforcement = &models.LicenseEnforcement{
+			CaseNumber:       caseNumber,
+			LicenseID:        license.ID,
+			ProposedAction:   req.Action,
+			Status:           models.EnforcementStatusNoticeIssued,
+			Grounds:          grounds,
+			ResponseDeadline: deadline,
+			IssuedByID:       req.UserID,
+		}
+		if err := tx.Create(enforcement).Error; err != nil {
+			return fmt.Errorf("failed to create enforcement case: %w", err)
+		}
+		if err := saveEnforcementFiles(tx, models.EnforcementNoticeAttachmentEntityType, enforcement.ID, req.UserID, req.Files, "หลักฐานประกอบหนังสือแจ้ง"); err != nil {
+			return err
+		}
+		if err := recordEnforcementEvent(tx, enforcement, nil, models.EnforcementEventNoticeIssued, license.Status, license.Status, &req.UserID, grounds); err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("กรมพัฒนาพลังงานทดแทนและอนุรักษ์พลังงานพิจารณา%sเลขที่ %s (เรื่องเลขที่ %s) เนื่องจาก: %s กรุณาชี้แจงภายในวันที่ %s",
+			enforcementActionLabels[req.Action], license.LicenseNumber, caseNumber, grounds, utils.FormatThaiDate(deadline))
+		return s.notifyHolder(tx, enforcement, license, "แจ้งให้ชี้แจงก่อนพิจารณา"+enforcementActionLabels[req.Action], message,
+			models.NotificationType("enforcement_notice"), models.PriorityCritical)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.enforcementResponse(enforcement.ID)
+}
+
+// Respond records the holder's response to a notice, which lets a DEDE Head decide the case
+func (s *licenseEnforcementService) Respond(req dto.RespondEnforcementRequest) (*dto.EnforcementResponse, error) {
+	now := time.Now()
+	response := strings.TrimSpace(req.Response)
+	if response == "" {
+		return nil, fmt.Errorf("%w: the response is required", ErrInvalidTransition)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		enforcement, err := lockEnforcement(tx, req.EnforcementID)
+		if err != nil {
+			return err
+		}
+		license, err := lockEnforcedLicense(tx, enforcement.LicenseID)
+		if err != nil {
+			return err
+		}
+		corporateIDs, err := repository.NewLicenseRepository(tx).GetCorporateIDs(req.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get corporates: %w", err)
+		}
+		if !license.IsHeldBy(req.UserID, corporateIDs) {
+			return ErrEnforcementNotFound
+		}
+		if !enforcement.CanRespond(now) {
+			if enforcement.Status == models.EnforcementStatusNoticeIssued {
+				return fmt.Errorf("%w: the response deadline of case %s passed on %s", ErrInvalidTransition, enforcement.CaseNumber, utils.FormatThaiDate(enforcement.ResponseDeadline))
+			}
+			return fmt.Errorf("%w: case %s is %s", ErrInvalidTransition, enforcement.CaseNumber, enforcement.Status)
+		}
+
+		from := enforcement.Status
+		err = tx.Model(enforcement).Updates(map[string]interface{}{
+			"status":          models.EnforcementStatusResponded,
+			"response":        response,
+			"responded_by_id": req.UserID,
+			"responded_at":    now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to record response: %w", err)
+		}
+		enforcement.Status = models.EnforcementStatusResponded
+		if err := saveEnforcementFiles(tx, models.EnforcementResponseAttachmentEntityType, enforcement.ID, req.UserID, req.Files, "เอกสารประกอบคำชี้แจง"); err != nil {
+			return err
+		}
+		if err := recordEnforcementEvent(tx, enforcement, &from, models.EnforcementEventResponded, license.Status, license.Status, &req.UserID, response); err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("ผู้รับใบอนุญาตเลขที่ %s ชี้แจงเรื่องเลขที่ %s แล้ว รอการพิจารณาของหัวหน้าเจ้าหน้าที่", license.LicenseNumber, enforcement.CaseNumber)
+		return s.notifyOfficers(tx, enforcement, "ผู้รับใบอนุญาตชี้แจงแล้ว", message,
+			models.NotificationType("enforcement_response"), models.PriorityHigh)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.enforcementResponse(req.EnforcementID)
+}
+
+// Decide suspends or revokes the license, or dismisses the case. A Head decides once the holder
+// has responded or the response deadline has passed.
+func (s *licenseEnforcementService) Decide(req dto.DecideEnforcementRequest) (*dto.EnforcementResponse, error) {
+	now := time.Now()
+	if req.SuspendedUntil != nil {
+		if req.Decision != string(models.EnforcementActionSuspend) {
+			return nil, fmt.Errorf("%w: only a suspension has an end", ErrInvalidTransition)
+		}
+		if !req.SuspendedUntil.After(now) {
+			return nil, fmt.Errorf("%w: the suspension must end in the future", ErrInvalidTransition)
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		enforcement, err := lockEnforcement(tx, req.EnforcementID)
+		if err != nil {
+			return err
+		}
+		if !enforcement.CanDecide(now) {
+			if enforcement.Status == models.EnforcementStatusNoticeIssued {
+				return fmt.Errorf("%w: the holder can respond to case %s until %s", ErrInvalidTransition, enforcement.CaseNumber, utils.FormatThaiDate(enforcement.ResponseDeadline))
+			}
+			return fmt.Errorf("%w: case %s is already %s", ErrInvalidTransition, enforcement.CaseNumber, enforcement.Status)
+		}
+		license, err := lockEnforcedLicense(tx, enforcement.LicenseID)
+		if err != nil {
+			return err
+		}
+
+		from := enforcement.Status
+		licenseBefore := license.Status
+		updates := map[string]interface{}{
+			"decision_notes": strings.TrimSpace(req.Notes),
+			"decided_by_id":  req.UserID,
+			"decided_at":     now,
+		}
+		var title, message string
+		if req.Decision == EnforcementDecisionDismiss {
+			updates["status"] = models.EnforcementStatusDismissed
+			title = "ยุติเรื่องการพิจารณาใบอนุญาต"
+			message = fmt.Sprintf("เรื่องเลขที่ %s เกี่ยวกับใบอนุญาตเลขที่ %s ได้รับการพิจารณาให้ยุติเรื่อง ใบอนุญาตยังคงมีผลตามเดิม", enforcement.CaseNumber, license.LicenseNumber)
+		} else {
+			action := models.EnforcementAction(req.Decision)
+			if err := checkEnforceable(license, action, now); err != nil {
+				return err
+			}
+			if err := applyEnforcement(tx, license, action, now); err != nil {
+				return err
+			}
+			updates["decision"] = action
+			updates["previous_status"] = licenseBefore
+			if action == models.EnforcementActionSuspend {
+				updates["status"] = models.EnforcementStatusSuspended
+				updates["suspended_until"] = req.SuspendedUntil
+			} else {
+				updates["status"] = models.EnforcementStatusRevoked
+			}
+
+			title = enforcementActionLabels[action]
+			message = fmt.Sprintf("ใบอนุญาตเลขที่ %s ถูก%s ตามเรื่องเลขที่ %s", license.LicenseNumber, enforcementActionLabels[action], enforcement.CaseNumber)
+			if req.SuspendedUntil != nil {
+				message += " จนถึงวันที่ " + utils.FormatThaiDate(*req.SuspendedUntil)
+			}
+		}
+		if notes := strings.TrimSpace(req.Notes); notes != "" {
+			message += ": " + notes
+		}
+
+		if err := tx.Model(enforcement).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to record decision: %w", err)
+		}
+		enforcement.Status = updates["status"].(models.EnforcementStatus)
+		if err := recordEnforcementEvent(tx, enforcement, &from, models.EnforcementEventDecided, licenseBefore, license.Status, &req.UserID, req.Notes); err != nil {
+			return err
+		}
+		if err := s.notifyHolder(tx, enforcement, license, title, message,
+			models.NotificationType("enforcement_decision"), models.PriorityCritical); err != nil {
+			return err
+		}
+		return s.notifyIssuer(tx, enforcement, title, message, models.NotificationType("enforcement_decision"))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.enforcementResponse(req.EnforcementID)
+}
+
+// Reinstate lifts the suspension of a license before its end, or when the suspension was open-ended
+func (s *licenseEnforcementService) Reinstate(req dto.ReinstateLicenseRequest) (*dto.EnforcementResponse, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		enforcement, err := lockEnforcement(tx, req.EnforcementID)
+		if err != nil {
+			return err
+		}
+		return s.reinstate(tx, enforcement, &req.UserID, strings.TrimSpace(req.Notes), time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.enforcementResponse(req.EnforcementID)
+}
+
+// GetEnforcement returns a case with its files and history; applicants only see cases against licenses they hold
+func (s *licenseEnforcementService) GetEnforcement(enforcementID, userID uint, role models.UserRole) (*dto.EnforcementResponse, error) {
+	response, err := s.enforcementResponse(enforcementID)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.RoleUser {
+		license, err := s.licenseRepo.GetByID(response.LicenseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get license: %w", err)
+		}
+		corporateIDs, err := s.licenseRepo.GetCorporateIDs(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get corporates: %w", err)
+		}
+		if !license.IsHeldBy(userID, corporateIDs) {
+			return nil, ErrEnforcementNotFound
+		}
+	}
+	return response, nil
+}
+
+// GetEnforcements lists cases, newest first; applicants only see cases against licenses they hold
+func (s *licenseEnforcementService) GetEnforcements(filter dto.EnforcementFilter, userID uint, role models.UserRole) ([]dto.EnforcementResponse, error) {
+	query := s.enforcementQuery()
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Open {
+		query = query.Where("status IN ?", openEnforcementStatuses)
+	}
+	if filter.LicenseID != 0 {
+		query = query.Where("license_id = ?", filter.LicenseID)
+	}
+	if role == models.RoleUser {
+		corporateIDs, err := s.licenseRepo.GetCorporateIDs(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get corporates: %w", err)
+		}
+		held := s.db.Model(&models.License{}).Select("id").
+			Where("holder_user_id = ? OR holder_corporate_id IN ?", userID, corporateIDs)
+		query = query.Where("license_id IN (?)", held)
+	}
+
+	var enforcements []models.LicenseEnforcement
+	if err := query.Order("created_at DESC, id DESC").Find(&enforcements).Error; err != nil {
+		return nil, fmt.Errorf("failed to get enforcement cases: %w", err)
+	}
+	return s.enforcementResponses(enforcements)
+}
+
+// RunEnforcementChecks marks notices whose response deadline passed without a response as overdue,
+// so that a Head can decide them, and reinstates licenses whose suspension has ended
+func (s *licenseEnforcementService) RunEnforcementChecks() (*dto.EnforcementRunResult, error) {
+	now := time.Now()
+	result := &dto.EnforcementRunResult{}
+
+	var overdueIDs []uint
+	err := s.db.Model(&models.LicenseEnforcement{}).
+		Where("status = ? AND response_deadline < ?", models.EnforcementStatusNoticeIssued, now).
+		Order("id").
+		Pluck("id", &overdueIDs).Error
+	if err != nil {
+		return result, fmt.Errorf("failed to get overdue notices: %w", err)
+	}
+	for _, id := range overdueIDs {
+		done, err := s.markOverdue(id, now)
+		if err != nil {
+			log.Printf("Failed to mark enforcement case %d overdue: %v", id, err)
+			continue
+		}
+		if done {
+			result.Overdue++
+		}
+	}
+
+	var endedIDs []uint
+	err = s.db.Model(&models.LicenseEnforcement{}).
+		Where("status = ? AND suspended_until IS NOT NULL AND suspended_until < ?", models.EnforcementStatusSuspended, now).
+		Order("id").
+		Pluck("id", &endedIDs).Error
+	if err != nil {
+		return result, fmt.Errorf("failed to get ended suspensions: %w", err)
+	}
+	for _, id := range endedIDs {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			enforcement, err := lockEnforcement(tx, id)
+			if err != nil {
+				return err
+			}
+			return s.reinstate(tx, enforcement, nil, "สิ้นสุดระยะเวลาพักใช้ใบอนุญาต", now)
+		})
+		switch {
+		case err == nil:
+			result.Reinstated++
+		case errors.Is(err, ErrInvalidTransition):
+			// A Head reinstated the license meanwhile
+		default:
+			log.Printf("Failed to end suspension of enforcement case %d: %v", id, err)
+		}
+	}
+
+	return result, nil
+}
+
+// markOverdue moves a notice past its response deadline to response_overdue and tells the officers.
+// It returns false when the holder responded or another run got to the case first.
+func (s *licenseEnforcementService) markOverdue(enforcementID uint, now time.Time) (bool, error) {
+	marked := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		enforcement, err := lockEnforcement(tx, enforcementID)
+		if err != nil {
+			return err
+		}
+		if enforcement.Status != models.EnforcementStatusNoticeIssued || !now.After(enforcement.ResponseDeadline) {
+			return nil
+		}
+		var license models.License
+		if err := tx.First(&license, enforcement.LicenseID).Error; err != nil {
+			return fmt.Errorf("failed to get license: %w", err)
+		}
+
+		from := enforcement.Status
+		if err := tx.Model(enforcement).Update("status", models.EnforcementStatusResponseOverdue).Error; err != nil {
+			return fmt.Errorf("failed to mark case overdue: %w", err)
+		}
+		enforcement.Status = models.EnforcementStatusResponseOverdue
+		if err := recordEnforcementEvent(tx, enforcement, &from, models.EnforcementEventResponseOverdue, license.Status, license.Status, nil, "พ้นกำหนดชี้แจง"); err != nil {
+			return err
+		}
+		marked = true
+
+		message := fmt.Sprintf("ผู้รับใบอนุญาตเลขที่ %s ไม่ได้ชี้แจงเรื่องเลขที่ %s ภายในวันที่ %s รอการพิจารณาของหัวหน้าเจ้าหน้าที่",
+			license.LicenseNumber, enforcement.CaseNumber, utils.FormatThaiDate(enforcement.ResponseDeadline))
+		return s.notifyOfficers(tx, enforcement, "พ้นกำหนดชี้แจง", message,
+			models.NotificationType("enforcement_response_overdue"), models.PriorityHigh)
+	})
+	return marked, err
+}
+
+// reinstate restores the status a suspended license had before the suspension. actorID is nil
+// when the suspension period ended.
+func (s *licenseEnforcementService) reinstate(tx *gorm.DB, enforcement *models.LicenseEnforcement, actorID *uint, notes string, now time.Time) error {
+	if enforcement.Status != models.EnforcementStatusSuspended {
+		return fmt.Errorf("%w: case %s is %s, not suspended", ErrInvalidTransition, enforcement.CaseNumber, enforcement.Status)
+	}
+	license, err := lockEnforcedLicense(tx, enforcement.LicenseID)
+	if err != nil {
+		return err
+	}
+	if license.Status != models.LicenseStatusSuspended {
+		return fmt.Errorf("%w: license %s is %s, not suspended", ErrInvalidTransition, license.LicenseNumber, license.Status)
+	}
+
+	licenseBefore := license.Status
+	restored := enforcement.PreviousStatus
+	if restored == "" || restored == models.LicenseStatusSuspended {
+		restored = models.LicenseStatusActive
+	}
+	// A license that expired while suspended is expired by the next expiry run
+	if err := tx.Model(license).Update("status", restored).Error; err != nil {
+		return fmt.Errorf("failed to reinstate license: %w", err)
+	}
+	license.Status = restored
+
+	from := enforcement.Status
+	err = tx.Model(enforcement).Updates(map[string]interface{}{
+		"status":        models.EnforcementStatusReinstated,
+		"reinstated_at": now,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record reinstatement: %w", err)
+	}
+	enforcement.Status = models.EnforcementStatusReinstated
+	if err := recordEnforcementEvent(tx, enforcement, &from, models.EnforcementEventReinstated, licenseBefore, license.Status, actorID, notes); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("ใบอนุญาตเลขที่ %s พ้นจากการพักใช้และกลับมามีผลใช้บังคับตามเรื่องเลขที่ %s", license.LicenseNumber, enforcement.CaseNumber)
+	return s.notifyHolder(tx, enforcement, license, "คืนสิทธิ์ใบอนุญาต", message,
+		models.NotificationType("enforcement_reinstated"), models.PriorityHigh)
+}
+
+// checkEnforceable reports why an action cannot be taken against a license: only a license in force
+// can be suspended, and only one in force or suspended can be revoked
+func checkEnforceable(license *models.License, action models.EnforcementAction, now time.Time) error {
+	status := license.CurrentStatus(now)
+	switch {
+	case status == models.LicenseStatusActive:
+		return nil
+	case status == models.LicenseStatusSuspended && action == models.EnforcementActionRevoke:
+		return nil
+	}
+	return fmt.Errorf("%w: license %s is %s and cannot be %s", ErrInvalidTransition, license.LicenseNumber, status, enforcementActionVerbs[action])
+}
+
+// applyEnforcement changes the status of a locked license; a revoked license licenses no capacity
+func applyEnforcement(tx *gorm.DB, license *models.License, action models.EnforcementAction, now time.Time) error {
+	status := models.LicenseStatusSuspended
+	if action == models.EnforcementActionRevoke {
+		status = models.LicenseStatusRevoked
+	}
+	if err := tx.Model(license).Update("status", status).Error; err != nil {
+		return fmt.Errorf("failed to update license: %w", err)
+	}
+	license.Status = status
+
+	if action != models.EnforcementActionRevoke {
+		return nil
+	}
+	err := repository.NewLicenseRepository(tx).AddCapacityEntry(&models.LicenseCapacityEntry{
+		LicenseID:        license.ID,
+		EntryType:        models.CapacityEntryRevoked,
+		PreviousCapacity: license.Capacity,
+		Capacity:         0,
+		CapacityUnit:     license.CapacityUnit,
+		RecordedAt:       now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record capacity change: %w", err)
+	}
+	return nil
+}
+
+// lockEnforcement loads a case for update so that responses, decisions and runs do not interleave
+func lockEnforcement(tx *gorm.DB, enforcementID uint) (*models.LicenseEnforcement, error) {
+	var enforcement models.LicenseEnforcement
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&enforcement, enforcementID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEnforcementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enforcement case: %w", err)
+	}
+	return &enforcement, nil
+}
+
+// lockEnforcedLicense loads the license of a case for update, as approvals of requests do
+func lockEnforcedLicense(tx *gorm.DB, licenseID uint) (*models.License, error) {
+	var license models.License
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&license, licenseID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLicenseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+	return &license, nil
+}
+
+// nextCaseNumber numbers enforcement cases per year, ENF-YYYY-XXXX
+func nextCaseNumber(tx *gorm.DB, now time.Time) (string, error) {
+	yearStart := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+	var count int64
+	err := tx.Model(&models.LicenseEnforcement{}).
+		Where("created_at >= ?", yearStart).
+		Count(&count).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to count enforcement cases: %w", err)
+	}
+	return fmt.Sprintf("ENF-%d-%04d", now.Year(), count+1), nil
+}
+
+// recordEnforcementEvent adds an entry to the history of a case, which has moved from from to its current status
+func recordEnforcementEvent(tx *gorm.DB, enforcement *models.LicenseEnforcement, from *models.EnforcementStatus, eventType models.EnforcementEventType, licenseBefore, licenseAfter models.LicenseStatus, actorID *uint, notes string) error {
+	event := &models.LicenseEnforcementEvent{
+		EnforcementID:       enforcement.ID,
+		EventType:           eventType,
+		FromStatus:          from,
+		ToStatus:            enforcement.Status,
+		LicenseStatusBefore: licenseBefore,
+		LicenseStatusAfter:  licenseAfter,
+		ActorID:             actorID,
+		Notes:               strings.TrimSpace(notes),
+	}
+	if err := tx.Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record enforcement history: %w", err)
+	}
+	return nil
+}
+
+// saveEnforcementFiles attaches the files uploaded with a notice or a response to the case
+func saveEnforcementFiles(tx *gorm.DB, entityType string, enforcementID, uploaderID uint, files []dto.EnforcementFile, description string) error {
+	for _, file := range files {
+		attachment := &models.Attachment{
+			FileName:     file.FileName,
+			OriginalName: file.OriginalName,
+			FilePath:     file.FilePath,
+			FileSize:     file.FileSize,
+			MimeType:     file.MimeType,
+			FileType:     file.FileType,
+			Description:  description,
+			EntityType:   entityType,
+			EntityID:     enforcementID,
+			UploaderID:   uploaderID,
+		}
+		if err := tx.Create(attachment).Error; err != nil {
+			return fmt.Errorf("failed to save enforcement attachment: %w", err)
+		}
+	}
+	return nil
+}
+
+// notifyHolder queues a notification about a case for the license holder
+func (s *licenseEnforcementService) notifyHolder(tx *gorm.DB, enforcement *models.LicenseEnforcement, license *models.License, title, message string, notifType models.NotificationType, priority models.NotificationPriority) error {
+	return s.outboxService.EnqueueNotification(tx, models.Notification{
+		Title:       title,
+		Message:     message,
+		Type:        notifType,
+		Priority:    priority,
+		RecipientID: &license.HolderUserID,
+		EntityType:  "license_enforcement",
+		EntityID:    &enforcement.ID,
+		ActionURL:   "/dashboard/licenses",
+	})
+}
+
+// notifyOfficers queues a notification for the DEDE Heads, who decide the case, and for the officer who issued the notice
+func (s *licenseEnforcementService) notifyOfficers(tx *gorm.DB, enforcement *models.LicenseEnforcement, title, message string, notifType models.NotificationType, priority models.NotificationPriority) error {
+	role := models.RoleDEDEHead
+	err := s.outboxService.EnqueueNotification(tx, models.Notification{
+		Title:         title,
+		Message:       message,
+		Type:          notifType,
+		Priority:      priority,
+		RecipientRole: &role,
+		EntityType:    "license_enforcement",
+		EntityID:      &enforcement.ID,
+		ActionURL:     fmt.Sprintf("/admin-portal/enforcements/%d", enforcement.ID),
+	})
+	if err != nil {
+		return err
+	}
+	return s.notifyIssuer(tx, enforcement, title, message, notifType)
+}
+
+// notifyIssuer queues a notification for the officer who issued the notice, unless the DEDE Heads were told already
+func (s *licenseEnforcementService) notifyIssuer(tx *gorm.DB, enforcement *models.LicenseEnforcement, title, message string, notifType models.NotificationType) error {
+	var issuer models.User
+	if err := tx.Select("id", "role").Take(&issuer, enforcement.IssuedByID).Error; err != nil {
+		return fmt.Errorf("failed to get issuing officer: %w", err)
+	}
+	if issuer.Role == models.RoleDEDEHead {
+		return nil
+	}
+	return s.outboxService.EnqueueNotification(tx, models.Notification{
+		Title:       title,
+		Message:     message,
+		Type:        notifType,
+		Priority:    models.PriorityNormal,
+		RecipientID: &issuer.ID,
+		EntityType:  "license_enforcement",
+		EntityID:    &enforcement.ID,
+		ActionURL:   fmt.Sprintf("/admin-portal/enforcements/%d", enforcement.ID),
+	})
+}
+
+func (s *licenseEnforcementService) enforcementQuery() *gorm.DB {
+	return s.db.Preload("License.HolderUser").Preload("License.HolderCorporate").
+		Preload("IssuedBy").Preload("RespondedBy").Preload("DecidedBy")
+}
+
+// enforcementResponse returns a single case with its history
+func (s *licenseEnforcementService) enforcementResponse(enforcementID uint) (*dto.EnforcementResponse, error) {
+	var enforcement models.LicenseEnforcement
+	err := s.enforcementQuery().Take(&enforcement, enforcementID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEnforcementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enforcement case: %w", err)
+	}
+
+	responses, err := s.enforcementResponses([]models.LicenseEnforcement{enforcement})
+	if err != nil {
+		return nil, err
+	}
+	response := &responses[0]
+
+	var events []models.LicenseEnforcementEvent
+	err = s.db.Preload("Actor").
+		Where("enforcement_id = ?", enforcementID).
+		Order("created_at, id").
+		Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enforcement history: %w", err)
+	}
+	response.Events = make([]dto.EnforcementEventResponse, 0, len(events))
+	for _, event := range events {
+		response.Events = append(response.Events, dto.EnforcementEventResponse{
+			ID:                  event.ID,
+			EventType:           event.EventType,
+			FromStatus:          event.FromStatus,
+			ToStatus:            event.ToStatus,
+			LicenseStatusBefore: event.LicenseStatusBefore,
+			LicenseStatusAfter:  event.LicenseStatusAfter,
+			ActorID:             event.ActorID,
+			Actor:               userFullName(event.Actor),
+			Notes:               event.Notes,
+			CreatedAt:           event.CreatedAt,
+		})
+	}
+	return response, nil
+}
+
+// enforcementResponses adds the license and files to cases
+func (s *licenseEnforcementService) enforcementResponses(enforcements []models.LicenseEnforcement) ([]dto.EnforcementResponse, error) {
+	responses := make([]dto.EnforcementResponse, 0, len(enforcements))
+	if len(enforcements) == 0 {
+		return responses, nil
+	}
+
+	ids := make([]uint, 0, len(enforcements))
+	for _, enforcement := range enforcements {
+		ids = append(ids, enforcement.ID)
+	}
+	var attachments []models.Attachment
+	err := s.db.Where("entity_type IN ? AND entity_id IN ?",
+		[]string{models.EnforcementNoticeAttachmentEntityType, models.EnforcementResponseAttachmentEntityType}, ids).
+		Order("id").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enforcement attachments: %w", err)
+	}
+	files := make(map[string]map[uint][]models.SubmittedAttachment, 2)
+	for _, attachment := range attachments {
+		if files[attachment.EntityType] == nil {
+			files[attachment.EntityType] = make(map[uint][]models.SubmittedAttachment)
+		}
+		files[attachment.EntityType][attachment.EntityID] = append(files[attachment.EntityType][attachment.EntityID], models.SubmittedAttachment{
+			ID:           attachment.ID,
+			FileName:     attachment.FileName,
+			OriginalName: attachment.OriginalName,
+			FileSize:     attachment.FileSize,
+			MimeType:     attachment.MimeType,
+			Description:  attachment.Description,
+			UploadedAt:   attachment.CreatedAt,
+		})
+	}
+	filesOf := func(entityType string, id uint) []models.SubmittedAttachment {
+		if list := files[entityType][id]; list != nil {
+			return list
+		}
+		return make([]models.SubmittedAttachment, 0)
+	}
+
+	for _, enforcement := range enforcements {
+		response := dto.EnforcementResponse{
+			ID:                  enforcement.ID,
+			CaseNumber:          enforcement.CaseNumber,
+			LicenseID:           enforcement.LicenseID,
+			ProposedAction:      enforcement.ProposedAction,
+			Status:              enforcement.Status,
+			Grounds:             enforcement.Grounds,
+			ResponseDeadline:    enforcement.ResponseDeadline,
+			IssuedByID:          enforcement.IssuedByID,
+			IssuedBy:            userFullName(enforcement.IssuedBy),
+			Response:            enforcement.Response,
+			RespondedByID:       enforcement.RespondedByID,
+			RespondedBy:         userFullName(enforcement.RespondedBy),
+			RespondedAt:         enforcement.RespondedAt,
+			Decision:            enforcement.Decision,
+			DecisionNotes:       enforcement.DecisionNotes,
+			DecidedByID:         enforcement.DecidedByID,
+			DecidedBy:           userFullName(enforcement.DecidedBy),
+			DecidedAt:           enforcement.DecidedAt,
+			SuspendedUntil:      enforcement.SuspendedUntil,
+			ReinstatedAt:        enforcement.ReinstatedAt,
+			NoticeAttachments:   filesOf(models.EnforcementNoticeAttachmentEntityType, enforcement.ID),
+			ResponseAttachments: filesOf(models.EnforcementResponseAttachmentEntityType, enforcement.ID),
+			CreatedAt:           enforcement.CreatedAt,
+		}
+		if enforcement.License != nil {
+			response.LicenseNumber = enforcement.License.LicenseNumber
+			response.HolderName = enforcement.License.HolderName()
+			response.LicenseStatus = enforcement.License.CurrentStatus(time.Now())
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func userFullName(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.FullName
+}
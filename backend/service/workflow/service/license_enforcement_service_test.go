@@ -0,0 +1,190 @@
+package service
+
+import (
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// issueTestNotice opens a case against the license and, if respond is set, lets the holder respond to it
+func issueTestNotice(t *testing.T, db *gorm.DB, s LicenseEnforcementService, license *models.License, issuerID uint, respond bool) *dto.EnforcementResponse {
+	t.Helper()
+
+	enforcement, err := s.IssueNotice(dto.IssueEnforcementNoticeRequest{
+		LicenseID: license.ID,
+		Action:    models.EnforcementActionSuspend,
+		Grounds:   "Plant operated above the licensed capacity",
+		UserID:    issuerID,
+	})
+	require.NoError(t, err)
+	if respond {
+		enforcement, err = s.Respond(dto.RespondEnforcementRequest{
+			EnforcementID: enforcement.ID,
+			UserID:        license.HolderUserID,
+			Response:      "The meter was faulty",
+		})
+		require.NoError(t, err)
+	}
+	return enforcement
+}
+
+func TestDecideEnforcement(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		respond        bool
+		licenseStatus  models.LicenseStatus // Set after the notice was issued
+		decision       string
+		suspendedUntil *time.Time
+		wantErr        error
+		wantCase       models.EnforcementStatus
+		wantLicense    models.LicenseStatus
+		wantCapacity   float64 // Licensed capacity in the ledger afterwards
+	}{
+		{name: "suspend after the response", respond: true, decision: "suspend", wantCase: models.EnforcementStatusSuspended, wantLicense: models.LicenseStatusSuspended, wantCapacity: 10},
+		{name: "revoke", respond: true, decision: "revoke", wantCase: models.EnforcementStatusRevoked, wantLicense: models.LicenseStatusRevoked},
+		{name: "dismiss", respond: true, decision: EnforcementDecisionDismiss, wantCase: models.EnforcementStatusDismissed, wantLicense: models.LicenseStatusActive, wantCapacity: 10},
+		{name: "before the holder responded", decision: "suspend", wantErr: ErrInvalidTransition},
+		{name: "suspension ending in the past", respond: true, decision: "suspend", suspendedUntil: &past, wantErr: ErrInvalidTransition},
+		{name: "suspend a cancelled license", respond: true, licenseStatus: models.LicenseStatusCancelled, decision: "suspend", wantErr: ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewLicenseEnforcementService(db, &config.Config{})
+			holder := createTestUser(t, db, models.RoleUser)
+			head := createTestUser(t, db, models.RoleDEDEHead)
+			license := createTestLicense(t, db, holder.ID, 10)
+			enforcement := issueTestNotice(t, db, s, license, head.ID, tt.respond)
+			if tt.licenseStatus != "" {
+				require.NoError(t, db.Model(license).Update("status", tt.licenseStatus).Error)
+			}
+
+			decided, err := s.Decide(dto.DecideEnforcementRequest{
+				EnforcementID:  enforcement.ID,
+				UserID:         head.ID,
+				Decision:       tt.decision,
+				SuspendedUntil: tt.suspendedUntil,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				stored, getErr := s.GetEnforcement(enforcement.ID, head.ID, models.RoleDEDEHead)
+				require.NoError(t, getErr)
+				assert.Equal(t, enforcement.Status, stored.Status, "a refused decision leaves the case open")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCase, decided.Status)
+			assert.Equal(t, tt.wantLicense, reloadTestLicense(t, db, license.ID).Status)
+
+			entry, err := repository.NewLicenseRepository(db).GetLatestCapacityEntry(license.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCapacity, entry.Capacity)
+		})
+	}
+}
+
+func TestReinstateLicense(t *testing.T) {
+	tests := []struct {
+		name        string
+		decision    string
+		wantErr     error
+		wantLicense models.LicenseStatus
+	}{
+		{name: "open-ended suspension", decision: "suspend", wantLicense: models.LicenseStatusActive},
+		{name: "revoked license", decision: "revoke", wantErr: ErrInvalidTransition, wantLicense: models.LicenseStatusRevoked},
+		{name: "dismissed case", decision: EnforcementDecisionDismiss, wantErr: ErrInvalidTransition, wantLicense: models.LicenseStatusActive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewLicenseEnforcementService(db, &config.Config{})
+			holder := createTestUser(t, db, models.RoleUser)
+			head := createTestUser(t, db, models.RoleDEDEHead)
+			license := createTestLicense(t, db, holder.ID, 10)
+			enforcement := issueTestNotice(t, db, s, license, head.ID, true)
+			_, err := s.Decide(dto.DecideEnforcementRequest{EnforcementID: enforcement.ID, UserID: head.ID, Decision: tt.decision})
+			require.NoError(t, err)
+
+			reinstated, err := s.Reinstate(dto.ReinstateLicenseRequest{EnforcementID: enforcement.ID, UserID: head.ID, Notes: "Meter replaced"})
+			assert.Equal(t, tt.wantLicense, reloadTestLicense(t, db, license.ID).Status)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.EnforcementStatusReinstated, reinstated.Status)
+			last := reinstated.Events[len(reinstated.Events)-1]
+			assert.Equal(t, models.EnforcementEventReinstated, last.EventType)
+			assert.Equal(t, models.LicenseStatusSuspended, last.LicenseStatusBefore)
+			assert.Equal(t, models.LicenseStatusActive, last.LicenseStatusAfter)
+		})
+	}
+}
+
+func TestRunEnforcementChecks(t *testing.T) {
+	tests := []struct {
+		name           string
+		respond        bool
+		deadlinePassed bool
+		suspendFor     time.Duration // Suspends the license for this long, ending in the past when negative
+		wantOverdue    int
+		wantReinstated int
+		wantCase       models.EnforcementStatus
+		wantLicense    models.LicenseStatus
+	}{
+		{name: "waiting for the response", wantCase: models.EnforcementStatusNoticeIssued, wantLicense: models.LicenseStatusActive},
+		{name: "no response by the deadline", deadlinePassed: true, wantOverdue: 1, wantCase: models.EnforcementStatusResponseOverdue, wantLicense: models.LicenseStatusActive},
+		{name: "responded before the deadline passed", respond: true, deadlinePassed: true, wantCase: models.EnforcementStatusResponded, wantLicense: models.LicenseStatusActive},
+		{name: "suspension still running", respond: true, suspendFor: 24 * time.Hour, wantCase: models.EnforcementStatusSuspended, wantLicense: models.LicenseStatusSuspended},
+		{name: "suspension ended", respond: true, suspendFor: -time.Hour, wantReinstated: 1, wantCase: models.EnforcementStatusReinstated, wantLicense: models.LicenseStatusActive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewLicenseEnforcementService(db, &config.Config{})
+			holder := createTestUser(t, db, models.RoleUser)
+			head := createTestUser(t, db, models.RoleDEDEHead)
+			license := createTestLicense(t, db, holder.ID, 10)
+			enforcement := issueTestNotice(t, db, s, license, head.ID, tt.respond)
+			if tt.deadlinePassed {
+				require.NoError(t, db.Model(&models.LicenseEnforcement{}).Where("id = ?", enforcement.ID).
+					Update("response_deadline", time.Now().Add(-time.Hour)).Error)
+			}
+			if tt.suspendFor != 0 {
+				until := time.Now().Add(24 * time.Hour)
+				_, err := s.Decide(dto.DecideEnforcementRequest{EnforcementID: enforcement.ID, UserID: head.ID, Decision: "suspend", SuspendedUntil: &until})
+				require.NoError(t, err)
+				require.NoError(t, db.Model(&models.LicenseEnforcement{}).Where("id = ?", enforcement.ID).
+					Update("suspended_until", time.Now().Add(tt.suspendFor)).Error)
+			}
+
+			result, err := s.RunEnforcementChecks()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOverdue, result.Overdue)
+			assert.Equal(t, tt.wantReinstated, result.Reinstated)
+
+			// A later run finds nothing left to do
+			again, err := s.RunEnforcementChecks()
+			require.NoError(t, err)
+			assert.Zero(t, again.Overdue)
+			assert.Zero(t, again.Reinstated)
+
+			stored, err := s.GetEnforcement(enforcement.ID, head.ID, models.RoleDEDEHead)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCase, stored.Status)
+			assert.Equal(t, tt.wantLicense, reloadTestLicense(t, db, license.ID).Status)
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/utils"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultExpiryReminderDays are the reminder tiers used when LICENSE_EXPIRY_REMINDER_DAYS has none
+var defaultExpiryReminderDays = []int{180, 90, 30}
+
+type LicenseExpiryService interface {
+	RunExpiryChecks() (*dto.LicenseExpiryRunResult, error)
+	GetLicenseReminders(licenseID uint) ([]models.LicenseExpiryReminder, error)
+}
+
+type licenseExpiryService struct {
+	db                *gorm.DB
+	definitionService WorkflowDefinitionService
+	outboxService     OutboxService
+	reminderDays      []int // Longest first
+}
+
+func NewLicenseExpiryService(db *gorm.DB, cfg *config.Config) LicenseExpiryService {
+	return &licenseExpiryService{
+		db:                db,
+		definitionService: NewWorkflowDefinitionService(db),
+		outboxService:     NewOutboxService(db, cfg),
+		reminderDays:      parseReminderDays(cfg.LicenseExpiryReminderDays),
+	}
+}
+
+// parseReminderDays reads comma separated reminder tiers, skipping anything that is not a positive number of days
+func parseReminderDays(value string) []int {
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day <= 0 {
+			log.Printf("Ignoring invalid license expiry reminder day %q", part)
+			continue
+		}
+		days = append(days, day)
+	}
+	if len(days) == 0 {
+		days = append(days, defaultExpiryReminderDays...)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// RunExpiryChecks expires active licenses past their validity and reminds the holders of licenses
+// that reached a reminder tier. Each license gets at most one reminder per tier and expiry date;
+// when runs were missed, only the most urgent tier reached is sent.
+func (s *licenseExpiryService) RunExpiryChecks() (*dto.LicenseExpiryRunResult, error) {
+	now := time.Now()
+	result := &dto.LicenseExpiryRunResult{}
+
+	var expired []models.License
+	err := s.db.Where("status = ? AND valid_until < ?", models.LicenseStatusActive, now).
+		Order("valid_until, id").
+		Find(&expired).Error
+	if err != nil {
+		return result, fmt.Errorf("failed to get expired licenses: %w", err)
+	}
+	for i := range expired {
+		done, err := s.expireLicense(&expired[i], now)
+		if err != nil {
+			log.Printf("Failed to expire license %s: %v", expired[i].LicenseNumber, err)
+			continue
+		}
+		if done {
+			result.Expired++
+		}
+	}
+
+	var expiring []models.License
+	err = s.db.Where("status = ? AND valid_until >= ? AND valid_until <= ?", models.LicenseStatusActive, now, now.AddDate(0, 0, s.reminderDays[0])).
+		Order("valid_until, id").
+		Find(&expiring).Error
+	if err != nil {
+		return result, fmt.Errorf("failed to get expiring licenses: %w", err)
+	}
+	for i := range expiring {
+		license := &expiring[i]
+		daysLeft := int(math.Ceil(license.ValidUntil.Sub(now).Hours() / 24))
+		tier := s.reminderTier(daysLeft)
+		if tier == 0 {
+			continue
+		}
+		sent, err := s.sendReminder(license, tier, daysLeft, now)
+		if err != nil {
+			log.Printf("Failed to remind holder of license %s: %v", license.LicenseNumber, err)
+			continue
+		}
+		if sent {
+			result.Reminded++
+		}
+	}
+
+	return result, nil
+}
+
+// reminderTier returns the most urgent tier a license with daysLeft days of validity has reached, or 0 for none
+func (s *licenseExpiryService) reminderTier(daysLeft int) int {
+	tier := 0
+	for _, days := range s.reminderDays {
+		if daysLeft <= days {
+			tier = days
+		}
+	}
+	return tier
+}
+
+// sendReminder records the tier's reminder and notifies the holder with a link to a renewal draft.
+// It returns false when the reminder was sent already.
+func (s *licenseExpiryService) sendReminder(license *models.License, tier, daysLeft int, now time.Time) (bool, error) {
+	sent := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		reminder, err := recordExpiryReminder(tx, license, tier)
+		if err != nil || reminder == nil {
+			return err
+		}
+
+		draft, err := s.renewalDraft(tx, license, now)
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("ใบอนุญาตเลขที่ %s โครงการ %s จะหมดอายุในวันที่ %s (อีก %d วัน) กรุณายื่นคำขอต่ออายุใบอนุญาต",
+			license.LicenseNumber, license.ProjectName, utils.FormatThaiDate(license.ValidUntil), daysLeft)
+		priority := models.PriorityNormal
+		if tier <= 30 {
+			priority = models.PriorityHigh
+		}
+		if err := s.notifyHolder(tx, license, reminder, draft, "ใบอนุญาตใกล้หมดอายุ", message,
+			models.NotificationType("license_expiry_reminder"), priority); err != nil {
+			return err
+		}
+		sent = true
+		return nil
+	})
+	return sent, err
+}
+
+// expireLicense moves an active license past its validity to expired and tells the holder.
+// It returns false when another run or a renewal got to the license first.
+func (s *licenseExpiryService) expireLicense(license *models.License, now time.Time) (bool, error) {
+	expired := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		update := tx.Model(&models.License{}).
+			Where("id = ? AND status = ? AND valid_until < ?", license.ID, models.LicenseStatusActive, now).
+			Update("status", models.LicenseStatusExpired)
+		if update.Error != nil {
+			return fmt.Errorf("failed to expire license: %w", update.Error)
+		}
+		if update.RowsAffected == 0 {
+			return nil
+		}
+		expired = true
+		license.Status = models.LicenseStatusExpired
+
+		reminder, err := recordExpiryReminder(tx, license, 0)
+		if err != nil || reminder == nil {
+			return err
+		}
+		draft, err := s.renewalDraft(tx, license, now)
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("ใบอนุญาตเลขที่ %s โครงการ %s หมดอายุแล้วเมื่อวันที่ %s กรุณายื่นคำขอต่ออายุใบอนุญาต",
+			license.LicenseNumber, license.ProjectName, utils.FormatThaiDate(license.ValidUntil))
+		return s.notifyHolder(tx, license, reminder, draft, "ใบอนุญาตหมดอายุ", message,
+			models.NotificationType("license_expired"), models.PriorityCritical)
+	})
+	return expired, err
+}
+
+// recordExpiryReminder claims a reminder tier for the license's current expiry date. It returns nil
+// when the tier was claimed already, so concurrent runs send each reminder once.
+func recordExpiryReminder(tx *gorm.DB, license *models.License, tier int) (*models.LicenseExpiryReminder, error) {
+	reminder := &models.LicenseExpiryReminder{
+		LicenseID:   license.ID,
+		ValidUntil:  license.ValidUntil,
+		DaysBefore:  tier,
+		RecipientID: license.HolderUserID,
+	}
+	create := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	if create.Error != nil {
+		return nil, fmt.Errorf("failed to record expiry reminder: %w", create.Error)
+	}
+	if create.RowsAffected == 0 {
+		return nil, nil
+	}
+	return reminder, nil
+}
+
+// renewalDraft returns the renewal request the holder should continue: the open renewal of the
+// license if there is one, or else a new draft pre-filled from the license. It returns nil when
+// another kind of request for the license is still open, since a renewal cannot be filed until it ends.
+func (s *licenseExpiryService) renewalDraft(tx *gorm.DB, license *models.License, now time.Time) (*models.LicenseRequest, error) {
+	open, err := repository.NewLicenseRepository(tx).GetOpenRequest(license.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license requests: %w", err)
+	}
+	if open != nil {
+		if open.LicenseType == models.LicenseTypeRenew {
+			return open, nil
+		}
+		return nil, nil
+	}
+
+	version, err := s.definitionService.GetActiveVersion(string(models.LicenseTypeRenew))
+	if err != nil {
+		version = models.DefaultWorkflowVersion
+	}
+	requestNumber, err := nextRequestNumber(tx, now)
+	if err != nil {
+		return nil, err
+	}
+	deadline := utils.GetDeadline()
+
+	draft := &models.LicenseRequest{
+		UserID:            license.HolderUserID,
+		RequestNumber:     requestNumber,
+		LicenseType:       models.LicenseTypeRenew,
+		Status:            models.StatusDraft,
+		Title:             license.ProjectName,
+		Description:       "ร่างคำขอต่ออายุใบอนุญาตที่ระบบจัดเตรียมให้",
+		LicenseNumber:     license.LicenseNumber,
+		LicenseID:         &license.ID,
+		CorporateID:       license.HolderCorporateID,
+		CurrentCapacity:   license.Capacity,
+		RequestedCapacity: license.Capacity,
+		Location:          license.Location,
+		Deadline:          &deadline,
+		WorkflowVersion:   version,
+	}
+	err = draft.SetPayload(models.RenewalLicensePayload{
+		ProjectAddress:        license.Location,
+		CurrentCapacityUnit:   license.CapacityUnit,
+		RequestedCapacityUnit: license.CapacityUnit,
+		ExpiryDate:            license.ValidUntil,
+		RequestedExpiryDate:   license.ValidUntil.AddDate(models.DefaultLicenseValidityYears, 0, 0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Omit(clause.Associations).Create(draft).Error; err != nil {
+		return nil, fmt.Errorf("failed to create renewal draft: %w", err)
+	}
+	return draft, nil
+}
+
+// nextRequestNumber numbers a request the way applicants' requests are numbered, REQ-YYYYMMDD-XXXX
+func nextRequestNumber(tx *gorm.DB, now time.Time) (string, error) {
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var count int64
+	err := tx.Model(&models.LicenseRequest{}).
+		Where("created_at >= ? AND created_at < ?", dayStart, dayStart.Add(24*time.Hour)).
+		Count(&count).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to count requests: %w", err)
+	}
+	return fmt.Sprintf("REQ-%s-%04d", now.Format("20060102"), count+1), nil
+}
+
+// notifyHolder queues the reminder for the license holder, linking to the renewal draft when there is one
+func (s *licenseExpiryService) notifyHolder(tx *gorm.DB, license *models.License, reminder *models.LicenseExpiryReminder, draft *models.LicenseRequest, title, message string, notifType models.NotificationType, priority models.NotificationPriority) error {
+	actionURL := "/dashboard/licenses"
+	if draft != nil {
+		reminder.RenewalRequestID = &draft.ID
+		if err := tx.Model(reminder).Update("renewal_request_id", draft.ID).Error; err != nil {
+			return fmt.Errorf("failed to link renewal draft: %w", err)
+		}
+		actionURL = fmt.Sprintf("/eservice/dede/license/renewal?draft=%d", draft.ID)
+		message += fmt.Sprintf(" ระบบได้จัดเตรียมร่างคำขอเลขที่ %s ไว้ให้แล้ว", draft.RequestNumber)
+	}
+
+	return s.outboxService.EnqueueNotification(tx, models.Notification{
+		Title:       title,
+		Message:     message,
+		Type:        notifType,
+		Priority:    priority,
+		RecipientID: &license.HolderUserID,
+		EntityType:  "license",
+		EntityID:    &license.ID,
+		ActionURL:   actionURL,
+	})
+}
+
+// GetLicenseReminders returns the expiry reminders sent for a license, newest first
+func (s *licenseExpiryService) GetLicenseReminders(licenseID uint) ([]models.LicenseExpiryReminder, error) {
+	var reminders []models.LicenseExpiryReminder
+	err := s.db.Where("license_id = ?", licenseID).
+		Order("created_at DESC, id DESC").
+		Find(&reminders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiry reminders: %w", err)
+	}
+	return reminders, nil
+}
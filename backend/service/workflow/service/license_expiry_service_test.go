@@ -0,0 +1,116 @@
+package service
+
+import (
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminderDays(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []int
+	}{
+		{value: "", want: []int{180, 90, 30}},
+		{value: "30,90", want: []int{90, 30}},
+		{value: " 60 , 7 ", want: []int{60, 7}},
+		{value: "14,soon,-3,0", want: []int{14}},
+		{value: "never", want: []int{180, 90, 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseReminderDays(tt.value))
+		})
+	}
+}
+
+func TestRunExpiryChecks(t *testing.T) {
+	tests := []struct {
+		name         string
+		daysLeft     int
+		open         models.LicenseType // Type of a request for the license that is still open
+		wantReminded int
+		wantExpired  int
+		wantTier     int
+		wantPriority models.NotificationPriority
+		wantDraft    bool // A new renewal draft is created for the holder
+	}{
+		{name: "before the first tier", daysLeft: 200},
+		{name: "first tier", daysLeft: 150, wantReminded: 1, wantTier: 180, wantPriority: models.PriorityNormal, wantDraft: true},
+		{name: "missed runs send only the most urgent tier", daysLeft: 60, wantReminded: 1, wantTier: 90, wantPriority: models.PriorityNormal, wantDraft: true},
+		{name: "last tier", daysLeft: 10, wantReminded: 1, wantTier: 30, wantPriority: models.PriorityHigh, wantDraft: true},
+		{name: "past its validity", daysLeft: -1, wantExpired: 1, wantPriority: models.PriorityCritical, wantDraft: true},
+		{name: "renewal already filed", daysLeft: 10, open: models.LicenseTypeRenew, wantReminded: 1, wantTier: 30, wantPriority: models.PriorityHigh},
+		{name: "other request still open", daysLeft: 10, open: models.LicenseTypeExpand, wantReminded: 1, wantTier: 30, wantPriority: models.PriorityHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := NewLicenseExpiryService(db, &config.Config{})
+			holder := createTestUser(t, db, models.RoleUser)
+			license := createTestLicense(t, db, holder.ID, 10)
+			validUntil := time.Now().AddDate(0, 0, tt.daysLeft).Add(-time.Hour)
+			require.NoError(t, db.Model(license).Update("valid_until", validUntil).Error)
+			var open *models.LicenseRequest
+			if tt.open != "" {
+				open = createTestRequest(t, db, holder.ID, tt.open, models.StatusNewRequest)
+				require.NoError(t, db.Model(open).Update("license_id", license.ID).Error)
+			}
+
+			result, err := s.RunExpiryChecks()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReminded, result.Reminded)
+			assert.Equal(t, tt.wantExpired, result.Expired)
+
+			// Each tier is sent once per expiry date
+			again, err := s.RunExpiryChecks()
+			require.NoError(t, err)
+			assert.Zero(t, again.Reminded)
+			assert.Zero(t, again.Expired)
+
+			reminders, err := s.GetLicenseReminders(license.ID)
+			require.NoError(t, err)
+			notifications := queuedNotifications(t, db)
+			if tt.wantReminded+tt.wantExpired == 0 {
+				assert.Empty(t, reminders)
+				assert.Empty(t, notifications)
+				return
+			}
+			require.Len(t, reminders, 1)
+			assert.Equal(t, tt.wantTier, reminders[0].DaysBefore)
+			require.Len(t, notifications, 1)
+			assert.Equal(t, tt.wantPriority, notifications[0].Priority)
+			assert.Equal(t, holder.ID, *notifications[0].RecipientID)
+
+			stored := reloadTestLicense(t, db, license.ID)
+			if tt.wantExpired > 0 {
+				assert.Equal(t, models.LicenseStatusExpired, stored.Status)
+			} else {
+				assert.Equal(t, models.LicenseStatusActive, stored.Status)
+			}
+
+			var drafts []models.LicenseRequest
+			require.NoError(t, db.Where("license_id = ? AND status = ?", license.ID, models.StatusDraft).Find(&drafts).Error)
+			switch {
+			case tt.wantDraft:
+				require.Len(t, drafts, 1)
+				assert.Equal(t, models.LicenseTypeRenew, drafts[0].LicenseType)
+				require.NotNil(t, reminders[0].RenewalRequestID)
+				assert.Equal(t, drafts[0].ID, *reminders[0].RenewalRequestID)
+			case tt.open == models.LicenseTypeRenew:
+				assert.Empty(t, drafts)
+				require.NotNil(t, reminders[0].RenewalRequestID)
+				assert.Equal(t, open.ID, *reminders[0].RenewalRequestID, "the holder continues the renewal already filed")
+			default:
+				assert.Empty(t, drafts)
+				assert.Nil(t, reminders[0].RenewalRequestID)
+			}
+		})
+	}
+}
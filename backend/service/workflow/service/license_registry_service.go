@@ -0,0 +1,530 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrLicenseNotFound is returned when a license does not exist or is not visible to the caller
+	ErrLicenseNotFound = errors.New("license not found")
+	// ErrLicenseReference is returned when a request refers to a license it may not change
+	ErrLicenseReference = errors.New("invalid license reference")
+	// ErrDuplicateLicense is returned when a license number is registered twice
+	ErrDuplicateLicense = errors.New("license number already registered")
+	// ErrInvalidLicenseHolder is returned when a registered license names a holder that does not exist
+	ErrInvalidLicenseHolder = errors.New("invalid license holder")
+)
+
+type LicenseRegistryService interface {
+	GetLicenses(filter dto.LicenseFilter, userID uint, role models.UserRole) ([]dto.LicenseResponse, error)
+	GetLicense(id, userID uint, role models.UserRole) (*dto.LicenseResponse, error)
+	GetLicenseByNumber(licenseNumber string, userID uint, role models.UserRole) (*dto.LicenseResponse, error)
+	RegisterLicense(req dto.RegisterLicenseRequest) (*dto.LicenseResponse, error)
+	GetCapacityHistory(id, userID uint, role models.UserRole, at *time.Time) (*dto.CapacityHistoryResponse, error)
+}
+
+type licenseRegistryService struct {
+	db          *gorm.DB
+	licenseRepo repository.LicenseRepository
+}
+
+func NewLicenseRegistryService(db *gorm.DB) LicenseRegistryService {
+	return &licenseRegistryService{
+		db:          db,
+		licenseRepo: repository.NewLicenseRepository(db),
+	}
+}
+
+// GetLicenses returns the license registry, newest first; applicants only see the licenses they hold
+func (s *licenseRegistryService) GetLicenses(filter dto.LicenseFilter, userID uint, role models.UserRole) ([]dto.LicenseResponse, error) {
+	var licenses []models.License
+	var err error
+	if filter.Mine || role == models.RoleUser {
+		licenses, err = s.licenseRepo.GetByHolder(userID)
+	} else {
+		licenses, err = s.licenseRepo.GetAll()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get licenses: %w", err)
+	}
+
+	search := strings.ToLower(strings.TrimSpace(filter.Search))
+	responses := make([]dto.LicenseResponse, 0, len(licenses))
+	for i := range licenses {
+		license := &licenses[i]
+		if filter.Status != "" && license.Status != filter.Status {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(license.LicenseNumber), search) &&
+			!strings.Contains(strings.ToLower(license.ProjectName), search) {
+			continue
+		}
+		responses = append(responses, licenseResponse(license))
+	}
+	return responses, nil
+}
+
+// GetLicense returns a license with the requests that refer to it
+func (s *licenseRegistryService) GetLicense(id, userID uint, role models.UserRole) (*dto.LicenseResponse, error) {
+	license, err := s.licenseRepo.GetByID(id)
+	return s.visibleLicense(license, err, userID, role)
+}
+
+// GetLicenseByNumber returns a license by its number with the requests that refer to it
+func (s *licenseRegistryService) GetLicenseByNumber(licenseNumber string, userID uint, role models.UserRole) (*dto.LicenseResponse, error) {
+	license, err := s.licenseRepo.GetByLicenseNumber(strings.TrimSpace(licenseNumber))
+	return s.visibleLicense(license, err, userID, role)
+}
+
+// visibleLicense builds the response for a looked-up license, hiding licenses of other holders from applicants
+func (s *licenseRegistryService) visibleLicense(license *models.License, err error, userID uint, role models.UserRole) (*dto.LicenseResponse, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLicenseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+
+	if role == models.RoleUser {
+		corporateIDs, err := s.licenseRepo.GetCorporateIDs(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get corporates: %w", err)
+		}
+		if !license.IsHeldBy(userID, corporateIDs) {
+			return nil, ErrLicenseNotFound
+		}
+	}
+
+	var requests []models.LicenseRequest
+	err = s.db.Select("id", "request_number", "license_type", "status", "requested_capacity", "created_at").
+		Where("license_id = ?", license.ID).
+		Order("created_at, id").
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license requests: %w", err)
+	}
+
+	response := licenseResponse(license)
+	for _, request := range requests {
+		response.Requests = append(response.Requests, dto.LicenseRequestSummary{
+			RequestID:         request.ID,
+			RequestNumber:     request.RequestNumber,
+			LicenseType:       string(request.LicenseType),
+			Status:            request.Status,
+			RequestedCapacity: request.RequestedCapacity,
+			CreatedAt:         request.CreatedAt,
+		})
+	}
+	return &response, nil
+}
+
+// RegisterLicense records a license issued outside the system. Registered licenses have no issuing request.
+func (s *licenseRegistryService) RegisterLicense(req dto.RegisterLicenseRequest) (*dto.LicenseResponse, error) {
+	licenseNumber := strings.TrimSpace(req.LicenseNumber)
+	if _, err := s.licenseRepo.GetByLicenseNumber(licenseNumber); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateLicense, licenseNumber)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+
+	var holders int64
+	if err := s.db.Model(&models.User{}).Where("id = ?", req.HolderUserID).Count(&holders).Error; err != nil {
+		return nil, fmt.Errorf("failed to get holder: %w", err)
+	}
+	if holders == 0 {
+		return nil, fmt.Errorf("%w: user %d does not exist", ErrInvalidLicenseHolder, req.HolderUserID)
+	}
+	if req.HolderCorporateID != nil {
+		var member int64
+		err := s.db.Model(&models.CorporateMember{}).
+			Where("corporate_id = ? AND user_id = ? AND status = ?", *req.HolderCorporateID, req.HolderUserID, models.MemberStatusActive).
+			Count(&member).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get corporate member: %w", err)
+		}
+		if member == 0 {
+			return nil, fmt.Errorf("%w: user %d is not an active member of corporate %d", ErrInvalidLicenseHolder, req.HolderUserID, *req.HolderCorporateID)
+		}
+	}
+
+	status := models.LicenseStatusActive
+	if !req.ValidUntil.After(time.Now()) {
+		status = models.LicenseStatusExpired
+	}
+	license := &models.License{
+		LicenseNumber:     licenseNumber,
+		HolderUserID:      req.HolderUserID,
+		HolderCorporateID: req.HolderCorporateID,
+		ProjectName:       req.ProjectName,
+		Location:          req.Location,
+		Province:          req.Province,
+		EnergyType:        req.EnergyType,
+		Capacity:          req.Capacity,
+		CapacityUnit:      req.CapacityUnit,
+		Status:            status,
+		IssuedAt:          req.ValidFrom,
+		ValidFrom:         req.ValidFrom,
+		ValidUntil:        req.ValidUntil,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		licenseRepo := repository.NewLicenseRepository(tx)
+		if err := licenseRepo.Create(license); err != nil {
+			return fmt.Errorf("failed to register license: %w", err)
+		}
+		if err := licenseRepo.AddCapacityEntry(openingCapacityEntry(license, models.CapacityEntryRegistered, nil)); err != nil {
+			return fmt.Errorf("failed to record capacity: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetLicense(license.ID, 0, models.RoleAdmin)
+}
+
+// GetCapacityHistory returns the capacity ledger of a license, oldest first. With at set, it returns the
+// entries up to that time and the capacity licensed then.
+func (s *licenseRegistryService) GetCapacityHistory(id, userID uint, role models.UserRole, at *time.Time) (*dto.CapacityHistoryResponse, error) {
+	license, err := s.GetLicense(id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.licenseRepo.GetCapacityHistory(id, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capacity history: %w", err)
+	}
+
+	response := &dto.CapacityHistoryResponse{
+		LicenseID:     license.ID,
+		LicenseNumber: license.LicenseNumber,
+		CapacityUnit:  license.CapacityUnit,
+		At:            at,
+		Entries:       entries,
+	}
+	if len(entries) > 0 {
+		latest := entries[len(entries)-1]
+		response.Capacity = &latest.Capacity
+	}
+	return response, nil
+}
+
+// FindRequestLicense resolves the license a renewal, extension or reduction request refers to, by
+// its license ID or else its license number. The applicant must hold the license, directly or
+// through a corporate, and no other request for it may be in progress.
+func FindRequestLicense(licenseRepo repository.LicenseRepository, request *models.LicenseRequest) (*models.License, error) {
+	var license *models.License
+	var err error
+	if request.LicenseID != nil {
+		license, err = licenseRepo.GetByID(*request.LicenseID)
+	} else {
+		if strings.TrimSpace(request.LicenseNumber) == "" {
+			return nil, fmt.Errorf("%w: a license number is required for %s requests", ErrLicenseReference, request.LicenseType)
+		}
+		license, err = licenseRepo.GetByLicenseNumber(strings.TrimSpace(request.LicenseNumber))
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: license %s is not in the license registry", ErrLicenseReference, request.LicenseNumber)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+
+	corporateIDs, err := licenseRepo.GetCorporateIDs(request.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get corporates: %w", err)
+	}
+	if !license.IsHeldBy(request.UserID, corporateIDs) {
+		return nil, fmt.Errorf("%w: license %s is not held by the applicant", ErrLicenseReference, license.LicenseNumber)
+	}
+
+	open, err := licenseRepo.GetOpenRequest(license.ID, request.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license requests: %w", err)
+	}
+	if open != nil {
+		return nil, fmt.Errorf("%w: request %s for license %s is still in progress", ErrLicenseReference, open.RequestNumber, license.LicenseNumber)
+	}
+
+	return license, nil
+}
+
+// CheckRequestLicense resolves the license a request refers to and checks that the request can be applied to it.
+// Extensions and reductions are checked against the capacity ledger, and their current capacity must match it.
+func CheckRequestLicense(licenseRepo repository.LicenseRepository, request *models.LicenseRequest) (*models.License, error) {
+	license, err := FindRequestLicense(licenseRepo, request)
+	if err != nil {
+		return nil, err
+	}
+	if request.LicenseType == models.LicenseTypeExpand || request.LicenseType == models.LicenseTypeReduce {
+		entry, err := licenseRepo.GetLatestCapacityEntry(license.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get capacity ledger: %w", err)
+		}
+		if entry != nil {
+			// The ledger, not the license row, is the source of truth for the licensed capacity
+			license.Capacity = entry.Capacity
+		}
+		if request.CurrentCapacity != license.Capacity {
+			return nil, fmt.Errorf("%w: current capacity %.2f does not match the licensed capacity %.2f of license %s",
+				ErrLicenseReference, request.CurrentCapacity, license.Capacity, license.LicenseNumber)
+		}
+	}
+	if err := license.CheckChange(request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLicenseReference, err)
+	}
+	return license, nil
+}
+
+// issueLicense applies an approved request to the license registry: a new request issues a
+// license, and renewal, extension and reduction requests change the license they refer to.
+// Other license types leave the registry alone and return nil.
+func issueLicense(tx *gorm.DB, record *WorkflowRequestRecord, now time.Time) (*models.License, error) {
+	var request models.LicenseRequest
+	if err := tx.First(&request, record.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	if request.LicenseType == models.LicenseTypeNew {
+		return issueNewLicense(tx, &request, now)
+	}
+	if !request.LicenseType.RefersToLicense() {
+		return nil, nil
+	}
+
+	// Lock the license before checking the request against it, so an approval of another request
+	// for the same license waits for this one and then sees the license as it left it
+	if err := lockRequestLicense(tx, &request); err != nil {
+		return nil, err
+	}
+
+	// Approval is the last chance to catch a license changed by another request since submission
+	license, err := CheckRequestLicense(repository.NewLicenseRepository(tx), &request)
+	if err != nil {
+		if errors.Is(err, ErrLicenseReference) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTransition, err)
+		}
+		return nil, err
+	}
+
+	var capacityEntry *models.LicenseCapacityEntry
+	switch request.LicenseType {
+	case models.LicenseTypeRenew:
+		payload, err := request.DecodePayload()
+		if err != nil {
+			return nil, err
+		}
+		license.ValidUntil = payload.(*models.RenewalLicensePayload).RequestedExpiryDate
+		license.Status = models.LicenseStatusActive
+	case models.LicenseTypeExpand, models.LicenseTypeReduce:
+		capacityEntry = &models.LicenseCapacityEntry{
+			LicenseID:        license.ID,
+			EntryType:        models.CapacityEntryType(request.LicenseType),
+			RequestID:        &request.ID,
+			PreviousCapacity: request.CurrentCapacity, // Checked against the ledger above
+			Capacity:         request.RequestedCapacity,
+			CapacityUnit:     license.CapacityUnit,
+			RecordedAt:       now,
+		}
+		license.Capacity = request.RequestedCapacity
+	case models.LicenseTypeModify:
+		payload, err := request.DecodePayload()
+		if err != nil {
+			return nil, err
+		}
+		applyModification(license, payload.(*models.ModifyLicensePayload))
+	case models.LicenseTypeCancel:
+		// A retired license licenses no capacity; the license itself keeps the capacity it was retired with
+		capacityEntry = &models.LicenseCapacityEntry{
+			LicenseID:        license.ID,
+			EntryType:        models.CapacityEntryCancelled,
+			RequestID:        &request.ID,
+			PreviousCapacity: license.Capacity,
+			Capacity:         0,
+			CapacityUnit:     license.CapacityUnit,
+			RecordedAt:       now,
+		}
+		license.Status = models.LicenseStatusCancelled
+		license.CancelledAt = &now
+	}
+	license.LastAmendedRequestID = &request.ID
+	license.AmendedAt = &now
+
+	if err := tx.Omit(clause.Associations).Save(license).Error; err != nil {
+		return nil, fmt.Errorf("failed to update license: %w", err)
+	}
+	if capacityEntry != nil {
+		if err := repository.NewLicenseRepository(tx).AddCapacityEntry(capacityEntry); err != nil {
+			return nil, fmt.Errorf("failed to record capacity change: %w", err)
+		}
+	}
+	if err := linkRequestLicense(tx, request.ID, license); err != nil {
+		return nil, err
+	}
+	return license, nil
+}
+
+// lockRequestLicense locks the row of the license a request refers to. A license that cannot be
+// found is left to CheckRequestLicense to report.
+func lockRequestLicense(tx *gorm.DB, request *models.LicenseRequest) error {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id")
+	if request.LicenseID != nil {
+		query = query.Where("id = ?", *request.LicenseID)
+	} else {
+		query = query.Where("license_number = ?", strings.TrimSpace(request.LicenseNumber))
+	}
+
+	var license models.License
+	err := query.Take(&license).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to lock license: %w", err)
+	}
+	return nil
+}
+
+// applyModification amends the license particulars a modify request fills in; the others stay as they are
+func applyModification(license *models.License, modification *models.ModifyLicensePayload) {
+	if location := modification.Location(); location != "" {
+		license.Location = location
+	}
+	if modification.Province != "" {
+		license.Province = modification.Province
+	}
+	if modification.ContactPerson != "" {
+		license.ContactPerson = modification.ContactPerson
+	}
+	if modification.ContactPhone != "" {
+		license.ContactPhone = modification.ContactPhone
+	}
+	if modification.ContactEmail != "" {
+		license.ContactEmail = modification.ContactEmail
+	}
+	if modification.Equipment != "" {
+		license.Equipment = modification.Equipment
+	}
+}
+
+// issueNewLicense issues the license of an approved new request, held by the corporate the applicant filed for if any
+func issueNewLicense(tx *gorm.DB, request *models.LicenseRequest, now time.Time) (*models.License, error) {
+	if request.LicenseID != nil {
+		// Issued already, e.g. when an approval is replayed
+		var license models.License
+		if err := tx.First(&license, *request.LicenseID).Error; err != nil {
+			return nil, fmt.Errorf("failed to get license: %w", err)
+		}
+		return &license, nil
+	}
+
+	payload, err := request.DecodePayload()
+	if err != nil {
+		return nil, err
+	}
+	details := payload.(*models.NewLicensePayload)
+
+	license := &models.License{
+		LicenseNumber:     fmt.Sprintf("LIC-%d-%06d", now.Year(), request.ID),
+		HolderUserID:      request.UserID,
+		HolderCorporateID: request.CorporateID,
+		ProjectName:       request.Title,
+		Location:          request.Location,
+		Province:          details.Province,
+		EnergyType:        details.EnergyType,
+		Capacity:          request.RequestedCapacity,
+		CapacityUnit:      details.CapacityUnit,
+		ContactPerson:     request.ContactPerson,
+		ContactPhone:      request.ContactPhone,
+		ContactEmail:      request.ContactEmail,
+		Status:            models.LicenseStatusActive,
+		IssuedAt:          now,
+		ValidFrom:         now,
+		ValidUntil:        now.AddDate(models.DefaultLicenseValidityYears, 0, 0),
+		IssuedRequestID:   &request.ID,
+	}
+	licenseRepo := repository.NewLicenseRepository(tx)
+	if err := licenseRepo.Create(license); err != nil {
+		return nil, fmt.Errorf("failed to issue license: %w", err)
+	}
+	if err := licenseRepo.AddCapacityEntry(openingCapacityEntry(license, models.CapacityEntryIssued, &request.ID)); err != nil {
+		return nil, fmt.Errorf("failed to record capacity: %w", err)
+	}
+	if err := linkRequestLicense(tx, request.ID, license); err != nil {
+		return nil, err
+	}
+	return license, nil
+}
+
+// openingCapacityEntry starts the capacity ledger of a license with the capacity it was issued or registered with
+func openingCapacityEntry(license *models.License, entryType models.CapacityEntryType, requestID *uint) *models.LicenseCapacityEntry {
+	return &models.LicenseCapacityEntry{
+		LicenseID:    license.ID,
+		EntryType:    entryType,
+		RequestID:    requestID,
+		Capacity:     license.Capacity,
+		CapacityUnit: license.CapacityUnit,
+		RecordedAt:   license.IssuedAt,
+	}
+}
+
+// linkRequestLicense points a request at its license and records the validity the approval left the
+// license with. The transition has bumped the version already.
+func linkRequestLicense(tx *gorm.DB, requestID uint, license *models.License) error {
+	err := tx.Table(licenseRequestTable).
+		Where("id = ?", requestID).
+		UpdateColumns(map[string]interface{}{
+			"license_id":          license.ID,
+			"license_number":      license.LicenseNumber,
+			"license_valid_from":  license.ValidFrom,
+			"license_valid_until": license.ValidUntil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to link request to license: %w", err)
+	}
+	return nil
+}
+
+// licenseResponse converts a license with its holder preloaded
+func licenseResponse(license *models.License) dto.LicenseResponse {
+	response := dto.LicenseResponse{
+		ID:                   license.ID,
+		LicenseNumber:        license.LicenseNumber,
+		Status:               license.Status,
+		HolderUserID:         license.HolderUserID,
+		HolderCorporateID:    license.HolderCorporateID,
+		ProjectName:          license.ProjectName,
+		Location:             license.Location,
+		Province:             license.Province,
+		EnergyType:           license.EnergyType,
+		Capacity:             license.Capacity,
+		CapacityUnit:         license.CapacityUnit,
+		ContactPerson:        license.ContactPerson,
+		ContactPhone:         license.ContactPhone,
+		ContactEmail:         license.ContactEmail,
+		Equipment:            license.Equipment,
+		IssuedAt:             license.IssuedAt,
+		ValidFrom:            license.ValidFrom,
+		ValidUntil:           license.ValidUntil,
+		IssuedRequestID:      license.IssuedRequestID,
+		LastAmendedRequestID: license.LastAmendedRequestID,
+		AmendedAt:            license.AmendedAt,
+		CancelledAt:          license.CancelledAt,
+		CertificateID:        license.CertificateID,
+	}
+	if license.HolderUser != nil {
+		response.HolderName = license.HolderUser.FullName
+	}
+	if license.HolderCorporate != nil {
+		response.HolderCorporateName = license.HolderCorporate.CorporateName
+	}
+	return response
+}
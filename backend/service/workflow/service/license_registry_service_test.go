@@ -0,0 +1,425 @@
+package service
+
+import (
+	"encoding/json"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openRegistryTestDB returns an in-memory database with the license registry tables
+func openRegistryTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Corporate{},
+		&models.CorporateMember{},
+		&models.License{},
+		&models.LicenseRequest{},
+		&models.LicenseCapacityEntry{},
+	))
+	return db
+}
+
+func TestCheckRequestLicenseCapacityLedger(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	// A ledger entry recorded days after the license was issued
+	type entry struct {
+		days     int
+		capacity float64
+	}
+
+	tests := []struct {
+		name         string
+		ledger       []entry // In insertion order, which need not be the order they were recorded in
+		licenseType  models.LicenseType
+		current      float64
+		requested    float64
+		wantErr      string
+		wantCapacity float64
+	}{
+		{name: "no ledger falls back to the license", licenseType: models.LicenseTypeExpand, current: 10, requested: 15, wantCapacity: 10},
+		{
+			name:         "extension against the latest entry",
+			ledger:       []entry{{0, 10}, {30, 12}},
+			licenseType:  models.LicenseTypeExpand,
+			current:      12,
+			requested:    15,
+			wantCapacity: 12,
+		},
+		{
+			name:        "current capacity behind the ledger",
+			ledger:      []entry{{0, 10}, {30, 12}},
+			licenseType: models.LicenseTypeExpand,
+			current:     10,
+			requested:   15,
+			wantErr:     "current capacity 10.00 does not match the licensed capacity 12.00",
+		},
+		{
+			name:         "latest by recording time, not by insertion",
+			ledger:       []entry{{60, 8}, {30, 12}},
+			licenseType:  models.LicenseTypeReduce,
+			current:      8,
+			requested:    5,
+			wantCapacity: 8,
+		},
+		{
+			name:        "reduction must stay below the ledger capacity",
+			ledger:      []entry{{0, 10}, {30, 12}},
+			licenseType: models.LicenseTypeReduce,
+			current:     12,
+			requested:   12,
+			wantErr:     "must be more than 0 and less than the licensed capacity 12.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openRegistryTestDB(t)
+			licenseRepo := repository.NewLicenseRepository(db)
+
+			license := &models.License{
+				LicenseNumber: "LIC-1",
+				HolderUserID:  1,
+				ProjectName:   "Solar farm",
+				Capacity:      10,
+				CapacityUnit:  "MW",
+				Status:        models.LicenseStatusActive,
+				IssuedAt:      issuedAt,
+				ValidFrom:     issuedAt,
+				ValidUntil:    issuedAt.AddDate(models.DefaultLicenseValidityYears, 0, 0),
+			}
+			require.NoError(t, db.Omit("HolderUser", "HolderCorporate").Create(license).Error)
+			for _, e := range tt.ledger {
+				require.NoError(t, licenseRepo.AddCapacityEntry(&models.LicenseCapacityEntry{
+					LicenseID:  license.ID,
+					EntryType:  models.CapacityEntryExtension,
+					Capacity:   e.capacity,
+					RecordedAt: issuedAt.AddDate(0, 0, e.days),
+				}))
+			}
+
+			request := &models.LicenseRequest{
+				ID:                99,
+				UserID:            1,
+				LicenseType:       tt.licenseType,
+				LicenseID:         &license.ID,
+				CurrentCapacity:   tt.current,
+				RequestedCapacity: tt.requested,
+			}
+			checked, err := CheckRequestLicense(licenseRepo, request)
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, ErrLicenseReference)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCapacity, checked.Capacity)
+		})
+	}
+}
+
+func TestCapacityLedgerIsAppendOnly(t *testing.T) {
+	db := openRegistryTestDB(t)
+	entry := &models.LicenseCapacityEntry{LicenseID: 1, EntryType: models.CapacityEntryIssued, Capacity: 10}
+	require.NoError(t, repository.NewLicenseRepository(db).AddCapacityEntry(entry))
+	assert.False(t, entry.RecordedAt.IsZero(), "entries are stamped when they are recorded")
+
+	tests := []struct {
+		name   string
+		change func() error
+	}{
+		{name: "update", change: func() error { return db.Model(entry).Update("capacity", 20).Error }},
+		{name: "save", change: func() error { entry.Capacity = 30; return db.Save(entry).Error }},
+		{name: "delete", change: func() error { return db.Delete(entry).Error }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.change(), models.ErrCapacityLedgerAppendOnly)
+
+			var stored models.LicenseCapacityEntry
+			require.NoError(t, db.First(&stored, entry.ID).Error)
+			assert.Equal(t, float64(10), stored.Capacity)
+		})
+	}
+}
+
+// createTestLicense registers an active license of the holder with its opening ledger entry
+func createTestLicense(t *testing.T, db *gorm.DB, holderID uint, capacity float64) *models.License {
+	t.Helper()
+
+	var count int64
+	require.NoError(t, db.Model(&models.License{}).Count(&count).Error)
+	issuedAt := time.Now().AddDate(-1, 0, 0)
+	license := &models.License{
+		LicenseNumber: fmt.Sprintf("LIC-TEST-%04d", count+1),
+		HolderUserID:  holderID,
+		ProjectName:   "Solar farm",
+		Location:      "1 Energy Road, Bangkok",
+		Capacity:      capacity,
+		CapacityUnit:  "MW",
+		ContactPerson: "Somchai",
+		ContactEmail:  "somchai@example.com",
+		Status:        models.LicenseStatusActive,
+		IssuedAt:      issuedAt,
+		ValidFrom:     issuedAt,
+		ValidUntil:    issuedAt.AddDate(models.DefaultLicenseValidityYears, 0, 0),
+	}
+	licenseRepo := repository.NewLicenseRepository(db)
+	require.NoError(t, licenseRepo.Create(license))
+	require.NoError(t, licenseRepo.AddCapacityEntry(openingCapacityEntry(license, models.CapacityEntryIssued, nil)))
+	return license
+}
+
+// setTestRequestDetails fills in what the applicant filed: the license referred to, the capacities and the payload
+func setTestRequestDetails(t *testing.T, db *gorm.DB, request *models.LicenseRequest, license *models.License, current, requested float64, payload interface{}) {
+	t.Helper()
+
+	encoded, err := json.Marshal(payload)
+	require.NoError(t, err)
+	updates := map[string]interface{}{
+		"current_capacity":   current,
+		"requested_capacity": requested,
+		"payload":            string(encoded),
+	}
+	if license != nil {
+		updates["license_id"] = license.ID
+		updates["license_number"] = license.LicenseNumber
+	}
+	require.NoError(t, db.Model(request).Updates(updates).Error)
+}
+
+// approveTestRequest applies the request to the license registry as its approval does
+func approveTestRequest(db *gorm.DB, request *models.LicenseRequest) (*models.License, error) {
+	var license *models.License
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		license, err = issueLicense(tx, &WorkflowRequestRecord{ID: request.ID}, time.Now())
+		return err
+	})
+	return license, err
+}
+
+func TestIssueLicense(t *testing.T) {
+	tests := []struct {
+		name         string
+		licenseType  models.LicenseType
+		current      float64
+		requested    float64
+		payload      interface{}
+		wantErr      error
+		wantCapacity float64
+		wantEntries  int // Capacity ledger entries of the license afterwards
+		wantExtended bool
+	}{
+		{
+			name:         "new request issues a license",
+			licenseType:  models.LicenseTypeNew,
+			requested:    8,
+			payload:      models.NewLicensePayload{Province: "Chiang Mai", EnergyType: "solar", CapacityUnit: "MW"},
+			wantCapacity: 8,
+			wantEntries:  1,
+		},
+		{
+			name:         "renewal extends the validity",
+			licenseType:  models.LicenseTypeRenew,
+			payload:      models.RenewalLicensePayload{RequestedExpiryDate: time.Now().AddDate(10, 0, 0)},
+			wantCapacity: 10,
+			wantEntries:  1,
+			wantExtended: true,
+		},
+		{name: "extension raises the capacity", licenseType: models.LicenseTypeExpand, current: 10, requested: 15, payload: models.ExtensionLicensePayload{}, wantCapacity: 15, wantEntries: 2},
+		{name: "reduction lowers the capacity", licenseType: models.LicenseTypeReduce, current: 10, requested: 4, payload: models.ReductionLicensePayload{}, wantCapacity: 4, wantEntries: 2},
+		{
+			name:        "license changed since submission",
+			licenseType: models.LicenseTypeExpand,
+			current:     12,
+			requested:   15,
+			payload:     models.ExtensionLicensePayload{},
+			wantErr:     ErrInvalidTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			applicant := createTestUser(t, db, models.RoleUser)
+			request := createTestRequest(t, db, applicant.ID, tt.licenseType, models.StatusApproved)
+			var existing *models.License
+			if tt.licenseType != models.LicenseTypeNew {
+				existing = createTestLicense(t, db, applicant.ID, 10)
+			}
+			setTestRequestDetails(t, db, request, existing, tt.current, tt.requested, tt.payload)
+
+			license, err := approveTestRequest(db, request)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if existing != nil {
+				assert.Equal(t, existing.ID, license.ID, "the request changes the license it refers to")
+			}
+
+			stored, err := repository.NewLicenseRepository(db).GetByID(license.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCapacity, stored.Capacity)
+			assert.Equal(t, models.LicenseStatusActive, stored.Status)
+			if tt.wantExtended {
+				assert.True(t, stored.ValidUntil.After(existing.ValidUntil))
+			}
+
+			var entries []models.LicenseCapacityEntry
+			require.NoError(t, db.Where("license_id = ?", license.ID).Order("id").Find(&entries).Error)
+			require.Len(t, entries, tt.wantEntries)
+			assert.Equal(t, tt.wantCapacity, entries[len(entries)-1].Capacity)
+
+			// The request is linked to the license with the validity the approval left it with
+			linked := reloadTestRequest(t, db, request.ID)
+			require.NotNil(t, linked.LicenseID)
+			assert.Equal(t, license.ID, *linked.LicenseID)
+			assert.Equal(t, stored.LicenseNumber, linked.LicenseNumber)
+			require.NotNil(t, linked.LicenseValidUntil)
+			assert.True(t, stored.ValidUntil.Equal(*linked.LicenseValidUntil))
+
+			// Replaying the approval does not issue a second license
+			if tt.licenseType == models.LicenseTypeNew {
+				replayed, err := approveTestRequest(db, request)
+				require.NoError(t, err)
+				assert.Equal(t, license.ID, replayed.ID)
+				var licenses int64
+				require.NoError(t, db.Model(&models.License{}).Count(&licenses).Error)
+				assert.Equal(t, int64(1), licenses)
+			}
+		})
+	}
+}
+
+func TestIssueLicenseModifyAndCancel(t *testing.T) {
+	tests := []struct {
+		name          string
+		licenseType   models.LicenseType
+		licenseStatus models.LicenseStatus
+		payload       interface{}
+		wantErr       error
+		wantStatus    models.LicenseStatus
+		wantLocation  string
+		wantContact   string
+		wantEntries   int // Capacity ledger entries of the license afterwards
+	}{
+		{
+			name:         "modify the address",
+			licenseType:  models.LicenseTypeModify,
+			payload:      models.ModifyLicensePayload{ProjectAddress: "9 Solar Road", District: "Mueang", Province: "Chiang Mai", PostalCode: "50000"},
+			wantStatus:   models.LicenseStatusActive,
+			wantLocation: "9 Solar Road, Mueang, Chiang Mai 50000",
+			wantContact:  "Somchai",
+			wantEntries:  1,
+		},
+		{
+			name:         "modify the contact only",
+			licenseType:  models.LicenseTypeModify,
+			payload:      models.ModifyLicensePayload{ContactPerson: "Somsri", ModificationReason: "New site manager"},
+			wantStatus:   models.LicenseStatusActive,
+			wantLocation: "1 Energy Road, Bangkok",
+			wantContact:  "Somsri",
+			wantEntries:  1,
+		},
+		{
+			name:        "modify without changes",
+			licenseType: models.LicenseTypeModify,
+			payload:     models.ModifyLicensePayload{ModificationReason: "Nothing to change"},
+			wantErr:     ErrInvalidTransition,
+		},
+		{
+			name:          "modify a suspended license",
+			licenseType:   models.LicenseTypeModify,
+			licenseStatus: models.LicenseStatusSuspended,
+			payload:       models.ModifyLicensePayload{ContactPerson: "Somsri"},
+			wantErr:       ErrInvalidTransition,
+		},
+		{
+			name:         "cancel retires the license",
+			licenseType:  models.LicenseTypeCancel,
+			payload:      models.CancelLicensePayload{CancellationReason: "Plant closed", LastOperationDate: time.Now()},
+			wantStatus:   models.LicenseStatusCancelled,
+			wantLocation: "1 Energy Road, Bangkok",
+			wantContact:  "Somchai",
+			wantEntries:  2,
+		},
+		{
+			name:          "cancel a cancelled license",
+			licenseType:   models.LicenseTypeCancel,
+			licenseStatus: models.LicenseStatusCancelled,
+			payload:       models.CancelLicensePayload{CancellationReason: "Plant closed"},
+			wantErr:       ErrInvalidTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			applicant := createTestUser(t, db, models.RoleUser)
+			request := createTestRequest(t, db, applicant.ID, tt.licenseType, models.StatusApproved)
+			license := createTestLicense(t, db, applicant.ID, 10)
+			if tt.licenseStatus != "" {
+				require.NoError(t, db.Model(license).Update("status", tt.licenseStatus).Error)
+			}
+			setTestRequestDetails(t, db, request, license, 0, 0, tt.payload)
+
+			_, err := approveTestRequest(db, request)
+			stored, getErr := repository.NewLicenseRepository(db).GetByID(license.ID)
+			require.NoError(t, getErr)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, stored.LastAmendedRequestID, "a rejected change leaves the license alone")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, stored.Status)
+			assert.Equal(t, tt.wantLocation, stored.Location)
+			assert.Equal(t, tt.wantContact, stored.ContactPerson)
+			assert.Equal(t, "somchai@example.com", stored.ContactEmail, "particulars left blank stay as they are")
+			assert.Equal(t, float64(10), stored.Capacity)
+			require.NotNil(t, stored.LastAmendedRequestID)
+			assert.Equal(t, request.ID, *stored.LastAmendedRequestID)
+			assert.Equal(t, tt.wantStatus == models.LicenseStatusCancelled, stored.CancelledAt != nil)
+
+			var entries []models.LicenseCapacityEntry
+			require.NoError(t, db.Where("license_id = ?", license.ID).Order("id").Find(&entries).Error)
+			require.Len(t, entries, tt.wantEntries)
+			if tt.licenseType == models.LicenseTypeCancel {
+				last := entries[len(entries)-1]
+				assert.Equal(t, models.CapacityEntryCancelled, last.EntryType)
+				assert.Equal(t, float64(10), last.PreviousCapacity)
+				assert.Zero(t, last.Capacity, "a retired license licenses no capacity")
+			}
+		})
+	}
+}
+
+func reloadTestLicense(t *testing.T, db *gorm.DB, id uint) *models.License {
+	t.Helper()
+
+	license, err := repository.NewLicenseRepository(db).GetByID(id)
+	require.NoError(t, err)
+	return license
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// verificationAlgorithm is the signature algorithm of license verification statements
+const verificationAlgorithm = "Ed25519"
+
+type LicenseVerificationService interface {
+	VerifyByToken(token string) (*dto.SignedLicenseVerification, error)
+	VerifyByNumber(licenseNumber string) (*dto.SignedLicenseVerification, error)
+	GetVerificationKey() *dto.VerificationKeyResponse
+}
+
+// ErrSigningKeyMissing is returned when LICENSE_SIGNING_KEY is not set. Verification is refused
+// rather than signed with a key derived from another secret, which may be a well-known default.
+var ErrSigningKeyMissing = errors.New("LICENSE_SIGNING_KEY is not set")
+
+type licenseVerificationService struct {
+	licenseRepo repository.LicenseRepository
+	signingKey  ed25519.PrivateKey
+}
+
+// NewLicenseVerificationService fails when the signing key is missing or invalid
+func NewLicenseVerificationService(db *gorm.DB, cfg *config.Config) (LicenseVerificationService, error) {
+	signingKey, err := loadSigningKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &licenseVerificationService{
+		licenseRepo: repository.NewLicenseRepository(db),
+		signingKey:  signingKey,
+	}, nil
+}
+
+// loadSigningKey reads LICENSE_SIGNING_KEY, a base64 Ed25519 seed or private key
+func loadSigningKey(cfg *config.Config) (ed25519.PrivateKey, error) {
+	if cfg.LicenseSigningKey == "" {
+		return nil, ErrSigningKeyMissing
+	}
+
+	key, err := base64.StdEncoding.DecodeString(cfg.LicenseSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid license signing key: %w", err)
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	default:
+		return nil, fmt.Errorf("invalid license signing key: %d bytes, want %d or %d", len(key), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+// VerifyByToken returns the signed statement of the license whose certificate QR code carries the token
+func (s *licenseVerificationService) VerifyByToken(token string) (*dto.SignedLicenseVerification, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrLicenseNotFound
+	}
+	return s.verify(s.licenseRepo.GetByVerificationToken(token))
+}
+
+// VerifyByNumber returns the signed statement of a license by its exact number
+func (s *licenseVerificationService) VerifyByNumber(licenseNumber string) (*dto.SignedLicenseVerification, error) {
+	licenseNumber = strings.TrimSpace(licenseNumber)
+	if licenseNumber == "" {
+		return nil, ErrLicenseNotFound
+	}
+	return s.verify(s.licenseRepo.GetByLicenseNumber(licenseNumber))
+}
+
+// GetVerificationKey returns the public key third parties use to check statements offline
+func (s *licenseVerificationService) GetVerificationKey() *dto.VerificationKeyResponse {
+	publicKey := s.signingKey.Public().(ed25519.PublicKey)
+	return &dto.VerificationKeyResponse{
+		Algorithm: verificationAlgorithm,
+		KeyID:     verificationKeyID(publicKey),
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+	}
+}
+
+// verify signs the privacy-safe view of a looked-up license. Lookups that find nothing all
+// return ErrLicenseNotFound, so callers cannot tell a malformed token from an unknown one.
+func (s *licenseVerificationService) verify(license *models.License, err error) (*dto.SignedLicenseVerification, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLicenseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+
+	now := time.Now()
+	verification := dto.LicenseVerification{
+		LicenseNumber: license.LicenseNumber,
+		HolderName:    license.HolderName(),
+		ProjectName:   license.ProjectName,
+		Capacity:      license.Capacity,
+		CapacityUnit:  license.CapacityUnit,
+		ValidFrom:     license.ValidFrom,
+		ValidUntil:    license.ValidUntil,
+		Status:        license.CurrentStatus(now),
+		VerifiedAt:    now,
+	}
+	payload, err := json.Marshal(verification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode license verification: %w", err)
+	}
+
+	return &dto.SignedLicenseVerification{
+		License:       verification,
+		SignedPayload: base64.StdEncoding.EncodeToString(payload),
+		Signature:     base64.StdEncoding.EncodeToString(ed25519.Sign(s.signingKey, payload)),
+		Algorithm:     verificationAlgorithm,
+		KeyID:         verificationKeyID(s.signingKey.Public().(ed25519.PublicKey)),
+	}, nil
+}
+
+// verificationKeyID names a public key by the start of its SHA-256 digest, so that keys can be rotated
+func verificationKeyID(publicKey ed25519.PublicKey) string {
+	digest := sha256.Sum256(publicKey)
+	return hex.EncodeToString(digest[:8])
+}
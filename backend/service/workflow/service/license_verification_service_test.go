@@ -0,0 +1,126 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSigningKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{name: "seed", key: base64.StdEncoding.EncodeToString(seed)},
+		{name: "private key", key: base64.StdEncoding.EncodeToString(privateKey)},
+		{name: "missing", wantErr: ErrSigningKeyMissing.Error()},
+		{name: "not base64", key: "not a key!", wantErr: "invalid license signing key"},
+		{name: "wrong size", key: base64.StdEncoding.EncodeToString(seed[:16]), wantErr: "16 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := loadSigningKey(&config.Config{LicenseSigningKey: tt.key})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, privateKey, key, "a seed and its private key sign alike")
+		})
+	}
+}
+
+func TestVerifyLicense(t *testing.T) {
+	tests := []struct {
+		name       string
+		byNumber   bool
+		lookup     string // Overrides the token or number of the license
+		expired    bool
+		wantErr    error
+		wantStatus models.LicenseStatus
+	}{
+		{name: "by token", wantStatus: models.LicenseStatusActive},
+		{name: "by number", byNumber: true, wantStatus: models.LicenseStatusActive},
+		{name: "past its validity", expired: true, wantStatus: models.LicenseStatusExpired},
+		{name: "unknown token", lookup: "not-a-token", wantErr: ErrLicenseNotFound},
+		{name: "unknown number", byNumber: true, lookup: "LIC-0000-000000", wantErr: ErrLicenseNotFound},
+		{name: "blank token", lookup: "  ", wantErr: ErrLicenseNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			seed := make([]byte, ed25519.SeedSize)
+			cfg := &config.Config{LicenseSigningKey: base64.StdEncoding.EncodeToString(seed)}
+			s, err := NewLicenseVerificationService(db, cfg)
+			require.NoError(t, err)
+
+			holder := createTestUser(t, db, models.RoleUser)
+			license := createTestLicense(t, db, holder.ID, 10)
+			if tt.expired {
+				require.NoError(t, db.Model(license).Update("valid_until", time.Now().AddDate(0, 0, -1)).Error)
+			}
+
+			lookup := license.VerificationToken
+			if tt.byNumber {
+				lookup = license.LicenseNumber
+			}
+			if tt.lookup != "" {
+				lookup = tt.lookup
+			}
+			var signed *dto.SignedLicenseVerification
+			if tt.byNumber {
+				signed, err = s.VerifyByNumber(lookup)
+			} else {
+				signed, err = s.VerifyByToken(lookup)
+			}
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, license.LicenseNumber, signed.License.LicenseNumber)
+			assert.Equal(t, tt.wantStatus, signed.License.Status)
+
+			// A third party checks the statement offline with the published key
+			key := s.GetVerificationKey()
+			assert.Equal(t, key.Algorithm, signed.Algorithm)
+			assert.Equal(t, key.KeyID, signed.KeyID)
+			publicKey, err := base64.StdEncoding.DecodeString(key.PublicKey)
+			require.NoError(t, err)
+			payload, err := base64.StdEncoding.DecodeString(signed.SignedPayload)
+			require.NoError(t, err)
+			signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+			require.NoError(t, err)
+			assert.True(t, ed25519.Verify(publicKey, payload, signature))
+
+			var statement dto.LicenseVerification
+			require.NoError(t, json.Unmarshal(payload, &statement))
+			assert.Equal(t, signed.License.LicenseNumber, statement.LicenseNumber)
+			assert.Equal(t, signed.License.Status, statement.Status)
+
+			// A statement altered after signing no longer verifies
+			statement.Capacity = 100
+			tampered, err := json.Marshal(statement)
+			require.NoError(t, err)
+			assert.False(t, ed25519.Verify(publicKey, tampered, signature))
+		})
+	}
+}
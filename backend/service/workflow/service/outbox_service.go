@@ -0,0 +1,264 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/utils"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// outboxStaleAfter is how long a message may stay in processing before it is handed back to the dispatcher
+const outboxStaleAfter = 10 * time.Minute
+
+// Broadcaster pushes notifications to connected websocket clients
+type Broadcaster interface {
+	BroadcastToUser(userID uint, notification models.Notification) error
+	BroadcastToRole(role models.UserRole, notification models.Notification) error
+}
+
+type OutboxService interface {
+	EnqueueNotification(tx *gorm.DB, notification models.Notification) error
+	EnqueueCertificate(tx *gorm.DB, licenseID uint) error
+	EnqueueReceipt(tx *gorm.DB, invoiceID uint) error
+	DispatchPending(limit int) (int, error)
+	GetStatistics() (map[string]interface{}, error)
+}
+
+type outboxService struct {
+	db                 *gorm.DB
+	outboxRepo         repository.OutboxRepository
+	emailConfig        utils.EmailConfig
+	broadcaster        Broadcaster
+	certificateService LicenseCertificateService
+	receiptService     PaymentReceiptService
+}
+
+func NewOutboxService(db *gorm.DB, cfg *config.Config) OutboxService {
+	return &outboxService{
+		db:         db,
+		outboxRepo: repository.NewOutboxRepository(db),
+		emailConfig: utils.EmailConfig{
+			Host:     cfg.EmailHost,
+			Port:     cfg.EmailPort,
+			Username: cfg.EmailUser,
+			Password: cfg.EmailPass,
+			From:     cfg.EmailFrom,
+		},
+		broadcaster:        NewNotificationService(db),
+		certificateService: NewLicenseCertificateService(db, cfg),
+		receiptService:     NewPaymentReceiptService(db, cfg),
+	}
+}
+
+// EnqueueNotification writes an in-app notification, its websocket push and, for a single
+// recipient with an email address, an email to the outbox using the caller's transaction
+func (s *outboxService) EnqueueNotification(tx *gorm.DB, notification models.Notification) error {
+	outboxRepo := repository.NewOutboxRepository(tx)
+
+	if err := s.enqueue(outboxRepo, models.OutboxChannelNotification, notification, notification.EntityType, notification.EntityID); err != nil {
+		return err
+	}
+
+	push := models.OutboxWebSocketPayload{
+		RecipientID:   notification.RecipientID,
+		RecipientRole: notification.RecipientRole,
+		Notification:  notification,
+	}
+	if err := s.enqueue(outboxRepo, models.OutboxChannelWebSocket, push, notification.EntityType, notification.EntityID); err != nil {
+		return err
+	}
+
+	if notification.RecipientID == nil {
+		return nil
+	}
+
+	var recipient models.User
+	if err := tx.Select("id", "email").First(&recipient, *notification.RecipientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get notification recipient: %w", err)
+	}
+	if recipient.Email == "" {
+		return nil
+	}
+
+	email := models.OutboxEmailPayload{
+		To:      []string{recipient.Email},
+		Subject: notification.Title,
+		Body:    notification.Message,
+	}
+	return s.enqueue(outboxRepo, models.OutboxChannelEmail, email, notification.EntityType, notification.EntityID)
+}
+
+// EnqueueCertificate writes the rendering of a license certificate to the outbox using the caller's
+// transaction, so a certificate is only produced for an issue or amendment that commits
+func (s *outboxService) EnqueueCertificate(tx *gorm.DB, licenseID uint) error {
+	payload := models.OutboxCertificatePayload{LicenseID: licenseID}
+	return s.enqueue(repository.NewOutboxRepository(tx), models.OutboxChannelCertificate, payload, "license", &licenseID)
+}
+
+// EnqueueReceipt writes the rendering of a fee receipt to the outbox using the caller's transaction,
+// so a receipt is only produced for a payment that commits
+func (s *outboxService) EnqueueReceipt(tx *gorm.DB, invoiceID uint) error {
+	payload := models.OutboxReceiptPayload{InvoiceID: invoiceID}
+	return s.enqueue(repository.NewOutboxRepository(tx), models.OutboxChannelReceipt, payload, "invoice", &invoiceID)
+}
+
+func (s *outboxService) enqueue(outboxRepo repository.OutboxRepository, channel models.OutboxChannel, payload interface{}, entityType string, entityID *uint) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+
+	message := &models.OutboxMessage{
+		Channel:    channel,
+		Payload:    string(data),
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+	if err := outboxRepo.Create(message); err != nil {
+		return fmt.Errorf("failed to enqueue %s message: %w", channel, err)
+	}
+	return nil
+}
+
+// DispatchPending delivers up to limit pending messages and returns how many were sent
+func (s *outboxService) DispatchPending(limit int) (int, error) {
+	if err := s.releaseStale(); err != nil {
+		return 0, err
+	}
+
+	messages, err := s.outboxRepo.GetPending(limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending outbox messages: %w", err)
+	}
+
+	sent := 0
+	for _, message := range messages {
+		claimed, err := s.outboxRepo.Claim(message.ID)
+		if err != nil {
+			return sent, fmt.Errorf("failed to claim outbox message %d: %w", message.ID, err)
+		}
+		if !claimed {
+			// Another dispatcher picked it up
+			continue
+		}
+
+		if err := s.deliver(&message); err != nil {
+			attempts := message.Attempts + 1
+			var retryAt *time.Time
+			if attempts < models.OutboxMaxAttempts {
+				// Back off exponentially: 1, 2, 4, 8 minutes
+				next := time.Now().Add(time.Duration(1<<(attempts-1)) * time.Minute)
+				retryAt = &next
+			}
+			log.Printf("Failed to deliver outbox message %d (%s), attempt %d: %v", message.ID, message.Channel, attempts, err)
+			if markErr := s.outboxRepo.MarkFailed(message.ID, attempts, err.Error(), retryAt); markErr != nil {
+				return sent, markErr
+			}
+			continue
+		}
+
+		if err := s.outboxRepo.MarkSent(message.ID); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// releaseStale returns messages left in processing by a dispatcher that stopped mid-delivery
+func (s *outboxService) releaseStale() error {
+	return s.db.Model(&models.OutboxMessage{}).
+		Where("status = ? AND updated_at < ?", models.OutboxStatusProcessing, time.Now().Add(-outboxStaleAfter)).
+		Update("status", models.OutboxStatusPending).Error
+}
+
+func (s *outboxService) deliver(message *models.OutboxMessage) error {
+	switch message.Channel {
+	case models.OutboxChannelNotification:
+		var notification models.Notification
+		if err := json.Unmarshal([]byte(message.Payload), &notification); err != nil {
+			return fmt.Errorf("invalid notification payload: %w", err)
+		}
+		notification.ID = 0
+		return s.db.Create(&notification).Error
+
+	case models.OutboxChannelEmail:
+		var payload models.OutboxEmailPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return fmt.Errorf("invalid email payload: %w", err)
+		}
+		email := utils.EmailMessage{
+			To:      payload.To,
+			Subject: payload.Subject,
+			Body:    payload.Body,
+			IsHTML:  payload.IsHTML,
+		}
+		if s.emailConfig.Username == "" {
+			// SMTP is not configured, e.g. in development
+			log.Printf("Email delivery disabled, skipping email to %s: %s", email.To, email.Subject)
+			return nil
+		}
+		err := utils.SendEmail(s.emailConfig, email)
+		utils.LogEmail(email, err)
+		return err
+
+	case models.OutboxChannelWebSocket:
+		var payload models.OutboxWebSocketPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return fmt.Errorf("invalid websocket payload: %w", err)
+		}
+		if payload.RecipientID != nil {
+			return s.broadcaster.BroadcastToUser(*payload.RecipientID, payload.Notification)
+		}
+		if payload.RecipientRole != nil && *payload.RecipientRole != "" {
+			return s.broadcaster.BroadcastToRole(*payload.RecipientRole, payload.Notification)
+		}
+		return nil
+
+	case models.OutboxChannelCertificate:
+		var payload models.OutboxCertificatePayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return fmt.Errorf("invalid certificate payload: %w", err)
+		}
+		_, err := s.certificateService.GenerateCertificate(payload.LicenseID)
+		return err
+
+	case models.OutboxChannelReceipt:
+		var payload models.OutboxReceiptPayload
+		if err := json.Unmarshal([]byte(message.Payload), &payload); err != nil {
+			return fmt.Errorf("invalid receipt payload: %w", err)
+		}
+		_, err := s.receiptService.GenerateReceipt(payload.InvoiceID)
+		return err
+
+	default:
+		return fmt.Errorf("unsupported outbox channel: %s", message.Channel)
+	}
+}
+
+// GetStatistics returns outbox message counts by status
+func (s *outboxService) GetStatistics() (map[string]interface{}, error) {
+	counts, err := s.outboxRepo.CountByStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]interface{}{
+		"pending":    counts[models.OutboxStatusPending],
+		"processing": counts[models.OutboxStatusProcessing],
+		"sent":       counts[models.OutboxStatusSent],
+		"failed":     counts[models.OutboxStatusFailed],
+	}
+	return stats, nil
+}
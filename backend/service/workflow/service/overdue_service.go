@@ -1,8 +1,11 @@
 package service
 
 import (
+	"errors"
+	"eservice-backend/config"
 	"eservice-backend/models"
 	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
 	"fmt"
 	"log"
 	"time"
@@ -12,31 +15,25 @@ import (
 
 type OverdueService interface {
 	CheckOverdueRequests() error
-	ProcessOverdueRequest(requestID uint, licenseType string) error
+	ProcessOverdueRequest(requestID uint) error
 	SendOverdueNotifications() error
 	GetOverdueStatistics() (map[string]interface{}, error)
 }
 
 type overdueService struct {
 	db                   *gorm.DB
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
+	transitionService    WorkflowTransitionService
+	definitionService    WorkflowDefinitionService
 	notificationRepo     repository.NotificationRepository
-	serviceFlowLogRepo   repository.ServiceFlowLogRepo
 	deadlineReminderRepo *gorm.DB
 }
 
-func NewOverdueService(db *gorm.DB) OverdueService {
+func NewOverdueService(db *gorm.DB, cfg *config.Config) OverdueService {
 	return &overdueService{
 		db:                   db,
-		newLicenseRepo:       repository.NewNewLicenseRepo(db),
-		renewalLicenseRepo:   repository.NewRenewalLicenseRepo(db),
-		extensionLicenseRepo: repository.NewExtensionLicenseRepo(db),
-		reductionLicenseRepo: repository.NewReductionLicenseRepo(db),
+		transitionService:    NewWorkflowTransitionService(db, cfg),
+		definitionService:    NewWorkflowDefinitionService(db),
 		notificationRepo:     repository.NewNotificationRepository(db),
-		serviceFlowLogRepo:   repository.NewServiceFlowLogRepo(db),
 		deadlineReminderRepo: db,
 	}
 }
@@ -64,105 +61,20 @@ func (s *overdueService) CheckOverdueRequests() error {
 	return nil
 }
 
-// ProcessOverdueRequest processes a specific overdue request
-func (s *overdueService) ProcessOverdueRequest(requestID uint, licenseType string) error {
-	var userID uint
-	var requestNumber string
-	var currentStatus models.RequestStatus
-
-	switch licenseType {
-	case "new":
-		req, err := s.newLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return fmt.Errorf("request not found: %w", err)
-		}
-		userID = req.UserID
-		requestNumber = req.RequestNumber
-		currentStatus = req.Status
-
-	case "renewal":
-		req, err := s.renewalLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return fmt.Errorf("request not found: %w", err)
-		}
-		userID = req.UserID
-		requestNumber = req.RequestNumber
-		currentStatus = req.Status
-
-	case "extension":
-		req, err := s.extensionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return fmt.Errorf("request not found: %w", err)
-		}
-		userID = req.UserID
-		requestNumber = req.RequestNumber
-		currentStatus = req.Status
-
-	case "reduction":
-		req, err := s.reductionLicenseRepo.GetByID(requestID)
-		if err != nil {
-			return fmt.Errorf("request not found: %w", err)
-		}
-		userID = req.UserID
-		requestNumber = req.RequestNumber
-		currentStatus = req.Status
-
-	default:
-		return fmt.Errorf("unsupported license type: %s", licenseType)
-	}
-
-	// Update request status to Overdue
-	if err := s.updateRequestStatusToOverdue(requestID, licenseType); err != nil {
-		return fmt.Errorf("failed to update request status: %w", err)
-	}
-
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: requestID,
-		PreviousStatus:   &currentStatus,
-		NewStatus:        models.StatusOverdue,
-		ChangedBy:        nil, // System change
-		ChangeReason:     "Auto-cancelled due to timeout",
-		LicenseType:      licenseType,
-	}
-
-	if err := s.serviceFlowLogRepo.Create(flowLog); err != nil {
-		log.Printf("Failed to create flow log for request %d: %v", requestID, err)
-	}
-
-	// Create notification for user
-	notification := &models.Notification{
-		Title:       "คำขอหมดอายุเนื่องจากเกินกำหนดเวลา",
-		Message:     fmt.Sprintf("คำขอเลขที่ %s ถูกยกเลิกโดยอัตโนมัติเนื่องจากเกินกำหนดเวลาดำเนินการ", requestNumber),
-		Type:        models.NotificationType("request_overdue"),
-		Priority:    models.PriorityHigh,
-		RecipientID: &userID,
-		EntityType:  "license_request",
-		EntityID:    &requestID,
-		ActionURL:   "/dashboard/licenses",
-	}
-
-	if err := s.notificationRepo.Create(notification); err != nil {
-		log.Printf("Failed to create notification for user %d: %v", userID, err)
-	}
-
-	// Create notification for admins
-	adminNotification := &models.Notification{
-		Title:         "คำขอถูกยกเลิกโดยอัตโนมัติ",
-		Message:       fmt.Sprintf("คำขอเลขที่ %s ถูกยกเลิกโดยอัตโนมัติเนื่องจากเกินกำหนดเวลา", requestNumber),
-		Type:          models.NotificationType("request_overdue"),
-		Priority:      models.PriorityHigh,
-		RecipientRole: &[]models.UserRole{models.UserRole("admin")}[0],
-		EntityType:    "license_request",
-		EntityID:      &requestID,
-		ActionURL:     "/admin-portal/services",
-	}
-
-	if err := s.notificationRepo.Create(adminNotification); err != nil {
-		log.Printf("Failed to create notification for admins: %v", err)
+// ProcessOverdueRequest cancels a request that ran out of time through the workflow definition's overdue
+// transition. Statuses without one, such as terminal or applicant-owned statuses, are rejected as invalid.
+func (s *overdueService) ProcessOverdueRequest(requestID uint) error {
+	result, err := s.transitionService.ProcessTransition(dto.WorkflowTransitionRequest{
+		RequestID:    requestID,
+		ToStatus:     models.StatusOverdue,
+		Comments:     "Auto-cancelled due to timeout",
+		AutoApproved: true,
+	})
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Request %d (%s) marked as overdue and cancelled", requestID, licenseType)
+	log.Printf("Request %d (%s) marked as overdue and cancelled", requestID, result.LicenseType)
 	return nil
 }
 
@@ -223,7 +135,7 @@ func (s *overdueService) GetOverdueStatistics() (map[string]interface{}, error)
 
 	// Count overdue requests by status
 	var overdueCount int64
-	s.db.Model(&models.NewLicenseRequest{}).Where("status = ?", models.StatusOverdue).Count(&overdueCount)
+	s.db.Model(&models.LicenseRequest{}).Where("status = ?", models.StatusOverdue).Count(&overdueCount)
 	stats["overdue_requests"] = overdueCount
 
 	// Count active deadline reminders
@@ -250,14 +162,14 @@ func (s *overdueService) GetOverdueStatistics() (map[string]interface{}, error)
 
 func (s *overdueService) checkOverdueAppointments() error {
 	// Get all requests with appointment status that are overdue
-	var requests []models.NewLicenseRequest
+	var requests []models.LicenseRequest
 	err := s.db.Where("status = ? AND appointment_date < ?", models.StatusAppointment, time.Now()).Find(&requests).Error
 	if err != nil {
 		return err
 	}
 
 	for _, req := range requests {
-		if err := s.ProcessOverdueRequest(req.ID, "new"); err != nil {
+		if err := s.ProcessOverdueRequest(req.ID); err != nil {
 			log.Printf("Failed to process overdue appointment for request %d: %v", req.ID, err)
 		}
 	}
@@ -268,14 +180,14 @@ func (s *overdueService) checkOverdueAppointments() error {
 func (s *overdueService) checkOverdueDocumentReviews() error {
 	// Get all requests with document edit status that are overdue (14+ days)
 	fourteenDaysAgo := time.Now().AddDate(0, 0, -14)
-	var requests []models.NewLicenseRequest
+	var requests []models.LicenseRequest
 	err := s.db.Where("status = ? AND updated_at < ?", models.StatusDocumentEdit, fourteenDaysAgo).Find(&requests).Error
 	if err != nil {
 		return err
 	}
 
 	for _, req := range requests {
-		if err := s.ProcessOverdueRequest(req.ID, "new"); err != nil {
+		if err := s.ProcessOverdueRequest(req.ID); err != nil {
 			log.Printf("Failed to process overdue document review for request %d: %v", req.ID, err)
 		}
 	}
@@ -292,12 +204,25 @@ func (s *overdueService) checkOverdueTasks() error {
 	}
 
 	for _, task := range tasks {
+		// Time only runs out while DEDE holds the request, not while it waits on the applicant
+		withDEDE, err := s.isWithDEDE(task.RequestID)
+		if err != nil {
+			log.Printf("Failed to check SLA clock of request %d: %v", task.RequestID, err)
+			continue
+		}
+		if !withDEDE {
+			continue
+		}
+
 		// Mark task as overdue
 		task.Status = models.TaskStatusOverdue
-		s.db.Save(&task)
+		if err := s.db.Save(&task).Error; err != nil {
+			log.Printf("Failed to mark task %d overdue: %v", task.ID, err)
+			continue
+		}
 
 		// Process the associated request as overdue
-		if err := s.ProcessOverdueRequest(task.RequestID, task.LicenseType); err != nil {
+		if err := s.ProcessOverdueRequest(task.RequestID); err != nil {
 			log.Printf("Failed to process overdue task for request %d: %v", task.RequestID, err)
 		}
 	}
@@ -305,18 +230,37 @@ func (s *overdueService) checkOverdueTasks() error {
 	return nil
 }
 
-func (s *overdueService) updateRequestStatusToOverdue(requestID uint, licenseType string) error {
-	switch licenseType {
-	case "new":
-		return s.db.Model(&models.NewLicenseRequest{}).Where("id = ?", requestID).Update("status", models.StatusOverdue).Error
-	case "renewal":
-		return s.db.Model(&models.RenewalLicenseRequest{}).Where("id = ?", requestID).Update("status", models.StatusOverdue).Error
-	case "extension":
-		return s.db.Model(&models.ExtensionLicenseRequest{}).Where("id = ?", requestID).Update("status", models.StatusOverdue).Error
-	case "reduction":
-		return s.db.Model(&models.ReductionLicenseRequest{}).Where("id = ?", requestID).Update("status", models.StatusOverdue).Error
+// isWithDEDE reports whether a request's SLA clock is running: its status belongs to DEDE in the
+// workflow definition it is pinned to, and its clock has not been paused or stopped
+func (s *overdueService) isWithDEDE(requestID uint) (bool, error) {
+	var record WorkflowRequestRecord
+	err := s.db.Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", requestID).
+		Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return false, fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+	if stateMachine.SLAClockState(record.Status) != models.SLAClockRunning {
+		return false, nil
+	}
+
+	var clock models.RequestSLAClock
+	err = s.db.Where("request_id = ?", requestID).Take(&clock).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get SLA clock: %w", err)
 	}
-	return fmt.Errorf("unsupported license type: %s", licenseType)
+	return clock.State == models.SLAClockRunning, nil
 }
 
 func (s *overdueService) sendDeadlineReminder(reminder models.DeadlineReminder, reminderType string) error {
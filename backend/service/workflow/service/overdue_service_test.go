@@ -0,0 +1,164 @@
+package service
+
+import (
+	"eservice-backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessOverdueRequest(t *testing.T) {
+	tests := []struct {
+		status      models.RequestStatus
+		wantOverdue bool
+	}{
+		{status: models.StatusAppointment, wantOverdue: true},
+		{status: models.StatusDocumentEdit, wantOverdue: true},
+		{status: models.StatusAssigned},
+		{status: models.StatusReturned},
+		{status: models.StatusApproved},
+		{status: models.StatusWithdrawn},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := &overdueService{
+				db:                db,
+				transitionService: newTestTransitionService(db),
+				definitionService: NewWorkflowDefinitionService(db),
+			}
+			applicant := createTestUser(t, db, models.RoleUser)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, tt.status)
+
+			err := s.ProcessOverdueRequest(request.ID)
+			stored := reloadTestRequest(t, db, request.ID)
+			if !tt.wantOverdue {
+				require.ErrorIs(t, err, ErrInvalidTransition)
+				assert.Equal(t, tt.status, stored.Status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.StatusOverdue, stored.Status)
+			assert.Equal(t, 2, stored.Version)
+
+			var flowLog models.ServiceFlowLog
+			require.NoError(t, db.Where("license_request_id = ?", request.ID).First(&flowLog).Error)
+			assert.Nil(t, flowLog.ChangedBy, "the overdue cancellation is a system change")
+			assert.Equal(t, models.StatusOverdue, flowLog.NewStatus)
+		})
+	}
+}
+
+func TestCheckOverdueTasks(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      models.RequestStatus
+		clock       models.SLAClockState // Empty for a request without a clock
+		deadline    time.Duration
+		wantTask    models.TaskStatus
+		wantRequest models.RequestStatus
+	}{
+		{
+			name:        "clock running",
+			status:      models.StatusAppointment,
+			clock:       models.SLAClockRunning,
+			deadline:    -time.Hour,
+			wantTask:    models.TaskStatusOverdue,
+			wantRequest: models.StatusOverdue,
+		},
+		{
+			name:        "request without a clock",
+			status:      models.StatusAppointment,
+			deadline:    -time.Hour,
+			wantTask:    models.TaskStatusOverdue,
+			wantRequest: models.StatusOverdue,
+		},
+		{
+			name:        "clock paused",
+			status:      models.StatusAppointment,
+			clock:       models.SLAClockPaused,
+			deadline:    -time.Hour,
+			wantTask:    models.TaskStatusPending,
+			wantRequest: models.StatusAppointment,
+		},
+		{
+			name:        "clock stopped",
+			status:      models.StatusAppointment,
+			clock:       models.SLAClockStopped,
+			deadline:    -time.Hour,
+			wantTask:    models.TaskStatusPending,
+			wantRequest: models.StatusAppointment,
+		},
+		{
+			name:        "request with the applicant",
+			status:      models.StatusReturned,
+			clock:       models.SLAClockRunning,
+			deadline:    -time.Hour,
+			wantTask:    models.TaskStatusPending,
+			wantRequest: models.StatusReturned,
+		},
+		{
+			name:        "deadline not reached",
+			status:      models.StatusAppointment,
+			clock:       models.SLAClockRunning,
+			deadline:    time.Hour,
+			wantTask:    models.TaskStatusPending,
+			wantRequest: models.StatusAppointment,
+		},
+		{
+			name:        "status without an overdue transition",
+			status:      models.StatusAssigned,
+			clock:       models.SLAClockRunning,
+			deadline:    -time.Hour,
+			wantTask:    models.TaskStatusOverdue,
+			wantRequest: models.StatusAssigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := &overdueService{
+				db:                db,
+				transitionService: newTestTransitionService(db),
+				definitionService: NewWorkflowDefinitionService(db),
+			}
+			applicant := createTestUser(t, db, models.RoleUser)
+			staff := createTestUser(t, db, models.RoleDEDEStaff)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, tt.status)
+
+			now := time.Now()
+			if tt.clock != "" {
+				require.NoError(t, db.Create(&models.RequestSLAClock{
+					RequestID:     request.ID,
+					LicenseType:   string(request.LicenseType),
+					State:         tt.clock,
+					StartedAt:     now.AddDate(0, 0, -7),
+					LastChangedAt: now.AddDate(0, 0, -1),
+				}).Error)
+			}
+			deadline := now.Add(tt.deadline)
+			task := &models.TaskAssignment{
+				RequestID:    request.ID,
+				LicenseType:  string(request.LicenseType),
+				AssignedToID: staff.ID,
+				AssignedByID: staff.ID,
+				AssignedRole: models.RoleDEDEStaff,
+				TaskType:     models.TaskTypeInspection,
+				Status:       models.TaskStatusPending,
+				Deadline:     &deadline,
+			}
+			require.NoError(t, db.Omit("AssignedTo", "AssignedBy").Create(task).Error)
+
+			require.NoError(t, s.checkOverdueTasks())
+
+			var stored models.TaskAssignment
+			require.NoError(t, db.First(&stored, task.ID).Error)
+			assert.Equal(t, tt.wantTask, stored.Status)
+			assert.Equal(t, tt.wantRequest, reloadTestRequest(t, db, request.ID).Status)
+		})
+	}
+}
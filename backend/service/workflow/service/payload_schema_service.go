@@ -0,0 +1,382 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/utils"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPayloadSchemaNotFound is returned when a payload schema version does not exist, or no
+// version is active for a license type and energy type
+var ErrPayloadSchemaNotFound = errors.New("payload schema not found")
+
+// PayloadValidationError lists every field of technical data that does not match its schema
+type PayloadValidationError struct {
+	SchemaID      uint                `json:"schema_id"`
+	SchemaVersion int                 `json:"schema_version"`
+	Errors        []utils.SchemaError `json:"errors"`
+}
+
+func (e *PayloadValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for _, schemaErr := range e.Errors {
+		fields = append(fields, schemaErr.Field)
+	}
+	return fmt.Sprintf("technical data does not match payload schema version %d: %s", e.SchemaVersion, strings.Join(fields, ", "))
+}
+
+func (e *PayloadValidationError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// payloadSchemaCache holds parsed schemas by ID. Versions are immutable once published, so
+// entries never need to be invalidated.
+var payloadSchemaCache = struct {
+	sync.RWMutex
+	schemas map[uint]*utils.JSONSchema
+}{schemas: make(map[uint]*utils.JSONSchema)}
+
+type PayloadSchemaService interface {
+	PublishSchema(req dto.PublishPayloadSchemaRequest) (*dto.PayloadSchemaResponse, error)
+	ActivateSchema(schemaID uint) (*dto.PayloadSchemaResponse, error)
+	GetSchemas(filter dto.PayloadSchemaFilter) ([]dto.PayloadSchemaResponse, error)
+	GetSchema(schemaID uint) (*dto.PayloadSchemaResponse, error)
+	GetActiveSchema(licenseType models.LicenseType, energyType string) (*dto.PayloadSchemaResponse, error)
+	GetTechnicalData(requestID, userID uint, role models.UserRole) (*dto.TechnicalDataResponse, error)
+	SaveTechnicalData(req dto.SaveTechnicalDataRequest) (*dto.TechnicalDataResponse, error)
+}
+
+type payloadSchemaService struct {
+	db *gorm.DB
+}
+
+func NewPayloadSchemaService(db *gorm.DB) PayloadSchemaService {
+	return &payloadSchemaService{db: db}
+}
+
+// PublishSchema checks a schema and publishes it as the next version for its license type and
+// energy type. Unless told otherwise the new version becomes the active one.
+func (s *payloadSchemaService) PublishSchema(req dto.PublishPayloadSchemaRequest) (*dto.PayloadSchemaResponse, error) {
+	if _, err := utils.ParseJSONSchema(req.Schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTransition, err)
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, req.Schema); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON schema: %v", ErrInvalidTransition, err)
+	}
+
+	schema := &models.PayloadSchema{
+		LicenseType: req.LicenseType,
+		EnergyType:  strings.TrimSpace(req.EnergyType),
+		Schema:      compacted.String(),
+		Description: strings.TrimSpace(req.Description),
+		CreatedByID: &req.UserID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		err := tx.Model(&models.PayloadSchema{}).
+			Where("license_type = ? AND energy_type = ?", schema.LicenseType, schema.EnergyType).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error
+		if err != nil {
+			return fmt.Errorf("failed to get payload schema version: %w", err)
+		}
+		schema.Version = latest + 1
+
+		if err := tx.Create(schema).Error; err != nil {
+			return fmt.Errorf("failed to create payload schema: %w", err)
+		}
+		if req.Activate != nil && !*req.Activate {
+			return nil
+		}
+		return activatePayloadSchema(tx, schema)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payloadSchemaResponse(schema), nil
+}
+
+// ActivateSchema makes a version the one requests of its license type and energy type are checked
+// against, e.g. to roll back to an earlier version
+func (s *payloadSchemaService) ActivateSchema(schemaID uint) (*dto.PayloadSchemaResponse, error) {
+	var schema models.PayloadSchema
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&schema, schemaID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPayloadSchemaNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get payload schema: %w", err)
+		}
+		return activatePayloadSchema(tx, &schema)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payloadSchemaResponse(&schema), nil
+}
+
+// GetSchemas lists published versions, newest first within each license type and energy type
+func (s *payloadSchemaService) GetSchemas(filter dto.PayloadSchemaFilter) ([]dto.PayloadSchemaResponse, error) {
+	query := s.db.Model(&models.PayloadSchema{})
+	if filter.LicenseType != "" {
+		query = query.Where("license_type = ?", filter.LicenseType)
+	}
+	if filter.EnergyType != nil {
+		query = query.Where("LOWER(energy_type) = LOWER(?)", strings.TrimSpace(*filter.EnergyType))
+	}
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+
+	var schemas []models.PayloadSchema
+	if err := query.Order("license_type, energy_type, version DESC").Find(&schemas).Error; err != nil {
+		return nil, fmt.Errorf("failed to get payload schemas: %w", err)
+	}
+
+	responses := make([]dto.PayloadSchemaResponse, 0, len(schemas))
+	for i := range schemas {
+		responses = append(responses, *payloadSchemaResponse(&schemas[i]))
+	}
+	return responses, nil
+}
+
+// GetSchema returns a published version by ID
+func (s *payloadSchemaService) GetSchema(schemaID uint) (*dto.PayloadSchemaResponse, error) {
+	var schema models.PayloadSchema
+	err := s.db.Take(&schema, schemaID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPayloadSchemaNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payload schema: %w", err)
+	}
+	return payloadSchemaResponse(&schema), nil
+}
+
+// GetActiveSchema returns the version a request of the license type and energy type is checked
+// against, for its form to render from
+func (s *payloadSchemaService) GetActiveSchema(licenseType models.LicenseType, energyType string) (*dto.PayloadSchemaResponse, error) {
+	schema, err := activePayloadSchema(s.db, licenseType, energyType)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, ErrPayloadSchemaNotFound
+	}
+	return payloadSchemaResponse(schema), nil
+}
+
+// GetTechnicalData returns the technical data of a request; applicants only see their own requests
+func (s *payloadSchemaService) GetTechnicalData(requestID, userID uint, role models.UserRole) (*dto.TechnicalDataResponse, error) {
+	query := s.db.Where("id = ?", requestID)
+	if role == models.RoleUser {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var request models.LicenseRequest
+	err := query.Take(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	return technicalData(s.db, &request)
+}
+
+// SaveTechnicalData checks the technical data of an applicant's request against the active schema
+// and saves it. Nothing is saved when a field does not match; the error lists every such field.
+func (s *payloadSchemaService) SaveTechnicalData(req dto.SaveTechnicalDataRequest) (*dto.TechnicalDataResponse, error) {
+	var request models.LicenseRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", req.RequestID, req.UserID).
+			Take(&request).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRequestNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get request: %w", err)
+		}
+		if !slices.Contains(applicantEditStatuses, request.Status) {
+			return fmt.Errorf("%w: the technical data of request %s cannot be changed while it is %s", ErrInvalidTransition, request.RequestNumber, request.Status)
+		}
+
+		energyType, err := requestEnergyType(tx, &request)
+		if err != nil {
+			return err
+		}
+		schema, err := activePayloadSchema(tx, request.LicenseType, energyType)
+		if err != nil {
+			return err
+		}
+		if schema == nil {
+			return fmt.Errorf("%w: no payload schema applies to request %s", ErrInvalidTransition, request.RequestNumber)
+		}
+		if err := validateTechnicalData(schema, req.Data); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(req.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode technical data: %w", err)
+		}
+		err = tx.Model(&request).Updates(map[string]interface{}{
+			"technical_data":      string(data),
+			"technical_schema_id": schema.ID,
+			"version":             gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to save technical data: %w", err)
+		}
+		request.TechnicalData = string(data)
+		request.TechnicalSchemaID = &schema.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return technicalData(s.db, &request)
+}
+
+// activatePayloadSchema makes a version the active one, retiring the version it replaces
+func activatePayloadSchema(tx *gorm.DB, schema *models.PayloadSchema) error {
+	err := tx.Model(&models.PayloadSchema{}).
+		Where("license_type = ? AND energy_type = ? AND id <> ? AND is_active = ?", schema.LicenseType, schema.EnergyType, schema.ID, true).
+		Update("is_active", false).Error
+	if err != nil {
+		return fmt.Errorf("failed to deactivate payload schema: %w", err)
+	}
+
+	now := time.Now()
+	err = tx.Model(schema).Updates(map[string]interface{}{
+		"is_active":    true,
+		"activated_at": now,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to activate payload schema: %w", err)
+	}
+	schema.IsActive = true
+	schema.ActivatedAt = &now
+	return nil
+}
+
+// activePayloadSchema returns the active version for a license type and energy type, falling back
+// to the version for every energy type; nil when neither exists
+func activePayloadSchema(tx *gorm.DB, licenseType models.LicenseType, energyType string) (*models.PayloadSchema, error) {
+	var schemas []models.PayloadSchema
+	err := tx.Where("license_type = ? AND is_active = ?", licenseType, true).
+		Where("energy_type = '' OR LOWER(energy_type) = LOWER(?)", strings.TrimSpace(energyType)).
+		Find(&schemas).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payload schema: %w", err)
+	}
+
+	var fallback *models.PayloadSchema
+	for i := range schemas {
+		if schemas[i].EnergyType != "" {
+			return &schemas[i], nil
+		}
+		fallback = &schemas[i]
+	}
+	return fallback, nil
+}
+
+// validateTechnicalData checks technical data against a schema version and returns a
+// PayloadValidationError listing every field that does not match it
+func validateTechnicalData(schema *models.PayloadSchema, data map[string]interface{}) error {
+	payloadSchemaCache.RLock()
+	parsed, exists := payloadSchemaCache.schemas[schema.ID]
+	payloadSchemaCache.RUnlock()
+	if !exists {
+		var err error
+		parsed, err = schema.Parse()
+		if err != nil {
+			return fmt.Errorf("payload schema %d: %w", schema.ID, err)
+		}
+
+		payloadSchemaCache.Lock()
+		payloadSchemaCache.schemas[schema.ID] = parsed
+		payloadSchemaCache.Unlock()
+	}
+
+	// Round-trip through JSON so numbers and nested values have the types the validator expects
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode technical data: %w", err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return fmt.Errorf("failed to decode technical data: %w", err)
+	}
+
+	if errs := parsed.Validate(document); len(errs) > 0 {
+		return &PayloadValidationError{
+			SchemaID:      schema.ID,
+			SchemaVersion: schema.Version,
+			Errors:        errs,
+		}
+	}
+	return nil
+}
+
+// technicalData describes the technical data of a request with the schema its form renders from
+func technicalData(tx *gorm.DB, request *models.LicenseRequest) (*dto.TechnicalDataResponse, error) {
+	energyType, err := requestEnergyType(tx, request)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := activePayloadSchema(tx, request.LicenseType, energyType)
+	if err != nil {
+		return nil, err
+	}
+	data, err := request.TechnicalFields()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.TechnicalDataResponse{
+		RequestID:     request.ID,
+		RequestNumber: request.RequestNumber,
+		LicenseType:   request.LicenseType,
+		EnergyType:    energyType,
+		Data:          data,
+		SavedSchemaID: request.TechnicalSchemaID,
+	}
+	if schema != nil {
+		response.Schema = payloadSchemaResponse(schema)
+	}
+	return response, nil
+}
+
+func payloadSchemaResponse(schema *models.PayloadSchema) *dto.PayloadSchemaResponse {
+	return &dto.PayloadSchemaResponse{
+		ID:          schema.ID,
+		LicenseType: schema.LicenseType,
+		EnergyType:  schema.EnergyType,
+		Version:     schema.Version,
+		Description: schema.Description,
+		IsActive:    schema.IsActive,
+		Schema:      json.RawMessage(schema.Schema),
+		CreatedByID: schema.CreatedByID,
+		ActivatedAt: schema.ActivatedAt,
+		CreatedAt:   schema.CreatedAt,
+	}
+}
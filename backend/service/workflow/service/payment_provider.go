@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/utils"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Built-in payment provider names
+const (
+	FakePaymentProviderName = "fake"
+	PromptPayProviderName   = "promptpay"
+)
+
+// ErrPaymentRejected is returned when a reported payment does not settle the invoice
+var ErrPaymentRejected = errors.New("payment rejected")
+
+// PaymentCharge is what a provider prepared for the payer of an invoice
+type PaymentCharge struct {
+	Reference string // Reference of the charge at the provider
+	Payload   string // What the payer needs to pay, e.g. a PromptPay QR payload
+}
+
+// PaymentReport is a payment of an invoice as reported by an officer or as known to the provider
+type PaymentReport struct {
+	Reference string
+	Amount    float64
+	PaidAt    time.Time
+}
+
+// PaymentProvider is a way license fees are paid
+type PaymentProvider interface {
+	// Name is the key the provider is registered under and recorded on its invoices
+	Name() string
+	// CreateCharge prepares the payment of a new invoice
+	CreateCharge(invoice *models.Invoice) (*PaymentCharge, error)
+	// VerifyPayment checks a reported payment of an invoice and returns the payment as the provider knows it
+	VerifyPayment(invoice *models.Invoice, report PaymentReport) (*PaymentReport, error)
+}
+
+// PaymentProviderFactory builds a payment provider from the configuration
+type PaymentProviderFactory func(cfg *config.Config) (PaymentProvider, error)
+
+var (
+	paymentProvidersMu sync.RWMutex
+	paymentProviders   = map[string]PaymentProviderFactory{
+		FakePaymentProviderName: newFakePaymentProvider,
+		PromptPayProviderName:   newPromptPayProvider,
+	}
+)
+
+// RegisterPaymentProvider makes a payment provider available to PAYMENT_PROVIDER under a name,
+// replacing any provider already registered with that name
+func RegisterPaymentProvider(name string, factory PaymentProviderFactory) {
+	paymentProvidersMu.Lock()
+	defer paymentProvidersMu.Unlock()
+	paymentProviders[name] = factory
+}
+
+// NewPaymentProvider builds the provider new invoices are paid through
+func NewPaymentProvider(cfg *config.Config) (PaymentProvider, error) {
+	return newPaymentProvider(cfg, cfg.PaymentProvider)
+}
+
+// newPaymentProvider builds a provider by name, e.g. the one an earlier invoice was issued with
+func newPaymentProvider(cfg *config.Config, name string) (PaymentProvider, error) {
+	paymentProvidersMu.RLock()
+	factory, ok := paymentProviders[name]
+	paymentProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+	return factory(cfg)
+}
+
+// fakePaymentProvider accepts every reported payment. It lets development and test setups take
+// requests through the payment step without a bank.
+type fakePaymentProvider struct{}
+
+func newFakePaymentProvider(cfg *config.Config) (PaymentProvider, error) {
+	if cfg.Env == "production" {
+		return nil, fmt.Errorf("the %s payment provider cannot be used in production", FakePaymentProviderName)
+	}
+	return fakePaymentProvider{}, nil
+}
+
+func (fakePaymentProvider) Name() string {
+	return FakePaymentProviderName
+}
+
+func (fakePaymentProvider) CreateCharge(invoice *models.Invoice) (*PaymentCharge, error) {
+	reference := "FAKE-" + invoice.InvoiceNumber
+	return &PaymentCharge{
+		Reference: reference,
+		Payload:   fmt.Sprintf("fake-payment:%s:%.2f", reference, invoice.Amount),
+	}, nil
+}
+
+func (fakePaymentProvider) VerifyPayment(invoice *models.Invoice, report PaymentReport) (*PaymentReport, error) {
+	if report.Reference == "" {
+		report.Reference = invoice.ProviderReference
+	}
+	return &report, nil
+}
+
+// promptPayProvider charges fees with a PromptPay QR code for the exact amount. PromptPay has no
+// callback, so an officer confirms each payment from the bank statement with its transaction reference.
+type promptPayProvider struct {
+	promptPayID string
+}
+
+func newPromptPayProvider(cfg *config.Config) (PaymentProvider, error) {
+	// Check the ID once, so a bad configuration shows up before the first invoice
+	if _, err := utils.PromptPayPayload(cfg.PromptPayID, 1); err != nil {
+		return nil, fmt.Errorf("invalid PROMPTPAY_ID: %w", err)
+	}
+	return promptPayProvider{promptPayID: cfg.PromptPayID}, nil
+}
+
+func (promptPayProvider) Name() string {
+	return PromptPayProviderName
+}
+
+func (p promptPayProvider) CreateCharge(invoice *models.Invoice) (*PaymentCharge, error) {
+	payload, err := utils.PromptPayPayload(p.promptPayID, invoice.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentCharge{Reference: invoice.InvoiceNumber, Payload: payload}, nil
+}
+
+func (promptPayProvider) VerifyPayment(invoice *models.Invoice, report PaymentReport) (*PaymentReport, error) {
+	report.Reference = strings.TrimSpace(report.Reference)
+	if report.Reference == "" {
+		return nil, fmt.Errorf("%w: the bank transaction reference of the PromptPay transfer is required", ErrPaymentRejected)
+	}
+	return &report, nil
+}
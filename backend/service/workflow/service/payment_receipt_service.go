@@ -0,0 +1,202 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/utils"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"gorm.io/gorm"
+)
+
+// ErrReceiptNotFound is returned when an invoice has no receipt yet
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+// paymentProviderLabels are the payment methods printed on receipts
+var paymentProviderLabels = map[string]string{
+	FakePaymentProviderName: "ทดสอบระบบ",
+	PromptPayProviderName:   "พร้อมเพย์ (PromptPay)",
+}
+
+type PaymentReceiptService interface {
+	GenerateReceipt(invoiceID uint) (*models.Attachment, error)
+	GetReceipt(invoiceID uint) (*models.Attachment, error)
+}
+
+type paymentReceiptService struct {
+	db        *gorm.DB
+	uploadDir string
+	fontPath  string
+}
+
+func NewPaymentReceiptService(db *gorm.DB, cfg *config.Config) PaymentReceiptService {
+	return &paymentReceiptService{
+		db:        db,
+		uploadDir: filepath.Join(cfg.UploadPath, "receipts"),
+		fontPath:  cfg.CertificateFontPath,
+	}
+}
+
+// GenerateReceipt renders the receipt of a paid invoice and attaches it to the request the fee was
+// paid for. A receipt is issued once, so an invoice that already has one keeps it.
+func (s *paymentReceiptService) GenerateReceipt(invoiceID uint) (*models.Attachment, error) {
+	var invoice models.Invoice
+	err := s.db.Preload("LicenseRequest.User").Take(&invoice, invoiceID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if !invoice.IsPaid() {
+		return nil, fmt.Errorf("%w: invoice %s is %s", ErrInvalidTransition, invoice.InvoiceNumber, invoice.Status)
+	}
+	if invoice.ReceiptID != nil {
+		return s.GetReceipt(invoice.ID)
+	}
+
+	var payment models.Payment
+	err = s.db.Preload("ConfirmedBy").
+		Where("invoice_id = ?", invoice.ID).
+		Order("paid_at DESC, id DESC").
+		First(&payment).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment of invoice %s: %w", invoice.InvoiceNumber, err)
+	}
+
+	pdf, err := utils.NewThaiPDF(s.fontPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.drawReceipt(pdf, &invoice, &payment); err != nil {
+		return nil, err
+	}
+	file, err := utils.SavePDF(pdf, s.uploadDir, invoice.ReceiptNumber+".pdf")
+	if err != nil {
+		return nil, err
+	}
+
+	uploaderID := invoice.LicenseRequest.UserID
+	if payment.ConfirmedByID != nil {
+		uploaderID = *payment.ConfirmedByID
+	}
+	attachment := &models.Attachment{
+		FileName:     file.FileName,
+		OriginalName: file.OriginalName,
+		FilePath:     file.FilePath,
+		FileSize:     file.FileSize,
+		MimeType:     file.MimeType,
+		FileType:     models.AttachmentTypePDF,
+		Description:  "ใบเสร็จรับเงินเลขที่ " + invoice.ReceiptNumber,
+		EntityType:   "license_request",
+		EntityID:     invoice.LicenseRequestID,
+		UploaderID:   uploaderID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewAttachmentRepository(tx).Create(attachment); err != nil {
+			return fmt.Errorf("failed to create receipt attachment: %w", err)
+		}
+		err := tx.Model(&models.Invoice{}).
+			Where("id = ?", invoice.ID).
+			UpdateColumn("receipt_id", attachment.ID).Error
+		if err != nil {
+			return fmt.Errorf("failed to link receipt to invoice: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		os.Remove(file.FilePath)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// GetReceipt returns the receipt attachment of an invoice
+func (s *paymentReceiptService) GetReceipt(invoiceID uint) (*models.Attachment, error) {
+	var invoice models.Invoice
+	err := s.db.Select("id", "receipt_id").Take(&invoice, invoiceID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if invoice.ReceiptID == nil {
+		return nil, ErrReceiptNotFound
+	}
+
+	attachment, err := repository.NewAttachmentRepository(s.db).GetByID(*invoice.ReceiptID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	return attachment, nil
+}
+
+// drawReceipt lays out the receipt on a single A4 page
+func (s *paymentReceiptService) drawReceipt(pdf *fpdf.Fpdf, invoice *models.Invoice, payment *models.Payment) error {
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		loc = time.UTC
+	}
+
+	pdf.AddPage()
+
+	// Issuer and title
+	pdf.SetY(20)
+	pdf.SetFontSize(16)
+	pdf.CellFormat(0, 8, "กรมพัฒนาพลังงานทดแทนและอนุรักษ์พลังงาน", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+	pdf.SetFontSize(24)
+	pdf.CellFormat(0, 12, "ใบเสร็จรับเงิน", "", 1, "C", false, 0, "")
+	pdf.SetFontSize(14)
+	pdf.CellFormat(0, 8, "เลขที่ "+invoice.ReceiptNumber, "", 1, "C", false, 0, "")
+	pdf.Ln(8)
+
+	request := invoice.LicenseRequest
+	method := paymentProviderLabels[invoice.Provider]
+	if method == "" {
+		method = invoice.Provider
+	}
+	fields := []struct{ label, value string }{
+		{"ได้รับเงินจาก", request.User.FullName},
+		{"คำขอเลขที่", request.RequestNumber},
+		{"ใบแจ้งชำระเลขที่", invoice.InvoiceNumber},
+		{"รายการ", invoice.Description},
+		{"กำลังการผลิต", strconv.FormatFloat(invoice.Capacity, 'f', -1, 64)},
+		{"จำนวนเงิน", formatBaht(invoice.Amount) + " บาท"},
+		{"ชำระโดย", method},
+		{"เลขที่อ้างอิง", payment.Reference},
+		{"วันที่ชำระ", utils.FormatThaiDate(payment.PaidAt.In(loc))},
+	}
+	for _, field := range fields {
+		value := field.value
+		if value == "" {
+			value = "-"
+		}
+		pdf.SetX(25)
+		pdf.CellFormat(45, 8, field.label, "", 0, "L", false, 0, "")
+		pdf.MultiCell(115, 8, value, "", "L", false)
+	}
+
+	// Receiver
+	pdf.Ln(20)
+	pdf.SetX(100)
+	pdf.CellFormat(90, 8, "ผู้รับเงิน (ลงนามอิเล็กทรอนิกส์)", "", 1, "C", false, 0, "")
+	if payment.ConfirmedBy != nil {
+		pdf.SetX(100)
+		pdf.MultiCell(90, 7, "("+payment.ConfirmedBy.FullName+")", "", "C", false)
+	}
+
+	return pdf.Error()
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"eservice-backend/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvoiceNotFound is returned when an invoice does not exist or is not visible to the caller
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// liveInvoiceStatuses are the statuses of the invoice a request is charged with
+var liveInvoiceStatuses = []models.InvoiceStatus{models.InvoiceStatusPending, models.InvoiceStatusPaid}
+
+type PaymentService interface {
+	GetInvoice(invoiceID, userID uint, role models.UserRole) (*dto.InvoiceResponse, error)
+	GetInvoices(filter dto.InvoiceFilter, userID uint, role models.UserRole) ([]dto.InvoiceResponse, error)
+	ConfirmPayment(req dto.ConfirmPaymentRequest) (*dto.InvoiceResponse, error)
+}
+
+type paymentService struct {
+	db                *gorm.DB
+	cfg               *config.Config
+	transitionService WorkflowTransitionService
+	definitionService WorkflowDefinitionService
+	outboxService     OutboxService
+}
+
+func NewPaymentService(db *gorm.DB, cfg *config.Config) PaymentService {
+	return &paymentService{
+		db:                db,
+		cfg:               cfg,
+		transitionService: NewWorkflowTransitionService(db, cfg),
+		definitionService: NewWorkflowDefinitionService(db),
+		outboxService:     NewOutboxService(db, cfg),
+	}
+}
+
+// GetInvoice returns an invoice with its payments; applicants only see the invoices of their own requests
+func (s *paymentService) GetInvoice(invoiceID, userID uint, role models.UserRole) (*dto.InvoiceResponse, error) {
+	query := s.db.Where("invoices.id = ?", invoiceID)
+	if role == models.RoleUser {
+		query = query.Joins("JOIN license_requests ON license_requests.id = invoices.license_request_id").
+			Where("license_requests.user_id = ?", userID)
+	}
+
+	var invoice models.Invoice
+	if err := query.Take(&invoice).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	responses, err := s.invoiceResponses([]models.Invoice{invoice})
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
+}
+
+// GetInvoices lists invoices, newest first; applicants only see the invoices of their own requests
+func (s *paymentService) GetInvoices(filter dto.InvoiceFilter, userID uint, role models.UserRole) ([]dto.InvoiceResponse, error) {
+	query := s.db.Model(&models.Invoice{})
+	if role == models.RoleUser {
+		query = query.Joins("JOIN license_requests ON license_requests.id = invoices.license_request_id").
+			Where("license_requests.user_id = ?", userID)
+	}
+	if filter.RequestID != 0 {
+		query = query.Where("invoices.license_request_id = ?", filter.RequestID)
+	}
+	if filter.Status != "" {
+		query = query.Where("invoices.status = ?", filter.Status)
+	}
+
+	var invoices []models.Invoice
+	if err := query.Order("invoices.issued_at DESC, invoices.id DESC").Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %w", err)
+	}
+	return s.invoiceResponses(invoices)
+}
+
+// ConfirmPayment records a payment of a pending invoice once its provider accepts it, and queues the
+// receipt. A request waiting in a state whose automatic transition needs the fee paid moves on in
+// the same transaction.
+func (s *paymentService) ConfirmPayment(req dto.ConfirmPaymentRequest) (*dto.InvoiceResponse, error) {
+	var invoice models.Invoice
+	if err := s.db.Take(&invoice, req.InvoiceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if !invoice.IsPending() {
+		return nil, fmt.Errorf("%w: invoice %s is %s", ErrInvalidTransition, invoice.InvoiceNumber, invoice.Status)
+	}
+
+	now := time.Now()
+	paidAt := now
+	if req.PaidAt != nil {
+		if req.PaidAt.After(now) {
+			return nil, fmt.Errorf("%w: the payment date is in the future", ErrPaymentRejected)
+		}
+		paidAt = *req.PaidAt
+	}
+
+	provider, err := newPaymentProvider(s.cfg, invoice.Provider)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := provider.VerifyPayment(&invoice, PaymentReport{
+		Reference: strings.TrimSpace(req.Reference),
+		Amount:    roundSatang(req.Amount),
+		PaidAt:    paidAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if roundSatang(payment.Amount) != roundSatang(invoice.Amount) {
+		return nil, fmt.Errorf("%w: %s baht was paid, but invoice %s is for %s baht", ErrPaymentRejected,
+			formatBaht(payment.Amount), invoice.InvoiceNumber, formatBaht(invoice.Amount))
+	}
+
+	var record WorkflowRequestRecord
+	err = s.db.Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", invoice.LicenseRequestID).
+		Take(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+
+	settle := func(tx *gorm.DB) error {
+		return s.settleInvoice(tx, &record, invoice.ID, payment, req, now)
+	}
+
+	var transition *dto.WorkflowTransitionResult
+	if toStatus, ok := feePaidTransition(stateMachine, record.Status); ok {
+		transition, err = s.transitionService.ProcessTransition(dto.WorkflowTransitionRequest{
+			RequestID:    record.ID,
+			FromStatus:   record.Status,
+			ToStatus:     toStatus,
+			Comments:     "ชำระค่าธรรมเนียมแล้ว ตามใบแจ้งชำระเลขที่ " + invoice.InvoiceNumber,
+			AutoApproved: true,
+		}, func(tc *TransitionContext) error {
+			return settle(tc.Tx)
+		})
+	} else {
+		err = s.db.Transaction(settle)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.GetInvoice(invoice.ID, req.UserID, models.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	response.Transition = transition
+	return response, nil
+}
+
+// settleInvoice marks an invoice paid with a payment, queues its receipt and tells the applicant
+// and the officer handling the request
+func (s *paymentService) settleInvoice(tx *gorm.DB, record *WorkflowRequestRecord, invoiceID uint, payment *PaymentReport, req dto.ConfirmPaymentRequest, now time.Time) error {
+	var invoice models.Invoice
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&invoice, invoiceID).Error
+	if err != nil {
+		return fmt.Errorf("failed to get invoice: %w", err)
+	}
+	// Another officer may have confirmed a payment meanwhile
+	if !invoice.IsPending() {
+		return fmt.Errorf("%w: invoice %s is %s", ErrInvalidTransition, invoice.InvoiceNumber, invoice.Status)
+	}
+
+	var used int64
+	err = tx.Model(&models.Payment{}).
+		Where("provider = ? AND reference = ?", invoice.Provider, payment.Reference).
+		Count(&used).Error
+	if err != nil {
+		return fmt.Errorf("failed to check payment reference: %w", err)
+	}
+	if used > 0 {
+		return fmt.Errorf("%w: reference %s was already used for another payment", ErrPaymentRejected, payment.Reference)
+	}
+
+	err = tx.Create(&models.Payment{
+		InvoiceID:     invoice.ID,
+		Provider:      invoice.Provider,
+		Reference:     payment.Reference,
+		Amount:        invoice.Amount,
+		PaidAt:        payment.PaidAt,
+		ConfirmedByID: &req.UserID,
+		Notes:         strings.TrimSpace(req.Notes),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record payment: %w", err)
+	}
+
+	receiptNumber, err := nextDocumentNumber(tx, "receipt_number", "RCPT", now)
+	if err != nil {
+		return err
+	}
+	err = tx.Model(&invoice).Updates(map[string]interface{}{
+		"status":         models.InvoiceStatusPaid,
+		"paid_at":        payment.PaidAt,
+		"receipt_number": receiptNumber,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update invoice: %w", err)
+	}
+
+	if err := s.outboxService.EnqueueReceipt(tx, invoice.ID); err != nil {
+		return err
+	}
+
+	err = s.outboxService.EnqueueNotification(tx, models.Notification{
+		Title: "ชำระค่าธรรมเนียมแล้ว",
+		Message: fmt.Sprintf("ได้รับชำระค่าธรรมเนียมของคำขอเลขที่ %s จำนวน %s บาทแล้ว ใบเสร็จรับเงินเลขที่ %s",
+			record.RequestNumber, formatBaht(invoice.Amount), receiptNumber),
+		Type:        models.NotificationType("payment_received"),
+		Priority:    models.PriorityNormal,
+		RecipientID: &record.UserID,
+		EntityType:  "license_request",
+		EntityID:    &record.ID,
+		ActionURL:   "/dashboard/licenses",
+	})
+	if err != nil {
+		return err
+	}
+
+	// The officer who took the request through the workflow, or the DEDE Heads, continue with the final approval
+	officerNotice := models.Notification{
+		Title:      "ชำระค่าธรรมเนียมแล้ว",
+		Message:    fmt.Sprintf("คำขอเลขที่ %s ชำระค่าธรรมเนียมแล้ว พร้อมสำหรับการพิจารณาอนุมัติ", record.RequestNumber),
+		Type:       models.NotificationType("payment_received"),
+		Priority:   models.PriorityNormal,
+		EntityType: "license_request",
+		EntityID:   &record.ID,
+		ActionURL:  fmt.Sprintf("/admin-portal/services/%d", record.ID),
+	}
+	if record.InspectorID != nil {
+		officerNotice.RecipientID = record.InspectorID
+	} else {
+		role := models.RoleDEDEHead
+		officerNotice.RecipientRole = &role
+	}
+	return s.outboxService.EnqueueNotification(tx, officerNotice)
+}
+
+// invoiceResponses builds the responses of invoices with their requests and payments
+func (s *paymentService) invoiceResponses(invoices []models.Invoice) ([]dto.InvoiceResponse, error) {
+	if len(invoices) == 0 {
+		return []dto.InvoiceResponse{}, nil
+	}
+
+	invoiceIDs := make([]uint, 0, len(invoices))
+	requestIDs := make([]uint, 0, len(invoices))
+	for _, invoice := range invoices {
+		invoiceIDs = append(invoiceIDs, invoice.ID)
+		requestIDs = append(requestIDs, invoice.LicenseRequestID)
+	}
+
+	var requests []models.LicenseRequest
+	err := s.db.Unscoped().Preload("User").
+		Select("id", "user_id", "request_number", "license_type").
+		Where("id IN ?", requestIDs).
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requests: %w", err)
+	}
+	requestByID := make(map[uint]*models.LicenseRequest, len(requests))
+	for i := range requests {
+		requestByID[requests[i].ID] = &requests[i]
+	}
+
+	var payments []models.Payment
+	err = s.db.Preload("ConfirmedBy").
+		Where("invoice_id IN ?", invoiceIDs).
+		Order("paid_at, id").
+		Find(&payments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+	paymentsByInvoice := make(map[uint][]models.Payment)
+	for _, payment := range payments {
+		paymentsByInvoice[payment.InvoiceID] = append(paymentsByInvoice[payment.InvoiceID], payment)
+	}
+
+	responses := make([]dto.InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		response := dto.InvoiceResponse{
+			ID:                invoice.ID,
+			InvoiceNumber:     invoice.InvoiceNumber,
+			LicenseRequestID:  invoice.LicenseRequestID,
+			FeeScheduleID:     invoice.FeeScheduleID,
+			Capacity:          invoice.Capacity,
+			Amount:            invoice.Amount,
+			Currency:          invoice.Currency,
+			Description:       invoice.Description,
+			Status:            invoice.Status,
+			DueDate:           invoice.DueDate,
+			Provider:          invoice.Provider,
+			ProviderReference: invoice.ProviderReference,
+			IssuedAt:          invoice.IssuedAt,
+			PaidAt:            invoice.PaidAt,
+			ReceiptNumber:     invoice.ReceiptNumber,
+			ReceiptID:         invoice.ReceiptID,
+			CancelledAt:       invoice.CancelledAt,
+			Payments:          paymentsByInvoice[invoice.ID],
+		}
+		// The payer only needs the payment details while the invoice is open
+		if invoice.IsPending() {
+			response.PaymentPayload = invoice.PaymentPayload
+		}
+		if request, ok := requestByID[invoice.LicenseRequestID]; ok {
+			response.RequestNumber = request.RequestNumber
+			response.LicenseType = request.LicenseType
+			response.ApplicantName = request.User.FullName
+		}
+		if response.Payments == nil {
+			response.Payments = []models.Payment{}
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// issueInvoice invoices the fee of a request entering a state that issues invoices, due at the
+// deadline of that state. A pending invoice is kept with the new due date, and a paid fee is not
+// charged again. Entering the state is refused when no fee schedule covers the request.
+func issueInvoice(tc *TransitionContext, provider PaymentProvider, now time.Time) error {
+	tx := tc.Tx
+	invoice, err := currentInvoice(tx, tc.Record.ID)
+	if err != nil {
+		return err
+	}
+	if invoice != nil && invoice.IsPaid() {
+		return fmt.Errorf("%w: the fee of request %s is paid already with invoice %s", ErrInvalidTransition, tc.Record.RequestNumber, invoice.InvoiceNumber)
+	}
+
+	if invoice != nil {
+		if err := tx.Model(invoice).Update("due_date", tc.Record.Deadline).Error; err != nil {
+			return fmt.Errorf("failed to update invoice: %w", err)
+		}
+		return notifyInvoice(tc, invoice)
+	}
+
+	schedule, capacity, err := requestFee(tx, tc.Record.ID)
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		return fmt.Errorf("%w: no fee schedule covers %s requests of capacity %g", ErrInvalidTransition, tc.Record.LicenseType, capacity)
+	}
+
+	number, err := nextDocumentNumber(tx, "invoice_number", "INV", now)
+	if err != nil {
+		return err
+	}
+	description := schedule.Description
+	if description == "" {
+		description = "ค่าธรรมเนียมคำขอเลขที่ " + tc.Record.RequestNumber
+	}
+	invoice = &models.Invoice{
+		InvoiceNumber:    number,
+		LicenseRequestID: tc.Record.ID,
+		FeeScheduleID:    schedule.ID,
+		Capacity:         capacity,
+		Amount:           schedule.Amount,
+		Currency:         feeCurrency,
+		Description:      description,
+		Status:           models.InvoiceStatusPending,
+		DueDate:          tc.Record.Deadline,
+		Provider:         provider.Name(),
+		IssuedAt:         now,
+	}
+
+	charge, err := provider.CreateCharge(invoice)
+	if err != nil {
+		return fmt.Errorf("failed to create payment charge: %w", err)
+	}
+	invoice.ProviderReference = charge.Reference
+	invoice.PaymentPayload = charge.Payload
+
+	if err := tx.Create(invoice).Error; err != nil {
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+	return notifyInvoice(tc, invoice)
+}
+
+// notifyInvoice tells the applicant what to pay and by when
+func notifyInvoice(tc *TransitionContext, invoice *models.Invoice) error {
+	message := fmt.Sprintf("คำขอเลขที่ %s มีค่าธรรมเนียม %s บาท ตามใบแจ้งชำระเลขที่ %s",
+		tc.Record.RequestNumber, formatBaht(invoice.Amount), invoice.InvoiceNumber)
+	if invoice.DueDate != nil {
+		message += " กรุณาชำระภายในวันที่ " + utils.FormatThaiDate(*invoice.DueDate)
+	}
+	return tc.NotifyUser(tc.Record.UserID, "แจ้งชำระค่าธรรมเนียม", message,
+		models.NotificationType("invoice_issued"), models.PriorityHigh, "/dashboard/licenses")
+}
+
+// cancelInvoices cancels the unpaid invoice of a request that ended
+func cancelInvoices(tx *gorm.DB, record *WorkflowRequestRecord, now time.Time) error {
+	err := tx.Model(&models.Invoice{}).
+		Where("license_request_id = ? AND status = ?", record.ID, models.InvoiceStatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.InvoiceStatusCancelled,
+			"cancelled_at": now,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to cancel invoices: %w", err)
+	}
+	return nil
+}
+
+// currentInvoice returns the pending or paid invoice of a request, or nil when it has none
+func currentInvoice(tx *gorm.DB, requestID uint) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := tx.Where("license_request_id = ? AND status IN ?", requestID, liveInvoiceStatuses).
+		Order("id DESC").
+		Take(&invoice).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return &invoice, nil
+}
+
+// feePaidTransition returns the status an automatic transition out of a status takes once the fee is paid
+func feePaidTransition(stateMachine *models.WorkflowStateMachine, status models.RequestStatus) (models.RequestStatus, bool) {
+	for _, transition := range stateMachine.GetValidTransitions(status, models.UserRole("")) {
+		if !transition.AutoAllowed {
+			continue
+		}
+		for _, guard := range transition.Guards {
+			if guard == GuardFeePaid {
+				return transition.ToStatus, true
+			}
+		}
+	}
+	return "", false
+}
+
+// nextDocumentNumber numbers invoices and receipts per year, e.g. INV-YYYY-XXXXXX
+func nextDocumentNumber(tx *gorm.DB, column, prefix string, now time.Time) (string, error) {
+	yearPrefix := fmt.Sprintf("%s-%d-", prefix, now.Year())
+	var count int64
+	err := tx.Model(&models.Invoice{}).
+		Where(column+" LIKE ?", yearPrefix+"%").
+		Count(&count).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to count %s numbers: %w", prefix, err)
+	}
+	return fmt.Sprintf("%s%06d", yearPrefix, count+1), nil
+}
+
+// formatBaht formats an amount with thousands separators, e.g. 12,500.00
+func formatBaht(amount float64) string {
+	text := strconv.FormatFloat(amount, 'f', 2, 64)
+	whole, fraction := text[:len(text)-3], text[len(text)-3:]
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && digit != '-' && (len(whole)-i)%3 == 0 && whole[i-1] != '-' {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String() + fraction
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// replayBatchSize is the number of requests a consistency check loads at a time
+const replayBatchSize = 200
+
+// repairChangeReason is the reason logged on entries appended by the log repair strategy
+const repairChangeReason = "บันทึกการเปลี่ยนสถานะที่ไม่มีประวัติ (ตรวจสอบความสอดคล้อง)"
+
+// replayRecord is a request with the columns needed to replay its flow log
+type replayRecord struct {
+	WorkflowRequestRecord
+	CreatedAt time.Time
+}
+
+type ReplayService interface {
+	ReplayRequest(requestID uint) (*dto.ReplayReport, error)
+	CheckConsistency(req dto.ConsistencyCheckRequest) (*dto.ConsistencyCheckResult, error)
+	GetRequestStateAsOf(requestID uint, at time.Time) (*dto.RequestStateAsOf, error)
+}
+
+type replayService struct {
+	db                *gorm.DB
+	definitionService WorkflowDefinitionService
+}
+
+func NewReplayService(db *gorm.DB) ReplayService {
+	return &replayService{
+		db:                db,
+		definitionService: NewWorkflowDefinitionService(db),
+	}
+}
+
+// ReplayRequest replays a request's flow log through the workflow definition it is pinned to
+func (s *replayService) ReplayRequest(requestID uint) (*dto.ReplayReport, error) {
+	record, err := s.getRecord(s.db, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	flowLogs, err := s.getFlowLogs(s.db, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replay(record, flowLogs)
+}
+
+// CheckConsistency replays every selected request and, when asked to, repairs the ones whose
+// status does not match their flow log. Illegal transitions and gaps in the history are only
+// reported: they describe what happened and are not rewritten.
+func (s *replayService) CheckConsistency(req dto.ConsistencyCheckRequest) (*dto.ConsistencyCheckResult, error) {
+	if req.Strategy == "" {
+		req.Strategy = dto.RepairStrategyLog
+	}
+	if req.Strategy != dto.RepairStrategyLog && req.Strategy != dto.RepairStrategyStatus {
+		return nil, fmt.Errorf("unknown repair strategy: %s", req.Strategy)
+	}
+
+	result := &dto.ConsistencyCheckResult{
+		IssueCounts: make(map[string]int),
+		Reports:     make([]dto.ReplayReport, 0),
+		CheckedAt:   time.Now(),
+	}
+
+	query := s.db.Table(licenseRequestTable).Where("deleted_at IS NULL")
+	if req.LicenseType != "" {
+		query = query.Where("license_type = ?", models.ParseLicenseType(req.LicenseType))
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if len(req.RequestIDs) > 0 {
+		query = query.Where("id IN ?", req.RequestIDs)
+	}
+
+	var records []replayRecord
+	var checkErr error
+	batches := query.Order("id").FindInBatches(&records, replayBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range records {
+			report, err := s.check(&records[i], req)
+			if err != nil {
+				checkErr = err
+				return err
+			}
+
+			result.Checked++
+			if report.Consistent {
+				result.Consistent++
+				continue
+			}
+			result.Inconsistent++
+			for _, issue := range report.Issues {
+				result.IssueCounts[issue.Kind]++
+			}
+			if report.Repair != nil {
+				result.Repaired++
+			}
+			result.Reports = append(result.Reports, *report)
+		}
+		return nil
+	})
+	if checkErr != nil {
+		return nil, checkErr
+	}
+	if batches.Error != nil {
+		return nil, fmt.Errorf("failed to get requests: %w", batches.Error)
+	}
+
+	return result, nil
+}
+
+// GetRequestStateAsOf reconstructs a request as it stood at a point in time from its flow log
+// and submission snapshots
+func (s *replayService) GetRequestStateAsOf(requestID uint, at time.Time) (*dto.RequestStateAsOf, error) {
+	record, err := s.getRecord(s.db, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+
+	var flowLogs []models.ServiceFlowLog
+	err = s.db.Preload("ChangedByUser").Preload("OnBehalfOfUser").
+		Where("license_request_id = ? AND created_at <= ?", requestID, at).
+		Order("id").
+		Find(&flowLogs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow history: %w", err)
+	}
+
+	state := &dto.RequestStateAsOf{
+		RequestID:     record.ID,
+		RequestNumber: record.RequestNumber,
+		LicenseType:   record.LicenseType,
+		AsOf:          at,
+		Existed:       !at.Before(record.CreatedAt),
+		Steps:         replaySteps(stateMachine, flowLogs),
+		CurrentStatus: record.Status,
+	}
+	if len(state.Steps) > 0 {
+		last := state.Steps[len(state.Steps)-1]
+		state.Existed = true
+		state.Status = last.ToStatus
+		state.Since = &last.ChangedAt
+		state.ChangedByID = last.ChangedByID
+		state.ChangedBy = last.ChangedBy
+		state.Reason = last.Reason
+	}
+
+	var submission models.RequestSubmission
+	err = s.db.Preload("SubmittedBy").
+		Where("request_id = ? AND created_at <= ?", requestID, at).
+		Order("sequence DESC").
+		Take(&submission).Error
+	if err == nil {
+		response := submissionResponse(&submission)
+		state.Submission = &response
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+
+	return state, nil
+}
+
+// check replays one request and repairs it if asked to and needed
+func (s *replayService) check(record *replayRecord, req dto.ConsistencyCheckRequest) (*dto.ReplayReport, error) {
+	flowLogs, err := s.getFlowLogs(s.db, record.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.replay(record, flowLogs)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Repair || report.Status == report.ReplayedStatus {
+		return report, nil
+	}
+
+	repair, err := s.repair(record, report, req.Strategy, req.RepairedBy)
+	if err != nil {
+		return nil, err
+	}
+	report.Repair = repair
+	return report, nil
+}
+
+// replay compares a request with its flow log
+func (s *replayService) replay(record *replayRecord, flowLogs []models.ServiceFlowLog) (*dto.ReplayReport, error) {
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+
+	report := &dto.ReplayReport{
+		RequestID:       record.ID,
+		RequestNumber:   record.RequestNumber,
+		LicenseType:     record.LicenseType,
+		WorkflowVersion: record.WorkflowVersion,
+		Status:          record.Status,
+		Steps:           replaySteps(stateMachine, flowLogs),
+		Issues:          make([]dto.ReplayIssue, 0),
+	}
+
+	var replayed models.RequestStatus
+	for _, step := range report.Steps {
+		flowLogID := step.FlowLogID
+		if step.FromStatus != nil && replayed != "" && *step.FromStatus != replayed {
+			report.Issues = append(report.Issues, dto.ReplayIssue{
+				Kind:       dto.ReplayIssueHistoryGap,
+				FlowLogID:  &flowLogID,
+				FromStatus: replayed,
+				ToStatus:   *step.FromStatus,
+				Message:    fmt.Sprintf("entry %d starts from %s but the log left the request in %s", flowLogID, *step.FromStatus, replayed),
+			})
+		}
+		if !step.Legal {
+			issue := dto.ReplayIssue{
+				Kind:      dto.ReplayIssueIllegalTransition,
+				FlowLogID: &flowLogID,
+				ToStatus:  step.ToStatus,
+				Message:   fmt.Sprintf("entry %d starts the request in %s, which is not an initial status", flowLogID, step.ToStatus),
+			}
+			if step.FromStatus != nil {
+				issue.FromStatus = *step.FromStatus
+				issue.Message = fmt.Sprintf("entry %d moves the request from %s to %s, which workflow version %d does not allow", flowLogID, *step.FromStatus, step.ToStatus, record.WorkflowVersion)
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+		replayed = step.ToStatus
+	}
+	report.ReplayedStatus = replayed
+
+	switch {
+	case len(report.Steps) == 0:
+		report.Issues = append(report.Issues, dto.ReplayIssue{
+			Kind:     dto.ReplayIssueMissingHistory,
+			ToStatus: record.Status,
+			Message:  fmt.Sprintf("request is %s but has no flow log", record.Status),
+		})
+	case replayed != record.Status:
+		report.Issues = append(report.Issues, dto.ReplayIssue{
+			Kind:       dto.ReplayIssueStatusMismatch,
+			FromStatus: replayed,
+			ToStatus:   record.Status,
+			Message:    fmt.Sprintf("request is %s but its flow log ends in %s", record.Status, replayed),
+		})
+	}
+
+	report.Consistent = len(report.Issues) == 0
+	return report, nil
+}
+
+// repair brings a request's status and flow log back in line. The log strategy appends the
+// missing entry; the status strategy restores the status the log ends in. Either way the request
+// version moves on, so a transition racing the repair loses its compare-and-swap.
+func (s *replayService) repair(record *replayRecord, report *dto.ReplayReport, strategy string, repairedBy *uint) (*dto.ReplayRepair, error) {
+	if strategy == dto.RepairStrategyStatus && report.ReplayedStatus == "" {
+		// Nothing to restore from a request without history
+		return nil, nil
+	}
+
+	var repair *dto.ReplayRepair
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current WorkflowRequestRecord
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Table(licenseRequestTable).
+			Where("id = ? AND deleted_at IS NULL", record.ID).
+			Take(&current).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock request: %w", err)
+		}
+		if current.Version != record.Version {
+			// The request moved on while it was checked; the next check sees the new state
+			return nil
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"version":    current.Version + 1,
+			"updated_at": now,
+		}
+		repair = &dto.ReplayRepair{
+			Strategy:   strategy,
+			FromStatus: report.ReplayedStatus,
+			ToStatus:   current.Status,
+			Version:    current.Version + 1,
+		}
+
+		if strategy == dto.RepairStrategyStatus {
+			stateMachine, err := s.definitionService.GetStateMachine(current.LicenseType, current.WorkflowVersion)
+			if err != nil {
+				return fmt.Errorf("failed to load workflow definition: %w", err)
+			}
+			updates["status"] = report.ReplayedStatus
+			updates["deadline"] = stateMachine.GetDefaultDeadline(report.ReplayedStatus, now)
+			repair.FromStatus, repair.ToStatus = current.Status, report.ReplayedStatus
+		} else {
+			flowLog := &models.ServiceFlowLog{
+				LicenseRequestID: current.ID,
+				NewStatus:        current.Status,
+				ChangedBy:        repairedBy,
+				ChangeReason:     repairChangeReason,
+				LicenseType:      current.LicenseType,
+			}
+			if report.ReplayedStatus != "" {
+				flowLog.PreviousStatus = &report.ReplayedStatus
+			}
+			if err := tx.Create(flowLog).Error; err != nil {
+				return fmt.Errorf("failed to create flow log: %w", err)
+			}
+			repair.FlowLogID = &flowLog.ID
+		}
+
+		if err := tx.Table(licenseRequestTable).Where("id = ?", current.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update request: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repair, nil
+}
+
+// replaySteps checks each flow log entry against the state machine. An entry without a previous
+// status continues from where the log was, or starts the request when it is the first.
+func replaySteps(stateMachine *models.WorkflowStateMachine, flowLogs []models.ServiceFlowLog) []dto.ReplayStep {
+	steps := make([]dto.ReplayStep, 0, len(flowLogs))
+	var replayed models.RequestStatus
+	for _, flowLog := range flowLogs {
+		from := replayed
+		if flowLog.PreviousStatus != nil {
+			from = *flowLog.PreviousStatus
+		}
+
+		legal := stateMachine.IsInitialState(flowLog.NewStatus)
+		if from != "" {
+			legal = stateMachine.HasTransition(from, flowLog.NewStatus)
+		}
+
+		steps = append(steps, dto.ReplayStep{
+			FlowLogID:    flowLog.ID,
+			FromStatus:   flowLog.PreviousStatus,
+			ToStatus:     flowLog.NewStatus,
+			ChangedByID:  flowLog.ChangedBy,
+			ChangedBy:    flowLog.GetActorDisplayName(),
+			OnBehalfOfID: flowLog.OnBehalfOf,
+			Reason:       flowLog.ChangeReason,
+			ChangedAt:    flowLog.CreatedAt,
+			Legal:        legal,
+		})
+		replayed = flowLog.NewStatus
+	}
+	return steps
+}
+
+func (s *replayService) getRecord(tx *gorm.DB, requestID uint) (*replayRecord, error) {
+	var record replayRecord
+	err := tx.Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", requestID).
+		Take(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+	return &record, nil
+}
+
+// getFlowLogs returns a request's flow log, oldest first
+func (s *replayService) getFlowLogs(tx *gorm.DB, requestID uint) ([]models.ServiceFlowLog, error) {
+	var flowLogs []models.ServiceFlowLog
+	err := tx.Preload("ChangedByUser").Preload("OnBehalfOfUser").
+		Where("license_request_id = ?", requestID).
+		Order("id").
+		Find(&flowLogs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow history: %w", err)
+	}
+	return flowLogs, nil
+}
+
+// ParseAsOf parses the point in time of an as-of view: an RFC 3339 time, or a date meaning the
+// end of that day in Bangkok
+func ParseAsOf(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		loc = time.FixedZone("ICT", 7*60*60)
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a YYYY-MM-DD date: %q", value)
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"eservice-backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaySteps(t *testing.T) {
+	stateMachine := models.NewWorkflowStateMachine()
+	status := func(s models.RequestStatus) *models.RequestStatus { return &s }
+
+	tests := []struct {
+		name      string
+		flowLogs  []models.ServiceFlowLog // PreviousStatus and NewStatus only; IDs are filled in
+		wantLegal []bool
+	}{
+		{
+			name: "legal history",
+			flowLogs: []models.ServiceFlowLog{
+				{NewStatus: models.StatusDraft},
+				{PreviousStatus: status(models.StatusDraft), NewStatus: models.StatusNewRequest},
+				{PreviousStatus: status(models.StatusNewRequest), NewStatus: models.StatusAccepted},
+			},
+			wantLegal: []bool{true, true, true},
+		},
+		{
+			name:      "created already submitted",
+			flowLogs:  []models.ServiceFlowLog{{NewStatus: models.StatusNewRequest}},
+			wantLegal: []bool{true},
+		},
+		{
+			name:      "created midway through the workflow",
+			flowLogs:  []models.ServiceFlowLog{{NewStatus: models.StatusAssigned}},
+			wantLegal: []bool{false},
+		},
+		{
+			name: "skipped step",
+			flowLogs: []models.ServiceFlowLog{
+				{NewStatus: models.StatusDraft},
+				{PreviousStatus: status(models.StatusDraft), NewStatus: models.StatusAccepted},
+			},
+			wantLegal: []bool{true, false},
+		},
+		{
+			name: "entry without a previous status continues from the log",
+			flowLogs: []models.ServiceFlowLog{
+				{NewStatus: models.StatusDraft},
+				{NewStatus: models.StatusNewRequest},
+				{NewStatus: models.StatusApproved},
+			},
+			wantLegal: []bool{true, true, false},
+		},
+		{
+			name: "logged previous status wins over the replayed one",
+			flowLogs: []models.ServiceFlowLog{
+				{NewStatus: models.StatusDraft},
+				{PreviousStatus: status(models.StatusReportApproved), NewStatus: models.StatusApproved},
+			},
+			wantLegal: []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.flowLogs {
+				tt.flowLogs[i].ID = uint(i + 1)
+			}
+
+			steps := replaySteps(stateMachine, tt.flowLogs)
+
+			require.Len(t, steps, len(tt.flowLogs))
+			for i, step := range steps {
+				assert.Equal(t, uint(i+1), step.FlowLogID)
+				assert.Equal(t, tt.flowLogs[i].PreviousStatus, step.FromStatus)
+				assert.Equal(t, tt.flowLogs[i].NewStatus, step.ToStatus)
+				assert.Equal(t, tt.wantLegal[i], step.Legal, "step %d", i+1)
+			}
+		})
+	}
+}
+
+func TestParseAsOf(t *testing.T) {
+	bangkok := time.FixedZone("ICT", 7*60*60)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "RFC 3339 time", value: "2026-03-01T08:30:00Z", want: time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{name: "date is the end of the day in Bangkok", value: "2026-03-01", want: time.Date(2026, 3, 1, 23, 59, 59, 999999999, bangkok)},
+		{name: "invalid date", value: "2026-02-30", wantErr: true},
+		{name: "not a time", value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAsOf(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+		})
+	}
+}
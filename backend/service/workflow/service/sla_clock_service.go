@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSLAClockNotFound is returned for requests that have not been submitted since the SLA clock was introduced
+var ErrSLAClockNotFound = errors.New("sla clock not found")
+
+type SLAClockService interface {
+	GetRequestClock(requestID uint) (*dto.SLAClockResponse, error)
+	GetStatistics(licenseType string) ([]dto.SLAStatistics, error)
+}
+
+type slaClockService struct {
+	db *gorm.DB
+}
+
+func NewSLAClockService(db *gorm.DB) SLAClockService {
+	return &slaClockService{db: db}
+}
+
+// GetRequestClock returns a request's SLA clock with totals up to now
+func (s *slaClockService) GetRequestClock(requestID uint) (*dto.SLAClockResponse, error) {
+	var clock models.RequestSLAClock
+	if err := s.db.Where("request_id = ?", requestID).Take(&clock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSLAClockNotFound
+		}
+		return nil, fmt.Errorf("failed to get sla clock: %w", err)
+	}
+
+	withDEDE, withApplicant := clock.Totals(time.Now())
+	return &dto.SLAClockResponse{
+		RequestID:         clock.RequestID,
+		LicenseType:       clock.LicenseType,
+		State:             clock.State,
+		StartedAt:         clock.StartedAt,
+		PausedAt:          clock.PausedAt,
+		StoppedAt:         clock.StoppedAt,
+		PauseCount:        clock.PauseCount,
+		DaysWithDEDE:      durationInDays(withDEDE),
+		DaysWithApplicant: durationInDays(withApplicant),
+		TotalDays:         durationInDays(withDEDE + withApplicant),
+	}, nil
+}
+
+// GetStatistics returns clock counts and average totals per license type and clock state.
+// Averages of running and paused clocks cover the time booked at their last change.
+func (s *slaClockService) GetStatistics(licenseType string) ([]dto.SLAStatistics, error) {
+	var rows []struct {
+		LicenseType         string
+		State               models.SLAClockState
+		Requests            int64
+		AvgDEDESeconds      float64
+		AvgApplicantSeconds float64
+	}
+
+	query := s.db.Model(&models.RequestSLAClock{}).
+		Select("license_type, state, COUNT(*) AS requests, AVG(dede_seconds) AS avg_dede_seconds, AVG(applicant_seconds) AS avg_applicant_seconds").
+		Group("license_type, state").
+		Order("license_type, state")
+	if licenseType != "" {
+		query = query.Where("license_type = ?", licenseType)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sla statistics: %w", err)
+	}
+
+	stats := make([]dto.SLAStatistics, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, dto.SLAStatistics{
+			LicenseType:          row.LicenseType,
+			State:                row.State,
+			Requests:             row.Requests,
+			AvgDaysWithDEDE:      row.AvgDEDESeconds / secondsPerDay,
+			AvgDaysWithApplicant: row.AvgApplicantSeconds / secondsPerDay,
+		})
+	}
+	return stats, nil
+}
+
+const secondsPerDay = float64(24 * 60 * 60)
+
+func durationInDays(d time.Duration) float64 {
+	return d.Hours() / 24
+}
+
+// advanceSLAClock books the time since the clock's last change to DEDE or the applicant and
+// switches it to state. The clock starts at the first status change out of an applicant-owned
+// status, i.e. the first submission. When it stops, the totals are written as workflow metrics.
+func advanceSLAClock(tx *gorm.DB, requestID uint, licenseType string, state models.SLAClockState, assignedToID *uint, now time.Time) error {
+	var clock models.RequestSLAClock
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("request_id = ?", requestID).
+		Take(&clock).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Drafts are not timed
+		if state == models.SLAClockPaused {
+			return nil
+		}
+		clock = models.RequestSLAClock{
+			RequestID:     requestID,
+			LicenseType:   licenseType,
+			State:         models.SLAClockRunning,
+			StartedAt:     now,
+			LastChangedAt: now,
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get sla clock: %w", err)
+	case clock.State == models.SLAClockStopped:
+		return nil
+	}
+
+	clock.Advance(state, now)
+	if err := tx.Save(&clock).Error; err != nil {
+		return fmt.Errorf("failed to update sla clock: %w", err)
+	}
+
+	if state != models.SLAClockStopped {
+		return nil
+	}
+
+	withDEDE, withApplicant := clock.Totals(now)
+	metrics := []models.WorkflowMetric{
+		{MetricType: models.MetricTypeProcessingTime, MetricValue: durationInDays(withDEDE)},
+		{MetricType: models.MetricTypeApplicantTime, MetricValue: durationInDays(withApplicant)},
+	}
+	for i := range metrics {
+		metrics[i].RequestID = requestID
+		metrics[i].LicenseType = licenseType
+		metrics[i].MetricUnit = "days"
+		metrics[i].StartTime = clock.StartedAt
+		metrics[i].EndTime = now
+		metrics[i].AssignedToID = assignedToID
+	}
+	if err := tx.Create(&metrics).Error; err != nil {
+		return fmt.Errorf("failed to record workflow metrics: %w", err)
+	}
+	return nil
+}
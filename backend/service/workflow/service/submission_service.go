@@ -0,0 +1,318 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// ErrSubmissionNotFound is returned when a request has no submission with the asked sequence
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+type SubmissionService interface {
+	GetSubmissions(requestID uint) ([]dto.SubmissionResponse, error)
+	GetSubmissionDiff(requestID uint, previousSequence, currentSequence int) (*dto.SubmissionDiff, error)
+	GetResubmitStatus(requestID uint, role models.UserRole) (models.RequestStatus, error)
+}
+
+type submissionService struct {
+	db                *gorm.DB
+	definitionService WorkflowDefinitionService
+}
+
+func NewSubmissionService(db *gorm.DB) SubmissionService {
+	return &submissionService{
+		db:                db,
+		definitionService: NewWorkflowDefinitionService(db),
+	}
+}
+
+// GetSubmissions returns the submission snapshots of a request, oldest first
+func (s *submissionService) GetSubmissions(requestID uint) ([]dto.SubmissionResponse, error) {
+	var submissions []models.RequestSubmission
+	err := s.db.Preload("SubmittedBy").
+		Where("request_id = ?", requestID).
+		Order("sequence").
+		Find(&submissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submissions: %w", err)
+	}
+
+	responses := make([]dto.SubmissionResponse, 0, len(submissions))
+	for i := range submissions {
+		responses = append(responses, submissionResponse(&submissions[i]))
+	}
+	return responses, nil
+}
+
+// GetSubmissionDiff compares two submissions of a request. A zero current sequence means the
+// latest submission and a zero previous sequence the one before the current submission.
+func (s *submissionService) GetSubmissionDiff(requestID uint, previousSequence, currentSequence int) (*dto.SubmissionDiff, error) {
+	current, err := s.getSubmission(requestID, currentSequence)
+	if err != nil {
+		return nil, err
+	}
+	if previousSequence == 0 {
+		previousSequence = current.Sequence - 1
+	}
+	if previousSequence < 1 {
+		return nil, fmt.Errorf("%w: submission %d has no previous submission", ErrSubmissionNotFound, current.Sequence)
+	}
+	previous, err := s.getSubmission(requestID, previousSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffSubmissions(previous, current), nil
+}
+
+// GetResubmitStatus returns the status a returned request goes back to when the applicant
+// resubmits it: the status it was returned from if the workflow allows the role to go back
+// there, otherwise new_request
+func (s *submissionService) GetResubmitStatus(requestID uint, role models.UserRole) (models.RequestStatus, error) {
+	var record WorkflowRequestRecord
+	err := s.db.Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", requestID).
+		Take(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrRequestNotFound
+		}
+		return "", fmt.Errorf("failed to get request: %w", err)
+	}
+	if record.Status != models.StatusReturned {
+		return "", fmt.Errorf("%w: request %s is not returned", ErrInvalidTransition, record.RequestNumber)
+	}
+
+	from, err := returnedFrom(s.db, record.ID)
+	if err != nil {
+		return "", err
+	}
+
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+	if stateMachine.CanTransition(models.StatusReturned, from, role) {
+		return from, nil
+	}
+	return models.StatusNewRequest, nil
+}
+
+// getSubmission returns a submission by sequence, or the latest one for sequence 0
+func (s *submissionService) getSubmission(requestID uint, sequence int) (*models.RequestSubmission, error) {
+	query := s.db.Where("request_id = ?", requestID)
+	if sequence > 0 {
+		query = query.Where("sequence = ?", sequence)
+	}
+
+	var submission models.RequestSubmission
+	if err := query.Order("sequence DESC").Take(&submission).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubmissionNotFound
+		}
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+	return &submission, nil
+}
+
+// SaveRequestFields returns a transition hook that writes the applicant-editable fields of a
+// request, so corrections are committed together with the resubmission and end up in its snapshot
+func SaveRequestFields(request *models.LicenseRequest) TransitionHook {
+	return func(tc *TransitionContext) error {
+		err := tc.Tx.Table(licenseRequestTable).
+			Where("id = ?", tc.Record.ID).
+			Updates(map[string]interface{}{
+				"title":              request.Title,
+				"description":        request.Description,
+				"current_capacity":   request.CurrentCapacity,
+				"requested_capacity": request.RequestedCapacity,
+				"location":           request.Location,
+				"contact_person":     request.ContactPerson,
+				"contact_phone":      request.ContactPhone,
+				"contact_email":      request.ContactEmail,
+				"payload":            request.Payload,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to save request fields: %w", err)
+		}
+		tc.Record.RequestedCapacity = request.RequestedCapacity
+		return nil
+	}
+}
+
+// recordSubmission stores a snapshot of the request's fields and attachments as they are inside the transaction
+func recordSubmission(tx *gorm.DB, record *WorkflowRequestRecord, from, to models.RequestStatus, submittedByID uint, flowLogID *uint) error {
+	var request models.LicenseRequest
+	if err := tx.Take(&request, record.ID).Error; err != nil {
+		return fmt.Errorf("failed to get request: %w", err)
+	}
+
+	var attachments []models.Attachment
+	err := tx.Where("entity_type = ? AND entity_id = ?", "license_request", record.ID).
+		Order("id").
+		Find(&attachments).Error
+	if err != nil {
+		return fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	var last int
+	err = tx.Model(&models.RequestSubmission{}).
+		Where("request_id = ?", record.ID).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return fmt.Errorf("failed to get submission sequence: %w", err)
+	}
+
+	submission := &models.RequestSubmission{
+		RequestID:     record.ID,
+		LicenseType:   record.LicenseType,
+		Sequence:      last + 1,
+		FromStatus:    from,
+		ToStatus:      to,
+		FlowLogID:     flowLogID,
+		SubmittedByID: submittedByID,
+	}
+	if err := submission.SetSnapshot(&request, attachments); err != nil {
+		return err
+	}
+	if err := tx.Create(submission).Error; err != nil {
+		return fmt.Errorf("failed to record submission: %w", err)
+	}
+	return nil
+}
+
+// hasSubmission reports whether a snapshot of the request has been taken before
+func hasSubmission(tx *gorm.DB, requestID uint) (bool, error) {
+	var submissions int64
+	err := tx.Model(&models.RequestSubmission{}).
+		Where("request_id = ?", requestID).
+		Count(&submissions).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to count submissions: %w", err)
+	}
+	return submissions > 0, nil
+}
+
+// returnedFrom returns the status the request was last returned to the applicant from.
+// Requests returned before flow logs were kept go back to new_request.
+func returnedFrom(tx *gorm.DB, requestID uint) (models.RequestStatus, error) {
+	flowLog, err := lastEntryInto(tx, requestID, models.StatusReturned)
+	if err != nil {
+		return "", err
+	}
+	if flowLog == nil || flowLog.PreviousStatus == nil {
+		return models.StatusNewRequest, nil
+	}
+	return *flowLog.PreviousStatus, nil
+}
+
+// lastEntryInto returns the latest flow log entry that moved the request into status, or nil if there is none
+func lastEntryInto(tx *gorm.DB, requestID uint, status models.RequestStatus) (*models.ServiceFlowLog, error) {
+	var flowLog models.ServiceFlowLog
+	err := tx.Where("license_request_id = ? AND new_status = ?", requestID, status).
+		Order("id DESC").
+		Take(&flowLog).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s entry: %w", status, err)
+	}
+	return &flowLog, nil
+}
+
+// diffSubmissions lists the field and attachment changes from previous to current
+func diffSubmissions(previous, current *models.RequestSubmission) *dto.SubmissionDiff {
+	diff := &dto.SubmissionDiff{
+		RequestID:           current.RequestID,
+		PreviousSequence:    previous.Sequence,
+		CurrentSequence:     current.Sequence,
+		PreviousSubmittedAt: previous.CreatedAt,
+		CurrentSubmittedAt:  current.CreatedAt,
+		Fields:              make([]dto.FieldChange, 0),
+		AddedAttachments:    make([]models.SubmittedAttachment, 0),
+		RemovedAttachments:  make([]models.SubmittedAttachment, 0),
+		ChangedAttachments:  make([]dto.AttachmentChange, 0),
+	}
+
+	previousFields := previous.GetFields()
+	currentFields := current.GetFields()
+	names := make([]string, 0, len(currentFields))
+	for name := range currentFields {
+		names = append(names, name)
+	}
+	for name := range previousFields {
+		if _, ok := currentFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !reflect.DeepEqual(previousFields[name], currentFields[name]) {
+			diff.Fields = append(diff.Fields, dto.FieldChange{
+				Field:    name,
+				Previous: previousFields[name],
+				Current:  currentFields[name],
+			})
+		}
+	}
+
+	previousAttachments := make(map[uint]models.SubmittedAttachment)
+	for _, attachment := range previous.GetAttachments() {
+		previousAttachments[attachment.ID] = attachment
+	}
+	for _, attachment := range current.GetAttachments() {
+		before, ok := previousAttachments[attachment.ID]
+		delete(previousAttachments, attachment.ID)
+		switch {
+		case !ok:
+			diff.AddedAttachments = append(diff.AddedAttachments, attachment)
+		case attachmentChanged(before, attachment):
+			diff.ChangedAttachments = append(diff.ChangedAttachments, dto.AttachmentChange{Previous: before, Current: attachment})
+		}
+	}
+	for _, attachment := range previous.GetAttachments() {
+		if _, ok := previousAttachments[attachment.ID]; ok {
+			diff.RemovedAttachments = append(diff.RemovedAttachments, attachment)
+		}
+	}
+
+	diff.HasChanges = len(diff.Fields) > 0 || len(diff.AddedAttachments) > 0 ||
+		len(diff.RemovedAttachments) > 0 || len(diff.ChangedAttachments) > 0
+	return diff
+}
+
+// attachmentChanged reports whether the file behind an attachment was replaced or relabelled
+func attachmentChanged(before, after models.SubmittedAttachment) bool {
+	return before.FileName != after.FileName ||
+		before.OriginalName != after.OriginalName ||
+		before.FileSize != after.FileSize ||
+		before.MimeType != after.MimeType ||
+		before.Description != after.Description
+}
+
+func submissionResponse(submission *models.RequestSubmission) dto.SubmissionResponse {
+	response := dto.SubmissionResponse{
+		ID:            submission.ID,
+		RequestID:     submission.RequestID,
+		LicenseType:   submission.LicenseType,
+		Sequence:      submission.Sequence,
+		FromStatus:    submission.FromStatus,
+		ToStatus:      submission.ToStatus,
+		SubmittedByID: submission.SubmittedByID,
+		Fields:        submission.GetFields(),
+		Attachments:   submission.GetAttachments(),
+		SubmittedAt:   submission.CreatedAt,
+	}
+	if submission.SubmittedBy != nil {
+		response.SubmittedBy = submission.SubmittedBy.FullName
+	}
+	return response
+}
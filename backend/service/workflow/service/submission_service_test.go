@@ -0,0 +1,111 @@
+package service
+
+import (
+	"encoding/json"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSubmissions(t *testing.T) {
+	permit := models.SubmittedAttachment{ID: 1, FileName: "permit.pdf", OriginalName: "permit.pdf", FileSize: 100, MimeType: "application/pdf"}
+	sitePlan := models.SubmittedAttachment{ID: 2, FileName: "plan.pdf", OriginalName: "plan.pdf", FileSize: 200, MimeType: "application/pdf"}
+	replacedPermit := permit
+	replacedPermit.FileName = "permit-v2.pdf"
+	replacedPermit.FileSize = 120
+	relabelledPlan := sitePlan
+	relabelledPlan.Description = "Site plan, sheet 2"
+	photo := models.SubmittedAttachment{ID: 3, FileName: "roof.jpg", OriginalName: "roof.jpg", FileSize: 300, MimeType: "image/jpeg"}
+
+	tests := []struct {
+		name                string
+		previousFields      string
+		currentFields       string
+		previousAttachments string
+		currentAttachments  string
+		wantFields          []dto.FieldChange
+		wantAdded           []models.SubmittedAttachment
+		wantRemoved         []models.SubmittedAttachment
+		wantChanged         []dto.AttachmentChange
+	}{
+		{
+			name:           "nothing changed",
+			previousFields: `{"title": "Solar farm", "requested_capacity": 10}`,
+			currentFields:  `{"requested_capacity": 10, "title": "Solar farm"}`,
+		},
+		{
+			name:           "changed fields are listed by name",
+			previousFields: `{"title": "Solar farm", "requested_capacity": 10, "location": "Chiang Mai"}`,
+			currentFields:  `{"title": "Solar farm 2", "requested_capacity": 12.5, "location": "Chiang Mai"}`,
+			wantFields: []dto.FieldChange{
+				{Field: "requested_capacity", Previous: float64(10), Current: 12.5},
+				{Field: "title", Previous: "Solar farm", Current: "Solar farm 2"},
+			},
+		},
+		{
+			name:           "added and removed fields",
+			previousFields: `{"payload.reason": "expiring"}`,
+			currentFields:  `{"technical.inverters": [{"model": "X1"}]}`,
+			wantFields: []dto.FieldChange{
+				{Field: "payload.reason", Previous: "expiring", Current: nil},
+				{Field: "technical.inverters", Previous: nil, Current: []interface{}{map[string]interface{}{"model": "X1"}}},
+			},
+		},
+		{
+			name:                "attachments added, removed and replaced",
+			previousAttachments: attachmentsJSON(permit, sitePlan),
+			currentAttachments:  attachmentsJSON(replacedPermit, photo),
+			wantAdded:           []models.SubmittedAttachment{photo},
+			wantRemoved:         []models.SubmittedAttachment{sitePlan},
+			wantChanged:         []dto.AttachmentChange{{Previous: permit, Current: replacedPermit}},
+		},
+		{
+			name:                "relabelled attachment",
+			previousAttachments: attachmentsJSON(permit, sitePlan),
+			currentAttachments:  attachmentsJSON(permit, relabelledPlan),
+			wantChanged:         []dto.AttachmentChange{{Previous: sitePlan, Current: relabelledPlan}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := &models.RequestSubmission{RequestID: 7, Sequence: 1, Fields: rawJSON(tt.previousFields, "{}"), Attachments: rawJSON(tt.previousAttachments, "[]")}
+			current := &models.RequestSubmission{RequestID: 7, Sequence: 2, Fields: rawJSON(tt.currentFields, "{}"), Attachments: rawJSON(tt.currentAttachments, "[]")}
+
+			diff := diffSubmissions(previous, current)
+
+			assert.Equal(t, uint(7), diff.RequestID)
+			assert.Equal(t, 1, diff.PreviousSequence)
+			assert.Equal(t, 2, diff.CurrentSequence)
+			assert.Equal(t, orEmpty(tt.wantFields), diff.Fields)
+			assert.Equal(t, orEmpty(tt.wantAdded), diff.AddedAttachments)
+			assert.Equal(t, orEmpty(tt.wantRemoved), diff.RemovedAttachments)
+			assert.Equal(t, orEmpty(tt.wantChanged), diff.ChangedAttachments)
+			wantChanges := len(tt.wantFields) > 0 || len(tt.wantAdded) > 0 || len(tt.wantRemoved) > 0 || len(tt.wantChanged) > 0
+			assert.Equal(t, wantChanges, diff.HasChanges)
+		})
+	}
+}
+
+func attachmentsJSON(attachments ...models.SubmittedAttachment) string {
+	encoded, _ := json.Marshal(attachments)
+	return string(encoded)
+}
+
+// rawJSON returns the JSON of a snapshot column, or its default when the test leaves it out
+func rawJSON(value, empty string) json.RawMessage {
+	if value == "" {
+		value = empty
+	}
+	return json.RawMessage(value)
+}
+
+// orEmpty turns a left-out expectation into the empty list diffSubmissions returns
+func orEmpty[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
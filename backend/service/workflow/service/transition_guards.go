@@ -0,0 +1,517 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Built-in guard names that workflow definitions can list on a transition
+const (
+	GuardRequiredAttachments  = "required_attachments"
+	GuardAppointmentDate      = "appointment_date"
+	GuardSubmittedAuditReport = "submitted_audit_report"
+	GuardApprovedAuditReport  = "approved_audit_report"
+	GuardReturnedFrom         = "returned_from"
+	GuardReasonRequired       = "reason_required"
+	GuardAppealWindow         = "appeal_window"
+	GuardAppealWindowClosed   = "appeal_window_closed"
+	GuardAppealFiled          = "appeal_filed"
+	GuardAppealReviewer       = "appeal_reviewer"
+	GuardRejectedFrom         = "rejected_from"
+	GuardLicenseReference     = "license_reference"
+	GuardFeePaid              = "fee_paid"
+	GuardDocumentChecklist    = "document_checklist"
+	GuardTechnicalData        = "technical_data"
+)
+
+// ErrGuardFailed is returned when a transition's guard conditions are not met
+var ErrGuardFailed = errors.New("transition guard failed")
+
+// GuardError lists every condition that blocked a transition, for the UI to show
+type GuardError struct {
+	Action          string               `json:"action"`
+	ToStatus        models.RequestStatus `json:"to_status"`
+	UnmetConditions []dto.UnmetCondition `json:"unmet_conditions"`
+}
+
+func (e *GuardError) Error() string {
+	guards := make([]string, 0, len(e.UnmetConditions))
+	for _, condition := range e.UnmetConditions {
+		guards = append(guards, condition.Guard)
+	}
+	return fmt.Sprintf("transition %s is blocked by unmet conditions: %s", e.Action, strings.Join(guards, ", "))
+}
+
+func (e *GuardError) Unwrap() error {
+	return ErrGuardFailed
+}
+
+// GuardContext is passed to transition guards. Guards run inside the transition transaction
+// after the update and hooks, so they see the state the transition would commit.
+type GuardContext struct {
+	Tx           *gorm.DB
+	Request      dto.WorkflowTransitionRequest
+	Record       *WorkflowRequestRecord
+	Transition   models.WorkflowTransition
+	StateMachine *models.WorkflowStateMachine
+}
+
+// TransitionGuard checks a condition and returns the parts of it that are not met
+type TransitionGuard func(gc *GuardContext) ([]dto.UnmetCondition, error)
+
+var (
+	transitionGuardsMu sync.RWMutex
+	transitionGuards   = map[string]TransitionGuard{
+		GuardRequiredAttachments:  requiredAttachmentsGuard,
+		GuardAppointmentDate:      appointmentDateGuard,
+		GuardSubmittedAuditReport: submittedAuditReportGuard,
+		GuardApprovedAuditReport:  approvedAuditReportGuard,
+		GuardReturnedFrom:         returnedFromGuard,
+		GuardReasonRequired:       reasonRequiredGuard,
+		GuardAppealWindow:         appealWindowGuard,
+		GuardAppealWindowClosed:   appealWindowClosedGuard,
+		GuardAppealFiled:          appealFiledGuard,
+		GuardAppealReviewer:       appealReviewerGuard,
+		GuardRejectedFrom:         rejectedFromGuard,
+		GuardLicenseReference:     licenseReferenceGuard,
+		GuardFeePaid:              feePaidGuard,
+		GuardDocumentChecklist:    documentChecklistGuard,
+		GuardTechnicalData:        technicalDataGuard,
+	}
+)
+
+// RegisterTransitionGuard makes a guard available to workflow definitions under a name,
+// replacing any guard already registered with that name
+func RegisterTransitionGuard(name string, guard TransitionGuard) {
+	transitionGuardsMu.Lock()
+	defer transitionGuardsMu.Unlock()
+	transitionGuards[name] = guard
+}
+
+// ValidateTransitionGuards checks that every guard a definition uses is registered
+func ValidateTransitionGuards(spec *models.WorkflowDefinitionSpec) error {
+	transitionGuardsMu.RLock()
+	defer transitionGuardsMu.RUnlock()
+
+	for _, transition := range spec.Transitions {
+		for _, name := range transition.Guards {
+			if _, ok := transitionGuards[name]; !ok {
+				return fmt.Errorf("transition %s from %s uses unknown guard %s", transition.Action, transition.FromStatus, name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkGuards runs all guards of the transition and returns a GuardError listing every unmet condition
+func checkGuards(gc *GuardContext) error {
+	unmet := make([]dto.UnmetCondition, 0)
+
+	for _, name := range gc.Transition.Guards {
+		transitionGuardsMu.RLock()
+		guard, ok := transitionGuards[name]
+		transitionGuardsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("unknown transition guard %s", name)
+		}
+
+		conditions, err := guard(gc)
+		if err != nil {
+			return fmt.Errorf("failed to check guard %s: %w", name, err)
+		}
+		unmet = append(unmet, conditions...)
+	}
+
+	if len(unmet) == 0 {
+		return nil
+	}
+	return &GuardError{
+		Action:          gc.Transition.Action,
+		ToStatus:        gc.Transition.ToStatus,
+		UnmetConditions: unmet,
+	}
+}
+
+// requiredAttachmentsGuard requires a file for every attachment marked as required
+func requiredAttachmentsGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	var attachments []models.Attachment
+	err := gc.Tx.Select("id", "original_name", "description").
+		Where("entity_type = ? AND entity_id = ? AND is_required = ?", "license_request", gc.Record.ID, true).
+		Where("file_path = '' OR file_size <= 0").
+		Order("id").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+
+	missing := make([]map[string]interface{}, 0, len(attachments))
+	names := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		name := attachment.OriginalName
+		if name == "" {
+			name = attachment.Description
+		}
+		names = append(names, name)
+		missing = append(missing, map[string]interface{}{
+			"attachment_id": attachment.ID,
+			"name":          name,
+		})
+	}
+
+	return []dto.UnmetCondition{{
+		Guard:   GuardRequiredAttachments,
+		Message: "ยังไม่ได้อัปโหลดเอกสารที่จำเป็น: " + strings.Join(names, ", "),
+		Details: map[string]interface{}{"missing_attachments": missing},
+	}}, nil
+}
+
+// appointmentDateGuard requires the request to have an appointment date
+func appointmentDateGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	if gc.Record.AppointmentDate != nil {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardAppointmentDate,
+		Message: "กรุณาระบุวันนัดหมายตรวจสอบ",
+	}}, nil
+}
+
+// submittedAuditReportGuard requires an audit report, or a report version, that has been submitted for review
+func submittedAuditReportGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	submitted := []models.ReportStatus{models.ReportStatusSubmitted, models.ReportStatusUnderReview}
+
+	var reports int64
+	err := gc.Tx.Model(&models.AuditReport{}).
+		Where("request_id = ? AND status IN ?", gc.Record.ID, submitted).
+		Count(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var versions int64
+	err = gc.Tx.Model(&models.AuditReportVersion{}).
+		Where("license_request_id = ? AND status IN ?", gc.Record.ID, submitted).
+		Count(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if reports+versions > 0 {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardSubmittedAuditReport,
+		Message: "ยังไม่มีรายงานการตรวจสอบที่ส่งเพื่อพิจารณา",
+	}}, nil
+}
+
+// approvedAuditReportGuard requires the latest audit report version of the request to be approved
+func approvedAuditReportGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	var latest models.AuditReportVersion
+	err := gc.Tx.Select("id", "status").
+		Where("license_request_id = ?", gc.Record.ID).
+		Order("created_at DESC, id DESC").
+		Take(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err == nil && latest.Status == models.ReportStatusApproved {
+		return nil, nil
+	}
+
+	condition := dto.UnmetCondition{
+		Guard:   GuardApprovedAuditReport,
+		Message: "รายงานการตรวจสอบยังไม่ได้รับการอนุมัติ",
+	}
+	if err == nil {
+		condition.Details = map[string]interface{}{
+			"report_version_id": latest.ID,
+			"report_status":     latest.Status,
+		}
+	}
+	return []dto.UnmetCondition{condition}, nil
+}
+
+// returnedFromGuard only lets a returned request skip ahead to the status it was returned from
+func returnedFromGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	from, err := returnedFrom(gc.Tx, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if from == gc.Request.ToStatus {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardReturnedFrom,
+		Message: "คำขอนี้ไม่ได้ถูกตีกลับจากขั้นตอนนี้ กรุณาส่งเป็นคำขอใหม่",
+		Details: map[string]interface{}{"returned_from": from},
+	}}, nil
+}
+
+// reasonRequiredGuard requires the caller to give a reason, e.g. when withdrawing a request
+func reasonRequiredGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	if strings.TrimSpace(gc.Request.Comments) != "" || strings.TrimSpace(gc.Request.RejectionReason) != "" {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardReasonRequired,
+		Message: "กรุณาระบุเหตุผล",
+	}}, nil
+}
+
+// appealWindowGuard only lets the applicant appeal until the appeal window after the rejection has passed
+func appealWindowGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	closesAt, err := appealWindowEnd(gc.Tx, gc.StateMachine, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if closesAt == nil || time.Now().Before(*closesAt) {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardAppealWindow,
+		Message: "พ้นกำหนดระยะเวลายื่นอุทธรณ์แล้ว",
+		Details: map[string]interface{}{"appeal_window_closed_at": closesAt},
+	}}, nil
+}
+
+// appealWindowClosedGuard keeps a rejection open to appeal until its appeal window has passed
+func appealWindowClosedGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	closesAt, err := appealWindowEnd(gc.Tx, gc.StateMachine, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if closesAt != nil && !time.Now().Before(*closesAt) {
+		return nil, nil
+	}
+	condition := dto.UnmetCondition{
+		Guard:   GuardAppealWindowClosed,
+		Message: "ยังอยู่ในระยะเวลายื่นอุทธรณ์",
+	}
+	if closesAt != nil {
+		condition.Details = map[string]interface{}{"appeal_window_closed_at": closesAt}
+	}
+	return []dto.UnmetCondition{condition}, nil
+}
+
+// appealFiledGuard requires a pending appeal with grounds, so a request only enters appeal through an appeal
+func appealFiledGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	appeal, err := latestAppeal(gc.Tx, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if appeal != nil && appeal.IsPending() && strings.TrimSpace(appeal.Grounds) != "" {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardAppealFiled,
+		Message: "กรุณาระบุเหตุผลในการอุทธรณ์",
+	}}, nil
+}
+
+// appealReviewerGuard keeps whoever rejected the request from deciding its appeal
+func appealReviewerGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	appeal, err := latestAppeal(gc.Tx, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if appeal == nil {
+		return []dto.UnmetCondition{{
+			Guard:   GuardAppealReviewer,
+			Message: "ไม่พบอุทธรณ์ของคำขอนี้",
+		}}, nil
+	}
+	if !appeal.WasRejectedBy(gc.Request.UserID, gc.Request.OnBehalfOfID) {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardAppealReviewer,
+		Message: "ผู้ที่ปฏิเสธคำขอไม่สามารถพิจารณาอุทธรณ์ของคำขอนั้นได้",
+		Details: map[string]interface{}{"appeal_id": appeal.ID},
+	}}, nil
+}
+
+// rejectedFromGuard only lets a granted appeal skip ahead to the status the request was rejected from
+func rejectedFromGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	appeal, err := latestAppeal(gc.Tx, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if appeal != nil && appeal.RejectedFrom == gc.Request.ToStatus {
+		return nil, nil
+	}
+	condition := dto.UnmetCondition{
+		Guard:   GuardRejectedFrom,
+		Message: "คำขอนี้ไม่ได้ถูกปฏิเสธจากขั้นตอนนี้ กรุณาส่งกลับไปพิจารณาเป็นคำขอใหม่",
+	}
+	if appeal != nil {
+		condition.Details = map[string]interface{}{"rejected_from": appeal.RejectedFrom}
+	}
+	return []dto.UnmetCondition{condition}, nil
+}
+
+// licenseReferenceGuard requires renewal, extension and reduction requests to refer to a license the
+// applicant holds, with no other request for it in progress, and to be applicable to that license
+func licenseReferenceGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	var request models.LicenseRequest
+	if err := gc.Tx.First(&request, gc.Record.ID).Error; err != nil {
+		return nil, err
+	}
+	if !request.LicenseType.RefersToLicense() {
+		return nil, nil
+	}
+
+	_, err := CheckRequestLicense(repository.NewLicenseRepository(gc.Tx), &request)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, ErrLicenseReference) {
+		return nil, err
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardLicenseReference,
+		Message: "คำขอไม่สอดคล้องกับใบอนุญาตที่อ้างถึง",
+		Details: map[string]interface{}{
+			"license_number": request.LicenseNumber,
+			"reason":         strings.TrimPrefix(err.Error(), ErrLicenseReference.Error()+": "),
+		},
+	}}, nil
+}
+
+// feePaidGuard requires the fee of the request to be paid. Requests no fee schedule covers owe
+// nothing and pass.
+func feePaidGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	invoice, err := currentInvoice(gc.Tx, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice != nil {
+		if invoice.IsPaid() {
+			return nil, nil
+		}
+		return []dto.UnmetCondition{{
+			Guard:   GuardFeePaid,
+			Message: "ยังไม่ได้ชำระค่าธรรมเนียม",
+			Details: map[string]interface{}{
+				"invoice_id":     invoice.ID,
+				"invoice_number": invoice.InvoiceNumber,
+				"amount":         invoice.Amount,
+				"due_date":       invoice.DueDate,
+			},
+		}}, nil
+	}
+
+	schedule, capacity, err := requestFee(gc.Tx, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, nil
+	}
+	return []dto.UnmetCondition{{
+		Guard:   GuardFeePaid,
+		Message: "ยังไม่ได้ออกใบแจ้งชำระค่าธรรมเนียม",
+		Details: map[string]interface{}{
+			"fee_schedule_id": schedule.ID,
+			"capacity":        capacity,
+			"amount":          schedule.Amount,
+		},
+	}}, nil
+}
+
+// documentChecklistGuard requires every document the checklist of the request calls for to be
+// uploaded, and none of them to stand rejected
+func documentChecklistGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	checklist, err := documentChecklist(gc.Tx, gc.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if checklist.Complete {
+		return nil, nil
+	}
+
+	missing := make([]map[string]interface{}, 0, checklist.Missing)
+	rejected := make([]map[string]interface{}, 0, checklist.Rejected)
+	names := make([]string, 0, checklist.Missing+checklist.Rejected)
+	for _, item := range checklist.Items {
+		entry := map[string]interface{}{
+			"requirement_id": item.RequirementID,
+			"code":           item.Code,
+			"name":           item.Name,
+		}
+		switch item.Status {
+		case dto.ChecklistItemMissing:
+			missing = append(missing, entry)
+		case dto.ChecklistItemRejected:
+			entry["reason"] = item.Document.ReviewNotes
+			rejected = append(rejected, entry)
+		default:
+			continue
+		}
+		names = append(names, item.Name)
+	}
+
+	return []dto.UnmetCondition{{
+		Guard:   GuardDocumentChecklist,
+		Message: "เอกสารประกอบคำขอยังไม่ครบถ้วน: " + strings.Join(names, ", "),
+		Details: map[string]interface{}{
+			"missing_documents":  missing,
+			"rejected_documents": rejected,
+		},
+	}}, nil
+}
+
+// technicalDataGuard requires the technical data of the request to match the active payload schema
+// for its license type and energy type. Requests no schema applies to pass.
+func technicalDataGuard(gc *GuardContext) ([]dto.UnmetCondition, error) {
+	var request models.LicenseRequest
+	err := gc.Tx.Select("id", "request_number", "license_type", "license_id", "payload", "technical_data").
+		Take(&request, gc.Record.ID).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+	energyType, err := requestEnergyType(gc.Tx, &request)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := activePayloadSchema(gc.Tx, request.LicenseType, energyType)
+	if err != nil || schema == nil {
+		return nil, err
+	}
+
+	data, err := request.TechnicalFields()
+	if err != nil {
+		return nil, err
+	}
+
+	var invalid *PayloadValidationError
+	err = validateTechnicalData(schema, data)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.As(err, &invalid) {
+		return nil, err
+	}
+
+	return []dto.UnmetCondition{{
+		Guard:   GuardTechnicalData,
+		Message: "ข้อมูลทางเทคนิคของคำขอไม่ครบถ้วนหรือไม่ถูกต้อง",
+		Details: map[string]interface{}{
+			"schema_id":      invalid.SchemaID,
+			"schema_version": invalid.SchemaVersion,
+			"errors":         invalid.Errors,
+		},
+	}}, nil
+}
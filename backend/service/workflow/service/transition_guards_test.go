@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	RegisterTransitionGuard("test_met", func(gc *GuardContext) ([]dto.UnmetCondition, error) {
+		return nil, nil
+	})
+	RegisterTransitionGuard("test_unmet", func(gc *GuardContext) ([]dto.UnmetCondition, error) {
+		return []dto.UnmetCondition{{Guard: "test_unmet", Message: "not met"}}, nil
+	})
+	RegisterTransitionGuard("test_failing", func(gc *GuardContext) ([]dto.UnmetCondition, error) {
+		return nil, errors.New("lookup failed")
+	})
+}
+
+func TestValidateTransitionGuards(t *testing.T) {
+	defaultSpec, err := models.DefaultWorkflowDefinitionSpec()
+	require.NoError(t, err)
+	standardSpec, err := models.ParseWorkflowDefinitionSpec(models.StandardWorkflowDefinitionJSON())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		spec    *models.WorkflowDefinitionSpec
+		wantErr string
+	}{
+		{name: "default definition", spec: defaultSpec},
+		{name: "standard definition", spec: standardSpec},
+		{name: "registered guard", spec: guardedSpec("test_met")},
+		{name: "unknown guard", spec: guardedSpec(GuardReasonRequired, "no_such_guard"), wantErr: "uses unknown guard no_such_guard"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTransitionGuards(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckGuards(t *testing.T) {
+	appointment := time.Now()
+
+	tests := []struct {
+		name      string
+		guards    []string
+		request   dto.WorkflowTransitionRequest
+		record    WorkflowRequestRecord
+		wantUnmet []string // Guards listed in the GuardError, in order
+		wantErr   string   // Error other than a GuardError
+	}{
+		{name: "no guards"},
+		{name: "met guard", guards: []string{"test_met"}},
+		{name: "unmet guard", guards: []string{"test_met", "test_unmet"}, wantUnmet: []string{"test_unmet"}},
+		{
+			name:      "every unmet condition is listed",
+			guards:    []string{"test_unmet", GuardReasonRequired, GuardAppointmentDate},
+			wantUnmet: []string{"test_unmet", GuardReasonRequired, GuardAppointmentDate},
+		},
+		{
+			name:    "reason and appointment given",
+			guards:  []string{GuardReasonRequired, GuardAppointmentDate},
+			request: dto.WorkflowTransitionRequest{Comments: "no longer needed"},
+			record:  WorkflowRequestRecord{AppointmentDate: &appointment},
+		},
+		{name: "rejection reason counts as a reason", guards: []string{GuardReasonRequired}, request: dto.WorkflowTransitionRequest{RejectionReason: "incomplete"}},
+		{name: "blank reason", guards: []string{GuardReasonRequired}, request: dto.WorkflowTransitionRequest{Comments: "  "}, wantUnmet: []string{GuardReasonRequired}},
+		{name: "unknown guard", guards: []string{"no_such_guard"}, wantErr: "unknown transition guard no_such_guard"},
+		{name: "failing guard", guards: []string{"test_unmet", "test_failing"}, wantErr: "failed to check guard test_failing: lookup failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := tt.record
+			err := checkGuards(&GuardContext{
+				Request: tt.request,
+				Record:  &record,
+				Transition: models.WorkflowTransition{
+					FromStatus: models.StatusNewRequest,
+					ToStatus:   models.StatusAccepted,
+					Action:     "accept",
+					Guards:     tt.guards,
+				},
+			})
+
+			switch {
+			case tt.wantErr != "":
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				assert.False(t, errors.Is(err, ErrGuardFailed))
+			case len(tt.wantUnmet) > 0:
+				var guardErr *GuardError
+				require.ErrorAs(t, err, &guardErr)
+				assert.ErrorIs(t, err, ErrGuardFailed)
+				assert.Equal(t, "accept", guardErr.Action)
+				assert.Equal(t, models.StatusAccepted, guardErr.ToStatus)
+				unmet := make([]string, 0, len(guardErr.UnmetConditions))
+				for _, condition := range guardErr.UnmetConditions {
+					unmet = append(unmet, condition.Guard)
+				}
+				assert.Equal(t, tt.wantUnmet, unmet)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// guardedSpec returns a one-transition definition whose transition lists the guards
+func guardedSpec(guards ...string) *models.WorkflowDefinitionSpec {
+	return &models.WorkflowDefinitionSpec{
+		States: []models.WorkflowStateDefinition{{Status: models.StatusDraft}, {Status: models.StatusNewRequest}},
+		Transitions: []models.WorkflowTransitionDefinition{{
+			FromStatus: models.StatusDraft,
+			ToStatus:   models.StatusNewRequest,
+			Roles:      []models.UserRole{models.RoleUser},
+			Action:     "submit",
+			Guards:     guards,
+		}},
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// workflowLicenseTypes lists the license types that can carry their own workflow definition
+var workflowLicenseTypes = []string{"new", "renewal", "extension", "reduction", "modify", "cancel"}
+
+// stateMachineCache holds parsed definitions. Definitions are immutable once
+// published, so entries never need to be invalidated.
+var stateMachineCache = struct {
+	sync.RWMutex
+	machines map[string]*models.WorkflowStateMachine
+}{machines: make(map[string]*models.WorkflowStateMachine)}
+
+type WorkflowDefinitionService interface {
+	GetStateMachine(licenseType string, version int) (*models.WorkflowStateMachine, error)
+	GetActiveStateMachine(licenseType string) (*models.WorkflowStateMachine, error)
+	GetActiveVersion(licenseType string) (int, error)
+	ListDefinitions(licenseType string) ([]dto.WorkflowDefinitionResponse, error)
+	GetDefinition(id uint) (*dto.WorkflowDefinitionResponse, error)
+	GetActiveDefinition(licenseType string) (*dto.WorkflowDefinitionResponse, error)
+	CreateDefinition(req dto.CreateWorkflowDefinitionRequest, createdByID uint) (*dto.WorkflowDefinitionResponse, error)
+	ActivateDefinition(id uint) (*dto.WorkflowDefinitionResponse, error)
+}
+
+type workflowDefinitionService struct {
+	definitionRepo repository.WorkflowDefinitionRepository
+}
+
+func NewWorkflowDefinitionService(db *gorm.DB) WorkflowDefinitionService {
+	return &workflowDefinitionService{
+		definitionRepo: repository.NewWorkflowDefinitionRepository(db),
+	}
+}
+
+// IsWorkflowLicenseType checks if the license type supports workflow definitions
+func IsWorkflowLicenseType(licenseType string) bool {
+	for _, t := range workflowLicenseTypes {
+		if t == licenseType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetStateMachine returns the state machine for the definition version a request is pinned to
+func (s *workflowDefinitionService) GetStateMachine(licenseType string, version int) (*models.WorkflowStateMachine, error) {
+	if version == models.DefaultWorkflowVersion {
+		return models.NewWorkflowStateMachine(), nil
+	}
+
+	key := fmt.Sprintf("%s:%d", licenseType, version)
+
+	stateMachineCache.RLock()
+	machine, exists := stateMachineCache.machines[key]
+	stateMachineCache.RUnlock()
+	if exists {
+		return machine, nil
+	}
+
+	definition, err := s.definitionRepo.GetByLicenseTypeAndVersion(licenseType, version)
+	if err != nil {
+		return nil, fmt.Errorf("workflow definition %s v%d not found: %w", licenseType, version, err)
+	}
+
+	spec, err := definition.GetSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	machine = models.NewWorkflowStateMachineFromSpec(definition.LicenseType, definition.Version, spec)
+
+	stateMachineCache.Lock()
+	stateMachineCache.machines[key] = machine
+	stateMachineCache.Unlock()
+
+	return machine, nil
+}
+
+// GetActiveStateMachine returns the state machine new requests of the license type start on
+func (s *workflowDefinitionService) GetActiveStateMachine(licenseType string) (*models.WorkflowStateMachine, error) {
+	version, err := s.GetActiveVersion(licenseType)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetStateMachine(licenseType, version)
+}
+
+// GetActiveVersion returns the active definition version, falling back to the built-in definition
+func (s *workflowDefinitionService) GetActiveVersion(licenseType string) (int, error) {
+	definition, err := s.definitionRepo.GetActive(licenseType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.DefaultWorkflowVersion, nil
+		}
+		return 0, err
+	}
+	return definition.Version, nil
+}
+
+// ListDefinitions lists published definitions, optionally filtered by license type
+func (s *workflowDefinitionService) ListDefinitions(licenseType string) ([]dto.WorkflowDefinitionResponse, error) {
+	definitions, err := s.definitionRepo.GetAll(licenseType)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.WorkflowDefinitionResponse, 0, len(definitions))
+	for i := range definitions {
+		response, err := s.toResponse(&definitions[i])
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, *response)
+	}
+
+	return responses, nil
+}
+
+// GetDefinition returns a published definition by ID
+func (s *workflowDefinitionService) GetDefinition(id uint) (*dto.WorkflowDefinitionResponse, error) {
+	definition, err := s.definitionRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(definition)
+}
+
+// GetActiveDefinition returns the active definition for a license type, or the built-in one
+func (s *workflowDefinitionService) GetActiveDefinition(licenseType string) (*dto.WorkflowDefinitionResponse, error) {
+	if !IsWorkflowLicenseType(licenseType) {
+		return nil, fmt.Errorf("unsupported license type: %s", licenseType)
+	}
+
+	definition, err := s.definitionRepo.GetActive(licenseType)
+	if err == nil {
+		return s.toResponse(definition)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	spec, err := models.DefaultWorkflowDefinitionSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.WorkflowDefinitionResponse{
+		LicenseType: licenseType,
+		Version:     models.DefaultWorkflowVersion,
+		Name:        spec.Name,
+		Description: spec.Description,
+		IsActive:    true,
+		IsBuiltIn:   true,
+		Definition:  spec,
+		Summary:     models.NewWorkflowStateMachine().GetWorkflowSummary(),
+	}, nil
+}
+
+// CreateDefinition validates and publishes the next definition version for a license type
+func (s *workflowDefinitionService) CreateDefinition(req dto.CreateWorkflowDefinitionRequest, createdByID uint) (*dto.WorkflowDefinitionResponse, error) {
+	req.LicenseType = string(models.ParseLicenseType(req.LicenseType))
+	if !IsWorkflowLicenseType(req.LicenseType) {
+		return nil, fmt.Errorf("unsupported license type: %s", req.LicenseType)
+	}
+
+	spec, err := models.ParseWorkflowDefinitionSpec(req.Definition)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateTransitionGuards(spec); err != nil {
+		return nil, err
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, req.Definition); err != nil {
+		return nil, fmt.Errorf("invalid workflow definition: %w", err)
+	}
+
+	latestVersion, err := s.definitionRepo.GetLatestVersion(req.LicenseType)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = spec.Name
+	}
+	description := req.Description
+	if description == "" {
+		description = spec.Description
+	}
+
+	definition := &models.WorkflowDefinition{
+		LicenseType: req.LicenseType,
+		Version:     latestVersion + 1,
+		Name:        name,
+		Description: description,
+		Definition:  compacted.String(),
+		CreatedByID: &createdByID,
+	}
+
+	if err := s.definitionRepo.Create(definition); err != nil {
+		return nil, fmt.Errorf("failed to create workflow definition: %w", err)
+	}
+
+	if req.Activate {
+		return s.ActivateDefinition(definition.ID)
+	}
+
+	return s.toResponse(definition)
+}
+
+// ActivateDefinition makes a definition the one new requests are pinned to
+func (s *workflowDefinitionService) ActivateDefinition(id uint) (*dto.WorkflowDefinitionResponse, error) {
+	if err := s.definitionRepo.Activate(id); err != nil {
+		return nil, err
+	}
+	return s.GetDefinition(id)
+}
+
+func (s *workflowDefinitionService) toResponse(definition *models.WorkflowDefinition) (*dto.WorkflowDefinitionResponse, error) {
+	machine, err := s.GetStateMachine(definition.LicenseType, definition.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := definition.GetSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := definition.CreatedAt
+	return &dto.WorkflowDefinitionResponse{
+		ID:          definition.ID,
+		LicenseType: definition.LicenseType,
+		Version:     definition.Version,
+		Name:        definition.Name,
+		Description: definition.Description,
+		IsActive:    definition.IsActive,
+		Definition:  spec,
+		Summary:     machine.GetWorkflowSummary(),
+		CreatedByID: definition.CreatedByID,
+		ActivatedAt: definition.ActivatedAt,
+		CreatedAt:   &createdAt,
+	}, nil
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"eservice-backend/models"
+	"eservice-backend/repository"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Formats a workflow diagram can be exported in
+const (
+	WorkflowExportJSON    = "json"
+	WorkflowExportDOT     = "dot"
+	WorkflowExportMermaid = "mermaid"
+)
+
+// Colours used to highlight a request's path in rendered diagrams
+const (
+	highlightColor     = "#1f77b4"
+	currentStateColor  = "#cfe2ff"
+	terminalStateColor = "#eeeeee"
+)
+
+type WorkflowExportService interface {
+	GetDiagram(licenseType string, version *int) (*dto.WorkflowDiagramResponse, error)
+	GetRequestDiagram(requestID uint) (*dto.WorkflowDiagramResponse, error)
+}
+
+type workflowExportService struct {
+	db                 *gorm.DB
+	definitionService  WorkflowDefinitionService
+	serviceFlowLogRepo repository.ServiceFlowLogRepo
+}
+
+func NewWorkflowExportService(db *gorm.DB) WorkflowExportService {
+	return &workflowExportService{
+		db:                 db,
+		definitionService:  NewWorkflowDefinitionService(db),
+		serviceFlowLogRepo: repository.NewServiceFlowLogRepo(db),
+	}
+}
+
+// GetDiagram returns the diagram of a definition version. Without a version it uses the
+// active definition of the license type; without a license type it uses the built-in one.
+func (s *workflowExportService) GetDiagram(licenseType string, version *int) (*dto.WorkflowDiagramResponse, error) {
+	if licenseType == "" || licenseType == models.DefaultWorkflowLicenseType {
+		if version != nil && *version != models.DefaultWorkflowVersion {
+			return nil, fmt.Errorf("the built-in workflow only has version %d", models.DefaultWorkflowVersion)
+		}
+		return BuildWorkflowDiagram(models.NewWorkflowStateMachine()), nil
+	}
+
+	if !IsWorkflowLicenseType(licenseType) {
+		return nil, fmt.Errorf("unsupported license type: %s", licenseType)
+	}
+
+	var stateMachine *models.WorkflowStateMachine
+	var err error
+	if version == nil {
+		stateMachine, err = s.definitionService.GetActiveStateMachine(licenseType)
+	} else {
+		stateMachine, err = s.definitionService.GetStateMachine(licenseType, *version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildWorkflowDiagram(stateMachine), nil
+}
+
+// GetRequestDiagram returns the diagram of the definition a request is pinned to,
+// with the states and transitions from the request's flow log highlighted
+func (s *workflowExportService) GetRequestDiagram(requestID uint) (*dto.WorkflowDiagramResponse, error) {
+	var record WorkflowRequestRecord
+	err := s.db.Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", requestID).
+		Take(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get request: %w", err)
+	}
+
+	stateMachine, err := s.definitionService.GetStateMachine(record.LicenseType, record.WorkflowVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow definition: %w", err)
+	}
+
+	flowLogs, err := s.serviceFlowLogRepo.GetByLicenseRequestID(record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow history: %w", err)
+	}
+
+	diagram := BuildWorkflowDiagram(stateMachine)
+	highlightRequestPath(diagram, &record, flowLogs)
+	return diagram, nil
+}
+
+// BuildWorkflowDiagram turns a state machine into a diagram with one edge per transition
+// and the roles allowed to take it
+func BuildWorkflowDiagram(stateMachine *models.WorkflowStateMachine) *dto.WorkflowDiagramResponse {
+	diagram := &dto.WorkflowDiagramResponse{
+		LicenseType: stateMachine.LicenseType(),
+		Version:     stateMachine.Version(),
+		Name:        stateMachine.Name(),
+		Path:        stateMachine.GetWorkflowPath(),
+		Nodes:       make([]dto.WorkflowNode, 0),
+		Edges:       make([]dto.WorkflowEdge, 0),
+		Summary:     stateMachine.GetWorkflowSummary(),
+	}
+
+	for _, state := range stateMachine.GetStates() {
+		diagram.Nodes = append(diagram.Nodes, dto.WorkflowNode{
+			ID:           string(state.Status),
+			Label:        string(state.Status),
+			Description:  state.Description,
+			Progress:     state.Progress,
+			Terminal:     state.Terminal,
+			DeadlineDays: state.DeadlineDays,
+		})
+	}
+
+	// The state machine keeps one transition per role; merge them back into one edge per action
+	edgeIndex := make(map[string]int)
+	for _, transition := range stateMachine.GetTransitions() {
+		key := fmt.Sprintf("%s|%s|%s", transition.FromStatus, transition.ToStatus, transition.Action)
+		if i, exists := edgeIndex[key]; exists {
+			if transition.RequiredRole != "" {
+				diagram.Edges[i].Roles = append(diagram.Edges[i].Roles, transition.RequiredRole)
+			}
+			continue
+		}
+
+		edge := dto.WorkflowEdge{
+			From:        string(transition.FromStatus),
+			To:          string(transition.ToStatus),
+			Type:        "manual",
+			Action:      transition.Action,
+			Description: transition.Description,
+			Roles:       make([]models.UserRole, 0),
+			Guards:      transition.Guards,
+			Approval:    transition.Approval,
+		}
+		if transition.AutoAllowed {
+			edge.Type = "auto"
+		}
+		if transition.RequiredRole != "" {
+			edge.Roles = append(edge.Roles, transition.RequiredRole)
+		}
+
+		edgeIndex[key] = len(diagram.Edges)
+		diagram.Edges = append(diagram.Edges, edge)
+	}
+
+	return diagram
+}
+
+// highlightRequestPath marks the states and transitions a request went through
+func highlightRequestPath(diagram *dto.WorkflowDiagramResponse, record *WorkflowRequestRecord, flowLogs []models.ServiceFlowLog) {
+	path := &dto.WorkflowDiagramPath{
+		RequestID:     record.ID,
+		RequestNumber: record.RequestNumber,
+		CurrentStatus: record.Status,
+		Statuses:      make([]models.RequestStatus, 0, len(flowLogs)+1),
+	}
+
+	visited := make(map[string]bool)
+	taken := make(map[string]bool)
+
+	// Flow logs come newest first
+	for i := len(flowLogs) - 1; i >= 0; i-- {
+		flowLog := flowLogs[i]
+		if flowLog.PreviousStatus != nil {
+			if len(path.Statuses) == 0 {
+				path.Statuses = append(path.Statuses, *flowLog.PreviousStatus)
+			}
+			visited[string(*flowLog.PreviousStatus)] = true
+			taken[string(*flowLog.PreviousStatus)+"|"+string(flowLog.NewStatus)] = true
+		}
+		path.Statuses = append(path.Statuses, flowLog.NewStatus)
+		visited[string(flowLog.NewStatus)] = true
+	}
+	visited[string(record.Status)] = true
+
+	for i := range diagram.Nodes {
+		diagram.Nodes[i].Visited = visited[diagram.Nodes[i].ID]
+		diagram.Nodes[i].Current = diagram.Nodes[i].ID == string(record.Status)
+	}
+	for i := range diagram.Edges {
+		diagram.Edges[i].Taken = taken[diagram.Edges[i].From+"|"+diagram.Edges[i].To]
+	}
+
+	diagram.Request = path
+}
+
+// RenderWorkflowDiagram renders a diagram as JSON, Graphviz DOT or a Mermaid flowchart
+func RenderWorkflowDiagram(diagram *dto.WorkflowDiagramResponse, format string) (string, error) {
+	switch format {
+	case WorkflowExportJSON:
+		data, err := json.MarshalIndent(diagram, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode workflow diagram: %w", err)
+		}
+		return string(data) + "\n", nil
+	case WorkflowExportDOT:
+		return renderWorkflowDOT(diagram), nil
+	case WorkflowExportMermaid:
+		return renderWorkflowMermaid(diagram), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func renderWorkflowDOT(diagram *dto.WorkflowDiagramResponse) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(diagramTitle(diagram)))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n\n")
+
+	for _, node := range diagram.Nodes {
+		attrs := []string{"label=" + dotQuote(strings.Join(nodeLines(node), "\n"))}
+		styles := []string{"rounded"}
+		switch {
+		case node.Current:
+			styles = append(styles, "filled")
+			attrs = append(attrs, "fillcolor="+dotQuote(currentStateColor))
+		case node.Terminal:
+			styles = append(styles, "filled")
+			attrs = append(attrs, "fillcolor="+dotQuote(terminalStateColor))
+		}
+		if node.Terminal {
+			attrs = append(attrs, "peripheries=2")
+		}
+		if node.Visited {
+			attrs = append(attrs, "color="+dotQuote(highlightColor), "penwidth=2")
+		}
+		attrs = append(attrs, "style="+dotQuote(strings.Join(styles, ",")))
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.ID), strings.Join(attrs, ", "))
+	}
+	b.WriteString("\n")
+
+	for _, edge := range diagram.Edges {
+		attrs := []string{"label=" + dotQuote(edgeLabel(edge, "\n"))}
+		if edge.Type == "auto" {
+			attrs = append(attrs, "style=dashed")
+		}
+		if edge.Taken {
+			attrs = append(attrs, "color="+dotQuote(highlightColor), "fontcolor="+dotQuote(highlightColor), "penwidth=2")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To), strings.Join(attrs, ", "))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderWorkflowMermaid(diagram *dto.WorkflowDiagramResponse) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\n", diagramTitle(diagram))
+	b.WriteString("flowchart LR\n")
+
+	terminal := make([]string, 0)
+	visited := make([]string, 0)
+	current := make([]string, 0)
+	for _, node := range diagram.Nodes {
+		fmt.Fprintf(&b, "    %s[%s]\n", node.ID, mermaidQuote(strings.Join(nodeLines(node), "<br/>")))
+		if node.Terminal {
+			terminal = append(terminal, node.ID)
+		}
+		if node.Visited {
+			visited = append(visited, node.ID)
+		}
+		if node.Current {
+			current = append(current, node.ID)
+		}
+	}
+
+	taken := make([]string, 0)
+	for i, edge := range diagram.Edges {
+		arrow := "-->"
+		if edge.Type == "auto" {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "    %s %s|%s| %s\n", edge.From, arrow, mermaidQuote(edgeLabel(edge, "<br/>")), edge.To)
+		if edge.Taken {
+			taken = append(taken, fmt.Sprint(i))
+		}
+	}
+
+	fmt.Fprintf(&b, "    classDef terminal fill:%s,stroke-width:3px\n", terminalStateColor)
+	fmt.Fprintf(&b, "    classDef visited stroke:%s,stroke-width:2px\n", highlightColor)
+	fmt.Fprintf(&b, "    classDef current fill:%s,stroke:%s,stroke-width:2px\n", currentStateColor, highlightColor)
+	if len(terminal) > 0 {
+		fmt.Fprintf(&b, "    class %s terminal\n", strings.Join(terminal, ","))
+	}
+	if len(visited) > 0 {
+		fmt.Fprintf(&b, "    class %s visited\n", strings.Join(visited, ","))
+	}
+	if len(current) > 0 {
+		fmt.Fprintf(&b, "    class %s current\n", strings.Join(current, ","))
+	}
+	if len(taken) > 0 {
+		fmt.Fprintf(&b, "    linkStyle %s stroke:%s,stroke-width:2px\n", strings.Join(taken, ","), highlightColor)
+	}
+
+	return b.String()
+}
+
+func diagramTitle(diagram *dto.WorkflowDiagramResponse) string {
+	title := fmt.Sprintf("%s (%s v%d)", diagram.Name, diagram.LicenseType, diagram.Version)
+	if diagram.Request != nil {
+		title += " - " + diagram.Request.RequestNumber
+	}
+	return title
+}
+
+// nodeLines returns the label lines of a state: status, description, progress and deadline
+func nodeLines(node dto.WorkflowNode) []string {
+	lines := []string{node.Label}
+	if node.Description != "" {
+		lines = append(lines, node.Description)
+	}
+	details := fmt.Sprintf("%d%%", node.Progress)
+	if node.DeadlineDays > 0 {
+		details += fmt.Sprintf(", deadline %d days", node.DeadlineDays)
+	}
+	return append(lines, details)
+}
+
+// edgeLabel returns the action of a transition with the roles that can take it
+func edgeLabel(edge dto.WorkflowEdge, separator string) string {
+	who := "auto"
+	if len(edge.Roles) > 0 {
+		roles := make([]string, 0, len(edge.Roles))
+		for _, role := range edge.Roles {
+			roles = append(roles, string(role))
+		}
+		who = strings.Join(roles, ", ")
+		if edge.Type == "auto" {
+			who += ", auto"
+		}
+	}
+	label := edge.Action + separator + "[" + who + "]"
+
+	if policy := edge.Approval; policy != nil {
+		quorum := fmt.Sprintf("quorum %d", policy.MinApprovals)
+		if len(policy.RequiredRoles) > 0 {
+			roles := make([]string, 0, len(policy.RequiredRoles))
+			for _, role := range policy.RequiredRoles {
+				roles = append(roles, string(role))
+			}
+			quorum += " incl. " + strings.Join(roles, " + ")
+		}
+		if policy.MinCapacity > 0 {
+			quorum += fmt.Sprintf(" if capacity >= %g", policy.MinCapacity)
+		}
+		label += separator + quorum
+	}
+	return label
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
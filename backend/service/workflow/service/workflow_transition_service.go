@@ -1,74 +1,426 @@
 package service
 
 import (
+	"errors"
+	"eservice-backend/config"
 	"eservice-backend/models"
 	"eservice-backend/repository"
 	"eservice-backend/service/workflow/dto"
 	"fmt"
+	"log"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+var (
+	ErrRequestNotFound   = errors.New("request not found")
+	ErrInvalidTransition = errors.New("invalid transition")
+	ErrVersionConflict   = repository.ErrVersionConflict
+)
+
+// VersionConflictError is returned when a request was changed by someone else after the
+// caller read it. Current holds the state the caller lost to.
+type VersionConflictError struct {
+	Current dto.WorkflowRequestState
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("request was modified concurrently: now %s at version %d", e.Current.Status, e.Current.Version)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// licenseRequestTable holds the requests of every license type
+const licenseRequestTable = "license_requests"
+
+// outboxDispatchBatchSize is the number of outbox messages delivered right after a transition commits
+const outboxDispatchBatchSize = 50
+
+// WorkflowRequestRecord holds the workflow columns of a license request
+type WorkflowRequestRecord struct {
+	ID                uint
+	UserID            uint
+	RequestNumber     string
+	LicenseType       string
+	Status            models.RequestStatus
+	WorkflowVersion   int
+	Version           int
+	InspectorID       *uint
+	RequestedCapacity float64
+	AppointmentDate   *time.Time
+	Deadline          *time.Time
+	UpdatedAt         time.Time
+}
+
+// TransitionContext is passed to transition hooks. All writes made through Tx
+// are committed or rolled back together with the status change.
+type TransitionContext struct {
+	Tx             *gorm.DB
+	Request        dto.WorkflowTransitionRequest
+	Record         *WorkflowRequestRecord
+	PreviousStatus models.RequestStatus
+	StateMachine   *models.WorkflowStateMachine
+	License        *models.License // License issued or changed by an approval, set before the default notifications
+
+	outboxService    OutboxService
+	notified         bool
+	openWorkOfficers []uint // Officers with open work on a request that reached a terminal status
+}
+
+// TransitionHook runs inside the transition transaction after the request has been updated
+type TransitionHook func(tc *TransitionContext) error
+
+// NotifyUser queues a notification for a user. Delivery happens after the transaction commits.
+func (tc *TransitionContext) NotifyUser(userID uint, title, message string, notifType models.NotificationType, priority models.NotificationPriority, actionURL string) error {
+	return tc.notify(models.Notification{
+		Title:       title,
+		Message:     message,
+		Type:        notifType,
+		Priority:    priority,
+		RecipientID: &userID,
+		ActionURL:   actionURL,
+	})
+}
+
+// NotifyRole queues a notification for all users with a role. Delivery happens after the transaction commits.
+func (tc *TransitionContext) NotifyRole(role models.UserRole, title, message string, notifType models.NotificationType, priority models.NotificationPriority, actionURL string) error {
+	return tc.notify(models.Notification{
+		Title:         title,
+		Message:       message,
+		Type:          notifType,
+		Priority:      priority,
+		RecipientRole: &role,
+		ActionURL:     actionURL,
+	})
+}
+
+func (tc *TransitionContext) notify(notification models.Notification) error {
+	notification.EntityType = "license_request"
+	notification.EntityID = &tc.Record.ID
+	tc.notified = true
+	return tc.outboxService.EnqueueNotification(tc.Tx, notification)
+}
+
+// RequireRequestOwner returns a transition hook that only lets the applicant who owns the request take the transition
+func RequireRequestOwner(userID uint) TransitionHook {
+	return func(tc *TransitionContext) error {
+		if tc.Record.UserID != userID {
+			return fmt.Errorf("%w: only the applicant who owns request %s can take this transition", ErrInvalidTransition, tc.Record.RequestNumber)
+		}
+		return nil
+	}
+}
+
 type WorkflowTransitionService interface {
-	ProcessTransition(req dto.WorkflowTransitionRequest) error
+	ProcessTransition(req dto.WorkflowTransitionRequest, hooks ...TransitionHook) (*dto.WorkflowTransitionResult, error)
+	ProcessTransitions(action string, reqs []dto.WorkflowTransitionRequest, mode dto.BulkMode, hooks ...TransitionHook) (*dto.BulkTransitionResult, error)
 	ValidateTransition(fromStatus, toStatus models.RequestStatus, userID uint, userRole models.UserRole) (bool, error)
 	GetValidTransitions(currentStatus models.RequestStatus, role models.UserRole) []models.WorkflowTransition
 	GetWorkflowHistory(requestID uint) ([]models.ServiceFlowLog, error)
+	GetRequestState(requestID uint) (*dto.WorkflowRequestState, error)
+	GetApprovalStatus(requestID uint) (*dto.ApprovalStatus, error)
+	CastApprovalVote(req dto.ApprovalVoteRequest) (*dto.WorkflowTransitionResult, error)
 }
 
 type workflowTransitionService struct {
-	db                   *gorm.DB
-	stateMachine         *models.WorkflowStateMachine
-	newLicenseRepo       repository.NewLicenseRepo
-	renewalLicenseRepo   repository.RenewalLicenseRepo
-	extensionLicenseRepo repository.ExtensionLicenseRepo
-	reductionLicenseRepo repository.ReductionLicenseRepo
-	notificationRepo     repository.NotificationRepository
-	serviceFlowLogRepo   repository.ServiceFlowLogRepo
+	db                 *gorm.DB
+	stateMachine       *models.WorkflowStateMachine
+	definitionService  WorkflowDefinitionService
+	outboxService      OutboxService
+	serviceFlowLogRepo repository.ServiceFlowLogRepo
+	paymentProvider    PaymentProvider
+	paymentProviderErr error // Why PAYMENT_PROVIDER could not be built, reported when an invoice is due
+	deferDispatch      bool  // Set while a bulk action runs its items inside one transaction
 }
 
-func NewWorkflowTransitionService(db *gorm.DB) WorkflowTransitionService {
+func NewWorkflowTransitionService(db *gorm.DB, cfg *config.Config) WorkflowTransitionService {
+	paymentProvider, paymentProviderErr := NewPaymentProvider(cfg)
 	return &workflowTransitionService{
-		db:                   db,
-		stateMachine:         models.NewWorkflowStateMachine(),
-		newLicenseRepo:       repository.NewNewLicenseRepo(db),
-		renewalLicenseRepo:   repository.NewRenewalLicenseRepo(db),
-		extensionLicenseRepo: repository.NewExtensionLicenseRepo(db),
-		reductionLicenseRepo: repository.NewReductionLicenseRepo(db),
-		notificationRepo:     repository.NewNotificationRepository(db),
-		serviceFlowLogRepo:   repository.NewServiceFlowLogRepo(db),
+		db:                 db,
+		stateMachine:       models.NewWorkflowStateMachine(),
+		definitionService:  NewWorkflowDefinitionService(db),
+		outboxService:      NewOutboxService(db, cfg),
+		serviceFlowLogRepo: repository.NewServiceFlowLogRepo(db),
+		paymentProvider:    paymentProvider,
+		paymentProviderErr: paymentProviderErr,
 	}
 }
 
-func (s *workflowTransitionService) ProcessTransition(req dto.WorkflowTransitionRequest) error {
-	// Validate transition
-	transitionReq := models.TransitionRequest{
-		FromStatus:   req.FromStatus,
-		ToStatus:     req.ToStatus,
-		UserID:       req.UserID,
-		UserRole:     req.UserRole,
-		Comments:     req.Comments,
-		AutoApproved: req.AutoApproved,
-	}
+// ProcessTransition applies a status change together with its flow log, task updates,
+// deadline reminders, hook writes and queued notifications in a single transaction.
+// It returns a GuardError when the transition's guard conditions are not met. At a step that
+// needs several approvals the call records a vote and returns a Pending result until the quorum is met.
+func (s *workflowTransitionService) ProcessTransition(req dto.WorkflowTransitionRequest, hooks ...TransitionHook) (*dto.WorkflowTransitionResult, error) {
+	var result *dto.WorkflowTransitionResult
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.getRecord(tx, req.RequestID)
+		if err != nil {
+			return err
+		}
 
-	if err := s.stateMachine.ValidateTransitionRequest(transitionReq); err != nil {
-		return fmt.Errorf("invalid transition: %w", err)
+		// Requests are keyed by ID alone; the license type always comes from the stored request
+		if req.LicenseType != "" && string(models.ParseLicenseType(req.LicenseType)) != record.LicenseType {
+			return ErrRequestNotFound
+		}
+		req.LicenseType = record.LicenseType
+
+		// Preconditions from the caller must match what is stored, otherwise they acted on a stale view
+		if (req.ExpectedVersion != nil && *req.ExpectedVersion != record.Version) ||
+			(req.FromStatus != "" && req.FromStatus != record.Status) {
+			return s.conflict(record)
+		}
+
+		// The current status always comes from the database, never from the caller
+		previousStatus := record.Status
+		req.FromStatus = previousStatus
+
+		// Validate against the workflow definition the request was created with, honouring the caller's delegations
+		stateMachine, err := s.pinnedStateMachine(tx, &req, record.WorkflowVersion)
+		if err != nil {
+			return err
+		}
+
+		// Steps that need several approvals record the caller's vote and only move on once the quorum is met or vetoed
+		approval, err := s.collectApproval(tx, req, record, stateMachine)
+		if err != nil {
+			return err
+		}
+		if approval != nil && !approval.QuorumMet && !approval.Vetoed {
+			tc := &TransitionContext{
+				Tx:             tx,
+				Request:        req,
+				Record:         record,
+				PreviousStatus: previousStatus,
+				StateMachine:   stateMachine,
+				outboxService:  s.outboxService,
+			}
+			if err := notifyPendingApprovers(tc, approval); err != nil {
+				return err
+			}
+
+			result = &dto.WorkflowTransitionResult{
+				RequestID:      record.ID,
+				LicenseType:    req.LicenseType,
+				RequestNumber:  record.RequestNumber,
+				PreviousStatus: previousStatus,
+				NewStatus:      previousStatus,
+				Version:        record.Version,
+				Deadline:       record.Deadline,
+				TransitionedAt: time.Now(),
+				OnBehalfOfID:   req.OnBehalfOfID,
+				Pending:        true,
+				Approval:       approval,
+			}
+			return nil
+		}
+
+		now := time.Now()
+		deadline := stateMachine.GetDefaultDeadline(req.ToStatus, now)
+
+		updates := map[string]interface{}{
+			"status":     req.ToStatus,
+			"notes":      req.Comments,
+			"deadline":   deadline,
+			"version":    record.Version + 1,
+			"updated_at": now,
+		}
+
+		// Handle specific transition logic
+		switch req.ToStatus {
+		case models.StatusAssigned:
+			if req.AssignedToID == 0 {
+				return fmt.Errorf("%w: assigned_to_id is required", ErrInvalidTransition)
+			}
+			updates["inspector_id"] = req.AssignedToID
+			updates["assigned_by_id"] = req.UserID
+			updates["assigned_at"] = now
+			record.InspectorID = &req.AssignedToID
+		case models.StatusAppointment:
+			if req.AppointmentDate != nil {
+				updates["appointment_date"] = req.AppointmentDate
+				record.AppointmentDate = req.AppointmentDate
+			}
+		case models.StatusInspecting:
+			updates["inspection_date"] = now
+		case models.StatusApproved:
+			updates["completion_date"] = now
+		case models.StatusRejected, models.StatusReturned:
+			if req.RejectionReason != "" {
+				updates["rejection_reason"] = req.RejectionReason
+			}
+		}
+
+		// Compare-and-swap on the version read above: only one of two concurrent transitions wins
+		update := tx.Table(licenseRequestTable).
+			Where("id = ? AND version = ? AND deleted_at IS NULL", record.ID, record.Version).
+			Updates(updates)
+		if update.Error != nil {
+			return fmt.Errorf("failed to update request: %w", update.Error)
+		}
+		if update.RowsAffected == 0 {
+			current, err := s.getRecord(tx, record.ID)
+			if err != nil {
+				return err
+			}
+			return s.conflict(current)
+		}
+		record.Status = req.ToStatus
+		record.Deadline = deadline
+		record.Version++
+		record.UpdatedAt = now
+
+		// Create service flow log; automatic transitions without a user are logged as system changes
+		changeReason := req.Comments
+		if changeReason == "" {
+			changeReason = req.RejectionReason
+		}
+		var changedBy *uint
+		if req.UserID != 0 {
+			changedBy = &req.UserID
+		}
+		flowLog := &models.ServiceFlowLog{
+			LicenseRequestID: record.ID,
+			PreviousStatus:   &previousStatus,
+			NewStatus:        req.ToStatus,
+			ChangedBy:        changedBy,
+			OnBehalfOf:       req.OnBehalfOfID,
+			ChangeReason:     changeReason,
+			LicenseType:      req.LicenseType,
+		}
+		if err := repository.NewServiceFlowLogRepo(tx).Create(flowLog); err != nil {
+			return fmt.Errorf("failed to create flow log: %w", err)
+		}
+
+		// Officers whose open work a terminal status cancels are collected before it is cancelled
+		var officers []uint
+		if stateMachine.IsTerminalState(req.ToStatus) {
+			if officers, err = openWorkOfficers(tx, record); err != nil {
+				return err
+			}
+			if err := cancelInspections(tx, record); err != nil {
+				return err
+			}
+			if err := cancelInvoices(tx, record, now); err != nil {
+				return err
+			}
+		}
+
+		// Leaving appeal settles the pending appeal, whether it was decided or withdrawn
+		if previousStatus == models.StatusAppealed {
+			if err := settleAppeal(tx, req, record, stateMachine, now); err != nil {
+				return err
+			}
+		}
+
+		if err := s.updateTasks(tx, req, record, stateMachine, now); err != nil {
+			return err
+		}
+
+		if err := s.updateDeadlineReminders(tx, req, record); err != nil {
+			return err
+		}
+
+		// The SLA clock pauses while the applicant owns the request
+		if err := advanceSLAClock(tx, record.ID, req.LicenseType, stateMachine.SLAClockState(req.ToStatus), record.InspectorID, now); err != nil {
+			return err
+		}
+
+		tc := &TransitionContext{
+			Tx:               tx,
+			Request:          req,
+			Record:           record,
+			PreviousStatus:   previousStatus,
+			StateMachine:     stateMachine,
+			outboxService:    s.outboxService,
+			openWorkOfficers: officers,
+		}
+
+		for _, hook := range hooks {
+			if err := hook(tc); err != nil {
+				return err
+			}
+		}
+
+		// Snapshot what the applicant hands over to DEDE, including corrections saved by hooks
+		if err := s.recordSubmission(tx, req, record, previousStatus, stateMachine, flowLog.ID); err != nil {
+			return err
+		}
+
+		// Guards see the request as the transition and its hooks left it; any unmet condition rolls everything back
+		transition, _ := stateMachine.GetTransition(previousStatus, req.ToStatus, req.UserRole)
+		if err := checkGuards(&GuardContext{Tx: tx, Request: req, Record: record, Transition: transition, StateMachine: stateMachine}); err != nil {
+			return err
+		}
+
+		// Entering a payment state invoices the fee, due at the state's deadline
+		if stateMachine.IssuesInvoice(req.ToStatus) {
+			if s.paymentProviderErr != nil {
+				return fmt.Errorf("payment provider unavailable: %w", s.paymentProviderErr)
+			}
+			if err := issueInvoice(tc, s.paymentProvider, now); err != nil {
+				return err
+			}
+		}
+
+		// Approval issues the license of a new request or applies the request to the license it refers to,
+		// and the certificate is rendered again once the change commits, unless the license was retired
+		if req.ToStatus == models.StatusApproved {
+			if tc.License, err = issueLicense(tx, record, now); err != nil {
+				return err
+			}
+			if tc.License != nil && tc.License.IsActive() {
+				if err := s.outboxService.EnqueueCertificate(tx, tc.License.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Fall back to the default notifications when no hook sent its own
+		if !tc.notified {
+			if err := s.sendTransitionNotifications(tc); err != nil {
+				return err
+			}
+		}
+
+		result = &dto.WorkflowTransitionResult{
+			RequestID:      record.ID,
+			LicenseType:    req.LicenseType,
+			RequestNumber:  record.RequestNumber,
+			PreviousStatus: previousStatus,
+			NewStatus:      req.ToStatus,
+			Version:        record.Version,
+			Deadline:       deadline,
+			TransitionedAt: now,
+			OnBehalfOfID:   req.OnBehalfOfID,
+			Approval:       approval,
+		}
+		if tc.License != nil {
+			result.LicenseID = &tc.License.ID
+			result.LicenseNumber = tc.License.LicenseNumber
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Process the transition based on license type
-	switch req.LicenseType {
-	case "new":
-		return s.processNewLicenseTransition(req)
-	case "renewal":
-		return s.processRenewalLicenseTransition(req)
-	case "extension":
-		return s.processExtensionLicenseTransition(req)
-	case "reduction":
-		return s.processReductionLicenseTransition(req)
-	default:
-		return fmt.Errorf("unsupported license type: %s", req.LicenseType)
+	if !s.deferDispatch {
+		s.dispatchOutbox()
 	}
+
+	return result, nil
+}
+
+// dispatchOutbox delivers the queued side effects now; the outbox cron retries anything that fails
+func (s *workflowTransitionService) dispatchOutbox() {
+	go func() {
+		if _, err := s.outboxService.DispatchPending(outboxDispatchBatchSize); err != nil {
+			log.Printf("Error dispatching outbox messages: %v", err)
+		}
+	}()
 }
 
 func (s *workflowTransitionService) ValidateTransition(fromStatus, toStatus models.RequestStatus, userID uint, userRole models.UserRole) (bool, error) {
@@ -95,392 +447,367 @@ func (s *workflowTransitionService) GetWorkflowHistory(requestID uint) ([]models
 	return s.serviceFlowLogRepo.GetByLicenseRequestID(requestID)
 }
 
-func (s *workflowTransitionService) processNewLicenseTransition(req dto.WorkflowTransitionRequest) error {
-	request, err := s.newLicenseRepo.GetByID(req.RequestID)
+// GetRequestState returns the current status and version of a request
+func (s *workflowTransitionService) GetRequestState(requestID uint) (*dto.WorkflowRequestState, error) {
+	record, err := s.getRecord(s.db, requestID)
 	if err != nil {
-		return fmt.Errorf("request not found: %w", err)
+		return nil, err
 	}
 
-	// Update request status
-	request.Status = req.ToStatus
-	request.Notes = req.Comments
-	request.Deadline = s.calculateDeadline(req.ToStatus, time.Now())
-
-	// Handle specific transition logic
-	if req.ToStatus == models.StatusAssigned {
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{req.UserID}[0]
-		now := time.Now()
-		request.AssignedAt = &now
-	}
-
-	if req.ToStatus == models.StatusAppointment {
-		if req.AppointmentDate != nil {
-			request.AppointmentDate = req.AppointmentDate
-		}
-	}
-
-	err = s.db.Save(request).Error
-	if err != nil {
-		return fmt.Errorf("failed to update request: %w", err)
-	}
-
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: req.RequestID,
-		PreviousStatus:   &req.FromStatus,
-		NewStatus:        req.ToStatus,
-		ChangedBy:        &[]uint{req.UserID}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      req.LicenseType,
-	}
+	state := requestState(record)
+	return &state, nil
+}
 
-	err = s.serviceFlowLogRepo.Create(flowLog)
+// getRecord reads the workflow columns of a request
+func (s *workflowTransitionService) getRecord(tx *gorm.DB, requestID uint) (*WorkflowRequestRecord, error) {
+	var record WorkflowRequestRecord
+	err := tx.Table(licenseRequestTable).
+		Where("id = ? AND deleted_at IS NULL", requestID).
+		Take(&record).Error
 	if err != nil {
-		return fmt.Errorf("failed to create flow log: %w", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, fmt.Errorf("failed to get request: %w", err)
 	}
+	return &record, nil
+}
 
-	// Create task assignment if needed
-	if req.ToStatus == models.StatusAssigned {
-		taskAssignment := &models.TaskAssignment{
-			RequestID:    req.RequestID,
-			LicenseType:  req.LicenseType,
-			AssignedToID: req.AssignedToID,
-			AssignedByID: req.UserID,
-			AssignedRole: req.UserRole,
-			TaskType:     models.TaskTypeInspection,
-			Status:       models.TaskStatusPending,
-			Priority:     models.TaskPriorityNormal,
-			Deadline:     request.Deadline,
-			Comments:     req.Comments,
-		}
+// conflict builds the version conflict error carrying the request's current state
+func (s *workflowTransitionService) conflict(current *WorkflowRequestRecord) error {
+	return &VersionConflictError{Current: requestState(current)}
+}
 
-		err = s.db.Create(taskAssignment).Error
-		if err != nil {
-			return fmt.Errorf("failed to create task assignment: %w", err)
-		}
+func requestState(record *WorkflowRequestRecord) dto.WorkflowRequestState {
+	return dto.WorkflowRequestState{
+		RequestID:   record.ID,
+		LicenseType: record.LicenseType,
+		Status:      record.Status,
+		Version:     record.Version,
+		UpdatedAt:   record.UpdatedAt,
 	}
-
-	// Send notifications
-	return s.sendTransitionNotifications(req, request)
 }
 
-func (s *workflowTransitionService) processRenewalLicenseTransition(req dto.WorkflowTransitionRequest) error {
-	request, err := s.renewalLicenseRepo.GetByID(req.RequestID)
+// pinnedStateMachine returns the state machine for the request's workflow version and validates the transition
+// against it. When the caller acts under a delegation, req is updated with the delegated role and delegator.
+func (s *workflowTransitionService) pinnedStateMachine(tx *gorm.DB, req *dto.WorkflowTransitionRequest, workflowVersion int) (*models.WorkflowStateMachine, error) {
+	stateMachine, err := s.definitionService.GetStateMachine(req.LicenseType, workflowVersion)
 	if err != nil {
-		return fmt.Errorf("request not found: %w", err)
+		return nil, fmt.Errorf("failed to load workflow definition: %w", err)
 	}
 
-	// Update request status
-	request.Status = req.ToStatus
-	request.Notes = req.Comments
-	request.Deadline = s.calculateDeadline(req.ToStatus, time.Now())
-
-	// Handle specific transition logic
-	if req.ToStatus == models.StatusAssigned {
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{req.UserID}[0]
-		now := time.Now()
-		request.AssignedAt = &now
+	if err := resolveDelegation(tx, req, stateMachine); err != nil {
+		return nil, err
 	}
 
-	if req.ToStatus == models.StatusAppointment {
-		if req.AppointmentDate != nil {
-			request.AppointmentDate = req.AppointmentDate
-		}
+	transitionReq := models.TransitionRequest{
+		FromStatus:   req.FromStatus,
+		ToStatus:     req.ToStatus,
+		UserID:       req.UserID,
+		UserRole:     req.UserRole,
+		Comments:     req.Comments,
+		AutoApproved: req.AutoApproved,
 	}
 
-	err = s.db.Save(request).Error
-	if err != nil {
-		return fmt.Errorf("failed to update request: %w", err)
+	if err := stateMachine.ValidateTransitionRequest(transitionReq); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTransition, err)
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: req.RequestID,
-		PreviousStatus:   &req.FromStatus,
-		NewStatus:        req.ToStatus,
-		ChangedBy:        &[]uint{req.UserID}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      req.LicenseType,
-	}
+	return stateMachine, nil
+}
 
-	err = s.serviceFlowLogRepo.Create(flowLog)
-	if err != nil {
-		return fmt.Errorf("failed to create flow log: %w", err)
-	}
+// updateTasks keeps the request's task assignments in step with its status
+func (s *workflowTransitionService) updateTasks(tx *gorm.DB, req dto.WorkflowTransitionRequest, record *WorkflowRequestRecord, stateMachine *models.WorkflowStateMachine, now time.Time) error {
+	openTasks := tx.Model(&models.TaskAssignment{}).
+		Where("request_id = ? AND license_type = ? AND status IN ?", record.ID, req.LicenseType,
+			[]models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress})
+
+	switch {
+	case req.ToStatus == models.StatusAssigned:
+		var assignee models.User
+		if err := tx.Select("id", "role").First(&assignee, req.AssignedToID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: assigned user not found", ErrInvalidTransition)
+			}
+			return fmt.Errorf("failed to get assigned user: %w", err)
+		}
+
+		// Reassignment replaces any task still open for the previous assignee
+		if err := openTasks.Update("status", models.TaskStatusCancelled).Error; err != nil {
+			return fmt.Errorf("failed to cancel previous tasks: %w", err)
+		}
 
-	// Create task assignment if needed
-	if req.ToStatus == models.StatusAssigned {
 		taskAssignment := &models.TaskAssignment{
-			RequestID:    req.RequestID,
+			RequestID:    record.ID,
 			LicenseType:  req.LicenseType,
 			AssignedToID: req.AssignedToID,
 			AssignedByID: req.UserID,
-			AssignedRole: req.UserRole,
+			AssignedRole: assignee.Role,
 			TaskType:     models.TaskTypeInspection,
 			Status:       models.TaskStatusPending,
 			Priority:     models.TaskPriorityNormal,
-			Deadline:     request.Deadline,
+			Deadline:     record.Deadline,
 			Comments:     req.Comments,
 		}
-
-		err = s.db.Create(taskAssignment).Error
-		if err != nil {
+		if err := tx.Create(taskAssignment).Error; err != nil {
 			return fmt.Errorf("failed to create task assignment: %w", err)
 		}
-	}
-
-	// Send notifications
-	return s.sendTransitionNotifications(req, request)
-}
 
-func (s *workflowTransitionService) processExtensionLicenseTransition(req dto.WorkflowTransitionRequest) error {
-	request, err := s.extensionLicenseRepo.GetByID(req.RequestID)
-	if err != nil {
-		return fmt.Errorf("request not found: %w", err)
-	}
+	case req.ToStatus == models.StatusAppointment || req.ToStatus == models.StatusInspecting:
+		updates := map[string]interface{}{"status": models.TaskStatusInProgress}
+		if req.ToStatus == models.StatusAppointment && req.AppointmentDate != nil {
+			updates["appointment_date"] = req.AppointmentDate
+		}
+		if err := openTasks.Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update tasks: %w", err)
+		}
 
-	// Update request status
-	request.Status = req.ToStatus
-	request.Notes = req.Comments
-	request.Deadline = s.calculateDeadline(req.ToStatus, time.Now())
+	case req.ToStatus == models.StatusInspectionDone:
+		err := openTasks.Updates(map[string]interface{}{
+			"status":       models.TaskStatusCompleted,
+			"completed_at": now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to complete tasks: %w", err)
+		}
 
-	// Handle specific transition logic
-	if req.ToStatus == models.StatusAssigned {
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{req.UserID}[0]
-		now := time.Now()
-		request.AssignedAt = &now
+	case req.ToStatus == models.StatusRejected || req.ToStatus == models.StatusReturned || stateMachine.IsTerminalState(req.ToStatus):
+		if err := openTasks.Update("status", models.TaskStatusCancelled).Error; err != nil {
+			return fmt.Errorf("failed to cancel tasks: %w", err)
+		}
 	}
 
-	if req.ToStatus == models.StatusAppointment {
-		if req.AppointmentDate != nil {
-			request.AppointmentDate = req.AppointmentDate
+	return nil
+}
+
+// recordSubmission stores an immutable snapshot whenever a request leaves an applicant-owned status
+// for DEDE. Requests returned before they have a snapshot, such as typed requests that skip the
+// draft stage, get the state they were returned in as their baseline submission.
+func (s *workflowTransitionService) recordSubmission(tx *gorm.DB, req dto.WorkflowTransitionRequest, record *WorkflowRequestRecord, previousStatus models.RequestStatus, stateMachine *models.WorkflowStateMachine, flowLogID uint) error {
+	switch {
+	case stateMachine.IsApplicantOwned(previousStatus) && !stateMachine.IsApplicantOwned(req.ToStatus) && !stateMachine.IsTerminalState(req.ToStatus):
+		return recordSubmission(tx, record, previousStatus, req.ToStatus, req.UserID, &flowLogID)
+
+	case req.ToStatus == models.StatusReturned:
+		exists, err := hasSubmission(tx, record.ID)
+		if err != nil || exists {
+			return err
 		}
+		return recordSubmission(tx, record, previousStatus, req.ToStatus, record.UserID, &flowLogID)
 	}
+	return nil
+}
 
-	err = s.db.Save(request).Error
+// openWorkOfficers returns the request's inspector and the officers with an open task, inspection or appeal on it
+func openWorkOfficers(tx *gorm.DB, record *WorkflowRequestRecord) ([]uint, error) {
+	var taskOfficers []uint
+	err := tx.Model(&models.TaskAssignment{}).
+		Where("request_id = ? AND license_type = ? AND status IN ?", record.ID, record.LicenseType,
+			[]models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProgress}).
+		Distinct().
+		Pluck("assigned_to_id", &taskOfficers).Error
 	if err != nil {
-		return fmt.Errorf("failed to update request: %w", err)
+		return nil, fmt.Errorf("failed to get task officers: %w", err)
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: req.RequestID,
-		PreviousStatus:   &req.FromStatus,
-		NewStatus:        req.ToStatus,
-		ChangedBy:        &[]uint{req.UserID}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      req.LicenseType,
+	var inspectors []uint
+	err = tx.Model(&models.Inspection{}).
+		Where("request_id = ? AND status IN ?", record.ID, openInspectionStatuses).
+		Distinct().
+		Pluck("inspector_id", &inspectors).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inspectors: %w", err)
 	}
 
-	err = s.serviceFlowLogRepo.Create(flowLog)
+	var reviewers []uint
+	err = tx.Model(&models.LicenseAppeal{}).
+		Where("request_id = ? AND status = ? AND reviewer_id IS NOT NULL", record.ID, models.AppealStatusPending).
+		Pluck("reviewer_id", &reviewers).Error
 	if err != nil {
-		return fmt.Errorf("failed to create flow log: %w", err)
+		return nil, fmt.Errorf("failed to get appeal reviewers: %w", err)
 	}
 
-	// Create task assignment if needed
-	if req.ToStatus == models.StatusAssigned {
-		taskAssignment := &models.TaskAssignment{
-			RequestID:    req.RequestID,
-			LicenseType:  req.LicenseType,
-			AssignedToID: req.AssignedToID,
-			AssignedByID: req.UserID,
-			AssignedRole: req.UserRole,
-			TaskType:     models.TaskTypeInspection,
-			Status:       models.TaskStatusPending,
-			Priority:     models.TaskPriorityNormal,
-			Deadline:     request.Deadline,
-			Comments:     req.Comments,
-		}
-
-		err = s.db.Create(taskAssignment).Error
-		if err != nil {
-			return fmt.Errorf("failed to create task assignment: %w", err)
+	officers := make([]uint, 0, len(taskOfficers)+len(inspectors)+len(reviewers)+1)
+	seen := make(map[uint]bool)
+	candidates := append(append(taskOfficers, inspectors...), reviewers...)
+	if record.InspectorID != nil {
+		candidates = append(candidates, *record.InspectorID)
+	}
+	for _, officerID := range candidates {
+		if !seen[officerID] {
+			seen[officerID] = true
+			officers = append(officers, officerID)
 		}
 	}
-
-	// Send notifications
-	return s.sendTransitionNotifications(req, request)
+	return officers, nil
 }
 
-func (s *workflowTransitionService) processReductionLicenseTransition(req dto.WorkflowTransitionRequest) error {
-	request, err := s.reductionLicenseRepo.GetByID(req.RequestID)
+// openInspectionStatuses are the inspection statuses cancelled when a request ends
+var openInspectionStatuses = []models.InspectionStatus{models.InspectionStatusScheduled, models.InspectionStatusInProgress}
+
+// cancelInspections cancels the inspections still scheduled or in progress for a request
+func cancelInspections(tx *gorm.DB, record *WorkflowRequestRecord) error {
+	err := tx.Model(&models.Inspection{}).
+		Where("request_id = ? AND status IN ?", record.ID, openInspectionStatuses).
+		Update("status", models.InspectionStatusCancelled).Error
 	if err != nil {
-		return fmt.Errorf("request not found: %w", err)
+		return fmt.Errorf("failed to cancel inspections: %w", err)
 	}
+	return nil
+}
 
-	// Update request status
-	request.Status = req.ToStatus
-	request.Notes = req.Comments
-	request.Deadline = s.calculateDeadline(req.ToStatus, time.Now())
-
-	// Handle specific transition logic
-	if req.ToStatus == models.StatusAssigned {
-		request.InspectorID = &req.AssignedToID
-		request.AssignedByID = &[]uint{req.UserID}[0]
-		now := time.Now()
-		request.AssignedAt = &now
+// updateDeadlineReminders replaces the request's active reminders with the one for its new status
+func (s *workflowTransitionService) updateDeadlineReminders(tx *gorm.DB, req dto.WorkflowTransitionRequest, record *WorkflowRequestRecord) error {
+	err := tx.Model(&models.DeadlineReminder{}).
+		Where("request_id = ? AND license_type = ? AND status = ?", record.ID, req.LicenseType, models.DeadlineReminderStatusActive).
+		Update("status", models.DeadlineReminderStatusCancelled).Error
+	if err != nil {
+		return fmt.Errorf("failed to cancel deadline reminders: %w", err)
 	}
 
-	if req.ToStatus == models.StatusAppointment {
-		if req.AppointmentDate != nil {
-			request.AppointmentDate = req.AppointmentDate
+	var deadlineType models.DeadlineType
+	deadlineDate := record.Deadline
+
+	switch req.ToStatus {
+	case models.StatusAppointment:
+		deadlineType = models.DeadlineTypeAppointment
+		if record.AppointmentDate != nil {
+			deadlineDate = record.AppointmentDate
 		}
+	case models.StatusInspecting:
+		deadlineType = models.DeadlineTypeInspection
+	case models.StatusDocumentEdit:
+		deadlineType = models.DeadlineTypeDocumentReview
+	default:
+		return nil
 	}
 
-	err = s.db.Save(request).Error
-	if err != nil {
-		return fmt.Errorf("failed to update request: %w", err)
+	if deadlineDate == nil {
+		return nil
 	}
 
-	// Create service flow log
-	flowLog := &models.ServiceFlowLog{
-		LicenseRequestID: req.RequestID,
-		PreviousStatus:   &req.FromStatus,
-		NewStatus:        req.ToStatus,
-		ChangedBy:        &[]uint{req.UserID}[0],
-		ChangeReason:     req.Comments,
-		LicenseType:      req.LicenseType,
+	assignedToID := record.InspectorID
+	if assignedToID == nil {
+		assignedToID = &req.UserID
 	}
 
-	err = s.serviceFlowLogRepo.Create(flowLog)
-	if err != nil {
-		return fmt.Errorf("failed to create flow log: %w", err)
+	reminder := &models.DeadlineReminder{
+		RequestID:    record.ID,
+		LicenseType:  req.LicenseType,
+		DeadlineType: deadlineType,
+		DeadlineDate: *deadlineDate,
+		AssignedToID: assignedToID,
+		Status:       models.DeadlineReminderStatusActive,
 	}
-
-	// Create task assignment if needed
-	if req.ToStatus == models.StatusAssigned {
-		taskAssignment := &models.TaskAssignment{
-			RequestID:    req.RequestID,
-			LicenseType:  req.LicenseType,
-			AssignedToID: req.AssignedToID,
-			AssignedByID: req.UserID,
-			AssignedRole: req.UserRole,
-			TaskType:     models.TaskTypeInspection,
-			Status:       models.TaskStatusPending,
-			Priority:     models.TaskPriorityNormal,
-			Deadline:     request.Deadline,
-			Comments:     req.Comments,
-		}
-
-		err = s.db.Create(taskAssignment).Error
-		if err != nil {
-			return fmt.Errorf("failed to create task assignment: %w", err)
-		}
+	if err := tx.Create(reminder).Error; err != nil {
+		return fmt.Errorf("failed to create deadline reminder: %w", err)
 	}
 
-	// Send notifications
-	return s.sendTransitionNotifications(req, request)
+	return nil
 }
 
-func (s *workflowTransitionService) calculateDeadline(status models.RequestStatus, startTime time.Time) *time.Time {
-	// Define default deadlines based on status
-	var days int
-	switch status {
-	case models.StatusNewRequest:
-		days = 3
-	case models.StatusForwarded:
-		days = 5
-	case models.StatusAssigned:
-		days = 14
-	case models.StatusAppointment:
-		days = 7
-	case models.StatusInspecting:
-		days = 10
-	case models.StatusDocumentEdit:
-		days = 14
-	default:
-		return nil
+func (s *workflowTransitionService) sendTransitionNotifications(tc *TransitionContext) error {
+	req := tc.Request
+	actionURL := fmt.Sprintf("/admin-portal/services/%d", req.RequestID)
+	message := func(format string) string {
+		return fmt.Sprintf(format, tc.Record.RequestNumber)
 	}
 
-	if days > 0 {
-		deadline := startTime.AddDate(0, 0, days)
-		return &deadline
+	// The applicant hears the outcome of an appeal; a granted appeal also goes to the owners of the step it reopens
+	if tc.PreviousStatus == models.StatusAppealed && req.ToStatus != models.StatusWithdrawn {
+		if err := notifyAppealOutcome(tc); err != nil {
+			return err
+		}
+		if tc.StateMachine.IsTerminalState(req.ToStatus) {
+			return nil
+		}
 	}
-	return nil
-}
 
-func (s *workflowTransitionService) sendTransitionNotifications(req dto.WorkflowTransitionRequest, request interface{}) error {
-	var title, message string
-	var recipientID *uint
-	var recipientRole *models.UserRole
+	// A resubmission goes to whoever returned the request: the assigned officer or the admins
+	if tc.PreviousStatus == models.StatusReturned && !tc.StateMachine.IsTerminalState(req.ToStatus) {
+		title, body := "แก้ไขเอกสารแล้ว", message("คำขอเลขที่ %s ได้รับการแก้ไขและส่งกลับมาเพื่อพิจารณาอีกครั้ง")
+		if req.ToStatus != models.StatusNewRequest && tc.Record.InspectorID != nil {
+			return tc.NotifyUser(*tc.Record.InspectorID, title, body,
+				models.NotificationType("request_resubmitted"), models.PriorityNormal, actionURL)
+		}
+		return tc.NotifyRole(models.RoleAdmin, title, body,
+			models.NotificationType("request_resubmitted"), models.PriorityNormal, actionURL)
+	}
 
 	switch req.ToStatus {
 	case models.StatusNewRequest:
-		title = "คำขอใหม่"
-		message = fmt.Sprintf("คำขอเลขที่ %d ได้รับการส่งเข้าระบบแล้ว", req.RequestID)
-		recipientRole = &[]models.UserRole{models.UserRole("admin")}[0]
+		return tc.NotifyRole(models.RoleAdmin, "คำขอใหม่", message("คำขอเลขที่ %s ได้รับการส่งเข้าระบบแล้ว"),
+			models.NotificationTypeRequestSubmitted, models.PriorityNormal, actionURL)
 
 	case models.StatusAccepted:
-		title = "คำขอได้รับการอนุมัติเบื้องต้น"
-		message = fmt.Sprintf("คำขอเลขที่ %d ได้รับการอนุมัติเบื้องต้น", req.RequestID)
-		recipientRole = &[]models.UserRole{models.UserRole("admin")}[0]
+		return tc.NotifyRole(models.RoleAdmin, "คำขอได้รับการอนุมัติเบื้องต้น", message("คำขอเลขที่ %s ได้รับการอนุมัติเบื้องต้น"),
+			models.NotificationTypeRequestAccepted, models.PriorityNormal, actionURL)
 
 	case models.StatusForwarded:
-		title = "คำขอถูกส่งต่อ"
-		message = fmt.Sprintf("คำขอเลขที่ %d ถูกส่งต่อให้ DEDE Head", req.RequestID)
-		recipientRole = &[]models.UserRole{models.UserRole("dede_head")}[0]
+		return tc.NotifyRole(models.RoleDEDEHead, "คำขอถูกส่งต่อ", message("คำขอเลขที่ %s ถูกส่งต่อให้ DEDE Head"),
+			models.NotificationTypeRequestAssigned, models.PriorityNormal, actionURL)
 
 	case models.StatusAssigned:
-		title = "มอบหมายงาน"
-		message = fmt.Sprintf("คำขอเลขที่ %d ถูกมอบหมายให้ดำเนินการ", req.RequestID)
-		recipientID = &req.AssignedToID
+		return tc.NotifyUser(req.AssignedToID, "มอบหมายงาน", message("คำขอเลขที่ %s ถูกมอบหมายให้ดำเนินการ"),
+			models.NotificationTypeRequestAssigned, models.PriorityNormal, actionURL)
 
 	case models.StatusAppointment:
-		title = "นัดหมายตรวจสอบ"
-		message = fmt.Sprintf("คำขอเลขที่ %d มีการนัดหมายตรวจสอบ", req.RequestID)
-		recipientID = &req.AssignedToID
+		if tc.Record.InspectorID == nil {
+			return nil
+		}
+		return tc.NotifyUser(*tc.Record.InspectorID, "นัดหมายตรวจสอบ", message("คำขอเลขที่ %s มีการนัดหมายตรวจสอบ"),
+			models.NotificationTypeAppointmentSet, models.PriorityNormal, actionURL)
 
 	case models.StatusDocumentEdit:
-		title = "ส่งรายงานตรวจสอบ"
-		message = fmt.Sprintf("คำขอเลขที่ %d มีรายงานตรวจสอบสำหรับพิจารณา", req.RequestID)
-		recipientRole = &[]models.UserRole{models.UserRole("dede_staff")}[0]
+		return tc.NotifyRole(models.RoleDEDEStaff, "ส่งรายงานตรวจสอบ", message("คำขอเลขที่ %s มีรายงานตรวจสอบสำหรับพิจารณา"),
+			models.NotificationTypeReportSubmitted, models.PriorityNormal, actionURL)
 
 	case models.StatusApproved:
-		title = "อนุมัติคำขอ"
-		message = fmt.Sprintf("คำขอเลขที่ %d ได้รับการอนุมัติแล้ว", req.RequestID)
-		// Get the original user ID from the request
-		if req.LicenseType == "new" {
-			if request, ok := request.(*models.NewLicenseRequest); ok {
-				recipientID = &request.UserID
-			}
+		body := message("คำขอเลขที่ %s ได้รับการอนุมัติแล้ว")
+		if tc.License != nil {
+			body += fmt.Sprintf(" ใบอนุญาตเลขที่ %s มีผลถึงวันที่ %s", tc.License.LicenseNumber, tc.License.ValidUntil.Format("2006-01-02"))
 		}
+		return tc.NotifyUser(tc.Record.UserID, "อนุมัติคำขอ", body,
+			models.NotificationType("request_approved"), models.PriorityHigh, "/dashboard/licenses")
 
 	case models.StatusRejected:
-		title = "ปฏิเสธคำขอ"
-		message = fmt.Sprintf("คำขอเลขที่ %d ถูกปฏิเสธ", req.RequestID)
-		// Get the original user ID from the request
-		if req.LicenseType == "new" {
-			if request, ok := request.(*models.NewLicenseRequest); ok {
-				recipientID = &request.UserID
-			}
-		}
+		return tc.NotifyUser(tc.Record.UserID, "ปฏิเสธคำขอ", message("คำขอเลขที่ %s ถูกปฏิเสธ"),
+			models.NotificationTypeRequestRejected, models.PriorityHigh, "/dashboard/licenses")
+
+	case models.StatusRejectedFinal:
+		return tc.NotifyUser(tc.Record.UserID, "ปฏิเสธคำขอเป็นที่สุด", message("คำขอเลขที่ %s ถูกปฏิเสธเป็นที่สุดเนื่องจากพ้นกำหนดระยะเวลายื่นอุทธรณ์"),
+			models.NotificationTypeRequestRejected, models.PriorityHigh, "/dashboard/licenses")
 
 	case models.StatusReturned:
-		title = "ตีกลับเอกสาร"
-		message = fmt.Sprintf("คำขอเลขที่ %d ต้องมีการแก้ไขเอกสาร", req.RequestID)
-		// Get the original user ID from the request
-		if req.LicenseType == "new" {
-			if request, ok := request.(*models.NewLicenseRequest); ok {
-				recipientID = &request.UserID
-			}
+		return tc.NotifyUser(tc.Record.UserID, "ตีกลับเอกสาร", message("คำขอเลขที่ %s ต้องมีการแก้ไขเอกสาร"),
+			models.NotificationTypeRequestRejected, models.PriorityHigh, "/dashboard/licenses")
+
+	case models.StatusOverdue:
+		if err := tc.NotifyUser(tc.Record.UserID, "คำขอหมดอายุเนื่องจากเกินกำหนดเวลา", message("คำขอเลขที่ %s ถูกยกเลิกโดยอัตโนมัติเนื่องจากเกินกำหนดเวลาดำเนินการ"),
+			models.NotificationType("request_overdue"), models.PriorityHigh, "/dashboard/licenses"); err != nil {
+			return err
 		}
-	}
+		return tc.NotifyRole(models.RoleAdmin, "คำขอถูกยกเลิกโดยอัตโนมัติ", message("คำขอเลขที่ %s ถูกยกเลิกโดยอัตโนมัติเนื่องจากเกินกำหนดเวลา"),
+			models.NotificationType("request_overdue"), models.PriorityHigh, "/admin-portal/services")
 
-	// Create notification
-	notification := &models.Notification{
-		Title:         title,
-		Message:       message,
-		Type:          models.NotificationType("request_assigned"),
-		Priority:      models.PriorityNormal,
-		RecipientID:   recipientID,
-		RecipientRole: recipientRole,
-		EntityType:    "license_request",
-		EntityID:      &req.RequestID,
-		ActionURL:     fmt.Sprintf("/admin-portal/services/%d", req.RequestID),
+	case models.StatusWithdrawn:
+		// Drafts never reached DEDE, so nobody is waiting on them
+		if tc.PreviousStatus == models.StatusDraft {
+			return nil
+		}
+		body := fmt.Sprintf("คำขอเลขที่ %s ถูกถอนโดยผู้ยื่นคำขอ: %s", tc.Record.RequestNumber, req.Comments)
+		if len(tc.openWorkOfficers) == 0 {
+			return tc.NotifyRole(models.RoleAdmin, "ถอนคำขอ", body,
+				models.NotificationType("request_withdrawn"), models.PriorityNormal, actionURL)
+		}
+		for _, officerID := range tc.openWorkOfficers {
+			if err := tc.NotifyUser(officerID, "ถอนคำขอ", body,
+				models.NotificationType("request_withdrawn"), models.PriorityNormal, actionURL); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	return s.notificationRepo.Create(notification)
+	return nil
 }
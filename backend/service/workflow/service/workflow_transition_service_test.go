@@ -0,0 +1,255 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"eservice-backend/config"
+	"eservice-backend/database/migrations"
+	"eservice-backend/models"
+	"eservice-backend/service/workflow/dto"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openWorkflowTestDB returns a migrated database with the standard workflow published as version 1.
+// Transitions read the workflow definition outside their transaction, so the database is a file in
+// WAL mode rather than a single in-memory connection.
+func openWorkflowTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/test.db?_journal_mode=WAL&_busy_timeout=5000"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, migrations.Migrate(db))
+	return db
+}
+
+// newTestTransitionService returns a transition service that leaves queued outbox messages in place
+// instead of delivering them in the background
+func newTestTransitionService(db *gorm.DB) *workflowTransitionService {
+	s := NewWorkflowTransitionService(db, &config.Config{}).(*workflowTransitionService)
+	s.deferDispatch = true
+	return s
+}
+
+func createTestUser(t *testing.T, db *gorm.DB, role models.UserRole) *models.User {
+	t.Helper()
+
+	var count int64
+	require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+	user := &models.User{
+		Username: fmt.Sprintf("%s%d", role, count+1),
+		Email:    fmt.Sprintf("%s%d@example.com", role, count+1),
+		Password: "secret",
+		FullName: fmt.Sprintf("Test %s", role),
+		Role:     role,
+	}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// createTestRequest creates a request of the applicant in the given status, pinned to the standard workflow
+func createTestRequest(t *testing.T, db *gorm.DB, userID uint, licenseType models.LicenseType, status models.RequestStatus) *models.LicenseRequest {
+	t.Helper()
+
+	var count int64
+	require.NoError(t, db.Model(&models.LicenseRequest{}).Count(&count).Error)
+	request := &models.LicenseRequest{
+		UserID:          userID,
+		RequestNumber:   fmt.Sprintf("REQ-%04d", count+1),
+		LicenseType:     licenseType,
+		Status:          status,
+		Title:           "Solar farm",
+		WorkflowVersion: 1,
+	}
+	require.NoError(t, db.Omit("User").Create(request).Error)
+	return request
+}
+
+func reloadTestRequest(t *testing.T, db *gorm.DB, requestID uint) *models.LicenseRequest {
+	t.Helper()
+
+	var request models.LicenseRequest
+	require.NoError(t, db.First(&request, requestID).Error)
+	return &request
+}
+
+func TestProcessTransitionIsAtomic(t *testing.T) {
+	hookErr := errors.New("hook failed")
+	staleVersion := 7
+
+	tests := []struct {
+		name    string
+		version *int
+		hook    TransitionHook
+		wantErr error
+	}{
+		{
+			name: "a failing hook rolls back its own writes",
+			hook: func(tc *TransitionContext) error {
+				if err := tc.NotifyUser(tc.Record.UserID, "title", "message", models.NotificationTypeRequestAccepted, models.PriorityNormal, ""); err != nil {
+					return err
+				}
+				return hookErr
+			},
+			wantErr: hookErr,
+		},
+		{name: "a stale version is a conflict", version: &staleVersion, wantErr: &VersionConflictError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := newTestTransitionService(db)
+			applicant := createTestUser(t, db, models.RoleUser)
+			admin := createTestUser(t, db, models.RoleAdmin)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, models.StatusNewRequest)
+
+			var hooks []TransitionHook
+			if tt.hook != nil {
+				hooks = append(hooks, tt.hook)
+			}
+			_, err := s.ProcessTransition(dto.WorkflowTransitionRequest{
+				RequestID:       request.ID,
+				ExpectedVersion: tt.version,
+				ToStatus:        models.StatusAccepted,
+				UserID:          admin.ID,
+				UserRole:        models.RoleAdmin,
+			}, hooks...)
+			var conflict *VersionConflictError
+			if errors.As(tt.wantErr, &conflict) {
+				require.ErrorAs(t, err, &conflict)
+				assert.Equal(t, 1, conflict.Current.Version)
+			} else {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+
+			stored := reloadTestRequest(t, db, request.ID)
+			assert.Equal(t, models.StatusNewRequest, stored.Status)
+			assert.Equal(t, 1, stored.Version)
+			for _, table := range []interface{}{&models.ServiceFlowLog{}, &models.Notification{}, &models.OutboxMessage{}} {
+				var count int64
+				require.NoError(t, db.Model(table).Count(&count).Error)
+				assert.Zero(t, count, "%T rows", table)
+			}
+		})
+	}
+}
+
+// queuedNotifications returns the notifications waiting in the outbox, oldest first
+func queuedNotifications(t *testing.T, db *gorm.DB) []models.Notification {
+	t.Helper()
+
+	var messages []models.OutboxMessage
+	require.NoError(t, db.Where("channel = ?", models.OutboxChannelNotification).Order("id").Find(&messages).Error)
+	notifications := make([]models.Notification, len(messages))
+	for i, message := range messages {
+		require.NoError(t, json.Unmarshal([]byte(message.Payload), &notifications[i]))
+	}
+	return notifications
+}
+
+func TestWithdrawRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         models.RequestStatus
+		reason         string
+		byOther        bool
+		wantErr        error
+		wantNotifyRole bool // Nobody holds open work, so the admins are told
+		wantOfficer    bool
+	}{
+		{name: "draft", status: models.StatusDraft, reason: "Filed by mistake"},
+		{name: "waiting for review", status: models.StatusNewRequest, reason: "Project cancelled", wantNotifyRole: true},
+		{name: "under inspection", status: models.StatusAppointment, reason: "Project cancelled", wantOfficer: true},
+		{name: "returned to the applicant", status: models.StatusReturned, reason: "Project cancelled", wantNotifyRole: true},
+		{name: "without a reason", status: models.StatusNewRequest, wantErr: ErrGuardFailed},
+		{name: "by another applicant", status: models.StatusNewRequest, reason: "Project cancelled", byOther: true, wantErr: ErrInvalidTransition},
+		{name: "already approved", status: models.StatusApproved, reason: "Project cancelled", wantErr: ErrInvalidTransition},
+		{name: "already withdrawn", status: models.StatusWithdrawn, reason: "Project cancelled", wantErr: ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openWorkflowTestDB(t)
+			s := newTestTransitionService(db)
+			applicant := createTestUser(t, db, models.RoleUser)
+			staff := createTestUser(t, db, models.RoleDEDEStaff)
+			request := createTestRequest(t, db, applicant.ID, models.LicenseTypeNew, tt.status)
+
+			if tt.wantOfficer {
+				task := &models.TaskAssignment{
+					RequestID:    request.ID,
+					LicenseType:  string(request.LicenseType),
+					AssignedToID: staff.ID,
+					AssignedByID: staff.ID,
+					AssignedRole: models.RoleDEDEStaff,
+					TaskType:     models.TaskTypeInspection,
+					Status:       models.TaskStatusPending,
+				}
+				require.NoError(t, db.Omit("AssignedTo", "AssignedBy").Create(task).Error)
+				inspection := &models.Inspection{
+					RequestID:     request.ID,
+					InspectorID:   staff.ID,
+					Status:        models.InspectionStatusScheduled,
+					ScheduledDate: time.Now().AddDate(0, 0, 3),
+					Location:      "Site",
+				}
+				require.NoError(t, db.Omit("Request", "Inspector").Create(inspection).Error)
+			}
+
+			callerID := applicant.ID
+			if tt.byOther {
+				callerID = createTestUser(t, db, models.RoleUser).ID
+			}
+			_, err := s.ProcessTransition(dto.WorkflowTransitionRequest{
+				RequestID: request.ID,
+				ToStatus:  models.StatusWithdrawn,
+				Comments:  tt.reason,
+				UserID:    callerID,
+				UserRole:  models.RoleUser,
+			}, RequireRequestOwner(callerID))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.status, reloadTestRequest(t, db, request.ID).Status)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.StatusWithdrawn, reloadTestRequest(t, db, request.ID).Status)
+
+			var openTasks, openInspections int64
+			require.NoError(t, db.Model(&models.TaskAssignment{}).Where("request_id = ? AND status IN ?", request.ID, openTaskStatuses).Count(&openTasks).Error)
+			require.NoError(t, db.Model(&models.Inspection{}).Where("request_id = ? AND status IN ?", request.ID, openInspectionStatuses).Count(&openInspections).Error)
+			assert.Zero(t, openTasks)
+			assert.Zero(t, openInspections)
+
+			var recipients []string
+			for _, notification := range queuedNotifications(t, db) {
+				switch {
+				case notification.RecipientID != nil:
+					recipients = append(recipients, fmt.Sprintf("user %d", *notification.RecipientID))
+				case notification.RecipientRole != nil:
+					recipients = append(recipients, string(*notification.RecipientRole))
+				}
+			}
+			switch {
+			case tt.wantOfficer:
+				assert.Equal(t, []string{fmt.Sprintf("user %d", staff.ID)}, recipients)
+			case tt.wantNotifyRole:
+				assert.Equal(t, []string{string(models.RoleAdmin)}, recipients)
+			default:
+				assert.Empty(t, recipients)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidIfMatch is returned when the If-Match header is not a version ETag
+var ErrInvalidIfMatch = errors.New("If-Match must be a single version ETag")
+
+// VersionETag formats a row version as a strong ETag
+func VersionETag(version int) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// SetVersionETag sets the ETag response header from a row version
+func SetVersionETag(c *gin.Context, version int) {
+	c.Header("ETag", VersionETag(version))
+}
+
+// IfMatchVersion parses the If-Match request header into a row version.
+// It returns nil when the header is absent or "*".
+func IfMatchVersion(c *gin.Context) (*int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || !strings.HasPrefix(value, "\"") || !strings.HasSuffix(value, "\"") {
+		return nil, ErrInvalidIfMatch
+	}
+
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil {
+		return nil, ErrInvalidIfMatch
+	}
+	return &version, nil
+}
@@ -0,0 +1,402 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// jsonSchemaKeywords are the JSON Schema keywords JSONSchema understands. Schemas using any other
+// keyword are rejected rather than half-enforced; extensions prefixed with x- are left to clients.
+var jsonSchemaKeywords = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true,
+	"type": true, "title": true, "description": true, "default": true, "examples": true,
+	"properties": true, "required": true, "additionalProperties": true, "items": true, "enum": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minLength": true, "maxLength": true, "pattern": true, "format": true,
+	"minItems": true, "maxItems": true,
+}
+
+// jsonSchemaTypes are the supported values of the type keyword
+var jsonSchemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true,
+}
+
+// jsonSchemaFormats are the supported values of the format keyword
+var jsonSchemaFormats = map[string]bool{"date": true, "date-time": true, "email": true}
+
+// JSONSchema is the subset of JSON Schema that request forms are described with. Titles are shown
+// in English errors; the x-title-th extension holds the Thai title used in Thai errors.
+type JSONSchema struct {
+	Type                 string                 `json:"type,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	TitleTH              string                 `json:"x-title-th,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"` // Only the boolean form is supported
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// SchemaError is a field of a document that does not match its schema, described in English and Thai
+type SchemaError struct {
+	Field     string `json:"field"`   // Path of the field, e.g. inverters[0].capacity_kw; empty for the document itself
+	Keyword   string `json:"keyword"` // Schema keyword the value fails, e.g. required or minimum
+	Message   string `json:"message"`
+	MessageTH string `json:"message_th"`
+}
+
+// ParseJSONSchema decodes a schema and checks that every keyword in it is supported and well formed.
+// The root of a schema must describe an object.
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if err := checkSchemaKeywords(raw, ""); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	var schema JSONSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if schema.Type != "object" {
+		return nil, fmt.Errorf("invalid JSON schema: the root must have type object")
+	}
+	if err := schema.compile(""); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// checkSchemaKeywords rejects keywords the validator does not enforce, e.g. oneOf or $ref
+func checkSchemaKeywords(node interface{}, path string) error {
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s must be an object", schemaPath(path))
+	}
+
+	for keyword, value := range object {
+		if strings.HasPrefix(keyword, "x-") {
+			continue
+		}
+		if !jsonSchemaKeywords[keyword] {
+			return fmt.Errorf("%s uses unsupported keyword %s", schemaPath(path), keyword)
+		}
+
+		switch keyword {
+		case "properties":
+			properties, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s has properties that are not an object", schemaPath(path))
+			}
+			for name, property := range properties {
+				if err := checkSchemaKeywords(property, joinFieldPath(path, name)); err != nil {
+					return err
+				}
+			}
+		case "items":
+			if err := checkSchemaKeywords(value, path+"[]"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compile checks the values of the keywords and compiles patterns
+func (s *JSONSchema) compile(path string) error {
+	if s.Type != "" && !jsonSchemaTypes[s.Type] {
+		return fmt.Errorf("%s has unsupported type %s", schemaPath(path), s.Type)
+	}
+	if s.Format != "" && !jsonSchemaFormats[s.Format] {
+		return fmt.Errorf("%s has unsupported format %s", schemaPath(path), s.Format)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s has an invalid pattern: %w", schemaPath(path), err)
+		}
+		s.pattern = pattern
+	}
+	for _, bound := range []*int{s.MinLength, s.MaxLength, s.MinItems, s.MaxItems} {
+		if bound != nil && *bound < 0 {
+			return fmt.Errorf("%s has a negative length bound", schemaPath(path))
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok && s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			return fmt.Errorf("%s requires %s, which it does not allow", schemaPath(path), name)
+		}
+	}
+
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s has an empty schema", schemaPath(joinFieldPath(path, name)))
+		}
+		if err := property.compile(joinFieldPath(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Validate checks a decoded JSON document against the schema and returns every field that does
+// not match it, in a stable order
+func (s *JSONSchema) Validate(document interface{}) []SchemaError {
+	errs := make([]SchemaError, 0)
+	s.validate(document, "", &errs)
+	return errs
+}
+
+func (s *JSONSchema) validate(value interface{}, path string, errs *[]SchemaError) {
+	if s.Type != "" && !matchesSchemaType(s.Type, value) {
+		*errs = append(*errs, s.schemaError(path, "type", "must be "+schemaTypeNames[s.Type][0], "ต้องเป็น"+schemaTypeNames[s.Type][1]))
+		return
+	}
+	if len(s.Enum) > 0 && !containsSchemaValue(s.Enum, value) {
+		options := make([]string, 0, len(s.Enum))
+		for _, option := range s.Enum {
+			options = append(options, fmt.Sprint(option))
+		}
+		list := strings.Join(options, ", ")
+		*errs = append(*errs, s.schemaError(path, "enum", "must be one of "+list, "ต้องเป็นค่าใดค่าหนึ่งต่อไปนี้: "+list))
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(typed, path, errs)
+	case []interface{}:
+		s.validateArray(typed, path, errs)
+	case string:
+		s.validateString(typed, path, errs)
+	case float64:
+		s.validateNumber(typed, path, errs)
+	}
+}
+
+func (s *JSONSchema) validateObject(object map[string]interface{}, path string, errs *[]SchemaError) {
+	for _, name := range s.Required {
+		if value, ok := object[name]; !ok || value == nil {
+			property := s.Properties[name]
+			if property == nil {
+				property = &JSONSchema{}
+			}
+			label, labelTH := property.labels(joinFieldPath(path, name))
+			*errs = append(*errs, SchemaError{
+				Field:     joinFieldPath(path, name),
+				Keyword:   "required",
+				Message:   label + " is required",
+				MessageTH: "กรุณาระบุ " + labelTH,
+			})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := object[name]
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, SchemaError{
+					Field:     joinFieldPath(path, name),
+					Keyword:   "additionalProperties",
+					Message:   fmt.Sprintf("%s is not a known field", joinFieldPath(path, name)),
+					MessageTH: fmt.Sprintf("ไม่รู้จักข้อมูล %s", joinFieldPath(path, name)),
+				})
+			}
+			continue
+		}
+		// An explicit null counts as left out; required has already reported it
+		if value == nil {
+			continue
+		}
+		property.validate(value, joinFieldPath(path, name), errs)
+	}
+}
+
+func (s *JSONSchema) validateArray(items []interface{}, path string, errs *[]SchemaError) {
+	if s.MinItems != nil && len(items) < *s.MinItems {
+		*errs = append(*errs, s.schemaError(path, "minItems",
+			fmt.Sprintf("must have at least %d items", *s.MinItems),
+			fmt.Sprintf("ต้องมีอย่างน้อย %d รายการ", *s.MinItems)))
+	}
+	if s.MaxItems != nil && len(items) > *s.MaxItems {
+		*errs = append(*errs, s.schemaError(path, "maxItems",
+			fmt.Sprintf("must have at most %d items", *s.MaxItems),
+			fmt.Sprintf("ต้องมีไม่เกิน %d รายการ", *s.MaxItems)))
+	}
+	if s.Items == nil {
+		return
+	}
+	for i, item := range items {
+		s.Items.validate(item, path+"["+strconv.Itoa(i)+"]", errs)
+	}
+}
+
+func (s *JSONSchema) validateString(value, path string, errs *[]SchemaError) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		*errs = append(*errs, s.schemaError(path, "minLength",
+			fmt.Sprintf("must be at least %d characters", *s.MinLength),
+			fmt.Sprintf("ต้องมีความยาวอย่างน้อย %d ตัวอักษร", *s.MinLength)))
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		*errs = append(*errs, s.schemaError(path, "maxLength",
+			fmt.Sprintf("must be at most %d characters", *s.MaxLength),
+			fmt.Sprintf("ต้องมีความยาวไม่เกิน %d ตัวอักษร", *s.MaxLength)))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		*errs = append(*errs, s.schemaError(path, "pattern", "is not in the expected format", "มีรูปแบบไม่ถูกต้อง"))
+	}
+
+	switch s.Format {
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			*errs = append(*errs, s.schemaError(path, "format", "must be a date (YYYY-MM-DD)", "ต้องเป็นวันที่ในรูปแบบ YYYY-MM-DD"))
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			*errs = append(*errs, s.schemaError(path, "format", "must be a date and time (RFC 3339)", "ต้องเป็นวันที่และเวลาในรูปแบบ RFC 3339"))
+		}
+	case "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			*errs = append(*errs, s.schemaError(path, "format", "must be an email address", "ต้องเป็นอีเมลที่ถูกต้อง"))
+		}
+	}
+}
+
+func (s *JSONSchema) validateNumber(value float64, path string, errs *[]SchemaError) {
+	if s.Minimum != nil && value < *s.Minimum {
+		*errs = append(*errs, s.schemaError(path, "minimum",
+			fmt.Sprintf("must be at least %g", *s.Minimum),
+			fmt.Sprintf("ต้องไม่น้อยกว่า %g", *s.Minimum)))
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		*errs = append(*errs, s.schemaError(path, "maximum",
+			fmt.Sprintf("must be at most %g", *s.Maximum),
+			fmt.Sprintf("ต้องไม่เกิน %g", *s.Maximum)))
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		*errs = append(*errs, s.schemaError(path, "exclusiveMinimum",
+			fmt.Sprintf("must be more than %g", *s.ExclusiveMinimum),
+			fmt.Sprintf("ต้องมากกว่า %g", *s.ExclusiveMinimum)))
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		*errs = append(*errs, s.schemaError(path, "exclusiveMaximum",
+			fmt.Sprintf("must be less than %g", *s.ExclusiveMaximum),
+			fmt.Sprintf("ต้องน้อยกว่า %g", *s.ExclusiveMaximum)))
+	}
+}
+
+// schemaTypeNames are the English and Thai names of the types used in type errors
+var schemaTypeNames = map[string][2]string{
+	"object":  {"an object", "ชุดข้อมูล"},
+	"array":   {"a list", "รายการ"},
+	"string":  {"text", "ข้อความ"},
+	"number":  {"a number", "ตัวเลข"},
+	"integer": {"a whole number", "จำนวนเต็ม"},
+	"boolean": {"true or false", "ค่าจริงหรือเท็จ"},
+}
+
+// schemaError describes a field that fails a keyword, naming it by its titles where the schema has them
+func (s *JSONSchema) schemaError(path, keyword, message, messageTH string) SchemaError {
+	label, labelTH := s.labels(path)
+	return SchemaError{
+		Field:     path,
+		Keyword:   keyword,
+		Message:   label + " " + message,
+		MessageTH: labelTH + " " + messageTH,
+	}
+}
+
+// labels returns the English and Thai names of a field, falling back to its path
+func (s *JSONSchema) labels(path string) (string, string) {
+	if path == "" {
+		return "The document", "ข้อมูล"
+	}
+	label, labelTH := path, path
+	if s.Title != "" {
+		label, labelTH = s.Title, s.Title
+	}
+	if s.TitleTH != "" {
+		labelTH = s.TitleTH
+	}
+	return label, labelTH
+}
+
+func matchesSchemaType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return false
+}
+
+func containsSchemaValue(options []interface{}, value interface{}) bool {
+	for _, option := range options {
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func schemaPath(path string) string {
+	if path == "" {
+		return "the schema"
+	}
+	return "property " + path
+}
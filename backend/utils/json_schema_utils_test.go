@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "object schema", schema: `{"type": "object", "properties": {"capacity_kw": {"type": "number", "minimum": 0}}}`},
+		{name: "extension keyword", schema: `{"type": "object", "x-title-th": "ข้อมูล", "x-widget": "form"}`},
+		{name: "malformed JSON", schema: `{"type": "object"`, wantErr: "invalid JSON schema"},
+		{name: "root is not an object schema", schema: `{"type": "array"}`, wantErr: "the root must have type object"},
+		{name: "root is not a JSON object", schema: `["object"]`, wantErr: "the schema must be an object"},
+		{name: "unsupported keyword", schema: `{"type": "object", "oneOf": []}`, wantErr: "the schema uses unsupported keyword oneOf"},
+		{
+			name:    "unsupported keyword in a property",
+			schema:  `{"type": "object", "properties": {"site": {"type": "object", "properties": {"id": {"$ref": "#/x"}}}}}`,
+			wantErr: "property site.id uses unsupported keyword $ref",
+		},
+		{name: "unsupported keyword in items", schema: `{"type": "object", "properties": {"a": {"type": "array", "items": {"anyOf": []}}}}`, wantErr: "property a[] uses unsupported keyword anyOf"},
+		{name: "unsupported type", schema: `{"type": "object", "properties": {"a": {"type": "null"}}}`, wantErr: "property a has unsupported type null"},
+		{name: "unsupported format", schema: `{"type": "object", "properties": {"a": {"type": "string", "format": "uri"}}}`, wantErr: "unsupported format uri"},
+		{name: "invalid pattern", schema: `{"type": "object", "properties": {"a": {"type": "string", "pattern": "("}}}`, wantErr: "property a has an invalid pattern"},
+		{name: "negative length", schema: `{"type": "object", "properties": {"a": {"type": "string", "minLength": -1}}}`, wantErr: "negative length bound"},
+		{name: "empty property schema", schema: `{"type": "object", "properties": {"a": null}}`, wantErr: "property a must be an object"},
+		{
+			name:    "required field that is not allowed",
+			schema:  `{"type": "object", "required": ["b"], "additionalProperties": false, "properties": {"a": {"type": "string"}}}`,
+			wantErr: "the schema requires b, which it does not allow",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ParseJSONSchema([]byte(tt.schema))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "object", schema.Type)
+		})
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := ParseJSONSchema([]byte(`{
+		"type": "object",
+		"required": ["capacity_kw", "inverters"],
+		"additionalProperties": false,
+		"properties": {
+			"capacity_kw": {"type": "number", "exclusiveMinimum": 0, "maximum": 1000, "title": "Capacity", "x-title-th": "กำลังการผลิต"},
+			"panels": {"type": "integer", "minimum": 1},
+			"grid": {"type": "string", "enum": ["on", "off"]},
+			"commissioned": {"type": "string", "format": "date"},
+			"contact": {"type": "string", "format": "email"},
+			"serial": {"type": "string", "pattern": "^[A-Z]{2}[0-9]+$", "minLength": 3, "maxLength": 8},
+			"inverters": {
+				"type": "array",
+				"minItems": 1,
+				"maxItems": 2,
+				"items": {"type": "object", "required": ["model"], "properties": {"model": {"type": "string"}, "rating_kw": {"type": "number"}}}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		document string
+		want     []SchemaError // Only Field and Keyword are compared
+	}{
+		{
+			name:     "valid document",
+			document: `{"capacity_kw": 12.5, "panels": 40, "grid": "on", "commissioned": "2024-02-29", "contact": "a@example.com", "serial": "AB123", "inverters": [{"model": "X1", "rating_kw": 5}]}`,
+		},
+		{name: "null optional field", document: `{"capacity_kw": 1, "grid": null, "inverters": [{"model": "X1"}]}`},
+		{
+			name:     "missing required fields",
+			document: `{}`,
+			want:     []SchemaError{{Field: "capacity_kw", Keyword: "required"}, {Field: "inverters", Keyword: "required"}},
+		},
+		{name: "null required field", document: `{"capacity_kw": null, "inverters": [{"model": "X1"}]}`, want: []SchemaError{{Field: "capacity_kw", Keyword: "required"}}},
+		{name: "wrong type", document: `{"capacity_kw": "12", "inverters": [{"model": "X1"}]}`, want: []SchemaError{{Field: "capacity_kw", Keyword: "type"}}},
+		{name: "not a whole number", document: `{"capacity_kw": 1, "panels": 2.5, "inverters": [{"model": "X1"}]}`, want: []SchemaError{{Field: "panels", Keyword: "type"}}},
+		{
+			name:     "number bounds",
+			document: `{"capacity_kw": 0, "panels": 0, "inverters": [{"model": "X1"}]}`,
+			want:     []SchemaError{{Field: "capacity_kw", Keyword: "exclusiveMinimum"}, {Field: "panels", Keyword: "minimum"}},
+		},
+		{name: "above maximum", document: `{"capacity_kw": 1000.5, "inverters": [{"model": "X1"}]}`, want: []SchemaError{{Field: "capacity_kw", Keyword: "maximum"}}},
+		{name: "not in enum", document: `{"capacity_kw": 1, "grid": "hybrid", "inverters": [{"model": "X1"}]}`, want: []SchemaError{{Field: "grid", Keyword: "enum"}}},
+		{
+			name:     "formats",
+			document: `{"capacity_kw": 1, "commissioned": "2023-02-29", "contact": "Someone <a@example.com>", "inverters": [{"model": "X1"}]}`,
+			want:     []SchemaError{{Field: "commissioned", Keyword: "format"}, {Field: "contact", Keyword: "format"}},
+		},
+		{
+			name:     "string length and pattern",
+			document: `{"capacity_kw": 1, "serial": "ab1234567", "inverters": [{"model": "X1"}]}`,
+			want:     []SchemaError{{Field: "serial", Keyword: "maxLength"}, {Field: "serial", Keyword: "pattern"}},
+		},
+		{name: "unknown field", document: `{"capacity_kw": 1, "colour": "red", "inverters": [{"model": "X1"}]}`, want: []SchemaError{{Field: "colour", Keyword: "additionalProperties"}}},
+		{name: "too few items", document: `{"capacity_kw": 1, "inverters": []}`, want: []SchemaError{{Field: "inverters", Keyword: "minItems"}}},
+		{
+			name:     "errors inside items",
+			document: `{"capacity_kw": 1, "inverters": [{"model": "X1"}, {"rating_kw": "5"}, {"model": 3}]}`,
+			want: []SchemaError{
+				{Field: "inverters", Keyword: "maxItems"},
+				{Field: "inverters[1].model", Keyword: "required"},
+				{Field: "inverters[1].rating_kw", Keyword: "type"},
+				{Field: "inverters[2].model", Keyword: "type"},
+			},
+		},
+		{name: "document is not an object", document: `[1, 2]`, want: []SchemaError{{Field: "", Keyword: "type"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.document), &document))
+
+			got := make([]SchemaError, 0)
+			for _, schemaErr := range schema.Validate(document) {
+				assert.NotEmpty(t, schemaErr.Message)
+				assert.NotEmpty(t, schemaErr.MessageTH)
+				got = append(got, SchemaError{Field: schemaErr.Field, Keyword: schemaErr.Keyword})
+			}
+			if tt.want == nil {
+				tt.want = []SchemaError{}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJSONSchemaValidateMessages(t *testing.T) {
+	schema, err := ParseJSONSchema([]byte(`{
+		"type": "object",
+		"required": ["capacity_kw", "site"],
+		"properties": {"capacity_kw": {"type": "number", "title": "Capacity", "x-title-th": "กำลังการผลิต"}}
+	}`))
+	require.NoError(t, err)
+
+	errs := schema.Validate(map[string]interface{}{})
+	require.Len(t, errs, 2)
+	assert.Equal(t, "Capacity is required", errs[0].Message)
+	assert.Equal(t, "กรุณาระบุ กำลังการผลิต", errs[0].MessageTH)
+	assert.Equal(t, "site is required", errs[1].Message, "fields without a title are named by their path")
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+)
+
+// PDFFontFamily is the font family registered on documents created by NewThaiPDF
+const PDFFontFamily = "thai"
+
+//go:embed fonts/FreeSerif.ttf
+var defaultThaiFont []byte
+
+// NewThaiPDF creates an A4 portrait document with a Thai-capable font embedded under PDFFontFamily.
+// An empty fontPath uses the bundled FreeSerif font.
+func NewThaiPDF(fontPath string) (*fpdf.Fpdf, error) {
+	font := defaultThaiFont
+	if fontPath != "" {
+		data, err := os.ReadFile(fontPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read font: %w", err)
+		}
+		font = data
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(PDFFontFamily, "", font)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+	pdf.SetFont(PDFFontFamily, "", 14)
+	pdf.SetCreationDate(time.Now())
+	return pdf, nil
+}
+
+// DrawQRCode draws a QR code of the content as a size x size square with its top left corner at x, y
+func DrawQRCode(pdf *fpdf.Fpdf, content string, x, y, size float64) error {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return fmt.Errorf("failed to encode QR code: %w", err)
+	}
+
+	bounds := code.Bounds()
+	modules := bounds.Dx()
+	module := size / float64(modules)
+
+	pdf.SetFillColor(0, 0, 0)
+	for row := 0; row < modules; row++ {
+		for col := 0; col < modules; col++ {
+			if isDark(code, bounds.Min.X+col, bounds.Min.Y+row) {
+				pdf.Rect(x+float64(col)*module, y+float64(row)*module, module, module, "F")
+			}
+		}
+	}
+	return pdf.Error()
+}
+
+func isDark(code barcode.Barcode, x, y int) bool {
+	r, _, _, _ := code.At(x, y).RGBA()
+	return r < 0x8000
+}
+
+// SavePDF renders the document and writes it to a new file in uploadDir
+func SavePDF(pdf *fpdf.Fpdf, uploadDir, originalName string) (*FileUpload, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	fileName := GenerateUniqueFilename(originalName)
+	filePath := filepath.Join(uploadDir, fileName)
+	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+
+	return &FileUpload{
+		FileName:     fileName,
+		OriginalName: originalName,
+		FilePath:     filePath,
+		FileSize:     int64(buf.Len()),
+		MimeType:     "application/pdf",
+		FileType:     "pdf",
+	}, nil
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromptPay application ID of a merchant-presented QR code
+const promptPayAID = "A000000677010111"
+
+// PromptPayPayload builds the EMVCo merchant-presented QR payload that asks a Thai banking app to
+// pay amount baht to a PromptPay ID: a mobile number, a 13-digit tax ID or a 15-digit e-wallet ID.
+// The payload is meant for a single payment, so banking apps do not offer to save it.
+func PromptPayPayload(promptPayID string, amount float64) (string, error) {
+	id := digitsOnly(promptPayID)
+
+	var account string
+	switch {
+	case len(id) == 15:
+		account = emvField("03", id)
+	case len(id) == 13:
+		account = emvField("02", id)
+	case len(id) == 10 && strings.HasPrefix(id, "0"):
+		// Mobile numbers are written with the country code, padded to 13 digits
+		account = emvField("01", "0066"+id[1:])
+	default:
+		return "", fmt.Errorf("invalid PromptPay ID %q: want a mobile number, a tax ID or an e-wallet ID", promptPayID)
+	}
+	if amount <= 0 {
+		return "", fmt.Errorf("invalid PromptPay amount %.2f", amount)
+	}
+
+	payload := emvField("00", "01") + // Payload format indicator
+		emvField("01", "12") + // Dynamic QR code, for one payment
+		emvField("29", emvField("00", promptPayAID)+account) +
+		emvField("58", "TH") +
+		emvField("53", "764") + // Thai baht
+		emvField("54", fmt.Sprintf("%.2f", amount)) +
+		"6304"
+	return payload + fmt.Sprintf("%04X", crc16CCITT([]byte(payload))), nil
+}
+
+// emvField encodes an EMVCo tag-length-value field
+func emvField(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+// crc16CCITT is the CRC-16/CCITT-FALSE checksum EMVCo QR codes end with
+func crc16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -82,11 +82,41 @@ func ErrorConflict(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusConflict, message, err)
 }
 
+// ErrorConflictWithData sends a 409 Conflict response carrying data, e.g. the current state of a resource
+func ErrorConflictWithData(c *gin.Context, message string, err error, data interface{}) {
+	errorMsg := message
+	if err != nil {
+		errorMsg = err.Error()
+	}
+
+	c.JSON(http.StatusConflict, APIResponse{
+		Success: false,
+		Message: message,
+		Error:   errorMsg,
+		Data:    data,
+	})
+}
+
 // ErrorUnprocessableEntity sends a 422 Unprocessable Entity response
 func ErrorUnprocessableEntity(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusUnprocessableEntity, message, err)
 }
 
+// ErrorUnprocessableEntityWithData sends a 422 Unprocessable Entity response with data describing what is missing
+func ErrorUnprocessableEntityWithData(c *gin.Context, message string, err error, data interface{}) {
+	errorMsg := message
+	if err != nil {
+		errorMsg = err.Error()
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, APIResponse{
+		Success: false,
+		Message: message,
+		Error:   errorMsg,
+		Data:    data,
+	})
+}
+
 // ErrorInternalServerError sends a 500 Internal Server Error response
 func ErrorInternalServerError(c *gin.Context, message string, err error) {
 	ErrorResponse(c, http.StatusInternalServerError, message, err)
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -121,3 +122,13 @@ func GetBusinessDaysBetween(start, end time.Time) int {
 
 	return days
 }
+
+var thaiMonths = [...]string{
+	"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม",
+}
+
+// FormatThaiDate formats a date the way Thai official documents do, e.g. "17 ตุลาคม 2569" (Buddhist era)
+func FormatThaiDate(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), thaiMonths[t.Month()-1], t.Year()+543)
+}